@@ -1,14 +1,21 @@
 package v1
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"path"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/annotations"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/scale"
@@ -37,6 +44,12 @@ const (
 
 const (
 	defaultPasswordKey = "password"
+	defaultUserDB      = "admin"
+
+	// DefaultHostnameTemplate is the template used to generate member hostnames if none is specified.
+	DefaultHostnameTemplate = "{{.PodName}}.{{.ServiceName}}.{{.Namespace}}.svc.{{.ClusterDomain}}"
+
+	defaultClusterDomain = "cluster.local"
 )
 
 // MongoDBCommunitySpec defines the desired state of MongoDB
@@ -50,6 +63,20 @@ type MongoDBCommunitySpec struct {
 	// Version defines which version of MongoDB will be used
 	Version string `json:"version"`
 
+	// ReplicaSetName is the name of the replica set. Defaults to the name of the resource.
+	// The replica set name can only be changed if ReplicaSetNameChangePolicy is set to RecreateRetainingData.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_-]+$`
+	// +optional
+	ReplicaSetName string `json:"replicaSetName,omitempty"`
+
+	// ReplicaSetNameChangePolicy defines how a change of ReplicaSetName is handled.
+	// Reject, the default, refuses the change. RecreateRetainingData tears down the StatefulSet,
+	// rewrites the replica set name stored on each member and recreates the StatefulSet,
+	// retaining the data of all members.
+	// +kubebuilder:validation:Enum=Reject;RecreateRetainingData
+	// +optional
+	ReplicaSetNameChangePolicy ReplicaSetNameChangePolicy `json:"replicaSetNameChangePolicy,omitempty"`
+
 	// FeatureCompatibilityVersion configures the feature compatibility version that will
 	// be set for the deployment
 	// +optional
@@ -64,6 +91,73 @@ type MongoDBCommunitySpec struct {
 	// +optional
 	ReplicaSetHorizons ReplicaSetHorizonConfiguration `json:"replicaSetHorizons,omitempty"`
 
+	// ExternalAccess creates a Service of type NodePort or LoadBalancer for each member, so that clients outside
+	// of the Kubernetes cluster can connect to every member directly. The addresses of the Services are added
+	// to the replica set configuration as a horizon, and to the connection string Secrets of the users.
+	// +optional
+	ExternalAccess *ExternalAccess `json:"externalAccess,omitempty"`
+
+	// ExternalMembers are members of the replica set which run outside of the Kubernetes cluster, e.g. on the
+	// virtual machines of a replica set which is migrated into the cluster. Their processes are added to the
+	// automation config, so that the agents on their hosts manage them together with the members in the cluster.
+	// +optional
+	ExternalMembers []ExternalMember `json:"externalMembers,omitempty"`
+
+	// HostnameTemplate is a Go template which is used to generate the hostname of each
+	// member of the replica set. The template can reference .PodName, .Index, .ServiceName,
+	// .Namespace and .ClusterDomain.
+	// Defaults to "{{.PodName}}.{{.ServiceName}}.{{.Namespace}}.svc.{{.ClusterDomain}}"
+	// +optional
+	HostnameTemplate string `json:"hostnameTemplate,omitempty"`
+
+	// PodSubdomain is the name of the headless Service governing the StatefulSet, which is the subdomain of the
+	// hostname of each member, "<pod name>.<podSubdomain>.<namespace>.svc.<cluster domain>".
+	// Defaults to "<metadata.name>-svc". It can not be changed once the resource has been deployed.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	PodSubdomain string `json:"podSubdomain,omitempty"`
+
+	// Service configures the port, the additional ports, the labels and the annotations of the headless Service,
+	// and an optional Service with a cluster IP for clients which can not use the DNS records of the headless
+	// Service.
+	// +optional
+	Service *ServiceConfiguration `json:"service,omitempty"`
+
+	// Network configures the IP families of the Services and enables IPv6 in mongod, for dual-stack and
+	// IPv6-only clusters.
+	// +optional
+	Network *NetworkConfiguration `json:"network,omitempty"`
+
+	// NetworkPolicy creates a NetworkPolicy which only admits traffic to the members from the other members and
+	// the Jobs of the resource, the operator, and the configured clients.
+	// +optional
+	NetworkPolicy *NetworkPolicyConfiguration `json:"networkPolicy,omitempty"`
+
+	// ServiceMesh configures the members, their Services and the Jobs of the resource to run inside an Istio or
+	// Linkerd service mesh, without annotating the Pods manually.
+	// +optional
+	ServiceMesh *ServiceMesh `json:"serviceMesh,omitempty"`
+
+	// PodHostnamePrefix is the name of the StatefulSet, which the Pod name and hostname of each member are
+	// generated from as "<podHostnamePrefix>-<ordinal>". Defaults to metadata.name. It can not be changed once
+	// the resource has been deployed.
+	// +kubebuilder:validation:MaxLength=52
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	PodHostnamePrefix string `json:"podHostnamePrefix,omitempty"`
+
+	// MemberOrdinalStart is the ordinal of the first member. The Pod names, hostnames, process names and replica
+	// set member ids of the members are numbered from it, e.g. "<podHostnamePrefix>-3" is the first member for a
+	// value of 3, so that a replacement replica set can be built alongside an existing one without overlapping
+	// members. It requires the StatefulSetStartOrdinal feature of Kubernetes, enabled by default since 1.27.
+	// It can not be changed once the resource has been deployed. As the ids of the members can not exceed 255,
+	// the ordinal of the last member can not either.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=255
+	// +optional
+	MemberOrdinalStart int `json:"memberOrdinalStart,omitempty"`
+
 	// Security configures security features, such as TLS, and authentication settings for a deployment
 	// +required
 	Security Security `json:"security"`
@@ -72,9 +166,69 @@ type MongoDBCommunitySpec struct {
 	// +required
 	Users []MongoDBUser `json:"users"`
 
+	// Initialization seeds the data of a new deployment once the replica set first becomes ready, by running
+	// scripts or restoring a backup. The resource only reports the Running phase once it has completed. It is
+	// ignored if the resource was already deployed when it is set.
+	// +optional
+	Initialization *Initialization `json:"initialization,omitempty"`
+
 	// +optional
 	StatefulSetConfiguration StatefulSetConfiguration `json:"statefulSet,omitempty"`
 
+	// MongodLivenessProbe adds a liveness probe to the mongod container which runs the "ping" command against
+	// the local mongod, so that members which are running but no longer respond are restarted. By default the
+	// mongod container is only restarted when the mongod process exits.
+	// +optional
+	MongodLivenessProbe *MongodLivenessProbe `json:"mongodLivenessProbe,omitempty"`
+
+	// Coordination configures how restarts of the members are coordinated with other controllers which
+	// modify the StatefulSet, such as service meshes or backup tools.
+	// +optional
+	Coordination *Coordination `json:"coordination,omitempty"`
+
+	// ScaleDown configures the check made before a member is removed by a scale down, which blocks the removal
+	// while the remaining secondaries are not caught up with the primary. The outcome is reported in the
+	// ScaleDownBlocked condition.
+	// +optional
+	ScaleDown *ScaleDownConfiguration `json:"scaleDown,omitempty"`
+
+	// ReplicationLagGate adds a readiness gate to the Pods of the members, which the operator sets to false
+	// while the member is a secondary lagging behind the primary. Lagging members are removed from the
+	// endpoints of the Services until they have caught up. The state of the gate is reported in
+	// status.members.
+	// +optional
+	ReplicationLagGate *ReplicationLagGate `json:"replicationLagGate,omitempty"`
+
+	// MemberHealth reports the replica set state, the replication lag and the last heartbeat of each member
+	// in status.members.
+	// +optional
+	MemberHealth *MemberHealth `json:"memberHealth,omitempty"`
+
+	// StuckMemberRemediation detects members which remain in the RECOVERING or ROLLBACK state, or fall too far
+	// behind the primary, for longer than a threshold. Stuck members are reported with events, and resynced
+	// from the other members if enabled.
+	// +optional
+	StuckMemberRemediation *StuckMemberRemediation `json:"stuckMemberRemediation,omitempty"`
+
+	// PodRecovery configures how the Pods of members which have been lost, e.g. together with their node, are
+	// recreated.
+	// +optional
+	PodRecovery *PodRecovery `json:"podRecovery,omitempty"`
+
+	// SecurityContextPreset selects the security context of the Pods, the handling of the volume permissions
+	// and the init containers of the deployment. "restricted" satisfies the restricted Pod Security Standard,
+	// "baseline" is the security context the operator configures by default, "openshift" lets the
+	// SecurityContextConstraints assign the user and group, and "legacy" changes the ownership of the volumes
+	// with an init container running as root. The security context can still be overridden in StatefulSetConfiguration.
+	// +kubebuilder:validation:Enum=restricted;baseline;openshift;legacy
+	// +optional
+	SecurityContextPreset SecurityContextPreset `json:"securityContextPreset,omitempty"`
+
+	// TemporaryDirectory configures the emptyDir volume the agent, mongod, the probes and the hooks write their
+	// temporary files to, so that no container writes to its root filesystem.
+	// +optional
+	TemporaryDirectory TemporaryDirectory `json:"temporaryDirectory,omitempty"`
+
 	// AdditionalMongodConfig is additional configuration that can be passed to
 	// each data-bearing mongod at runtime. Uses the same structure as the mongod
 	// configuration file: https://docs.mongodb.com/manual/reference/configuration-options/
@@ -82,279 +236,2577 @@ type MongoDBCommunitySpec struct {
 	// +optional
 	// +nullable
 	AdditionalMongodConfig MongodConfiguration `json:"additionalMongodConfig,omitempty"`
+
+	// ServerParameters are the server parameters (setParameter) of each mongod, by parameter name.
+	// The values of known parameters are validated, parameters which are not known to the operator
+	// are rejected unless AllowUnknownServerParameters is set.
+	// +kubebuilder:validation:Type=object
+	// +optional
+	// +nullable
+	ServerParameters MongodConfiguration `json:"serverParameters,omitempty"`
+
+	// AllowUnknownServerParameters passes server parameters which are not known to the operator
+	// to mongod without validating them.
+	// +optional
+	AllowUnknownServerParameters bool `json:"allowUnknownServerParameters,omitempty"`
+
+	// SystemLog configures the log of mongod and the rotation of the log files of mongod and of the agent.
+	// It can not be combined with systemLog in AdditionalMongodConfig.
+	// +optional
+	SystemLog *SystemLog `json:"systemLog,omitempty"`
+
+	// AuditLogForwarder deploys a sidecar which forwards the audit log of each member to a remote destination.
+	// It requires auditLog.destination to be set to "file" in AdditionalMongodConfig.
+	// +optional
+	AuditLogForwarder *AuditLogForwarder `json:"auditLogForwarder,omitempty"`
+
+	// ChangeStreamVerification opens a change stream with majority read concern once the deployment is
+	// running, to verify that applications can use change streams. The outcome is reported in the
+	// ChangeStreamsUnavailable condition.
+	// +optional
+	ChangeStreamVerification *ChangeStreamVerification `json:"changeStreamVerification,omitempty"`
+
+	// ConnectionExamples publishes a ConfigMap with examples of connecting applications to the deployment,
+	// which contains no credentials.
+	// +optional
+	ConnectionExamples bool `json:"connectionExamples,omitempty"`
+
+	// PlannedOutage removes the votes of the members running in zones scheduled for maintenance, so that the
+	// replica set keeps a majority while those members are unavailable. The votes are restored once the zones
+	// are removed again.
+	// +optional
+	PlannedOutage *PlannedOutage `json:"plannedOutage,omitempty"`
+
+	// ZoneAwareness spreads the members evenly across the topology domains, e.g. zones, of the given node labels
+	// and tags each member with the domains of the node it runs on in the replica set configuration, so that
+	// read preferences and write concerns can refer to them.
+	// +optional
+	ZoneAwareness *ZoneAwareness `json:"zoneAwareness,omitempty"`
+
+	// ForceReconfig acknowledges a forced reconfiguration of the replica set around the surviving members,
+	// for when a majority of the members is permanently lost. The reconfiguration is only performed when it is
+	// requested with the mongodbcommunity.mongodb.com/force-reconfig annotation.
+	// +optional
+	ForceReconfig *ForceReconfig `json:"forceReconfig,omitempty"`
+
+	// Diagnostics configures the capture of diagnostic data when the resource fails, so that the evidence is
+	// kept after the logs of the members have rotated away.
+	// +optional
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+
+	// PBM deploys a Percona Backup for MongoDB agent next to each member, so that MongoDBCommunityBackup and
+	// MongoDBCommunityRestore resources can take and restore backups with PBM.
+	// +optional
+	PBM *PBMConfiguration `json:"pbm,omitempty"`
+
+	// Prometheus deploys a mongodb_exporter sidecar next to each member, which exposes the metrics of the member
+	// to Prometheus. The exporter connects as the metrics user, which must be enabled.
+	// +optional
+	Prometheus *Prometheus `json:"prometheus,omitempty"`
+
+	// UpdateStrategy configures how changes which restart the members are rolled out.
+	// +optional
+	UpdateStrategy *UpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// MaintenanceWindow restricts changes of the MongoDB version and restarts of the members, such as those caused
+	// by a rotated TLS certificate or a new image, to recurring windows. They are queued outside of the windows,
+	// while other changes are applied immediately.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// ConcurrentChangePolicy defines how a change of the spec is handled while the rollout of a previous
+	// change is in progress. Merge, the default, applies the change to the rollout in progress. Queue keeps
+	// rolling out the previous spec and applies the change once the rollout has completed. Reject keeps
+	// rolling out the previous spec and ignores the change until the spec is changed again after the rollout
+	// has completed. The policy of the most recent spec is used.
+	// +kubebuilder:validation:Enum=Merge;Queue;Reject
+	// +optional
+	ConcurrentChangePolicy ConcurrentChangePolicy `json:"concurrentChangePolicy,omitempty"`
 }
 
-// ReplicaSetHorizonConfiguration holds the split horizon DNS settings for
-// replica set members.
-type ReplicaSetHorizonConfiguration []automationconfig.ReplicaSetHorizons
+// PlannedOutage lists the zones scheduled for maintenance.
+type PlannedOutage struct {
+	// Zones are the zones scheduled for maintenance, as in the topology.kubernetes.io/zone label of the nodes.
+	// The members running on nodes in these zones do not vote and can not become primary.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+}
 
-// CustomRole defines a custom MongoDB role.
-type CustomRole struct {
-	// The name of the role.
-	Role string `json:"role"`
-	// The database of the role.
-	DB string `json:"db"`
-	// The privileges to grant the role.
-	Privileges []Privilege `json:"privileges"`
-	// An array of roles from which this role inherits privileges.
+// GetZones returns the zones scheduled for maintenance.
+func (p *PlannedOutage) GetZones() []string {
+	if p == nil {
+		return nil
+	}
+	return p.Zones
+}
+
+// ExternalAccess configures the Services which expose each member outside of the Kubernetes cluster.
+type ExternalAccess struct {
+	// Type is the type of the Service of each member, NodePort or LoadBalancer. Defaults to LoadBalancer
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer
 	// +optional
-	Roles []Role `json:"roles"`
-	// The authentication restrictions the server enforces on the role.
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Annotations are added to the Service of each member, e.g. to configure the load balancer of the cloud
+	// provider.
 	// +optional
-	AuthenticationRestrictions []AuthenticationRestriction `json:"authenticationRestrictions,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// HorizonName is the name of the horizon of the external addresses in the replica set configuration.
+	// Clients connecting with TLS to an external address are handed the external addresses of the other
+	// members. Defaults to "external"
+	// +optional
+	HorizonName string `json:"horizonName,omitempty"`
+
+	// Host exposes each member on a port of the node it runs on instead of with its own Service, for bare-metal
+	// environments where clients connect to the nodes directly and node ports are blocked. The members are
+	// scheduled on distinct nodes, and Type and Annotations are ignored.
+	// +optional
+	Host *HostAccess `json:"host,omitempty"`
 }
 
-// ConvertToAutomationConfigCustomRole converts between a custom role defined by the crd and a custom role
-// that can be used in the automation config.
-func (c CustomRole) ConvertToAutomationConfigCustomRole() automationconfig.CustomRole {
-	ac := automationconfig.CustomRole{Role: c.Role, DB: c.DB, Roles: []automationconfig.Role{}}
+// HostAccess configures how the members are exposed on the nodes they run on.
+type HostAccess struct {
+	// HostNetwork runs the members in the network of their nodes, so that mongod listens on port 27017 of the
+	// node. Otherwise port 27017 of mongod is mapped to Port of the node.
+	// +optional
+	HostNetwork bool `json:"hostNetwork,omitempty"`
 
-	// Add privileges.
-	for _, privilege := range c.Privileges {
-		ac.Privileges = append(ac.Privileges, automationconfig.Privilege{
-			Resource: automationconfig.Resource{
-				DB:          privilege.Resource.DB,
-				Collection:  privilege.Resource.Collection,
-				AnyResource: privilege.Resource.AnyResource,
-				Cluster:     privilege.Resource.Cluster,
-			},
-			Actions: privilege.Actions,
-		})
+	// Port is the port of the node clients connect to, if HostNetwork is not set. Defaults to 27017
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// GetPort returns the port of the node clients connect to, 27017 by default and with host networking.
+func (h *HostAccess) GetPort() int32 {
+	if h == nil || h.Port == 0 || h.HostNetwork {
+		return automationconfig.DefaultDBPort
 	}
+	return h.Port
+}
 
-	// Add roles.
-	for _, dbRole := range c.Roles {
-		ac.Roles = append(ac.Roles, automationconfig.Role{
-			Role:     dbRole.Name,
-			Database: dbRole.DB,
-		})
+// GetType returns the type of the Service of each member, LoadBalancer by default.
+func (e *ExternalAccess) GetType() corev1.ServiceType {
+	if e == nil || e.Type == "" {
+		return corev1.ServiceTypeLoadBalancer
 	}
+	return e.Type
+}
 
-	// Add authentication restrictions (if any).
-	for _, restriction := range c.AuthenticationRestrictions {
-		ac.AuthenticationRestrictions = append(ac.AuthenticationRestrictions,
-			automationconfig.AuthenticationRestriction{
-				ClientSource:  restriction.ClientSource,
-				ServerAddress: restriction.ServerAddress,
-			})
+// UsesServices returns true if the members are exposed with a Service each, rather than on their nodes.
+func (e *ExternalAccess) UsesServices() bool {
+	return e != nil && e.Host == nil
+}
+
+// GetHorizonName returns the name of the horizon of the external addresses, "external" by default.
+func (e *ExternalAccess) GetHorizonName() string {
+	if e == nil || e.HorizonName == "" {
+		return "external"
 	}
+	return e.HorizonName
+}
 
-	return ac
+// ServiceConfiguration configures the Services of the members.
+type ServiceConfiguration struct {
+	// Port is the port of the client Service, which forwards it to port 27017 of mongod. It can only be set with
+	// ClientService. The headless Service always uses port 27017, which the members and the clients connecting to
+	// the hostnames of the members use. Defaults to 27017
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// AdditionalPorts are added to the Services next to the "mongodb" port, e.g. for sidecar containers.
+	// +optional
+	AdditionalPorts []ServicePort `json:"additionalPorts,omitempty"`
+
+	// Labels are added to the Services.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the Services.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ClientService creates the Service "<metadata.name>-client", which has a cluster IP and only routes to
+	// ready members.
+	// +optional
+	ClientService *ClientService `json:"clientService,omitempty"`
 }
 
-// ConvertCustomRolesToAutomationConfigCustomRole converts custom roles to custom roles
-// that can be used in the automation config.
-func ConvertCustomRolesToAutomationConfigCustomRole(roles []CustomRole) []automationconfig.CustomRole {
-	acRoles := []automationconfig.CustomRole{}
-	for _, role := range roles {
-		acRoles = append(acRoles, role.ConvertToAutomationConfigCustomRole())
+// GetPort returns the port of the client Service, 27017 by default.
+func (s *ServiceConfiguration) GetPort() int32 {
+	if s == nil || s.Port == 0 {
+		return 27017
 	}
-	return acRoles
+	return s.Port
 }
 
-// Privilege defines the actions a role is allowed to perform on a given resource.
-type Privilege struct {
-	Resource Resource `json:"resource"`
-	Actions  []string `json:"actions"`
+// ServicePort is an additional port of the Services.
+type ServicePort struct {
+	// Name is the name of the port, which must be unique and can not be "mongodb".
+	// +kubebuilder:validation:MaxLength=15
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Port is the port of the Services.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// TargetPort is the port of the container the Services forward the port to. Defaults to Port
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	TargetPort int32 `json:"targetPort,omitempty"`
 }
 
-// Resource specifies specifies the resources upon which a privilege permits actions.
-// See https://docs.mongodb.com/manual/reference/resource-document for more.
-type Resource struct {
+// ClientService configures the Service with a cluster IP for clients.
+type ClientService struct {
+	// Type is the type of the Service, ClusterIP, NodePort or LoadBalancer. Defaults to ClusterIP
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
 	// +optional
-	DB *string `json:"db,omitempty"`
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Annotations are added to this Service only, e.g. to request an internal load balancer from the cloud
+	// provider.
 	// +optional
-	Collection *string `json:"collection,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GetType returns the type of the Service, ClusterIP by default.
+func (c *ClientService) GetType() corev1.ServiceType {
+	if c == nil || c.Type == "" {
+		return corev1.ServiceTypeClusterIP
+	}
+	return c.Type
+}
+
+// NetworkConfiguration configures the IP families of the deployment.
+type NetworkConfiguration struct {
+	// IPFamilyPolicy is the IP family policy of the Services, SingleStack, PreferDualStack or RequireDualStack.
+	// Defaults to the policy of the cluster
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
 	// +optional
-	Cluster bool `json:"cluster,omitempty"`
+	IPFamilyPolicy corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
+
+	// IPFamilies are the IP families of the Services, IPv4 and IPv6, in the order of preference. Defaults to the
+	// IP families of the cluster
+	// +kubebuilder:validation:MaxItems=2
 	// +optional
-	AnyResource bool `json:"anyResource,omitempty"`
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// Listeners sets the addresses mongod listens on, which are all IP addresses of the Pod by default, to all IP
+	// addresses and additional Unix domain sockets.
+	// +optional
+	Listeners *ListenersConfiguration `json:"listeners,omitempty"`
 }
 
-// AuthenticationRestriction specifies a list of IP addresses and CIDR ranges users
-// are allowed to connect to or from.
-type AuthenticationRestriction struct {
-	ClientSource  []string `json:"clientSource"`
-	ServerAddress []string `json:"serverAddress"`
+// ListenersConfiguration configures the addresses mongod listens on. mongod always listens on all IP addresses on
+// port 27017, as it only has a single port, which the agents, the exporter, the other members, the operator and
+// the clients connect to.
+type ListenersConfiguration struct {
+	// AdditionalAddresses are the paths of the Unix domain sockets mongod listens on in addition to all IP
+	// addresses.
+	// +optional
+	AdditionalAddresses []string `json:"additionalAddresses,omitempty"`
 }
 
-// StatefulSetConfiguration holds the optional custom StatefulSet
-// that should be merged into the operator created one.
-type StatefulSetConfiguration struct {
-	SpecWrapper StatefulSetSpecWrapper `json:"spec"`
+// UsesIPv6 returns true if the Services may have IPv6 addresses, in which case mongod must listen on IPv6.
+func (n *NetworkConfiguration) UsesIPv6() bool {
+	if n == nil {
+		return false
+	}
+	if n.IPFamilyPolicy == corev1.IPFamilyPolicyPreferDualStack || n.IPFamilyPolicy == corev1.IPFamilyPolicyRequireDualStack {
+		return true
+	}
+	for _, family := range n.IPFamilies {
+		if family == corev1.IPv6Protocol {
+			return true
+		}
+	}
+	return false
 }
 
-// StatefulSetSpecWrapper is a wrapper around StatefulSetSpec with a custom implementation
-// of MarshalJSON and UnmarshalJSON which delegate to the underlying Spec to avoid CRD pollution.
+// NetworkPolicyConfiguration configures the peers the NetworkPolicy of the members admits, in addition to the
+// other members, the Jobs of the resource and the operator.
+type NetworkPolicyConfiguration struct {
+	// Clients are the Pods, namespaces and IP blocks which may connect to mongod. Clients connecting through
+	// the Services of spec.externalAccess or a LoadBalancer client Service must be admitted by their IP block.
+	// +optional
+	Clients []networkingv1.NetworkPolicyPeer `json:"clients,omitempty"`
 
-type StatefulSetSpecWrapper struct {
-	Spec appsv1.StatefulSetSpec `json:"-"`
+	// MetricsClients are the Pods, namespaces and IP blocks which may scrape the Prometheus exporters of
+	// spec.prometheus. Defaults to all Pods in all namespaces
+	// +optional
+	MetricsClients []networkingv1.NetworkPolicyPeer `json:"metricsClients,omitempty"`
 }
 
-// MarshalJSON defers JSON encoding to the wrapped map
-func (m *StatefulSetSpecWrapper) MarshalJSON() ([]byte, error) {
-	return json.Marshal(m.Spec)
+// ServiceMeshType is the service mesh the members run in.
+type ServiceMeshType string
+
+const (
+	ServiceMeshIstio   ServiceMeshType = "Istio"
+	ServiceMeshLinkerd ServiceMeshType = "Linkerd"
+)
+
+// ServiceMesh configures the compatibility with a service mesh.
+type ServiceMesh struct {
+	// Type is the service mesh the sidecar proxies are injected by, Istio or Linkerd.
+	// +kubebuilder:validation:Enum=Istio;Linkerd
+	Type ServiceMeshType `json:"type"`
+
+	// RedirectMongod routes the traffic of the mongod port through the sidecar proxies, e.g. to encrypt it
+	// with the mutual TLS of the mesh. By default the port is excluded from the redirection, as the members
+	// connect to each other through a headless Service before they are ready, which the proxies do not route
+	// reliably.
+	// +optional
+	RedirectMongod bool `json:"redirectMongod,omitempty"`
 }
 
-// UnmarshalJSON will decode the data into the wrapped map
-func (m *StatefulSetSpecWrapper) UnmarshalJSON(data []byte) error {
-	return json.Unmarshal(data, &m.Spec)
+// IsIstio returns true if the members run in an Istio service mesh.
+func (s *ServiceMesh) IsIstio() bool {
+	return s != nil && s.Type == ServiceMeshIstio
 }
 
-func (m *StatefulSetSpecWrapper) DeepCopy() *StatefulSetSpecWrapper {
-	return &StatefulSetSpecWrapper{
-		Spec: m.Spec,
-	}
+// IsLinkerd returns true if the members run in a Linkerd service mesh.
+func (s *ServiceMesh) IsLinkerd() bool {
+	return s != nil && s.Type == ServiceMeshLinkerd
 }
 
-// MongodConfiguration holds the optional mongod configuration
-// that should be merged with the operator created one.
-//
-// The CRD generator does not support map[string]interface{}
-// on the top level and hence we need to work around this with
-// a wrapping struct.
-type MongodConfiguration struct {
-	Object map[string]interface{} `json:"-"`
+// ZoneAwareness configures the topology domains the members are spread across and tagged with.
+type ZoneAwareness struct {
+	// TopologyKeys are the labels of the nodes whose values are the topology domains, e.g.
+	// topology.kubernetes.io/zone. Each member is tagged with the value of each label of its node, under the
+	// name of the label without its prefix, e.g. zone. The replica set also defines a write concern for each
+	// tag, e.g. zoneMajority, which acknowledges writes once they reached the members in a majority of the
+	// domains.
+	// +kubebuilder:validation:MinItems=1
+	TopologyKeys []string `json:"topologyKeys"`
+
+	// WhenUnsatisfiable defines how a member is scheduled if it can not be placed without skewing the spread
+	// of the members across the domains by more than one. DoNotSchedule, the default, keeps the member
+	// pending, ScheduleAnyway places it regardless.
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +optional
+	WhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
 }
 
-// MarshalJSON defers JSON encoding to the wrapped map
-func (m *MongodConfiguration) MarshalJSON() ([]byte, error) {
-	return json.Marshal(m.Object)
+// GetTopologyKeys returns the labels of the nodes the members are spread across and tagged with.
+func (z *ZoneAwareness) GetTopologyKeys() []string {
+	if z == nil {
+		return nil
+	}
+	return z.TopologyKeys
 }
 
-// UnmarshalJSON will decode the data into the wrapped map
-func (m *MongodConfiguration) UnmarshalJSON(data []byte) error {
-	if m.Object == nil {
-		m.Object = map[string]interface{}{}
+// GetWhenUnsatisfiable returns how members are scheduled which can not be spread evenly, DoNotSchedule by
+// default.
+func (z *ZoneAwareness) GetWhenUnsatisfiable() corev1.UnsatisfiableConstraintAction {
+	if z == nil || z.WhenUnsatisfiable == "" {
+		return corev1.DoNotSchedule
 	}
+	return z.WhenUnsatisfiable
+}
 
-	return json.Unmarshal(data, &m.Object)
+// UpdateStrategy configures how changes which restart the members are rolled out.
+type UpdateStrategy struct {
+	// Type is the update strategy of the StatefulSet. With RollingUpdate, the default, the members are
+	// restarted by the StatefulSet controller once the Pod template changes. With OnDelete, the operator still
+	// updates the StatefulSet, but the members only run the new Pod template once their Pods are deleted, e.g.
+	// by an administrator during a change window. The Pods pending a restart are reported in
+	// status.pendingRestarts. Changes of the MongoDB version are still rolled out by the agents.
+	// +kubebuilder:validation:Enum=RollingUpdate;OnDelete
+	// +optional
+	Type appsv1.StatefulSetUpdateStrategyType `json:"type,omitempty"`
+
+	// Canary rolls a change of the MongoDB version or of the StatefulSet out to a single member first, the
+	// member with the highest ordinal, and only rolls it out to the other members once that member has been
+	// ready for the soak period without restarting.
+	// +optional
+	Canary *CanaryUpdate `json:"canary,omitempty"`
 }
 
-func (m *MongodConfiguration) DeepCopy() *MongodConfiguration {
-	return &MongodConfiguration{
-		Object: runtime.DeepCopyJSON(m.Object),
-	}
+// IsOnDelete returns true if the members are only restarted once their Pods are deleted.
+func (u *UpdateStrategy) IsOnDelete() bool {
+	return u != nil && u.Type == appsv1.OnDeleteStatefulSetStrategyType
 }
 
-type MongoDBUser struct {
-	// Name is the username of the user
-	Name string `json:"name"`
+// GetCanary returns the configuration of canary updates, or nil if changes are rolled out to all members.
+func (u *UpdateStrategy) GetCanary() *CanaryUpdate {
+	if u == nil {
+		return nil
+	}
+	return u.Canary
+}
 
-	// DB is the database the user is stored in. Defaults to "admin"
+// CanaryUpdate configures canary updates.
+type CanaryUpdate struct {
+	// SoakPeriod is how long the canary member must be ready without restarting before the change is rolled
+	// out to the other members. Defaults to 10m
 	// +optional
-	DB string `json:"db"`
+	SoakPeriod *metav1.Duration `json:"soakPeriod,omitempty"`
+}
 
-	// PasswordSecretRef is a reference to the secret containing this user's password
-	PasswordSecretRef SecretKeyReference `json:"passwordSecretRef"`
+const defaultCanarySoakPeriod = 10 * time.Minute
 
-	// Roles is an array of roles assigned to this user
-	Roles []Role `json:"roles"`
+// GetSoakPeriod returns how long the canary member must be healthy before the change is rolled out further.
+func (c *CanaryUpdate) GetSoakPeriod() time.Duration {
+	if c == nil || c.SoakPeriod == nil {
+		return defaultCanarySoakPeriod
+	}
+	return c.SoakPeriod.Duration
+}
 
-	// ScramCredentialsSecretName appended by string "scram-credentials" is the name of the secret object created by the mongoDB operator for storing SCRAM credentials
-	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
-	ScramCredentialsSecretName string `json:"scramCredentialsSecretName"`
+// MaintenanceWindow defines recurring windows in which disruptive changes are made.
+type MaintenanceWindow struct {
+	// Schedule is when each window starts, in Cron format, e.g. "0 2 * * 6" for 2am every Saturday. The times are
+	// in UTC unless the schedule is prefixed with a time zone, e.g. "CRON_TZ=Europe/Berlin 0 2 * * 6".
+	Schedule string `json:"schedule"`
+	// Duration is how long each window lasts. Defaults to 4h
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
 }
 
-func (m MongoDBUser) GetPasswordSecretKey() string {
-	if m.PasswordSecretRef.Key == "" {
-		return defaultPasswordKey
-	}
-	return m.PasswordSecretRef.Key
+const defaultMaintenanceWindowDuration = 4 * time.Hour
+
+// GetDuration returns how long each maintenance window lasts.
+func (w *MaintenanceWindow) GetDuration() time.Duration {
+	if w == nil || w.Duration == nil {
+		return defaultMaintenanceWindowDuration
+	}
+	return w.Duration.Duration
+}
+
+// ForceReconfig lists the members a forced reconfiguration keeps the votes of.
+type ForceReconfig struct {
+	// Members are the names of the Pods of the surviving members. The other members keep their place in the
+	// replica set but do not vote and can not become primary, until ForceReconfig is removed again.
+	// +kubebuilder:validation:MinItems=1
+	Members []string `json:"members"`
+
+	// AcknowledgeDataLoss must be true. The writes which were not replicated to the surviving members are lost,
+	// and are rolled back on the other members if they ever rejoin the replica set.
+	AcknowledgeDataLoss bool `json:"acknowledgeDataLoss"`
+}
+
+// GetMembers returns the surviving members of a forced reconfiguration.
+func (f *ForceReconfig) GetMembers() []string {
+	if f == nil {
+		return nil
+	}
+	return f.Members
+}
+
+// Diagnostics configures the capture of diagnostic data.
+type Diagnostics struct {
+	// CaptureOnFailure captures the last lines of the mongod and agent logs, the Events of the member Pods and
+	// the output of replSetGetStatus into a ConfigMap when the resource enters the Failed phase. The data can be
+	// captured at any time with the mongodbcommunity.mongodb.com/capture-diagnostics annotation.
+	// +optional
+	CaptureOnFailure bool `json:"captureOnFailure,omitempty"`
+
+	// LogLines is the number of lines captured from the log of each container. Defaults to 200
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=5000
+	// +optional
+	LogLines int64 `json:"logLines,omitempty"`
+
+	// Retention is how long a capture is kept before its ConfigMap is deleted. Defaults to 24h
+	// +optional
+	Retention *metav1.Duration `json:"retention,omitempty"`
+}
+
+const (
+	defaultDiagnosticsLogLines  = 200
+	defaultDiagnosticsRetention = 24 * time.Hour
+)
+
+// CapturesOnFailure returns true if diagnostic data is captured when the resource fails.
+func (d *Diagnostics) CapturesOnFailure() bool {
+	return d != nil && d.CaptureOnFailure
+}
+
+// GetLogLines returns the number of lines captured from the log of each container.
+func (d *Diagnostics) GetLogLines() int64 {
+	if d == nil || d.LogLines == 0 {
+		return defaultDiagnosticsLogLines
+	}
+	return d.LogLines
+}
+
+// GetRetention returns how long a capture is kept.
+func (d *Diagnostics) GetRetention() time.Duration {
+	if d == nil || d.Retention == nil {
+		return defaultDiagnosticsRetention
+	}
+	return d.Retention.Duration
+}
+
+type ReplicaSetNameChangePolicy string
+
+type ConcurrentChangePolicy string
+
+// MongodLivenessProbe configures the liveness probe of the mongod container.
+type MongodLivenessProbe struct {
+	// Enabled adds the liveness probe to the mongod container.
+	Enabled bool `json:"enabled"`
+
+	// InitialDelaySeconds is the number of seconds after the container has started before the probe is run.
+	// Defaults to the time mongod of the MongoDB version may take to start: 60 before 5.0, 120 for 5.0
+	// and 300 from 6.0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds *int `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is how often the probe is run. Defaults to 30
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds int `json:"periodSeconds,omitempty"`
+
+	// TimeoutSeconds is the number of seconds after which the probe times out. Defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed probes after which mongod is restarted.
+	// Defaults to 6
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+}
+
+const (
+	defaultMongodLivenessPeriodSeconds    = 30
+	defaultMongodLivenessTimeoutSeconds   = 10
+	defaultMongodLivenessFailureThreshold = 6
+)
+
+// GetPeriodSeconds returns how often the liveness probe is run.
+func (p MongodLivenessProbe) GetPeriodSeconds() int {
+	if p.PeriodSeconds == 0 {
+		return defaultMongodLivenessPeriodSeconds
+	}
+	return p.PeriodSeconds
+}
+
+// GetTimeoutSeconds returns the number of seconds after which the liveness probe times out.
+func (p MongodLivenessProbe) GetTimeoutSeconds() int {
+	if p.TimeoutSeconds == 0 {
+		return defaultMongodLivenessTimeoutSeconds
+	}
+	return p.TimeoutSeconds
+}
+
+// GetFailureThreshold returns the number of consecutive failed probes after which mongod is restarted.
+func (p MongodLivenessProbe) GetFailureThreshold() int {
+	if p.FailureThreshold == 0 {
+		return defaultMongodLivenessFailureThreshold
+	}
+	return p.FailureThreshold
+}
+
+// Coordination configures the coordination.k8s.io Lease which the operator holds while it restarts members.
+type Coordination struct {
+	// Lease makes the operator acquire the Lease "<name>-coordination" in the namespace of the resource
+	// before it restarts members, and hold it until all members have been restarted. Members are not
+	// restarted while the Lease is held by another controller.
+	// +optional
+	Lease bool `json:"lease,omitempty"`
+
+	// LeaseDurationSeconds is the duration after which a Lease which has not been renewed by its holder
+	// can be acquired by another controller. Defaults to 60
+	// +kubebuilder:validation:Minimum=30
+	// +optional
+	LeaseDurationSeconds int `json:"leaseDurationSeconds,omitempty"`
+}
+
+// TemporaryDirectory is the emptyDir volume mounted in all containers of the members for temporary files.
+type TemporaryDirectory struct {
+	// Path is where the volume is mounted in all containers, which is also exposed to them as TMPDIR and used as
+	// the directory of the UNIX domain socket of mongod. Defaults to "/tmp".
+	// +kubebuilder:validation:Pattern=`^/.+`
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Medium of the emptyDir volume, either empty for the storage of the node or "Memory" for a tmpfs.
+	// +kubebuilder:validation:Enum="";Memory
+	// +optional
+	Medium corev1.StorageMedium `json:"medium,omitempty"`
+
+	// SizeLimit is the maximum size of the temporary files.
+	// +optional
+	SizeLimit *resource.Quantity `json:"sizeLimit,omitempty"`
+}
+
+const defaultTemporaryDirectory = "/tmp"
+
+type SecurityContextPreset string
+
+const (
+	SecurityContextPresetRestricted SecurityContextPreset = "restricted"
+	SecurityContextPresetBaseline   SecurityContextPreset = "baseline"
+	SecurityContextPresetOpenShift  SecurityContextPreset = "openshift"
+	SecurityContextPresetLegacy     SecurityContextPreset = "legacy"
+)
+
+const (
+	ReplicaSetNameChangeReject                ReplicaSetNameChangePolicy = "Reject"
+	ReplicaSetNameChangeRecreateRetainingData ReplicaSetNameChangePolicy = "RecreateRetainingData"
+)
+
+const (
+	ConcurrentChangeMerge  ConcurrentChangePolicy = "Merge"
+	ConcurrentChangeQueue  ConcurrentChangePolicy = "Queue"
+	ConcurrentChangeReject ConcurrentChangePolicy = "Reject"
+)
+
+// Initialization configures how the data of a new deployment is seeded. Exactly one of ScriptsConfigMapRef
+// and BackupRef must be set.
+type Initialization struct {
+	// User is the name of the user in Users the scripts run as, or the backup is restored as.
+	User string `json:"user"`
+
+	// ScriptsConfigMapRef is a ConfigMap whose keys ending in ".js" are run with the MongoDB shell against the
+	// primary, in the lexical order of the keys.
+	// +optional
+	ScriptsConfigMapRef *LocalObjectReference `json:"scriptsConfigMapRef,omitempty"`
+
+	// BackupRef is a MongoDBCommunityBackup resource in the same namespace whose archive is restored.
+	// +optional
+	BackupRef *LocalObjectReference `json:"backupRef,omitempty"`
+}
+
+// ScaleDownConfiguration configures the safety check of scale downs.
+type ScaleDownConfiguration struct {
+	// MaxReplicationLag is how far behind the primary each remaining secondary may be for a member to be
+	// removed. Defaults to "10s"
+	// +optional
+	MaxReplicationLag string `json:"maxReplicationLag,omitempty"`
+
+	// SkipSafetyCheck removes the members without checking the replication lag of the remaining members,
+	// e.g. to remove members while the remaining secondaries are being resynchronized.
+	// +optional
+	SkipSafetyCheck bool `json:"skipSafetyCheck,omitempty"`
+}
+
+// ReplicationLagGate configures the readiness gate of the members which reports their replication lag.
+type ReplicationLagGate struct {
+	// Enabled adds the readiness gate to the Pods of the members.
+	Enabled bool `json:"enabled"`
+
+	// MaxReplicationLag is how far behind the primary a secondary may be for its Pod to be ready.
+	// Defaults to "10s"
+	// +optional
+	MaxReplicationLag string `json:"maxReplicationLag,omitempty"`
+}
+
+// IsEnabled returns true if the readiness gate is added to the Pods of the members.
+func (g *ReplicationLagGate) IsEnabled() bool {
+	return g != nil && g.Enabled
+}
+
+// MemberHealth configures the reporting of the health of the members.
+type MemberHealth struct {
+	// Enabled reports the health of the members, read with replSetGetStatus, in status.members.
+	Enabled bool `json:"enabled"`
+}
+
+// IsEnabled returns true if the health of the members is reported in the status.
+func (h *MemberHealth) IsEnabled() bool {
+	return h != nil && h.Enabled
+}
+
+// StuckMemberRemediation configures the detection and the remediation of stuck members.
+type StuckMemberRemediation struct {
+	// Threshold is how long a member may be stuck before it is reported, and resynced if enabled.
+	// Defaults to "30m"
+	// +optional
+	Threshold string `json:"threshold,omitempty"`
+
+	// MaxReplicationLag is how far behind the primary a secondary may be before it is considered to hold stale
+	// data. Defaults to "1h"
+	// +optional
+	MaxReplicationLag string `json:"maxReplicationLag,omitempty"`
+
+	// Resync deletes the PersistentVolumeClaims and the Pod of a member which has been stuck for longer than
+	// the threshold, so that the member is rebuilt with an initial sync from the other members. Members are
+	// only resynced one at a time, while every other member is healthy, unless podRecovery.parallel is set.
+	// By default stuck members are only reported.
+	// +optional
+	Resync bool `json:"resync,omitempty"`
+}
+
+// PodRecovery configures how the Pods of lost members are recreated.
+type PodRecovery struct {
+	// Parallel lets the StatefulSet recreate the Pods of several lost members at the same time, instead of one
+	// at a time in the order of their ordinals, and lets several stuck members be resynced at the same time as
+	// long as a healthy majority of the members remains. Changing this setting recreates the StatefulSet
+	// without restarting the members.
+	// +optional
+	Parallel bool `json:"parallel,omitempty"`
+}
+
+// IsParallel returns true if the Pods of lost members are recreated in parallel.
+func (p *PodRecovery) IsParallel() bool {
+	return p != nil && p.Parallel
+}
+
+// SystemLogDestination is where mongod writes its log.
+type SystemLogDestination string
+
+const (
+	SystemLogDestinationFile   SystemLogDestination = "file"
+	SystemLogDestinationStdout SystemLogDestination = "stdout"
+)
+
+// SystemLog configures the log of mongod.
+type SystemLog struct {
+	// Destination is where mongod writes its log: a file in the logs volume, which is also copied to the
+	// standard output of the mongod container, or only the standard output of the mongod container.
+	// Defaults to file.
+	// +kubebuilder:validation:Enum=file;stdout
+	// +optional
+	Destination SystemLogDestination `json:"destination,omitempty"`
+
+	// Verbosity is the default verbosity of the log messages of mongod, from 0 to 5.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Verbosity *int `json:"verbosity,omitempty"`
+
+	// Components is the verbosity of the log messages of components of mongod, from 0 to 5, by component
+	// name, e.g. {"replication": 2, "storage.journal": 1}.
+	// +optional
+	Components map[string]int `json:"components,omitempty"`
+
+	// LogRotate rotates the log files of mongod and of the agent once they grow beyond a size.
+	// +optional
+	LogRotate *LogRotate `json:"logRotate,omitempty"`
+}
+
+// GetDestination returns where mongod writes its log.
+func (s SystemLog) GetDestination() SystemLogDestination {
+	if s.Destination == "" {
+		return SystemLogDestinationFile
+	}
+	return s.Destination
+}
+
+// LogRotate configures the rotation of the log files.
+type LogRotate struct {
+	// MaxLogSizeMB is the size in megabytes above which a log file is rotated.
+	// +kubebuilder:validation:Minimum=1
+	MaxLogSizeMB int `json:"maxLogSizeMB"`
+
+	// TimeThresholdHours is the age in hours after which a log file is rotated, regardless of its size.
+	// Defaults to 24.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeThresholdHours int `json:"timeThresholdHours,omitempty"`
+
+	// NumTotal is the number of rotated log files of mongod which are kept. All rotated files are kept
+	// by default.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumTotal int `json:"numTotal,omitempty"`
+
+	// NumUncompressed is the number of the most recent rotated log files of mongod which are not compressed.
+	// Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumUncompressed int `json:"numUncompressed,omitempty"`
+}
+
+// ChangeStreamVerification configures the verification of change streams.
+type ChangeStreamVerification struct {
+	// User is the name of the user in Users whose credentials are used to open the change stream.
+	// It must be a SCRAM user which is allowed to run the changeStream and find actions on Database.
+	User string `json:"user"`
+
+	// Database is the database which is watched. Defaults to the database of the user
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// Timeout is the time given to connect and open the change stream, at most "1m". Defaults to "10s"
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// AuditLogForwarder configures the sidecar which forwards the audit log to a syslog server or an HTTP endpoint.
+// Exactly one destination must be specified.
+type AuditLogForwarder struct {
+	// Image is the Fluent Bit image used by the sidecar. Defaults to "fluent/fluent-bit:1.7.4"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Syslog forwards the audit events to a syslog server
+	// +optional
+	Syslog *AuditLogSyslogDestination `json:"syslog,omitempty"`
+
+	// HTTP forwards the audit events to an HTTP endpoint as JSON
+	// +optional
+	HTTP *AuditLogHTTPDestination `json:"http,omitempty"`
+
+	// BufferLimit is the amount of audit events buffered in memory. Once it is reached the sidecar stops
+	// reading the audit log until the destination accepts more events. Defaults to "5MB"
+	// +optional
+	BufferLimit string `json:"bufferLimit,omitempty"`
+
+	// RetryLimit is the number of times sending a batch of events is retried before the events are dropped. Defaults to 5
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RetryLimit *int `json:"retryLimit,omitempty"`
+
+	// MetricsPort is the port on which the sidecar exposes its Prometheus metrics. Defaults to 2020
+	// +optional
+	MetricsPort int `json:"metricsPort,omitempty"`
+}
+
+// AuditLogSyslogDestination is a syslog server receiving audit events.
+type AuditLogSyslogDestination struct {
+	// Host is the hostname of the syslog server
+	Host string `json:"host"`
+
+	// Port is the port of the syslog server
+	Port int `json:"port"`
+
+	// Mode is the transport used to send the events. Defaults to "tcp"
+	// +kubebuilder:validation:Enum=tcp;udp;tls
+	// +optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// AuditLogHTTPDestination is an HTTP endpoint receiving audit events.
+type AuditLogHTTPDestination struct {
+	// Host is the hostname of the HTTP endpoint
+	Host string `json:"host"`
+
+	// Port is the port of the HTTP endpoint
+	Port int `json:"port"`
+
+	// URI is the path the events are sent to. Defaults to "/"
+	// +optional
+	URI string `json:"uri,omitempty"`
+
+	// TLS configures whether the events are sent using HTTPS
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+}
+
+const (
+	defaultAuditLogForwarderImage       = "fluent/fluent-bit:1.7.4"
+	defaultAuditLogForwarderBufferLimit = "5MB"
+	defaultAuditLogForwarderRetryLimit  = 5
+	defaultAuditLogForwarderMetricsPort = 2020
+)
+
+// GetImage returns the image of the audit log forwarder.
+func (a AuditLogForwarder) GetImage() string {
+	if a.Image == "" {
+		return defaultAuditLogForwarderImage
+	}
+	return a.Image
+}
+
+// GetBufferLimit returns the amount of audit events buffered in memory.
+func (a AuditLogForwarder) GetBufferLimit() string {
+	if a.BufferLimit == "" {
+		return defaultAuditLogForwarderBufferLimit
+	}
+	return a.BufferLimit
+}
+
+// GetRetryLimit returns the number of retries before audit events are dropped.
+func (a AuditLogForwarder) GetRetryLimit() int {
+	if a.RetryLimit == nil {
+		return defaultAuditLogForwarderRetryLimit
+	}
+	return *a.RetryLimit
+}
+
+// GetMetricsPort returns the port on which the forwarder exposes its metrics.
+func (a AuditLogForwarder) GetMetricsPort() int {
+	if a.MetricsPort == 0 {
+		return defaultAuditLogForwarderMetricsPort
+	}
+	return a.MetricsPort
+}
+
+// PBMConfiguration configures the Percona Backup for MongoDB agents of the members and the storage of the backups.
+type PBMConfiguration struct {
+	// Enabled deploys the pbm-agent sidecars.
+	Enabled bool `json:"enabled"`
+
+	// Image is the Percona Backup for MongoDB image running pbm-agent and the pbm CLI.
+	// Defaults to "percona/percona-backup-mongodb:2.0.2"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// User is the name of a user of the resource the agents connect to their member as. The user needs the
+	// backup, restore and clusterMonitor roles and the readWrite role on the admin database, and a role
+	// allowing anyAction on anyResource.
+	User string `json:"user"`
+
+	// Storage is the S3-compatible bucket the backups and the oplog slices are stored in
+	Storage S3BackupTarget `json:"storage"`
+
+	// PointInTimeRecovery makes the agents continuously upload the oplog once a backup has been taken, so that
+	// the data can be restored to any point in time.
+	// +optional
+	PointInTimeRecovery *PBMPointInTimeRecovery `json:"pointInTimeRecovery,omitempty"`
+}
+
+// PBMPointInTimeRecovery configures the oplog slices uploaded by the agents.
+type PBMPointInTimeRecovery struct {
+	// Enabled uploads the oplog slices
+	Enabled bool `json:"enabled"`
+
+	// OplogSpanMinutes is the duration of an oplog slice. Defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	OplogSpanMinutes int `json:"oplogSpanMinutes,omitempty"`
+}
+
+const (
+	defaultPBMImage            = "percona/percona-backup-mongodb:2.0.2"
+	defaultPBMOplogSpanMinutes = 10
+)
+
+// GetImage returns the Percona Backup for MongoDB image.
+func (p PBMConfiguration) GetImage() string {
+	if p.Image == "" {
+		return defaultPBMImage
+	}
+	return p.Image
+}
+
+// GetOplogSpanMinutes returns the duration of an oplog slice in minutes.
+func (p PBMPointInTimeRecovery) GetOplogSpanMinutes() int {
+	if p.OplogSpanMinutes == 0 {
+		return defaultPBMOplogSpanMinutes
+	}
+	return p.OplogSpanMinutes
+}
+
+// StorageLocation returns the URL of the directory the backups are stored in.
+func (p PBMConfiguration) StorageLocation() string {
+	return strings.TrimSuffix(fmt.Sprintf("s3://%s/%s", p.Storage.Bucket, strings.Trim(p.Storage.Prefix, "/")), "/")
+}
+
+// Prometheus configures the mongodb_exporter sidecars.
+type Prometheus struct {
+	// Enabled deploys the exporter sidecars.
+	Enabled bool `json:"enabled"`
+
+	// Image is the mongodb_exporter image. Defaults to "percona/mongodb_exporter:0.40.0"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Port is the port the exporter serves the metrics on, at /metrics. Defaults to 9216
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int `json:"port,omitempty"`
+
+	// TLS serves the metrics over HTTPS with the certificate and key of a kubernetes.io/tls Secret.
+	// +optional
+	TLS *PrometheusTLS `json:"tls,omitempty"`
+
+	// Args are additional arguments of the exporter, e.g. "--collect-all".
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Monitor configures the ServiceMonitor or PodMonitor created for the exporters if the Prometheus Operator
+	// is installed.
+	// +optional
+	Monitor *PrometheusMonitor `json:"monitor,omitempty"`
+
+	// Rules creates a PrometheusRule with alerts for the replica set if the Prometheus Operator is installed.
+	// +optional
+	Rules *PrometheusRules `json:"rules,omitempty"`
+}
+
+// PrometheusRules configures the alerts of the PrometheusRule created for the replica set.
+type PrometheusRules struct {
+	// Enabled creates the PrometheusRule.
+	Enabled bool `json:"enabled"`
+
+	// Labels are added to the PrometheusRule, so that it matches the rule selector of a Prometheus instance.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// MaxReplicationLag is the replication lag of a secondary above which an alert fires. Defaults to "30s"
+	// +optional
+	MaxReplicationLag string `json:"maxReplicationLag,omitempty"`
+
+	// MinFreeDiskPercent is the share of free space of a data or logs volume below which an alert fires.
+	// Defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +optional
+	MinFreeDiskPercent int `json:"minFreeDiskPercent,omitempty"`
+
+	// CertificateExpiry is how long before a certificate of the members expires an alert fires. Defaults to
+	// "336h"
+	// +optional
+	CertificateExpiry string `json:"certificateExpiry,omitempty"`
+
+	// FailedFor is how long the resource stays in the Failed phase before an alert fires. Defaults to "15m"
+	// +optional
+	FailedFor string `json:"failedFor,omitempty"`
+}
+
+// PrometheusMonitorKind is the kind of the Prometheus Operator object scraping the exporters.
+type PrometheusMonitorKind string
+
+const (
+	ServiceMonitor PrometheusMonitorKind = "ServiceMonitor"
+	PodMonitor     PrometheusMonitorKind = "PodMonitor"
+	// NoMonitor leaves the discovery of the exporters to the Prometheus configuration.
+	NoMonitor PrometheusMonitorKind = "None"
+)
+
+// PrometheusMonitor configures the Prometheus Operator object scraping the exporters.
+type PrometheusMonitor struct {
+	// Kind is ServiceMonitor, which scrapes the exporters through the "<name>-prometheus" Service, PodMonitor,
+	// which scrapes the Pods directly, or None. Defaults to ServiceMonitor
+	// +kubebuilder:validation:Enum=ServiceMonitor;PodMonitor;None
+	// +optional
+	Kind PrometheusMonitorKind `json:"kind,omitempty"`
+
+	// Labels are added to the monitor, so that it matches the monitor selector of a Prometheus instance.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Interval is how often the exporters are scraped, e.g. "30s". Defaults to the interval of the Prometheus
+	// instance
+	// +optional
+	Interval string `json:"interval,omitempty"`
+}
+
+// PrometheusTLS configures the HTTPS endpoint of the exporters.
+type PrometheusTLS struct {
+	// SecretRef is a Secret of type kubernetes.io/tls with the certificate in "tls.crt" and the key in "tls.key"
+	SecretRef LocalObjectReference `json:"secretRef"`
+}
+
+const (
+	defaultPrometheusImage = "percona/mongodb_exporter:0.40.0"
+	defaultPrometheusPort  = 9216
+)
+
+// GetImage returns the mongodb_exporter image.
+func (p Prometheus) GetImage() string {
+	if p.Image == "" {
+		return defaultPrometheusImage
+	}
+	return p.Image
+}
+
+// GetPort returns the port the exporter serves the metrics on.
+func (p Prometheus) GetPort() int {
+	if p.Port == 0 {
+		return defaultPrometheusPort
+	}
+	return p.Port
+}
+
+const defaultMinFreeDiskPercent = 10
+
+// IsEnabled returns true if the PrometheusRule is created.
+func (r *PrometheusRules) IsEnabled() bool {
+	return r != nil && r.Enabled
+}
+
+// GetMinFreeDiskPercent returns the share of free space of a volume below which an alert fires.
+func (r PrometheusRules) GetMinFreeDiskPercent() int {
+	if r.MinFreeDiskPercent == 0 {
+		return defaultMinFreeDiskPercent
+	}
+	return r.MinFreeDiskPercent
+}
+
+// GetMonitorKind returns the kind of the Prometheus Operator object scraping the exporters.
+func (p Prometheus) GetMonitorKind() PrometheusMonitorKind {
+	if p.Monitor == nil || p.Monitor.Kind == "" {
+		return ServiceMonitor
+	}
+	return p.Monitor.Kind
+}
+
+// ReplicaSetHorizonConfiguration holds the split horizon DNS settings for
+// replica set members.
+type ReplicaSetHorizonConfiguration []automationconfig.ReplicaSetHorizons
+
+// CustomRole defines a custom MongoDB role.
+type CustomRole struct {
+	// The name of the role.
+	Role string `json:"role"`
+	// The database of the role.
+	DB string `json:"db"`
+	// The privileges to grant the role.
+	Privileges []Privilege `json:"privileges"`
+	// An array of roles from which this role inherits privileges.
+	// +optional
+	Roles []Role `json:"roles"`
+	// The authentication restrictions the server enforces on the role.
+	// +optional
+	AuthenticationRestrictions []AuthenticationRestriction `json:"authenticationRestrictions,omitempty"`
+}
+
+// ConvertToAutomationConfigCustomRole converts between a custom role defined by the crd and a custom role
+// that can be used in the automation config.
+func (c CustomRole) ConvertToAutomationConfigCustomRole() automationconfig.CustomRole {
+	ac := automationconfig.CustomRole{Role: c.Role, DB: c.DB, Roles: []automationconfig.Role{}}
+
+	// Add privileges.
+	for _, privilege := range c.Privileges {
+		ac.Privileges = append(ac.Privileges, automationconfig.Privilege{
+			Resource: automationconfig.Resource{
+				DB:          privilege.Resource.DB,
+				Collection:  privilege.Resource.Collection,
+				AnyResource: privilege.Resource.AnyResource,
+				Cluster:     privilege.Resource.Cluster,
+			},
+			Actions: privilege.Actions,
+		})
+	}
+
+	// Add roles.
+	for _, dbRole := range c.Roles {
+		ac.Roles = append(ac.Roles, automationconfig.Role{
+			Role:     dbRole.Name,
+			Database: dbRole.DB,
+		})
+	}
+
+	// Add authentication restrictions (if any).
+	ac.AuthenticationRestrictions = convertAuthenticationRestrictions(c.AuthenticationRestrictions)
+
+	return ac
+}
+
+// convertAuthenticationRestrictions converts authentication restrictions to the ones used in the automation config.
+func convertAuthenticationRestrictions(restrictions []AuthenticationRestriction) []automationconfig.AuthenticationRestriction {
+	var acRestrictions []automationconfig.AuthenticationRestriction
+	for _, restriction := range restrictions {
+		acRestrictions = append(acRestrictions,
+			automationconfig.AuthenticationRestriction{
+				ClientSource:  restriction.ClientSource,
+				ServerAddress: restriction.ServerAddress,
+			})
+	}
+	return acRestrictions
+}
+
+// ConvertCustomRolesToAutomationConfigCustomRole converts custom roles to custom roles
+// that can be used in the automation config.
+func ConvertCustomRolesToAutomationConfigCustomRole(roles []CustomRole) []automationconfig.CustomRole {
+	acRoles := []automationconfig.CustomRole{}
+	for _, role := range roles {
+		acRoles = append(acRoles, role.ConvertToAutomationConfigCustomRole())
+	}
+	return acRoles
+}
+
+// Privilege defines the actions a role is allowed to perform on a given resource.
+type Privilege struct {
+	Resource Resource `json:"resource"`
+	Actions  []string `json:"actions"`
+}
+
+// Resource specifies specifies the resources upon which a privilege permits actions.
+// See https://docs.mongodb.com/manual/reference/resource-document for more.
+type Resource struct {
+	// +optional
+	DB *string `json:"db,omitempty"`
+	// +optional
+	Collection *string `json:"collection,omitempty"`
+	// +optional
+	Cluster bool `json:"cluster,omitempty"`
+	// +optional
+	AnyResource bool `json:"anyResource,omitempty"`
+}
+
+// AuthenticationRestriction specifies a list of IP addresses and CIDR ranges users
+// are allowed to connect to or from.
+type AuthenticationRestriction struct {
+	// ClientSource are the IP addresses and CIDR ranges users are allowed to connect from.
+	// +optional
+	ClientSource []string `json:"clientSource,omitempty"`
+	// ServerAddress are the IP addresses and CIDR ranges of the members users are allowed to connect to.
+	// +optional
+	ServerAddress []string `json:"serverAddress,omitempty"`
+}
+
+// StatefulSetConfiguration holds the optional custom StatefulSet
+// that should be merged into the operator created one.
+type StatefulSetConfiguration struct {
+	SpecWrapper StatefulSetSpecWrapper `json:"spec"`
+}
+
+// StatefulSetSpecWrapper is a wrapper around StatefulSetSpec with a custom implementation
+// of MarshalJSON and UnmarshalJSON which delegate to the underlying Spec to avoid CRD pollution.
+
+type StatefulSetSpecWrapper struct {
+	Spec appsv1.StatefulSetSpec `json:"-"`
+}
+
+// MarshalJSON defers JSON encoding to the wrapped map
+func (m *StatefulSetSpecWrapper) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Spec)
+}
+
+// UnmarshalJSON will decode the data into the wrapped map
+func (m *StatefulSetSpecWrapper) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &m.Spec)
+}
+
+func (m *StatefulSetSpecWrapper) DeepCopy() *StatefulSetSpecWrapper {
+	return &StatefulSetSpecWrapper{
+		Spec: m.Spec,
+	}
+}
+
+// MongodConfiguration holds the optional mongod configuration
+// that should be merged with the operator created one.
+//
+// The CRD generator does not support map[string]interface{}
+// on the top level and hence we need to work around this with
+// a wrapping struct.
+type MongodConfiguration struct {
+	Object map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON defers JSON encoding to the wrapped map
+func (m *MongodConfiguration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Object)
+}
+
+// UnmarshalJSON will decode the data into the wrapped map
+func (m *MongodConfiguration) UnmarshalJSON(data []byte) error {
+	if m.Object == nil {
+		m.Object = map[string]interface{}{}
+	}
+
+	return json.Unmarshal(data, &m.Object)
+}
+
+func (m *MongodConfiguration) DeepCopy() *MongodConfiguration {
+	return &MongodConfiguration{
+		Object: runtime.DeepCopyJSON(m.Object),
+	}
+}
+
+type MongoDBUser struct {
+	// Name is the username of the user
+	Name string `json:"name"`
+
+	// DB is the database the user is stored in. Defaults to "admin"
+	// +optional
+	DB string `json:"db"`
+
+	// PasswordSecretRef is a reference to the secret containing this user's password
+	PasswordSecretRef SecretKeyReference `json:"passwordSecretRef"`
+
+	// Roles is an array of roles assigned to this user. Required unless ReadOnly is set
+	// +optional
+	Roles []Role `json:"roles,omitempty"`
+
+	// ReadOnly grants this user the read role on each of ReadOnlyDatabases instead of Roles. The connection
+	// strings in the connection string Secret of a read-only user prefer reading from secondaries.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// ReadOnlyDatabases are the databases a read-only user can read. Defaults to all databases
+	// +optional
+	ReadOnlyDatabases []string `json:"readOnlyDatabases,omitempty"`
+
+	// ScramCredentialsSecretName appended by string "scram-credentials" is the name of the secret object created by the mongoDB operator for storing SCRAM credentials
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
+	ScramCredentialsSecretName string `json:"scramCredentialsSecretName"`
+
+	// ConnectionStringSecret configures the Secret created by the operator which stores the connection strings of this user
+	// +optional
+	ConnectionStringSecret ConnectionStringSecret `json:"connectionStringSecret,omitempty"`
+
+	// AuthenticationRestrictions limit the addresses this user can connect from and to. The user can
+	// authenticate if the connection matches all fields of any of the restrictions.
+	// +optional
+	AuthenticationRestrictions []AuthenticationRestriction `json:"authenticationRestrictions,omitempty"`
+
+	// ScramSha1 allows this user to authenticate with SCRAM-SHA-1 in addition to SCRAM-SHA-256, for
+	// applications using drivers which do not support SCRAM-SHA-256. Enabling it for any user enables
+	// the SCRAM-SHA-1 mechanism on the deployment.
+	// +optional
+	ScramSha1 bool `json:"scramSha1,omitempty"`
+}
+
+// ConnectionStringFormat is an additional format in which the connection details of a user are stored
+// +kubebuilder:validation:Enum=srv;hosts;properties;json
+type ConnectionStringFormat string
+
+const (
+	// ConnectionStringFormatSRV stores a mongodb+srv:// connection string.
+	ConnectionStringFormatSRV ConnectionStringFormat = "srv"
+	// ConnectionStringFormatHosts stores the comma separated list of members.
+	ConnectionStringFormatHosts ConnectionStringFormat = "hosts"
+	// ConnectionStringFormatProperties stores the connection details as a .properties file.
+	ConnectionStringFormatProperties ConnectionStringFormat = "properties"
+	// ConnectionStringFormatJSON stores the connection details as a JSON document.
+	ConnectionStringFormatJSON ConnectionStringFormat = "json"
+)
+
+// ConnectionStringSecret configures the Secret storing the connection strings of a user
+type ConnectionStringSecret struct {
+	// Name is the name of the Secret. Defaults to "<resource name>-<user db>-<user name>"
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// AdditionalFormats are the formats which are stored in the Secret in addition to the standard connection string
+	// +optional
+	AdditionalFormats []ConnectionStringFormat `json:"additionalFormats,omitempty"`
+
+	// Options are connection options appended to the connection strings, e.g. readPreference: secondaryPreferred
+	// +optional
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// HasFormat returns true if the given additional format has been requested.
+func (c ConnectionStringSecret) HasFormat(format ConnectionStringFormat) bool {
+	for _, f := range c.AdditionalFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConnectionStringSecretName returns the name of the Secret storing the connection strings of this user
+// for the resource with the given name.
+func (m MongoDBUser) GetConnectionStringSecretName(resourceName string) string {
+	if m.ConnectionStringSecret.Name != "" {
+		return m.ConnectionStringSecret.Name
+	}
+	return normalizeName(fmt.Sprintf("%s-%s-%s", resourceName, m.GetDB(), m.Name))
+}
+
+// normalizeName turns the given string into a valid Kubernetes object name by lowercasing it
+// and replacing any unsupported character with a dash.
+func normalizeName(name string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+	return strings.Trim(normalized, "-.")
+}
+
+// GetRoles returns the roles of the user, which are the read roles of the read-only databases
+// if the user is read-only.
+func (m MongoDBUser) GetRoles() []Role {
+	if !m.ReadOnly {
+		return m.Roles
+	}
+	if len(m.ReadOnlyDatabases) == 0 {
+		return []Role{{Name: "readAnyDatabase", DB: defaultUserDB}}
+	}
+	roles := make([]Role, len(m.ReadOnlyDatabases))
+	for i, db := range m.ReadOnlyDatabases {
+		roles[i] = Role{Name: "read", DB: db}
+	}
+	return roles
+}
+
+func (m MongoDBUser) GetPasswordSecretKey() string {
+	if m.PasswordSecretRef.Key == "" {
+		return defaultPasswordKey
+	}
+	return m.PasswordSecretRef.Key
+}
+
+// GetDB returns the database the user is stored in.
+func (m MongoDBUser) GetDB() string {
+	if m.DB == "" {
+		return defaultUserDB
+	}
+	return m.DB
+}
+
+// GetScramCredentialsSecretName gets the final SCRAM credentials secret-name by appending the user provided
+// scramsCredentialSecretName with "scram-credentials"
+func (m MongoDBUser) GetScramCredentialsSecretName() string {
+	return fmt.Sprintf("%s-%s", m.ScramCredentialsSecretName, "scram-credentials")
+}
+
+// SecretKeyReference is a reference to the secret containing the user's password
+type SecretKeyReference struct {
+	// Name is the name of the secret storing this user's password
+	Name string `json:"name"`
+
+	// Key is the key in the secret storing this password. Defaults to "password"
+	// +optional
+	Key string `json:"key"`
+}
+
+// Role is the database role this user should have
+type Role struct {
+	// DB is the database the role can act on
+	DB string `json:"db"`
+	// Name is the name of the role
+	Name string `json:"name"`
+}
+
+type Security struct {
+	// +optional
+	Authentication Authentication `json:"authentication"`
+	// TLS configuration for both client-server and server-server communication
+	// +optional
+	TLS TLS `json:"tls"`
+	// User-specified custom MongoDB roles that should be configured in the deployment.
+	// +optional
+	Roles []CustomRole `json:"roles,omitempty"`
+	// KeyfileRotation requests a rotation of the keyfile used for internal authentication
+	// between the members of the replica set.
+	// +optional
+	KeyfileRotation *KeyfileRotation `json:"keyfileRotation,omitempty"`
+	// AuditLog configures the audit log of mongod, which is only available in MongoDB Enterprise. It can not be
+	// combined with auditLog in additionalMongodConfig.
+	// +optional
+	AuditLog *AuditLog `json:"auditLog,omitempty"`
+}
+
+// AuditLogDestination is where mongod writes the audit events.
+type AuditLogDestination string
+
+const (
+	AuditLogDestinationFile    AuditLogDestination = "file"
+	AuditLogDestinationSyslog  AuditLogDestination = "syslog"
+	AuditLogDestinationConsole AuditLogDestination = "console"
+)
+
+// AuditLogFormat is the format of the audit log file.
+type AuditLogFormat string
+
+const (
+	AuditLogFormatJSON AuditLogFormat = "JSON"
+	AuditLogFormatBSON AuditLogFormat = "BSON"
+)
+
+// AuditLog configures the audit log of mongod.
+type AuditLog struct {
+	// Destination is where mongod writes the audit events: a file in the logs volume, the syslog of the
+	// container, or the standard output of the mongod container.
+	// +kubebuilder:validation:Enum=file;syslog;console
+	Destination AuditLogDestination `json:"destination"`
+
+	// Format is the format of the audit log file. It can only be set with the file destination.
+	// Defaults to JSON.
+	// +kubebuilder:validation:Enum=JSON;BSON
+	// +optional
+	Format AuditLogFormat `json:"format,omitempty"`
+
+	// Filter is a JSON document selecting the audit events which are recorded, e.g.
+	// {"atype": {"$in": ["authenticate", "createUser"]}}. All events are recorded by default.
+	// +optional
+	Filter string `json:"filter,omitempty"`
+
+	// Path is the path of the audit log file, which must be in /var/log/mongodb-mms-automation, where
+	// the logs volume is mounted. It can only be set with the file destination. Defaults to audit.json,
+	// or audit.bson for the BSON format, in that directory.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// GetFormat returns the format of the audit log file.
+func (a AuditLog) GetFormat() AuditLogFormat {
+	if a.Format == "" {
+		return AuditLogFormatJSON
+	}
+	return a.Format
+}
+
+// KeyfileRotation is used to request a rotation of the keyfile. The password of the agent is replaced
+// together with the keyfile, unless it is provided in agentCredentialsSecretRef.
+type KeyfileRotation struct {
+	// RotationID identifies a keyfile rotation. Setting it to a new value starts a rolling
+	// rotation of the keyfile: a new key is added to all members before the old key is removed.
+	// +optional
+	RotationID string `json:"rotationId,omitempty"`
+
+	// RotationInterval is the time after the last rotation, or after the resource was created, after which
+	// a rotation is started automatically, e.g. "720h". The keyfile is not rotated on a schedule if it is
+	// not set.
+	// +optional
+	RotationInterval string `json:"rotationInterval,omitempty"`
+}
+
+// TLS is the configuration used to set up TLS encryption
+type TLS struct {
+	Enabled bool `json:"enabled"`
+
+	// Optional configures if TLS should be required or optional for connections
+	// Deprecated: set Mode to preferTLS instead. Rejected in strict validation mode.
+	// +optional
+	Optional bool `json:"optional"`
+
+	// Mode configures which connections the members accept. allowTLS accepts TLS and non-TLS connections but
+	// does not use TLS for connections between the members, preferTLS uses TLS between the members but accepts
+	// non-TLS connections from clients, and requireTLS only accepts TLS connections. It takes precedence over
+	// Optional, which corresponds to preferTLS. Defaults to requireTLS.
+	// Enabling TLS on an existing deployment or changing the mode moves the members through the intermediate
+	// modes, one automation config change at a time.
+	// +kubebuilder:validation:Enum=allowTLS;preferTLS;requireTLS
+	// +optional
+	Mode automationconfig.TLSMode `json:"mode,omitempty"`
+
+	// MinimumTLSVersion is the lowest TLS protocol version the members accept, older versions are disabled
+	// with net.tls.disabledProtocols. By default, the defaults of mongod apply.
+	// +kubebuilder:validation:Enum=TLS1_0;TLS1_1;TLS1_2;TLS1_3
+	// +optional
+	MinimumTLSVersion string `json:"minimumTLSVersion,omitempty"`
+
+	// CipherSuites are the OpenSSL names of the cipher suites the members allow for TLS 1.2 and older, e.g.
+	// "ECDHE-RSA-AES256-GCM-SHA384". They are configured with the opensslCipherConfig server parameter.
+	// By default, the defaults of mongod apply.
+	// +optional
+	CipherSuites []string `json:"cipherSuites,omitempty"`
+
+	// CertificateKeySecret is a reference to a Secret containing a private key and certificate to use for TLS.
+	// The key and cert are expected to be PEM encoded and available at "tls.key" and "tls.crt".
+	// This is the same format used for the standard "kubernetes.io/tls" Secret type, but no specific type is required.
+	// A Secret without these fields may instead contain the certificate and key in a single PEM file under
+	// "tls.pem", or under the key set in CertificatePEMKey.
+	// +optional
+	CertificateKeySecret LocalObjectReference `json:"certificateKeySecretRef"`
+
+	// CertificatePEMKey is the key of a single PEM file containing both the certificate and the private key in
+	// the CertificateKeySecret and MemberCertificateSecret. If set, "tls.crt" and "tls.key" are ignored.
+	// +optional
+	CertificatePEMKey string `json:"certificatePemKey,omitempty"`
+
+	// CaConfigMap is a reference to a ConfigMap containing the certificate for the CA which signed the server certificates
+	// The certificate is expected to be available under the key "ca.crt", or under the key set in CaCertificateKey.
+	// +optional
+	CaConfigMap LocalObjectReference `json:"caConfigMapRef"`
+
+	// CaCertificateSecret is a reference to a Secret containing the certificate for the CA which signed the server
+	// certificates. It can be used instead of CaConfigMap, only one of them may be set. The certificate is expected
+	// to be available under the key "ca.crt", or under the key set in CaCertificateKey.
+	// +optional
+	CaCertificateSecret *LocalObjectReference `json:"caCertificateSecretRef,omitempty"`
+
+	// CaCertificateKey is the key of the CA certificate in the CaConfigMap or CaCertificateSecret. Defaults to "ca.crt".
+	// +optional
+	CaCertificateKey string `json:"caCertificateKey,omitempty"`
+
+	// InternalClusterAuth makes the members authenticate to each other with X.509 certificates instead of the
+	// keyfile. It requires a TLS mode which uses TLS between the members. Enabling or disabling it on an existing
+	// deployment moves the members through the intermediate cluster authentication modes, one automation config
+	// change at a time.
+	// +optional
+	InternalClusterAuth bool `json:"internalClusterAuth,omitempty"`
+
+	// MemberCertificateSecret is a reference to a Secret containing the private key and certificate the members
+	// authenticate to each other with, it is required if InternalClusterAuth is enabled. The key and cert are
+	// expected to be PEM encoded and available at "tls.key" and "tls.crt". The certificate must be signed by the
+	// CA in CaConfigMap and be valid for client authentication.
+	// +optional
+	MemberCertificateSecret *LocalObjectReference `json:"memberCertificateSecretRef,omitempty"`
+
+	// OperatorClient configures the TLS connections the operator opens to the members, e.g. to verify the
+	// credentials of the users. By default, the operator trusts the CA of the deployment and presents no
+	// client certificate.
+	// +optional
+	OperatorClient *OperatorClientTLS `json:"operatorClient,omitempty"`
+}
+
+// OperatorClientTLS configures the TLS connections the operator opens to the members.
+type OperatorClientTLS struct {
+	// CaConfigMap is a reference to a ConfigMap containing the CA the operator verifies the member certificates
+	// with, in "ca.crt". It is needed if the member certificates are not issued by the CA the members trust,
+	// e.g. if they are issued by a public CA. By default, the CA of the deployment is used.
+	// +optional
+	CaConfigMap *LocalObjectReference `json:"caConfigMapRef,omitempty"`
+
+	// CertificateKeySecret is a reference to a Secret containing the client certificate and private key the
+	// operator presents to the members, in "tls.crt" and "tls.key". The certificate must be issued by the
+	// CA the members trust.
+	// +optional
+	CertificateKeySecret *LocalObjectReference `json:"certificateKeySecretRef,omitempty"`
+}
+
+// HasCaConfigMap returns true if the operator verifies the member certificates with its own CA.
+func (o *OperatorClientTLS) HasCaConfigMap() bool {
+	return o != nil && o.CaConfigMap != nil && o.CaConfigMap.Name != ""
+}
+
+// HasCertificateKeySecret returns true if the operator presents a client certificate to the members.
+func (o *OperatorClientTLS) HasCertificateKeySecret() bool {
+	return o != nil && o.CertificateKeySecret != nil && o.CertificateKeySecret.Name != ""
+}
+
+// GetCaCertificateKey returns the key of the CA certificate in the CA ConfigMap or Secret.
+func (t TLS) GetCaCertificateKey() string {
+	if t.CaCertificateKey == "" {
+		return "ca.crt"
+	}
+	return t.CaCertificateKey
+}
+
+// HasCaCertificateSecret returns true if the CA certificate is read from a Secret instead of a ConfigMap.
+func (t TLS) HasCaCertificateSecret() bool {
+	return t.CaCertificateSecret != nil && t.CaCertificateSecret.Name != ""
+}
+
+// DesiredMode returns the TLS mode configured by Mode and Optional, or TLSModeDisabled if TLS is not enabled.
+func (t TLS) DesiredMode() automationconfig.TLSMode {
+	if !t.Enabled {
+		return automationconfig.TLSModeDisabled
+	}
+	if t.Mode != "" {
+		return t.Mode
+	}
+	if t.Optional {
+		return automationconfig.TLSModePreferred
+	}
+	return automationconfig.TLSModeRequired
+}
+
+// DesiredClusterAuthMode returns the cluster authentication mode configured by InternalClusterAuth.
+func (t TLS) DesiredClusterAuthMode() automationconfig.ClusterAuthMode {
+	if t.Enabled && t.InternalClusterAuth {
+		return automationconfig.ClusterAuthModeX509
+	}
+	return automationconfig.ClusterAuthModeKeyFile
+}
+
+// LocalObjectReference is a reference to another Kubernetes object by name.
+// TODO: Replace with a type from the K8s API. CoreV1 has an equivalent
+// 	"LocalObjectReference" type but it contains a TODO in its
+// 	description that we don't want in our CRD.
+type LocalObjectReference struct {
+	Name string `json:"name"`
+}
+
+type Authentication struct {
+	// Modes is an array specifying which authentication methods should be enabled.
+	// Changing the modes of a running deployment enables the new modes on all members
+	// before the modes which are no longer specified are disabled.
+	Modes []AuthMode `json:"modes"`
+
+	// LDAP configures the LDAP servers used to authenticate users, it is required
+	// if "LDAP" is one of the enabled Modes.
+	// +optional
+	LDAP *LDAP `json:"ldap,omitempty"`
+
+	// IgnoreUnknownUsers set to true will ensure any users added manually (not through the CRD)
+	// will not be removed.
+
+	// TODO: defaults will work once we update to v1 CRD.
+
+	// +optional
+	// +kubebuilder:default:=true
+	// +nullable
+	IgnoreUnknownUsers *bool `json:"ignoreUnknownUsers"`
+
+	// AgentCredentialsSecretRef is a reference to an existing Secret containing the "password" and,
+	// optionally, the "username" the automation agents authenticate with. If set, the operator
+	// uses these credentials instead of generating them.
+	// +optional
+	AgentCredentialsSecretRef *LocalObjectReference `json:"agentCredentialsSecretRef,omitempty"`
+
+	// KeyfileSecretRef is a reference to an existing Secret containing the keyfile the members authenticate
+	// to each other with, in "keyfile". If set, the operator uses this keyfile instead of generating one.
+	// Changes of the keyfile are rolled out like a keyfile rotation, so it can not be combined with
+	// keyfileRotation.
+	// +optional
+	KeyfileSecretRef *LocalObjectReference `json:"keyfileSecretRef,omitempty"`
+
+	// CredentialVerification periodically verifies that every SCRAM user can authenticate with the
+	// password stored in its Secret. Failures are reported in the CredentialDrift condition.
+	// +optional
+	CredentialVerification *CredentialVerification `json:"credentialVerification,omitempty"`
+
+	// MetricsUser creates a SCRAM user which only has the clusterMonitor role, for exporters and APM agents.
+	// Its credentials are stored in the Secret "<name>-metrics-user".
+	// +optional
+	MetricsUser *MetricsUser `json:"metricsUser,omitempty"`
+}
+
+// MetricsUser configures the user monitoring tools connect with.
+type MetricsUser struct {
+	// Enabled creates the metrics user.
+	Enabled bool `json:"enabled"`
+
+	// Name is the name of the user in the admin database. Defaults to "metrics"
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// RotationInterval is the time after which the password of the user is replaced, e.g. "720h". The password
+	// is not rotated if it is not set.
+	// +optional
+	RotationInterval string `json:"rotationInterval,omitempty"`
+}
+
+// CredentialVerification configures the periodic verification of the credentials of the users.
+type CredentialVerification struct {
+	// Interval is the time between two verifications, e.g. "30m". Defaults to "1h"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Timeout is the time a single user is given to connect and authenticate. Defaults to "10s"
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=SCRAM;LDAP;X509
+type AuthMode string
+
+const (
+	AuthModeScram AuthMode = "SCRAM"
+	AuthModeLDAP  AuthMode = "LDAP"
+	// AuthModeX509 allows users to authenticate with X.509 client certificates, it requires TLS to be enabled.
+	AuthModeX509 AuthMode = "X509"
+)
+
+// LDAP is the configuration used to authenticate users against LDAP servers.
+// LDAP authentication is only supported by MongoDB Enterprise.
+type LDAP struct {
+	// Servers is a list of LDAP servers, in "host" or "host:port" format, mongod will connect to.
+	// +kubebuilder:validation:MinItems=1
+	Servers []string `json:"servers"`
+
+	// TransportSecurity configures whether the connection to the LDAP servers uses TLS.
+	// Defaults to "tls"
+	// +kubebuilder:validation:Enum=tls;none
+	// +optional
+	TransportSecurity LDAPTransportSecurity `json:"transportSecurity,omitempty"`
+
+	// CaConfigMap is a reference to a ConfigMap containing the certificate for the CA which signed
+	// the certificates of the LDAP servers. The certificate is expected to be available under the key "ca.crt"
+	// +optional
+	CaConfigMap *LocalObjectReference `json:"caConfigMapRef,omitempty"`
+
+	// BindQueryUser is the distinguished name mongod binds as when querying the LDAP servers.
+	BindQueryUser string `json:"bindQueryUser"`
+
+	// BindQueryPasswordSecretRef is a reference to the Secret containing the password of the BindQueryUser.
+	BindQueryPasswordSecretRef SecretKeyReference `json:"bindQueryPasswordSecretRef"`
+
+	// UserToDNMapping maps the usernames provided to mongod to LDAP distinguished names.
+	// See https://docs.mongodb.com/manual/reference/program/mongoldap/#cmdoption-mongoldap-ldapusertodnmapping
+	// +optional
+	UserToDNMapping string `json:"userToDNMapping,omitempty"`
+
+	// AuthzQueryTemplate is the RFC4516 formatted LDAP query used to obtain the LDAP groups a user belongs to.
+	// +optional
+	AuthzQueryTemplate string `json:"authzQueryTemplate,omitempty"`
+
+	// ValidateLDAPServerConfig configures if mongod validates the LDAP configuration on startup.
+	// Defaults to true
+	// +optional
+	ValidateLDAPServerConfig *bool `json:"validateLDAPServerConfig,omitempty"`
+
+	// TimeoutMS is the number of milliseconds mongod waits for an LDAP server to respond.
+	// +optional
+	TimeoutMS int `json:"timeoutMS,omitempty"`
+}
+
+// GetBindQueryPasswordSecretKey returns the key of the bind query password in the referenced Secret.
+func (l LDAP) GetBindQueryPasswordSecretKey() string {
+	if l.BindQueryPasswordSecretRef.Key == "" {
+		return defaultPasswordKey
+	}
+	return l.BindQueryPasswordSecretRef.Key
+}
+
+// GetTransportSecurity returns the configured transport security, defaulting to TLS.
+func (l LDAP) GetTransportSecurity() LDAPTransportSecurity {
+	if l.TransportSecurity == "" {
+		return LDAPTransportSecurityTLS
+	}
+	return l.TransportSecurity
+}
+
+// ShouldValidateLDAPServerConfig returns true if mongod should validate the LDAP configuration on startup.
+func (l LDAP) ShouldValidateLDAPServerConfig() bool {
+	return l.ValidateLDAPServerConfig == nil || *l.ValidateLDAPServerConfig
+}
+
+type LDAPTransportSecurity string
+
+const (
+	LDAPTransportSecurityTLS  LDAPTransportSecurity = "tls"
+	LDAPTransportSecurityNone LDAPTransportSecurity = "none"
+)
+
+// IsLDAPEnabled returns true if LDAP authentication is enabled.
+func (a Authentication) IsLDAPEnabled() bool {
+	for _, mode := range a.Modes {
+		if mode == AuthModeLDAP {
+			return true
+		}
+	}
+	return false
+}
+
+// MongoDBCommunityStatus defines the observed state of MongoDB
+type MongoDBCommunityStatus struct {
+	MongoURI string `json:"mongoUri"`
+	Phase    Phase  `json:"phase"`
+
+	CurrentStatefulSetReplicas int `json:"currentStatefulSetReplicas"`
+	CurrentMongoDBMembers      int `json:"currentMongoDBMembers"`
+
+	Message string `json:"message,omitempty"`
+
+	// KeyfileRotation reports the progress of the most recent keyfile rotation.
+	// +optional
+	KeyfileRotation *KeyfileRotationStatus `json:"keyfileRotation,omitempty"`
+
+	// AuthenticationMigration reports the progress of the most recent change of the authentication modes.
+	// +optional
+	AuthenticationMigration *AuthenticationMigrationStatus `json:"authenticationMigration,omitempty"`
+
+	// ReplicaSetRename reports the progress of the most recent change of the replica set name.
+	// +optional
+	ReplicaSetRename *ReplicaSetRenameStatus `json:"replicaSetRename,omitempty"`
+
+	// LastCredentialVerificationTime is the time the credentials of the users were last verified.
+	// +optional
+	LastCredentialVerificationTime *metav1.Time `json:"lastCredentialVerificationTime,omitempty"`
+
+	// LastReconcile reports the most recent reconciliation which completed in the Running phase.
+	// +optional
+	LastReconcile *LastReconcileStatus `json:"lastReconcile,omitempty"`
+
+	// ErrorBudget reports how much of a rolling window the resource spent outside the Running phase.
+	// +optional
+	ErrorBudget *ErrorBudgetStatus `json:"errorBudget,omitempty"`
+
+	// OnDeleteUpdateStrategy reports why the StatefulSet uses the OnDelete update strategy. It is removed
+	// once the StatefulSet uses the RollingUpdate strategy again.
+	// +optional
+	OnDeleteUpdateStrategy *OnDeleteUpdateStrategyStatus `json:"onDeleteUpdateStrategy,omitempty"`
+
+	// PendingRestarts are the names of the Pods which do not run the current revision of the StatefulSet yet,
+	// if spec.updateStrategy.type is OnDelete. The changes are applied to these members once their Pods
+	// are deleted.
+	// +optional
+	PendingRestarts []string `json:"pendingRestarts,omitempty"`
+
+	// NetworkPolicy is the name of the NetworkPolicy of the members, if the operator has created it. It is
+	// removed once the NetworkPolicy is deleted.
+	// +optional
+	NetworkPolicy string `json:"networkPolicy,omitempty"`
+
+	// ExternalAddresses are the addresses the members can be reached at from outside of the Kubernetes
+	// cluster, if spec.externalAccess is set. Members whose Service has no address yet are not listed.
+	// +optional
+	ExternalAddresses []ExternalAddress `json:"externalAddresses,omitempty"`
+
+	// TLSMode is the TLS mode the members are being configured with. It differs from the mode in the spec
+	// while the members are moved through the intermediate modes.
+	// +optional
+	TLSMode automationconfig.TLSMode `json:"tlsMode,omitempty"`
+
+	// CARotation reports the progress of the most recent change of the CA ConfigMap.
+	// +optional
+	CARotation *CARotationStatus `json:"caRotation,omitempty"`
+
+	// PlannedOutage reports the members whose votes are removed for a planned outage.
+	// +optional
+	PlannedOutage *PlannedOutageStatus `json:"plannedOutage,omitempty"`
+
+	// ForceReconfig reports the most recent forced reconfiguration requested with the
+	// mongodbcommunity.mongodb.com/force-reconfig annotation.
+	// +optional
+	ForceReconfig *ForceReconfigStatus `json:"forceReconfig,omitempty"`
+
+	// Initialization reports the progress of the initialization of the data of the deployment.
+	// +optional
+	Initialization *InitializationStatus `json:"initialization,omitempty"`
+
+	// Progress reports the progress of the most recent rollout of a change to the members, such as a version
+	// upgrade, a TLS transition or a change of the StatefulSet.
+	// +optional
+	Progress *ProgressStatus `json:"progress,omitempty"`
+
+	// Canary reports the most recent canary update.
+	// +optional
+	Canary *CanaryStatus `json:"canary,omitempty"`
+
+	// ConcurrentChange reports the generation whose rollout is in progress, and a more recent generation
+	// which is queued or rejected according to the ConcurrentChangePolicy. It is removed once the rollout has
+	// completed, unless a generation has been rejected.
+	// +optional
+	ConcurrentChange *ConcurrentChangeStatus `json:"concurrentChange,omitempty"`
+
+	// TLSCertificates reports when the certificates used by the members expire.
+	// +optional
+	TLSCertificates *TLSCertificatesStatus `json:"tlsCertificates,omitempty"`
+
+	// ClusterAuthMode is the cluster authentication mode the members are being configured with. It differs
+	// from the mode configured in the spec while the members are moved through the intermediate modes.
+	// +optional
+	ClusterAuthMode automationconfig.ClusterAuthMode `json:"clusterAuthMode,omitempty"`
+
+	// Members reports the state of each member.
+	// +optional
+	Members []MemberStatus `json:"members,omitempty"`
+
+	// StuckMembers reports the members which are stuck, if stuck member remediation is enabled.
+	// +optional
+	StuckMembers []StuckMemberStatus `json:"stuckMembers,omitempty"`
+
+	// DiagnosticsCapture reports the most recent capture of diagnostic data requested with the
+	// mongodbcommunity.mongodb.com/capture-diagnostics annotation.
+	// +optional
+	DiagnosticsCapture *DiagnosticsCaptureStatus `json:"diagnosticsCapture,omitempty"`
+
+	// VersionRollout reports the MongoDB version each member runs, and the progress of the most recent change
+	// of the MongoDB version.
+	// +optional
+	VersionRollout *VersionRolloutStatus `json:"versionRollout,omitempty"`
+
+	// Conditions summarize the outcome of the backup, restore and maintenance Jobs
+	// which belong to this resource, and of the verification of the user credentials.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// MemberStatus reports the state of a member.
+type MemberStatus struct {
+	// Name is the name of the Pod of the member.
+	Name string `json:"name"`
+
+	// State is the replica set state of the member, e.g. PRIMARY, SECONDARY or RECOVERING, if the member
+	// health is reported.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// ReplicationLag is how far the member is behind the primary, if it is a secondary.
+	// +optional
+	ReplicationLag *metav1.Duration `json:"replicationLag,omitempty"`
+
+	// LastHeartbeat is when the member last responded to a heartbeat of the member the replica set status
+	// was read from, if the member health is reported.
+	// +optional
+	LastHeartbeat *metav1.Time `json:"lastHeartbeat,omitempty"`
+
+	// ReplicationLagGate is the status of the replication lag readiness gate of the Pod, if it is enabled.
+	// +optional
+	ReplicationLagGate corev1.ConditionStatus `json:"replicationLagGate,omitempty"`
+}
+
+// StuckMemberStatus reports a stuck member.
+type StuckMemberStatus struct {
+	// Name is the name of the Pod of the member.
+	Name string `json:"name"`
+
+	// State is the replica set state of the member, or Lagging for a secondary which is too far behind the
+	// primary.
+	State string `json:"state"`
+
+	// Since is when the member was first observed in the state.
+	Since metav1.Time `json:"since"`
+
+	// ThresholdExceeded is true once the member has been stuck for longer than the threshold.
+	// +optional
+	ThresholdExceeded bool `json:"thresholdExceeded,omitempty"`
+}
+
+// DiagnosticsCaptureStatus reports a requested capture of diagnostic data.
+type DiagnosticsCaptureStatus struct {
+	// Request is the value of the annotation which requested the capture.
+	Request string `json:"request"`
+
+	// ConfigMapName is the name of the ConfigMap storing the captured data, which is deleted after the
+	// retention of spec.diagnostics.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// CaptureTime is when the data was captured.
+	CaptureTime metav1.Time `json:"captureTime"`
+
+	// Error is the reason the data could not be captured.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// VersionRolloutStatus reports the MongoDB version each member runs. A member runs Version once its agent has
+// reached the goal state of the automation config which changed the version, and PreviousVersion until then.
+type VersionRolloutStatus struct {
+	// Version is the MongoDB version of the automation config.
+	Version string `json:"version"`
+
+	// PreviousVersion is the MongoDB version the members ran before Version, if it is known.
+	// +optional
+	PreviousVersion string `json:"previousVersion,omitempty"`
+
+	// AutomationConfigVersion is the version of the automation config which changed the MongoDB version to Version.
+	// +optional
+	AutomationConfigVersion int `json:"automationConfigVersion,omitempty"`
+
+	// UpdatedMembers is the number of members which run Version.
+	UpdatedMembers int `json:"updatedMembers"`
+
+	// Members reports the MongoDB version each member runs.
+	// +optional
+	Members []MemberVersion `json:"members,omitempty"`
+}
+
+// MemberVersion reports the MongoDB version a member runs.
+type MemberVersion struct {
+	// Name is the name of the Pod of the member.
+	Name string `json:"name"`
+
+	// Version is the MongoDB version the member runs, empty if it is not known.
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// LastReconcileStatus reports when a resource was last fully reconciled.
+type LastReconcileStatus struct {
+	// Time is when the reconciliation completed.
+	Time metav1.Time `json:"time"`
+
+	// QueueWait is how long the resource waited in the queue of the operator before the reconciliation
+	// started, for example because all workers were busy reconciling other resources.
+	QueueWait metav1.Duration `json:"queueWait"`
+}
+
+// ErrorBudgetStatus reports the time the resource spent outside the Running phase during a rolling window,
+// from which the availability of the deployment as reported by the operator can be computed.
+type ErrorBudgetStatus struct {
+	// Window is the length of the rolling window.
+	Window metav1.Duration `json:"window"`
+
+	// ObservedSince is the start of the part of the window during which the phase of the resource was
+	// observed. It is the start of the window, unless the resource was created more recently.
+	ObservedSince metav1.Time `json:"observedSince"`
+
+	// NonRunningDuration is how long the resource was in a phase other than Running since ObservedSince.
+	NonRunningDuration metav1.Duration `json:"nonRunningDuration"`
+
+	// NonRunningRatio is NonRunningDuration divided by the time elapsed since ObservedSince, as a decimal
+	// number between 0 and 1.
+	NonRunningRatio string `json:"nonRunningRatio"`
+
+	// Periods are the periods the resource spent outside the Running phase which overlap the window, the
+	// oldest first. The last period has no end while the resource is not Running.
+	// +optional
+	Periods []NonRunningPeriod `json:"periods,omitempty"`
+}
+
+// NonRunningPeriod is a period during which the resource was in a phase other than Running.
+type NonRunningPeriod struct {
+	// Start is when the resource left the Running phase, or was first observed in another phase.
+	Start metav1.Time `json:"start"`
+
+	// End is when the resource reached the Running phase again.
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+}
+
+// TLSCertificatesStatus reports the expiry times of the certificates used by the members. A certificate
+// which can not be read or parsed is not reported.
+type TLSCertificatesStatus struct {
+	// CertificateNotAfter is the expiry time of the server certificate.
+	// +optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+
+	// CANotAfter is the expiry time of the CA certificate.
+	// +optional
+	CANotAfter *metav1.Time `json:"caNotAfter,omitempty"`
+
+	// MemberCertificateNotAfter is the expiry time of the member certificate used for internal cluster authentication.
+	// +optional
+	MemberCertificateNotAfter *metav1.Time `json:"memberCertificateNotAfter,omitempty"`
+}
+
+// OnDeleteUpdateStrategyReasonVersionChange indicates the StatefulSet uses the OnDelete update strategy
+// because the agents restart the members with a new MongoDB version.
+const OnDeleteUpdateStrategyReasonVersionChange = "VersionChange"
+
+// OnDeleteUpdateStrategyReasonVerticalScaling indicates the StatefulSet uses the OnDelete update strategy
+// because the operator restarts the members in order, the secondaries first and the primary last, after the
+// resources or the ephemeral storage of the containers changed.
+const OnDeleteUpdateStrategyReasonVerticalScaling = "VerticalScaling"
+
+// ExternalMember is a member of the replica set which runs outside of the Kubernetes cluster.
+type ExternalMember struct {
+	// ID is the _id of the member in the replica set configuration. It can not be the _id of a member in the
+	// cluster, which are the ordinals of their Pods, starting at spec.memberOrdinalStart.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=255
+	ID int `json:"id"`
+
+	// Hostname is the hostname the members in the cluster reach the member at. The agent on the host of the
+	// member identifies the process of the member by it.
+	Hostname string `json:"hostname"`
+
+	// Port is the port mongod listens on. Defaults to 27017
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Votes is the number of votes of the member, 0 or 1. Defaults to 1
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	Votes *int `json:"votes,omitempty"`
+
+	// Priority is the priority of the member in elections, which must be 0 for a member without votes.
+	// Defaults to 1
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Priority *int `json:"priority,omitempty"`
+
+	// Horizons are the addresses of the member in the horizons of the replica set. Horizons of the members in
+	// the cluster which are not listed default to the hostname and port of the member.
+	// +optional
+	Horizons map[string]string `json:"horizons,omitempty"`
+
+	// DBPath is the data directory of mongod on the host of the member. Defaults to /data
+	// +optional
+	DBPath string `json:"dbPath,omitempty"`
+
+	// TLS configures the certificate of the member, which is required if TLS is enabled.
+	// +optional
+	TLS *ExternalMemberTLS `json:"tls,omitempty"`
+}
+
+// ExternalMemberTLS configures the TLS files on the host of an external member.
+type ExternalMemberTLS struct {
+	// CertificateKeyFile is the path of the PEM file with the certificate and the private key of the member.
+	CertificateKeyFile string `json:"certificateKeyFile"`
+
+	// CAFile is the path of the CA certificate the member verifies the certificates of the other members and
+	// of the clients with.
+	CAFile string `json:"caFile"`
+}
+
+// GetPort returns the port mongod listens on, 27017 by default.
+func (e ExternalMember) GetPort() int32 {
+	if e.Port == 0 {
+		return 27017
+	}
+	return e.Port
+}
+
+// GetVotes returns the number of votes of the member, 1 by default.
+func (e ExternalMember) GetVotes() int {
+	if e.Votes == nil {
+		return 1
+	}
+	return *e.Votes
+}
+
+// GetPriority returns the priority of the member in elections, 1 by default.
+func (e ExternalMember) GetPriority() int {
+	if e.Priority == nil {
+		return 1
+	}
+	return *e.Priority
+}
+
+// ExternalAddress is the address a member can be reached at from outside of the Kubernetes cluster.
+type ExternalAddress struct {
+	// Name is the name of the Pod of the member.
+	Name string `json:"name"`
+	// Address is the "<host>:<port>" of the Service of the member.
+	Address string `json:"address"`
+}
+
+// OnDeleteUpdateStrategyStatus reports why and since when the StatefulSet uses the OnDelete update strategy.
+type OnDeleteUpdateStrategyStatus struct {
+	// Reason is the reason the OnDelete update strategy is used.
+	Reason string `json:"reason"`
+	// Message describes the change which requires the OnDelete update strategy.
+	Message string `json:"message"`
+	// Since is the time the StatefulSet was switched to the OnDelete update strategy.
+	Since metav1.Time `json:"since"`
+}
+
+type KeyfileRotationPhase string
+
+const (
+	// KeyfileRotationAddingNewKey indicates the members are being configured to accept both the old and the new key.
+	KeyfileRotationAddingNewKey KeyfileRotationPhase = "AddingNewKey"
+	// KeyfileRotationRemovingOldKey indicates the old key is being removed from the members.
+	KeyfileRotationRemovingOldKey KeyfileRotationPhase = "RemovingOldKey"
+	// KeyfileRotationReplacingAgentPassword indicates the agents are being configured with the new password of
+	// the agent, once the old key has been removed.
+	KeyfileRotationReplacingAgentPassword KeyfileRotationPhase = "ReplacingAgentPassword"
+	// KeyfileRotationCompleted indicates the rotation has finished.
+	KeyfileRotationCompleted KeyfileRotationPhase = "Completed"
+)
+
+// KeyfileRotationStatus reports the progress of a keyfile rotation.
+type KeyfileRotationStatus struct {
+	// RotationID is the ID of the rotation this status refers to.
+	RotationID string `json:"rotationId"`
+	// Phase is the current phase of the rotation.
+	Phase KeyfileRotationPhase `json:"phase"`
+	// LastRotationTime is the time the most recent rotation completed.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// Scheduled is true if the rotation was started because the rotation interval elapsed.
+	// +optional
+	Scheduled bool `json:"scheduled,omitempty"`
+	// AgentPasswordRotated is true if the password of the agent is replaced by the rotation.
+	// +optional
+	AgentPasswordRotated bool `json:"agentPasswordRotated,omitempty"`
+}
+
+type AuthenticationMigrationPhase string
+
+const (
+	// AuthenticationMigrationEnablingNewModes indicates the members are being configured to accept both
+	// the previous and the new authentication modes.
+	AuthenticationMigrationEnablingNewModes AuthenticationMigrationPhase = "EnablingNewModes"
+	// AuthenticationMigrationDisablingOldModes indicates the previous authentication modes are being disabled.
+	AuthenticationMigrationDisablingOldModes AuthenticationMigrationPhase = "DisablingOldModes"
+	// AuthenticationMigrationCompleted indicates only the new authentication modes are enabled.
+	AuthenticationMigrationCompleted AuthenticationMigrationPhase = "Completed"
+)
+
+// AuthenticationMigrationStatus reports the progress of a change of the authentication modes.
+type AuthenticationMigrationStatus struct {
+	// From are the authentication modes enabled before the migration.
+	From []AuthMode `json:"from"`
+	// To are the authentication modes enabled after the migration.
+	To []AuthMode `json:"to"`
+	// Phase is the current phase of the migration.
+	Phase AuthenticationMigrationPhase `json:"phase"`
+}
+
+type CARotationPhase string
+
+const (
+	// CARotationTrustingBoth indicates the members are being configured to trust both the previous and the new CA.
+	CARotationTrustingBoth CARotationPhase = "TrustingBoth"
+	// CARotationRemovingOldCA indicates the members are being configured to only trust the new CA.
+	CARotationRemovingOldCA CARotationPhase = "RemovingOldCA"
+	// CARotationCompleted indicates the members only trust the new CA.
+	CARotationCompleted CARotationPhase = "Completed"
+)
+
+// CARotationStatus reports the progress of a CA rotation.
+type CARotationStatus struct {
+	// From is the name of the ConfigMap storing the previous CA.
+	From string `json:"from"`
+	// To is the name of the ConfigMap storing the new CA.
+	To string `json:"to"`
+	// Phase is the current phase of the rotation.
+	Phase CARotationPhase `json:"phase"`
+}
+
+// PlannedOutageStatus reports the members whose votes are removed for a planned outage. The members are
+// determined once when the zones change, so that members rescheduled during the outage do not vote again.
+type PlannedOutageStatus struct {
+	// Zones are the zones scheduled for maintenance.
+	Zones []string `json:"zones"`
+	// Members are the names of the Pods of the members in these zones.
+	// +optional
+	Members []string `json:"members,omitempty"`
+}
+
+// CanaryChange is the kind of change a canary update rolls out.
+type CanaryChange string
+
+const (
+	// CanaryChangeVersion is a change of the MongoDB version, which the canary member runs first.
+	CanaryChangeVersion CanaryChange = "Version"
+	// CanaryChangeStatefulSet is a change of the Pod template of the StatefulSet, which is rolled out to the
+	// canary member first by partitioning the rolling update.
+	CanaryChangeStatefulSet CanaryChange = "StatefulSet"
+)
+
+// CanaryPhase is the phase of a canary update.
+type CanaryPhase string
+
+const (
+	// CanaryUpdating is reported until the canary member runs the change and is ready.
+	CanaryUpdating CanaryPhase = "Updating"
+	// CanarySoaking is reported while the canary member has to stay ready without restarting.
+	CanarySoaking CanaryPhase = "Soaking"
+	// CanaryCompleted is reported once the change is rolled out to the other members.
+	CanaryCompleted CanaryPhase = "Completed"
+	// CanaryFailed is reported if the canary member became unready or restarted during the soak period. The
+	// change is not rolled out to the other members.
+	CanaryFailed CanaryPhase = "Failed"
+)
+
+// CanaryStatus reports the progress of a canary update.
+type CanaryStatus struct {
+	// Change is the kind of change rolled out.
+	Change CanaryChange `json:"change"`
+	// Version is the MongoDB version rolled out, for changes of the version.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// PreviousVersion is the MongoDB version the other members run until the canary update has completed.
+	// +optional
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	// Member is the name of the Pod of the canary member.
+	Member string `json:"member"`
+	// Phase is the phase of the canary update.
+	Phase CanaryPhase `json:"phase"`
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is when the change was rolled out to the canary member.
+	StartTime metav1.Time `json:"startTime"`
+	// ReadySince is when the soak period started.
+	// +optional
+	ReadySince *metav1.Time `json:"readySince,omitempty"`
+	// Restarts is the number of restarts of the containers of the canary member when the soak period started.
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+	// CompletionTime is when the soak period ended and the change was rolled out to the other members.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
-// GetScramCredentialsSecretName gets the final SCRAM credentials secret-name by appending the user provided
-// scramsCredentialSecretName with "scram-credentials"
-func (m MongoDBUser) GetScramCredentialsSecretName() string {
-	return fmt.Sprintf("%s-%s", m.ScramCredentialsSecretName, "scram-credentials")
+// ForceReconfigStatus reports a forced reconfiguration of the replica set.
+type ForceReconfigStatus struct {
+	// Request is the value of the annotation which requested the reconfiguration.
+	Request string `json:"request"`
+	// Members are the names of the Pods of the surviving members.
+	Members []string `json:"members"`
+	// StartTime is when the reconfiguration was requested.
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime is when the agents of the surviving members reached the goal state of the forced
+	// reconfiguration.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
-// SecretKeyReference is a reference to the secret containing the user's password
-type SecretKeyReference struct {
-	// Name is the name of the secret storing this user's password
-	Name string `json:"name"`
+// InitializationPhase is the phase of the initialization of the data of a deployment.
+type InitializationPhase string
 
-	// Key is the key in the secret storing this password. Defaults to "password"
+const (
+	InitializationRunning   InitializationPhase = "Running"
+	InitializationCompleted InitializationPhase = "Completed"
+	InitializationFailed    InitializationPhase = "Failed"
+	// InitializationSkipped is reported if the initialization was set after the resource was deployed.
+	InitializationSkipped InitializationPhase = "Skipped"
+)
+
+// InitializationStatus reports the progress of the initialization of the data of a deployment.
+type InitializationStatus struct {
+	// Phase is the current phase of the initialization.
+	Phase InitializationPhase `json:"phase"`
+	// Message explains why the initialization is running, failed or was skipped.
 	// +optional
-	Key string `json:"key"`
+	Message string `json:"message,omitempty"`
+	// JobName is the name of the Job running the scripts.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// RestoreName is the name of the MongoDBCommunityRestore resource restoring the backup.
+	// +optional
+	RestoreName string `json:"restoreName,omitempty"`
+	// CompletionTime is when the initialization completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
-// Role is the database role this user should have
-type Role struct {
-	// DB is the database the role can act on
-	DB string `json:"db"`
-	// Name is the name of the role
-	Name string `json:"name"`
+// ProgressStatus reports the progress of a rollout. A member has completed the rollout once its Pod runs the
+// current revision of the StatefulSet, is ready and its agent has reached the goal state.
+type ProgressStatus struct {
+	// Operation is the change being rolled out: Upgrade, Scaling or Rollout.
+	Operation string `json:"operation"`
+	// MembersCompleted is the number of members which have completed the rollout.
+	MembersCompleted int `json:"membersCompleted"`
+	// Members is the number of members the change is rolled out to.
+	Members int `json:"members"`
+	// Percent is the percentage of the members which have completed the rollout.
+	Percent int `json:"percent"`
+	// CurrentMember is the name of the Pod of the member the change is being rolled out to.
+	// +optional
+	CurrentMember string `json:"currentMember,omitempty"`
+	// StartTime is when the rollout was first observed.
+	StartTime metav1.Time `json:"startTime"`
+	// EstimatedCompletionTime is when the rollout is expected to complete, from the time the members have
+	// taken so far, or the time they took in the previous rollout.
+	// +optional
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+	// CompletionTime is when all members completed the rollout.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// MemberDuration is the average time a member took to complete the most recent completed rollout.
+	// +optional
+	MemberDuration *metav1.Duration `json:"memberDuration,omitempty"`
 }
 
-type Security struct {
+// ConcurrentChangeStatus reports the generation being rolled out while the spec changes.
+type ConcurrentChangeStatus struct {
+	// Generation is the generation of the spec being rolled out.
+	Generation int64 `json:"generation"`
+	// Spec is the JSON encoding of the spec being rolled out, which is kept while the rollout is in progress
+	// if the ConcurrentChangePolicy is Queue or Reject.
 	// +optional
-	Authentication Authentication `json:"authentication"`
-	// TLS configuration for both client-server and server-server communication
+	Spec string `json:"spec,omitempty"`
+	// PendingGeneration is the most recent generation, which is queued or rejected.
 	// +optional
-	TLS TLS `json:"tls"`
-	// User-specified custom MongoDB roles that should be configured in the deployment.
+	PendingGeneration int64 `json:"pendingGeneration,omitempty"`
+	// Rejected is true if PendingGeneration has been rejected, in which case it is not applied.
 	// +optional
-	Roles []CustomRole `json:"roles,omitempty"`
+	Rejected bool `json:"rejected,omitempty"`
 }
 
-// TLS is the configuration used to set up TLS encryption
-type TLS struct {
-	Enabled bool `json:"enabled"`
+// Operations reported in ProgressStatus.
+const (
+	ProgressOperationUpgrade = "Upgrade"
+	ProgressOperationScaling = "Scaling"
+	ProgressOperationRollout = "Rollout"
+)
 
-	// Optional configures if TLS should be required or optional for connections
-	// +optional
-	Optional bool `json:"optional"`
+type ReplicaSetRenamePhase string
 
-	// CertificateKeySecret is a reference to a Secret containing a private key and certificate to use for TLS.
-	// The key and cert are expected to be PEM encoded and available at "tls.key" and "tls.crt".
-	// This is the same format used for the standard "kubernetes.io/tls" Secret type, but no specific type is required.
-	// +optional
-	CertificateKeySecret LocalObjectReference `json:"certificateKeySecretRef"`
+const (
+	// ReplicaSetRenameTearingDown indicates the StatefulSet is being deleted, retaining the volumes of all members.
+	ReplicaSetRenameTearingDown ReplicaSetRenamePhase = "TearingDown"
+	// ReplicaSetRenameRewritingMetadata indicates the replica set name stored on each member is being rewritten.
+	ReplicaSetRenameRewritingMetadata ReplicaSetRenamePhase = "RewritingMetadata"
+	// ReplicaSetRenameReprovisioning indicates the StatefulSet is being recreated with the new replica set name.
+	ReplicaSetRenameReprovisioning ReplicaSetRenamePhase = "Reprovisioning"
+	// ReplicaSetRenameCompleted indicates the replica set is running with the new name.
+	ReplicaSetRenameCompleted ReplicaSetRenamePhase = "Completed"
+)
 
-	// CaConfigMap is a reference to a ConfigMap containing the certificate for the CA which signed the server certificates
-	// The certificate is expected to be available under the key "ca.crt"
-	// +optional
-	CaConfigMap LocalObjectReference `json:"caConfigMapRef"`
+// ReplicaSetRenameStatus reports the progress of a change of the replica set name.
+type ReplicaSetRenameStatus struct {
+	// From is the previous name of the replica set.
+	From string `json:"from"`
+	// To is the new name of the replica set.
+	To string `json:"to"`
+	// Members is the number of members whose data is retained.
+	Members int `json:"members"`
+	// Phase is the current phase of the rename.
+	Phase ReplicaSetRenamePhase `json:"phase"`
 }
 
-// LocalObjectReference is a reference to another Kubernetes object by name.
-// TODO: Replace with a type from the K8s API. CoreV1 has an equivalent
-// 	"LocalObjectReference" type but it contains a TODO in its
-// 	description that we don't want in our CRD.
-type LocalObjectReference struct {
-	Name string `json:"name"`
-}
+// JobType is the kind of operation performed by a Job or CronJob which belongs to a MongoDBCommunity resource.
+type JobType string
 
-type Authentication struct {
-	// Modes is an array specifying which authentication methods should be enabled.
-	Modes []AuthMode `json:"modes"`
+const (
+	BackupJob      JobType = "backup"
+	RestoreJob     JobType = "restore"
+	MaintenanceJob JobType = "maintenance"
+	// InitializationJob runs the scripts initializing the data of a new deployment.
+	InitializationJob JobType = "initialization"
+)
 
-	// IgnoreUnknownUsers set to true will ensure any users added manually (not through the CRD)
-	// will not be removed.
+const (
+	// JobResourceLabel is the label a Job or CronJob must have, set to the name of the
+	// MongoDBCommunity resource, for its outcome to be reported in the resource status.
+	JobResourceLabel = "mongodbcommunity.mongodb.com/resource"
+	// JobTypeLabel is the label specifying the JobType of a Job or CronJob.
+	JobTypeLabel = "mongodbcommunity.mongodb.com/job-type"
+)
 
-	// TODO: defaults will work once we update to v1 CRD.
+// Labels of the label schema, which the operator sets on every object it creates for a resource and on the Pods
+// of its members. The same labels are set as annotations on the Events recorded for the resource.
+const (
+	LabelAppName      = "app.kubernetes.io/name"
+	LabelAppInstance  = "app.kubernetes.io/instance"
+	LabelAppComponent = "app.kubernetes.io/component"
+	LabelAppManagedBy = "app.kubernetes.io/managed-by"
+	// LabelResource is the name of the MongoDBCommunity resource. Unlike app.kubernetes.io/instance, which
+	// deployment tools may override, it is only ever set by the operator.
+	LabelResource = "mongodb.com/resource"
+	// LabelReplicaSet is the name of the replica set the object belongs to.
+	LabelReplicaSet = "mongodb.com/replica-set"
+)
 
-	// +optional
-	// +kubebuilder:default:=true
-	// +nullable
-	IgnoreUnknownUsers *bool `json:"ignoreUnknownUsers"`
+// Values of the app.kubernetes.io/component label.
+const (
+	ComponentDatabase         = "database"
+	ComponentAutomationConfig = "automation-config"
+	ComponentCredentials      = "credentials"
+	ComponentTLS              = "tls"
+	ComponentConfiguration    = "configuration"
+	ComponentJob              = "job"
+	ComponentDiagnostics      = "diagnostics"
+	ComponentMetrics          = "metrics"
+	ComponentExternalAccess   = "external-access"
+)
+
+// SchemaLabels returns the labels of the label schema for an object of the given component.
+func (m MongoDBCommunity) SchemaLabels(component string) map[string]string {
+	return map[string]string{
+		LabelAppName:      "mongodb",
+		LabelAppInstance:  m.Name,
+		LabelAppComponent: component,
+		LabelAppManagedBy: "mongodb-kubernetes-operator",
+		LabelResource:     m.Name,
+		LabelReplicaSet:   m.GetReplicaSetName(),
+	}
 }
 
-// +kubebuilder:validation:Enum=SCRAM
-type AuthMode string
+// Condition types reporting the outcome of Jobs belonging to the resource.
+const (
+	// ConditionLastBackup reports the outcome of the most recent backup Job.
+	ConditionLastBackup = "LastBackup"
+	// ConditionBackupScheduled reports when the next backup is scheduled.
+	ConditionBackupScheduled = "BackupScheduled"
+	// ConditionLastRestore reports the outcome of the most recent restore Job.
+	ConditionLastRestore = "LastRestore"
+	// ConditionLastMaintenance reports the outcome of the most recent maintenance Job.
+	ConditionLastMaintenance = "LastMaintenance"
+)
 
-// MongoDBCommunityStatus defines the observed state of MongoDB
-type MongoDBCommunityStatus struct {
-	MongoURI string `json:"mongoUri"`
-	Phase    Phase  `json:"phase"`
+// ConditionCredentialDrift reports whether a user could not authenticate with the password stored in
+// its Secret during the most recent credential verification.
+const ConditionCredentialDrift = "CredentialDrift"
 
-	CurrentStatefulSetReplicas int `json:"currentStatefulSetReplicas"`
-	CurrentMongoDBMembers      int `json:"currentMongoDBMembers"`
+// ConditionTLSCertificateExpiry reports when the TLS certificate of the members expires, and the progress of
+// restarting the members after the certificate has been renewed.
+const ConditionTLSCertificateExpiry = "TLSCertificateExpiry"
 
-	Message string `json:"message,omitempty"`
-}
+// ConditionCertificateExpiringSoon reports whether any of the certificates used by the members expires within
+// the warning period configured for the operator.
+const ConditionCertificateExpiringSoon = "CertificateExpiringSoon"
+
+// ConditionSRVUnavailable reports whether the SRV records of the Service can not be used by drivers, in which
+// case the connection string Secrets of the users contain standard connection strings instead of mongodb+srv:// ones.
+const ConditionSRVUnavailable = "SRVUnavailable"
+
+// ConditionChangeStreamsUnavailable reports whether a change stream could not be opened during the most
+// recent verification of change streams.
+const ConditionChangeStreamsUnavailable = "ChangeStreamsUnavailable"
+
+// ConditionScaleDownBlocked reports whether the removal of a member is blocked because the remaining members
+// are not caught up with the primary.
+const ConditionScaleDownBlocked = "ScaleDownBlocked"
+
+// ConditionConcurrentSpecChange reports whether a change of the spec is queued or rejected because it was made
+// while the rollout of a previous change was in progress.
+const ConditionConcurrentSpecChange = "ConcurrentSpecChange"
+
+// ConditionDisruptiveChangesQueued reports whether a disruptive change is queued until the next maintenance window.
+const ConditionDisruptiveChangesQueued = "DisruptiveChangesQueued"
+
+// ConditionAdmissionRejected reports whether the API server rejected an object generated for the resource, such as
+// its StatefulSet, in the dry-run made before the objects are changed.
+const ConditionAdmissionRejected = "AdmissionRejected"
+
+// ConditionTLSSecretMissing reports whether the TLS certificate Secret of a deployment which uses TLS was deleted.
+// The reconciliation is held while it is missing.
+const ConditionTLSSecretMissing = "TLSSecretMissing"
 
+// +genclient
+// +resourceName=mongodbcommunity
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 
@@ -363,6 +2815,8 @@ type MongoDBCommunityStatus struct {
 // +kubebuilder:resource:path=mongodbcommunity,scope=Namespaced,shortName=mdbc,singular=mongodbcommunity
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Current state of the MongoDB deployment"
 // +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="Version of MongoDB server"
+// +kubebuilder:printcolumn:name="Progress",type="integer",JSONPath=".status.progress.percent",description="Percentage of the members which completed the most recent rollout"
+// +kubebuilder:printcolumn:name="Member",type="string",JSONPath=".status.progress.currentMember",priority=1,description="Member the current rollout is applied to"
 type MongoDBCommunity struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -371,10 +2825,24 @@ type MongoDBCommunity struct {
 	Status MongoDBCommunityStatus `json:"status,omitempty"`
 }
 
+// GetAgentPasswordSecretNamespacedName returns the Secret storing the agent credentials, which is the
+// referenced Secret if the agent credentials are provided.
 func (m MongoDBCommunity) GetAgentPasswordSecretNamespacedName() types.NamespacedName {
+	if m.Spec.Security.Authentication.AgentCredentialsSecretRef != nil {
+		return types.NamespacedName{Name: m.Spec.Security.Authentication.AgentCredentialsSecretRef.Name, Namespace: m.Namespace}
+	}
 	return types.NamespacedName{Name: m.Name + "-agent-password", Namespace: m.Namespace}
 }
 
+// KeyfileSecretNamespacedName returns the namespaced name of the Secret containing the keyfile supplied by the
+// user, which is copied into the agent keyfile Secret.
+func (m MongoDBCommunity) KeyfileSecretNamespacedName() types.NamespacedName {
+	if m.Spec.Security.Authentication.KeyfileSecretRef == nil {
+		return types.NamespacedName{Namespace: m.Namespace}
+	}
+	return types.NamespacedName{Name: m.Spec.Security.Authentication.KeyfileSecretRef.Name, Namespace: m.Namespace}
+}
+
 func (m MongoDBCommunity) GetAgentKeyfileSecretNamespacedName() types.NamespacedName {
 	return types.NamespacedName{Name: m.Name + "-keyfile", Namespace: m.Namespace}
 }
@@ -388,6 +2856,73 @@ func (m MongoDBCommunity) GetOwnerReferences() []metav1.OwnerReference {
 	return []metav1.OwnerReference{ownerReference}
 }
 
+// IsRotatingCA returns true if a CA rotation is in progress.
+func (m MongoDBCommunity) IsRotatingCA() bool {
+	rotation := m.Status.CARotation
+	return rotation != nil && rotation.Phase != CARotationCompleted
+}
+
+// IsServingCABundle returns true if the members are configured to trust both the previous and the new CA.
+func (m MongoDBCommunity) IsServingCABundle() bool {
+	return m.IsRotatingCA() && m.Status.CARotation.Phase == CARotationTrustingBoth
+}
+
+// IsMigratingAuthentication returns true if a change of the authentication modes is in progress.
+func (m MongoDBCommunity) IsMigratingAuthentication() bool {
+	migration := m.Status.AuthenticationMigration
+	return migration != nil && migration.Phase != AuthenticationMigrationCompleted
+}
+
+// IsAuthModeEnabled returns true if the given authentication mode is enabled on the members. While new modes
+// are being enabled during a migration, the previous modes remain enabled as well.
+func (m MongoDBCommunity) IsAuthModeEnabled(mode AuthMode) bool {
+	if containsAuthMode(m.Spec.Security.Authentication.Modes, mode) {
+		return true
+	}
+	migration := m.Status.AuthenticationMigration
+	return migration != nil && migration.Phase == AuthenticationMigrationEnablingNewModes && containsAuthMode(migration.From, mode)
+}
+
+func containsAuthMode(modes []AuthMode, mode AuthMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// GetReplicaSetName returns the name of the replica set, which defaults to the name of the resource.
+func (m MongoDBCommunity) GetReplicaSetName() string {
+	if m.Spec.ReplicaSetName != "" {
+		return m.Spec.ReplicaSetName
+	}
+	return m.Name
+}
+
+// IsRenamingReplicaSet returns true if a change of the replica set name is in progress.
+func (m MongoDBCommunity) IsRenamingReplicaSet() bool {
+	rename := m.Status.ReplicaSetRename
+	return rename != nil && rename.Phase != ReplicaSetRenameCompleted
+}
+
+// IsKeyfileRotationRequested returns true if a keyfile rotation has been requested which
+// has not been started yet.
+func (m MongoDBCommunity) IsKeyfileRotationRequested() bool {
+	rotation := m.Spec.Security.KeyfileRotation
+	if rotation == nil || rotation.RotationID == "" {
+		return false
+	}
+	return m.Status.KeyfileRotation == nil || m.Status.KeyfileRotation.RotationID != rotation.RotationID
+}
+
+// IsRotatingKeyfile returns true if a keyfile rotation is in progress.
+func (m MongoDBCommunity) IsRotatingKeyfile() bool {
+	rotation := m.Status.KeyfileRotation
+	return rotation != nil && (rotation.Phase == KeyfileRotationAddingNewKey || rotation.Phase == KeyfileRotationRemovingOldKey ||
+		rotation.Phase == KeyfileRotationReplacingAgentPassword)
+}
+
 // GetScramOptions returns a set of Options that are used to configure scram
 // authentication.
 func (m MongoDBCommunity) GetScramOptions() scram.Options {
@@ -403,6 +2938,8 @@ func (m MongoDBCommunity) GetScramOptions() scram.Options {
 		AutoAuthMechanisms: []string{scram.Sha256},
 		AgentName:          scram.AgentName,
 		AutoAuthMechanism:  scram.Sha256,
+
+		UseExistingAgentCredentials: m.Spec.Security.Authentication.AgentCredentialsSecretRef != nil,
 	}
 }
 
@@ -411,8 +2948,9 @@ func (m MongoDBCommunity) GetScramOptions() scram.Options {
 func (m MongoDBCommunity) GetScramUsers() []scram.User {
 	users := make([]scram.User, len(m.Spec.Users))
 	for i, u := range m.Spec.Users {
-		roles := make([]scram.Role, len(u.Roles))
-		for j, r := range u.Roles {
+		userRoles := u.GetRoles()
+		roles := make([]scram.Role, len(userRoles))
+		for j, r := range userRoles {
 			roles[j] = scram.Role{
 				Name:     r.Name,
 				Database: r.DB,
@@ -425,11 +2963,52 @@ func (m MongoDBCommunity) GetScramUsers() []scram.User {
 			PasswordSecretKey:          u.GetPasswordSecretKey(),
 			PasswordSecretName:         u.PasswordSecretRef.Name,
 			ScramCredentialsSecretName: u.GetScramCredentialsSecretName(),
+			AuthenticationRestrictions: convertAuthenticationRestrictions(u.AuthenticationRestrictions),
+			ScramSha1:                  u.ScramSha1,
 		}
 	}
+	if m.IsMetricsUserEnabled() {
+		users = append(users, scram.User{
+			Username:                   m.GetMetricsUserName(),
+			Database:                   "admin",
+			Roles:                      []scram.Role{{Name: "clusterMonitor", Database: "admin"}},
+			PasswordSecretKey:          MetricsUserPasswordKey,
+			PasswordSecretName:         m.MetricsUserPasswordSecretNamespacedName().Name,
+			ScramCredentialsSecretName: m.Name + "-metrics-user-scram-credentials",
+		})
+	}
 	return users
 }
 
+// MetricsUserPasswordKey is the key of the password in the Secrets of the metrics user.
+const MetricsUserPasswordKey = "password"
+
+// IsMetricsUserEnabled returns true if the metrics user is created, which requires SCRAM authentication.
+func (m MongoDBCommunity) IsMetricsUserEnabled() bool {
+	user := m.Spec.Security.Authentication.MetricsUser
+	return user != nil && user.Enabled && m.IsAuthModeEnabled(AuthModeScram)
+}
+
+// GetMetricsUserName returns the name of the metrics user.
+func (m MongoDBCommunity) GetMetricsUserName() string {
+	if user := m.Spec.Security.Authentication.MetricsUser; user != nil && user.Name != "" {
+		return user.Name
+	}
+	return "metrics"
+}
+
+// MetricsUserPasswordSecretNamespacedName returns the namespaced name of the Secret storing the generated
+// password of the metrics user, which is applied to the members.
+func (m MongoDBCommunity) MetricsUserPasswordSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-metrics-user-password", Namespace: m.Namespace}
+}
+
+// MetricsUserSecretNamespacedName returns the namespaced name of the Secret storing the credentials and the
+// connection string of the metrics user, once its password has been applied to the members.
+func (m MongoDBCommunity) MetricsUserSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-metrics-user", Namespace: m.Namespace}
+}
+
 func (m MongoDBCommunity) AutomationConfigMembersThisReconciliation() int {
 	// determine the correct number of automation config replica set members
 	// based on our desired number, and our current number
@@ -441,39 +3020,260 @@ func (m MongoDBCommunity) AutomationConfigMembersThisReconciliation() int {
 
 // MongoURI returns a mongo uri which can be used to connect to this deployment
 func (m MongoDBCommunity) MongoURI() string {
-	members := make([]string, m.Spec.Members)
-	clusterDomain := "svc.cluster.local" // TODO: make this configurable
-	for i := 0; i < m.Spec.Members; i++ {
-		members[i] = fmt.Sprintf("%s-%d.%s.%s.%s:%d", m.Name, i, m.ServiceName(), m.Namespace, clusterDomain, 27017)
-	}
-	return fmt.Sprintf("mongodb://%s", strings.Join(members, ","))
+	return fmt.Sprintf("mongodb://%s", strings.Join(m.Hosts(), ","))
 }
 
 func (m MongoDBCommunity) Hosts() []string {
 	hosts := make([]string, m.Spec.Members)
-	clusterDomain := "svc.cluster.local" // TODO: make this configurable
 	for i := 0; i < m.Spec.Members; i++ {
-		hosts[i] = fmt.Sprintf("%s-%d.%s.%s.%s:%d", m.Name, i, m.ServiceName(), m.Namespace, clusterDomain, 27017)
+		hostname, err := m.MemberHostname(i, defaultClusterDomain)
+		if err != nil {
+			// an invalid template is reported during reconciliation, fall back to the default hostname.
+			hostname = m.defaultMemberHostname(i, defaultClusterDomain)
+		}
+		hosts[i] = fmt.Sprintf("%s:%d", hostname, 27017)
 	}
 	return hosts
 }
 
+// HostnameTemplateData contains the values which can be referenced in spec.hostnameTemplate.
+type HostnameTemplateData struct {
+	// PodName is the name of the Pod of the member, e.g. "my-rs-0".
+	PodName string
+	// Index is the ordinal of the member, counted from spec.memberOrdinalStart.
+	Index int
+	// ServiceName is the name of the headless Service backing the replica set.
+	ServiceName string
+	// Namespace is the namespace the resource is deployed in.
+	Namespace string
+	// ClusterDomain is the cluster domain, e.g. "cluster.local".
+	ClusterDomain string
+}
+
+// MemberHostname renders the hostname of the member with the given index using spec.hostnameTemplate.
+// An empty clusterDomain defaults to "cluster.local".
+func (m MongoDBCommunity) MemberHostname(index int, clusterDomain string) (string, error) {
+	if clusterDomain == "" {
+		clusterDomain = defaultClusterDomain
+	}
+	hostnameTemplate := m.Spec.HostnameTemplate
+	if hostnameTemplate == "" {
+		hostnameTemplate = DefaultHostnameTemplate
+	}
+
+	tmpl, err := template.New("hostname").Option("missingkey=error").Parse(hostnameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid hostname template: %s", err)
+	}
+
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, m.hostnameTemplateData(index, clusterDomain)); err != nil {
+		return "", fmt.Errorf("could not render hostname template: %s", err)
+	}
+
+	hostname := strings.TrimSpace(buf.String())
+	if hostname == "" {
+		return "", fmt.Errorf("hostname template rendered an empty hostname for member %d", index)
+	}
+	return hostname, nil
+}
+
+// MemberHostnames renders the hostnames of the first n members using spec.hostnameTemplate.
+func (m MongoDBCommunity) MemberHostnames(n int, clusterDomain string) ([]string, error) {
+	hostnames := make([]string, n)
+	for i := 0; i < n; i++ {
+		hostname, err := m.MemberHostname(i, clusterDomain)
+		if err != nil {
+			return nil, err
+		}
+		hostnames[i] = hostname
+	}
+	return hostnames, nil
+}
+
+func (m MongoDBCommunity) hostnameTemplateData(index int, clusterDomain string) HostnameTemplateData {
+	return HostnameTemplateData{
+		PodName:       m.PodName(index),
+		Index:         m.MemberOrdinal(index),
+		ServiceName:   m.ServiceName(),
+		Namespace:     m.Namespace,
+		ClusterDomain: clusterDomain,
+	}
+}
+
+func (m MongoDBCommunity) defaultMemberHostname(index int, clusterDomain string) string {
+	return fmt.Sprintf("%s.%s.%s.svc.%s", m.PodName(index), m.ServiceName(), m.Namespace, clusterDomain)
+}
+
 // ServiceName returns the name of the Service that should be created for
-// this resource
+// this resource, which is the subdomain of the members
 func (m MongoDBCommunity) ServiceName() string {
+	if m.Spec.PodSubdomain != "" {
+		return m.Spec.PodSubdomain
+	}
 	return m.Name + "-svc"
 }
 
+// StatefulSetName returns the name of the StatefulSet backing this resource, which prefixes the Pod names
+// and hostnames of the members.
+func (m MongoDBCommunity) StatefulSetName() string {
+	if m.Spec.PodHostnamePrefix != "" {
+		return m.Spec.PodHostnamePrefix
+	}
+	return m.Name
+}
+
+// StatefulSetNamespacedName returns the NamespacedName of the StatefulSet backing this resource.
+func (m MongoDBCommunity) StatefulSetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.StatefulSetName(), Namespace: m.Namespace}
+}
+
+// PodName returns the name of the Pod of the member with the given index.
+func (m MongoDBCommunity) PodName(index int) string {
+	return fmt.Sprintf("%s-%d", m.StatefulSetName(), m.MemberOrdinal(index))
+}
+
+// MemberOrdinalStart returns the StatefulSet ordinal of the first member.
+func (m MongoDBCommunity) MemberOrdinalStart() int {
+	return m.Spec.MemberOrdinalStart
+}
+
+// MemberOrdinal returns the StatefulSet ordinal of the member with the given index.
+func (m MongoDBCommunity) MemberOrdinal(index int) int {
+	return m.MemberOrdinalStart() + index
+}
+
 func (m MongoDBCommunity) AutomationConfigSecretName() string {
 	return m.Name + "-config"
 }
 
+// CoordinationLeaseNamespacedName returns the NamespacedName of the Lease which coordinates restarts of the members.
+func (m MongoDBCommunity) CoordinationLeaseNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-coordination", Namespace: m.Namespace}
+}
+
+// IsCoordinationLeaseEnabled returns true if the operator acquires the coordination Lease before restarting members.
+func (m MongoDBCommunity) IsCoordinationLeaseEnabled() bool {
+	return m.Spec.Coordination != nil && m.Spec.Coordination.Lease
+}
+
+// CoordinationLeaseDurationSeconds returns the duration of the coordination Lease.
+func (m MongoDBCommunity) CoordinationLeaseDurationSeconds() int {
+	if m.Spec.Coordination == nil || m.Spec.Coordination.LeaseDurationSeconds == 0 {
+		return 60
+	}
+	return m.Spec.Coordination.LeaseDurationSeconds
+}
+
+// IsPBMEnabled returns true if the Percona Backup for MongoDB agents are deployed.
+func (m MongoDBCommunity) IsPBMEnabled() bool {
+	return m.Spec.PBM != nil && m.Spec.PBM.Enabled
+}
+
+// PBMConfigSecretName returns the name of the Secret storing the configuration applied with "pbm config".
+func (m MongoDBCommunity) PBMConfigSecretName() string {
+	return m.Name + "-pbm-config"
+}
+
+// PBMAgentSecretName returns the name of the Secret storing the connection string of the agent of each member.
+func (m MongoDBCommunity) PBMAgentSecretName() string {
+	return m.Name + "-pbm-agent"
+}
+
+// IsPrometheusEnabled returns true if the mongodb_exporter sidecars are deployed.
+func (m MongoDBCommunity) IsPrometheusEnabled() bool {
+	return m.Spec.Prometheus != nil && m.Spec.Prometheus.Enabled
+}
+
+// PrometheusWebConfigMapName returns the name of the ConfigMap storing the web configuration of the exporters,
+// which enables HTTPS.
+func (m MongoDBCommunity) PrometheusWebConfigMapName() string {
+	return m.Name + "-prometheus-web-config"
+}
+
+// PrometheusRuleName returns the name of the PrometheusRule with the alerts of the replica set.
+func (m MongoDBCommunity) PrometheusRuleName() string {
+	return m.Name + "-alerts"
+}
+
+// ExternalServiceName returns the name of the Service exposing the member with the given index outside of the
+// Kubernetes cluster.
+func (m MongoDBCommunity) ExternalServiceName(index int) string {
+	return m.PodName(index) + "-external"
+}
+
+// ClientServiceName returns the name of the Service with a cluster IP for clients.
+func (m MongoDBCommunity) ClientServiceName() string {
+	return m.Name + "-client"
+}
+
+// NetworkPolicyName returns the name of the NetworkPolicy of the members.
+func (m MongoDBCommunity) NetworkPolicyName() string {
+	return m.Name + "-members"
+}
+
+// PrometheusServiceName returns the name of the Service exposing the exporters, which is also the name of the
+// ServiceMonitor or PodMonitor scraping them.
+func (m MongoDBCommunity) PrometheusServiceName() string {
+	return m.Name + "-prometheus"
+}
+
+// InitializationName returns the name of the Job or of the MongoDBCommunityRestore resource initializing the data
+// of the deployment.
+func (m MongoDBCommunity) InitializationName() string {
+	return m.Name + "-initialization"
+}
+
+// AuditLogForwarderConfigMapName returns the name of the ConfigMap storing the configuration of the audit log forwarder.
+func (m MongoDBCommunity) AuditLogForwarderConfigMapName() string {
+	return m.Name + "-audit-log-forwarder"
+}
+
+// CABundleConfigMapNamespacedName returns the namespaced name of the ConfigMap storing both the previous and
+// the new CA during a CA rotation.
+func (m MongoDBCommunity) CABundleConfigMapNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-ca-bundle", Namespace: m.Namespace}
+}
+
+// ConnectionExamplesConfigMapName returns the name of the ConfigMap storing the examples of connecting
+// applications to the deployment.
+func (m MongoDBCommunity) ConnectionExamplesConfigMapName() string {
+	return m.Name + "-connection-examples"
+}
+
+// DiagnosticsConfigMapName returns the name of the ConfigMap storing the diagnostic data captured at the given time.
+func (m MongoDBCommunity) DiagnosticsConfigMapName(captureTime time.Time) string {
+	return fmt.Sprintf("%s-diagnostics-%d", m.Name, captureTime.Unix())
+}
+
 // TLSConfigMapNamespacedName will get the namespaced name of the ConfigMap containing the CA certificate
 // As the ConfigMap will be mounted to our pods, it has to be in the same namespace as the MongoDB resource
 func (m MongoDBCommunity) TLSConfigMapNamespacedName() types.NamespacedName {
 	return types.NamespacedName{Name: m.Spec.Security.TLS.CaConfigMap.Name, Namespace: m.Namespace}
 }
 
+// TLSCASecretNamespacedName will get the namespaced name of the Secret containing the CA certificate,
+// if the CA certificate is read from a Secret.
+func (m MongoDBCommunity) TLSCASecretNamespacedName() types.NamespacedName {
+	name := ""
+	if m.Spec.Security.TLS.CaCertificateSecret != nil {
+		name = m.Spec.Security.TLS.CaCertificateSecret.Name
+	}
+	return types.NamespacedName{Name: name, Namespace: m.Namespace}
+}
+
+// LDAPBindQueryPasswordSecretNamespacedName will get the namespaced name of the Secret containing
+// the password of the LDAP bind query user.
+func (m MongoDBCommunity) LDAPBindQueryPasswordSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Spec.Security.Authentication.LDAP.BindQueryPasswordSecretRef.Name, Namespace: m.Namespace}
+}
+
+// LDAPConfigMapNamespacedName will get the namespaced name of the ConfigMap containing the CA certificate
+// of the LDAP servers.
+func (m MongoDBCommunity) LDAPConfigMapNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Spec.Security.Authentication.LDAP.CaConfigMap.Name, Namespace: m.Namespace}
+}
+
 // TLSSecretNamespacedName will get the namespaced name of the Secret containing the server certificate and key
 func (m MongoDBCommunity) TLSSecretNamespacedName() types.NamespacedName {
 	return types.NamespacedName{Name: m.Spec.Security.TLS.CertificateKeySecret.Name, Namespace: m.Namespace}
@@ -485,6 +3285,29 @@ func (m MongoDBCommunity) TLSOperatorSecretNamespacedName() types.NamespacedName
 	return types.NamespacedName{Name: m.Name + "-server-certificate-key", Namespace: m.Namespace}
 }
 
+// OperatorClientSecretNamespacedName returns the namespaced name of the Secret containing the client certificate
+// the operator presents to the members.
+func (m MongoDBCommunity) OperatorClientSecretNamespacedName() types.NamespacedName {
+	if !m.Spec.Security.TLS.OperatorClient.HasCertificateKeySecret() {
+		return types.NamespacedName{Namespace: m.Namespace}
+	}
+	return types.NamespacedName{Name: m.Spec.Security.TLS.OperatorClient.CertificateKeySecret.Name, Namespace: m.Namespace}
+}
+
+// TLSMemberSecretNamespacedName will get the namespaced name of the Secret containing the member certificate and key.
+func (m MongoDBCommunity) TLSMemberSecretNamespacedName() types.NamespacedName {
+	if m.Spec.Security.TLS.MemberCertificateSecret == nil {
+		return types.NamespacedName{Namespace: m.Namespace}
+	}
+	return types.NamespacedName{Name: m.Spec.Security.TLS.MemberCertificateSecret.Name, Namespace: m.Namespace}
+}
+
+// TLSMemberOperatorSecretNamespacedName will get the namespaced name of the Secret created by the operator
+// containing the combined member certificate and key.
+func (m MongoDBCommunity) TLSMemberOperatorSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-member-certificate-key", Namespace: m.Namespace}
+}
+
 func (m MongoDBCommunity) NamespacedName() types.NamespacedName {
 	return types.NamespacedName{Name: m.Name, Namespace: m.Namespace}
 }
@@ -516,23 +3339,68 @@ func (m *MongoDBCommunity) StatefulSetReplicasThisReconciliation() int {
 	return scale.ReplicasThisReconciliation(m)
 }
 
+// GetConcurrentChangePolicy returns how a change of the spec is handled while a rollout is in progress.
+func (m MongoDBCommunity) GetConcurrentChangePolicy() ConcurrentChangePolicy {
+	if m.Spec.ConcurrentChangePolicy == "" {
+		return ConcurrentChangeMerge
+	}
+	return m.Spec.ConcurrentChangePolicy
+}
+
 // GetUpdateStrategyType returns the type of RollingUpgradeStrategy that the
 // MongoDB StatefulSet should be configured with.
 func (m MongoDBCommunity) GetUpdateStrategyType() appsv1.StatefulSetUpdateStrategyType {
-	if !m.IsChangingVersion() {
+	if !m.IsChangingVersion() && !m.IsScalingVertically() && !m.Spec.UpdateStrategy.IsOnDelete() {
 		return appsv1.RollingUpdateStatefulSetStrategyType
 	}
 	return appsv1.OnDeleteStatefulSetStrategyType
 }
 
+// DesiredTLSMode returns the TLS mode the members should be configured with once any change
+// of the mode has completed.
+func (m MongoDBCommunity) DesiredTLSMode() automationconfig.TLSMode {
+	return m.Spec.Security.TLS.DesiredMode()
+}
+
+// DesiredClusterAuthMode returns the cluster authentication mode the members should be configured
+// with once any change of the mode has completed.
+func (m MongoDBCommunity) DesiredClusterAuthMode() automationconfig.ClusterAuthMode {
+	return m.Spec.Security.TLS.DesiredClusterAuthMode()
+}
+
+// GetSecurityContextPreset returns the name of the security context preset of the deployment,
+// or an empty string if the security context is configured by the operator's defaults.
+func (m MongoDBCommunity) GetSecurityContextPreset() string {
+	return string(m.Spec.SecurityContextPreset)
+}
+
+// TemporaryDirectory returns the directory the containers of the members write temporary files to.
+func (m MongoDBCommunity) TemporaryDirectory() string {
+	if m.Spec.TemporaryDirectory.Path == "" {
+		return defaultTemporaryDirectory
+	}
+	return path.Clean(m.Spec.TemporaryDirectory.Path)
+}
+
+// TemporaryDirectoryVolumeSource returns the emptyDir volume mounted at the temporary directory.
+func (m MongoDBCommunity) TemporaryDirectoryVolumeSource() corev1.EmptyDirVolumeSource {
+	return corev1.EmptyDirVolumeSource{Medium: m.Spec.TemporaryDirectory.Medium, SizeLimit: m.Spec.TemporaryDirectory.SizeLimit}
+}
+
+// IsScalingVertically returns true while the operator restarts the members in order after the resources or the
+// ephemeral storage of the containers changed.
+func (m MongoDBCommunity) IsScalingVertically() bool {
+	return m.Status.OnDeleteUpdateStrategy != nil && m.Status.OnDeleteUpdateStrategy.Reason == OnDeleteUpdateStrategyReasonVerticalScaling
+}
+
 // IsChangingVersion returns true if an attempted version change is occurring.
 func (m MongoDBCommunity) IsChangingVersion() bool {
-	prevVersion := m.getPreviousVersion()
+	prevVersion := m.GetPreviousVersion()
 	return prevVersion != "" && prevVersion != m.Spec.Version
 }
 
 // GetPreviousVersion returns the last MDB version the statefulset was configured with.
-func (m MongoDBCommunity) getPreviousVersion() string {
+func (m MongoDBCommunity) GetPreviousVersion() string {
 	return annotations.GetAnnotation(&m, annotations.LastAppliedMongoDBVersion)
 }
 
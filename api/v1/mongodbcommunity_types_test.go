@@ -16,6 +16,46 @@ func TestMongoDB_MongoURI(t *testing.T) {
 	assert.Equal(t, mdb.MongoURI(), "mongodb://my-big-rs-0.my-big-rs-svc.my-big-namespace.svc.cluster.local:27017,my-big-rs-1.my-big-rs-svc.my-big-namespace.svc.cluster.local:27017,my-big-rs-2.my-big-rs-svc.my-big-namespace.svc.cluster.local:27017,my-big-rs-3.my-big-rs-svc.my-big-namespace.svc.cluster.local:27017,my-big-rs-4.my-big-rs-svc.my-big-namespace.svc.cluster.local:27017")
 }
 
+func TestMongoDB_MemberHostname(t *testing.T) {
+	t.Run("Default template", func(t *testing.T) {
+		mdb := newReplicaSet(3, "my-rs", "my-namespace")
+		hostname, err := mdb.MemberHostname(1, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-rs-1.my-rs-svc.my-namespace.svc.cluster.local", hostname)
+
+		hostname, err = mdb.MemberHostname(1, "my.domain")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-rs-1.my-rs-svc.my-namespace.svc.my.domain", hostname)
+	})
+	t.Run("Custom template", func(t *testing.T) {
+		mdb := newReplicaSet(2, "my-rs", "my-namespace")
+		mdb.Spec.HostnameTemplate = "mongo-{{.Index}}.{{.Namespace}}.example.com"
+		hostnames, err := mdb.MemberHostnames(2, "")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"mongo-0.my-namespace.example.com", "mongo-1.my-namespace.example.com"}, hostnames)
+		assert.Equal(t, "mongodb://mongo-0.my-namespace.example.com:27017,mongo-1.my-namespace.example.com:27017", mdb.MongoURI())
+	})
+	t.Run("Pod subdomain and hostname prefix", func(t *testing.T) {
+		mdb := newReplicaSet(2, "my-rs", "my-namespace")
+		mdb.Spec.PodSubdomain = "mongo"
+		mdb.Spec.PodHostnamePrefix = "db"
+		hostnames, err := mdb.MemberHostnames(2, "")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"db-0.mongo.my-namespace.svc.cluster.local", "db-1.mongo.my-namespace.svc.cluster.local"}, hostnames)
+		assert.Equal(t, "db", mdb.StatefulSetName())
+		assert.Equal(t, "mongo", mdb.ServiceName())
+	})
+	t.Run("Invalid template", func(t *testing.T) {
+		mdb := newReplicaSet(1, "my-rs", "my-namespace")
+		for _, tmpl := range []string{"{{.PodName", "{{.Unknown}}", "  "} {
+			mdb.Spec.HostnameTemplate = tmpl
+			_, err := mdb.MemberHostname(0, "")
+			assert.Error(t, err, tmpl)
+		}
+		assert.Equal(t, "mongodb://my-rs-0.my-rs-svc.my-namespace.svc.cluster.local:27017", mdb.MongoURI())
+	})
+}
+
 func TestGetScramCredentialsSecretName(t *testing.T) {
 	testusers := []struct {
 		in  MongoDBUser
@@ -97,3 +137,10 @@ func newReplicaSet(members int, name, namespace string) MongoDBCommunity {
 		},
 	}
 }
+
+func TestMongoDBUser_GetRoles(t *testing.T) {
+	roles := []Role{{DB: "my-db", Name: "readWrite"}}
+	assert.Equal(t, roles, MongoDBUser{Roles: roles}.GetRoles())
+	assert.Equal(t, []Role{{DB: "admin", Name: "readAnyDatabase"}}, MongoDBUser{ReadOnly: true}.GetRoles())
+	assert.Equal(t, []Role{{DB: "sales", Name: "read"}, {DB: "events", Name: "read"}}, MongoDBUser{ReadOnly: true, ReadOnlyDatabases: []string{"sales", "events"}}.GetRoles())
+}
@@ -0,0 +1,384 @@
+package v1
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MongoDBCommunityBackupSpec defines the desired state of MongoDBCommunityBackup
+type MongoDBCommunityBackupSpec struct {
+	// MongoDBResourceRef references the MongoDBCommunity resource to back up, which must be in the same namespace.
+	MongoDBResourceRef LocalObjectReference `json:"mongodbResourceRef"`
+
+	// User is the name of a user of the MongoDBCommunity resource whose connection string Secret is used to run
+	// mongodump. The user needs the backup role on the admin database. For the Snapshot method, the operator
+	// locks a secondary as this user, which needs the fsync privilege, e.g. from the hostManager role.
+	User string `json:"user"`
+
+	// Method is how the backup is taken, either by dumping the data with mongodump into an archive stored in
+	// S3, by taking a CSI VolumeSnapshot of the data volume of a secondary, or with the Percona Backup for
+	// MongoDB agents of the members, which must be enabled in the MongoDBCommunity resource. Defaults to Dump.
+	// +kubebuilder:validation:Enum=Dump;Snapshot;PBM
+	// +optional
+	Method BackupMethod `json:"method,omitempty"`
+
+	// S3 is the S3-compatible bucket the backup is stored in. Required for the Dump method.
+	// +optional
+	S3 S3BackupTarget `json:"s3,omitempty"`
+
+	// Snapshot configures the VolumeSnapshot taken by the Snapshot method
+	// +optional
+	Snapshot *SnapshotBackupOptions `json:"snapshot,omitempty"`
+
+	// PBM configures the backup taken by the PBM method
+	// +optional
+	PBM *PBMBackupOptions `json:"pbm,omitempty"`
+
+	// Encryption encrypts the archive before it is uploaded to the bucket. Only applies to the Dump method.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+
+	// Image is the image uploading the backup to the bucket, which must contain the AWS CLI.
+	// Defaults to "amazon/aws-cli:2.2.4"
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// BackupMethod is how a backup is taken.
+type BackupMethod string
+
+const (
+	// BackupMethodDump dumps the data with mongodump into an archive stored in S3.
+	BackupMethodDump BackupMethod = "Dump"
+	// BackupMethodSnapshot locks a secondary for writes and takes a CSI VolumeSnapshot of its data volume.
+	BackupMethodSnapshot BackupMethod = "Snapshot"
+	// BackupMethodPBM takes the backup with the Percona Backup for MongoDB agents of the members, which store it
+	// in the storage configured in the MongoDBCommunity resource.
+	BackupMethodPBM BackupMethod = "PBM"
+)
+
+// PBMBackupType is the type of a backup taken with Percona Backup for MongoDB.
+type PBMBackupType string
+
+const (
+	// PBMBackupLogical dumps the data of the members.
+	PBMBackupLogical PBMBackupType = "Logical"
+	// PBMBackupPhysical copies the data files of the members.
+	PBMBackupPhysical PBMBackupType = "Physical"
+	// PBMBackupIncremental copies the data files which changed since the previous incremental backup.
+	PBMBackupIncremental PBMBackupType = "Incremental"
+)
+
+// PBMBackupOptions configures a backup taken with Percona Backup for MongoDB.
+type PBMBackupOptions struct {
+	// Type is the type of the backup. Defaults to Logical
+	// +kubebuilder:validation:Enum=Logical;Physical;Incremental
+	// +optional
+	Type PBMBackupType `json:"type,omitempty"`
+
+	// Base starts a new chain of incremental backups. The first incremental backup must be a base.
+	// +optional
+	Base bool `json:"base,omitempty"`
+}
+
+// GetType returns the type of the backup.
+func (o *PBMBackupOptions) GetType() PBMBackupType {
+	if o == nil || o.Type == "" {
+		return PBMBackupLogical
+	}
+	return o.Type
+}
+
+// SnapshotBackupOptions configures the VolumeSnapshot of a backup.
+type SnapshotBackupOptions struct {
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the VolumeSnapshot, whose driver must be the CSI
+	// driver of the data volumes. Defaults to the default VolumeSnapshotClass of the cluster.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// BackupEncryption configures the client-side encryption of backup artifacts with AES-256-GCM. The key is either
+// read from a Secret, or a data key is generated for each artifact by a KMS, which wraps it with a key that never
+// leaves the KMS. Exactly one of KeySecretRef and KMS must be set.
+type BackupEncryption struct {
+	// KeySecretRef references the key in a Secret, either 32 random bytes or their base64 encoding. The key
+	// defaults to "key".
+	// +optional
+	KeySecretRef *SecretKeyReference `json:"keySecretRef,omitempty"`
+
+	// KMS generates a data key for each artifact and wraps it with one of its keys
+	// +optional
+	KMS *KMSEncryption `json:"kms,omitempty"`
+}
+
+// KMSProvider is a key management service wrapping the data keys of encrypted artifacts.
+type KMSProvider string
+
+const (
+	// KMSProviderAWS is the AWS Key Management Service, which is accessed with the credentials of the bucket.
+	KMSProviderAWS KMSProvider = "AWS"
+)
+
+// KMSEncryption is a key of a key management service.
+type KMSEncryption struct {
+	// Provider is the key management service
+	// +kubebuilder:validation:Enum=AWS
+	Provider KMSProvider `json:"provider"`
+
+	// KeyID is the ID, ARN or alias of the key wrapping the data keys
+	KeyID string `json:"keyId"`
+
+	// Region is the region of the key management service. Defaults to the region of the bucket.
+	// +optional
+	Region string `json:"region,omitempty"`
+}
+
+const defaultEncryptionKeySecretKey = "key"
+
+// GetKeySecretKey returns the key of the Secret containing the encryption key.
+func (e BackupEncryption) GetKeySecretKey() string {
+	if e.KeySecretRef == nil || e.KeySecretRef.Key == "" {
+		return defaultEncryptionKeySecretKey
+	}
+	return e.KeySecretRef.Key
+}
+
+// S3BackupTarget is a bucket of an S3-compatible object storage service.
+type S3BackupTarget struct {
+	// Bucket is the name of the bucket
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the keys of the backup archives, e.g. "backups/"
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Endpoint is the URL of the S3-compatible service, e.g. "https://minio.example.com:9000". Defaults to AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretRef references a Secret containing the access key in the "accessKeyId" key and the
+	// secret key in the "secretAccessKey" key
+	CredentialsSecretRef LocalObjectReference `json:"credentialsSecretRef"`
+}
+
+// Keys of the Secret containing the credentials of an S3-compatible service.
+const (
+	S3AccessKeyIDKey     = "accessKeyId"
+	S3SecretAccessKeyKey = "secretAccessKey"
+)
+
+const defaultBackupUploadImage = "amazon/aws-cli:2.2.4"
+
+// BackupPhase is the progress of a backup.
+type BackupPhase string
+
+const (
+	// BackupPending means the backup waits for the MongoDBCommunity resource to be running.
+	BackupPending BackupPhase = "Pending"
+	// BackupDumping means mongodump is reading the data from a secondary.
+	BackupDumping BackupPhase = "Dumping"
+	// BackupUploading means the archive written by mongodump is uploaded to the bucket.
+	BackupUploading BackupPhase = "Uploading"
+	// BackupSnapshotting means the VolumeSnapshot of a secondary is being taken.
+	BackupSnapshotting BackupPhase = "Snapshotting"
+	// BackupInProgress means the Percona Backup for MongoDB agents are taking the backup.
+	BackupInProgress BackupPhase = "InProgress"
+	// BackupCompleted means the archive is stored in the bucket.
+	BackupCompleted BackupPhase = "Completed"
+	// BackupFailed means the backup could not be taken. The Job is kept so its logs can be inspected.
+	BackupFailed BackupPhase = "Failed"
+)
+
+// MongoDBCommunityBackupStatus defines the observed state of MongoDBCommunityBackup
+type MongoDBCommunityBackupStatus struct {
+	Phase BackupPhase `json:"phase,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// Location is the URL of the backup archive, e.g. "s3://bucket/backups/my-ns/my-rs/my-backup-20210601T120000Z.archive.gz"
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// SizeBytes is the size of the gzip compressed archive written by mongodump.
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// StartTime is when the backup Job was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the archive was stored in the bucket, or the VolumeSnapshot was ready to use.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Snapshot reports the VolumeSnapshot taken by the Snapshot method
+	// +optional
+	Snapshot *BackupSnapshotStatus `json:"snapshot,omitempty"`
+
+	// Encryption reports how the archive was encrypted, which is used to decrypt it when it is restored
+	// +optional
+	Encryption *BackupEncryptionStatus `json:"encryption,omitempty"`
+
+	// PBM reports the backup taken by the PBM method
+	// +optional
+	PBM *BackupPBMStatus `json:"pbm,omitempty"`
+}
+
+// BackupPBMStatus is a backup taken with Percona Backup for MongoDB.
+type BackupPBMStatus struct {
+	// Name is the name of the backup in PBM, which is the time it was started at
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Type is the type of the backup
+	Type PBMBackupType `json:"type"`
+}
+
+// BackupEncryptionStatus is how a backup archive was encrypted. The archive starts with a header containing the
+// same information and, for a KMS, the wrapped data key, so that it can be decrypted without this status.
+type BackupEncryptionStatus struct {
+	// Algorithm is the algorithm the archive is encrypted with
+	Algorithm string `json:"algorithm"`
+
+	// KeySecretRef references the key in a Secret the archive was encrypted with
+	// +optional
+	KeySecretRef *SecretKeyReference `json:"keySecretRef,omitempty"`
+
+	// KeyFingerprint identifies the key of the Secret without revealing it
+	// +optional
+	KeyFingerprint string `json:"keyFingerprint,omitempty"`
+
+	// KMS is the key of the key management service which wrapped the data key of the archive
+	// +optional
+	KMS *KMSEncryption `json:"kms,omitempty"`
+}
+
+// Encryption returns the configuration decrypting the archive.
+func (s BackupEncryptionStatus) Encryption() *BackupEncryption {
+	return &BackupEncryption{KeySecretRef: s.KeySecretRef, KMS: s.KMS}
+}
+
+// BackupSnapshotStatus is the VolumeSnapshot of the data volume of a member.
+type BackupSnapshotStatus struct {
+	// Member is the name of the Pod of the member whose data volume was snapshotted
+	Member string `json:"member"`
+
+	// PersistentVolumeClaimName is the name of the data volume claim of the member
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+
+	// VolumeSnapshotName is the name of the VolumeSnapshot, which is deleted with the backup
+	VolumeSnapshotName string `json:"volumeSnapshotName"`
+
+	// LockTime is when the member was locked for writes. It is unset once the member has been unlocked.
+	// +optional
+	LockTime *metav1.Time `json:"lockTime,omitempty"`
+
+	// SnapshotHandle is the identifier of the snapshot on the storage system
+	// +optional
+	SnapshotHandle string `json:"snapshotHandle,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=mongodbcommunitybackup,scope=Namespaced,shortName=mdbcbackup,singular=mongodbcommunitybackup
+// +kubebuilder:printcolumn:name="Resource",type="string",JSONPath=".spec.mongodbResourceRef.name",description="MongoDBCommunity resource being backed up"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Progress of the backup"
+// +kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".status.sizeBytes",description="Size of the backup archive in bytes"
+// +kubebuilder:printcolumn:name="Location",type="string",JSONPath=".status.location",description="Location of the backup archive"
+
+// MongoDBCommunityBackup is a backup of a MongoDBCommunity resource taken with mongodump.
+type MongoDBCommunityBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBCommunityBackupSpec   `json:"spec,omitempty"`
+	Status MongoDBCommunityBackupStatus `json:"status,omitempty"`
+}
+
+func (b MongoDBCommunityBackup) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: b.Name, Namespace: b.Namespace}
+}
+
+// MongoDBResourceNamespacedName returns the namespaced name of the MongoDBCommunity resource being backed up.
+func (b MongoDBCommunityBackup) MongoDBResourceNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: b.Spec.MongoDBResourceRef.Name, Namespace: b.Namespace}
+}
+
+// CredentialsSecretNamespacedName returns the namespaced name of the Secret with the credentials of the bucket.
+func (b MongoDBCommunityBackup) CredentialsSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: b.Spec.S3.CredentialsSecretRef.Name, Namespace: b.Namespace}
+}
+
+// JobName returns the name of the Job taking the backup.
+func (b MongoDBCommunityBackup) JobName() string {
+	return fmt.Sprintf("%s-backup", b.Name)
+}
+
+// GetImage returns the image uploading the backup.
+func (b MongoDBCommunityBackup) GetImage() string {
+	if b.Spec.Image == "" {
+		return defaultBackupUploadImage
+	}
+	return b.Spec.Image
+}
+
+// GetMethod returns how the backup is taken.
+func (b MongoDBCommunityBackup) GetMethod() BackupMethod {
+	if b.Spec.Method == "" {
+		return BackupMethodDump
+	}
+	return b.Spec.Method
+}
+
+// VolumeSnapshotName returns the name of the VolumeSnapshot taken by the Snapshot method.
+func (b MongoDBCommunityBackup) VolumeSnapshotName() string {
+	return b.Name
+}
+
+// ArchiveKey returns the key of the backup archive in the bucket. It includes the creation time of the backup,
+// so that a backup which is deleted and created again with the same name does not overwrite the earlier archive.
+func (b MongoDBCommunityBackup) ArchiveKey() string {
+	name := fmt.Sprintf("%s-%s.archive.gz", b.Name, b.CreationTimestamp.UTC().Format("20060102T150405Z"))
+	return strings.TrimPrefix(path.Join(b.Spec.S3.Prefix, b.Namespace, b.Spec.MongoDBResourceRef.Name, name), "/")
+}
+
+// ArchiveLocation returns the URL of the backup archive.
+func (b MongoDBCommunityBackup) ArchiveLocation() string {
+	return fmt.Sprintf("s3://%s/%s", b.Spec.S3.Bucket, b.ArchiveKey())
+}
+
+func (b MongoDBCommunityBackup) GetOwnerReferences() []metav1.OwnerReference {
+	ownerReference := *metav1.NewControllerRef(&b, schema.GroupVersionKind{
+		Group:   GroupVersion.Group,
+		Version: GroupVersion.Version,
+		Kind:    b.Kind,
+	})
+	return []metav1.OwnerReference{ownerReference}
+}
+
+// IsFinished returns true if the backup has completed or failed.
+func (b MongoDBCommunityBackup) IsFinished() bool {
+	return b.Status.Phase == BackupCompleted || b.Status.Phase == BackupFailed
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBCommunityBackupList contains a list of MongoDBCommunityBackup
+type MongoDBCommunityBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBCommunityBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBCommunityBackup{}, &MongoDBCommunityBackupList{})
+}
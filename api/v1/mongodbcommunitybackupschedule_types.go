@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// BackupScheduleLabel is set on the MongoDBCommunityBackup resources created by a MongoDBCommunityBackupSchedule
+// resource, with the name of the schedule as its value.
+const BackupScheduleLabel = "mongodbcommunity.mongodb.com/backup-schedule"
+
+// MongoDBCommunityBackupScheduleSpec defines the desired state of MongoDBCommunityBackupSchedule
+type MongoDBCommunityBackupScheduleSpec struct {
+	// Schedule is a cron expression in the standard five field format, e.g. "0 2 * * *" to back up
+	// every day at 02:00 UTC
+	Schedule string `json:"schedule"`
+
+	// Suspend stops the creation of new backups and the capture of the oplog. Expired backups are still deleted.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Retention configures when backups are deleted together with their archive
+	// +optional
+	Retention BackupRetention `json:"retention,omitempty"`
+
+	// Template is the spec of the MongoDBCommunityBackup resources created on schedule
+	Template MongoDBCommunityBackupSpec `json:"template"`
+
+	// Oplog enables the continuous capture of the oplog into the bucket of the backups, which allows restoring
+	// the data as it was at any time after the first backup.
+	// +optional
+	Oplog *OplogCapture `json:"oplog,omitempty"`
+}
+
+// OplogCapture configures the capture of the oplog between backups.
+type OplogCapture struct {
+	// Interval is how often the oplog entries written since the last capture are uploaded as a slice, e.g. "10m".
+	// The oplog of the members must hold the entries of at least one interval.
+	Interval metav1.Duration `json:"interval"`
+}
+
+// BackupRetention configures which completed backups are kept. A completed backup is deleted when it is either
+// beyond the count or older than the maximum age. Failed backups are deleted once a later backup has completed.
+type BackupRetention struct {
+	// Count is the number of most recent completed backups which are kept
+	// +optional
+	Count *int `json:"count,omitempty"`
+
+	// MaxAge is how long completed backups are kept after they were completed, e.g. "168h"
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// MongoDBCommunityBackupScheduleStatus defines the observed state of MongoDBCommunityBackupSchedule
+type MongoDBCommunityBackupScheduleStatus struct {
+	Message string `json:"message,omitempty"`
+
+	// LastScheduleTime is the time at which the last backup was scheduled
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime is the time at which the next backup will be created
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// LastSuccessfulBackup is the name of the most recent completed backup
+	// +optional
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+
+	// Oplog reports the range of the captured oplog
+	// +optional
+	Oplog *OplogCaptureStatus `json:"oplog,omitempty"`
+}
+
+// OplogCaptureStatus is the range of the oplog which has been captured into the bucket without gaps.
+type OplogCaptureStatus struct {
+	// CapturedSince is the start of the first captured slice
+	// +optional
+	CapturedSince *metav1.Time `json:"capturedSince,omitempty"`
+
+	// CapturedUntil is the end of the last captured slice. The data can be restored as it was at any time
+	// between the first backup completed after CapturedSince and CapturedUntil.
+	// +optional
+	CapturedUntil *metav1.Time `json:"capturedUntil,omitempty"`
+
+	// CapturingUntil is the end of the slice being captured
+	// +optional
+	CapturingUntil *metav1.Time `json:"capturingUntil,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=mongodbcommunitybackupschedule,scope=Namespaced,shortName=mdbcbackupschedule,singular=mongodbcommunitybackupschedule
+// +kubebuilder:printcolumn:name="Resource",type="string",JSONPath=".spec.template.mongodbResourceRef.name",description="MongoDBCommunity resource being backed up"
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="Cron expression of the schedule"
+// +kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime",description="When the last backup was scheduled"
+// +kubebuilder:printcolumn:name="Last Successful Backup",type="string",JSONPath=".status.lastSuccessfulBackup",description="The most recent completed backup"
+
+// MongoDBCommunityBackupSchedule creates MongoDBCommunityBackup resources on a cron schedule and deletes the
+// backups which expired according to its retention.
+type MongoDBCommunityBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBCommunityBackupScheduleSpec   `json:"spec,omitempty"`
+	Status MongoDBCommunityBackupScheduleStatus `json:"status,omitempty"`
+}
+
+func (s MongoDBCommunityBackupSchedule) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: s.Name, Namespace: s.Namespace}
+}
+
+// BackupName returns the name of the backup created for the given scheduled time. The name is derived from the
+// time, so that a backup is created only once for each scheduled time.
+func (s MongoDBCommunityBackupSchedule) BackupName(scheduledTime time.Time) string {
+	return fmt.Sprintf("%s-%d", s.Name, scheduledTime.Unix()/60)
+}
+
+// OplogLocation returns the URL of the directory of the bucket the oplog slices are uploaded to.
+func (s MongoDBCommunityBackupSchedule) OplogLocation() string {
+	key := strings.TrimPrefix(path.Join(s.Spec.Template.S3.Prefix, s.Namespace, s.Spec.Template.MongoDBResourceRef.Name, "oplog", s.Name), "/")
+	return fmt.Sprintf("s3://%s/%s/", s.Spec.Template.S3.Bucket, key)
+}
+
+// OplogSliceLocation returns the URL of the slice containing the oplog entries from the given time up to, but
+// excluding, the given end. The times are encoded in the name in seconds since the epoch, so that the slices
+// covering a time range can be found by their name.
+func (s MongoDBCommunityBackupSchedule) OplogSliceLocation(from, until time.Time) string {
+	return fmt.Sprintf("%s%d-%d.bson.gz", s.OplogLocation(), from.Unix(), until.Unix())
+}
+
+// OplogJobName returns the name of the Job capturing the oplog slice ending at the given time.
+func (s MongoDBCommunityBackupSchedule) OplogJobName(until time.Time) string {
+	return fmt.Sprintf("%s-oplog-%d", s.Name, until.Unix())
+}
+
+func (s MongoDBCommunityBackupSchedule) GetOwnerReferences() []metav1.OwnerReference {
+	ownerReference := *metav1.NewControllerRef(&s, schema.GroupVersionKind{
+		Group:   GroupVersion.Group,
+		Version: GroupVersion.Version,
+		Kind:    s.Kind,
+	})
+	return []metav1.OwnerReference{ownerReference}
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBCommunityBackupScheduleList contains a list of MongoDBCommunityBackupSchedule
+type MongoDBCommunityBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBCommunityBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBCommunityBackupSchedule{}, &MongoDBCommunityBackupScheduleList{})
+}
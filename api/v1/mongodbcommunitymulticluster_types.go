@@ -0,0 +1,313 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// MultiClusterLabel is set on the objects created in the member clusters of a MongoDBCommunityMultiCluster
+	// resource, with the name of the resource as its value.
+	MultiClusterLabel = "mongodbcommunity.mongodb.com/multi-cluster"
+
+	// KubeconfigKey is the key of the kubeconfig in the Secret referenced by a member cluster.
+	KubeconfigKey = "kubeconfig"
+)
+
+// MongoDBCommunityMultiClusterSpec defines the desired state of MongoDBCommunityMultiCluster
+type MongoDBCommunityMultiClusterSpec struct {
+	// Version defines which version of MongoDB will be used
+	Version string `json:"version"`
+
+	// FeatureCompatibilityVersion configures the feature compatibility version that will
+	// be set for the deployment
+	// +optional
+	FeatureCompatibilityVersion string `json:"featureCompatibilityVersion,omitempty"`
+
+	// ReplicaSetName is the name of the replica set. Defaults to metadata.name.
+	// +optional
+	ReplicaSetName string `json:"replicaSetName,omitempty"`
+
+	// Clusters are the Kubernetes clusters the members of the replica set are deployed to. The members of
+	// all clusters form a single replica set.
+	// +kubebuilder:validation:MinItems=1
+	Clusters []MemberCluster `json:"clusters"`
+
+	// Users specifies the MongoDB users that should be configured in your deployment. Their password
+	// Secrets are read from the namespace of the resource, in the cluster the operator runs in.
+	// +required
+	Users []MongoDBUser `json:"users"`
+}
+
+// MemberCluster is a Kubernetes cluster members of a MongoDBCommunityMultiCluster resource are deployed to.
+// The members are deployed to the namespace of the resource, which must exist in the cluster.
+type MemberCluster struct {
+	// Name identifies the cluster. The StatefulSet of its members and their Pods are named
+	// "<metadata.name>-<name>", and the headless Service "<metadata.name>-<name>-svc".
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+
+	// Members is the number of members deployed to the cluster.
+	// +kubebuilder:validation:Minimum=0
+	Members int `json:"members"`
+
+	// KubeconfigSecretRef references a Secret in the namespace of the resource, whose "kubeconfig" key
+	// stores the kubeconfig used to access the cluster. The members are deployed to the cluster the
+	// operator runs in if it is not set.
+	// +optional
+	KubeconfigSecretRef *LocalObjectReference `json:"kubeconfigSecretRef,omitempty"`
+
+	// ClusterDomain is the cluster domain of the cluster, which the hostnames of its members end with.
+	// Defaults to "cluster.local". A distinct domain per cluster lets the DNS servers of the other
+	// clusters forward the lookups of the hostnames of its members to the DNS server of the cluster.
+	// +optional
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+}
+
+// MongoDBCommunityMultiClusterStatus defines the observed state of MongoDBCommunityMultiCluster
+type MongoDBCommunityMultiClusterStatus struct {
+	MongoURI string `json:"mongoUri"`
+	Phase    Phase  `json:"phase"`
+
+	Message string `json:"message,omitempty"`
+
+	// Clusters reports the members of each cluster.
+	// +optional
+	Clusters []MemberClusterStatus `json:"clusters,omitempty"`
+}
+
+// MemberClusterStatus reports the members of a member cluster.
+type MemberClusterStatus struct {
+	// Name is the name of the cluster.
+	Name string `json:"name"`
+
+	// Members is the number of members of the cluster which are part of the replica set configuration.
+	Members int `json:"members"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=mongodbcommunitymulticluster,scope=Namespaced,shortName=mdbcmc,singular=mongodbcommunitymulticluster
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Current state of the MongoDB deployment"
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.version",description="Version of MongoDB server"
+
+// MongoDBCommunityMultiCluster is a MongoDB replica set whose members are distributed across several
+// Kubernetes clusters.
+type MongoDBCommunityMultiCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBCommunityMultiClusterSpec   `json:"spec,omitempty"`
+	Status MongoDBCommunityMultiClusterStatus `json:"status,omitempty"`
+}
+
+func (m MongoDBCommunityMultiCluster) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name, Namespace: m.Namespace}
+}
+
+// GetReplicaSetName returns the name of the replica set, which defaults to the name of the resource.
+func (m MongoDBCommunityMultiCluster) GetReplicaSetName() string {
+	if m.Spec.ReplicaSetName != "" {
+		return m.Spec.ReplicaSetName
+	}
+	return m.Name
+}
+
+func (m MongoDBCommunityMultiCluster) AutomationConfigSecretName() string {
+	return m.Name + "-config"
+}
+
+func (m MongoDBCommunityMultiCluster) GetAgentPasswordSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-agent-password", Namespace: m.Namespace}
+}
+
+func (m MongoDBCommunityMultiCluster) GetAgentKeyfileSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: m.Name + "-keyfile", Namespace: m.Namespace}
+}
+
+func (m MongoDBCommunityMultiCluster) GetOwnerReferences() []metav1.OwnerReference {
+	ownerReference := *metav1.NewControllerRef(&m, schema.GroupVersionKind{
+		Group:   GroupVersion.Group,
+		Version: GroupVersion.Version,
+		Kind:    m.Kind,
+	})
+	return []metav1.OwnerReference{ownerReference}
+}
+
+func (m MongoDBCommunityMultiCluster) GetScramOptions() scram.Options {
+	return scram.Options{
+		AuthoritativeSet:   false,
+		KeyFile:            scram.AutomationAgentKeyFilePathInContainer,
+		AutoAuthMechanisms: []string{scram.Sha256},
+		AgentName:          scram.AgentName,
+		AutoAuthMechanism:  scram.Sha256,
+	}
+}
+
+// GetScramUsers converts all of the users from the spec into users
+// that can be used to configure scram authentication.
+func (m MongoDBCommunityMultiCluster) GetScramUsers() []scram.User {
+	users := make([]scram.User, len(m.Spec.Users))
+	for i, u := range m.Spec.Users {
+		userRoles := u.GetRoles()
+		roles := make([]scram.Role, len(userRoles))
+		for j, r := range userRoles {
+			roles[j] = scram.Role{
+				Name:     r.Name,
+				Database: r.DB,
+			}
+		}
+		users[i] = scram.User{
+			Username:                   u.Name,
+			Database:                   u.DB,
+			Roles:                      roles,
+			PasswordSecretKey:          u.GetPasswordSecretKey(),
+			PasswordSecretName:         u.PasswordSecretRef.Name,
+			ScramCredentialsSecretName: u.GetScramCredentialsSecretName(),
+			AuthenticationRestrictions: convertAuthenticationRestrictions(u.AuthenticationRestrictions),
+			ScramSha1:                  u.ScramSha1,
+		}
+	}
+	return users
+}
+
+// ClusterMembers returns the members of the resource in the given cluster.
+func (m MongoDBCommunityMultiCluster) ClusterMembers(cluster MemberCluster) ClusterMembers {
+	return ClusterMembers{mdb: m, cluster: cluster}
+}
+
+// MemberHostnames returns the hostnames of the members of all clusters which are part of the replica set
+// configuration according to the given number of members of each cluster, in the order of the clusters.
+func (m MongoDBCommunityMultiCluster) MemberHostnames(members map[string]int) []string {
+	var hostnames []string
+	for _, cluster := range m.Spec.Clusters {
+		clusterMembers := m.ClusterMembers(cluster)
+		for i := 0; i < members[cluster.Name]; i++ {
+			hostnames = append(hostnames, clusterMembers.MemberHostname(i))
+		}
+	}
+	return hostnames
+}
+
+// MongoURI returns the connection string of the replica set, listing the members of all clusters.
+func (m MongoDBCommunityMultiCluster) MongoURI() string {
+	members := map[string]int{}
+	for _, cluster := range m.Spec.Clusters {
+		members[cluster.Name] = cluster.Members
+	}
+	hosts := m.MemberHostnames(members)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("%s:%d", hosts[i], 27017)
+	}
+	return fmt.Sprintf("mongodb://%s/?replicaSet=%s", strings.Join(hosts, ","), m.GetReplicaSetName())
+}
+
+// +kubebuilder:object:generate=false
+
+// ClusterMembers are the members of a MongoDBCommunityMultiCluster resource in one of its clusters, which are
+// managed by a StatefulSet in that cluster.
+type ClusterMembers struct {
+	mdb     MongoDBCommunityMultiCluster
+	cluster MemberCluster
+}
+
+// ServiceName returns the name of the headless Service governing the StatefulSet in the cluster.
+func (c ClusterMembers) ServiceName() string {
+	return c.StatefulSetName() + "-svc"
+}
+
+func (c ClusterMembers) GetName() string {
+	return c.mdb.Name
+}
+
+// StatefulSetName returns the name of the StatefulSet in the cluster, which prefixes the Pod names and
+// hostnames of its members.
+func (c ClusterMembers) StatefulSetName() string {
+	return fmt.Sprintf("%s-%s", c.mdb.Name, c.cluster.Name)
+}
+
+func (c ClusterMembers) StatefulSetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: c.StatefulSetName(), Namespace: c.mdb.Namespace}
+}
+
+func (c ClusterMembers) GetNamespace() string {
+	return c.mdb.Namespace
+}
+
+func (c ClusterMembers) MemberOrdinalStart() int {
+	return 0
+}
+
+// PodName returns the name of the Pod of the member with the given index in the cluster.
+func (c ClusterMembers) PodName(index int) string {
+	return fmt.Sprintf("%s-%d", c.StatefulSetName(), index)
+}
+
+// MemberHostname returns the hostname of the member with the given index in the cluster.
+func (c ClusterMembers) MemberHostname(index int) string {
+	clusterDomain := c.cluster.ClusterDomain
+	if clusterDomain == "" {
+		clusterDomain = defaultClusterDomain
+	}
+	return fmt.Sprintf("%s.%s.%s.svc.%s", c.PodName(index), c.ServiceName(), c.mdb.Namespace, clusterDomain)
+}
+
+func (c ClusterMembers) GetMongoDBVersion() string {
+	return c.mdb.Spec.Version
+}
+
+func (c ClusterMembers) AutomationConfigSecretName() string {
+	return c.mdb.AutomationConfigSecretName()
+}
+
+func (c ClusterMembers) GetUpdateStrategyType() appsv1.StatefulSetUpdateStrategyType {
+	return appsv1.RollingUpdateStatefulSetStrategyType
+}
+
+func (c ClusterMembers) HasSeparateDataAndLogsVolumes() bool {
+	return true
+}
+
+func (c ClusterMembers) GetAgentKeyfileSecretNamespacedName() types.NamespacedName {
+	return c.mdb.GetAgentKeyfileSecretNamespacedName()
+}
+
+func (c ClusterMembers) DataVolumeName() string {
+	return "data-volume"
+}
+
+func (c ClusterMembers) LogsVolumeName() string {
+	return "logs-volume"
+}
+
+func (c ClusterMembers) GetSecurityContextPreset() string {
+	return ""
+}
+
+func (c ClusterMembers) TemporaryDirectory() string {
+	return defaultTemporaryDirectory
+}
+
+func (c ClusterMembers) TemporaryDirectoryVolumeSource() corev1.EmptyDirVolumeSource {
+	return corev1.EmptyDirVolumeSource{}
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBCommunityMultiClusterList contains a list of MongoDBCommunityMultiCluster
+type MongoDBCommunityMultiClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBCommunityMultiCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBCommunityMultiCluster{}, &MongoDBCommunityMultiClusterList{})
+}
@@ -0,0 +1,207 @@
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RestoreInProgressAnnotation is set on a MongoDBCommunity resource while a MongoDBCommunityRestore resource,
+// whose name is the value of the annotation, restores data into it. The resource is not reconciled meanwhile.
+const RestoreInProgressAnnotation = "mongodbcommunity.mongodb.com/restore-in-progress"
+
+// MongoDBCommunityRestoreSpec defines the desired state of MongoDBCommunityRestore
+type MongoDBCommunityRestoreSpec struct {
+	// MongoDBResourceRef references the MongoDBCommunity resource the data is restored into, which must be in
+	// the same namespace.
+	MongoDBResourceRef LocalObjectReference `json:"mongodbResourceRef"`
+
+	// MongoDBResourceSpec is the spec of the MongoDBCommunity resource which is created if the resource
+	// referenced by MongoDBResourceRef does not exist. The created resource is not deleted with the restore.
+	// +optional
+	MongoDBResourceSpec *MongoDBCommunitySpec `json:"mongodbResourceSpec,omitempty"`
+
+	// User is the name of a user of the MongoDBCommunity resource whose connection string Secret is used to run
+	// mongorestore. The user needs the restore role on the admin database.
+	User string `json:"user"`
+
+	// BackupRef references a completed MongoDBCommunityBackup resource in the same namespace whose archive is
+	// restored. Exactly one of BackupRef, S3, PointInTime and PBM must be set.
+	// +optional
+	BackupRef *LocalObjectReference `json:"backupRef,omitempty"`
+
+	// S3 is the location of an archive written by mongodump in an S3-compatible bucket, which is restored.
+	// +optional
+	S3 *S3RestoreSource `json:"s3,omitempty"`
+
+	// PointInTime restores the data as it was at a given time, from the backups and the oplog captured by a
+	// MongoDBCommunityBackupSchedule resource.
+	// +optional
+	PointInTime *PointInTimeSource `json:"pointInTime,omitempty"`
+
+	// PBM restores a backup, or a point in time, from the storage of the Percona Backup for MongoDB agents of
+	// the MongoDBCommunity resource.
+	// +optional
+	PBM *PBMRestoreSource `json:"pbm,omitempty"`
+
+	// Drop drops each collection before restoring it, so that the restored collections only contain the
+	// documents of the archive.
+	// +optional
+	Drop bool `json:"drop,omitempty"`
+
+	// Image is the image downloading the archive from the bucket, which must contain the AWS CLI.
+	// Defaults to "amazon/aws-cli:2.2.4"
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// S3RestoreSource is an archive in a bucket of an S3-compatible object storage service.
+type S3RestoreSource struct {
+	// Location is the URL of the gzip compressed archive, e.g. "s3://bucket/backups/my-ns/my-rs/my-backup-20210601T120000Z.archive.gz"
+	// +kubebuilder:validation:Pattern=`^s3://.+/.+`
+	Location string `json:"location"`
+
+	// Endpoint is the URL of the S3-compatible service, e.g. "https://minio.example.com:9000". Defaults to AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretRef references a Secret containing the access key in the "accessKeyId" key and the
+	// secret key in the "secretAccessKey" key
+	CredentialsSecretRef LocalObjectReference `json:"credentialsSecretRef"`
+
+	// Encryption is the key the archive was encrypted with. For a KMS, the wrapped data key is read from the
+	// archive and unwrapped with the credentials of the bucket.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+}
+
+// PointInTimeSource is a time to restore the data to, using the backups and the oplog of a schedule.
+type PointInTimeSource struct {
+	// ScheduleRef references the MongoDBCommunityBackupSchedule resource in the same namespace which captures
+	// the oplog
+	ScheduleRef LocalObjectReference `json:"scheduleRef"`
+
+	// TargetTime is the time to restore to. The changes made before this time are restored.
+	TargetTime metav1.Time `json:"targetTime"`
+}
+
+// PBMRestoreSource is a logical backup, or a point in time, restored by Percona Backup for MongoDB. Exactly one
+// of BackupName and TargetTime must be set.
+type PBMRestoreSource struct {
+	// BackupName is the name of the backup in PBM, e.g. "2021-06-01T12:00:00Z"
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+
+	// TargetTime is the time to restore to, from the latest backup taken before it and the oplog slices
+	// uploaded since. Point-in-time recovery must be enabled in the MongoDBCommunity resource.
+	// +optional
+	TargetTime *metav1.Time `json:"targetTime,omitempty"`
+}
+
+// RestorePhase is the progress of a restore.
+type RestorePhase string
+
+const (
+	// RestorePending means the restore waits for the archive or the MongoDBCommunity resource to be available.
+	RestorePending RestorePhase = "Pending"
+	// RestoreRestoring means the archive is being restored. The MongoDBCommunity resource is not reconciled.
+	RestoreRestoring RestorePhase = "Restoring"
+	// RestoreCompleted means the archive has been restored.
+	RestoreCompleted RestorePhase = "Completed"
+	// RestoreFailed means the archive could not be restored. The Job is kept so its logs can be inspected.
+	RestoreFailed RestorePhase = "Failed"
+)
+
+// MongoDBCommunityRestoreStatus defines the observed state of MongoDBCommunityRestore
+type MongoDBCommunityRestoreStatus struct {
+	Phase RestorePhase `json:"phase,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// Location is the URL of the restored archive
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// OplogLocation is the URL of the directory of the oplog slices replayed after restoring the archive
+	// +optional
+	OplogLocation string `json:"oplogLocation,omitempty"`
+
+	// StartTime is when the restore Job was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the archive was restored.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=mongodbcommunityrestore,scope=Namespaced,shortName=mdbcrestore,singular=mongodbcommunityrestore
+// +kubebuilder:printcolumn:name="Resource",type="string",JSONPath=".spec.mongodbResourceRef.name",description="MongoDBCommunity resource being restored"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Progress of the restore"
+// +kubebuilder:printcolumn:name="Location",type="string",JSONPath=".status.location",description="Location of the restored archive"
+
+// MongoDBCommunityRestore restores an archive written by mongodump into a MongoDBCommunity resource.
+type MongoDBCommunityRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBCommunityRestoreSpec   `json:"spec,omitempty"`
+	Status MongoDBCommunityRestoreStatus `json:"status,omitempty"`
+}
+
+func (r MongoDBCommunityRestore) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: r.Name, Namespace: r.Namespace}
+}
+
+// MongoDBResourceNamespacedName returns the namespaced name of the MongoDBCommunity resource being restored.
+func (r MongoDBCommunityRestore) MongoDBResourceNamespacedName() types.NamespacedName {
+	return types.NamespacedName{Name: r.Spec.MongoDBResourceRef.Name, Namespace: r.Namespace}
+}
+
+// JobName returns the name of the Job restoring the archive.
+func (r MongoDBCommunityRestore) JobName() string {
+	return fmt.Sprintf("%s-restore", r.Name)
+}
+
+// GetImage returns the image downloading the archive.
+func (r MongoDBCommunityRestore) GetImage() string {
+	if r.Spec.Image == "" {
+		return defaultBackupUploadImage
+	}
+	return r.Spec.Image
+}
+
+func (r MongoDBCommunityRestore) GetOwnerReferences() []metav1.OwnerReference {
+	ownerReference := *metav1.NewControllerRef(&r, schema.GroupVersionKind{
+		Group:   GroupVersion.Group,
+		Version: GroupVersion.Version,
+		Kind:    r.Kind,
+	})
+	return []metav1.OwnerReference{ownerReference}
+}
+
+// IsFinished returns true if the restore has completed or failed.
+func (r MongoDBCommunityRestore) IsFinished() bool {
+	return r.Status.Phase == RestoreCompleted || r.Status.Phase == RestoreFailed
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBCommunityRestoreList contains a list of MongoDBCommunityRestore
+type MongoDBCommunityRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBCommunityRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBCommunityRestore{}, &MongoDBCommunityRestoreList{})
+}
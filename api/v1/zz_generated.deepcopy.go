@@ -22,9 +22,87 @@ package v1
 
 import (
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLog) DeepCopyInto(out *AuditLog) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLog.
+func (in *AuditLog) DeepCopy() *AuditLog {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogForwarder) DeepCopyInto(out *AuditLogForwarder) {
+	*out = *in
+	if in.Syslog != nil {
+		in, out := &in.Syslog, &out.Syslog
+		*out = new(AuditLogSyslogDestination)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(AuditLogHTTPDestination)
+		**out = **in
+	}
+	if in.RetryLimit != nil {
+		in, out := &in.RetryLimit, &out.RetryLimit
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogForwarder.
+func (in *AuditLogForwarder) DeepCopy() *AuditLogForwarder {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogForwarder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogHTTPDestination) DeepCopyInto(out *AuditLogHTTPDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogHTTPDestination.
+func (in *AuditLogHTTPDestination) DeepCopy() *AuditLogHTTPDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogHTTPDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogSyslogDestination) DeepCopyInto(out *AuditLogSyslogDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogSyslogDestination.
+func (in *AuditLogSyslogDestination) DeepCopy() *AuditLogSyslogDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogSyslogDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Authentication) DeepCopyInto(out *Authentication) {
 	*out = *in
@@ -33,11 +111,36 @@ func (in *Authentication) DeepCopyInto(out *Authentication) {
 		*out = make([]AuthMode, len(*in))
 		copy(*out, *in)
 	}
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(LDAP)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.IgnoreUnknownUsers != nil {
 		in, out := &in.IgnoreUnknownUsers, &out.IgnoreUnknownUsers
 		*out = new(bool)
 		**out = **in
 	}
+	if in.AgentCredentialsSecretRef != nil {
+		in, out := &in.AgentCredentialsSecretRef, &out.AgentCredentialsSecretRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.KeyfileSecretRef != nil {
+		in, out := &in.KeyfileSecretRef, &out.KeyfileSecretRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.CredentialVerification != nil {
+		in, out := &in.CredentialVerification, &out.CredentialVerification
+		*out = new(CredentialVerification)
+		**out = **in
+	}
+	if in.MetricsUser != nil {
+		in, out := &in.MetricsUser, &out.MetricsUser
+		*out = new(MetricsUser)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authentication.
@@ -50,6 +153,31 @@ func (in *Authentication) DeepCopy() *Authentication {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthenticationMigrationStatus) DeepCopyInto(out *AuthenticationMigrationStatus) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]AuthMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]AuthMode, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthenticationMigrationStatus.
+func (in *AuthenticationMigrationStatus) DeepCopy() *AuthenticationMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthenticationMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthenticationRestriction) DeepCopyInto(out *AuthenticationRestriction) {
 	*out = *in
@@ -76,301 +204,2365 @@ func (in *AuthenticationRestriction) DeepCopy() *AuthenticationRestriction {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomRole) DeepCopyInto(out *CustomRole) {
+func (in *BackupEncryption) DeepCopyInto(out *BackupEncryption) {
 	*out = *in
-	if in.Privileges != nil {
-		in, out := &in.Privileges, &out.Privileges
-		*out = make([]Privilege, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
-		*out = make([]Role, len(*in))
-		copy(*out, *in)
+	if in.KeySecretRef != nil {
+		in, out := &in.KeySecretRef, &out.KeySecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
 	}
-	if in.AuthenticationRestrictions != nil {
-		in, out := &in.AuthenticationRestrictions, &out.AuthenticationRestrictions
-		*out = make([]AuthenticationRestriction, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSEncryption)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomRole.
-func (in *CustomRole) DeepCopy() *CustomRole {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryption.
+func (in *BackupEncryption) DeepCopy() *BackupEncryption {
 	if in == nil {
 		return nil
 	}
-	out := new(CustomRole)
+	out := new(BackupEncryption)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+func (in *BackupEncryptionStatus) DeepCopyInto(out *BackupEncryptionStatus) {
 	*out = *in
+	if in.KeySecretRef != nil {
+		in, out := &in.KeySecretRef, &out.KeySecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSEncryption)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
-func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryptionStatus.
+func (in *BackupEncryptionStatus) DeepCopy() *BackupEncryptionStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalObjectReference)
+	out := new(BackupEncryptionStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBCommunity) DeepCopyInto(out *MongoDBCommunity) {
+func (in *BackupPBMStatus) DeepCopyInto(out *BackupPBMStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunity.
-func (in *MongoDBCommunity) DeepCopy() *MongoDBCommunity {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupPBMStatus.
+func (in *BackupPBMStatus) DeepCopy() *BackupPBMStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBCommunity)
+	out := new(BackupPBMStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBCommunity) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetention) DeepCopyInto(out *BackupRetention) {
+	*out = *in
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int)
+		**out = **in
 	}
-	return nil
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetention.
+func (in *BackupRetention) DeepCopy() *BackupRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetention)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBCommunityList) DeepCopyInto(out *MongoDBCommunityList) {
+func (in *BackupSnapshotStatus) DeepCopyInto(out *BackupSnapshotStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]MongoDBCommunity, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.LockTime != nil {
+		in, out := &in.LockTime, &out.LockTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityList.
-func (in *MongoDBCommunityList) DeepCopy() *MongoDBCommunityList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSnapshotStatus.
+func (in *BackupSnapshotStatus) DeepCopy() *BackupSnapshotStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBCommunityList)
+	out := new(BackupSnapshotStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBCommunityList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CARotationStatus) DeepCopyInto(out *CARotationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CARotationStatus.
+func (in *CARotationStatus) DeepCopy() *CARotationStatus {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(CARotationStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBCommunitySpec) DeepCopyInto(out *MongoDBCommunitySpec) {
+func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 	*out = *in
-	if in.ReplicaSetHorizons != nil {
-		in, out := &in.ReplicaSetHorizons, &out.ReplicaSetHorizons
-		*out = make(ReplicaSetHorizonConfiguration, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = make(automationconfig.ReplicaSetHorizons, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
-		}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.ReadySince != nil {
+		in, out := &in.ReadySince, &out.ReadySince
+		*out = (*in).DeepCopy()
 	}
-	in.Security.DeepCopyInto(&out.Security)
-	if in.Users != nil {
-		in, out := &in.Users, &out.Users
-		*out = make([]MongoDBUser, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
-	in.StatefulSetConfiguration.DeepCopyInto(&out.StatefulSetConfiguration)
-	in.AdditionalMongodConfig.DeepCopyInto(&out.AdditionalMongodConfig)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunitySpec.
-func (in *MongoDBCommunitySpec) DeepCopy() *MongoDBCommunitySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryStatus.
+func (in *CanaryStatus) DeepCopy() *CanaryStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBCommunitySpec)
+	out := new(CanaryStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBCommunityStatus) DeepCopyInto(out *MongoDBCommunityStatus) {
+func (in *CanaryUpdate) DeepCopyInto(out *CanaryUpdate) {
 	*out = *in
+	if in.SoakPeriod != nil {
+		in, out := &in.SoakPeriod, &out.SoakPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityStatus.
-func (in *MongoDBCommunityStatus) DeepCopy() *MongoDBCommunityStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryUpdate.
+func (in *CanaryUpdate) DeepCopy() *CanaryUpdate {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBCommunityStatus)
+	out := new(CanaryUpdate)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
+func (in *ChangeStreamVerification) DeepCopyInto(out *ChangeStreamVerification) {
 	*out = *in
-	out.PasswordSecretRef = in.PasswordSecretRef
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
-		*out = make([]Role, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUser.
-func (in *MongoDBUser) DeepCopy() *MongoDBUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeStreamVerification.
+func (in *ChangeStreamVerification) DeepCopy() *ChangeStreamVerification {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBUser)
+	out := new(ChangeStreamVerification)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongodConfiguration) DeepCopyInto(out *MongodConfiguration) {
-	clone := in.DeepCopy()
-	*out = *clone
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Privilege) DeepCopyInto(out *Privilege) {
+func (in *ClientService) DeepCopyInto(out *ClientService) {
 	*out = *in
-	in.Resource.DeepCopyInto(&out.Resource)
-	if in.Actions != nil {
-		in, out := &in.Actions, &out.Actions
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Privilege.
-func (in *Privilege) DeepCopy() *Privilege {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientService.
+func (in *ClientService) DeepCopy() *ClientService {
 	if in == nil {
 		return nil
 	}
-	out := new(Privilege)
+	out := new(ClientService)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in ReplicaSetHorizonConfiguration) DeepCopyInto(out *ReplicaSetHorizonConfiguration) {
-	{
-		in := &in
-		*out = make(ReplicaSetHorizonConfiguration, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = make(automationconfig.ReplicaSetHorizons, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
-		}
-	}
+func (in *ConcurrentChangeStatus) DeepCopyInto(out *ConcurrentChangeStatus) {
+	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSetHorizonConfiguration.
-func (in ReplicaSetHorizonConfiguration) DeepCopy() ReplicaSetHorizonConfiguration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrentChangeStatus.
+func (in *ConcurrentChangeStatus) DeepCopy() *ConcurrentChangeStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ReplicaSetHorizonConfiguration)
+	out := new(ConcurrentChangeStatus)
 	in.DeepCopyInto(out)
-	return *out
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Resource) DeepCopyInto(out *Resource) {
+func (in *ConnectionStringSecret) DeepCopyInto(out *ConnectionStringSecret) {
 	*out = *in
-	if in.DB != nil {
-		in, out := &in.DB, &out.DB
-		*out = new(string)
-		**out = **in
+	if in.AdditionalFormats != nil {
+		in, out := &in.AdditionalFormats, &out.AdditionalFormats
+		*out = make([]ConnectionStringFormat, len(*in))
+		copy(*out, *in)
 	}
-	if in.Collection != nil {
-		in, out := &in.Collection, &out.Collection
-		*out = new(string)
-		**out = **in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resource.
-func (in *Resource) DeepCopy() *Resource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionStringSecret.
+func (in *ConnectionStringSecret) DeepCopy() *ConnectionStringSecret {
 	if in == nil {
 		return nil
 	}
-	out := new(Resource)
+	out := new(ConnectionStringSecret)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Role) DeepCopyInto(out *Role) {
+func (in *Coordination) DeepCopyInto(out *Coordination) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Role.
-func (in *Role) DeepCopy() *Role {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Coordination.
+func (in *Coordination) DeepCopy() *Coordination {
 	if in == nil {
 		return nil
 	}
-	out := new(Role)
+	out := new(Coordination)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+func (in *CredentialVerification) DeepCopyInto(out *CredentialVerification) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
-func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialVerification.
+func (in *CredentialVerification) DeepCopy() *CredentialVerification {
 	if in == nil {
 		return nil
 	}
-	out := new(SecretKeyReference)
+	out := new(CredentialVerification)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Security) DeepCopyInto(out *Security) {
+func (in *CustomRole) DeepCopyInto(out *CustomRole) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]Privilege, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]Role, len(*in))
+		copy(*out, *in)
+	}
+	if in.AuthenticationRestrictions != nil {
+		in, out := &in.AuthenticationRestrictions, &out.AuthenticationRestrictions
+		*out = make([]AuthenticationRestriction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomRole.
+func (in *CustomRole) DeepCopy() *CustomRole {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Diagnostics) DeepCopyInto(out *Diagnostics) {
+	*out = *in
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Diagnostics.
+func (in *Diagnostics) DeepCopy() *Diagnostics {
+	if in == nil {
+		return nil
+	}
+	out := new(Diagnostics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiagnosticsCaptureStatus) DeepCopyInto(out *DiagnosticsCaptureStatus) {
+	*out = *in
+	in.CaptureTime.DeepCopyInto(&out.CaptureTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosticsCaptureStatus.
+func (in *DiagnosticsCaptureStatus) DeepCopy() *DiagnosticsCaptureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DiagnosticsCaptureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrorBudgetStatus) DeepCopyInto(out *ErrorBudgetStatus) {
+	*out = *in
+	out.Window = in.Window
+	in.ObservedSince.DeepCopyInto(&out.ObservedSince)
+	out.NonRunningDuration = in.NonRunningDuration
+	if in.Periods != nil {
+		in, out := &in.Periods, &out.Periods
+		*out = make([]NonRunningPeriod, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorBudgetStatus.
+func (in *ErrorBudgetStatus) DeepCopy() *ErrorBudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorBudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalAccess) DeepCopyInto(out *ExternalAccess) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Host != nil {
+		in, out := &in.Host, &out.Host
+		*out = new(HostAccess)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalAccess.
+func (in *ExternalAccess) DeepCopy() *ExternalAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalAddress) DeepCopyInto(out *ExternalAddress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalAddress.
+func (in *ExternalAddress) DeepCopy() *ExternalAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMember) DeepCopyInto(out *ExternalMember) {
+	*out = *in
+	if in.Votes != nil {
+		in, out := &in.Votes, &out.Votes
+		*out = new(int)
+		**out = **in
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int)
+		**out = **in
+	}
+	if in.Horizons != nil {
+		in, out := &in.Horizons, &out.Horizons
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ExternalMemberTLS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMember.
+func (in *ExternalMember) DeepCopy() *ExternalMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMemberTLS) DeepCopyInto(out *ExternalMemberTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMemberTLS.
+func (in *ExternalMemberTLS) DeepCopy() *ExternalMemberTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMemberTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForceReconfig) DeepCopyInto(out *ForceReconfig) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForceReconfig.
+func (in *ForceReconfig) DeepCopy() *ForceReconfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ForceReconfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForceReconfigStatus) DeepCopyInto(out *ForceReconfigStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForceReconfigStatus.
+func (in *ForceReconfigStatus) DeepCopy() *ForceReconfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ForceReconfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAccess) DeepCopyInto(out *HostAccess) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAccess.
+func (in *HostAccess) DeepCopy() *HostAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostnameTemplateData) DeepCopyInto(out *HostnameTemplateData) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostnameTemplateData.
+func (in *HostnameTemplateData) DeepCopy() *HostnameTemplateData {
+	if in == nil {
+		return nil
+	}
+	out := new(HostnameTemplateData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Initialization) DeepCopyInto(out *Initialization) {
+	*out = *in
+	if in.ScriptsConfigMapRef != nil {
+		in, out := &in.ScriptsConfigMapRef, &out.ScriptsConfigMapRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.BackupRef != nil {
+		in, out := &in.BackupRef, &out.BackupRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Initialization.
+func (in *Initialization) DeepCopy() *Initialization {
+	if in == nil {
+		return nil
+	}
+	out := new(Initialization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InitializationStatus) DeepCopyInto(out *InitializationStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InitializationStatus.
+func (in *InitializationStatus) DeepCopy() *InitializationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InitializationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSEncryption) DeepCopyInto(out *KMSEncryption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSEncryption.
+func (in *KMSEncryption) DeepCopy() *KMSEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(KMSEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyfileRotation) DeepCopyInto(out *KeyfileRotation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyfileRotation.
+func (in *KeyfileRotation) DeepCopy() *KeyfileRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyfileRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyfileRotationStatus) DeepCopyInto(out *KeyfileRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyfileRotationStatus.
+func (in *KeyfileRotationStatus) DeepCopy() *KeyfileRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyfileRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAP) DeepCopyInto(out *LDAP) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CaConfigMap != nil {
+		in, out := &in.CaConfigMap, &out.CaConfigMap
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	out.BindQueryPasswordSecretRef = in.BindQueryPasswordSecretRef
+	if in.ValidateLDAPServerConfig != nil {
+		in, out := &in.ValidateLDAPServerConfig, &out.ValidateLDAPServerConfig
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAP.
+func (in *LDAP) DeepCopy() *LDAP {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LastReconcileStatus) DeepCopyInto(out *LastReconcileStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.QueueWait = in.QueueWait
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LastReconcileStatus.
+func (in *LastReconcileStatus) DeepCopy() *LastReconcileStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LastReconcileStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ListenersConfiguration) DeepCopyInto(out *ListenersConfiguration) {
+	*out = *in
+	if in.AdditionalAddresses != nil {
+		in, out := &in.AdditionalAddresses, &out.AdditionalAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ListenersConfiguration.
+func (in *ListenersConfiguration) DeepCopy() *ListenersConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ListenersConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
+func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRotate) DeepCopyInto(out *LogRotate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRotate.
+func (in *LogRotate) DeepCopy() *LogRotate {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRotate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberCluster) DeepCopyInto(out *MemberCluster) {
+	*out = *in
+	if in.KubeconfigSecretRef != nil {
+		in, out := &in.KubeconfigSecretRef, &out.KubeconfigSecretRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberCluster.
+func (in *MemberCluster) DeepCopy() *MemberCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberClusterStatus) DeepCopyInto(out *MemberClusterStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberClusterStatus.
+func (in *MemberClusterStatus) DeepCopy() *MemberClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberHealth) DeepCopyInto(out *MemberHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberHealth.
+func (in *MemberHealth) DeepCopy() *MemberHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberStatus) DeepCopyInto(out *MemberStatus) {
+	*out = *in
+	if in.ReplicationLag != nil {
+		in, out := &in.ReplicationLag, &out.ReplicationLag
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LastHeartbeat != nil {
+		in, out := &in.LastHeartbeat, &out.LastHeartbeat
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberStatus.
+func (in *MemberStatus) DeepCopy() *MemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberVersion) DeepCopyInto(out *MemberVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberVersion.
+func (in *MemberVersion) DeepCopy() *MemberVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsUser) DeepCopyInto(out *MetricsUser) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsUser.
+func (in *MetricsUser) DeepCopy() *MetricsUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunity) DeepCopyInto(out *MongoDBCommunity) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunity.
+func (in *MongoDBCommunity) DeepCopy() *MongoDBCommunity {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunity) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackup) DeepCopyInto(out *MongoDBCommunityBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackup.
+func (in *MongoDBCommunityBackup) DeepCopy() *MongoDBCommunityBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupList) DeepCopyInto(out *MongoDBCommunityBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBCommunityBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupList.
+func (in *MongoDBCommunityBackupList) DeepCopy() *MongoDBCommunityBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupSchedule) DeepCopyInto(out *MongoDBCommunityBackupSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupSchedule.
+func (in *MongoDBCommunityBackupSchedule) DeepCopy() *MongoDBCommunityBackupSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityBackupSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupScheduleList) DeepCopyInto(out *MongoDBCommunityBackupScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBCommunityBackupSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupScheduleList.
+func (in *MongoDBCommunityBackupScheduleList) DeepCopy() *MongoDBCommunityBackupScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityBackupScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupScheduleSpec) DeepCopyInto(out *MongoDBCommunityBackupScheduleSpec) {
+	*out = *in
+	in.Retention.DeepCopyInto(&out.Retention)
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Oplog != nil {
+		in, out := &in.Oplog, &out.Oplog
+		*out = new(OplogCapture)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupScheduleSpec.
+func (in *MongoDBCommunityBackupScheduleSpec) DeepCopy() *MongoDBCommunityBackupScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupScheduleStatus) DeepCopyInto(out *MongoDBCommunityBackupScheduleStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Oplog != nil {
+		in, out := &in.Oplog, &out.Oplog
+		*out = new(OplogCaptureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupScheduleStatus.
+func (in *MongoDBCommunityBackupScheduleStatus) DeepCopy() *MongoDBCommunityBackupScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupSpec) DeepCopyInto(out *MongoDBCommunityBackupSpec) {
+	*out = *in
+	out.MongoDBResourceRef = in.MongoDBResourceRef
+	out.S3 = in.S3
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(SnapshotBackupOptions)
+		**out = **in
+	}
+	if in.PBM != nil {
+		in, out := &in.PBM, &out.PBM
+		*out = new(PBMBackupOptions)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupSpec.
+func (in *MongoDBCommunityBackupSpec) DeepCopy() *MongoDBCommunityBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityBackupStatus) DeepCopyInto(out *MongoDBCommunityBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(BackupSnapshotStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryptionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PBM != nil {
+		in, out := &in.PBM, &out.PBM
+		*out = new(BackupPBMStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityBackupStatus.
+func (in *MongoDBCommunityBackupStatus) DeepCopy() *MongoDBCommunityBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityList) DeepCopyInto(out *MongoDBCommunityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBCommunity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityList.
+func (in *MongoDBCommunityList) DeepCopy() *MongoDBCommunityList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityMultiCluster) DeepCopyInto(out *MongoDBCommunityMultiCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityMultiCluster.
+func (in *MongoDBCommunityMultiCluster) DeepCopy() *MongoDBCommunityMultiCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityMultiCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityMultiCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityMultiClusterList) DeepCopyInto(out *MongoDBCommunityMultiClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBCommunityMultiCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityMultiClusterList.
+func (in *MongoDBCommunityMultiClusterList) DeepCopy() *MongoDBCommunityMultiClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityMultiClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityMultiClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityMultiClusterSpec) DeepCopyInto(out *MongoDBCommunityMultiClusterSpec) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]MemberCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]MongoDBUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityMultiClusterSpec.
+func (in *MongoDBCommunityMultiClusterSpec) DeepCopy() *MongoDBCommunityMultiClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityMultiClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityMultiClusterStatus) DeepCopyInto(out *MongoDBCommunityMultiClusterStatus) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]MemberClusterStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityMultiClusterStatus.
+func (in *MongoDBCommunityMultiClusterStatus) DeepCopy() *MongoDBCommunityMultiClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityMultiClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityRestore) DeepCopyInto(out *MongoDBCommunityRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityRestore.
+func (in *MongoDBCommunityRestore) DeepCopy() *MongoDBCommunityRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityRestoreList) DeepCopyInto(out *MongoDBCommunityRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBCommunityRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityRestoreList.
+func (in *MongoDBCommunityRestoreList) DeepCopy() *MongoDBCommunityRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCommunityRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityRestoreSpec) DeepCopyInto(out *MongoDBCommunityRestoreSpec) {
+	*out = *in
+	out.MongoDBResourceRef = in.MongoDBResourceRef
+	if in.MongoDBResourceSpec != nil {
+		in, out := &in.MongoDBResourceSpec, &out.MongoDBResourceSpec
+		*out = new(MongoDBCommunitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupRef != nil {
+		in, out := &in.BackupRef, &out.BackupRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3RestoreSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PointInTime != nil {
+		in, out := &in.PointInTime, &out.PointInTime
+		*out = new(PointInTimeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PBM != nil {
+		in, out := &in.PBM, &out.PBM
+		*out = new(PBMRestoreSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityRestoreSpec.
+func (in *MongoDBCommunityRestoreSpec) DeepCopy() *MongoDBCommunityRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityRestoreStatus) DeepCopyInto(out *MongoDBCommunityRestoreStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityRestoreStatus.
+func (in *MongoDBCommunityRestoreStatus) DeepCopy() *MongoDBCommunityRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunitySpec) DeepCopyInto(out *MongoDBCommunitySpec) {
+	*out = *in
+	if in.ReplicaSetHorizons != nil {
+		in, out := &in.ReplicaSetHorizons, &out.ReplicaSetHorizons
+		*out = make(ReplicaSetHorizonConfiguration, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(automationconfig.ReplicaSetHorizons, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.ExternalAccess != nil {
+		in, out := &in.ExternalAccess, &out.ExternalAccess
+		*out = new(ExternalAccess)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalMembers != nil {
+		in, out := &in.ExternalMembers, &out.ExternalMembers
+		*out = make([]ExternalMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceMesh != nil {
+		in, out := &in.ServiceMesh, &out.ServiceMesh
+		*out = new(ServiceMesh)
+		**out = **in
+	}
+	in.Security.DeepCopyInto(&out.Security)
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]MongoDBUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Initialization != nil {
+		in, out := &in.Initialization, &out.Initialization
+		*out = new(Initialization)
+		(*in).DeepCopyInto(*out)
+	}
+	in.StatefulSetConfiguration.DeepCopyInto(&out.StatefulSetConfiguration)
+	if in.MongodLivenessProbe != nil {
+		in, out := &in.MongodLivenessProbe, &out.MongodLivenessProbe
+		*out = new(MongodLivenessProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Coordination != nil {
+		in, out := &in.Coordination, &out.Coordination
+		*out = new(Coordination)
+		**out = **in
+	}
+	if in.ScaleDown != nil {
+		in, out := &in.ScaleDown, &out.ScaleDown
+		*out = new(ScaleDownConfiguration)
+		**out = **in
+	}
+	if in.ReplicationLagGate != nil {
+		in, out := &in.ReplicationLagGate, &out.ReplicationLagGate
+		*out = new(ReplicationLagGate)
+		**out = **in
+	}
+	if in.MemberHealth != nil {
+		in, out := &in.MemberHealth, &out.MemberHealth
+		*out = new(MemberHealth)
+		**out = **in
+	}
+	if in.StuckMemberRemediation != nil {
+		in, out := &in.StuckMemberRemediation, &out.StuckMemberRemediation
+		*out = new(StuckMemberRemediation)
+		**out = **in
+	}
+	if in.PodRecovery != nil {
+		in, out := &in.PodRecovery, &out.PodRecovery
+		*out = new(PodRecovery)
+		**out = **in
+	}
+	in.TemporaryDirectory.DeepCopyInto(&out.TemporaryDirectory)
+	in.AdditionalMongodConfig.DeepCopyInto(&out.AdditionalMongodConfig)
+	in.ServerParameters.DeepCopyInto(&out.ServerParameters)
+	if in.SystemLog != nil {
+		in, out := &in.SystemLog, &out.SystemLog
+		*out = new(SystemLog)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuditLogForwarder != nil {
+		in, out := &in.AuditLogForwarder, &out.AuditLogForwarder
+		*out = new(AuditLogForwarder)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ChangeStreamVerification != nil {
+		in, out := &in.ChangeStreamVerification, &out.ChangeStreamVerification
+		*out = new(ChangeStreamVerification)
+		**out = **in
+	}
+	if in.PlannedOutage != nil {
+		in, out := &in.PlannedOutage, &out.PlannedOutage
+		*out = new(PlannedOutage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneAwareness != nil {
+		in, out := &in.ZoneAwareness, &out.ZoneAwareness
+		*out = new(ZoneAwareness)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForceReconfig != nil {
+		in, out := &in.ForceReconfig, &out.ForceReconfig
+		*out = new(ForceReconfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Diagnostics != nil {
+		in, out := &in.Diagnostics, &out.Diagnostics
+		*out = new(Diagnostics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PBM != nil {
+		in, out := &in.PBM, &out.PBM
+		*out = new(PBMConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(Prometheus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(UpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunitySpec.
+func (in *MongoDBCommunitySpec) DeepCopy() *MongoDBCommunitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCommunityStatus) DeepCopyInto(out *MongoDBCommunityStatus) {
+	*out = *in
+	if in.KeyfileRotation != nil {
+		in, out := &in.KeyfileRotation, &out.KeyfileRotation
+		*out = new(KeyfileRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuthenticationMigration != nil {
+		in, out := &in.AuthenticationMigration, &out.AuthenticationMigration
+		*out = new(AuthenticationMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaSetRename != nil {
+		in, out := &in.ReplicaSetRename, &out.ReplicaSetRename
+		*out = new(ReplicaSetRenameStatus)
+		**out = **in
+	}
+	if in.LastCredentialVerificationTime != nil {
+		in, out := &in.LastCredentialVerificationTime, &out.LastCredentialVerificationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReconcile != nil {
+		in, out := &in.LastReconcile, &out.LastReconcile
+		*out = new(LastReconcileStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ErrorBudget != nil {
+		in, out := &in.ErrorBudget, &out.ErrorBudget
+		*out = new(ErrorBudgetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OnDeleteUpdateStrategy != nil {
+		in, out := &in.OnDeleteUpdateStrategy, &out.OnDeleteUpdateStrategy
+		*out = new(OnDeleteUpdateStrategyStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingRestarts != nil {
+		in, out := &in.PendingRestarts, &out.PendingRestarts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExternalAddresses != nil {
+		in, out := &in.ExternalAddresses, &out.ExternalAddresses
+		*out = make([]ExternalAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.CARotation != nil {
+		in, out := &in.CARotation, &out.CARotation
+		*out = new(CARotationStatus)
+		**out = **in
+	}
+	if in.PlannedOutage != nil {
+		in, out := &in.PlannedOutage, &out.PlannedOutage
+		*out = new(PlannedOutageStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForceReconfig != nil {
+		in, out := &in.ForceReconfig, &out.ForceReconfig
+		*out = new(ForceReconfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Initialization != nil {
+		in, out := &in.Initialization, &out.Initialization
+		*out = new(InitializationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(ProgressStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConcurrentChange != nil {
+		in, out := &in.ConcurrentChange, &out.ConcurrentChange
+		*out = new(ConcurrentChangeStatus)
+		**out = **in
+	}
+	if in.TLSCertificates != nil {
+		in, out := &in.TLSCertificates, &out.TLSCertificates
+		*out = new(TLSCertificatesStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]MemberStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StuckMembers != nil {
+		in, out := &in.StuckMembers, &out.StuckMembers
+		*out = make([]StuckMemberStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DiagnosticsCapture != nil {
+		in, out := &in.DiagnosticsCapture, &out.DiagnosticsCapture
+		*out = new(DiagnosticsCaptureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VersionRollout != nil {
+		in, out := &in.VersionRollout, &out.VersionRollout
+		*out = new(VersionRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCommunityStatus.
+func (in *MongoDBCommunityStatus) DeepCopy() *MongoDBCommunityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCommunityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
+	*out = *in
+	out.PasswordSecretRef = in.PasswordSecretRef
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]Role, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReadOnlyDatabases != nil {
+		in, out := &in.ReadOnlyDatabases, &out.ReadOnlyDatabases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.ConnectionStringSecret.DeepCopyInto(&out.ConnectionStringSecret)
+	if in.AuthenticationRestrictions != nil {
+		in, out := &in.AuthenticationRestrictions, &out.AuthenticationRestrictions
+		*out = make([]AuthenticationRestriction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUser.
+func (in *MongoDBUser) DeepCopy() *MongoDBUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongodConfiguration) DeepCopyInto(out *MongodConfiguration) {
+	clone := in.DeepCopy()
+	*out = *clone
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongodLivenessProbe) DeepCopyInto(out *MongodLivenessProbe) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongodLivenessProbe.
+func (in *MongodLivenessProbe) DeepCopy() *MongodLivenessProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(MongodLivenessProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkConfiguration) DeepCopyInto(out *NetworkConfiguration) {
+	*out = *in
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]corev1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = new(ListenersConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfiguration.
+func (in *NetworkConfiguration) DeepCopy() *NetworkConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfiguration) DeepCopyInto(out *NetworkPolicyConfiguration) {
+	*out = *in
+	if in.Clients != nil {
+		in, out := &in.Clients, &out.Clients
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricsClients != nil {
+		in, out := &in.MetricsClients, &out.MetricsClients
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfiguration.
+func (in *NetworkPolicyConfiguration) DeepCopy() *NetworkPolicyConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NonRunningPeriod) DeepCopyInto(out *NonRunningPeriod) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonRunningPeriod.
+func (in *NonRunningPeriod) DeepCopy() *NonRunningPeriod {
+	if in == nil {
+		return nil
+	}
+	out := new(NonRunningPeriod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnDeleteUpdateStrategyStatus) DeepCopyInto(out *OnDeleteUpdateStrategyStatus) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnDeleteUpdateStrategyStatus.
+func (in *OnDeleteUpdateStrategyStatus) DeepCopy() *OnDeleteUpdateStrategyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OnDeleteUpdateStrategyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorClientTLS) DeepCopyInto(out *OperatorClientTLS) {
+	*out = *in
+	if in.CaConfigMap != nil {
+		in, out := &in.CaConfigMap, &out.CaConfigMap
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.CertificateKeySecret != nil {
+		in, out := &in.CertificateKeySecret, &out.CertificateKeySecret
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorClientTLS.
+func (in *OperatorClientTLS) DeepCopy() *OperatorClientTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorClientTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OplogCapture) DeepCopyInto(out *OplogCapture) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OplogCapture.
+func (in *OplogCapture) DeepCopy() *OplogCapture {
+	if in == nil {
+		return nil
+	}
+	out := new(OplogCapture)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OplogCaptureStatus) DeepCopyInto(out *OplogCaptureStatus) {
+	*out = *in
+	if in.CapturedSince != nil {
+		in, out := &in.CapturedSince, &out.CapturedSince
+		*out = (*in).DeepCopy()
+	}
+	if in.CapturedUntil != nil {
+		in, out := &in.CapturedUntil, &out.CapturedUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.CapturingUntil != nil {
+		in, out := &in.CapturingUntil, &out.CapturingUntil
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OplogCaptureStatus.
+func (in *OplogCaptureStatus) DeepCopy() *OplogCaptureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OplogCaptureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PBMBackupOptions) DeepCopyInto(out *PBMBackupOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PBMBackupOptions.
+func (in *PBMBackupOptions) DeepCopy() *PBMBackupOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(PBMBackupOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PBMConfiguration) DeepCopyInto(out *PBMConfiguration) {
+	*out = *in
+	out.Storage = in.Storage
+	if in.PointInTimeRecovery != nil {
+		in, out := &in.PointInTimeRecovery, &out.PointInTimeRecovery
+		*out = new(PBMPointInTimeRecovery)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PBMConfiguration.
+func (in *PBMConfiguration) DeepCopy() *PBMConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PBMConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PBMPointInTimeRecovery) DeepCopyInto(out *PBMPointInTimeRecovery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PBMPointInTimeRecovery.
+func (in *PBMPointInTimeRecovery) DeepCopy() *PBMPointInTimeRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(PBMPointInTimeRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PBMRestoreSource) DeepCopyInto(out *PBMRestoreSource) {
+	*out = *in
+	if in.TargetTime != nil {
+		in, out := &in.TargetTime, &out.TargetTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PBMRestoreSource.
+func (in *PBMRestoreSource) DeepCopy() *PBMRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PBMRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedOutage) DeepCopyInto(out *PlannedOutage) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedOutage.
+func (in *PlannedOutage) DeepCopy() *PlannedOutage {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedOutage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedOutageStatus) DeepCopyInto(out *PlannedOutageStatus) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedOutageStatus.
+func (in *PlannedOutageStatus) DeepCopy() *PlannedOutageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedOutageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodRecovery) DeepCopyInto(out *PodRecovery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodRecovery.
+func (in *PodRecovery) DeepCopy() *PodRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(PodRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PointInTimeSource) DeepCopyInto(out *PointInTimeSource) {
+	*out = *in
+	out.ScheduleRef = in.ScheduleRef
+	in.TargetTime.DeepCopyInto(&out.TargetTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PointInTimeSource.
+func (in *PointInTimeSource) DeepCopy() *PointInTimeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PointInTimeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Privilege) DeepCopyInto(out *Privilege) {
+	*out = *in
+	in.Resource.DeepCopyInto(&out.Resource)
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Privilege.
+func (in *Privilege) DeepCopy() *Privilege {
+	if in == nil {
+		return nil
+	}
+	out := new(Privilege)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProgressStatus) DeepCopyInto(out *ProgressStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.MemberDuration != nil {
+		in, out := &in.MemberDuration, &out.MemberDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProgressStatus.
+func (in *ProgressStatus) DeepCopy() *ProgressStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgressStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Prometheus) DeepCopyInto(out *Prometheus) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(PrometheusTLS)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Monitor != nil {
+		in, out := &in.Monitor, &out.Monitor
+		*out = new(PrometheusMonitor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = new(PrometheusRules)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Prometheus.
+func (in *Prometheus) DeepCopy() *Prometheus {
+	if in == nil {
+		return nil
+	}
+	out := new(Prometheus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusMonitor) DeepCopyInto(out *PrometheusMonitor) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusMonitor.
+func (in *PrometheusMonitor) DeepCopy() *PrometheusMonitor {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusMonitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusRules) DeepCopyInto(out *PrometheusRules) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusRules.
+func (in *PrometheusRules) DeepCopy() *PrometheusRules {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusTLS) DeepCopyInto(out *PrometheusTLS) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusTLS.
+func (in *PrometheusTLS) DeepCopy() *PrometheusTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ReplicaSetHorizonConfiguration) DeepCopyInto(out *ReplicaSetHorizonConfiguration) {
+	{
+		in := &in
+		*out = make(ReplicaSetHorizonConfiguration, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(automationconfig.ReplicaSetHorizons, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSetHorizonConfiguration.
+func (in ReplicaSetHorizonConfiguration) DeepCopy() ReplicaSetHorizonConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSetHorizonConfiguration)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaSetRenameStatus) DeepCopyInto(out *ReplicaSetRenameStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSetRenameStatus.
+func (in *ReplicaSetRenameStatus) DeepCopy() *ReplicaSetRenameStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSetRenameStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationLagGate) DeepCopyInto(out *ReplicationLagGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationLagGate.
+func (in *ReplicationLagGate) DeepCopy() *ReplicationLagGate {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationLagGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Resource) DeepCopyInto(out *Resource) {
+	*out = *in
+	if in.DB != nil {
+		in, out := &in.DB, &out.DB
+		*out = new(string)
+		**out = **in
+	}
+	if in.Collection != nil {
+		in, out := &in.Collection, &out.Collection
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resource.
+func (in *Resource) DeepCopy() *Resource {
+	if in == nil {
+		return nil
+	}
+	out := new(Resource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Role) DeepCopyInto(out *Role) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Role.
+func (in *Role) DeepCopy() *Role {
+	if in == nil {
+		return nil
+	}
+	out := new(Role)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3BackupTarget) DeepCopyInto(out *S3BackupTarget) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BackupTarget.
+func (in *S3BackupTarget) DeepCopy() *S3BackupTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(S3BackupTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3RestoreSource) DeepCopyInto(out *S3RestoreSource) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3RestoreSource.
+func (in *S3RestoreSource) DeepCopy() *S3RestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(S3RestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownConfiguration) DeepCopyInto(out *ScaleDownConfiguration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownConfiguration.
+func (in *ScaleDownConfiguration) DeepCopy() *ScaleDownConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Security) DeepCopyInto(out *Security) {
 	*out = *in
 	in.Authentication.DeepCopyInto(&out.Authentication)
-	out.TLS = in.TLS
+	in.TLS.DeepCopyInto(&out.TLS)
 	if in.Roles != nil {
 		in, out := &in.Roles, &out.Roles
 		*out = make([]CustomRole, len(*in))
@@ -378,6 +2570,16 @@ func (in *Security) DeepCopyInto(out *Security) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.KeyfileRotation != nil {
+		in, out := &in.KeyfileRotation, &out.KeyfileRotation
+		*out = new(KeyfileRotation)
+		**out = **in
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(AuditLog)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Security.
@@ -390,6 +2592,90 @@ func (in *Security) DeepCopy() *Security {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceConfiguration) DeepCopyInto(out *ServiceConfiguration) {
+	*out = *in
+	if in.AdditionalPorts != nil {
+		in, out := &in.AdditionalPorts, &out.AdditionalPorts
+		*out = make([]ServicePort, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClientService != nil {
+		in, out := &in.ClientService, &out.ClientService
+		*out = new(ClientService)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceConfiguration.
+func (in *ServiceConfiguration) DeepCopy() *ServiceConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMesh) DeepCopyInto(out *ServiceMesh) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMesh.
+func (in *ServiceMesh) DeepCopy() *ServiceMesh {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMesh)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePort) DeepCopyInto(out *ServicePort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePort.
+func (in *ServicePort) DeepCopy() *ServicePort {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotBackupOptions) DeepCopyInto(out *SnapshotBackupOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotBackupOptions.
+func (in *SnapshotBackupOptions) DeepCopy() *SnapshotBackupOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotBackupOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatefulSetConfiguration) DeepCopyInto(out *StatefulSetConfiguration) {
 	*out = *in
@@ -412,11 +2698,94 @@ func (in *StatefulSetSpecWrapper) DeepCopyInto(out *StatefulSetSpecWrapper) {
 	*out = *clone
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StuckMemberRemediation) DeepCopyInto(out *StuckMemberRemediation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StuckMemberRemediation.
+func (in *StuckMemberRemediation) DeepCopy() *StuckMemberRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(StuckMemberRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StuckMemberStatus) DeepCopyInto(out *StuckMemberStatus) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StuckMemberStatus.
+func (in *StuckMemberStatus) DeepCopy() *StuckMemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StuckMemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SystemLog) DeepCopyInto(out *SystemLog) {
+	*out = *in
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int)
+		**out = **in
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LogRotate != nil {
+		in, out := &in.LogRotate, &out.LogRotate
+		*out = new(LogRotate)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemLog.
+func (in *SystemLog) DeepCopy() *SystemLog {
+	if in == nil {
+		return nil
+	}
+	out := new(SystemLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
+	if in.CipherSuites != nil {
+		in, out := &in.CipherSuites, &out.CipherSuites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.CertificateKeySecret = in.CertificateKeySecret
 	out.CaConfigMap = in.CaConfigMap
+	if in.CaCertificateSecret != nil {
+		in, out := &in.CaCertificateSecret, &out.CaCertificateSecret
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.MemberCertificateSecret != nil {
+		in, out := &in.MemberCertificateSecret, &out.MemberCertificateSecret
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.OperatorClient != nil {
+		in, out := &in.OperatorClient, &out.OperatorClient
+		*out = new(OperatorClientTLS)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLS.
@@ -428,3 +2797,110 @@ func (in *TLS) DeepCopy() *TLS {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSCertificatesStatus) DeepCopyInto(out *TLSCertificatesStatus) {
+	*out = *in
+	if in.CertificateNotAfter != nil {
+		in, out := &in.CertificateNotAfter, &out.CertificateNotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.CANotAfter != nil {
+		in, out := &in.CANotAfter, &out.CANotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.MemberCertificateNotAfter != nil {
+		in, out := &in.MemberCertificateNotAfter, &out.MemberCertificateNotAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSCertificatesStatus.
+func (in *TLSCertificatesStatus) DeepCopy() *TLSCertificatesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSCertificatesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemporaryDirectory) DeepCopyInto(out *TemporaryDirectory) {
+	*out = *in
+	if in.SizeLimit != nil {
+		in, out := &in.SizeLimit, &out.SizeLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemporaryDirectory.
+func (in *TemporaryDirectory) DeepCopy() *TemporaryDirectory {
+	if in == nil {
+		return nil
+	}
+	out := new(TemporaryDirectory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryUpdate)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
+func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionRolloutStatus) DeepCopyInto(out *VersionRolloutStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]MemberVersion, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionRolloutStatus.
+func (in *VersionRolloutStatus) DeepCopy() *VersionRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneAwareness) DeepCopyInto(out *ZoneAwareness) {
+	*out = *in
+	if in.TopologyKeys != nil {
+		in, out := &in.TopologyKeys, &out.TopologyKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneAwareness.
+func (in *ZoneAwareness) DeepCopy() *ZoneAwareness {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneAwareness)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/encryption"
+)
+
+const usage = `Usage: backup-crypt <command> [options] < input > output
+
+Commands:
+  encrypt      encrypts the input with the key of --key-file
+  decrypt      decrypts the input with the key of --key-file
+  wrapped-key  prints the base64 encoded data key wrapped by a KMS of the encrypted input, nothing if there is none
+`
+
+// backup-crypt encrypts and decrypts the artifacts of the backup Jobs. The Jobs copy it from its image, and wrap
+// and unwrap the data keys with the CLI of the KMS, so it only handles plaintext keys.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "encrypt":
+		err = encrypt(os.Args[2:])
+	case "decrypt":
+		err = decrypt(os.Args[2:])
+	case "wrapped-key":
+		err = wrappedKey()
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup-crypt %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func encrypt(args []string) error {
+	flags := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "file containing the key, either 32 bytes or their base64 encoding")
+	keySecret := flags.String("key-secret", "", "<name>/<key> of the Secret the key was read from")
+	kmsProvider := flags.String("kms-provider", "", "KMS which generated the data key")
+	kmsKeyID := flags.String("kms-key-id", "", "key of the KMS which wrapped the data key")
+	wrappedKey := flags.String("wrapped-key", "", "base64 encoded data key wrapped by the KMS")
+	_ = flags.Parse(args)
+
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	header := encryption.Header{KeySecret: *keySecret, KMSProvider: *kmsProvider, KMSKeyID: *kmsKeyID, WrappedKey: *wrappedKey}
+	out := bufio.NewWriter(os.Stdout)
+	w, err := encryption.NewWriter(out, key, header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, bufio.NewReader(os.Stdin)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+func decrypt(args []string) error {
+	flags := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "file containing the key, either 32 bytes or their base64 encoding")
+	allowPlaintext := flags.Bool("allow-plaintext", false, "copy the input as is if it is not encrypted")
+	_ = flags.Parse(args)
+
+	in := bufio.NewReader(os.Stdin)
+	header, encrypted, err := encryption.ReadHeader(in)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	if !encrypted {
+		if !*allowPlaintext {
+			return errors.New("the input is not encrypted")
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+
+	if *keyFile == "" {
+		if header.KeySecret != "" {
+			return errors.Errorf("the input is encrypted with the key of Secret %s, which was not given", header.KeySecret)
+		}
+		return errors.Errorf("the input is encrypted with a data key wrapped by %s key %s, which was not given", header.KMSProvider, header.KMSKeyID)
+	}
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	r, err := encryption.NewReader(in, header, key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+func wrappedKey() error {
+	header, encrypted, err := encryption.ReadHeader(bufio.NewReader(os.Stdin))
+	if err != nil || !encrypted {
+		return err
+	}
+	if header.WrappedKey != "" {
+		fmt.Println(header.WrappedKey)
+	}
+	return nil
+}
+
+func readKey(keyFile string) ([]byte, error) {
+	if keyFile == "" {
+		return nil, errors.New("--key-file is required")
+	}
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.ParseKey(data)
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
 	"github.com/mongodb/mongodb-kubernetes-operator/controllers"
 	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/secretbackend"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/loglevel"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/redact"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/tracing"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -22,6 +30,20 @@ var (
 
 const (
 	WatchNamespaceEnv = "WATCH_NAMESPACE"
+
+	// LogLevelEnv is the level the operator logs with, defaults to "debug".
+	LogLevelEnv = "LOG_LEVEL"
+	// LogLevelConfigEnv is the path to a file, usually mounted from a ConfigMap, which configures the log
+	// levels of the operator and of its individual loggers. The file is read again whenever it changes
+	// and whenever the operator receives SIGHUP.
+	LogLevelConfigEnv = "LOG_LEVEL_CONFIG"
+	// LogEncodingEnv is the encoding of the log entries of the operator, "console" or "json", defaults to
+	// "console". It is overridden by the -log-encoding flag.
+	LogEncodingEnv = "LOG_ENCODING"
+
+	logLevelConfigCheckInterval = 10 * time.Second
+
+	tracingShutdownTimeout = 5 * time.Second
 )
 
 func init() {
@@ -31,11 +53,27 @@ func init() {
 	// +kubebuilder:scaffold:scheme
 }
 
-func configureLogger() (*zap.Logger, error) {
-	// TODO: configure non development logger
-	logger, err := zap.NewDevelopment()
+func configureLogger(levels *loglevel.Levels, encoding string) (*zap.Logger, error) {
+	var cfg zap.Config
+	switch encoding {
+	case "console":
+		cfg = zap.NewDevelopmentConfig()
+	case "json":
+		cfg = zap.NewProductionConfig()
+		// the entries are filtered by the levels, and none are sampled so that a reconciliation is logged in full.
+		cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		cfg.Sampling = nil
+		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	default:
+		return nil, fmt.Errorf(`invalid log encoding %q, must be "console" or "json"`, encoding)
+	}
+	// all log output goes through the redacting core to make sure no credentials are ever logged.
+	logger, err := cfg.Build(zap.WrapCore(redact.NewCore), zap.WrapCore(levels.WrapCore))
+	if err != nil {
+		return nil, err
+	}
 	zap.ReplaceGlobals(logger)
-	return logger, err
+	return logger, nil
 }
 
 func hasRequiredVariables(logger *zap.Logger, envVariables ...string) bool {
@@ -50,15 +88,49 @@ func hasRequiredVariables(logger *zap.Logger, envVariables ...string) bool {
 }
 
 func main() {
-	log, err := configureLogger()
+	defaultLogEncoding, ok := os.LookupEnv(LogEncodingEnv)
+	if !ok {
+		defaultLogEncoding = "console"
+	}
+	logEncoding := flag.String("log-encoding", defaultLogEncoding, `The encoding of the log entries, "console" or "json".`)
+	flag.Parse()
+
+	levels := loglevel.New(zapcore.DebugLevel)
+	log, err := configureLogger(levels, *logEncoding)
 	if err != nil {
-		log.Sugar().Fatalf("Failed to configure logger: %v", err)
+		fmt.Fprintf(os.Stderr, "Failed to configure logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := levels.Apply(loglevel.Config{Level: os.Getenv(LogLevelEnv)}); err != nil {
+		log.Sugar().Fatalf("Invalid %s: %v", LogLevelEnv, err)
+	}
+
+	ctx := signals.SetupSignalHandler()
+	if path, ok := os.LookupEnv(LogLevelConfigEnv); ok {
+		go levels.Watch(ctx, path, logLevelConfigCheckInterval, log.Sugar())
 	}
 
 	if !hasRequiredVariables(log, construct.AgentImageEnv, construct.VersionUpgradeHookImageEnv, construct.ReadinessProbeImageEnv) {
 		os.Exit(1)
 	}
 
+	if err := secretbackend.ValidateEnv(); err != nil {
+		log.Sugar().Fatalf("Invalid secret backend configuration: %v", err)
+	}
+
+	if err := controllers.ValidateEnv(); err != nil {
+		log.Sugar().Fatalf("Invalid operator configuration: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Sugar().Fatalf("Unable to configure tracing: %v", err)
+	}
+	if tracing.Enabled() {
+		log.Info("Exporting the spans of the reconciliations over OTLP")
+	}
+
 	// Get watch namespace from environment variable.
 	namespace, nsSpecified := os.LookupEnv(WatchNamespaceEnv)
 	if !nsSpecified {
@@ -99,12 +171,33 @@ func main() {
 	if err = controllers.NewReconciler(mgr).SetupWithManager(mgr); err != nil {
 		log.Sugar().Fatalf("Unable to create controller: %v", err)
 	}
+	if err = controllers.NewBackupReconciler(mgr).SetupWithManager(mgr); err != nil {
+		log.Sugar().Fatalf("Unable to create backup controller: %v", err)
+	}
+	if err = controllers.NewBackupScheduleReconciler(mgr).SetupWithManager(mgr); err != nil {
+		log.Sugar().Fatalf("Unable to create backup schedule controller: %v", err)
+	}
+	if err = controllers.NewRestoreReconciler(mgr).SetupWithManager(mgr); err != nil {
+		log.Sugar().Fatalf("Unable to create restore controller: %v", err)
+	}
+	if err = controllers.NewMultiClusterReconciler(mgr).SetupWithManager(mgr); err != nil {
+		log.Sugar().Fatalf("Unable to create multi-cluster controller: %v", err)
+	}
 	// +kubebuilder:scaffold:builder
 
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctx)
+
+	// flush the spans which have not been exported yet.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Sugar().Warnf("Unable to export the remaining spans: %v", err)
+	}
+
+	if err != nil {
 		log.Sugar().Fatalf("Unable to start manager: %v", err)
 	}
 }
@@ -83,9 +83,26 @@ func isPodReady(conf config.Config) bool {
 		return true
 	}
 
+	// the output of a failed probe is reported in the Unhealthy Event of the Pod
+	fmt.Println(healthSummary(health))
 	return false
 }
 
+// healthSummary returns the state of the processes and the current step of the agent from the health status.
+func healthSummary(health health.Status) string {
+	summary := "Agent has not reached goal state"
+	for name, processHealth := range health.Healthiness {
+		summary += fmt.Sprintf("; %s: %s", name, processHealth)
+	}
+	if step := findCurrentStep(health.ProcessPlans); step != nil {
+		summary += fmt.Sprintf("; current step: %s", step.Step)
+		if step.Started != nil {
+			summary += fmt.Sprintf(" started at %s", step.Started.Format(time.RFC3339))
+		}
+	}
+	return summary
+}
+
 func readAgentHealthStatus(file *os.File) (health.Status, error) {
 	var health health.Status
 
@@ -34,6 +34,14 @@ func TestNoDeadlock(t *testing.T) {
 	assert.False(t, isPodReady(testConfig("testdata/health-status-no-deadlock.json")))
 }
 
+// TestHealthSummary verifies that the output of a failed probe reports the state of the process and the current step
+func TestHealthSummary(t *testing.T) {
+	summary := healthSummary(readHealthinessFile("testdata/health-status-no-deadlock.json"))
+	assert.Contains(t, summary, "Agent has not reached goal state; ")
+	assert.Contains(t, summary, "IsInGoalState: false")
+	assert.Contains(t, summary, "; current step: WaitFeatureCompatibilityVersionCorrect started at ")
+}
+
 // TestDeadlockDetection verifies that if the agent is in "WaitAllRsMembersUp" phase but started < 15 seconds ago
 // then the function returns "not ready". To achieve this "started" is put into some long future.
 // Note, that the status file is artificial: it has two plans (the first one is complete and has no moves) to make sure
@@ -31,6 +31,11 @@ const (
 func main() {
 	logger := setupLogger()
 
+	if len(os.Args) > 1 && os.Args[1] == preStopArg {
+		runPreStop(logger)
+		return
+	}
+
 	logger.Info("Running version change post-start hook")
 
 	if statusPath := os.Getenv(agentStatusFilePathEnv); statusPath == "" {
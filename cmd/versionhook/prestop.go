@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
+)
+
+const (
+	preStopArg = "pre-stop"
+
+	// mongodConfigFilePath is the configuration file the agent starts mongod with.
+	mongodConfigFilePath = "/data/automation-mongod.conf"
+
+	defaultMongodPort = 27017
+
+	// preStopTimeout bounds the step down and the election, so that the hook completes within the default
+	// termination grace period of 30 seconds.
+	preStopTimeout time.Duration = 25 * time.Second
+)
+
+// mongodConfig is the part of the configuration file of mongod the pre-stop hook uses to connect to it.
+type mongodConfig struct {
+	Net struct {
+		Port int             `json:"port"`
+		TLS  mongodTLSConfig `json:"tls"`
+		SSL  mongodTLSConfig `json:"ssl"`
+	} `json:"net"`
+	Security struct {
+		KeyFile string `json:"keyFile"`
+	} `json:"security"`
+}
+
+type mongodTLSConfig struct {
+	Mode               string `json:"mode"`
+	CertificateKeyFile string `json:"certificateKeyFile"`
+	PEMKeyFile         string `json:"PEMKeyFile"`
+}
+
+// runPreStop steps down mongod if it is the primary, and waits for another member to be elected primary before
+// the container is stopped. Errors are only logged, as the Pod must be deleted regardless.
+func runPreStop(logger *zap.SugaredLogger) {
+	logger.Info("Running pre-stop hook")
+
+	config, err := readMongodConfig(mongodConfigFilePath)
+	if err != nil {
+		// mongod has not been configured by the agent yet, so it is not the primary.
+		logger.Infof("Could not read the configuration of mongod, not stepping down: %s", err)
+		return
+	}
+	connectionString, err := config.connectionString()
+	if err != nil {
+		logger.Errorf("Could not build the connection string of mongod: %s", err)
+		return
+	}
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		logger.Errorf("Could not load the TLS certificate of mongod: %s", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), preStopTimeout)
+	defer cancel()
+	if err := stepDownAndWaitForElection(ctx, logger, connectionString, tlsConfig); err != nil {
+		logger.Errorf("Could not hand over the primary role before mongod is stopped: %s", err)
+	}
+}
+
+// stepDownAndWaitForElection steps down the member if it is the primary, and waits until another member has been
+// elected primary.
+func stepDownAndWaitForElection(ctx context.Context, logger *zap.SugaredLogger, connectionString string, tlsConfig *tls.Config) error {
+	statusReader := replication.NewStatusReader()
+	status, err := statusReader.ReplSetStatus(ctx, connectionString, tlsConfig)
+	if err != nil {
+		return errors.Errorf("could not read the replica set status: %s", err)
+	}
+	if self, ok := status.Self(); !ok || self.StateStr != replication.StatePrimary {
+		logger.Info("mongod is not the primary, not stepping down")
+		return nil
+	}
+
+	logger.Info("mongod is the primary, stepping down")
+	if err := replication.NewStepDowner().StepDown(ctx, connectionString, tlsConfig); err != nil {
+		return errors.Errorf("could not step down: %s", err)
+	}
+
+	ticker := time.NewTicker(pollingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("no other member has been elected primary within %s", preStopTimeout)
+		case <-ticker.C:
+		}
+		status, err := statusReader.ReplSetStatus(ctx, connectionString, tlsConfig)
+		if err != nil {
+			logger.Debugf("Could not read the replica set status: %s", err)
+			continue
+		}
+		if primary, ok := status.Primary(); ok && !primary.Self {
+			logger.Infof("%s has been elected primary", primary.Name)
+			return nil
+		}
+	}
+}
+
+func readMongodConfig(path string) (mongodConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return mongodConfig{}, err
+	}
+	config := mongodConfig{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return mongodConfig{}, err
+	}
+	return config, nil
+}
+
+// connectionString returns the connection string to the local mongod. If mongod uses a keyfile for the
+// authentication of the members, the hook authenticates as the internal __system user.
+func (c mongodConfig) connectionString() (string, error) {
+	port := c.Net.Port
+	if port == 0 {
+		port = defaultMongodPort
+	}
+	u := url.URL{Scheme: "mongodb", Host: fmt.Sprintf("localhost:%d", port), Path: "/"}
+	if c.Security.KeyFile != "" {
+		keyfile, err := ioutil.ReadFile(c.Security.KeyFile)
+		if err != nil {
+			return "", errors.Errorf("could not read the keyfile: %s", err)
+		}
+		// the password of the __system user is the content of the keyfile without whitespace.
+		password := strings.Join(strings.Fields(string(keyfile)), "")
+		u.User = url.UserPassword("__system", password)
+		u.RawQuery = "authSource=local&authMechanism=SCRAM-SHA-1"
+	}
+	return u.String(), nil
+}
+
+// tlsConfig returns the TLS configuration to connect to the local mongod, or nil if it accepts connections
+// without TLS. The certificate of mongod is presented as the client certificate, and is not verified, as it is
+// not issued for localhost.
+func (c mongodConfig) tlsConfig() (*tls.Config, error) {
+	settings := c.Net.TLS
+	if settings.Mode == "" {
+		settings = c.Net.SSL
+	}
+	switch settings.Mode {
+	case "requireTLS", "requireSSL":
+	default:
+		return nil, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	certificateKeyFile := settings.CertificateKeyFile
+	if certificateKeyFile == "" {
+		certificateKeyFile = settings.PEMKeyFile
+	}
+	if certificateKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certificateKeyFile, certificateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
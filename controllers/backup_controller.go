@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/locker"
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	backupLoggerName = "backup"
+
+	// backupPollInterval is how often the progress of a running backup Job is checked.
+	backupPollInterval = 10
+
+	metricLabelOutcome = "outcome"
+)
+
+var (
+	// backupsTotal exposes how many backups of a resource completed or failed.
+	backupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongodbcommunity_backups_total",
+		Help: "The number of finished backups of a MongoDBCommunity resource by outcome, which is either completed or failed.",
+	}, []string{metricLabelNamespace, metricLabelName, metricLabelOutcome})
+
+	// lastSuccessfulBackupTimestamp exposes when the last backup of a resource completed.
+	lastSuccessfulBackupTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodbcommunity_last_successful_backup_timestamp_seconds",
+		Help: "The time at which the last backup of a MongoDBCommunity resource completed, in seconds since the epoch.",
+	}, []string{metricLabelNamespace, metricLabelName})
+)
+
+func init() {
+	metrics.Registry.MustRegister(backupsTotal, lastSuccessfulBackupTimestamp)
+}
+
+// deleteBackupMetrics removes the backup metrics of the given resource.
+func deleteBackupMetrics(nsName types.NamespacedName) {
+	for _, phase := range []mdbv1.BackupPhase{mdbv1.BackupCompleted, mdbv1.BackupFailed} {
+		backupsTotal.DeleteLabelValues(nsName.Namespace, nsName.Name, strings.ToLower(string(phase)))
+	}
+	lastSuccessfulBackupTimestamp.DeleteLabelValues(nsName.Namespace, nsName.Name)
+}
+
+// BackupReconciler takes the backups described by MongoDBCommunityBackup resources.
+type BackupReconciler struct {
+	client   kubernetesClient.Client
+	log      *zap.SugaredLogger
+	recorder record.EventRecorder
+	locker   locker.Locker
+}
+
+func NewBackupReconciler(mgr manager.Manager) *BackupReconciler {
+	return &BackupReconciler{
+		client:   kubernetesClient.NewClient(mgr.GetClient()),
+		log:      zap.S().Named(backupLoggerName),
+		recorder: mgr.GetEventRecorderFor("mongodbcommunitybackup-controller"),
+		locker:   locker.New(),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager and configures the necessary watches.
+func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mdbv1.MongoDBCommunityBackup{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitybackup,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitybackup/status,verbs=get;update;patch
+
+// Reconcile creates the Job taking the backup once the MongoDBCommunity resource is running, and reports the
+// progress of the Job in the status of the backup. Finished backups are not reconciled anymore.
+func (r BackupReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	backup := mdbv1.MongoDBCommunityBackup{}
+	if err := r.client.Get(ctx, request.NamespacedName, &backup); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return result.OK()
+		}
+		r.log.Errorf("Error reconciling MongoDBCommunityBackup resource: %s", err)
+		return result.Failed()
+	}
+	r.log = zap.S().Named(backupLoggerName).With("Backup", request.NamespacedName)
+
+	if backup.IsFinished() {
+		return result.OK()
+	}
+	if backup.GetMethod() == mdbv1.BackupMethodSnapshot {
+		return r.reconcileSnapshot(backup)
+	}
+	if backup.GetMethod() == mdbv1.BackupMethodPBM {
+		return r.reconcilePBM(backup)
+	}
+
+	job := batchv1.Job{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &job)
+	if apiErrors.IsNotFound(err) {
+		return r.startBackup(backup)
+	}
+	if err != nil {
+		r.log.Errorf("Could not get the backup Job: %s", err)
+		return result.Failed()
+	}
+	return r.observeBackupJob(backup, job)
+}
+
+// startBackup creates the Job taking the backup. The backup stays pending while the MongoDBCommunity resource
+// is not running or any Secret used by the Job does not exist.
+func (r BackupReconciler) startBackup(backup mdbv1.MongoDBCommunityBackup) (reconcile.Result, error) {
+	if backup.Spec.S3.Bucket == "" {
+		return r.finishBackup(backup, mdbv1.BackupFailed, "spec.s3 is required for the Dump method")
+	}
+	mdb, user, message := r.backupResource(backup)
+	if message != "" {
+		return r.updateBackupStatus(backup, mdbv1.BackupPending, message)
+	}
+	encryptionStatus, phase, message := r.backupEncryption(backup)
+	if phase == mdbv1.BackupFailed {
+		return r.finishBackup(backup, phase, message)
+	}
+	if phase != "" {
+		return r.updateBackupStatus(backup, phase, message)
+	}
+	connectionStringSecret := types.NamespacedName{Name: user.GetConnectionStringSecretName(mdb.Name), Namespace: mdb.Namespace}
+	for _, nsName := range []types.NamespacedName{connectionStringSecret, backup.CredentialsSecretNamespacedName()} {
+		if _, err := r.client.GetSecret(nsName); err != nil {
+			if apiErrors.IsNotFound(err) {
+				return r.updateBackupStatus(backup, mdbv1.BackupPending, fmt.Sprintf("Waiting for Secret %s to exist", nsName.Name))
+			}
+			return r.updateBackupStatus(backup, mdbv1.BackupPending, fmt.Sprintf("Could not get Secret %s: %s", nsName.Name, err))
+		}
+	}
+
+	opts := construct.BackupJobOptions{
+		Name:                       backup.JobName(),
+		Namespace:                  backup.Namespace,
+		ConnectionStringSecretName: connectionStringSecret.Name,
+		ConnectionStringKey:        connectionStringStandardKey,
+		UploadImage:                backup.GetImage(),
+		URL:                        backup.ArchiveLocation(),
+		Endpoint:                   backup.Spec.S3.Endpoint,
+		Region:                     backup.Spec.S3.Region,
+		CredentialsSecretName:      backup.Spec.S3.CredentialsSecretRef.Name,
+		AccessKeyIDKey:             mdbv1.S3AccessKeyIDKey,
+		SecretAccessKeyKey:         mdbv1.S3SecretAccessKeyKey,
+		Encryption:                 encryptionOptions(backup.Spec.Encryption, backup.Spec.S3.Region),
+	}
+	if mdb.Spec.Security.TLS.Enabled {
+		opts.CAConfigMapName = mdb.Spec.Security.TLS.CaConfigMap.Name
+	}
+	job := construct.BuildBackupJob(&mdb, opts)
+	job.OwnerReferences = backup.GetOwnerReferences()
+	setJobMetadata(mdb, &job)
+	job.Labels[mdbv1.JobResourceLabel] = mdb.Name
+	job.Labels[mdbv1.JobTypeLabel] = string(mdbv1.BackupJob)
+	if err := r.client.Create(context.TODO(), &job); err != nil && !apiErrors.IsAlreadyExists(err) {
+		r.log.Errorf("Could not create the backup Job: %s", err)
+		return result.Failed()
+	}
+
+	r.log.Infof("Backing up MongoDBCommunity resource %s to %s", mdb.Name, backup.ArchiveLocation())
+	now := metav1.Now()
+	backup.Status.Location = backup.ArchiveLocation()
+	backup.Status.StartTime = &now
+	backup.Status.Encryption = encryptionStatus
+	if _, err := r.updateBackupStatus(backup, mdbv1.BackupDumping, ""); err != nil {
+		return result.Failed()
+	}
+	return result.Retry(backupPollInterval)
+}
+
+// observeBackupJob reports the progress of the given backup Job.
+func (r BackupReconciler) observeBackupJob(backup mdbv1.MongoDBCommunityBackup, job batchv1.Job) (reconcile.Result, error) {
+	backup.Status.Location = backup.ArchiveLocation()
+	if size, ok := r.backupArchiveSize(job); ok {
+		backup.Status.SizeBytes = size
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			r.log.Infof("Backup stored at %s", backup.Status.Location)
+			completionTime := c.LastTransitionTime
+			backup.Status.CompletionTime = &completionTime
+			return r.finishBackup(backup, mdbv1.BackupCompleted, "")
+		case batchv1.JobFailed:
+			r.log.Warnf("Backup Job %s failed: %s", job.Name, c.Message)
+			return r.finishBackup(backup, mdbv1.BackupFailed, fmt.Sprintf("Job %s failed: %s", job.Name, c.Message))
+		}
+	}
+
+	phase := mdbv1.BackupDumping
+	if backup.Status.SizeBytes > 0 {
+		phase = mdbv1.BackupUploading
+	}
+	if _, err := r.updateBackupStatus(backup, phase, ""); err != nil {
+		return result.Failed()
+	}
+	return result.Retry(backupPollInterval)
+}
+
+// backupResource returns the MongoDBCommunity resource being backed up and the user taking the backup. It
+// returns the message the backup is pending with instead if the resource is not running or has no such user.
+func (r BackupReconciler) backupResource(backup mdbv1.MongoDBCommunityBackup) (mdbv1.MongoDBCommunity, mdbv1.MongoDBUser, string) {
+	mdb := mdbv1.MongoDBCommunity{}
+	if err := r.client.Get(context.TODO(), backup.MongoDBResourceNamespacedName(), &mdb); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return mdb, mdbv1.MongoDBUser{}, fmt.Sprintf("MongoDBCommunity resource %s does not exist", backup.Spec.MongoDBResourceRef.Name)
+		}
+		return mdb, mdbv1.MongoDBUser{}, fmt.Sprintf("Could not get MongoDBCommunity resource %s: %s", backup.Spec.MongoDBResourceRef.Name, err)
+	}
+	if mdb.Status.Phase != mdbv1.Running {
+		return mdb, mdbv1.MongoDBUser{}, fmt.Sprintf("Waiting for MongoDBCommunity resource %s to be running", mdb.Name)
+	}
+	user, ok := findUser(mdb, backup.Spec.User)
+	if !ok {
+		return mdb, mdbv1.MongoDBUser{}, fmt.Sprintf("User %s is not a user of MongoDBCommunity resource %s", backup.Spec.User, mdb.Name)
+	}
+	return mdb, user, ""
+}
+
+// backupArchiveSize returns the size of the archive written by mongodump, which the dump container reports in
+// its termination message, once a Pod of the Job has dumped the data.
+func (r BackupReconciler) backupArchiveSize(job batchv1.Job) (int64, bool) {
+	pods := corev1.PodList{}
+	if err := r.client.List(context.TODO(), &pods, k8sClient.InNamespace(job.Namespace), k8sClient.MatchingLabels{"job-name": job.Name}); err != nil {
+		r.log.Debugf("Could not list the Pods of backup Job %s: %s", job.Name, err)
+		return 0, false
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.InitContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name != construct.BackupDumpContainerName || terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+			size, err := strconv.ParseInt(strings.TrimSpace(terminated.Message), 10, 64)
+			if err == nil {
+				return size, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// updateBackupStatus updates the phase and the message of the backup. Pending backups are reconciled again later.
+func (r BackupReconciler) updateBackupStatus(backup mdbv1.MongoDBCommunityBackup, phase mdbv1.BackupPhase, message string) (reconcile.Result, error) {
+	backup.Status.Phase = phase
+	backup.Status.Message = message
+	if err := r.client.Status().Update(context.TODO(), &backup); err != nil {
+		r.log.Errorf("Error updating the status of the MongoDBCommunityBackup resource: %s", err)
+		return reconcile.Result{}, err
+	}
+	if phase == mdbv1.BackupPending {
+		r.log.Infof("Backup is pending: %s", message)
+		return result.Retry(backupPollInterval)
+	}
+	return result.OK()
+}
+
+// finishBackup moves the backup to the given final phase, updates the backup metrics of the resource and records
+// an Event for the finished backup.
+func (r BackupReconciler) finishBackup(backup mdbv1.MongoDBCommunityBackup, phase mdbv1.BackupPhase, message string) (reconcile.Result, error) {
+	res, err := r.updateBackupStatus(backup, phase, message)
+	if err != nil {
+		return res, err
+	}
+
+	resource := backup.MongoDBResourceNamespacedName()
+	backupsTotal.WithLabelValues(resource.Namespace, resource.Name, strings.ToLower(string(phase))).Inc()
+	if phase == mdbv1.BackupCompleted {
+		lastSuccessfulBackupTimestamp.WithLabelValues(resource.Namespace, resource.Name).Set(float64(backup.Status.CompletionTime.Unix()))
+	}
+
+	if r.recorder == nil {
+		return res, nil
+	}
+	switch {
+	case phase == mdbv1.BackupCompleted && backup.Status.Snapshot != nil:
+		r.recorder.Eventf(&backup, corev1.EventTypeNormal, "BackupCompleted", "Backup of %s stored in VolumeSnapshot %s", resource.Name, backup.Status.Snapshot.VolumeSnapshotName)
+	case phase == mdbv1.BackupCompleted && backup.Status.PBM != nil:
+		r.recorder.Eventf(&backup, corev1.EventTypeNormal, "BackupCompleted", "Backup of %s stored as PBM backup %s at %s", resource.Name, backup.Status.PBM.Name, backup.Status.Location)
+	case phase == mdbv1.BackupCompleted:
+		r.recorder.Eventf(&backup, corev1.EventTypeNormal, "BackupCompleted", "Backup of %s stored at %s", resource.Name, backup.Status.Location)
+	default:
+		r.recorder.Eventf(&backup, corev1.EventTypeWarning, "BackupFailed", "Backup of %s failed: %s", resource.Name, message)
+	}
+	return res, nil
+}
+
+// findUser returns the user of the resource with the given name.
+func findUser(mdb mdbv1.MongoDBCommunity, name string) (mdbv1.MongoDBUser, bool) {
+	for _, user := range mdb.Spec.Users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return mdbv1.MongoDBUser{}, false
+}
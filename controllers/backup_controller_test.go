@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/encryption"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func newTestBackup() mdbv1.MongoDBCommunityBackup {
+	return mdbv1.MongoDBCommunityBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "my-backup",
+			Namespace:         "my-ns",
+			CreationTimestamp: metav1.NewTime(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		Spec: mdbv1.MongoDBCommunityBackupSpec{
+			MongoDBResourceRef: mdbv1.LocalObjectReference{Name: "my-rs"},
+			User:               "backup-user",
+			S3: mdbv1.S3BackupTarget{
+				Bucket:               "my-bucket",
+				Prefix:               "backups/",
+				Endpoint:             "https://minio.example.com:9000",
+				CredentialsSecretRef: mdbv1.LocalObjectReference{Name: "bucket-credentials"},
+			},
+		},
+	}
+}
+
+// setupBackup returns a reconciler for the given backup of the given resource, with the connection string
+// Secret of the backup user and the credentials of the bucket.
+func setupBackup(t *testing.T, mdb mdbv1.MongoDBCommunity, backup mdbv1.MongoDBCommunityBackup) (*BackupReconciler, *client.MockedManager) {
+	mdb.Spec.Users = []mdbv1.MongoDBUser{{Name: "backup-user", DB: "admin", PasswordSecretRef: mdbv1.SecretKeyReference{Name: "backup-user-password"}}}
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &backup))
+	for _, name := range []string{mdb.Spec.Users[0].GetConnectionStringSecretName(mdb.Name), "bucket-credentials"} {
+		s := secret.Builder().SetName(name).SetNamespace(mdb.Namespace).SetField("key", "value").Build()
+		assert.NoError(t, mgr.Client.CreateSecret(s))
+	}
+	return NewBackupReconciler(mgr), mgr
+}
+
+func reconcileBackup(t *testing.T, r *BackupReconciler, mgr *client.MockedManager, backup mdbv1.MongoDBCommunityBackup) (reconcile.Result, mdbv1.MongoDBCommunityBackup) {
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: backup.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), backup.NamespacedName(), &backup))
+	return res, backup
+}
+
+func TestBackup_IsPendingUntilTheResourceIsRunning(t *testing.T) {
+	mdb := newTestReplicaSet()
+	backup := newTestBackup()
+	r, mgr := setupBackup(t, mdb, backup)
+
+	res, backup := reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupPending, backup.Status.Phase)
+	assert.Equal(t, "Waiting for MongoDBCommunity resource my-rs to be running", backup.Status.Message)
+	assert.True(t, res.RequeueAfter > 0)
+
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &batchv1.Job{})
+	assert.Error(t, err, "no Job is created")
+}
+
+func TestBackup_RunsMongodumpAndUploadsTheArchive(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Status.Phase = mdbv1.Running
+	backup := newTestBackup()
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupDumping, backup.Status.Phase)
+	assert.Equal(t, "s3://my-bucket/backups/my-ns/my-rs/my-backup-20210601T120000Z.archive.gz", backup.Status.Location)
+	assert.NotNil(t, backup.Status.StartTime)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-backup-backup", Namespace: backup.Namespace}, &job))
+	assert.Equal(t, "my-rs", job.Labels[mdbv1.JobResourceLabel], "the outcome is reported in the LastBackup condition of the resource")
+	assert.Equal(t, string(mdbv1.BackupJob), job.Labels[mdbv1.JobTypeLabel])
+	assert.Equal(t, "my-rs", job.Spec.Template.Labels[mdbv1.LabelResource], "the Pods are admitted by the NetworkPolicy of the members")
+
+	dump := job.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(t, construct.BackupDumpContainerName, dump.Name)
+	assert.Contains(t, dump.Command[2], "--readPreference=secondary")
+	assert.Equal(t, "my-rs-admin-backup-user", dump.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, connectionStringStandardKey, dump.Env[0].ValueFrom.SecretKeyRef.Key)
+
+	upload := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "amazon/aws-cli:2.2.4", upload.Image)
+	assert.Contains(t, upload.Env, corev1.EnvVar{Name: "S3_URL", Value: backup.Status.Location})
+	assert.Contains(t, upload.Env, corev1.EnvVar{Name: "S3_ENDPOINT", Value: "https://minio.example.com:9000"})
+
+	t.Run("The size of the archive is reported once the data has been dumped", func(t *testing.T) {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-backup-backup-abcde", Namespace: backup.Namespace, Labels: map[string]string{"job-name": job.Name}},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  construct.BackupDumpContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "1048576\n"}},
+				}},
+			},
+		}
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+
+		_, backup = reconcileBackup(t, r, mgr, backup)
+		assert.Equal(t, mdbv1.BackupUploading, backup.Status.Phase)
+		assert.Equal(t, int64(1048576), backup.Status.SizeBytes)
+	})
+
+	t.Run("The backup completes with the Job", func(t *testing.T) {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+		completed := testutil.ToFloat64(backupsTotal.WithLabelValues("my-ns", "my-rs", "completed"))
+
+		res, backup := reconcileBackup(t, r, mgr, backup)
+		assert.Equal(t, mdbv1.BackupCompleted, backup.Status.Phase)
+		assert.NotNil(t, backup.Status.CompletionTime)
+		assert.Equal(t, reconcile.Result{}, res)
+		assert.Equal(t, completed+1, testutil.ToFloat64(backupsTotal.WithLabelValues("my-ns", "my-rs", "completed")))
+		assert.Equal(t, float64(backup.Status.CompletionTime.Unix()), testutil.ToFloat64(lastSuccessfulBackupTimestamp.WithLabelValues("my-ns", "my-rs")))
+	})
+}
+
+func TestBackup_FailsWithTheJob(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Status.Phase = mdbv1.Running
+	backup := newTestBackup()
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &job))
+	assert.Contains(t, job.Spec.Template.Spec.InitContainers[0].Env, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "--sslCAFile=/tls/ca.crt"})
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupFailed, backup.Status.Phase)
+	assert.Equal(t, "Job my-backup-backup failed: Job has reached the specified backoff limit", backup.Status.Message)
+}
+
+// findContainer returns the container or the init container of the Job with the given name.
+func findContainer(job batchv1.Job, name string) (corev1.Container, bool) {
+	for _, c := range append(job.Spec.Template.Spec.InitContainers, job.Spec.Template.Spec.Containers...) {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return corev1.Container{}, false
+}
+
+func TestBackup_EncryptsTheArchive(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Status.Phase = mdbv1.Running
+	backup := newTestBackup()
+	backup.Spec.Encryption = &mdbv1.BackupEncryption{KeySecretRef: &mdbv1.SecretKeyReference{Name: "backup-key"}}
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupPending, backup.Status.Phase)
+	assert.Equal(t, "Waiting for Secret backup-key to exist", backup.Status.Message)
+
+	key := strings.Repeat("k", 32)
+	s := secret.Builder().SetName("backup-key").SetNamespace(backup.Namespace).SetField("key", base64.StdEncoding.EncodeToString([]byte(key))).Build()
+	assert.NoError(t, mgr.Client.CreateSecret(s))
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupDumping, backup.Status.Phase)
+	if assert.NotNil(t, backup.Status.Encryption) {
+		assert.Equal(t, encryption.Algorithm, backup.Status.Encryption.Algorithm)
+		assert.Equal(t, &mdbv1.SecretKeyReference{Name: "backup-key", Key: "key"}, backup.Status.Encryption.KeySecretRef)
+		assert.Equal(t, encryption.Fingerprint([]byte(key)), backup.Status.Encryption.KeyFingerprint)
+	}
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &job))
+	_, ok := findContainer(job, construct.BackupCryptContainerName)
+	assert.True(t, ok, "the backup-crypt binary is copied into the Pod")
+	upload, _ := findContainer(job, construct.BackupUploadContainerName)
+	assert.Contains(t, upload.Command[2], "backup-crypt encrypt --key-file=/encryption/key")
+	assert.Contains(t, upload.Env, corev1.EnvVar{Name: "ENCRYPTION_KEY_SECRET", Value: "backup-key/key"})
+	assert.Contains(t, upload.VolumeMounts, corev1.VolumeMount{Name: "encryption-key", MountPath: "/encryption", ReadOnly: true})
+	assert.True(t, strings.Index(upload.Command[2], "encrypt") < strings.Index(upload.Command[2], "aws s3 cp"), "the archive is encrypted before it is uploaded")
+}
+
+func TestBackup_EncryptsTheArchiveWithAKMSDataKey(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Status.Phase = mdbv1.Running
+	backup := newTestBackup()
+	backup.Spec.S3.Region = "eu-west-1"
+	backup.Spec.Encryption = &mdbv1.BackupEncryption{KMS: &mdbv1.KMSEncryption{Provider: mdbv1.KMSProviderAWS, KeyID: "alias/backups"}}
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupDumping, backup.Status.Phase)
+	if assert.NotNil(t, backup.Status.Encryption) {
+		assert.Equal(t, backup.Spec.Encryption.KMS, backup.Status.Encryption.KMS)
+		assert.Nil(t, backup.Status.Encryption.KeySecretRef)
+	}
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &job))
+	upload, _ := findContainer(job, construct.BackupUploadContainerName)
+	assert.Contains(t, upload.Command[2], "aws kms generate-data-key")
+	assert.Contains(t, upload.Env, corev1.EnvVar{Name: "KMS_KEY_ID", Value: "alias/backups"})
+	assert.Contains(t, upload.Env, corev1.EnvVar{Name: "KMS_REGION", Value: "eu-west-1"}, "the KMS defaults to the region of the bucket")
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		if volume.Name == "data-keys" {
+			assert.Equal(t, corev1.StorageMediumMemory, volume.EmptyDir.Medium, "the plaintext data key is not written to disk")
+		}
+	}
+}
+
+func TestBackup_FailsWithAnInvalidEncryptionKey(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Status.Phase = mdbv1.Running
+	backup := newTestBackup()
+	backup.Spec.Encryption = &mdbv1.BackupEncryption{KeySecretRef: &mdbv1.SecretKeyReference{Name: "backup-key", Key: "aes"}}
+	r, mgr := setupBackup(t, mdb, backup)
+	s := secret.Builder().SetName("backup-key").SetNamespace(backup.Namespace).SetField("aes", "too short").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(s))
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupFailed, backup.Status.Phase)
+	assert.Equal(t, "Key aes of Secret backup-key is not a valid encryption key: the key must be 32 bytes, or their base64 encoding", backup.Status.Message)
+
+	backup = newTestBackup()
+	backup.Name = "other-backup"
+	backup.Spec.Encryption = &mdbv1.BackupEncryption{}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &backup))
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupFailed, backup.Status.Phase)
+	assert.Equal(t, "Exactly one of spec.encryption.keySecretRef and spec.encryption.kms must be set", backup.Status.Message)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/encryption"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// encryptionOptions returns the options encrypting or decrypting the artifacts of a Job, nil if they are not
+// encrypted. The KMS defaults to the region of the bucket.
+func encryptionOptions(e *mdbv1.BackupEncryption, bucketRegion string) *construct.EncryptionOptions {
+	if e == nil {
+		return nil
+	}
+	if e.KMS != nil {
+		region := e.KMS.Region
+		if region == "" {
+			region = bucketRegion
+		}
+		return &construct.EncryptionOptions{KMSProvider: string(e.KMS.Provider), KMSKeyID: e.KMS.KeyID, KMSRegion: region}
+	}
+	return &construct.EncryptionOptions{KeySecretName: e.KeySecretRef.Name, KeySecretKey: e.GetKeySecretKey()}
+}
+
+// validateEncryption returns the message a backup or a restore fails with if the encryption at the given path of
+// its spec is not configured correctly.
+func validateEncryption(e *mdbv1.BackupEncryption, path string) string {
+	if e == nil || (e.KeySecretRef == nil) != (e.KMS == nil) {
+		return ""
+	}
+	return fmt.Sprintf("Exactly one of %s.keySecretRef and %s.kms must be set", path, path)
+}
+
+// backupEncryption returns how the archive of the backup is encrypted, nil if it is not. The key of a Secret is
+// read to report its fingerprint. If the key is not available, it returns the phase and the message the backup
+// is updated with instead.
+func (r BackupReconciler) backupEncryption(backup mdbv1.MongoDBCommunityBackup) (*mdbv1.BackupEncryptionStatus, mdbv1.BackupPhase, string) {
+	e := backup.Spec.Encryption
+	if e == nil {
+		return nil, "", ""
+	}
+	if message := validateEncryption(e, "spec.encryption"); message != "" {
+		return nil, mdbv1.BackupFailed, message
+	}
+	status := &mdbv1.BackupEncryptionStatus{Algorithm: encryption.Algorithm, KMS: e.KMS}
+	if e.KMS != nil {
+		return status, "", ""
+	}
+
+	secret, err := r.client.GetSecret(types.NamespacedName{Name: e.KeySecretRef.Name, Namespace: backup.Namespace})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil, mdbv1.BackupPending, fmt.Sprintf("Waiting for Secret %s to exist", e.KeySecretRef.Name)
+		}
+		return nil, mdbv1.BackupPending, fmt.Sprintf("Could not get Secret %s: %s", e.KeySecretRef.Name, err)
+	}
+	key, err := encryption.ParseKey(secret.Data[e.GetKeySecretKey()])
+	if err != nil {
+		return nil, mdbv1.BackupFailed, fmt.Sprintf("Key %s of Secret %s is not a valid encryption key: %s", e.GetKeySecretKey(), e.KeySecretRef.Name, err)
+	}
+	status.KeySecretRef = &mdbv1.SecretKeyReference{Name: e.KeySecretRef.Name, Key: e.GetKeySecretKey()}
+	status.KeyFingerprint = encryption.Fingerprint(key)
+	return status, "", ""
+}
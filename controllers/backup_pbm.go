@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// reconcilePBM takes the backup with the Percona Backup for MongoDB agents of the resource. A Job runs the pbm
+// CLI, which starts the backup and waits for the agents to store it.
+func (r BackupReconciler) reconcilePBM(backup mdbv1.MongoDBCommunityBackup) (reconcile.Result, error) {
+	job := batchv1.Job{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &job)
+	if apiErrors.IsNotFound(err) {
+		return r.startPBMBackup(backup)
+	}
+	if err != nil {
+		r.log.Errorf("Could not get the backup Job: %s", err)
+		return result.Failed()
+	}
+	return r.observePBMBackupJob(backup, job)
+}
+
+// startPBMBackup creates the Job taking the backup. The backup stays pending while the MongoDBCommunity resource
+// is not running, has not enabled PBM, or any Secret used by the Job does not exist.
+func (r BackupReconciler) startPBMBackup(backup mdbv1.MongoDBCommunityBackup) (reconcile.Result, error) {
+	backupType := backup.Spec.PBM.GetType()
+	if backup.Spec.PBM != nil && backup.Spec.PBM.Base && backupType != mdbv1.PBMBackupIncremental {
+		return r.finishBackup(backup, mdbv1.BackupFailed, "spec.pbm.base only applies to Incremental backups")
+	}
+	mdb, user, message := r.backupResource(backup)
+	if message != "" {
+		return r.updateBackupStatus(backup, mdbv1.BackupPending, message)
+	}
+	if !mdb.IsPBMEnabled() {
+		return r.updateBackupStatus(backup, mdbv1.BackupPending, fmt.Sprintf("Waiting for PBM to be enabled in MongoDBCommunity resource %s", mdb.Name))
+	}
+	connectionStringSecret := types.NamespacedName{Name: user.GetConnectionStringSecretName(mdb.Name), Namespace: mdb.Namespace}
+	for _, nsName := range []types.NamespacedName{connectionStringSecret, {Name: mdb.PBMConfigSecretName(), Namespace: mdb.Namespace}} {
+		if _, err := r.client.GetSecret(nsName); err != nil {
+			if apiErrors.IsNotFound(err) {
+				return r.updateBackupStatus(backup, mdbv1.BackupPending, fmt.Sprintf("Waiting for Secret %s to exist", nsName.Name))
+			}
+			return r.updateBackupStatus(backup, mdbv1.BackupPending, fmt.Sprintf("Could not get Secret %s: %s", nsName.Name, err))
+		}
+	}
+
+	opts := pbmJobOptions(mdb, backup.JobName(), connectionStringSecret.Name)
+	job := construct.BuildPBMBackupJob(&mdb, opts, strings.ToLower(string(backupType)), backup.Spec.PBM != nil && backup.Spec.PBM.Base)
+	job.OwnerReferences = backup.GetOwnerReferences()
+	setJobMetadata(mdb, &job)
+	job.Labels[mdbv1.JobResourceLabel] = mdb.Name
+	job.Labels[mdbv1.JobTypeLabel] = string(mdbv1.BackupJob)
+	if err := r.client.Create(context.TODO(), &job); err != nil && !apiErrors.IsAlreadyExists(err) {
+		r.log.Errorf("Could not create the backup Job: %s", err)
+		return result.Failed()
+	}
+
+	r.log.Infof("Taking a %s PBM backup of MongoDBCommunity resource %s", strings.ToLower(string(backupType)), mdb.Name)
+	now := metav1.Now()
+	backup.Status.Location = mdb.Spec.PBM.StorageLocation()
+	backup.Status.StartTime = &now
+	backup.Status.PBM = &mdbv1.BackupPBMStatus{Type: backupType}
+	if _, err := r.updateBackupStatus(backup, mdbv1.BackupInProgress, ""); err != nil {
+		return result.Failed()
+	}
+	return result.Retry(backupPollInterval)
+}
+
+// observePBMBackupJob reports the outcome of the given backup Job, and the name of the backup in PBM.
+func (r BackupReconciler) observePBMBackupJob(backup mdbv1.MongoDBCommunityBackup, job batchv1.Job) (reconcile.Result, error) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			if backup.Status.PBM == nil {
+				backup.Status.PBM = &mdbv1.BackupPBMStatus{Type: backup.Spec.PBM.GetType()}
+			}
+			backup.Status.PBM.Name = r.pbmBackupName(job)
+			r.log.Infof("PBM backup %s stored at %s", backup.Status.PBM.Name, backup.Status.Location)
+			completionTime := c.LastTransitionTime
+			backup.Status.CompletionTime = &completionTime
+			return r.finishBackup(backup, mdbv1.BackupCompleted, "")
+		case batchv1.JobFailed:
+			r.log.Warnf("Backup Job %s failed: %s", job.Name, c.Message)
+			return r.finishBackup(backup, mdbv1.BackupFailed, fmt.Sprintf("Job %s failed: %s", job.Name, c.Message))
+		}
+	}
+	return result.Retry(backupPollInterval)
+}
+
+// pbmBackupName returns the name of the backup in PBM, which the pbm container reports in its termination message.
+func (r BackupReconciler) pbmBackupName(job batchv1.Job) string {
+	pods := corev1.PodList{}
+	if err := r.client.List(context.TODO(), &pods, k8sClient.InNamespace(job.Namespace), k8sClient.MatchingLabels{"job-name": job.Name}); err != nil {
+		r.log.Debugf("Could not list the Pods of backup Job %s: %s", job.Name, err)
+		return ""
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name == construct.PBMContainerName && terminated != nil && terminated.ExitCode == 0 {
+				return strings.TrimSpace(terminated.Message)
+			}
+		}
+	}
+	return ""
+}
+
+// pbmJobOptions returns the options of a Job running the pbm CLI against the given resource as the user of the
+// given connection string Secret.
+func pbmJobOptions(mdb mdbv1.MongoDBCommunity, name, connectionStringSecretName string) construct.PBMJobOptions {
+	opts := construct.PBMJobOptions{
+		Name:                       name,
+		Namespace:                  mdb.Namespace,
+		Image:                      mdb.Spec.PBM.GetImage(),
+		ConnectionStringSecretName: connectionStringSecretName,
+		ConnectionStringKey:        connectionStringStandardKey,
+		ConfigSecretName:           mdb.PBMConfigSecretName(),
+	}
+	if mdb.Spec.Security.TLS.Enabled {
+		opts.CAConfigMapName = mdb.Spec.Security.TLS.CaConfigMap.Name
+	}
+	return opts
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func newTestPBMBackup(options *mdbv1.PBMBackupOptions) mdbv1.MongoDBCommunityBackup {
+	backup := newTestBackup()
+	backup.Spec.Method = mdbv1.BackupMethodPBM
+	backup.Spec.S3 = mdbv1.S3BackupTarget{}
+	backup.Spec.PBM = options
+	return backup
+}
+
+// newRunningReplicaSetWithPBM returns a running replica set with PBM enabled, whose backup user is the test user
+// of the backup and restore controllers.
+func newRunningReplicaSetWithPBM(user string) mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSetWithPBM()
+	mdb.Spec.Users = []mdbv1.MongoDBUser{{Name: user, DB: "admin", PasswordSecretRef: mdbv1.SecretKeyReference{Name: user + "-password"}}}
+	mdb.Spec.PBM.User = user
+	mdb.Status.Phase = mdbv1.Running
+	return mdb
+}
+
+func TestPBMBackup_RunsThePBMCLI(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("backup-user")
+	backup := newTestPBMBackup(&mdbv1.PBMBackupOptions{Type: mdbv1.PBMBackupIncremental, Base: true})
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupPending, backup.Status.Phase)
+	assert.Equal(t, "Waiting for Secret my-rs-pbm-config to exist", backup.Status.Message)
+
+	config := secret.Builder().SetName(mdb.PBMConfigSecretName()).SetNamespace(mdb.Namespace).SetField(construct.PBMConfigKey, "").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(config))
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupInProgress, backup.Status.Phase)
+	assert.Equal(t, "s3://my-bucket/pbm", backup.Status.Location)
+	assert.Equal(t, &mdbv1.BackupPBMStatus{Type: mdbv1.PBMBackupIncremental}, backup.Status.PBM)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: backup.JobName(), Namespace: backup.Namespace}, &job))
+	pbm := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, construct.PBMContainerName, pbm.Name)
+	assert.Equal(t, "percona/percona-backup-mongodb:2.0.2", pbm.Image)
+	assert.Contains(t, pbm.Command[2], "pbm backup")
+	assert.Equal(t, "my-rs-admin-backup-user", pbm.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Contains(t, pbm.Env, corev1.EnvVar{Name: "PBM_BACKUP_TYPE", Value: "incremental"})
+	assert.Contains(t, pbm.Env, corev1.EnvVar{Name: "PBM_BASE_OPTION", Value: "--base"})
+
+	t.Run("The name of the backup is reported once it has completed", func(t *testing.T) {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-backup-backup-abcde", Namespace: backup.Namespace, Labels: map[string]string{"job-name": job.Name}},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  construct.PBMContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "2021-06-01T12:00:05Z\n"}},
+				}},
+			},
+		}
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+		_, backup := reconcileBackup(t, r, mgr, backup)
+		assert.Equal(t, mdbv1.BackupCompleted, backup.Status.Phase)
+		assert.Equal(t, "2021-06-01T12:00:05Z", backup.Status.PBM.Name)
+	})
+}
+
+func TestPBMBackup_IsPendingUntilPBMIsEnabled(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("backup-user")
+	mdb.Spec.PBM.Enabled = false
+	backup := newTestPBMBackup(nil)
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupPending, backup.Status.Phase)
+	assert.Equal(t, "Waiting for PBM to be enabled in MongoDBCommunity resource my-rs", backup.Status.Message)
+}
+
+func TestPBMBackup_BaseRequiresAnIncrementalBackup(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("backup-user")
+	backup := newTestPBMBackup(&mdbv1.PBMBackupOptions{Type: mdbv1.PBMBackupPhysical, Base: true})
+	r, mgr := setupBackup(t, mdb, backup)
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupFailed, backup.Status.Phase)
+	assert.Equal(t, "spec.pbm.base only applies to Incremental backups", backup.Status.Message)
+}
+
+func TestPBMRestore_RestoresALogicalBackup(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("restore-user")
+	backup := newTestPBMBackup(nil)
+	backup.Status.Phase = mdbv1.BackupCompleted
+	backup.Status.PBM = &mdbv1.BackupPBMStatus{Name: "2021-06-01T12:00:05Z", Type: mdbv1.PBMBackupLogical}
+	restore := newTestRestore()
+	r, mgr := setupRestore(t, restore, &mdb, &backup)
+	config := secret.Builder().SetName(mdb.PBMConfigSecretName()).SetNamespace(mdb.Namespace).SetField(construct.PBMConfigKey, "").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(config))
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+	assert.Equal(t, "s3://my-bucket/pbm", restore.Status.Location)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	pbm := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, pbm.Command[2], "pbm restore")
+	assert.Contains(t, pbm.Env, corev1.EnvVar{Name: "PBM_BACKUP_NAME", Value: "2021-06-01T12:00:05Z"})
+}
+
+func TestPBMRestore_RestoresAPointInTime(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("restore-user")
+	restore := newTestRestore()
+	restore.Spec.BackupRef = nil
+	targetTime := metav1.NewTime(time.Date(2021, 6, 1, 14, 30, 0, 0, time.UTC))
+	restore.Spec.PBM = &mdbv1.PBMRestoreSource{TargetTime: &targetTime}
+	r, mgr := setupRestore(t, restore, &mdb)
+	config := secret.Builder().SetName(mdb.PBMConfigSecretName()).SetNamespace(mdb.Namespace).SetField(construct.PBMConfigKey, "").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(config))
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	pbm := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, pbm.Command[2], "pbm config --set pitr.enabled=false")
+	assert.Contains(t, pbm.Env, corev1.EnvVar{Name: "PBM_TARGET_TIME", Value: "2021-06-01T14:30:00"})
+}
+
+func TestPBMRestore_PhysicalBackupsAreNotRestored(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("restore-user")
+	backup := newTestPBMBackup(&mdbv1.PBMBackupOptions{Type: mdbv1.PBMBackupPhysical})
+	backup.Status.Phase = mdbv1.BackupCompleted
+	restore := newTestRestore()
+	r, mgr := setupRestore(t, restore, &mdb, &backup)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreFailed, restore.Status.Phase)
+	assert.Equal(t, "Backup my-backup is a Physical PBM backup, which PBM restores by restarting mongod, and can not be restored by the operator", restore.Status.Message)
+}
+
+func TestPBMRestore_PointInTimeRequiresPointInTimeRecovery(t *testing.T) {
+	mdb := newRunningReplicaSetWithPBM("restore-user")
+	mdb.Spec.PBM.PointInTimeRecovery = nil
+	restore := newTestRestore()
+	restore.Spec.BackupRef = nil
+	restore.Spec.PBM = &mdbv1.PBMRestoreSource{TargetTime: &metav1.Time{Time: time.Now()}}
+	r, mgr := setupRestore(t, restore, &mdb)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreFailed, restore.Status.Phase)
+	assert.Equal(t, "Point-in-time recovery is not enabled in MongoDBCommunity resource my-rs", restore.Status.Message)
+}
@@ -0,0 +1,514 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	backupScheduleLoggerName = "backup-schedule"
+
+	// maxMissedSchedules bounds how many scheduled times are skipped over when the operator was not running.
+	maxMissedSchedules = 10000
+)
+
+// BackupScheduleReconciler creates the backups of MongoDBCommunityBackupSchedule resources, deletes the backups
+// which expired and captures the oplog between backups.
+type BackupScheduleReconciler struct {
+	client   kubernetesClient.Client
+	log      *zap.SugaredLogger
+	recorder record.EventRecorder
+}
+
+func NewBackupScheduleReconciler(mgr manager.Manager) *BackupScheduleReconciler {
+	return &BackupScheduleReconciler{
+		client:   kubernetesClient.NewClient(mgr.GetClient()),
+		log:      zap.S().Named(backupScheduleLoggerName),
+		recorder: mgr.GetEventRecorderFor("mongodbcommunitybackupschedule-controller"),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager and configures the necessary watches.
+func (r *BackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mdbv1.MongoDBCommunityBackupSchedule{}).
+		Owns(&mdbv1.MongoDBCommunityBackup{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitybackupschedule,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitybackupschedule/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitybackup,verbs=create;delete
+
+// Reconcile deletes the expired backups of the schedule and creates a backup when the schedule is due. A backup
+// is not created while an earlier backup of the schedule is still running, and only the most recent of the
+// scheduled times missed while the operator was not running is backed up. If the oplog is captured, a Job
+// uploads the oplog entries written since the last capture at every interval.
+func (r BackupScheduleReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	schedule := mdbv1.MongoDBCommunityBackupSchedule{}
+	if err := r.client.Get(ctx, request.NamespacedName, &schedule); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return result.OK()
+		}
+		r.log.Errorf("Error reconciling MongoDBCommunityBackupSchedule resource: %s", err)
+		return result.Failed()
+	}
+	r.log = zap.S().Named(backupScheduleLoggerName).With("BackupSchedule", request.NamespacedName)
+
+	cronSchedule, err := cron.ParseStandard(schedule.Spec.Schedule)
+	if err != nil {
+		schedule.Status.NextScheduleTime = nil
+		return r.updateScheduleStatus(schedule, fmt.Sprintf("Invalid schedule %q: %s", schedule.Spec.Schedule, err))
+	}
+
+	backups, err := scheduledBackups(r.client, schedule)
+	if err != nil {
+		r.log.Errorf("Could not list the backups of the schedule: %s", err)
+		return result.Failed()
+	}
+
+	now := time.Now()
+	message, expiring := r.deleteExpiredBackups(schedule, backups, now)
+
+	running := hasRunningBackup(backups)
+	schedule.Status.NextScheduleTime = nil
+	var nextCapture *time.Time
+	if !schedule.Spec.Suspend {
+		capturing, next, captureMessage, err := r.captureOplog(&schedule, backups, now)
+		if err != nil {
+			r.log.Errorf("Could not capture the oplog: %s", err)
+			return result.Failed()
+		}
+		if captureMessage != "" {
+			message = captureMessage
+		}
+		running = running || capturing
+		nextCapture = next
+
+		since := schedule.CreationTimestamp.Time
+		if schedule.Status.LastScheduleTime != nil {
+			since = schedule.Status.LastScheduleTime.Time
+		}
+		if scheduledTime, ok := lastMissedSchedule(cronSchedule, since, now); ok {
+			created, err := r.createScheduledBackup(schedule, backups, scheduledTime)
+			if err != nil {
+				r.log.Errorf("Could not create the backup scheduled at %s: %s", scheduledTime.UTC().Format(time.RFC3339), err)
+				return result.Failed()
+			}
+			running = running || created
+			lastScheduleTime := metav1.NewTime(scheduledTime)
+			schedule.Status.LastScheduleTime = &lastScheduleTime
+		}
+		nextScheduleTime := metav1.NewTime(cronSchedule.Next(now))
+		schedule.Status.NextScheduleTime = &nextScheduleTime
+	}
+	schedule.Status.LastSuccessfulBackup = lastSuccessfulBackup(backups)
+
+	if _, err := r.updateScheduleStatus(schedule, message); err != nil {
+		return result.Failed()
+	}
+	if expiring || running {
+		return result.Retry(backupPollInterval)
+	}
+	if nextCapture != nil && (schedule.Status.NextScheduleTime == nil || nextCapture.Before(schedule.Status.NextScheduleTime.Time)) {
+		return reconcile.Result{RequeueAfter: nextCapture.Sub(now)}, nil
+	}
+	if schedule.Status.NextScheduleTime != nil {
+		return reconcile.Result{RequeueAfter: schedule.Status.NextScheduleTime.Sub(now)}, nil
+	}
+	return result.OK()
+}
+
+// scheduledBackups returns the backups created by the given schedule.
+func scheduledBackups(c kubernetesClient.Client, schedule mdbv1.MongoDBCommunityBackupSchedule) ([]mdbv1.MongoDBCommunityBackup, error) {
+	list := mdbv1.MongoDBCommunityBackupList{}
+	if err := c.List(context.TODO(), &list, k8sClient.InNamespace(schedule.Namespace), k8sClient.MatchingLabels{mdbv1.BackupScheduleLabel: schedule.Name}); err != nil {
+		return nil, err
+	}
+	var backups []mdbv1.MongoDBCommunityBackup
+	for _, backup := range list.Items {
+		if metav1.IsControlledBy(&backup, &schedule) {
+			backups = append(backups, backup)
+		}
+	}
+	return backups, nil
+}
+
+// createScheduledBackup creates the backup for the given scheduled time, unless a backup is still running. It
+// returns true if the backup was created.
+func (r BackupScheduleReconciler) createScheduledBackup(schedule mdbv1.MongoDBCommunityBackupSchedule, backups []mdbv1.MongoDBCommunityBackup, scheduledTime time.Time) (bool, error) {
+	for _, backup := range backups {
+		if !backup.IsFinished() {
+			r.log.Infof("Skipping the backup scheduled at %s, backup %s is still running", scheduledTime.UTC().Format(time.RFC3339), backup.Name)
+			r.recordEvent(&schedule, corev1.EventTypeWarning, "BackupSkipped", "Skipped the backup scheduled at %s, backup %s is still running", scheduledTime.UTC().Format(time.RFC3339), backup.Name)
+			return false, nil
+		}
+	}
+
+	backup := mdbv1.MongoDBCommunityBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            schedule.BackupName(scheduledTime),
+			Namespace:       schedule.Namespace,
+			Labels:          map[string]string{mdbv1.BackupScheduleLabel: schedule.Name},
+			OwnerReferences: schedule.GetOwnerReferences(),
+		},
+		Spec: schedule.Spec.Template,
+	}
+	if err := r.client.Create(context.TODO(), &backup); err != nil {
+		if apiErrors.IsAlreadyExists(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	r.log.Infof("Created backup %s", backup.Name)
+	r.recordEvent(&schedule, corev1.EventTypeNormal, "BackupCreated", "Created backup %s", backup.Name)
+	return true, nil
+}
+
+// deleteExpiredBackups deletes the failed backups and the backups expired according to the retention of the
+// schedule. The archive of a completed backup is deleted by a Job before the backup itself is deleted, while a
+// VolumeSnapshot is deleted together with its backup. It returns a message if an archive could not be deleted,
+// and whether any archive is still being deleted.
+func (r BackupScheduleReconciler) deleteExpiredBackups(schedule mdbv1.MongoDBCommunityBackupSchedule, backups []mdbv1.MongoDBCommunityBackup, now time.Time) (string, bool) {
+	message := ""
+	expiring := false
+	for _, backup := range expiredBackups(schedule.Spec.Retention, backups, now) {
+		if backup.Status.Phase == mdbv1.BackupFailed {
+			if err := r.client.Delete(context.TODO(), &backup); err != nil && !apiErrors.IsNotFound(err) {
+				r.log.Warnf("Could not delete failed backup %s: %s", backup.Name, err)
+			}
+			continue
+		}
+
+		if backup.GetMethod() == mdbv1.BackupMethodSnapshot {
+			// the VolumeSnapshot is owned by the backup and deleted together with it
+			if err := r.client.Delete(context.TODO(), &backup); err != nil && !apiErrors.IsNotFound(err) {
+				r.log.Warnf("Could not delete expired backup %s: %s", backup.Name, err)
+				continue
+			}
+			r.log.Infof("Deleted expired backup %s and its VolumeSnapshot", backup.Name)
+			r.recordEvent(&schedule, corev1.EventTypeNormal, "BackupExpired", "Deleted expired backup %s and its VolumeSnapshot", backup.Name)
+			continue
+		}
+
+		deleted, err := r.deleteArchive(backup)
+		if err != nil {
+			r.log.Warnf("Could not delete the archive of backup %s: %s", backup.Name, err)
+			message = fmt.Sprintf("Could not delete the archive of backup %s: %s", backup.Name, err)
+			continue
+		}
+		if !deleted {
+			expiring = true
+			continue
+		}
+		if err := r.client.Delete(context.TODO(), &backup); err != nil && !apiErrors.IsNotFound(err) {
+			r.log.Warnf("Could not delete expired backup %s: %s", backup.Name, err)
+			continue
+		}
+		r.log.Infof("Deleted expired backup %s and its archive %s", backup.Name, backup.Status.Location)
+		r.recordEvent(&schedule, corev1.EventTypeNormal, "BackupExpired", "Deleted expired backup %s and its archive %s", backup.Name, backup.Status.Location)
+	}
+	return message, expiring
+}
+
+// deleteArchive creates the Job deleting the archive of the given backup, and returns true once the Job has
+// completed. A PBM backup is deleted from the storage of the agents with the pbm CLI. The Job is owned by the
+// backup, so that it is deleted together with it.
+func (r BackupScheduleReconciler) deleteArchive(backup mdbv1.MongoDBCommunityBackup) (bool, error) {
+	jobName := fmt.Sprintf("%s-expire", backup.Name)
+	job := batchv1.Job{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: jobName, Namespace: backup.Namespace}, &job)
+	if apiErrors.IsNotFound(err) {
+		// the security context preset of the resource is applied if it still exists
+		mdb := mdbv1.MongoDBCommunity{}
+		if err := r.client.Get(context.TODO(), backup.MongoDBResourceNamespacedName(), &mdb); err != nil && !apiErrors.IsNotFound(err) {
+			return false, err
+		}
+		if backup.GetMethod() == mdbv1.BackupMethodPBM {
+			if backup.Status.PBM == nil || backup.Status.PBM.Name == "" {
+				return true, nil
+			}
+			user, ok := findUser(mdb, backup.Spec.User)
+			if !mdb.IsPBMEnabled() || !ok {
+				return false, errors.Errorf("PBM backup %s can only be deleted while PBM is enabled in MongoDBCommunity resource %s with user %s", backup.Status.PBM.Name, backup.Spec.MongoDBResourceRef.Name, backup.Spec.User)
+			}
+			job = construct.BuildPBMDeletionJob(&mdb, pbmJobOptions(mdb, jobName, user.GetConnectionStringSecretName(mdb.Name)), backup.Status.PBM.Name)
+		} else {
+			job = construct.BuildBackupDeletionJob(&mdb, construct.BackupJobOptions{
+				Name:                  jobName,
+				Namespace:             backup.Namespace,
+				UploadImage:           backup.GetImage(),
+				URL:                   backup.Status.Location,
+				Endpoint:              backup.Spec.S3.Endpoint,
+				Region:                backup.Spec.S3.Region,
+				CredentialsSecretName: backup.Spec.S3.CredentialsSecretRef.Name,
+				AccessKeyIDKey:        mdbv1.S3AccessKeyIDKey,
+				SecretAccessKeyKey:    mdbv1.S3SecretAccessKeyKey,
+			})
+		}
+		job.OwnerReferences = backup.GetOwnerReferences()
+		if mdb.Name != "" {
+			setJobMetadata(mdb, &job)
+		}
+		if err := r.client.Create(context.TODO(), &job); err != nil && !apiErrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return false, errors.Errorf("job %s failed: %s", job.Name, c.Message)
+		}
+	}
+	return false, nil
+}
+
+// captureOplog finishes the capture of the current oplog slice, and creates the Job capturing the next slice
+// once the interval has passed since the end of the last slice. The first slice starts when the first backup of
+// the schedule started. It returns whether a slice is being captured, when the next slice is due, and a message
+// if the capture of a slice failed.
+func (r BackupScheduleReconciler) captureOplog(schedule *mdbv1.MongoDBCommunityBackupSchedule, backups []mdbv1.MongoDBCommunityBackup, now time.Time) (bool, *time.Time, string, error) {
+	if schedule.Spec.Oplog == nil {
+		schedule.Status.Oplog = nil
+		return false, nil, "", nil
+	}
+	if (mdbv1.MongoDBCommunityBackup{Spec: schedule.Spec.Template}).GetMethod() != mdbv1.BackupMethodDump {
+		schedule.Status.Oplog = nil
+		return false, nil, "The oplog is only captured for backups taken with the Dump method", nil
+	}
+	if schedule.Status.Oplog == nil {
+		schedule.Status.Oplog = &mdbv1.OplogCaptureStatus{}
+	}
+	status := schedule.Status.Oplog
+
+	message := ""
+	if status.CapturingUntil != nil {
+		job := batchv1.Job{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: schedule.OplogJobName(status.CapturingUntil.Time), Namespace: schedule.Namespace}, &job)
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return false, nil, "", err
+		}
+		finished := apiErrors.IsNotFound(err)
+		for _, c := range job.Status.Conditions {
+			if c.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batchv1.JobComplete:
+				status.CapturedUntil = status.CapturingUntil
+				finished = true
+			case batchv1.JobFailed:
+				r.log.Warnf("Oplog capture Job %s failed: %s", job.Name, c.Message)
+				r.recordEvent(schedule, corev1.EventTypeWarning, "OplogCaptureFailed", "Job %s failed: %s", job.Name, c.Message)
+				message = fmt.Sprintf("Could not capture the oplog until %s: job %s failed: %s", status.CapturingUntil.UTC().Format(time.RFC3339), job.Name, c.Message)
+				finished = true
+			}
+		}
+		if !finished {
+			return true, nil, "", nil
+		}
+		if job.Name != "" {
+			if err := r.client.Delete(context.TODO(), &job, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apiErrors.IsNotFound(err) {
+				return false, nil, "", err
+			}
+		}
+		status.CapturingUntil = nil
+	}
+
+	from := status.CapturedUntil
+	if from == nil {
+		from = oplogCaptureStart(*schedule, backups)
+	}
+	if from == nil {
+		// there is nothing to replay the oplog onto before the first backup has started
+		return false, nil, message, nil
+	}
+	next := from.Add(schedule.Spec.Oplog.Interval.Duration)
+	if now.Before(next) {
+		return false, &next, message, nil
+	}
+
+	mdb := mdbv1.MongoDBCommunity{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: schedule.Spec.Template.MongoDBResourceRef.Name, Namespace: schedule.Namespace}, &mdb); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, nil, fmt.Sprintf("MongoDBCommunity resource %s does not exist", schedule.Spec.Template.MongoDBResourceRef.Name), nil
+		}
+		return false, nil, "", err
+	}
+	user, ok := findUser(mdb, schedule.Spec.Template.User)
+	if !ok {
+		return false, nil, fmt.Sprintf("User %s is not a user of MongoDBCommunity resource %s", schedule.Spec.Template.User, mdb.Name), nil
+	}
+
+	until := metav1.NewTime(now.Truncate(time.Second))
+	opts := construct.OplogCaptureJobOptions{
+		BackupJobOptions: construct.BackupJobOptions{
+			Name:                       schedule.OplogJobName(until.Time),
+			Namespace:                  schedule.Namespace,
+			ConnectionStringSecretName: user.GetConnectionStringSecretName(mdb.Name),
+			ConnectionStringKey:        connectionStringStandardKey,
+			UploadImage:                mdbv1.MongoDBCommunityBackup{Spec: schedule.Spec.Template}.GetImage(),
+			URL:                        schedule.OplogSliceLocation(from.Time, until.Time),
+			Endpoint:                   schedule.Spec.Template.S3.Endpoint,
+			Region:                     schedule.Spec.Template.S3.Region,
+			CredentialsSecretName:      schedule.Spec.Template.S3.CredentialsSecretRef.Name,
+			AccessKeyIDKey:             mdbv1.S3AccessKeyIDKey,
+			SecretAccessKeyKey:         mdbv1.S3SecretAccessKeyKey,
+			Encryption:                 encryptionOptions(schedule.Spec.Template.Encryption, schedule.Spec.Template.S3.Region),
+		},
+		From:  from.Time,
+		Until: until.Time,
+	}
+	if mdb.Spec.Security.TLS.Enabled {
+		opts.CAConfigMapName = mdb.Spec.Security.TLS.CaConfigMap.Name
+	}
+	job := construct.BuildOplogCaptureJob(&mdb, opts)
+	job.OwnerReferences = schedule.GetOwnerReferences()
+	setJobMetadata(mdb, &job)
+	if err := r.client.Create(context.TODO(), &job); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return false, nil, "", err
+	}
+	r.log.Debugf("Capturing the oplog from %s until %s", from.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339))
+	if status.CapturedSince == nil {
+		status.CapturedSince = from
+	}
+	status.CapturingUntil = &until
+	return true, nil, message, nil
+}
+
+// updateScheduleStatus updates the status of the schedule with the given message.
+func (r BackupScheduleReconciler) updateScheduleStatus(schedule mdbv1.MongoDBCommunityBackupSchedule, message string) (reconcile.Result, error) {
+	schedule.Status.Message = message
+	if err := r.client.Status().Update(context.TODO(), &schedule); err != nil {
+		r.log.Errorf("Error updating the status of the MongoDBCommunityBackupSchedule resource: %s", err)
+		return reconcile.Result{}, err
+	}
+	return result.OK()
+}
+
+func (r BackupScheduleReconciler) recordEvent(schedule *mdbv1.MongoDBCommunityBackupSchedule, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.recorder != nil {
+		r.recorder.Eventf(schedule, eventType, reason, messageFmt, args...)
+	}
+}
+
+// lastMissedSchedule returns the most recent time the schedule was due after the given time and not after now.
+func lastMissedSchedule(schedule cron.Schedule, since, now time.Time) (time.Time, bool) {
+	var missed time.Time
+	for t, i := schedule.Next(since), 0; !t.After(now) && i < maxMissedSchedules; t, i = schedule.Next(t), i+1 {
+		missed = t
+	}
+	return missed, !missed.IsZero()
+}
+
+// expiredBackups returns the backups which are expired according to the given retention. Completed backups
+// expire when they are beyond the retained count or older than the maximum age, failed backups expire once a
+// later backup has completed. Running backups never expire.
+func expiredBackups(retention mdbv1.BackupRetention, backups []mdbv1.MongoDBCommunityBackup, now time.Time) []mdbv1.MongoDBCommunityBackup {
+	var completed, failed []mdbv1.MongoDBCommunityBackup
+	for _, backup := range backups {
+		switch backup.Status.Phase {
+		case mdbv1.BackupCompleted:
+			completed = append(completed, backup)
+		case mdbv1.BackupFailed:
+			failed = append(failed, backup)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completed[j].CreationTimestamp.Before(&completed[i].CreationTimestamp)
+	})
+
+	var expired []mdbv1.MongoDBCommunityBackup
+	for i, backup := range completed {
+		beyondCount := retention.Count != nil && i >= *retention.Count
+		tooOld := retention.MaxAge != nil && backup.Status.CompletionTime != nil && now.Sub(backup.Status.CompletionTime.Time) > retention.MaxAge.Duration
+		if beyondCount || tooOld {
+			expired = append(expired, backup)
+		}
+	}
+	if len(completed) > 0 {
+		latest := completed[0].CreationTimestamp
+		for _, backup := range failed {
+			if backup.CreationTimestamp.Before(&latest) {
+				expired = append(expired, backup)
+			}
+		}
+	}
+	return expired
+}
+
+// lastSuccessfulBackup returns the name of the most recently created completed backup.
+func lastSuccessfulBackup(backups []mdbv1.MongoDBCommunityBackup) string {
+	var latest *mdbv1.MongoDBCommunityBackup
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Status.Phase != mdbv1.BackupCompleted {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&backup.CreationTimestamp) {
+			latest = backup
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Name
+}
+
+// oplogCaptureStart returns when the capture of the oplog starts, which is the start of the earliest backup of
+// the schedule, or the start of the first slice once its capture has started.
+func oplogCaptureStart(schedule mdbv1.MongoDBCommunityBackupSchedule, backups []mdbv1.MongoDBCommunityBackup) *metav1.Time {
+	if schedule.Status.Oplog != nil && schedule.Status.Oplog.CapturedSince != nil {
+		return schedule.Status.Oplog.CapturedSince
+	}
+	var start *metav1.Time
+	for _, backup := range backups {
+		if backup.Status.StartTime != nil && (start == nil || backup.Status.StartTime.Before(start)) {
+			start = backup.Status.StartTime
+		}
+	}
+	return start
+}
+
+func hasRunningBackup(backups []mdbv1.MongoDBCommunityBackup) bool {
+	for _, backup := range backups {
+		if !backup.IsFinished() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func newTestBackupSchedule(created time.Time) mdbv1.MongoDBCommunityBackupSchedule {
+	return mdbv1.MongoDBCommunityBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "my-schedule",
+			Namespace:         "my-ns",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: mdbv1.MongoDBCommunityBackupScheduleSpec{
+			Schedule: "0 * * * *",
+			Template: newTestBackup().Spec,
+		},
+	}
+}
+
+// newScheduledBackup returns a backup of the given schedule created at the given time in the given phase.
+func newScheduledBackup(schedule mdbv1.MongoDBCommunityBackupSchedule, created time.Time, phase mdbv1.BackupPhase) mdbv1.MongoDBCommunityBackup {
+	backup := mdbv1.MongoDBCommunityBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              schedule.BackupName(created),
+			Namespace:         schedule.Namespace,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{mdbv1.BackupScheduleLabel: schedule.Name},
+			OwnerReferences:   schedule.GetOwnerReferences(),
+		},
+		Spec: schedule.Spec.Template,
+	}
+	backup.Status.Phase = phase
+	if phase == mdbv1.BackupCompleted {
+		completionTime := metav1.NewTime(created.Add(time.Minute))
+		backup.Status.CompletionTime = &completionTime
+		backup.Status.Location = backup.ArchiveLocation()
+	}
+	return backup
+}
+
+func setupBackupSchedule(t *testing.T, schedule mdbv1.MongoDBCommunityBackupSchedule, backups ...mdbv1.MongoDBCommunityBackup) (*BackupScheduleReconciler, *client.MockedManager) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &schedule))
+	for i := range backups {
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &backups[i]))
+	}
+	return NewBackupScheduleReconciler(mgr), mgr
+}
+
+func reconcileBackupSchedule(t *testing.T, r *BackupScheduleReconciler, mgr *client.MockedManager, schedule mdbv1.MongoDBCommunityBackupSchedule) (reconcile.Result, mdbv1.MongoDBCommunityBackupSchedule) {
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: schedule.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), schedule.NamespacedName(), &schedule))
+	return res, schedule
+}
+
+func listScheduledBackups(t *testing.T, mgr *client.MockedManager) []mdbv1.MongoDBCommunityBackup {
+	list := mdbv1.MongoDBCommunityBackupList{}
+	assert.NoError(t, mgr.GetClient().List(context.TODO(), &list))
+	return list.Items
+}
+
+func TestBackupSchedule_CreatesBackupWhenDue(t *testing.T) {
+	schedule := newTestBackupSchedule(time.Now().Add(-2 * time.Hour))
+	r, mgr := setupBackupSchedule(t, schedule)
+
+	res, schedule := reconcileBackupSchedule(t, r, mgr, schedule)
+	backups := listScheduledBackups(t, mgr)
+	assert.Len(t, backups, 1, "only the most recent missed schedule is backed up")
+	assert.Equal(t, schedule.BackupName(schedule.Status.LastScheduleTime.Time), backups[0].Name)
+	assert.Equal(t, "my-schedule", backups[0].Labels[mdbv1.BackupScheduleLabel])
+	assert.True(t, metav1.IsControlledBy(&backups[0], &schedule))
+	assert.Equal(t, schedule.Spec.Template, backups[0].Spec)
+
+	assert.Equal(t, 0, schedule.Status.LastScheduleTime.Minute())
+	assert.True(t, schedule.Status.NextScheduleTime.After(time.Now()))
+	assert.Equal(t, time.Duration(backupPollInterval)*time.Second, res.RequeueAfter, "the running backup is polled")
+
+	t.Run("No backup is created until the schedule is due again", func(t *testing.T) {
+		_, _ = reconcileBackupSchedule(t, r, mgr, schedule)
+		assert.Len(t, listScheduledBackups(t, mgr), 1)
+	})
+}
+
+func TestBackupSchedule_SkipsScheduleWhileBackupIsRunning(t *testing.T) {
+	schedule := newTestBackupSchedule(time.Now().Add(-3 * time.Hour))
+	running := newScheduledBackup(schedule, time.Now().Add(-2*time.Hour), mdbv1.BackupDumping)
+	r, mgr := setupBackupSchedule(t, schedule, running)
+
+	_, schedule = reconcileBackupSchedule(t, r, mgr, schedule)
+	backups := listScheduledBackups(t, mgr)
+	assert.Len(t, backups, 1)
+	assert.Equal(t, running.Name, backups[0].Name)
+	assert.NotNil(t, schedule.Status.LastScheduleTime, "the skipped schedule is not backed up later")
+}
+
+func TestBackupSchedule_Suspended(t *testing.T) {
+	schedule := newTestBackupSchedule(time.Now().Add(-2 * time.Hour))
+	schedule.Spec.Suspend = true
+	r, mgr := setupBackupSchedule(t, schedule)
+
+	res, schedule := reconcileBackupSchedule(t, r, mgr, schedule)
+	assert.Empty(t, listScheduledBackups(t, mgr))
+	assert.Nil(t, schedule.Status.NextScheduleTime)
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func TestBackupSchedule_InvalidSchedule(t *testing.T) {
+	schedule := newTestBackupSchedule(time.Now().Add(-2 * time.Hour))
+	schedule.Spec.Schedule = "every night"
+	r, mgr := setupBackupSchedule(t, schedule)
+
+	res, schedule := reconcileBackupSchedule(t, r, mgr, schedule)
+	assert.Contains(t, schedule.Status.Message, `Invalid schedule "every night"`)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.Empty(t, listScheduledBackups(t, mgr))
+}
+
+func TestBackupSchedule_DeletesExpiredBackupsWithTheirArchive(t *testing.T) {
+	now := time.Now()
+	schedule := newTestBackupSchedule(now.Add(-4 * time.Hour))
+	schedule.Spec.Suspend = true
+	count := 1
+	schedule.Spec.Retention.Count = &count
+	old := newScheduledBackup(schedule, now.Add(-3*time.Hour), mdbv1.BackupCompleted)
+	failed := newScheduledBackup(schedule, now.Add(-2*time.Hour), mdbv1.BackupFailed)
+	latest := newScheduledBackup(schedule, now.Add(-1*time.Hour), mdbv1.BackupCompleted)
+	r, mgr := setupBackupSchedule(t, schedule, old, failed, latest)
+
+	res, schedule := reconcileBackupSchedule(t, r, mgr, schedule)
+	assert.Equal(t, latest.Name, schedule.Status.LastSuccessfulBackup)
+	assert.Equal(t, time.Duration(backupPollInterval)*time.Second, res.RequeueAfter, "the deletion of the archive is polled")
+
+	backups := listScheduledBackups(t, mgr)
+	assert.Len(t, backups, 2, "the failed backup is deleted")
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: old.Name + "-expire", Namespace: old.Namespace}, &job))
+	assert.True(t, metav1.IsControlledBy(&job, &old))
+	deleteContainer := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, construct.BackupDeleteContainerName, deleteContainer.Name)
+	assert.Contains(t, deleteContainer.Command[2], "aws s3 rm")
+	assert.Contains(t, deleteContainer.Env, corev1.EnvVar{Name: "S3_URL", Value: old.Status.Location})
+
+	t.Run("The backup is deleted once its archive is deleted", func(t *testing.T) {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+		_, _ = reconcileBackupSchedule(t, r, mgr, schedule)
+		backups := listScheduledBackups(t, mgr)
+		assert.Len(t, backups, 1)
+		assert.Equal(t, latest.Name, backups[0].Name)
+	})
+}
+
+func TestExpiredBackups(t *testing.T) {
+	now := time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)
+	schedule := newTestBackupSchedule(now.Add(-30 * 24 * time.Hour))
+	day := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	backups := []mdbv1.MongoDBCommunityBackup{
+		newScheduledBackup(schedule, day(1), mdbv1.BackupDumping),
+		newScheduledBackup(schedule, day(2), mdbv1.BackupFailed),
+		newScheduledBackup(schedule, day(3), mdbv1.BackupCompleted),
+		newScheduledBackup(schedule, day(4), mdbv1.BackupFailed),
+		newScheduledBackup(schedule, day(5), mdbv1.BackupCompleted),
+		newScheduledBackup(schedule, day(9), mdbv1.BackupCompleted),
+	}
+	names := func(backups []mdbv1.MongoDBCommunityBackup) []string {
+		var names []string
+		for _, b := range backups {
+			names = append(names, b.Name)
+		}
+		return names
+	}
+
+	t.Run("Without retention only failed backups before a completed backup expire", func(t *testing.T) {
+		assert.Equal(t, []string{backups[3].Name}, names(expiredBackups(mdbv1.BackupRetention{}, backups, now)))
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		count := 2
+		expired := expiredBackups(mdbv1.BackupRetention{Count: &count}, backups, now)
+		assert.Equal(t, []string{backups[5].Name, backups[3].Name}, names(expired))
+	})
+
+	t.Run("MaxAge", func(t *testing.T) {
+		maxAge := metav1.Duration{Duration: 96 * time.Hour}
+		expired := expiredBackups(mdbv1.BackupRetention{MaxAge: &maxAge}, backups, now)
+		assert.Equal(t, []string{backups[4].Name, backups[5].Name, backups[3].Name}, names(expired))
+	})
+}
+
+func TestLastMissedSchedule(t *testing.T) {
+	hourly, err := cron.ParseStandard("0 * * * *")
+	assert.NoError(t, err)
+	since := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	_, ok := lastMissedSchedule(hourly, since, since.Add(20*time.Minute))
+	assert.False(t, ok)
+
+	missed, ok := lastMissedSchedule(hourly, since, since.Add(3*time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 6, 1, 15, 0, 0, 0, time.UTC), missed)
+}
+
+func TestBackupSchedule_CapturesTheOplog(t *testing.T) {
+	now := time.Now()
+	schedule := newTestBackupSchedule(now.Add(-30 * time.Minute))
+	// yearly, so that the next backup is not due before the next slice
+	schedule.Spec.Schedule = "0 0 1 1 *"
+	schedule.Spec.Oplog = &mdbv1.OplogCapture{Interval: metav1.Duration{Duration: 10 * time.Minute}}
+	backup := newScheduledBackup(schedule, now.Add(-25*time.Minute), mdbv1.BackupCompleted)
+	backupStart := metav1.NewTime(now.Add(-25 * time.Minute).Truncate(time.Second))
+	backup.Status.StartTime = &backupStart
+	mdb := newTestReplicaSet()
+	mdb.Spec.Users = []mdbv1.MongoDBUser{{Name: "backup-user", DB: "admin", PasswordSecretRef: mdbv1.SecretKeyReference{Name: "backup-user-password"}}}
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &schedule))
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &backup))
+	r := NewBackupScheduleReconciler(mgr)
+
+	res, schedule := reconcileBackupSchedule(t, r, mgr, schedule)
+	oplog := schedule.Status.Oplog
+	assert.NotNil(t, oplog)
+	assert.True(t, oplog.CapturedSince.Equal(&backupStart), "the oplog is captured from the start of the first backup")
+	assert.Nil(t, oplog.CapturedUntil)
+	assert.NotNil(t, oplog.CapturingUntil)
+	assert.Equal(t, time.Duration(backupPollInterval)*time.Second, res.RequeueAfter, "the capture is polled")
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: schedule.OplogJobName(oplog.CapturingUntil.Time), Namespace: schedule.Namespace}, &job))
+	assert.True(t, metav1.IsControlledBy(&job, &schedule))
+	dump := job.Spec.Template.Spec.InitContainers[0]
+	assert.Contains(t, dump.Command[2], "--db=local --collection=oplog.rs")
+	assert.Contains(t, dump.Env, corev1.EnvVar{Name: "OPLOG_FROM", Value: fmt.Sprint(backupStart.Unix())})
+	upload := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, upload.Env, corev1.EnvVar{Name: "S3_URL", Value: schedule.OplogSliceLocation(backupStart.Time, oplog.CapturingUntil.Time)})
+
+	t.Run("The slice is captured once the Job has completed", func(t *testing.T) {
+		capturingUntil := *oplog.CapturingUntil
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+		res, schedule := reconcileBackupSchedule(t, r, mgr, schedule)
+		oplog := schedule.Status.Oplog
+		assert.True(t, oplog.CapturedUntil.Equal(&capturingUntil))
+		assert.Nil(t, oplog.CapturingUntil)
+		assert.True(t, res.RequeueAfter > 9*time.Minute && res.RequeueAfter <= 10*time.Minute, "the next slice is captured after the interval")
+
+		err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, &batchv1.Job{})
+		assert.Error(t, err, "the Job is deleted")
+	})
+}
+
+func TestBackupSchedule_RetriesFailedOplogCapture(t *testing.T) {
+	now := time.Now()
+	since := metav1.NewTime(now.Add(-20 * time.Minute).Truncate(time.Second))
+	capturingUntil := metav1.NewTime(now.Add(-5 * time.Minute).Truncate(time.Second))
+	schedule := newTestBackupSchedule(now.Add(-30 * time.Minute))
+	schedule.Spec.Oplog = &mdbv1.OplogCapture{Interval: metav1.Duration{Duration: 10 * time.Minute}}
+	schedule.Status.Oplog = &mdbv1.OplogCaptureStatus{CapturedSince: &since, CapturingUntil: &capturingUntil}
+	failed := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: schedule.OplogJobName(capturingUntil.Time), Namespace: schedule.Namespace}}
+	failed.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"}}
+	mdb := newTestReplicaSet()
+	mdb.Spec.Users = []mdbv1.MongoDBUser{{Name: "backup-user", DB: "admin", PasswordSecretRef: mdbv1.SecretKeyReference{Name: "backup-user-password"}}}
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &schedule))
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &failed))
+	r := NewBackupScheduleReconciler(mgr)
+
+	_, schedule = reconcileBackupSchedule(t, r, mgr, schedule)
+	assert.Contains(t, schedule.Status.Message, "Could not capture the oplog until")
+	oplog := schedule.Status.Oplog
+	assert.Nil(t, oplog.CapturedUntil)
+	assert.NotNil(t, oplog.CapturingUntil)
+	assert.True(t, oplog.CapturingUntil.After(capturingUntil.Time), "the capture is retried from the same time")
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: schedule.OplogJobName(oplog.CapturingUntil.Time), Namespace: schedule.Namespace}, &job))
+	assert.Contains(t, job.Spec.Template.Spec.InitContainers[0].Env, corev1.EnvVar{Name: "OPLOG_FROM", Value: fmt.Sprint(since.Unix())})
+}
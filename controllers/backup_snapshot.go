@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/locker"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// snapshotPollInterval is how often a VolumeSnapshot is checked while its member is locked for writes.
+	snapshotPollInterval = 2
+
+	// snapshotLockTimeout is how long a member stays locked for writes at most. The backup fails if the
+	// snapshot has not been taken by then.
+	snapshotLockTimeout = 5 * time.Minute
+
+	// snapshotLockCallTimeout bounds each call locking or unlocking a member.
+	snapshotLockCallTimeout = 30 * time.Second
+)
+
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;create;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get
+
+// reconcileSnapshot takes the backup as a VolumeSnapshot of the data volume of a secondary. The secondary is
+// locked for writes until the storage system has taken the snapshot, which happens before the snapshot is
+// ready to use. The VolumeSnapshot is owned by the backup, so that it is deleted together with it.
+func (r BackupReconciler) reconcileSnapshot(backup mdbv1.MongoDBCommunityBackup) (reconcile.Result, error) {
+	if backup.Status.Snapshot == nil {
+		return r.startSnapshot(backup)
+	}
+
+	snapshot, err := r.getVolumeSnapshot(backup)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		r.log.Errorf("Could not get VolumeSnapshot %s: %s", backup.Status.Snapshot.VolumeSnapshotName, err)
+		return result.Failed()
+	}
+	if apiErrors.IsNotFound(err) {
+		if backup.Status.Snapshot.LockTime == nil {
+			return r.finishBackup(backup, mdbv1.BackupFailed, fmt.Sprintf("VolumeSnapshot %s was deleted", backup.Status.Snapshot.VolumeSnapshotName))
+		}
+		// the member was locked, but the VolumeSnapshot could not be created yet
+		if err := r.createVolumeSnapshot(backup); err != nil {
+			r.log.Errorf("Could not create VolumeSnapshot %s: %s", backup.Status.Snapshot.VolumeSnapshotName, err)
+		}
+		return result.Retry(snapshotPollInterval)
+	}
+
+	snapshotErr, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+	_, taken, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime")
+	readyToUse, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+
+	if lockTime := backup.Status.Snapshot.LockTime; lockTime != nil {
+		timedOut := time.Since(lockTime.Time) > snapshotLockTimeout
+		if !taken && !readyToUse && snapshotErr == "" && !timedOut {
+			return result.Retry(snapshotPollInterval)
+		}
+		if err := r.unlockMember(backup); err != nil {
+			r.log.Errorf("Could not unlock member %s: %s", backup.Status.Snapshot.Member, err)
+			return result.Retry(snapshotPollInterval)
+		}
+		r.log.Infof("Unlocked member %s", backup.Status.Snapshot.Member)
+		backup.Status.Snapshot.LockTime = nil
+		if !taken && !readyToUse && snapshotErr == "" {
+			if err := r.client.Delete(context.TODO(), snapshot); err != nil && !apiErrors.IsNotFound(err) {
+				r.log.Warnf("Could not delete VolumeSnapshot %s: %s", snapshot.GetName(), err)
+			}
+			return r.finishBackup(backup, mdbv1.BackupFailed, fmt.Sprintf("VolumeSnapshot %s was not taken within %s", snapshot.GetName(), snapshotLockTimeout))
+		}
+	}
+
+	if snapshotErr != "" {
+		return r.finishBackup(backup, mdbv1.BackupFailed, fmt.Sprintf("VolumeSnapshot %s failed: %s", snapshot.GetName(), snapshotErr))
+	}
+	if !readyToUse {
+		if _, err := r.updateBackupStatus(backup, mdbv1.BackupSnapshotting, ""); err != nil {
+			return result.Failed()
+		}
+		return result.Retry(backupPollInterval)
+	}
+
+	contentName, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+	content := &unstructured.Unstructured{}
+	content.SetGroupVersionKind(construct.VolumeSnapshotContentGVK)
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: contentName}, content); err != nil {
+		r.log.Warnf("Could not get VolumeSnapshotContent %s: %s", contentName, err)
+	}
+	backup.Status.Snapshot.SnapshotHandle, _, _ = unstructured.NestedString(content.Object, "status", "snapshotHandle")
+	completionTime := metav1.Now()
+	backup.Status.CompletionTime = &completionTime
+	r.log.Infof("Backup stored in VolumeSnapshot %s", snapshot.GetName())
+	return r.finishBackup(backup, mdbv1.BackupCompleted, "")
+}
+
+// startSnapshot locks a secondary for writes, trying the members from the highest ordinal down, and creates the
+// VolumeSnapshot of its data volume. The locked member is recorded in the status before the VolumeSnapshot is
+// created, so that it is unlocked even if the operator restarts.
+func (r BackupReconciler) startSnapshot(backup mdbv1.MongoDBCommunityBackup) (reconcile.Result, error) {
+	mdb, user, message := r.backupResource(backup)
+	if message != "" {
+		return r.updateBackupStatus(backup, mdbv1.BackupPending, message)
+	}
+	hostnames, uri, tlsConfig, message := r.snapshotConnection(mdb, user)
+	if message != "" {
+		return r.updateBackupStatus(backup, mdbv1.BackupPending, message)
+	}
+
+	lockErr := errors.New("no member is a secondary")
+	for i := len(hostnames) - 1; i >= 0; i-- {
+		ctx, cancel := context.WithTimeout(context.Background(), snapshotLockCallTimeout)
+		err := r.locker.Lock(ctx, uri(hostnames[i]), tlsConfig)
+		cancel()
+		if errors.Is(err, locker.ErrNotSecondary) {
+			continue
+		}
+		if err != nil {
+			lockErr = err
+			continue
+		}
+
+		r.log.Infof("Locked member %s for writes", mdb.PodName(i))
+		now := metav1.Now()
+		backup.Status.StartTime = &now
+		backup.Status.Snapshot = &mdbv1.BackupSnapshotStatus{
+			Member:                    mdb.PodName(i),
+			PersistentVolumeClaimName: fmt.Sprintf("%s-%s", mdb.DataVolumeName(), mdb.PodName(i)),
+			VolumeSnapshotName:        backup.VolumeSnapshotName(),
+			LockTime:                  &now,
+		}
+		if _, err := r.updateBackupStatus(backup, mdbv1.BackupSnapshotting, ""); err != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), snapshotLockCallTimeout)
+			defer cancel()
+			if err := r.locker.Unlock(ctx, uri(hostnames[i]), tlsConfig); err != nil {
+				r.log.Errorf("Could not unlock member %s: %s", mdb.PodName(i), err)
+			}
+			return result.Failed()
+		}
+		if err := r.createVolumeSnapshot(backup); err != nil {
+			r.log.Errorf("Could not create VolumeSnapshot %s: %s", backup.VolumeSnapshotName(), err)
+		}
+		return result.Retry(snapshotPollInterval)
+	}
+	return r.updateBackupStatus(backup, mdbv1.BackupPending, fmt.Sprintf("Could not lock a secondary of MongoDBCommunity resource %s: %s", mdb.Name, lockErr))
+}
+
+// snapshotConnection returns the hostnames of the members, a function returning the connection string of the
+// member with the given hostname, and the TLS configuration of the connection. It returns the message the backup
+// is pending with instead if any of them can not be determined.
+func (r BackupReconciler) snapshotConnection(mdb mdbv1.MongoDBCommunity, user mdbv1.MongoDBUser) ([]string, func(string) string, *tls.Config, string) {
+	password, err := secret.ReadKey(r.client, user.GetPasswordSecretKey(), types.NamespacedName{Name: user.PasswordSecretRef.Name, Namespace: mdb.Namespace})
+	if err != nil {
+		return nil, nil, nil, fmt.Sprintf("Could not read the password of user %s: %s", user.Name, err)
+	}
+	tlsConfig, err := operatorTLSConfig(r.client, mdb)
+	if err != nil {
+		return nil, nil, nil, fmt.Sprintf("Could not build the TLS configuration: %s", err)
+	}
+	hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return nil, nil, nil, fmt.Sprintf("Could not determine the member hostnames: %s", err)
+	}
+	uri := func(hostname string) string {
+		return fmt.Sprintf("mongodb://%s@%s/?authSource=%s", url.UserPassword(user.Name, password), net.JoinHostPort(hostname, "27017"), url.QueryEscape(user.GetDB()))
+	}
+	return hostnames, uri, tlsConfig, ""
+}
+
+// unlockMember unlocks the member recorded in the status of the backup. The member is unlocked whatever the
+// phase of the resource is.
+func (r BackupReconciler) unlockMember(backup mdbv1.MongoDBCommunityBackup) error {
+	mdb := mdbv1.MongoDBCommunity{}
+	if err := r.client.Get(context.TODO(), backup.MongoDBResourceNamespacedName(), &mdb); err != nil {
+		return err
+	}
+	user, ok := findUser(mdb, backup.Spec.User)
+	if !ok {
+		return errors.Errorf("user %s is not a user of MongoDBCommunity resource %s", backup.Spec.User, mdb.Name)
+	}
+	hostnames, uri, tlsConfig, message := r.snapshotConnection(mdb, user)
+	if message != "" {
+		return errors.New(message)
+	}
+	for i, hostname := range hostnames {
+		if mdb.PodName(i) != backup.Status.Snapshot.Member {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), snapshotLockCallTimeout)
+		defer cancel()
+		return r.locker.Unlock(ctx, uri(hostname), tlsConfig)
+	}
+	return errors.Errorf("member %s is not a member of MongoDBCommunity resource %s anymore", backup.Status.Snapshot.Member, mdb.Name)
+}
+
+func (r BackupReconciler) createVolumeSnapshot(backup mdbv1.MongoDBCommunityBackup) error {
+	className := ""
+	if backup.Spec.Snapshot != nil {
+		className = backup.Spec.Snapshot.VolumeSnapshotClassName
+	}
+	snapshot := construct.BuildVolumeSnapshot(backup.Status.Snapshot.VolumeSnapshotName, backup.Namespace, backup.Status.Snapshot.PersistentVolumeClaimName, className)
+	snapshot.SetOwnerReferences(backup.GetOwnerReferences())
+	if err := r.client.Create(context.TODO(), snapshot); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (r BackupReconciler) getVolumeSnapshot(backup mdbv1.MongoDBCommunityBackup) (*unstructured.Unstructured, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(construct.VolumeSnapshotGVK)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: backup.Status.Snapshot.VolumeSnapshotName, Namespace: backup.Namespace}, snapshot)
+	return snapshot, err
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/locker"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+// mockLocker locks the members whose hostname is one of the secondaries it is configured with.
+type mockLocker struct {
+	secondaries map[string]bool
+	err         error
+	locked      map[string]bool
+}
+
+func (m *mockLocker) Lock(_ context.Context, connectionString string, _ *tls.Config) error {
+	uri, err := url.Parse(connectionString)
+	if err != nil {
+		return err
+	}
+	if password, _ := uri.User.Password(); password != "backup-password" {
+		return errors.New("authentication failed")
+	}
+	if m.err != nil {
+		return m.err
+	}
+	if !m.secondaries[uri.Hostname()] {
+		return locker.ErrNotSecondary
+	}
+	m.locked[uri.Hostname()] = true
+	return nil
+}
+
+func (m *mockLocker) Unlock(_ context.Context, connectionString string, _ *tls.Config) error {
+	uri, err := url.Parse(connectionString)
+	if err != nil {
+		return err
+	}
+	delete(m.locked, uri.Hostname())
+	return nil
+}
+
+func newTestSnapshotBackup() mdbv1.MongoDBCommunityBackup {
+	backup := newTestBackup()
+	backup.Spec.Method = mdbv1.BackupMethodSnapshot
+	backup.Spec.S3 = mdbv1.S3BackupTarget{}
+	backup.Spec.Snapshot = &mdbv1.SnapshotBackupOptions{VolumeSnapshotClassName: "csi-snapclass"}
+	return backup
+}
+
+func setupSnapshotBackup(t *testing.T, secondaries ...string) (*BackupReconciler, *client.MockedManager, *mockLocker, mdbv1.MongoDBCommunityBackup) {
+	mdb := newTestReplicaSet()
+	mdb.Status.Phase = mdbv1.Running
+	backup := newTestSnapshotBackup()
+	r, mgr := setupBackup(t, mdb, backup)
+	s := secret.Builder().SetName("backup-user-password").SetNamespace(mdb.Namespace).SetField("password", "backup-password").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(s))
+
+	mock := &mockLocker{secondaries: map[string]bool{}, locked: map[string]bool{}}
+	for _, hostname := range secondaries {
+		mock.secondaries[hostname] = true
+	}
+	r.locker = mock
+	return r, mgr, mock, backup
+}
+
+func getVolumeSnapshot(t *testing.T, mgr *client.MockedManager, name string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(construct.VolumeSnapshotGVK)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "my-ns"}, snapshot))
+	return snapshot
+}
+
+func TestBackup_SnapshotsTheVolumeOfALockedSecondary(t *testing.T) {
+	r, mgr, mock, backup := setupSnapshotBackup(t, "my-rs-1.my-rs-svc.my-ns.svc.cluster.local", "my-rs-2.my-rs-svc.my-ns.svc.cluster.local")
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupSnapshotting, backup.Status.Phase)
+	assert.NotNil(t, backup.Status.StartTime)
+	if assert.NotNil(t, backup.Status.Snapshot) {
+		assert.Equal(t, "my-rs-2", backup.Status.Snapshot.Member, "the member with the highest ordinal is locked")
+		assert.Equal(t, "data-volume-my-rs-2", backup.Status.Snapshot.PersistentVolumeClaimName)
+		assert.Equal(t, "my-backup", backup.Status.Snapshot.VolumeSnapshotName)
+		assert.NotNil(t, backup.Status.Snapshot.LockTime)
+	}
+	assert.Equal(t, map[string]bool{"my-rs-2.my-rs-svc.my-ns.svc.cluster.local": true}, mock.locked)
+
+	snapshot := getVolumeSnapshot(t, mgr, "my-backup")
+	pvcName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	assert.Equal(t, "data-volume-my-rs-2", pvcName)
+	className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	assert.Equal(t, "csi-snapclass", className)
+	assert.Equal(t, backup.GetOwnerReferences(), snapshot.GetOwnerReferences())
+
+	t.Run("The member stays locked until the snapshot is taken", func(t *testing.T) {
+		_, backup = reconcileBackup(t, r, mgr, backup)
+		assert.NotNil(t, backup.Status.Snapshot.LockTime)
+		assert.Len(t, mock.locked, 1)
+
+		assert.NoError(t, unstructured.SetNestedField(snapshot.Object, "2021-06-01T12:00:05Z", "status", "creationTime"))
+		assert.NoError(t, unstructured.SetNestedField(snapshot.Object, "snapcontent-1234", "status", "boundVolumeSnapshotContentName"))
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), snapshot))
+
+		_, backup = reconcileBackup(t, r, mgr, backup)
+		assert.Equal(t, mdbv1.BackupSnapshotting, backup.Status.Phase)
+		assert.Nil(t, backup.Status.Snapshot.LockTime)
+		assert.Empty(t, mock.locked)
+	})
+
+	t.Run("The backup completes once the snapshot is ready to use", func(t *testing.T) {
+		content := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"snapshotHandle": "snap-0123456789abcdef"},
+		}}
+		content.SetGroupVersionKind(construct.VolumeSnapshotContentGVK)
+		content.SetName("snapcontent-1234")
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), content))
+
+		snapshot = getVolumeSnapshot(t, mgr, "my-backup")
+		assert.NoError(t, unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse"))
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), snapshot))
+
+		_, backup = reconcileBackup(t, r, mgr, backup)
+		assert.Equal(t, mdbv1.BackupCompleted, backup.Status.Phase)
+		assert.NotNil(t, backup.Status.CompletionTime)
+		assert.Equal(t, "snap-0123456789abcdef", backup.Status.Snapshot.SnapshotHandle)
+	})
+}
+
+func TestBackup_FailsWithTheSnapshot(t *testing.T) {
+	r, mgr, mock, backup := setupSnapshotBackup(t, "my-rs-0.my-rs-svc.my-ns.svc.cluster.local")
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, "my-rs-0", backup.Status.Snapshot.Member)
+
+	snapshot := getVolumeSnapshot(t, mgr, "my-backup")
+	assert.NoError(t, unstructured.SetNestedField(snapshot.Object, "failed to take snapshot of the volume", "status", "error", "message"))
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), snapshot))
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupFailed, backup.Status.Phase)
+	assert.Equal(t, "VolumeSnapshot my-backup failed: failed to take snapshot of the volume", backup.Status.Message)
+	assert.Empty(t, mock.locked, "the member is unlocked")
+}
+
+func TestBackup_UnlocksTheMemberIfTheSnapshotIsNotTakenInTime(t *testing.T) {
+	r, mgr, mock, backup := setupSnapshotBackup(t, "my-rs-2.my-rs-svc.my-ns.svc.cluster.local")
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	lockTime := metav1.NewTime(time.Now().Add(-snapshotLockTimeout - time.Minute))
+	backup.Status.Snapshot.LockTime = &lockTime
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &backup))
+
+	_, backup = reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupFailed, backup.Status.Phase)
+	assert.Equal(t, "VolumeSnapshot my-backup was not taken within 5m0s", backup.Status.Message)
+	assert.Empty(t, mock.locked)
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(construct.VolumeSnapshotGVK)
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-backup", Namespace: "my-ns"}, snapshot)
+	assert.Error(t, err, "the VolumeSnapshot is deleted")
+}
+
+func TestBackup_SnapshotIsPendingWithoutASecondary(t *testing.T) {
+	r, mgr, mock, backup := setupSnapshotBackup(t)
+
+	res, backup := reconcileBackup(t, r, mgr, backup)
+	assert.Equal(t, mdbv1.BackupPending, backup.Status.Phase)
+	assert.Equal(t, "Could not lock a secondary of MongoDBCommunity resource my-rs: no member is a secondary", backup.Status.Message)
+	assert.True(t, res.RequeueAfter > 0)
+	assert.Nil(t, backup.Status.Snapshot)
+
+	t.Run("The last error is reported", func(t *testing.T) {
+		mock.err = errors.New("server selection timeout")
+		_, backup = reconcileBackup(t, r, mgr, backup)
+		assert.Equal(t, "Could not lock a secondary of MongoDBCommunity resource my-rs: server selection timeout", backup.Status.Message)
+	})
+}
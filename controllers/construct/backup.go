@@ -0,0 +1,192 @@
+package construct
+
+import (
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// BackupDumpContainerName is the name of the init container running mongodump. Its termination message
+	// is the size of the archive in bytes.
+	BackupDumpContainerName = "mongodump"
+	// BackupUploadContainerName is the name of the container uploading the archive to the bucket.
+	BackupUploadContainerName = "upload"
+	// BackupDeleteContainerName is the name of the container deleting an expired archive from the bucket.
+	BackupDeleteContainerName = "delete"
+
+	backupVolumeName = "backup"
+	backupTLSCAName  = "tls-ca"
+	backupArchive    = "/backup/archive.gz"
+)
+
+// BackupJobOptions configures the Job taking a backup of a resource.
+type BackupJobOptions struct {
+	// Name and Namespace of the Job
+	Name      string
+	Namespace string
+
+	// ConnectionStringSecretName is the connection string Secret of the user running mongodump
+	ConnectionStringSecretName string
+	// ConnectionStringKey is the key of the connection string in the Secret
+	ConnectionStringKey string
+	// CAConfigMapName is the ConfigMap containing the CA of the members in the "ca.crt" key, empty if TLS is disabled
+	CAConfigMapName string
+
+	// UploadImage is the image containing the AWS CLI
+	UploadImage string
+	// URL of the archive in the bucket, e.g. "s3://bucket/key"
+	URL string
+	// Endpoint of the S3-compatible service, empty for AWS S3
+	Endpoint string
+	// Region of the bucket
+	Region string
+	// CredentialsSecretName is the Secret containing the access key and secret key of the bucket
+	CredentialsSecretName string
+	// AccessKeyIDKey and SecretAccessKeyKey are the keys of the credentials in the Secret
+	AccessKeyIDKey     string
+	SecretAccessKeyKey string
+
+	// Encryption encrypts the uploaded artifacts, or decrypts the downloaded ones, nil if they are not encrypted
+	Encryption *EncryptionOptions
+}
+
+// BuildBackupJob returns a Job which dumps the data of the given resource from a secondary into a gzip
+// compressed archive, and uploads the archive to an S3-compatible bucket. The archive is encrypted before it is
+// uploaded if the options configure an encryption.
+func BuildBackupJob(mdb MongoDBStatefulSetOwner, opts BackupJobOptions) batchv1.Job {
+	backupVolume := statefulset.CreateVolumeFromEmptyDir(backupVolumeName)
+	backupVolumeMount := statefulset.CreateVolumeMount(backupVolumeName, "/backup", statefulset.WithReadOnly(false))
+
+	dumpCommand := `set -e
+mongodump --uri="$MONGODB_URI" --readPreference=secondary --archive=` + backupArchive + ` --gzip $TLS_OPTIONS
+stat -c %s ` + backupArchive + ` > /dev/termination-log
+`
+	uploadCommand := `set -e
+`
+	if opts.Encryption != nil {
+		uploadCommand += encryptCommand(backupArchive)
+	}
+	uploadCommand += `aws s3 cp ` + backupArchive + ` "$S3_URL" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"}
+`
+
+	dumpEnvs := []corev1.EnvVar{secretEnvVar("MONGODB_URI", opts.ConnectionStringSecretName, opts.ConnectionStringKey)}
+	dumpVolumeMounts := []corev1.VolumeMount{backupVolumeMount}
+	caVolume := podtemplatespec.NOOP()
+	if opts.CAConfigMapName != "" {
+		caVolume = podtemplatespec.WithVolume(statefulset.CreateVolumeFromConfigMap(backupTLSCAName, opts.CAConfigMapName))
+		dumpVolumeMounts = append(dumpVolumeMounts, statefulset.CreateVolumeMount(backupTLSCAName, "/tls"))
+		dumpEnvs = append(dumpEnvs, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "--sslCAFile=/tls/ca.crt"})
+	}
+
+	podSecurityContext, securityContext := backupSecurityContexts()
+	encryption, encryptionKeys := withEncryption(opts.Encryption)
+	job := newBackupJob(opts)
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		podtemplatespec.WithVolume(backupVolume),
+		caVolume,
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		encryption,
+		podtemplatespec.WithInitContainer(BackupDumpContainerName, container.Apply(
+			container.WithName(BackupDumpContainerName),
+			container.WithImage(getMongoDBImage(mdb.GetMongoDBVersion())),
+			container.WithCommand([]string{"/bin/sh", "-c", dumpCommand}),
+			container.WithEnvs(dumpEnvs...),
+			container.WithVolumeMounts(dumpVolumeMounts),
+			securityContext,
+		)),
+		podtemplatespec.WithContainer(BackupUploadContainerName, container.Apply(
+			container.WithName(BackupUploadContainerName),
+			container.WithImage(opts.UploadImage),
+			container.WithCommand([]string{"/bin/sh", "-c", uploadCommand}),
+			container.WithEnvs(s3EnvVars(opts)...),
+			container.WithVolumeMounts([]corev1.VolumeMount{backupVolumeMount}),
+			encryptionKeys,
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
+
+// BuildBackupDeletionJob returns a Job which deletes a backup archive from an S3-compatible bucket.
+// Only the options of the bucket and the archive are used.
+func BuildBackupDeletionJob(mdb MongoDBStatefulSetOwner, opts BackupJobOptions) batchv1.Job {
+	deleteCommand := `set -e
+aws s3 rm "$S3_URL" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"}
+`
+	podSecurityContext, securityContext := backupSecurityContexts()
+	job := newBackupJob(opts)
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		podtemplatespec.WithContainer(BackupDeleteContainerName, container.Apply(
+			container.WithName(BackupDeleteContainerName),
+			container.WithImage(opts.UploadImage),
+			container.WithCommand([]string{"/bin/sh", "-c", deleteCommand}),
+			container.WithEnvs(s3EnvVars(opts)...),
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
+
+func newBackupJob(opts BackupJobOptions) batchv1.Job {
+	backoffLimit := int32(2)
+	job := batchv1.Job{
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+		},
+	}
+	job.Name = opts.Name
+	job.Namespace = opts.Namespace
+	return job
+}
+
+func backupSecurityContexts() (podtemplatespec.Modification, container.Modification) {
+	if envvar.ReadBool(ManagedSecurityContextEnv) {
+		return podtemplatespec.NOOP(), container.NOOP()
+	}
+	return podtemplatespec.WithSecurityContext(podtemplatespec.DefaultPodSecurityContext()), container.WithSecurityContext(container.DefaultSecurityContext())
+}
+
+// s3EnvVars returns the environment variables configuring the AWS CLI for the bucket and the archive.
+func s3EnvVars(opts BackupJobOptions) []corev1.EnvVar {
+	envs := []corev1.EnvVar{
+		{Name: "S3_URL", Value: opts.URL},
+		{Name: "S3_ENDPOINT", Value: opts.Endpoint},
+		// the AWS CLI writes its cache to the home directory
+		{Name: "HOME", Value: "/tmp"},
+		secretEnvVar("AWS_ACCESS_KEY_ID", opts.CredentialsSecretName, opts.AccessKeyIDKey),
+		secretEnvVar("AWS_SECRET_ACCESS_KEY", opts.CredentialsSecretName, opts.SecretAccessKeyKey),
+	}
+	if opts.Region != "" {
+		envs = append(envs, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: opts.Region})
+	}
+	return envs
+}
+
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/resourcerequirements"
 
 	corev1 "k8s.io/api/core/v1"
@@ -61,12 +62,13 @@ func TestMultipleCalls_DoNotCauseSideEffects(t *testing.T) {
 }
 
 func TestMongod_Container(t *testing.T) {
-	c := container.New(mongodbContainer("4.2", []corev1.VolumeMount{}))
+	c := container.New(mongodbContainer("4.2", "/tmp", []corev1.VolumeMount{}))
 
 	t.Run("Has correct Env vars", func(t *testing.T) {
-		assert.Len(t, c.Env, 1)
+		assert.Len(t, c.Env, 2)
 		assert.Equal(t, agentHealthStatusFilePathEnv, c.Env[0].Name)
 		assert.Equal(t, "/healthstatus/agent-health-status.json", c.Env[0].Value)
+		assert.Equal(t, corev1.EnvVar{Name: "TMPDIR", Value: "/tmp"}, c.Env[1])
 	})
 
 	t.Run("Image is correct", func(t *testing.T) {
@@ -85,8 +87,8 @@ func assertStatefulSetIsBuiltCorrectly(t *testing.T, mdb mdbv1.MongoDBCommunity,
 	assert.Equal(t, mdb.Name, sts.Name)
 	assert.Equal(t, mdb.Namespace, sts.Namespace)
 	assert.Equal(t, operatorServiceAccountName, sts.Spec.Template.Spec.ServiceAccountName)
-	assert.Len(t, sts.Spec.Template.Spec.Containers[0].Env, 4)
-	assert.Len(t, sts.Spec.Template.Spec.Containers[1].Env, 1)
+	assert.Len(t, sts.Spec.Template.Spec.Containers[0].Env, 5)
+	assert.Len(t, sts.Spec.Template.Spec.Containers[1].Env, 2)
 
 	agentContainer := sts.Spec.Template.Spec.Containers[0]
 	assert.Equal(t, "agent-image", agentContainer.Image)
@@ -94,7 +96,7 @@ func assertStatefulSetIsBuiltCorrectly(t *testing.T, mdb mdbv1.MongoDBCommunity,
 	assert.True(t, reflect.DeepEqual(probes.New(DefaultReadiness()), *probe))
 	assert.Equal(t, probes.New(DefaultReadiness()).FailureThreshold, probe.FailureThreshold)
 	assert.Equal(t, int32(5), probe.InitialDelaySeconds)
-	assert.Len(t, agentContainer.VolumeMounts, 6)
+	assert.Len(t, agentContainer.VolumeMounts, 7)
 	assert.NotNil(t, agentContainer.ReadinessProbe)
 
 	assertContainsVolumeMountWithName(t, agentContainer.VolumeMounts, "agent-scripts")
@@ -103,16 +105,21 @@ func assertStatefulSetIsBuiltCorrectly(t *testing.T, mdb mdbv1.MongoDBCommunity,
 	assertContainsVolumeMountWithName(t, agentContainer.VolumeMounts, "healthstatus")
 	assertContainsVolumeMountWithName(t, agentContainer.VolumeMounts, "logs-volume")
 	assertContainsVolumeMountWithName(t, agentContainer.VolumeMounts, "my-rs-keyfile")
+	assertContainsVolumeMountWithName(t, agentContainer.VolumeMounts, "tmp")
 
 	mongodContainer := sts.Spec.Template.Spec.Containers[1]
 	assert.Equal(t, "repo/mongo:4.2.2", mongodContainer.Image)
-	assert.Len(t, mongodContainer.VolumeMounts, 5)
+	assert.Len(t, mongodContainer.VolumeMounts, 6)
 
 	assertContainsVolumeMountWithName(t, mongodContainer.VolumeMounts, "data-volume")
 	assertContainsVolumeMountWithName(t, mongodContainer.VolumeMounts, "healthstatus")
 	assertContainsVolumeMountWithName(t, mongodContainer.VolumeMounts, "hooks")
 	assertContainsVolumeMountWithName(t, mongodContainer.VolumeMounts, "logs-volume")
 	assertContainsVolumeMountWithName(t, mongodContainer.VolumeMounts, "my-rs-keyfile")
+	assertContainsVolumeMountWithName(t, mongodContainer.VolumeMounts, "tmp")
+	if assert.NotNil(t, mongodContainer.Lifecycle) && assert.NotNil(t, mongodContainer.Lifecycle.PreStop) {
+		assert.Equal(t, []string{"/hooks/version-upgrade", "pre-stop"}, mongodContainer.Lifecycle.PreStop.Exec.Command)
+	}
 
 	initContainer := sts.Spec.Template.Spec.InitContainers[0]
 	assert.Equal(t, versionUpgradeHookName, initContainer.Name)
@@ -130,3 +137,74 @@ func assertContainsVolumeMountWithName(t *testing.T, mounts []corev1.VolumeMount
 	}
 	assert.True(t, found, "Mounts should have contained a mount with name %s, but didn't. Actual mounts: %v", name, mounts)
 }
+
+func TestSecurityContextPreset(t *testing.T) {
+	_ = os.Setenv(MongodbRepoUrl, "repo")
+	_ = os.Setenv(MongodbImageEnv, "mongo")
+
+	buildStatefulSet := func(mdb mdbv1.MongoDBCommunity) *appsv1.StatefulSet {
+		sts := &appsv1.StatefulSet{}
+		BuildMongoDBReplicaSetStatefulSetModificationFunction(&mdb, mdb)(sts)
+		BuildSecurityContextPresetModification(&mdb)(&sts.Spec.Template)
+		return sts
+	}
+
+	t.Run("Operator defaults are used without a preset", func(t *testing.T) {
+		sts := buildStatefulSet(newTestReplicaSet())
+		assert.Equal(t, podtemplatespec.DefaultPodSecurityContext(), *sts.Spec.Template.Spec.SecurityContext)
+		for _, c := range sts.Spec.Template.Spec.Containers {
+			assert.Equal(t, container.DefaultSecurityContext(), *c.SecurityContext)
+		}
+	})
+
+	t.Run("Restricted preset is applied to all containers", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mdb.Spec.SecurityContextPreset = mdbv1.SecurityContextPresetRestricted
+		sts := buildStatefulSet(mdb)
+
+		podSecurityContext := sts.Spec.Template.Spec.SecurityContext
+		assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, podSecurityContext.SeccompProfile.Type)
+		assert.Equal(t, corev1.FSGroupChangeOnRootMismatch, *podSecurityContext.FSGroupChangePolicy)
+		assert.True(t, *podSecurityContext.RunAsNonRoot)
+
+		containers := append(sts.Spec.Template.Spec.Containers, sts.Spec.Template.Spec.InitContainers...)
+		for _, c := range containers {
+			assert.False(t, *c.SecurityContext.AllowPrivilegeEscalation, c.Name)
+			assert.Equal(t, []corev1.Capability{"ALL"}, c.SecurityContext.Capabilities.Drop, c.Name)
+		}
+	})
+
+	t.Run("OpenShift preset does not set the user or group", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mdb.Spec.SecurityContextPreset = mdbv1.SecurityContextPresetOpenShift
+		sts := buildStatefulSet(mdb)
+
+		assert.Equal(t, corev1.PodSecurityContext{}, *sts.Spec.Template.Spec.SecurityContext)
+		for _, c := range sts.Spec.Template.Spec.Containers {
+			assert.Nil(t, c.SecurityContext.RunAsUser)
+			assert.True(t, *c.SecurityContext.RunAsNonRoot)
+		}
+	})
+
+	t.Run("Legacy preset changes the ownership of the volumes as root", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mdb.Spec.SecurityContextPreset = mdbv1.SecurityContextPresetLegacy
+		sts := buildStatefulSet(mdb)
+
+		assert.Nil(t, sts.Spec.Template.Spec.SecurityContext.FSGroup)
+		volumePermissions := container.GetByName(volumePermissionsContainerName, sts.Spec.Template.Spec.InitContainers)
+		if assert.NotNil(t, volumePermissions) {
+			assert.Equal(t, int64(0), *volumePermissions.SecurityContext.RunAsUser)
+			assert.Equal(t, "repo/mongo:4.2.2", volumePermissions.Image)
+			assert.Contains(t, volumePermissions.Command[2], "chown -R 2000:2000 /data")
+			assertContainsVolumeMountWithName(t, volumePermissions.VolumeMounts, "data-volume")
+		}
+
+		t.Run("Init container is removed when the preset changes", func(t *testing.T) {
+			mdb.Spec.SecurityContextPreset = mdbv1.SecurityContextPresetBaseline
+			BuildSecurityContextPresetModification(&mdb)(&sts.Spec.Template)
+			assert.Nil(t, container.GetByName(volumePermissionsContainerName, sts.Spec.Template.Spec.InitContainers))
+			assert.Equal(t, podtemplatespec.DefaultPodSecurityContext(), *sts.Spec.Template.Spec.SecurityContext)
+		})
+	})
+}
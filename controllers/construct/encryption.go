@@ -0,0 +1,121 @@
+package construct
+
+import (
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// BackupCryptImageEnv is the image containing the backup-crypt binary, which encrypts and decrypts the
+	// backup artifacts.
+	BackupCryptImageEnv = "BACKUP_CRYPT_IMAGE"
+	// BackupCryptContainerName is the name of the init container copying the backup-crypt binary.
+	BackupCryptContainerName = "backup-crypt"
+
+	defaultBackupCryptImage = "quay.io/mongodb/mongodb-kubernetes-backup-crypt:1.0.0"
+
+	backupToolsVolumeName   = "backup-tools"
+	encryptionKeyVolumeName = "encryption-key"
+	// dataKeysVolumeName holds the plaintext data keys generated by a KMS, in memory
+	dataKeysVolumeName = "data-keys"
+
+	backupCrypt       = "/tools/backup-crypt"
+	encryptionKeyFile = "/encryption/key"
+)
+
+// EncryptionOptions configures the encryption of the artifacts of a Job with AES-256-GCM.
+type EncryptionOptions struct {
+	// KeySecretName and KeySecretKey locate the key in a Secret, empty if a KMS generates a data key
+	KeySecretName string
+	KeySecretKey  string
+
+	// KMSProvider, KMSKeyID and KMSRegion identify the key of the KMS wrapping the data key
+	KMSProvider string
+	KMSKeyID    string
+	KMSRegion   string
+}
+
+// encryptCommand returns the commands encrypting the given file in place. For a KMS, a data key is generated and
+// its wrapped copy is stored in the header of the file.
+func encryptCommand(file string) string {
+	return `if [ -n "$KMS_KEY_ID" ]; then
+  aws kms generate-data-key --key-id "$KMS_KEY_ID" --key-spec AES_256 ${KMS_REGION:+--region "$KMS_REGION"} --query '[Plaintext,CiphertextBlob,KeyId]' --output text > /keys/data-key
+  read -r plaintext wrapped key_id < /keys/data-key
+  echo "$plaintext" | base64 -d > /keys/data-key
+  ` + backupCrypt + ` encrypt --key-file=/keys/data-key --kms-provider="$KMS_PROVIDER" --kms-key-id="$key_id" --wrapped-key="$wrapped" < ` + file + ` > ` + file + `.enc
+  rm -f /keys/data-key
+else
+  ` + backupCrypt + ` encrypt --key-file=` + encryptionKeyFile + ` --key-secret="$ENCRYPTION_KEY_SECRET" < ` + file + ` > ` + file + `.enc
+fi
+mv ` + file + `.enc ` + file + `
+`
+}
+
+// decryptFunction defines the shell function decrypting the file given as argument in place. Files which are not
+// encrypted are kept as is. For a KMS, the data key is read from the header of the file and unwrapped.
+const decryptFunction = `decrypt() {
+  key_file=` + encryptionKeyFile + `
+  if [ -n "$KMS_PROVIDER" ]; then
+    wrapped=$(` + backupCrypt + ` wrapped-key < "$1")
+    key_file=
+    if [ -n "$wrapped" ]; then
+      echo "$wrapped" | base64 -d > /keys/wrapped-key
+      aws kms decrypt --ciphertext-blob fileb:///keys/wrapped-key ${KMS_REGION:+--region "$KMS_REGION"} --query Plaintext --output text | base64 -d > /keys/data-key
+      key_file=/keys/data-key
+    fi
+  fi
+  ` + backupCrypt + ` decrypt --allow-plaintext ${key_file:+--key-file="$key_file"} < "$1" > "$1.dec"
+  mv "$1.dec" "$1"
+  rm -f /keys/data-key /keys/wrapped-key
+}
+`
+
+// withEncryption returns the modifications adding the init container copying the backup-crypt binary and the
+// volumes of the keys to the Pod, and the modification giving the container using the keys access to them.
+func withEncryption(opts *EncryptionOptions) (podtemplatespec.Modification, container.Modification) {
+	if opts == nil {
+		return podtemplatespec.NOOP(), container.NOOP()
+	}
+
+	toolsVolume := statefulset.CreateVolumeFromEmptyDir(backupToolsVolumeName)
+	toolsVolumeMount := statefulset.CreateVolumeMount(backupToolsVolumeName, "/tools", statefulset.WithReadOnly(false))
+	dataKeysVolume := statefulset.CreateVolumeFromEmptyDir(dataKeysVolumeName)
+	dataKeysVolume.EmptyDir.Medium = corev1.StorageMediumMemory
+	volumeMounts := []corev1.VolumeMount{toolsVolumeMount, statefulset.CreateVolumeMount(dataKeysVolumeName, "/keys", statefulset.WithReadOnly(false))}
+
+	envs := []corev1.EnvVar{
+		{Name: "KMS_PROVIDER", Value: opts.KMSProvider},
+		{Name: "KMS_KEY_ID", Value: opts.KMSKeyID},
+		{Name: "KMS_REGION", Value: opts.KMSRegion},
+	}
+	keyVolume := podtemplatespec.NOOP()
+	if opts.KeySecretName != "" {
+		keyVolume = podtemplatespec.WithVolume(statefulset.CreateVolumeFromSecret(encryptionKeyVolumeName, opts.KeySecretName, func(v *corev1.Volume) {
+			v.Secret.Items = []corev1.KeyToPath{{Key: opts.KeySecretKey, Path: "key"}}
+		}))
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(encryptionKeyVolumeName, "/encryption", statefulset.WithReadOnly(true)))
+		envs = append(envs, corev1.EnvVar{Name: "ENCRYPTION_KEY_SECRET", Value: opts.KeySecretName + "/" + opts.KeySecretKey})
+	}
+
+	_, securityContext := backupSecurityContexts()
+	podModification := podtemplatespec.Apply(
+		podtemplatespec.WithVolume(toolsVolume),
+		podtemplatespec.WithVolume(dataKeysVolume),
+		keyVolume,
+		podtemplatespec.WithInitContainer(BackupCryptContainerName, container.Apply(
+			container.WithName(BackupCryptContainerName),
+			container.WithImage(envvar.GetEnvOrDefault(BackupCryptImageEnv, defaultBackupCryptImage)),
+			container.WithCommand([]string{"cp", "/backup-crypt", backupCrypt}),
+			container.WithVolumeMounts([]corev1.VolumeMount{toolsVolumeMount}),
+			securityContext,
+		)),
+	)
+	containerModification := container.Apply(
+		container.WithEnvs(envs...),
+		container.WithVolumeMounts(volumeMounts),
+	)
+	return podModification, containerModification
+}
@@ -0,0 +1,84 @@
+package construct
+
+import (
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// InitializationContainerName is the name of the container running the initialization scripts.
+	InitializationContainerName = "initialize"
+
+	initializationScriptsVolumeName = "scripts"
+	initializationScriptsMountPath  = "/scripts"
+)
+
+// InitializationJobOptions configures the Job running the initialization scripts of a resource.
+type InitializationJobOptions struct {
+	// Name and Namespace of the Job
+	Name      string
+	Namespace string
+
+	// ConnectionStringSecretName is the connection string Secret of the user running the scripts
+	ConnectionStringSecretName string
+	// ConnectionStringKey is the key of the connection string in the Secret
+	ConnectionStringKey string
+	// CAConfigMapName is the ConfigMap containing the CA of the members in the "ca.crt" key, empty if TLS is disabled
+	CAConfigMapName string
+
+	// ScriptsConfigMapName is the ConfigMap containing the scripts
+	ScriptsConfigMapName string
+}
+
+// initializationCommand runs the scripts in the lexical order of their names, which is the order the shell
+// expands the pattern in, and stops at the first script which fails. mongosh replaced the mongo shell in the
+// images of MongoDB 6.0.
+const initializationCommand = `set -e
+shell=$(command -v mongosh || command -v mongo)
+for script in ` + initializationScriptsMountPath + `/*.js; do
+  [ -e "$script" ] || continue
+  echo "Running $script"
+  "$shell" "$MONGODB_URI" $TLS_OPTIONS --quiet "$script"
+done
+`
+
+// BuildInitializationJob returns a Job which runs the scripts of the given ConfigMap with the MongoDB shell
+// against the primary of the given resource.
+func BuildInitializationJob(mdb MongoDBStatefulSetOwner, opts InitializationJobOptions) batchv1.Job {
+	scriptsVolume := statefulset.CreateVolumeFromConfigMap(initializationScriptsVolumeName, opts.ScriptsConfigMapName)
+	volumeMounts := []corev1.VolumeMount{statefulset.CreateVolumeMount(initializationScriptsVolumeName, initializationScriptsMountPath)}
+
+	envs := []corev1.EnvVar{secretEnvVar("MONGODB_URI", opts.ConnectionStringSecretName, opts.ConnectionStringKey)}
+	caVolume := podtemplatespec.NOOP()
+	if opts.CAConfigMapName != "" {
+		caVolume = podtemplatespec.WithVolume(statefulset.CreateVolumeFromConfigMap(backupTLSCAName, opts.CAConfigMapName))
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(backupTLSCAName, "/tls"))
+		envs = append(envs, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "--tlsCAFile=/tls/ca.crt"})
+	}
+
+	podSecurityContext, securityContext := backupSecurityContexts()
+	job := newBackupJob(BackupJobOptions{Name: opts.Name, Namespace: opts.Namespace})
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		podtemplatespec.WithVolume(scriptsVolume),
+		caVolume,
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		podtemplatespec.WithContainer(InitializationContainerName, container.Apply(
+			container.WithName(InitializationContainerName),
+			container.WithImage(getMongoDBImage(mdb.GetMongoDBVersion())),
+			container.WithCommand([]string{"/bin/sh", "-c", initializationCommand}),
+			container.WithEnvs(envs...),
+			container.WithVolumeMounts(volumeMounts),
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/lifecycle"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/persistentvolumeclaim"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/probes"
@@ -24,17 +25,19 @@ const (
 	AgentName   = "mongodb-agent"
 	MongodbName = "mongod"
 
-	versionUpgradeHookName         = "mongod-posthook"
-	ReadinessProbeContainerName    = "mongodb-agent-readinessprobe"
-	readinessProbePath             = "/opt/scripts/readinessprobe"
-	agentHealthStatusFilePathEnv   = "AGENT_STATUS_FILEPATH"
-	clusterFilePath                = "/var/lib/automation/config/cluster-config.json"
-	operatorServiceAccountName     = "mongodb-kubernetes-operator"
-	agentHealthStatusFilePathValue = "/var/log/mongodb-mms-automation/healthstatus/agent-health-status.json"
+	versionUpgradeHookName       = "mongod-posthook"
+	versionUpgradeHookPath       = "/hooks/version-upgrade"
+	ReadinessProbeContainerName  = "mongodb-agent-readinessprobe"
+	readinessProbePath           = "/opt/scripts/readinessprobe"
+	agentHealthStatusFilePathEnv = "AGENT_STATUS_FILEPATH"
+	clusterFilePath              = "/var/lib/automation/config/cluster-config.json"
+	operatorServiceAccountName   = "mongodb-kubernetes-operator"
+	AgentHealthStatusFilePath    = "/var/log/mongodb-mms-automation/healthstatus/agent-health-status.json"
 
 	MongodbRepoUrl = "MONGODB_REPO_URL"
 
 	headlessAgentEnv           = "HEADLESS_AGENT"
+	temporaryDirectoryEnv      = "TMPDIR"
 	podNamespaceEnv            = "POD_NAMESPACE"
 	automationConfigEnv        = "AUTOMATION_CONFIG_MAP"
 	AgentImageEnv              = "AGENT_IMAGE"
@@ -43,17 +46,20 @@ const (
 	ReadinessProbeImageEnv     = "READINESS_PROBE_IMAGE"
 	ManagedSecurityContextEnv  = "MANAGED_SECURITY_CONTEXT"
 
-	automationconfFilePath = "/data/automation-mongod.conf"
+	// AutomationConfFilePath is the configuration file of mongod, which is written by the agent.
+	AutomationConfFilePath = "/data/automation-mongod.conf"
 	keyfileFilePath        = "/var/lib/mongodb-mms-automation/authentication/keyfile"
 
+	temporaryDirectoryVolumeName = "tmp"
+
 	automationAgentOptions = " -skipMongoStart -noDaemonize -useLocalMongoDbTools"
 
 	MongodbUserCommand = `current_uid=$(id -u)
 declare -r current_uid
 if ! grep -q "${current_uid}" /etc/passwd ; then
-sed -e "s/^mongodb:/builder:/" /etc/passwd > /tmp/passwd
-echo "mongodb:x:$(id -u):$(id -g):,,,:/:/bin/bash" >> /tmp/passwd
-export NSS_WRAPPER_PASSWD=/tmp/passwd
+sed -e "s/^mongodb:/builder:/" /etc/passwd > ${TMPDIR:-/tmp}/passwd
+echo "mongodb:x:$(id -u):$(id -g):,,,:/:/bin/bash" >> ${TMPDIR:-/tmp}/passwd
+export NSS_WRAPPER_PASSWD=${TMPDIR:-/tmp}/passwd
 export LD_PRELOAD=libnss_wrapper.so
 export NSS_WRAPPER_GROUP=/etc/group
 fi
@@ -66,8 +72,12 @@ type MongoDBStatefulSetOwner interface {
 	ServiceName() string
 	// GetName returns the name of the resource.
 	GetName() string
+	// StatefulSetName returns the name of the StatefulSet, which prefixes the names of the Pods.
+	StatefulSetName() string
 	// GetNamespace returns the namespace the resource is defined in.
 	GetNamespace() string
+	// MemberOrdinalStart returns the ordinal of the first Pod of the StatefulSet.
+	MemberOrdinalStart() int
 	// GetMongoDBVersion returns the version of MongoDB to be used for this resource
 	GetMongoDBVersion() string
 	// AutomationConfigSecretName returns the name of the secret which will contain the automation config.
@@ -82,6 +92,12 @@ type MongoDBStatefulSetOwner interface {
 	DataVolumeName() string
 	// LogsVolumeName returns the name that the data volume should have
 	LogsVolumeName() string
+	// GetSecurityContextPreset returns the name of the security context preset, or an empty string if none is selected.
+	GetSecurityContextPreset() string
+	// TemporaryDirectory returns the directory the containers write temporary files to.
+	TemporaryDirectory() string
+	// TemporaryDirectoryVolumeSource returns the emptyDir volume mounted at the temporary directory.
+	TemporaryDirectoryVolumeSource() corev1.EmptyDirVolumeSource
 }
 
 // BuildMongoDBReplicaSetStatefulSetModificationFunction builds the parts of the replica set that are common between every resource that implements
@@ -115,8 +131,14 @@ func BuildMongoDBReplicaSetStatefulSetModificationFunction(mdb MongoDBStatefulSe
 	keyFileVolumeVolumeMount := statefulset.CreateVolumeMount(keyFileVolume.Name, "/var/lib/mongodb-mms-automation/authentication", statefulset.WithReadOnly(false))
 	keyFileVolumeVolumeMountMongod := statefulset.CreateVolumeMount(keyFileVolume.Name, "/var/lib/mongodb-mms-automation/authentication", statefulset.WithReadOnly(false))
 
-	mongodbAgentVolumeMounts := []corev1.VolumeMount{agentHealthStatusVolumeMount, automationConfigVolumeMount, scriptsVolumeMount, keyFileVolumeVolumeMount}
-	mongodVolumeMounts := []corev1.VolumeMount{mongodHealthStatusVolumeMount, hooksVolumeMount, keyFileVolumeVolumeMountMongod}
+	// temporary files are written to a volume, so that the containers work with a read-only root filesystem.
+	temporaryDirectoryVolume := corev1.Volume{Name: temporaryDirectoryVolumeName}
+	temporaryDirectorySource := mdb.TemporaryDirectoryVolumeSource()
+	temporaryDirectoryVolume.EmptyDir = &temporaryDirectorySource
+	temporaryDirectoryVolumeMount := statefulset.CreateVolumeMount(temporaryDirectoryVolume.Name, mdb.TemporaryDirectory(), statefulset.WithReadOnly(false))
+
+	mongodbAgentVolumeMounts := []corev1.VolumeMount{agentHealthStatusVolumeMount, automationConfigVolumeMount, scriptsVolumeMount, keyFileVolumeVolumeMount, temporaryDirectoryVolumeMount}
+	mongodVolumeMounts := []corev1.VolumeMount{mongodHealthStatusVolumeMount, hooksVolumeMount, keyFileVolumeVolumeMountMongod, temporaryDirectoryVolumeMount}
 	dataVolumeClaim := statefulset.NOOP()
 	logVolumeClaim := statefulset.NOOP()
 	singleModeVolumeClaim := func(s *appsv1.StatefulSet) {}
@@ -144,9 +166,10 @@ func BuildMongoDBReplicaSetStatefulSetModificationFunction(mdb MongoDBStatefulSe
 	}
 
 	return statefulset.Apply(
-		statefulset.WithName(mdb.GetName()),
+		statefulset.WithName(mdb.StatefulSetName()),
 		statefulset.WithNamespace(mdb.GetNamespace()),
 		statefulset.WithServiceName(mdb.ServiceName()),
+		statefulset.WithOrdinalsStart(mdb.MemberOrdinalStart()),
 		statefulset.WithLabels(labels),
 		statefulset.WithMatchLabels(labels),
 		statefulset.WithReplicas(scale.ReplicasThisReconciliation(scaler)),
@@ -163,9 +186,10 @@ func BuildMongoDBReplicaSetStatefulSetModificationFunction(mdb MongoDBStatefulSe
 				podtemplatespec.WithVolume(automationConfigVolume),
 				podtemplatespec.WithVolume(scriptsVolume),
 				podtemplatespec.WithVolume(keyFileVolume),
+				podtemplatespec.WithVolume(temporaryDirectoryVolume),
 				podtemplatespec.WithServiceAccount(operatorServiceAccountName),
-				podtemplatespec.WithContainer(AgentName, mongodbAgentContainer(mdb.AutomationConfigSecretName(), mongodbAgentVolumeMounts)),
-				podtemplatespec.WithContainer(MongodbName, mongodbContainer(mdb.GetMongoDBVersion(), mongodVolumeMounts)),
+				podtemplatespec.WithContainer(AgentName, mongodbAgentContainer(mdb.AutomationConfigSecretName(), mdb.TemporaryDirectory(), mongodbAgentVolumeMounts)),
+				podtemplatespec.WithContainer(MongodbName, mongodbContainer(mdb.GetMongoDBVersion(), mdb.TemporaryDirectory(), mongodVolumeMounts)),
 				podtemplatespec.WithInitContainer(versionUpgradeHookName, versionUpgradeHookInit([]corev1.VolumeMount{hooksVolumeMount})),
 				podtemplatespec.WithInitContainer(ReadinessProbeContainerName, readinessProbeInit([]corev1.VolumeMount{scriptsVolumeMount})),
 			),
@@ -173,14 +197,19 @@ func BuildMongoDBReplicaSetStatefulSetModificationFunction(mdb MongoDBStatefulSe
 }
 
 func BaseAgentCommand() string {
-	return "agent/mongodb-agent -cluster=" + clusterFilePath + " -healthCheckFilePath=" + agentHealthStatusFilePathValue + " -serveStatusPort=5000"
+	return "agent/mongodb-agent -cluster=" + clusterFilePath + " -healthCheckFilePath=" + AgentHealthStatusFilePath + " -serveStatusPort=5000"
 }
 
-func AutomationAgentCommand() []string {
-	return []string{"/bin/bash", "-c", MongodbUserCommand + BaseAgentCommand() + automationAgentOptions}
+// AutomationAgentCommand returns the command of the agent container, with the given additional options of the agent.
+func AutomationAgentCommand(options ...string) []string {
+	command := MongodbUserCommand + BaseAgentCommand() + automationAgentOptions
+	for _, option := range options {
+		command += " " + option
+	}
+	return []string{"/bin/bash", "-c", command}
 }
 
-func mongodbAgentContainer(automationConfigSecretName string, volumeMounts []corev1.VolumeMount) container.Modification {
+func mongodbAgentContainer(automationConfigSecretName, temporaryDirectory string, volumeMounts []corev1.VolumeMount) container.Modification {
 	securityContext := container.NOOP()
 	managedSecurityContext := envvar.ReadBool(ManagedSecurityContextEnv)
 	if !managedSecurityContext {
@@ -215,7 +244,11 @@ func mongodbAgentContainer(automationConfigSecretName string, volumeMounts []cor
 			},
 			corev1.EnvVar{
 				Name:  agentHealthStatusFilePathEnv,
-				Value: agentHealthStatusFilePathValue,
+				Value: AgentHealthStatusFilePath,
+			},
+			corev1.EnvVar{
+				Name:  temporaryDirectoryEnv,
+				Value: temporaryDirectory,
 			},
 		),
 	)
@@ -276,10 +309,10 @@ func getMongoDBImage(version string) string {
 	return fmt.Sprintf("%s/%s:%s", repoUrl, mongoImageName, version)
 }
 
-func mongodbContainer(version string, volumeMounts []corev1.VolumeMount) container.Modification {
+func mongodbContainer(version, temporaryDirectory string, volumeMounts []corev1.VolumeMount) container.Modification {
 	mongoDbCommand := fmt.Sprintf(`
 #run post-start hook to handle version changes
-/hooks/version-upgrade
+%s
 
 # wait for config and keyfile to be created by the agent
  while ! [ -f %s -a -f %s ]; do sleep 3 ; done ; sleep 2 ;
@@ -291,7 +324,7 @@ tail -F /var/log/mongodb-mms-automation/mongodb.log > /dev/stdout &
 # start mongod with this configuration
 exec mongod -f %s;
 
-`, automationconfFilePath, keyfileFilePath, automationconfFilePath)
+`, versionUpgradeHookPath, AutomationConfFilePath, keyfileFilePath, AutomationConfFilePath)
 
 	containerCommand := []string{
 		"/bin/sh",
@@ -315,8 +348,15 @@ exec mongod -f %s;
 				Name:  agentHealthStatusFilePathEnv,
 				Value: "/healthstatus/agent-health-status.json",
 			},
+			corev1.EnvVar{
+				Name:  temporaryDirectoryEnv,
+				Value: temporaryDirectory,
+			},
 		),
 		container.WithVolumeMounts(volumeMounts),
+		// the pre-stop hook steps the member down if it is the primary, and waits for another member to be
+		// elected, so that writes are not interrupted until the election timeout when the Pod is deleted.
+		container.WithLifecycle(lifecycle.WithPrestopCommand([]string{versionUpgradeHookPath, "pre-stop"})),
 
 		securityContext,
 	)
@@ -0,0 +1,113 @@
+package construct
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const oplogSlice = "/backup/local/oplog.rs.bson.gz"
+
+// OplogCaptureJobOptions configures the Job capturing a slice of the oplog. URL is the location of the slice.
+type OplogCaptureJobOptions struct {
+	BackupJobOptions
+
+	// From and Until are the range of the captured oplog entries, excluding Until
+	From  time.Time
+	Until time.Time
+}
+
+// OplogReplayOptions configures the replay of the oplog slices after an archive has been restored.
+type OplogReplayOptions struct {
+	// URL of the directory of the bucket containing the slices
+	URL string
+	// From is the time the oplog is replayed from, which is before the archive was dumped
+	From time.Time
+	// Until is the time the oplog is replayed until, excluding the entries written at this time
+	Until time.Time
+}
+
+// BuildOplogCaptureJob returns a Job which dumps the oplog entries of the given range from the primary, and
+// uploads them as a gzip compressed BSON file to an S3-compatible bucket, encrypted like the backup archives.
+func BuildOplogCaptureJob(mdb MongoDBStatefulSetOwner, opts OplogCaptureJobOptions) batchv1.Job {
+	backupVolume := statefulset.CreateVolumeFromEmptyDir(backupVolumeName)
+	backupVolumeMount := statefulset.CreateVolumeMount(backupVolumeName, "/backup", statefulset.WithReadOnly(false))
+
+	// mongodump refuses a database in the connection string which differs from --db
+	dumpCommand := `set -e
+uri=$(echo "$MONGODB_URI" | sed 's#/[^/?]*?#/?#')
+mongodump --uri="$uri" --readPreference=primary --db=local --collection=oplog.rs --query="{\"ts\": {\"\$gte\": {\"\$timestamp\": {\"t\": $OPLOG_FROM, \"i\": 0}}, \"\$lt\": {\"\$timestamp\": {\"t\": $OPLOG_UNTIL, \"i\": 0}}}}" --out=/backup --gzip $TLS_OPTIONS
+`
+	uploadCommand := `set -e
+`
+	if opts.Encryption != nil {
+		uploadCommand += encryptCommand(oplogSlice)
+	}
+	uploadCommand += `aws s3 cp ` + oplogSlice + ` "$S3_URL" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"}
+`
+
+	dumpEnvs := []corev1.EnvVar{
+		secretEnvVar("MONGODB_URI", opts.ConnectionStringSecretName, opts.ConnectionStringKey),
+		{Name: "OPLOG_FROM", Value: strconv.FormatInt(opts.From.Unix(), 10)},
+		{Name: "OPLOG_UNTIL", Value: strconv.FormatInt(opts.Until.Unix(), 10)},
+	}
+	dumpVolumeMounts := []corev1.VolumeMount{backupVolumeMount}
+	caVolume := podtemplatespec.NOOP()
+	if opts.CAConfigMapName != "" {
+		caVolume = podtemplatespec.WithVolume(statefulset.CreateVolumeFromConfigMap(backupTLSCAName, opts.CAConfigMapName))
+		dumpVolumeMounts = append(dumpVolumeMounts, statefulset.CreateVolumeMount(backupTLSCAName, "/tls"))
+		dumpEnvs = append(dumpEnvs, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "--sslCAFile=/tls/ca.crt"})
+	}
+
+	podSecurityContext, securityContext := backupSecurityContexts()
+	encryption, encryptionKeys := withEncryption(opts.Encryption)
+	job := newBackupJob(opts.BackupJobOptions)
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		podtemplatespec.WithVolume(backupVolume),
+		caVolume,
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		encryption,
+		podtemplatespec.WithInitContainer(BackupDumpContainerName, container.Apply(
+			container.WithName(BackupDumpContainerName),
+			container.WithImage(getMongoDBImage(mdb.GetMongoDBVersion())),
+			container.WithCommand([]string{"/bin/sh", "-c", dumpCommand}),
+			container.WithEnvs(dumpEnvs...),
+			container.WithVolumeMounts(dumpVolumeMounts),
+			securityContext,
+		)),
+		podtemplatespec.WithContainer(BackupUploadContainerName, container.Apply(
+			container.WithName(BackupUploadContainerName),
+			container.WithImage(opts.UploadImage),
+			container.WithCommand([]string{"/bin/sh", "-c", uploadCommand}),
+			container.WithEnvs(s3EnvVars(opts.BackupJobOptions)...),
+			container.WithVolumeMounts([]corev1.VolumeMount{backupVolumeMount}),
+			encryptionKeys,
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
+
+// oplogReplayEnvVars returns the environment variables selecting the oplog slices to replay, none if the oplog
+// is not replayed.
+func oplogReplayEnvVars(opts *OplogReplayOptions) []corev1.EnvVar {
+	if opts == nil {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "OPLOG_URL", Value: opts.URL},
+		{Name: "OPLOG_FROM", Value: strconv.FormatInt(opts.From.Unix(), 10)},
+		{Name: "OPLOG_UNTIL", Value: strconv.FormatInt(opts.Until.Unix(), 10)},
+	}
+}
@@ -0,0 +1,129 @@
+package construct
+
+import (
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PBMContainerName is the name of the container running the pbm CLI. The termination message of a backup
+	// is the name of the backup in PBM.
+	PBMContainerName = "pbm"
+
+	// PBMConfigKey is the key of the configuration in the Secret applied with "pbm config".
+	PBMConfigKey = "config.yaml"
+
+	pbmConfigVolumeName = "pbm-config"
+	pbmConfigMountPath  = "/etc/pbm"
+
+	// pbmTimeFormat is the format of the target time of a point-in-time restore, which is in UTC.
+	pbmTimeFormat = "2006-01-02T15:04:05"
+)
+
+// PBMJobOptions configures a Job running the pbm CLI against a resource.
+type PBMJobOptions struct {
+	// Name and Namespace of the Job
+	Name      string
+	Namespace string
+
+	// Image is the Percona Backup for MongoDB image
+	Image string
+
+	// ConnectionStringSecretName is the connection string Secret of the user running the pbm CLI
+	ConnectionStringSecretName string
+	// ConnectionStringKey is the key of the connection string in the Secret
+	ConnectionStringKey string
+	// CAConfigMapName is the ConfigMap containing the CA of the members in the "ca.crt" key, empty if TLS is disabled
+	CAConfigMapName string
+
+	// ConfigSecretName is the Secret containing the PBM configuration in the PBMConfigKey key, which is
+	// applied before the command runs
+	ConfigSecretName string
+}
+
+// pbmSetupCommand applies the configuration of the resource, so that the storage and the point-in-time
+// recovery settings are always those of the resource.
+const pbmSetupCommand = `set -e
+export PBM_MONGODB_URI="$MONGODB_URI$TLS_OPTIONS"
+pbm config --file ` + pbmConfigMountPath + "/" + PBMConfigKey + ` > /dev/null
+`
+
+// BuildPBMBackupJob returns a Job which takes a backup of the given type with the PBM agents of the resource,
+// and waits for it to complete. The name of the backup is the termination message of the container.
+func BuildPBMBackupJob(mdb MongoDBStatefulSetOwner, opts PBMJobOptions, backupType string, base bool) batchv1.Job {
+	command := pbmSetupCommand + `output=$(pbm backup --type="$PBM_BACKUP_TYPE" $PBM_BASE_OPTION --wait 2>&1) || { echo "$output"; exit 1; }
+echo "$output"
+echo "$output" | sed -n "s/^Starting backup '\([^']*\)'.*/\1/p" > /dev/termination-log
+`
+	envs := []corev1.EnvVar{{Name: "PBM_BACKUP_TYPE", Value: backupType}}
+	if base {
+		envs = append(envs, corev1.EnvVar{Name: "PBM_BASE_OPTION", Value: "--base"})
+	}
+	return buildPBMJob(mdb, opts, command, envs)
+}
+
+// BuildPBMRestoreJob returns a Job which restores the named backup, or the given point in time if the name is
+// empty, with the PBM agents of the resource. Point-in-time recovery is disabled during the restore, as PBM
+// requires, and is enabled again by the configuration applied before the next backup.
+func BuildPBMRestoreJob(mdb MongoDBStatefulSetOwner, opts PBMJobOptions, backupName string, targetTime time.Time) batchv1.Job {
+	command := pbmSetupCommand + `pbm config --set pitr.enabled=false > /dev/null
+if [ -n "$PBM_TARGET_TIME" ]; then
+  pbm restore --time="$PBM_TARGET_TIME" --wait
+else
+  pbm restore "$PBM_BACKUP_NAME" --wait
+fi
+`
+	envs := []corev1.EnvVar{{Name: "PBM_BACKUP_NAME", Value: backupName}}
+	if backupName == "" {
+		envs = append(envs, corev1.EnvVar{Name: "PBM_TARGET_TIME", Value: targetTime.UTC().Format(pbmTimeFormat)})
+	}
+	return buildPBMJob(mdb, opts, command, envs)
+}
+
+// BuildPBMDeletionJob returns a Job which deletes the named backup from the storage of the PBM agents.
+func BuildPBMDeletionJob(mdb MongoDBStatefulSetOwner, opts PBMJobOptions, backupName string) batchv1.Job {
+	command := pbmSetupCommand + `pbm delete-backup --force "$PBM_BACKUP_NAME"
+`
+	return buildPBMJob(mdb, opts, command, []corev1.EnvVar{{Name: "PBM_BACKUP_NAME", Value: backupName}})
+}
+
+func buildPBMJob(mdb MongoDBStatefulSetOwner, opts PBMJobOptions, command string, envs []corev1.EnvVar) batchv1.Job {
+	configVolume := statefulset.CreateVolumeFromSecret(pbmConfigVolumeName, opts.ConfigSecretName)
+	volumeMounts := []corev1.VolumeMount{statefulset.CreateVolumeMount(pbmConfigVolumeName, pbmConfigMountPath)}
+
+	envs = append([]corev1.EnvVar{secretEnvVar("MONGODB_URI", opts.ConnectionStringSecretName, opts.ConnectionStringKey)}, envs...)
+	caVolume := podtemplatespec.NOOP()
+	if opts.CAConfigMapName != "" {
+		caVolume = podtemplatespec.WithVolume(statefulset.CreateVolumeFromConfigMap(backupTLSCAName, opts.CAConfigMapName))
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(backupTLSCAName, "/tls"))
+		envs = append(envs, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "&tlsCAFile=/tls/ca.crt"})
+	}
+
+	podSecurityContext, securityContext := backupSecurityContexts()
+	job := newBackupJob(BackupJobOptions{Name: opts.Name, Namespace: opts.Namespace})
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		podtemplatespec.WithVolume(configVolume),
+		caVolume,
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		podtemplatespec.WithContainer(PBMContainerName, container.Apply(
+			container.WithName(PBMContainerName),
+			container.WithImage(opts.Image),
+			container.WithCommand([]string{"/bin/sh", "-c", command}),
+			container.WithEnvs(envs...),
+			container.WithVolumeMounts(volumeMounts),
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
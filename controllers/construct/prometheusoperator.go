@@ -0,0 +1,11 @@
+package construct
+
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+// The monitors and rules are provided by CRDs installed with the Prometheus Operator, so they are handled as
+// unstructured objects.
+var (
+	ServiceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	PodMonitorGVK     = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"}
+	PrometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+)
@@ -0,0 +1,94 @@
+package construct
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const replicaSetRenameContainerName = "rename-replica-set"
+
+// ReplicaSetRenameJobName returns the name of the Job which rewrites the replica set name stored on the given member.
+func ReplicaSetRenameJobName(mdb MongoDBStatefulSetOwner, member int) string {
+	return fmt.Sprintf("%s-rename-%d", mdb.GetName(), member)
+}
+
+// BuildReplicaSetRenameJob returns a Job which starts mongod as a standalone on the data volume of the given
+// member, which must not be running, and renames the replica set configuration stored in the local database.
+// See https://docs.mongodb.com/manual/tutorial/rename-unsharded-replica-set/
+func BuildReplicaSetRenameJob(mdb MongoDBStatefulSetOwner, member int, from, to string) batchv1.Job {
+	// the PersistentVolumeClaims created for the volumeClaimTemplates of the StatefulSet
+	dataClaimName := fmt.Sprintf("%s-%s-%d", mdb.DataVolumeName(), mdb.StatefulSetName(), mdb.MemberOrdinalStart()+member)
+	dataVolume := corev1.Volume{
+		Name: mdb.DataVolumeName(),
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: dataClaimName},
+		},
+	}
+	dataVolumeMount := statefulset.CreateVolumeMount(dataVolume.Name, "/data", statefulset.WithSubPath("data"), statefulset.WithReadOnly(false))
+	if mdb.HasSeparateDataAndLogsVolumes() {
+		dataVolumeMount = statefulset.CreateVolumeMount(dataVolume.Name, "/data", statefulset.WithReadOnly(false))
+	}
+
+	podSecurityContext := podtemplatespec.NOOP()
+	securityContext := container.NOOP()
+	if !envvar.ReadBool(ManagedSecurityContextEnv) {
+		podSecurityContext = podtemplatespec.WithSecurityContext(podtemplatespec.DefaultPodSecurityContext())
+		securityContext = container.WithSecurityContext(container.DefaultSecurityContext())
+	}
+
+	backoffLimit := int32(2)
+	job := batchv1.Job{
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+		},
+	}
+	job.Name = ReplicaSetRenameJobName(mdb, member)
+	job.Namespace = mdb.GetNamespace()
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		podtemplatespec.WithVolume(dataVolume),
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		podtemplatespec.WithContainer(replicaSetRenameContainerName, container.Apply(
+			container.WithName(replicaSetRenameContainerName),
+			container.WithImage(getMongoDBImage(mdb.GetMongoDBVersion())),
+			container.WithCommand([]string{"/bin/sh", "-c", replicaSetRenameCommand(from, to)}),
+			container.WithVolumeMounts([]corev1.VolumeMount{dataVolumeMount}),
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
+
+// replicaSetRenameCommand returns the script renaming the replica set configuration document. Running it
+// again after it succeeded has no effect, so failed Jobs can be retried safely.
+func replicaSetRenameCommand(from, to string) string {
+	fromJSON, _ := json.Marshal(from)
+	toJSON, _ := json.Marshal(to)
+	script := fmt.Sprintf(`var local = db.getSiblingDB("local");
+var config = local.system.replset.findOne({_id: %s});
+if (config !== null) {
+  config._id = %s;
+  local.system.replset.replaceOne({_id: config._id}, config, {upsert: true});
+  local.system.replset.deleteOne({_id: %s});
+}`, fromJSON, toJSON, fromJSON)
+
+	return fmt.Sprintf(`set -e
+mongod --dbpath /data --port 27017 --bind_ip localhost --fork --logpath /tmp/mongod.log
+shell=mongo
+command -v mongo > /dev/null || shell=mongosh
+$shell --quiet --port 27017 --eval '%s'
+mongod --dbpath /data --shutdown
+`, script)
+}
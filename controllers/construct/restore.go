@@ -0,0 +1,120 @@
+package construct
+
+import (
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RestoreDownloadContainerName is the name of the init container downloading the archive from the bucket.
+	RestoreDownloadContainerName = "download"
+	// RestoreContainerName is the name of the container running mongorestore.
+	RestoreContainerName = "mongorestore"
+
+	restoreVolumeName = "restore"
+	restoreArchive    = "/restore/archive.gz"
+	restoreOplogDir   = "/restore/oplog"
+)
+
+// RestoreJobOptions configures the Job restoring an archive into a resource. The options of the bucket refer to
+// the archive which is downloaded instead of uploaded.
+type RestoreJobOptions struct {
+	BackupJobOptions
+
+	// Drop drops each collection before it is restored
+	Drop bool
+
+	// Oplog replays the oplog slices of the given range after the archive has been restored, nil to only
+	// restore the archive
+	Oplog *OplogReplayOptions
+}
+
+// BuildRestoreJob returns a Job which downloads a gzip compressed archive written by mongodump from an
+// S3-compatible bucket, and restores it into the given resource. The users and roles in the archive are not
+// restored, as they are managed by the operator. If the options contain an oplog range, the oplog slices
+// overlapping the range are downloaded too and replayed in order after the archive has been restored. The
+// downloaded files are decrypted if the options configure an encryption.
+func BuildRestoreJob(mdb MongoDBStatefulSetOwner, opts RestoreJobOptions) batchv1.Job {
+	restoreVolume := statefulset.CreateVolumeFromEmptyDir(restoreVolumeName)
+	restoreVolumeMount := statefulset.CreateVolumeMount(restoreVolumeName, "/restore", statefulset.WithReadOnly(false))
+
+	decryptArchive, decryptSlice := "", ""
+	if opts.Encryption != nil {
+		decryptArchive = decryptFunction + "decrypt " + restoreArchive + "\n"
+		decryptSlice = "\n      decrypt \"" + restoreOplogDir + "/$slice\""
+	}
+	// the slices are named <from>-<until>.bson.gz in seconds since the epoch
+	downloadCommand := `set -e
+aws s3 cp "$S3_URL" ` + restoreArchive + ` ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"}
+` + decryptArchive + `if [ -n "$OPLOG_URL" ]; then
+  mkdir -p ` + restoreOplogDir + `
+  aws s3 ls "$OPLOG_URL" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"} | awk '{print $4}' | while read -r slice; do
+    from=${slice%%-*}
+    until=${slice#*-}
+    until=${until%%.*}
+    if [ "$until" -gt "$OPLOG_FROM" ] && [ "$from" -lt "$OPLOG_UNTIL" ]; then
+      aws s3 cp "$OPLOG_URL$slice" "` + restoreOplogDir + `/$slice" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"}` + decryptSlice + `
+    fi
+  done
+fi
+`
+	restoreCommand := `set -e
+mongorestore --uri="$MONGODB_URI" --archive=` + restoreArchive + ` --gzip --nsExclude='admin.system.*' $DROP_OPTION $TLS_OPTIONS
+if [ -d ` + restoreOplogDir + ` ]; then
+  mkdir -p /restore/empty
+  for slice in $(ls ` + restoreOplogDir + ` | sort -n); do
+    gunzip -c "` + restoreOplogDir + `/$slice" > /restore/oplog.bson
+    mongorestore --uri="$MONGODB_URI" --oplogReplay --oplogFile=/restore/oplog.bson --oplogLimit="$OPLOG_UNTIL:0" $TLS_OPTIONS /restore/empty
+  done
+fi
+`
+
+	restoreEnvs := append([]corev1.EnvVar{secretEnvVar("MONGODB_URI", opts.ConnectionStringSecretName, opts.ConnectionStringKey)}, oplogReplayEnvVars(opts.Oplog)...)
+	if opts.Drop {
+		restoreEnvs = append(restoreEnvs, corev1.EnvVar{Name: "DROP_OPTION", Value: "--drop"})
+	}
+	restoreVolumeMounts := []corev1.VolumeMount{restoreVolumeMount}
+	caVolume := podtemplatespec.NOOP()
+	if opts.CAConfigMapName != "" {
+		caVolume = podtemplatespec.WithVolume(statefulset.CreateVolumeFromConfigMap(backupTLSCAName, opts.CAConfigMapName))
+		restoreVolumeMounts = append(restoreVolumeMounts, statefulset.CreateVolumeMount(backupTLSCAName, "/tls"))
+		restoreEnvs = append(restoreEnvs, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "--sslCAFile=/tls/ca.crt"})
+	}
+
+	podSecurityContext, securityContext := backupSecurityContexts()
+	encryption, encryptionKeys := withEncryption(opts.Encryption)
+	job := newBackupJob(opts.BackupJobOptions)
+
+	podtemplatespec.Apply(
+		podSecurityContext,
+		podtemplatespec.WithVolume(restoreVolume),
+		caVolume,
+		func(template *corev1.PodTemplateSpec) {
+			template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		},
+		encryption,
+		podtemplatespec.WithInitContainer(RestoreDownloadContainerName, container.Apply(
+			container.WithName(RestoreDownloadContainerName),
+			container.WithImage(opts.UploadImage),
+			container.WithCommand([]string{"/bin/sh", "-c", downloadCommand}),
+			container.WithEnvs(append(s3EnvVars(opts.BackupJobOptions), oplogReplayEnvVars(opts.Oplog)...)...),
+			container.WithVolumeMounts([]corev1.VolumeMount{restoreVolumeMount}),
+			encryptionKeys,
+			securityContext,
+		)),
+		podtemplatespec.WithContainer(RestoreContainerName, container.Apply(
+			container.WithName(RestoreContainerName),
+			container.WithImage(getMongoDBImage(mdb.GetMongoDBVersion())),
+			container.WithCommand([]string{"/bin/sh", "-c", restoreCommand}),
+			container.WithEnvs(restoreEnvs...),
+			container.WithVolumeMounts(restoreVolumeMounts),
+			securityContext,
+		)),
+		BuildSecurityContextPresetJobModification(mdb),
+	)(&job.Spec.Template)
+
+	return job
+}
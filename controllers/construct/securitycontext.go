@@ -0,0 +1,147 @@
+package construct
+
+import (
+	"fmt"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecurityContextPresetRestricted satisfies the "restricted" Pod Security Standard.
+	SecurityContextPresetRestricted = "restricted"
+	// SecurityContextPresetBaseline is the security context the operator configures by default.
+	SecurityContextPresetBaseline = "baseline"
+	// SecurityContextPresetOpenShift leaves the user and group to be assigned by the OpenShift SecurityContextConstraints.
+	SecurityContextPresetOpenShift = "openshift"
+	// SecurityContextPresetLegacy fixes the ownership of the volumes with an init container running as root,
+	// for storage which does not support fsGroup.
+	SecurityContextPresetLegacy = "legacy"
+
+	volumePermissionsContainerName = "volume-permissions"
+
+	mongodbUserID = int64(2000)
+)
+
+// BuildSecurityContextPresetModification returns a modification which configures the security context of the Pod
+// and of every container in it according to the preset of the given resource. It must be applied after all containers
+// have been added to the Pod template. It does nothing if no preset is selected.
+func BuildSecurityContextPresetModification(mdb MongoDBStatefulSetOwner) podtemplatespec.Modification {
+	preset := mdb.GetSecurityContextPreset()
+	if preset == "" {
+		return podtemplatespec.NOOP()
+	}
+	podSecurityContext, securityContext := securityContextsForPreset(preset)
+
+	volumePermissions := removeInitContainer(volumePermissionsContainerName)
+	if preset == SecurityContextPresetLegacy {
+		volumePermissions = podtemplatespec.WithInitContainer(volumePermissionsContainerName, volumePermissionsInit(mdb))
+	}
+
+	return podtemplatespec.Apply(
+		podtemplatespec.WithSecurityContext(podSecurityContext),
+		withContainersSecurityContext(securityContext),
+		// the init container is added once the security context of the other containers has been set, as it needs to run as root.
+		volumePermissions,
+	)
+}
+
+// BuildSecurityContextPresetJobModification returns a modification which configures the security context of the Pod
+// of a Job which runs on the volumes of the given resource. It does nothing if no preset is selected.
+func BuildSecurityContextPresetJobModification(mdb MongoDBStatefulSetOwner) podtemplatespec.Modification {
+	preset := mdb.GetSecurityContextPreset()
+	if preset == "" {
+		return podtemplatespec.NOOP()
+	}
+	podSecurityContext, securityContext := securityContextsForPreset(preset)
+	return podtemplatespec.Apply(
+		podtemplatespec.WithSecurityContext(podSecurityContext),
+		withContainersSecurityContext(securityContext),
+	)
+}
+
+// securityContextsForPreset returns the security contexts of the Pod and of its containers for the given preset.
+func securityContextsForPreset(preset string) (corev1.PodSecurityContext, corev1.SecurityContext) {
+	runAsUser := mongodbUserID
+	fsGroup := mongodbUserID
+	runAsNonRoot := true
+	allowPrivilegeEscalation := false
+	dropAllCapabilities := &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+
+	switch preset {
+	case SecurityContextPresetRestricted:
+		fsGroupChangePolicy := corev1.FSGroupChangeOnRootMismatch
+		return corev1.PodSecurityContext{
+			RunAsUser:           &runAsUser,
+			RunAsNonRoot:        &runAsNonRoot,
+			FSGroup:             &fsGroup,
+			FSGroupChangePolicy: &fsGroupChangePolicy,
+			SeccompProfile:      &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		}, corev1.SecurityContext{
+			RunAsUser:                &runAsUser,
+			RunAsNonRoot:             &runAsNonRoot,
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities:             dropAllCapabilities,
+		}
+	case SecurityContextPresetOpenShift:
+		return corev1.PodSecurityContext{}, corev1.SecurityContext{
+			RunAsNonRoot:             &runAsNonRoot,
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities:             dropAllCapabilities,
+		}
+	case SecurityContextPresetLegacy:
+		return corev1.PodSecurityContext{}, container.DefaultSecurityContext()
+	default:
+		return podtemplatespec.DefaultPodSecurityContext(), container.DefaultSecurityContext()
+	}
+}
+
+// withContainersSecurityContext sets the security context of all containers and init containers of the Pod.
+func withContainersSecurityContext(securityContext corev1.SecurityContext) podtemplatespec.Modification {
+	return func(template *corev1.PodTemplateSpec) {
+		for i := range template.Spec.InitContainers {
+			container.WithSecurityContext(securityContext)(&template.Spec.InitContainers[i])
+		}
+		for i := range template.Spec.Containers {
+			container.WithSecurityContext(securityContext)(&template.Spec.Containers[i])
+		}
+	}
+}
+
+// removeInitContainer removes the init container with the given name from the Pod, if it exists.
+func removeInitContainer(name string) podtemplatespec.Modification {
+	return func(template *corev1.PodTemplateSpec) {
+		var initContainers []corev1.Container
+		for _, c := range template.Spec.InitContainers {
+			if c.Name != name {
+				initContainers = append(initContainers, c)
+			}
+		}
+		template.Spec.InitContainers = initContainers
+	}
+}
+
+// volumePermissionsInit returns a modification function which will add the container that changes the
+// ownership of the data and logs volumes to the user mongod and the agent are running as.
+func volumePermissionsInit(mdb MongoDBStatefulSetOwner) container.Modification {
+	rootUser := int64(0)
+	runAsNonRoot := false
+	volumeMounts := []corev1.VolumeMount{statefulset.CreateVolumeMount(mdb.DataVolumeName(), "/data", statefulset.WithReadOnly(false))}
+	paths := "/data"
+	if mdb.HasSeparateDataAndLogsVolumes() {
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(mdb.LogsVolumeName(), automationconfig.DefaultAgentLogPath, statefulset.WithReadOnly(false)))
+		paths += " " + automationconfig.DefaultAgentLogPath
+	}
+
+	return container.Apply(
+		container.WithName(volumePermissionsContainerName),
+		container.WithImage(getMongoDBImage(mdb.GetMongoDBVersion())),
+		container.WithCommand([]string{"/bin/sh", "-c", fmt.Sprintf("chown -R %d:%d %s", mongodbUserID, mongodbUserID, paths)}),
+		container.WithVolumeMounts(volumeMounts),
+		container.WithSecurityContext(corev1.SecurityContext{RunAsUser: &rootUser, RunAsNonRoot: &runAsNonRoot}),
+	)
+}
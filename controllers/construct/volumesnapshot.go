@@ -0,0 +1,31 @@
+package construct
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The snapshot API is provided by CRDs installed with the CSI snapshot controller, so its objects are handled
+// as unstructured objects.
+var (
+	VolumeSnapshotGVK        = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+	VolumeSnapshotContentGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshotContent"}
+)
+
+// BuildVolumeSnapshot returns a VolumeSnapshot of the given PersistentVolumeClaim. The default VolumeSnapshotClass
+// of the cluster is used if the class name is empty.
+func BuildVolumeSnapshot(name, namespace, persistentVolumeClaimName, volumeSnapshotClassName string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": persistentVolumeClaimName,
+		},
+	}
+	if volumeSnapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = volumeSnapshotClassName
+	}
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	snapshot.SetGroupVersionKind(VolumeSnapshotGVK)
+	snapshot.SetName(name)
+	snapshot.SetNamespace(namespace)
+	return snapshot
+}
@@ -0,0 +1,83 @@
+package construct
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// writablePaths returns the directories each container of the members writes to, by container name.
+func writablePaths(mdb MongoDBStatefulSetOwner) map[string][]string {
+	tmp := mdb.TemporaryDirectory()
+	return map[string][]string{
+		AgentName:                   {tmp, "/data", automationconfig.DefaultAgentLogPath, path.Dir(AgentHealthStatusFilePath), path.Dir(keyfileFilePath)},
+		MongodbName:                 {tmp, "/data", automationconfig.DefaultAgentLogPath},
+		versionUpgradeHookName:      {"/hooks"},
+		ReadinessProbeContainerName: {path.Dir(readinessProbePath)},
+	}
+}
+
+// ValidateWritablePaths returns an error if a container of the members would write outside of the writable
+// volumes mounted in the given Pod template, which fails on a read-only root filesystem. This happens when the
+// StatefulSet configuration of the resource removes a volume mount or mounts it read-only.
+func ValidateWritablePaths(mdb MongoDBStatefulSetOwner, template corev1.PodTemplateSpec) error {
+	containers := map[string]corev1.Container{}
+	for _, c := range append(template.Spec.InitContainers, template.Spec.Containers...) {
+		containers[c.Name] = c
+	}
+
+	paths := writablePaths(mdb)
+	var names []string
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c, ok := containers[name]
+		if !ok {
+			continue
+		}
+		mountPaths := map[string]bool{}
+		for _, mount := range c.VolumeMounts {
+			if mountPaths[path.Clean(mount.MountPath)] {
+				return errors.Errorf("more than one volume is mounted at %s in container %s", mount.MountPath, name)
+			}
+			mountPaths[path.Clean(mount.MountPath)] = true
+		}
+		for _, p := range paths[name] {
+			mount, ok := volumeMountOf(c.VolumeMounts, p)
+			if !ok {
+				return errors.Errorf("container %s writes to %s, which is not on a volume", name, p)
+			}
+			if mount.ReadOnly {
+				return errors.Errorf("container %s writes to %s, which is on the read-only volume %s", name, p, mount.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// volumeMountOf returns the volume mount containing the given path, which is the mount with the longest mount
+// path that is the path or one of its parents.
+func volumeMountOf(mounts []corev1.VolumeMount, p string) (corev1.VolumeMount, bool) {
+	var found corev1.VolumeMount
+	ok := false
+	for _, mount := range mounts {
+		mountPath := path.Clean(mount.MountPath)
+		if p != mountPath && !strings.HasPrefix(p, strings.TrimSuffix(mountPath, "/")+"/") {
+			continue
+		}
+		if !ok || len(mountPath) > len(path.Clean(found.MountPath)) {
+			found = mount
+			ok = true
+		}
+	}
+	return found, ok
+}
@@ -0,0 +1,84 @@
+package construct
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+)
+
+func TestTemporaryDirectory(t *testing.T) {
+	mdb := newTestReplicaSet()
+	sizeLimit := resource.MustParse("64Mi")
+	mdb.Spec.TemporaryDirectory.Path = "/scratch/"
+	mdb.Spec.TemporaryDirectory.Medium = corev1.StorageMediumMemory
+	mdb.Spec.TemporaryDirectory.SizeLimit = &sizeLimit
+	sts := appsv1.StatefulSet{}
+	BuildMongoDBReplicaSetStatefulSetModificationFunction(&mdb, mdb)(&sts)
+
+	var volume *corev1.Volume
+	for i := range sts.Spec.Template.Spec.Volumes {
+		if sts.Spec.Template.Spec.Volumes[i].Name == "tmp" {
+			volume = &sts.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	if assert.NotNil(t, volume) {
+		assert.Equal(t, corev1.StorageMediumMemory, volume.EmptyDir.Medium)
+		assert.Equal(t, "64Mi", volume.EmptyDir.SizeLimit.String())
+	}
+
+	for _, name := range []string{AgentName, MongodbName} {
+		c := container.GetByName(name, sts.Spec.Template.Spec.Containers)
+		assert.Contains(t, c.VolumeMounts, corev1.VolumeMount{Name: "tmp", MountPath: "/scratch"})
+		assert.Contains(t, c.Env, corev1.EnvVar{Name: "TMPDIR", Value: "/scratch"})
+	}
+	assert.NotContains(t, MongodbUserCommand, "> /tmp/", "the passwd file is written to the temporary directory")
+}
+
+func TestValidateWritablePaths(t *testing.T) {
+	mdb := newTestReplicaSet()
+	build := func() appsv1.StatefulSet {
+		sts := appsv1.StatefulSet{}
+		BuildMongoDBReplicaSetStatefulSetModificationFunction(&mdb, mdb)(&sts)
+		return sts
+	}
+
+	t.Run("The default Pod template only writes to volumes", func(t *testing.T) {
+		sts := build()
+		assert.NoError(t, ValidateWritablePaths(&mdb, sts.Spec.Template))
+	})
+
+	t.Run("A read-only volume is rejected", func(t *testing.T) {
+		sts := build()
+		mongod := container.GetByName(MongodbName, sts.Spec.Template.Spec.Containers)
+		for i := range mongod.VolumeMounts {
+			if mongod.VolumeMounts[i].Name == "tmp" {
+				mongod.VolumeMounts[i].ReadOnly = true
+			}
+		}
+		assert.EqualError(t, ValidateWritablePaths(&mdb, sts.Spec.Template), "container mongod writes to /tmp, which is on the read-only volume tmp")
+	})
+
+	t.Run("A missing volume is rejected", func(t *testing.T) {
+		sts := build()
+		agent := container.GetByName(AgentName, sts.Spec.Template.Spec.Containers)
+		var mounts []corev1.VolumeMount
+		for _, m := range agent.VolumeMounts {
+			if m.Name != "tmp" {
+				mounts = append(mounts, m)
+			}
+		}
+		agent.VolumeMounts = mounts
+		assert.EqualError(t, ValidateWritablePaths(&mdb, sts.Spec.Template), "container mongodb-agent writes to /tmp, which is not on a volume")
+	})
+
+	t.Run("A temporary directory colliding with another volume is rejected", func(t *testing.T) {
+		mdb.Spec.TemporaryDirectory.Path = "/data"
+		sts := build()
+		assert.EqualError(t, ValidateWritablePaths(&mdb, sts.Spec.Template), "more than one volume is mounted at /data in container mongod")
+	})
+}
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stretchr/objx"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	auditLogForwarderName = "audit-log-forwarder"
+
+	auditLogForwarderConfigMountPath = "/fluent-bit/config/"
+	auditLogForwarderBufferMountPath = "/fluent-bit/buffer/"
+	auditLogForwarderConfigKey       = "fluent-bit.conf"
+	auditLogForwarderParsersKey      = "parsers.conf"
+
+	// auditLogForwarderConfigHashAnnotation restarts the forwarder sidecars whenever their configuration changes.
+	auditLogForwarderConfigHashAnnotation = "mongodbcommunity.mongodb.com/audit-log-forwarder-config-hash"
+
+	defaultAuditLogPath     = automationconfig.DefaultAgentLogPath + "/audit.json"
+	defaultBSONAuditLogPath = automationconfig.DefaultAgentLogPath + "/audit.bson"
+)
+
+// validateAuditLog checks the audit log configuration in spec.security.auditLog.
+func validateAuditLog(mdb mdbv1.MongoDBCommunity) error {
+	auditLog := mdb.Spec.Security.AuditLog
+	if auditLog == nil {
+		return nil
+	}
+	if objx.New(mdb.Spec.AdditionalMongodConfig.Object).Has("auditLog") {
+		return errors.New("security.auditLog can not be combined with auditLog in additionalMongodConfig")
+	}
+	if auditLog.Destination != mdbv1.AuditLogDestinationFile && (auditLog.Format != "" || auditLog.Path != "") {
+		return errors.Errorf("security.auditLog.format and security.auditLog.path can only be set with the %q destination", mdbv1.AuditLogDestinationFile)
+	}
+	if auditLog.Path != "" && !inAgentLogDirectory(auditLog.Path) {
+		return errors.Errorf("security.auditLog.path must be in %s, got %q", automationconfig.DefaultAgentLogPath, auditLog.Path)
+	}
+	if auditLog.Filter != "" {
+		filter := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(auditLog.Filter), &filter); err != nil {
+			return errors.Errorf("security.auditLog.filter is not a JSON document: %s", err)
+		}
+	}
+	return nil
+}
+
+// auditLogSettings returns the destination, the format and the path of the audit log file, as configured in
+// spec.security.auditLog or in the auditLog section of additionalMongodConfig.
+func auditLogSettings(mdb mdbv1.MongoDBCommunity) (string, string, string) {
+	if auditLog := mdb.Spec.Security.AuditLog; auditLog != nil {
+		if auditLog.Destination != mdbv1.AuditLogDestinationFile {
+			return string(auditLog.Destination), "", ""
+		}
+		return string(auditLog.Destination), string(auditLog.GetFormat()), auditLog.Path
+	}
+	config := objx.New(mdb.Spec.AdditionalMongodConfig.Object)
+	return config.Get("auditLog.destination").Str(), config.Get("auditLog.format").Str(), config.Get("auditLog.path").Str()
+}
+
+// inAgentLogDirectory returns true if the given path is in the directory the logs volume is mounted at.
+func inAgentLogDirectory(p string) bool {
+	return strings.HasPrefix(path.Clean(p), automationconfig.DefaultAgentLogPath+"/")
+}
+
+// auditLogModification configures the audit log of mongod as specified in spec.security.auditLog.
+func auditLogModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	auditLog := mdb.Spec.Security.AuditLog
+	if auditLog == nil {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			args := config.Processes[i].Args26
+			args.Set("auditLog.destination", string(auditLog.Destination))
+			if auditLog.Destination == mdbv1.AuditLogDestinationFile {
+				args.Set("auditLog.format", string(auditLog.GetFormat()))
+				args.Set("auditLog.path", getAuditLogPath(mdb))
+			}
+			if auditLog.Filter != "" {
+				args.Set("auditLog.filter", auditLog.Filter)
+			}
+		}
+	}
+}
+
+// validateAuditLogForwarder checks that audit logging is configured in a way the forwarder can read.
+func validateAuditLogForwarder(mdb mdbv1.MongoDBCommunity) error {
+	forwarder := mdb.Spec.AuditLogForwarder
+	if forwarder == nil {
+		return nil
+	}
+
+	if (forwarder.Syslog == nil) == (forwarder.HTTP == nil) {
+		return errors.New("exactly one of auditLogForwarder.syslog and auditLogForwarder.http must be specified")
+	}
+
+	destination, format, auditLogPath := auditLogSettings(mdb)
+	if destination != "file" {
+		return errors.Errorf("the audit log forwarder requires auditLog.destination to be \"file\", got %q", destination)
+	}
+	if format != "" && format != "JSON" {
+		return errors.Errorf("the audit log forwarder requires auditLog.format to be \"JSON\", got %q", format)
+	}
+	if auditLogPath != "" && !inAgentLogDirectory(auditLogPath) {
+		return errors.Errorf("the audit log forwarder requires auditLog.path to be in %s, got %q", automationconfig.DefaultAgentLogPath, auditLogPath)
+	}
+	return nil
+}
+
+// getAuditLogPath returns the path of the audit log file.
+func getAuditLogPath(mdb mdbv1.MongoDBCommunity) string {
+	_, format, auditLogPath := auditLogSettings(mdb)
+	if auditLogPath != "" {
+		return auditLogPath
+	}
+	if format == string(mdbv1.AuditLogFormatBSON) {
+		return defaultBSONAuditLogPath
+	}
+	return defaultAuditLogPath
+}
+
+// auditLogForwarderModification configures mongod to write the audit log as JSON to the file read by the forwarder.
+func auditLogForwarderModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if mdb.Spec.AuditLogForwarder == nil {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			args := config.Processes[i].Args26
+			args.Set("auditLog.format", "JSON")
+			args.Set("auditLog.path", getAuditLogPath(mdb))
+		}
+	}
+}
+
+// ensureAuditLogForwarderConfig creates or updates the ConfigMap storing the configuration of the forwarder,
+// or deletes it if the forwarder is not configured.
+func (r *ReplicaSetReconciler) ensureAuditLogForwarderConfig(mdb mdbv1.MongoDBCommunity) error {
+	nsName := types.NamespacedName{Name: mdb.AuditLogForwarderConfigMapName(), Namespace: mdb.Namespace}
+	if mdb.Spec.AuditLogForwarder == nil {
+		if err := r.client.DeleteConfigMap(nsName); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	cm := configmap.Builder().
+		SetName(nsName.Name).
+		SetNamespace(nsName.Namespace).
+		SetField(auditLogForwarderConfigKey, buildAuditLogForwarderConfig(mdb)).
+		SetField(auditLogForwarderParsersKey, auditLogForwarderParsers).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+
+	return configmap.CreateOrUpdate(r.client, cm)
+}
+
+// auditLogForwarderParsers parses every line of the audit log as a JSON document.
+const auditLogForwarderParsers = `[PARSER]
+    Name   json
+    Format json
+`
+
+// buildAuditLogForwarderConfig returns the Fluent Bit configuration which tails the audit log and ships
+// it to the configured destination. The tail input is paused once the memory buffer is full, and events
+// which could not be delivered after all retries are dropped and counted in the
+// fluentbit_output_dropped_records_total metric.
+func buildAuditLogForwarderConfig(mdb mdbv1.MongoDBCommunity) string {
+	forwarder := *mdb.Spec.AuditLogForwarder
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, `[SERVICE]
+    Flush        1
+    Log_Level    info
+    Parsers_File %s
+    HTTP_Server  On
+    HTTP_Listen  0.0.0.0
+    HTTP_Port    %d
+    storage.path %s
+
+[INPUT]
+    Name             tail
+    Tag              audit
+    Path             %s
+    DB               %s
+    Mem_Buf_Limit    %s
+    storage.type     filesystem
+    Refresh_Interval 5
+    Skip_Long_Lines  On
+`,
+		auditLogForwarderConfigMountPath+auditLogForwarderParsersKey,
+		forwarder.GetMetricsPort(),
+		auditLogForwarderBufferMountPath,
+		getAuditLogPath(mdb),
+		auditLogForwarderBufferMountPath+"audit.db",
+		forwarder.GetBufferLimit(),
+	)
+
+	if forwarder.HTTP != nil {
+		uri := forwarder.HTTP.URI
+		if uri == "" {
+			uri = "/"
+		}
+		tls := "Off"
+		if forwarder.HTTP.TLS {
+			tls = "On"
+		}
+		fmt.Fprintf(&b, `    Parser           json
+
+[OUTPUT]
+    Name        http
+    Match       audit
+    Host        %s
+    Port        %d
+    URI         %s
+    Format      json
+    tls         %s
+    Retry_Limit %d
+`, forwarder.HTTP.Host, forwarder.HTTP.Port, uri, tls, forwarder.GetRetryLimit())
+		return b.String()
+	}
+
+	mode := forwarder.Syslog.Mode
+	if mode == "" {
+		mode = "tcp"
+	}
+	fmt.Fprintf(&b, `
+[OUTPUT]
+    Name               syslog
+    Match              audit
+    Host               %s
+    Port               %d
+    Mode               %s
+    Syslog_Format      rfc5424
+    Syslog_Message_Key log
+    Retry_Limit        %d
+`, forwarder.Syslog.Host, forwarder.Syslog.Port, mode, forwarder.GetRetryLimit())
+	return b.String()
+}
+
+// buildAuditLogForwarderPodSpecModification adds the forwarder sidecar to the pod template if it is configured.
+func buildAuditLogForwarderPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	forwarder := mdb.Spec.AuditLogForwarder
+	if forwarder == nil {
+		return podtemplatespec.NOOP()
+	}
+
+	configVolume := statefulset.CreateVolumeFromConfigMap("audit-log-forwarder-config", mdb.AuditLogForwarderConfigMapName())
+	configVolumeMount := statefulset.CreateVolumeMount(configVolume.Name, auditLogForwarderConfigMountPath, statefulset.WithReadOnly(true))
+
+	// the buffer stores the position in the audit log and the events which have not been delivered yet.
+	bufferVolume := statefulset.CreateVolumeFromEmptyDir("audit-log-forwarder-buffer")
+	bufferVolumeMount := statefulset.CreateVolumeMount(bufferVolume.Name, auditLogForwarderBufferMountPath, statefulset.WithReadOnly(false))
+
+	logsVolumeMount := statefulset.CreateVolumeMount(mdb.DataVolumeName(), automationconfig.DefaultAgentLogPath, statefulset.WithSubPath("logs"), statefulset.WithReadOnly(true))
+	if mdb.HasSeparateDataAndLogsVolumes() {
+		logsVolumeMount = statefulset.CreateVolumeMount(mdb.LogsVolumeName(), automationconfig.DefaultAgentLogPath, statefulset.WithReadOnly(true))
+	}
+
+	return podtemplatespec.Apply(
+		podtemplatespec.WithAnnotations(map[string]string{
+			auditLogForwarderConfigHashAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(buildAuditLogForwarderConfig(mdb)))),
+		}),
+		podtemplatespec.WithVolume(configVolume),
+		podtemplatespec.WithVolume(bufferVolume),
+		podtemplatespec.WithContainer(auditLogForwarderName, container.Apply(
+			container.WithName(auditLogForwarderName),
+			container.WithImage(forwarder.GetImage()),
+			container.WithCommand([]string{"/fluent-bit/bin/fluent-bit", "-c", auditLogForwarderConfigMountPath + auditLogForwarderConfigKey}),
+			container.WithVolumeMounts([]corev1.VolumeMount{configVolumeMount, bufferVolumeMount, logsVolumeMount}),
+			container.WithPorts([]corev1.ContainerPort{{
+				Name:          "forwarder-metrics",
+				ContainerPort: int32(forwarder.GetMetricsPort()),
+			}}),
+		)),
+	)
+}
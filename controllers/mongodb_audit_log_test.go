@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestValidateAuditLogForwarder(t *testing.T) {
+	t.Run("Valid configuration", func(t *testing.T) {
+		assert.NoError(t, validateAuditLogForwarder(newTestReplicaSetWithAuditLogForwarder()))
+	})
+	t.Run("Forwarder is not configured", func(t *testing.T) {
+		assert.NoError(t, validateAuditLogForwarder(newTestReplicaSet()))
+	})
+	t.Run("Both destinations are configured", func(t *testing.T) {
+		mdb := newTestReplicaSetWithAuditLogForwarder()
+		mdb.Spec.AuditLogForwarder.HTTP = &mdbv1.AuditLogHTTPDestination{Host: "audit.example.com", Port: 443}
+		assert.Error(t, validateAuditLogForwarder(mdb))
+	})
+	t.Run("No destination is configured", func(t *testing.T) {
+		mdb := newTestReplicaSetWithAuditLogForwarder()
+		mdb.Spec.AuditLogForwarder.Syslog = nil
+		assert.Error(t, validateAuditLogForwarder(mdb))
+	})
+	t.Run("Audit log is not written to a file", func(t *testing.T) {
+		mdb := newTestReplicaSetWithAuditLogForwarder()
+		mdb.Spec.AdditionalMongodConfig.Object["auditLog"] = map[string]interface{}{"destination": "syslog"}
+		assert.Error(t, validateAuditLogForwarder(mdb))
+	})
+	t.Run("Audit log is written as BSON", func(t *testing.T) {
+		mdb := newTestReplicaSetWithAuditLogForwarder()
+		mdb.Spec.AdditionalMongodConfig.Object["auditLog"] = map[string]interface{}{"destination": "file", "format": "BSON"}
+		assert.Error(t, validateAuditLogForwarder(mdb))
+	})
+	t.Run("Audit log is not in the logs volume", func(t *testing.T) {
+		mdb := newTestReplicaSetWithAuditLogForwarder()
+		mdb.Spec.AdditionalMongodConfig.Object["auditLog"] = map[string]interface{}{"destination": "file", "path": "/tmp/audit.json"}
+		assert.Error(t, validateAuditLogForwarder(mdb))
+	})
+}
+
+func TestValidateAuditLog(t *testing.T) {
+	newReplicaSet := func(auditLog mdbv1.AuditLog) mdbv1.MongoDBCommunity {
+		mdb := newTestReplicaSet()
+		mdb.Spec.Security.AuditLog = &auditLog
+		return mdb
+	}
+	t.Run("Valid configuration", func(t *testing.T) {
+		assert.NoError(t, validateAuditLog(newReplicaSet(mdbv1.AuditLog{Destination: "file", Format: "BSON", Path: "/var/log/mongodb-mms-automation/audit/audit.bson", Filter: `{"atype": "authenticate"}`})))
+		assert.NoError(t, validateAuditLog(newReplicaSet(mdbv1.AuditLog{Destination: "console"})))
+	})
+	t.Run("Audit log is also configured in additionalMongodConfig", func(t *testing.T) {
+		mdb := newTestReplicaSetWithAuditLogForwarder()
+		mdb.Spec.Security.AuditLog = &mdbv1.AuditLog{Destination: "file"}
+		assert.EqualError(t, validateAuditLog(mdb), "security.auditLog can not be combined with auditLog in additionalMongodConfig")
+	})
+	t.Run("Format is set without the file destination", func(t *testing.T) {
+		assert.Error(t, validateAuditLog(newReplicaSet(mdbv1.AuditLog{Destination: "syslog", Format: "JSON"})))
+	})
+	t.Run("Audit log is not in the logs volume", func(t *testing.T) {
+		assert.EqualError(t, validateAuditLog(newReplicaSet(mdbv1.AuditLog{Destination: "file", Path: "/var/log/mongodb-mms-automation/../audit.json"})),
+			`security.auditLog.path must be in /var/log/mongodb-mms-automation, got "/var/log/mongodb-mms-automation/../audit.json"`)
+	})
+	t.Run("Filter is not a JSON document", func(t *testing.T) {
+		assert.Error(t, validateAuditLog(newReplicaSet(mdbv1.AuditLog{Destination: "file", Filter: "atype: authenticate"})))
+	})
+}
+
+func TestAuditLog_IsConfigured(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Security.AuditLog = &mdbv1.AuditLog{Destination: "file", Format: "BSON", Filter: `{"atype": {"$in": ["authenticate", "createUser"]}}`}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, p := range ac.Processes {
+		assert.Equal(t, "file", p.Args26.Get("auditLog.destination").Str())
+		assert.Equal(t, "BSON", p.Args26.Get("auditLog.format").Str())
+		assert.Equal(t, defaultBSONAuditLogPath, p.Args26.Get("auditLog.path").Str())
+		assert.Equal(t, `{"atype": {"$in": ["authenticate", "createUser"]}}`, p.Args26.Get("auditLog.filter").Str())
+	}
+
+	t.Run("The forwarder requires the JSON format", func(t *testing.T) {
+		mdb.Spec.AuditLogForwarder = &mdbv1.AuditLogForwarder{Syslog: &mdbv1.AuditLogSyslogDestination{Host: "syslog.example.com", Port: 514}}
+		assert.Error(t, validateAuditLogForwarder(mdb))
+		mdb.Spec.Security.AuditLog.Format = ""
+		assert.NoError(t, validateAuditLogForwarder(mdb))
+		assert.Equal(t, defaultAuditLogPath, getAuditLogPath(mdb))
+	})
+
+	t.Run("Console destination", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Security.AuditLog = &mdbv1.AuditLog{Destination: "console"}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		makeStatefulSetReady(t, mgr.GetClient(), mdb)
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		for _, p := range ac.Processes {
+			assert.Equal(t, "console", p.Args26.Get("auditLog.destination").Str())
+			assert.False(t, p.Args26.Has("auditLog.path"))
+			assert.False(t, p.Args26.Has("auditLog.filter"))
+		}
+	})
+}
+
+func TestBuildAuditLogForwarderConfig(t *testing.T) {
+	mdb := newTestReplicaSetWithAuditLogForwarder()
+	config := buildAuditLogForwarderConfig(mdb)
+	assert.Contains(t, config, "Path             /var/log/mongodb-mms-automation/audit.json\n")
+	assert.Contains(t, config, "Mem_Buf_Limit    5MB\n")
+	assert.Contains(t, config, "Name               syslog\n")
+	assert.Contains(t, config, "Host               syslog.example.com\n")
+	assert.Contains(t, config, "Mode               tcp\n")
+	assert.Contains(t, config, "Retry_Limit        5\n")
+
+	mdb.Spec.AuditLogForwarder.Syslog = nil
+	mdb.Spec.AuditLogForwarder.HTTP = &mdbv1.AuditLogHTTPDestination{Host: "audit.example.com", Port: 443, URI: "/events", TLS: true}
+	config = buildAuditLogForwarderConfig(mdb)
+	assert.Contains(t, config, "Parser           json\n")
+	assert.Contains(t, config, "Name        http\n")
+	assert.Contains(t, config, "URI         /events\n")
+	assert.Contains(t, config, "tls         On\n")
+}
+
+func TestAuditLogForwarder_IsDeployed(t *testing.T) {
+	mdb := newTestReplicaSetWithAuditLogForwarder()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	cm, err := mgr.Client.GetConfigMap(types.NamespacedName{Name: mdb.AuditLogForwarderConfigMapName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, buildAuditLogForwarderConfig(mdb), cm.Data[auditLogForwarderConfigKey])
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	sidecar := container.GetByName(auditLogForwarderName, sts.Spec.Template.Spec.Containers)
+	if assert.NotNil(t, sidecar) {
+		assert.Equal(t, "fluent/fluent-bit:1.7.4", sidecar.Image)
+		assert.Len(t, sidecar.VolumeMounts, 3)
+		assert.Equal(t, int32(2020), sidecar.Ports[0].ContainerPort)
+	}
+	assert.NotEmpty(t, sts.Spec.Template.Annotations[auditLogForwarderConfigHashAnnotation])
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, p := range ac.Processes {
+		assert.Equal(t, "file", p.Args26.Get("auditLog.destination").Str())
+		assert.Equal(t, "JSON", p.Args26.Get("auditLog.format").Str())
+		assert.Equal(t, defaultAuditLogPath, p.Args26.Get("auditLog.path").Str())
+	}
+
+	t.Run("Configuration is removed with the forwarder", func(t *testing.T) {
+		err := mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+		assert.NoError(t, err)
+		mdb.Spec.AuditLogForwarder = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		_, err = mgr.Client.GetConfigMap(types.NamespacedName{Name: mdb.AuditLogForwarderConfigMapName(), Namespace: mdb.Namespace})
+		assert.Error(t, err)
+	})
+}
+
+func newTestReplicaSetWithAuditLogForwarder() mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSet()
+	mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{
+		"auditLog": map[string]interface{}{
+			"destination": "file",
+		},
+	}
+	mdb.Spec.AuditLogForwarder = &mdbv1.AuditLogForwarder{
+		Syslog: &mdbv1.AuditLogSyslogDestination{
+			Host: "syslog.example.com",
+			Port: 514,
+		},
+	}
+	return mdb
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/generate"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// x509AuthMechanism is the mechanism users authenticate with using X.509 client certificates.
+const x509AuthMechanism = "MONGODB-X509"
+
+// validateAuthenticationModes checks that the requirements of the enabled authentication modes are met.
+func validateAuthenticationModes(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.IsAuthModeEnabled(mdbv1.AuthModeX509) && !mdb.Spec.Security.TLS.Enabled {
+		return errors.New(`"X509" authentication requires TLS to be enabled`)
+	}
+	return nil
+}
+
+// x509ConfigModification enables the X.509 mechanism in the automation config if the X509 mode is enabled.
+func x509ConfigModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if !mdb.IsAuthModeEnabled(mdbv1.AuthModeX509) {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		if !contains.String(config.Auth.DeploymentAuthMechanisms, x509AuthMechanism) {
+			config.Auth.DeploymentAuthMechanisms = append(config.Auth.DeploymentAuthMechanisms, x509AuthMechanism)
+		}
+	}
+}
+
+// agentAuthenticatesWithX509 returns whether the agent authenticates with the certificate of the members. It does
+// if X509 is enabled and SCRAM is not, so that the members do not accept SCRAM for the agent only.
+func agentAuthenticatesWithX509(mdb mdbv1.MongoDBCommunity) bool {
+	return mdb.IsAuthModeEnabled(mdbv1.AuthModeX509) && !mdb.IsAuthModeEnabled(mdbv1.AuthModeScram)
+}
+
+// enableX509AgentAuthentication configures the agent to authenticate with the server certificate of the members
+// using MONGODB-X509, which is the only mechanism the members accept. The members still authenticate each other
+// with the keyfile unless internal cluster authentication is enabled. The returned modification sets the path
+// of the certificate the agent authenticates with.
+func enableX509AgentAuthentication(auth *automationconfig.Auth, client secret.GetUpdateCreateDeleter, mdb mdbv1.MongoDBCommunity) (automationconfig.Modification, error) {
+	generatedContents, err := generate.KeyFileContents()
+	if err != nil {
+		return nil, errors.Errorf("could not generate keyfile contents: %s", err)
+	}
+	keyfile, err := secret.EnsureSecretWithKey(client, mdb.GetAgentKeyfileSecretNamespacedName(), mdb.GetOwnerReferences(), scram.AgentKeyfileKey, generatedContents)
+	if err != nil {
+		return nil, err
+	}
+	certKey, err := getCertAndKey(client, mdb)
+	if err != nil {
+		return nil, errors.Errorf("could not read the certificate of the agent: %s", err)
+	}
+	cert, err := firstCertificate(certKey)
+	if err != nil {
+		return nil, errors.Errorf("could not parse the certificate of the agent: %s", err)
+	}
+
+	opts := mdb.GetScramOptions()
+	auth.Disabled = false
+	auth.AuthoritativeSet = opts.AuthoritativeSet
+	auth.KeyFile = opts.KeyFile
+	auth.KeyFileWindows = scram.AutomationAgentWindowsKeyFilePath
+	auth.Key = keyfile
+	auth.AutoUser = cert.Subject.String()
+	auth.AutoAuthMechanism = x509AuthMechanism
+	auth.AutoAuthMechanisms = []string{x509AuthMechanism}
+	auth.DeploymentAuthMechanisms = []string{x509AuthMechanism}
+
+	certificateKeyPath := tlsOperatorSecretMountPath + tlsOperatorSecretFileName(certKey)
+	return func(config *automationconfig.AutomationConfig) {
+		if config.TLSConfig != nil {
+			config.TLSConfig.AutoPEMKeyFilePath = certificateKeyPath
+		}
+	}, nil
+}
+
+// startAuthenticationMigration moves the resource to the EnablingNewModes phase if the authentication modes
+// have been changed in a way that both enables and disables modes. Applying such a change in a single
+// automation config would leave clients unable to authenticate with members which have not been
+// reconfigured yet, so all modes are enabled before the previous ones are disabled.
+func (r *ReplicaSetReconciler) startAuthenticationMigration(mdb *mdbv1.MongoDBCommunity) error {
+	desired := mdb.Spec.Security.Authentication.Modes
+
+	var current []mdbv1.AuthMode
+	if migration := mdb.Status.AuthenticationMigration; mdb.IsMigratingAuthentication() {
+		if sameAuthModes(migration.To, desired) {
+			return nil
+		}
+		// the modes have been changed again before the migration has completed.
+		current = migration.To
+		if migration.Phase == mdbv1.AuthenticationMigrationEnablingNewModes {
+			current = unionAuthModes(migration.From, migration.To)
+		}
+	} else {
+		prevSpec, err := lastSuccessfulSpec(*mdb)
+		if err != nil || prevSpec == nil {
+			return err
+		}
+		current = prevSpec.Security.Authentication.Modes
+		if last := mdb.Status.AuthenticationMigration; last != nil && sameAuthModes(last.To, desired) {
+			// the migration has completed, but the resource has not been reconciled successfully since.
+			return nil
+		}
+	}
+
+	migration := mdbv1.AuthenticationMigrationStatus{From: current, To: desired, Phase: mdbv1.AuthenticationMigrationEnablingNewModes}
+	if !requiresAuthenticationMigration(current, desired) {
+		if !mdb.IsMigratingAuthentication() {
+			return nil
+		}
+		// the new modes can be applied directly, the previous migration is superseded.
+		migration.Phase = mdbv1.AuthenticationMigrationCompleted
+	}
+
+	r.log.Infof("Migrating authentication modes from %v to %v, phase=%s", migration.From, migration.To, migration.Phase)
+	return r.updateAuthenticationMigrationStatus(mdb, migration)
+}
+
+// advanceAuthenticationMigration moves an in progress migration to its next phase. It must only be called
+// once all agents have reached goal state with the automation config of the current phase.
+// The returned boolean is true if the migration requires more automation config changes.
+func (r *ReplicaSetReconciler) advanceAuthenticationMigration(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.IsMigratingAuthentication() {
+		return false, nil
+	}
+
+	migration := *mdb.Status.AuthenticationMigration
+	switch migration.Phase {
+	case mdbv1.AuthenticationMigrationEnablingNewModes:
+		migration.Phase = mdbv1.AuthenticationMigrationDisablingOldModes
+		r.log.Infof("Authentication modes %v have been enabled on all members, disabling the previous modes", migration.To)
+		return true, r.updateAuthenticationMigrationStatus(mdb, migration)
+	case mdbv1.AuthenticationMigrationDisablingOldModes:
+		migration.Phase = mdbv1.AuthenticationMigrationCompleted
+		r.log.Infof("Authentication modes have been migrated from %v to %v", migration.From, migration.To)
+		return false, r.updateAuthenticationMigrationStatus(mdb, migration)
+	}
+	return false, nil
+}
+
+func (r *ReplicaSetReconciler) updateAuthenticationMigrationStatus(mdb *mdbv1.MongoDBCommunity, migration mdbv1.AuthenticationMigrationStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withAuthenticationMigration(migration))
+	return err
+}
+
+// requiresAuthenticationMigration returns true if changing the modes from the current to the desired ones
+// both enables and disables modes.
+func requiresAuthenticationMigration(current, desired []mdbv1.AuthMode) bool {
+	return len(subtractAuthModes(desired, current)) > 0 && len(subtractAuthModes(current, desired)) > 0
+}
+
+func sameAuthModes(a, b []mdbv1.AuthMode) bool {
+	return len(subtractAuthModes(a, b)) == 0 && len(subtractAuthModes(b, a)) == 0
+}
+
+// subtractAuthModes returns the modes in a which are not in b.
+func subtractAuthModes(a, b []mdbv1.AuthMode) []mdbv1.AuthMode {
+	var result []mdbv1.AuthMode
+	for _, mode := range a {
+		if !containsAuthMode(b, mode) {
+			result = append(result, mode)
+		}
+	}
+	return result
+}
+
+func unionAuthModes(a, b []mdbv1.AuthMode) []mdbv1.AuthMode {
+	return append(append([]mdbv1.AuthMode{}, a...), subtractAuthModes(b, a)...)
+}
+
+func containsAuthMode(modes []mdbv1.AuthMode, mode mdbv1.AuthMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRequiresAuthenticationMigration(t *testing.T) {
+	scram, ldap, x509 := mdbv1.AuthModeScram, mdbv1.AuthModeLDAP, mdbv1.AuthModeX509
+
+	assert.False(t, requiresAuthenticationMigration([]mdbv1.AuthMode{scram}, []mdbv1.AuthMode{scram}))
+	assert.False(t, requiresAuthenticationMigration([]mdbv1.AuthMode{scram}, []mdbv1.AuthMode{scram, x509}), "modes can be enabled directly")
+	assert.False(t, requiresAuthenticationMigration([]mdbv1.AuthMode{scram, x509}, []mdbv1.AuthMode{x509}), "modes can be disabled directly")
+	assert.True(t, requiresAuthenticationMigration([]mdbv1.AuthMode{scram}, []mdbv1.AuthMode{x509}))
+	assert.True(t, requiresAuthenticationMigration([]mdbv1.AuthMode{scram, ldap}, []mdbv1.AuthMode{x509, scram}))
+}
+
+func TestValidateAuthenticationModes(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.Authentication.Modes = []mdbv1.AuthMode{mdbv1.AuthModeX509}
+	assert.Error(t, validateAuthenticationModes(mdb))
+
+	mdb.Spec.Security.TLS.Enabled = true
+	assert.NoError(t, validateAuthenticationModes(mdb))
+}
+
+func TestAuthenticationModes_AreMigrated(t *testing.T) {
+	mdb := newTestReplicaSetWithLDAP()
+	mdb.Spec.Security.TLS = newTestReplicaSetWithTLS().Spec.Security.TLS
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createLDAPSecretAndConfigMap(mgr.GetClient(), mdb))
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	acNsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SCRAM-SHA-256", "PLAIN"}, ac.Auth.DeploymentAuthMechanisms)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.Authentication.Modes = []mdbv1.AuthMode{mdbv1.AuthModeScram, mdbv1.AuthModeX509}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	t.Run("All modes are enabled first", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"SCRAM-SHA-256", "PLAIN", "MONGODB-X509"}, ac.Auth.DeploymentAuthMechanisms)
+		assert.NotNil(t, ac.Ldap)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+		assert.Equal(t, &mdbv1.AuthenticationMigrationStatus{
+			From:  []mdbv1.AuthMode{mdbv1.AuthModeScram, mdbv1.AuthModeLDAP},
+			To:    []mdbv1.AuthMode{mdbv1.AuthModeScram, mdbv1.AuthModeX509},
+			Phase: mdbv1.AuthenticationMigrationDisablingOldModes,
+		}, mdb.Status.AuthenticationMigration)
+	})
+
+	t.Run("Previous modes are disabled once all members accept the new ones", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"SCRAM-SHA-256", "MONGODB-X509"}, ac.Auth.DeploymentAuthMechanisms)
+		assert.Nil(t, ac.Ldap)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+		assert.Equal(t, mdbv1.AuthenticationMigrationCompleted, mdb.Status.AuthenticationMigration.Phase)
+	})
+}
+
+func TestAuthenticationModes_AreEnabledDirectly(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Spec.Security.Authentication.Modes = []mdbv1.AuthMode{mdbv1.AuthModeScram}
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.Authentication.Modes = append(mdb.Spec.Security.Authentication.Modes, mdbv1.AuthModeX509)
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Contains(t, ac.Auth.DeploymentAuthMechanisms, "MONGODB-X509")
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Nil(t, mdb.Status.AuthenticationMigration)
+}
+
+func TestAuthenticationModes_X509OnlyDoesNotAcceptScram(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Spec.Security.Authentication.Modes = []mdbv1.AuthMode{mdbv1.AuthModeX509}
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	setTLSCertificate(t, mgr.GetClient(), mdb, newTestCA(t, "ca").issue(t))
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MONGODB-X509"}, ac.Auth.DeploymentAuthMechanisms)
+	assert.Equal(t, []string{"MONGODB-X509"}, ac.Auth.AutoAuthMechanisms)
+	assert.Equal(t, "MONGODB-X509", ac.Auth.AutoAuthMechanism)
+	assert.Equal(t, "CN=my-rs-svc.my-ns.svc.cluster.local", ac.Auth.AutoUser)
+	assert.Empty(t, ac.Auth.AutoPwd)
+	assert.Empty(t, ac.Auth.Users, "SCRAM users are not created")
+	assert.NotEmpty(t, ac.Auth.Key)
+	assert.Equal(t, ac.Processes[0].Args26.Get("net.tls.certificateKeyFile").Data(), ac.TLSConfig.AutoPEMKeyFilePath)
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	tlsCABundleVolumeName = "tls-ca-bundle"
+	tlsCABundleMountPath  = "/var/lib/tls/ca-bundle/"
+)
+
+// tlsCAFilePath returns the path of the CA certificates the members trust, which contains both the previous
+// and the new CA while the members are moved to a new CA.
+func tlsCAFilePath(mdb mdbv1.MongoDBCommunity) string {
+	if mdb.IsServingCABundle() {
+		return tlsCABundleMountPath + tlsCACertName
+	}
+	return tlsCAMountPath + tlsCACertName
+}
+
+// startCARotation starts a CA rotation if the CA ConfigMap or Secret has been changed since the last successful
+// reconciliation and contains a different CA. The previous and the new CA are stored in the CA bundle
+// ConfigMap, which the members trust until advanceCARotation removes the previous CA.
+func (r *ReplicaSetReconciler) startCARotation(mdb *mdbv1.MongoDBCommunity) error {
+	if !mdb.Spec.Security.TLS.Enabled {
+		return nil
+	}
+	desired := caReference(mdb.Spec.Security.TLS)
+	if mdb.IsRotatingCA() {
+		if rotation := mdb.Status.CARotation; rotation.To != desired {
+			return errors.Errorf("the CA can not be changed to %s while the CA is rotated from %s to %s", desired, rotation.From, rotation.To)
+		}
+		return nil
+	}
+
+	prevSpec, err := lastSuccessfulSpec(*mdb)
+	if err != nil || prevSpec == nil || !prevSpec.Security.TLS.Enabled {
+		return err
+	}
+	previous := caReference(prevSpec.Security.TLS)
+	if previous == desired {
+		return nil
+	}
+	if last := mdb.Status.CARotation; last != nil && last.To == desired {
+		// the rotation has completed, but the resource has not been reconciled successfully since.
+		return nil
+	}
+
+	previousCA, err := readCA(r.client, prevSpec.Security.TLS, mdb.Namespace)
+	if err != nil {
+		return errors.Errorf("could not read the previous CA from %s, which must exist until the rotation has started: %s", previous, err)
+	}
+	newCA, err := readCA(r.client, mdb.Spec.Security.TLS, mdb.Namespace)
+	if err != nil {
+		return errors.Errorf("could not read the new CA: %s", err)
+	}
+	if strings.TrimSpace(previousCA) == strings.TrimSpace(newCA) {
+		return nil
+	}
+
+	bundle := configmap.Builder().
+		SetName(mdb.CABundleConfigMapNamespacedName().Name).
+		SetNamespace(mdb.Namespace).
+		SetField(tlsCACertName, combineCertificates(previousCA, newCA)).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	if err := configmap.CreateOrUpdate(r.client, bundle); err != nil {
+		return errors.Errorf("could not create the CA bundle: %s", err)
+	}
+
+	r.log.Infof("Rotating the CA from %s to %s, the members trust both CAs until their certificates are issued by the new CA", previous, desired)
+	return r.updateCARotationStatus(mdb, mdbv1.CARotationStatus{From: previous, To: desired, Phase: mdbv1.CARotationTrustingBoth})
+}
+
+// advanceCARotation moves an in progress CA rotation to its next phase. It must only be called once all members
+// have been restarted with the CA of the current phase. The previous CA is only removed once the certificates of
+// the members are issued by the new CA. The returned boolean is true if the rotation is not complete yet.
+func (r *ReplicaSetReconciler) advanceCARotation(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.IsRotatingCA() {
+		return false, nil
+	}
+
+	rotation := *mdb.Status.CARotation
+	switch rotation.Phase {
+	case mdbv1.CARotationTrustingBoth:
+		if err := r.verifyCertificatesIssuedByCA(*mdb); err != nil {
+			r.log.Infof("All members trust the new CA, waiting for their certificates to be issued by it: %s", err)
+			return true, nil
+		}
+		rotation.Phase = mdbv1.CARotationRemovingOldCA
+		r.log.Infof("All members trust the new CA and use certificates issued by it, removing the previous CA %s", rotation.From)
+		return true, r.updateCARotationStatus(mdb, rotation)
+	case mdbv1.CARotationRemovingOldCA:
+		if err := r.client.DeleteConfigMap(mdb.CABundleConfigMapNamespacedName()); err != nil && !apiErrors.IsNotFound(err) {
+			return false, err
+		}
+		rotation.Phase = mdbv1.CARotationCompleted
+		r.log.Infof("The CA has been rotated from %s to %s", rotation.From, rotation.To)
+		return false, r.updateCARotationStatus(mdb, rotation)
+	}
+	return false, nil
+}
+
+func (r *ReplicaSetReconciler) updateCARotationStatus(mdb *mdbv1.MongoDBCommunity, rotation mdbv1.CARotationStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withCARotation(rotation))
+	return err
+}
+
+// verifyCertificatesIssuedByCA returns an error if the server certificate, or the member certificate if the
+// members authenticate with it, is not issued by the configured CA.
+func (r ReplicaSetReconciler) verifyCertificatesIssuedByCA(mdb mdbv1.MongoDBCommunity) error {
+	ca, err := readCA(r.client, mdb.Spec.Security.TLS, mdb.Namespace)
+	if err != nil {
+		return err
+	}
+	secrets := map[string]types.NamespacedName{serverCertificate: mdb.TLSSecretNamespacedName()}
+	if mdb.Spec.Security.TLS.InternalClusterAuth {
+		secrets[memberCertificate] = mdb.TLSMemberSecretNamespacedName()
+	}
+
+	for _, certificate := range []string{serverCertificate, memberCertificate} {
+		nsName, ok := secrets[certificate]
+		if !ok {
+			continue
+		}
+		cert, err := readCertificate(r.client, nsName, mdb.Spec.Security.TLS.CertificatePEMKey)
+		if err != nil {
+			return err
+		}
+		if err := verifyCertificateIssuedBy(cert, ca); err != nil {
+			return errors.Errorf("the %s certificate is not issued by the new CA: %s", certificate, err)
+		}
+	}
+	return nil
+}
+
+// verifyCertificateIssuedBy verifies the first certificate in the given PEM data against the given CA. Any
+// other certificates in the PEM data are used as intermediate certificates.
+func verifyCertificateIssuedBy(certPEM, caPEM string) error {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(caPEM)) {
+		return errors.New("the CA does not contain a valid certificate")
+	}
+
+	var chain []*x509.Certificate
+	rest := []byte(certPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return errors.New("no certificate found")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+func combineCertificates(certs ...string) string {
+	trimmed := make([]string, len(certs))
+	for i, cert := range certs {
+		trimmed[i] = strings.TrimRight(cert, "\n")
+	}
+	return fmt.Sprintf("%s\n", strings.Join(trimmed, "\n"))
+}
+
+// buildCABundlePodSpecModification mounts the CA bundle while the members trust both the previous and the new CA,
+// and removes it afterwards, as the bundle is deleted once the rotation has completed.
+func buildCABundlePodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	if !mdb.Spec.Security.TLS.Enabled || !mdb.IsServingCABundle() {
+		return podtemplatespec.RemoveVolume(tlsCABundleVolumeName)
+	}
+
+	bundleVolume := statefulset.CreateVolumeFromConfigMap(tlsCABundleVolumeName, mdb.CABundleConfigMapNamespacedName().Name)
+	bundleVolumeMount := statefulset.CreateVolumeMount(bundleVolume.Name, tlsCABundleMountPath, statefulset.WithReadOnly(true))
+	return podtemplatespec.Apply(
+		podtemplatespec.WithVolume(bundleVolume),
+		podtemplatespec.WithVolumeMounts(construct.AgentName, bundleVolumeMount),
+		podtemplatespec.WithVolumeMounts(construct.MongodbName, bundleVolumeMount),
+	)
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// testCA is a CA which issues certificates in tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return testCA{cert: cert, key: key, pem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+// issue returns a PEM encoded certificate issued by the CA.
+func (ca testCA) issue(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "my-rs-svc.my-ns.svc.cluster.local"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func createCAConfigMap(t *testing.T, c client.Client, nsName types.NamespacedName, ca string) {
+	cm := configmap.Builder().
+		SetName(nsName.Name).
+		SetNamespace(nsName.Namespace).
+		SetField(tlsCACertName, ca).
+		Build()
+	assert.NoError(t, configmap.CreateOrUpdate(c, cm))
+}
+
+func TestVerifyCertificateIssuedBy(t *testing.T) {
+	oldCA, newCA := newTestCA(t, "old-ca"), newTestCA(t, "new-ca")
+	cert := newCA.issue(t)
+
+	assert.NoError(t, verifyCertificateIssuedBy(cert, newCA.pem))
+	assert.Error(t, verifyCertificateIssuedBy(cert, oldCA.pem))
+	assert.NoError(t, verifyCertificateIssuedBy(cert, combineCertificates(oldCA.pem, newCA.pem)))
+	assert.Error(t, verifyCertificateIssuedBy("CERT", newCA.pem))
+	assert.Error(t, verifyCertificateIssuedBy(cert, "CERT"))
+}
+
+func TestCARotation_TrustsBothCAsUntilCertificatesAreRenewed(t *testing.T) {
+	oldCA, newCA := newTestCA(t, "old-ca"), newTestCA(t, "new-ca")
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	createCAConfigMap(t, mgr.Client, mdb.TLSConfigMapNamespacedName(), oldCA.pem)
+	setTLSCertificate(t, mgr.GetClient(), mdb, oldCA.issue(t))
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	acNsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+
+	newCANsName := types.NamespacedName{Name: "new-ca", Namespace: mdb.Namespace}
+	createCAConfigMap(t, mgr.Client, newCANsName, newCA.pem)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.TLS.CaConfigMap.Name = newCANsName.Name
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	// assertCAFile checks the CA file the members are configured with, and the phase of the rotation.
+	assertCAFile := func(t *testing.T, caFile string, phase mdbv1.CARotationPhase) {
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+		assert.NoError(t, err)
+		assert.Equal(t, caFile, ac.TLSConfig.CAFilePath)
+		for _, process := range ac.Processes {
+			assert.Equal(t, caFile, process.Args26.Get("net.tls.CAFile").Str())
+		}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		if assert.NotNil(t, mdb.Status.CARotation) {
+			assert.Equal(t, phase, mdb.Status.CARotation.Phase)
+		}
+	}
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assertCAFile(t, "/var/lib/tls/ca-bundle/ca.crt", mdbv1.CARotationTrustingBoth)
+	assert.Equal(t, "caConfigMap", mdb.Status.CARotation.From)
+
+	bundle, err := configmap.ReadKey(mgr.Client, tlsCACertName, mdb.CABundleConfigMapNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, combineCertificates(oldCA.pem, newCA.pem), bundle)
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.True(t, hasConfigMapVolume(sts.Spec.Template.Spec.Volumes, mdb.CABundleConfigMapNamespacedName().Name))
+
+	t.Run("The previous CA is kept until the certificate is issued by the new CA", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertCAFile(t, "/var/lib/tls/ca-bundle/ca.crt", mdbv1.CARotationTrustingBoth)
+	})
+
+	t.Run("The CA can not be changed during the rotation", func(t *testing.T) {
+		createCAConfigMap(t, mgr.Client, types.NamespacedName{Name: "other-ca", Namespace: mdb.Namespace}, newTestCA(t, "other-ca").pem)
+		mdb.Spec.Security.TLS.CaConfigMap.Name = "other-ca"
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+
+		mdb.Spec.Security.TLS.CaConfigMap.Name = newCANsName.Name
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	})
+
+	setTLSCertificate(t, mgr.GetClient(), mdb, newCA.issue(t))
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assertCAFile(t, "/var/lib/tls/ca-bundle/ca.crt", mdbv1.CARotationRemovingOldCA)
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assertCAFile(t, "/var/lib/tls/ca/ca.crt", mdbv1.CARotationCompleted)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+
+	sts, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.False(t, hasConfigMapVolume(sts.Spec.Template.Spec.Volumes, mdb.CABundleConfigMapNamespacedName().Name))
+	_, err = mgr.Client.GetConfigMap(mdb.CABundleConfigMapNamespacedName())
+	assert.True(t, apiErrors.IsNotFound(err))
+
+	t.Run("Completed rotations are not started again", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+		assertCAFile(t, "/var/lib/tls/ca/ca.crt", mdbv1.CARotationCompleted)
+	})
+}
+
+func hasConfigMapVolume(volumes []corev1.Volume, name string) bool {
+	for _, volume := range volumes {
+		if volume.ConfigMap != nil && volume.ConfigMap.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/agent"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// reconcileCanary starts a canary update when a change would restart the members, and advances the canary
+// update in progress.
+func (r *ReplicaSetReconciler) reconcileCanary(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	if err := r.startCanary(mdb, now); err != nil {
+		return err
+	}
+	return r.advanceCanary(mdb, now)
+}
+
+// startCanary starts a canary update when the MongoDB version or the Pod template of the StatefulSet changes
+// and canary updates are enabled. The member with the highest ordinal is the canary: the StatefulSet controller
+// restarts it first, and it is the last member of the automation config, which is never the only member the
+// others can sync from. It must be called before the StatefulSet and the automation config are updated.
+func (r *ReplicaSetReconciler) startCanary(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	members := mdb.StatefulSetReplicasThisReconciliation()
+	if mdb.Spec.UpdateStrategy.GetCanary() == nil || members < 2 || mdb.IsScalingVertically() {
+		return nil
+	}
+
+	canary := &mdbv1.CanaryStatus{
+		Member:    mdb.PodName(members - 1),
+		Phase:     mdbv1.CanaryUpdating,
+		StartTime: metav1.NewTime(now),
+	}
+	if mdb.IsChangingVersion() {
+		if current := mdb.Status.Canary; current != nil && current.Change == mdbv1.CanaryChangeVersion && current.Version == mdb.Spec.Version {
+			return nil
+		}
+		canary.Change = mdbv1.CanaryChangeVersion
+		canary.Version = mdb.Spec.Version
+		canary.PreviousVersion = mdb.GetPreviousVersion()
+	} else {
+		changed, err := r.statefulSetTemplateChanged(*mdb)
+		if err != nil || !changed {
+			return err
+		}
+		canary.Change = mdbv1.CanaryChangeStatefulSet
+	}
+	canary.Message = fmt.Sprintf("Waiting for the canary member %s to be updated and ready", canary.Member)
+
+	r.log.Infof("Rolling out the change of the %s to the canary member %s first", canaryChangeDescription(*canary), canary.Member)
+	return r.updateCanaryStatus(mdb, canary)
+}
+
+// statefulSetTemplateChanged returns true if the Pod template of the existing StatefulSet differs from the
+// template it is updated with, which restarts the members.
+func (r *ReplicaSetReconciler) statefulSetTemplateChanged(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	existing, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	desired := *existing.DeepCopy()
+	if err := r.applyStatefulSetModifications(mdb, &desired, true); err != nil {
+		return false, err
+	}
+	return !equality.Semantic.DeepEqual(existing.Spec.Template, desired.Spec.Template), nil
+}
+
+// advanceCanary moves the canary update in progress to the soak period once the canary member runs the change
+// and is ready, and completes it once the member stayed ready without restarting for the soak period. The
+// update fails if the member becomes unready or restarts during the soak period.
+func (r *ReplicaSetReconciler) advanceCanary(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	if !canaryHolds(*mdb) || mdb.Status.Canary.Phase == mdbv1.CanaryFailed {
+		return nil
+	}
+	canary := mdb.Status.Canary.DeepCopy()
+
+	pod, err := r.client.GetPod(types.NamespacedName{Name: canary.Member, Namespace: mdb.Namespace})
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	updated := false
+	if err == nil {
+		if updated, err = r.canaryMemberUpdated(*mdb, *canary, pod); err != nil {
+			return err
+		}
+	}
+	ready := updated && pod.DeletionTimestamp == nil && podReady(pod)
+	restarts := containerRestarts(pod)
+	soakPeriod := mdb.Spec.UpdateStrategy.GetCanary().GetSoakPeriod()
+
+	switch canary.Phase {
+	case mdbv1.CanaryUpdating:
+		if !ready {
+			return nil
+		}
+		readySince := metav1.NewTime(now)
+		canary.Phase = mdbv1.CanarySoaking
+		canary.ReadySince = &readySince
+		canary.Restarts = restarts
+		canary.Message = fmt.Sprintf("The canary member %s is soaking until %s", canary.Member, now.Add(soakPeriod).UTC().Format(time.RFC3339))
+		r.log.Infof("The canary member %s runs the change of the %s, soaking for %s", canary.Member, canaryChangeDescription(*canary), soakPeriod)
+	case mdbv1.CanarySoaking:
+		switch {
+		case !ready:
+			canary.Phase = mdbv1.CanaryFailed
+			canary.Message = fmt.Sprintf("The canary member %s became unready during the soak period", canary.Member)
+		case restarts > canary.Restarts:
+			canary.Phase = mdbv1.CanaryFailed
+			canary.Message = fmt.Sprintf("The canary member %s restarted during the soak period", canary.Member)
+		case now.Before(canary.ReadySince.Add(soakPeriod)):
+			return nil
+		default:
+			completionTime := metav1.NewTime(now)
+			canary.Phase = mdbv1.CanaryCompleted
+			canary.CompletionTime = &completionTime
+			canary.Message = fmt.Sprintf("The canary member %s was healthy for %s, rolling out the change to the other members", canary.Member, soakPeriod)
+			r.log.Info(canary.Message)
+		}
+		if canary.Phase == mdbv1.CanaryFailed {
+			r.log.Warnf("%s, the change of the %s is not rolled out to the other members", canary.Message, canaryChangeDescription(*canary))
+			if r.recorder != nil {
+				r.recorder.Eventf(mdb, corev1.EventTypeWarning, "CanaryFailed", "%s", canary.Message)
+			}
+		}
+	}
+	return r.updateCanaryStatus(mdb, canary)
+}
+
+// canaryMemberUpdated returns true if the canary member runs the change: the revision of the StatefulSet being
+// rolled out, or the MongoDB version of the canary update, whose automation config its agent has reached.
+func (r *ReplicaSetReconciler) canaryMemberUpdated(mdb mdbv1.MongoDBCommunity, canary mdbv1.CanaryStatus, pod corev1.Pod) (bool, error) {
+	if canary.Change == mdbv1.CanaryChangeStatefulSet {
+		sts, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+		if err != nil {
+			return false, err
+		}
+		if sts.Generation != sts.Status.ObservedGeneration || sts.Status.UpdateRevision == "" {
+			return false, nil
+		}
+		return pod.Labels[appsv1.StatefulSetRevisionLabel] == sts.Status.UpdateRevision, nil
+	}
+
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		return false, err
+	}
+	for i, process := range ac.Processes {
+		if mdb.PodName(i) == canary.Member {
+			return process.Version == canary.Version && agent.ReachedGoalState(pod, ac.Version, r.log), nil
+		}
+	}
+	return false, nil
+}
+
+// containerRestarts returns the number of restarts of the containers of the Pod.
+func containerRestarts(pod corev1.Pod) int32 {
+	var restarts int32
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		restarts += containerStatus.RestartCount
+	}
+	return restarts
+}
+
+func (r *ReplicaSetReconciler) updateCanaryStatus(mdb *mdbv1.MongoDBCommunity, canary *mdbv1.CanaryStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withCanary(canary))
+	return err
+}
+
+// canaryHolds returns true while the change of a canary update is not rolled out to the members other than the
+// canary member. Disabling canary updates or reverting the version releases the hold.
+func canaryHolds(mdb mdbv1.MongoDBCommunity) bool {
+	canary := mdb.Status.Canary
+	if mdb.Spec.UpdateStrategy.GetCanary() == nil || canary == nil || canary.Phase == mdbv1.CanaryCompleted {
+		return false
+	}
+	if canary.Change == mdbv1.CanaryChangeVersion {
+		return mdb.IsChangingVersion() && canary.Version == mdb.Spec.Version
+	}
+	return true
+}
+
+// canaryPartition returns the partition of the rolling update of the StatefulSet which only restarts the canary
+// member while a canary update of the StatefulSet holds, and 0 otherwise.
+func canaryPartition(mdb mdbv1.MongoDBCommunity) int32 {
+	if !canaryHolds(mdb) || mdb.Status.Canary.Change != mdbv1.CanaryChangeStatefulSet {
+		return 0
+	}
+	return int32(mdb.StatefulSetReplicasThisReconciliation() - 1)
+}
+
+// canaryPartitionModification partitions the rolling update of the StatefulSet so that only the canary member
+// is restarted while a canary update of the StatefulSet holds, unless the rolling update is paused altogether.
+// The partition is removed once the canary update has completed, unless restarts are gated.
+func (r *ReplicaSetReconciler) canaryPartitionModification(mdb mdbv1.MongoDBCommunity) statefulset.Modification {
+	partition := canaryPartition(mdb)
+	gated := r.restartsGated(mdb)
+	return func(sts *appsv1.StatefulSet) {
+		if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			return
+		}
+		current := int32(0)
+		if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+			current = *rollingUpdate.Partition
+		}
+		if partition > current || (!gated && partition < current) {
+			sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+		}
+	}
+}
+
+// canaryVersionModification keeps the previous MongoDB version on the members other than the canary member while
+// a canary update of the version holds.
+func canaryVersionModification(mdb mdbv1.MongoDBCommunity, currentAC automationconfig.AutomationConfig) automationconfig.Modification {
+	canary := mdb.Status.Canary
+	if !canaryHolds(mdb) || canary.Change != mdbv1.CanaryChangeVersion || canary.PreviousVersion == "" {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			if mdb.PodName(i) != canary.Member {
+				config.Processes[i].Version = canary.PreviousVersion
+			}
+		}
+		for _, version := range currentAC.Versions {
+			if version.Name == canary.PreviousVersion && !automationConfigHasVersion(*config, version.Name) {
+				config.Versions = append(config.Versions, version)
+			}
+		}
+	}
+}
+
+func automationConfigHasVersion(config automationconfig.AutomationConfig, name string) bool {
+	for _, version := range config.Versions {
+		if version.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// canaryChangeDescription describes the change of a canary update, e.g. "MongoDB version to 4.4.8".
+func canaryChangeDescription(canary mdbv1.CanaryStatus) string {
+	if canary.Change == mdbv1.CanaryChangeVersion {
+		return fmt.Sprintf("MongoDB version to %s", canary.Version)
+	}
+	return "StatefulSet"
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func newTestReplicaSetWithCanaryUpdates() mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSet()
+	mdb.Spec.UpdateStrategy = &mdbv1.UpdateStrategy{Canary: &mdbv1.CanaryUpdate{}}
+	return mdb
+}
+
+// setCanaryPodStatus simulates the canary member running the given revision of the StatefulSet, being ready and
+// having restarted the given number of times.
+func setCanaryPodStatus(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, revision string, restarts int32) {
+	pod := corev1.Pod{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(mdb.Spec.Members - 1), Namespace: mdb.Namespace}, &pod))
+	pod.Labels = map[string]string{appsv1.StatefulSetRevisionLabel: revision}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "mongod", RestartCount: restarts}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+}
+
+func assertStatefulSetNotPartitioned(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) {
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		assert.Equal(t, int32(0), *rollingUpdate.Partition)
+	}
+}
+
+func processVersions(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) []string {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	var versions []string
+	for _, process := range ac.Processes {
+		versions = append(versions, process.Version)
+	}
+	return versions
+}
+
+func TestCanary_StatefulSetChangeIsRolledOutToTheCanaryMemberFirst(t *testing.T) {
+	mdb := newTestReplicaSetWithCanaryUpdates()
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Nil(t, mdb.Status.Canary)
+	mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec.Template.Annotations = map[string]string{"example.com/restarted-at": "now"}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	if assert.NotNil(t, mdb.Status.Canary) {
+		assert.Equal(t, mdbv1.CanaryChangeStatefulSet, mdb.Status.Canary.Change)
+		assert.Equal(t, mdb.PodName(2), mdb.Status.Canary.Member)
+		assert.Equal(t, mdbv1.CanaryUpdating, mdb.Status.Canary.Phase)
+	}
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assertStatefulSetPartition(t, mgr.GetClient(), mdb, 2)
+
+	t.Run("The soak period starts once the canary member runs the new revision", func(t *testing.T) {
+		setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+		setCanaryPodStatus(t, mgr, mdb, "rev-2", 0)
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.CanarySoaking, mdb.Status.Canary.Phase)
+		assert.NotNil(t, mdb.Status.Canary.ReadySince)
+		assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+		assertStatefulSetPartition(t, mgr.GetClient(), mdb, 2)
+	})
+
+	t.Run("The change is rolled out to the other members after the soak period", func(t *testing.T) {
+		assert.NoError(t, r.advanceCanary(&mdb, time.Now().Add(11*time.Minute)))
+		assert.Equal(t, mdbv1.CanaryCompleted, mdb.Status.Canary.Phase)
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		assertStatefulSetNotPartitioned(t, mgr, mdb)
+	})
+}
+
+func TestCanary_VersionChangeIsRolledOutToTheCanaryMemberFirst(t *testing.T) {
+	mdb := newTestReplicaSetWithCanaryUpdates()
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Version = "4.4.8"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	if assert.NotNil(t, mdb.Status.Canary) {
+		assert.Equal(t, mdbv1.CanaryChangeVersion, mdb.Status.Canary.Change)
+		assert.Equal(t, "4.4.8", mdb.Status.Canary.Version)
+		assert.Equal(t, "4.2.2", mdb.Status.Canary.PreviousVersion)
+	}
+	assert.Equal(t, []string{"4.2.2", "4.2.2", "4.4.8"}, processVersions(t, mgr, mdb))
+
+	t.Run("The soak period starts once the agent of the canary member reached the goal state", func(t *testing.T) {
+		setCanaryPodStatus(t, mgr, mdb, "", 1)
+		setAgentGoalState(t, mgr, mdb, 2)
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.CanarySoaking, mdb.Status.Canary.Phase)
+		assert.Equal(t, int32(1), mdb.Status.Canary.Restarts)
+		assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+		assert.Equal(t, []string{"4.2.2", "4.2.2", "4.4.8"}, processVersions(t, mgr, mdb))
+	})
+
+	t.Run("The new version is rolled out to the other members after the soak period", func(t *testing.T) {
+		assert.NoError(t, r.advanceCanary(&mdb, time.Now().Add(11*time.Minute)))
+		assert.Equal(t, mdbv1.CanaryCompleted, mdb.Status.Canary.Phase)
+		assert.NotNil(t, mdb.Status.Canary.CompletionTime)
+
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+		assert.Equal(t, []string{"4.4.8", "4.4.8", "4.4.8"}, processVersions(t, mgr, mdb))
+	})
+}
+
+func TestCanary_FailsIfTheCanaryMemberRestarts(t *testing.T) {
+	mdb := newTestReplicaSetWithCanaryUpdates()
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec.Template.Annotations = map[string]string{"example.com/restarted-at": "now"}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+	setCanaryPodStatus(t, mgr, mdb, "rev-2", 0)
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	setCanaryPodStatus(t, mgr, mdb, "rev-2", 1)
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.CanaryFailed, mdb.Status.Canary.Phase)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "restarted during the soak period")
+	assertStatefulSetPartition(t, mgr.GetClient(), mdb, 2)
+
+	t.Run("Disabling canary updates rolls the change out", func(t *testing.T) {
+		mdb.Spec.UpdateStrategy = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		assertStatefulSetNotPartitioned(t, mgr, mdb)
+	})
+}
+
+func TestCanaryHolds_ReleasedWhenTheVersionIsReverted(t *testing.T) {
+	mdb := newTestReplicaSetWithCanaryUpdates()
+	mdb.Annotations = map[string]string{}
+	mdb.Status.Canary = &mdbv1.CanaryStatus{
+		Change:    mdbv1.CanaryChangeVersion,
+		Version:   "4.4.8",
+		Member:    mdb.PodName(2),
+		Phase:     mdbv1.CanaryFailed,
+		StartTime: metav1.Now(),
+	}
+	assert.False(t, canaryHolds(mdb))
+	assert.Equal(t, int32(0), canaryPartition(mdb))
+
+	mdb.Status.Canary.Change = mdbv1.CanaryChangeStatefulSet
+	assert.True(t, canaryHolds(mdb))
+	assert.Equal(t, int32(2), canaryPartition(mdb))
+}
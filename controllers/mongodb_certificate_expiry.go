@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// CertificateExpiryWarningEnv is how long before a certificate expires the CertificateExpiringSoon condition
+	// is reported, as a Go duration such as "720h". Defaults to 30 days.
+	CertificateExpiryWarningEnv = "CERTIFICATE_EXPIRY_WARNING"
+
+	defaultCertificateExpiryWarning = 30 * 24 * time.Hour
+
+	certificateExpiringSoonReason = "ExpiringSoon"
+
+	serverCertificate = "server"
+	caCertificate     = "ca"
+	memberCertificate = "member"
+)
+
+// certificateExpiryTimestamp exposes the expiry times of the certificates used by the members.
+var certificateExpiryTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "mongodbcommunity_certificate_expiry_timestamp_seconds",
+	Help: "The time at which a certificate used by the members of a MongoDBCommunity resource expires, in seconds since the epoch.",
+}, []string{metricLabelNamespace, metricLabelName, "certificate"})
+
+func init() {
+	metrics.Registry.MustRegister(certificateExpiryTimestamp)
+}
+
+func certificateExpiryWarningFromEnv() (time.Duration, error) {
+	value := os.Getenv(CertificateExpiryWarningEnv)
+	if value == "" {
+		return defaultCertificateExpiryWarning, nil
+	}
+	warning, err := time.ParseDuration(value)
+	if err != nil || warning < 0 {
+		return 0, errors.Errorf("%s must be a non-negative duration, got %q", CertificateExpiryWarningEnv, value)
+	}
+	return warning, nil
+}
+
+// observeCertificateExpiry parses the certificates used by the members, records their expiry times in the
+// certificate expiry metric and returns them. It returns nil if TLS is disabled.
+func (r ReplicaSetReconciler) observeCertificateExpiry(mdb mdbv1.MongoDBCommunity) *mdbv1.TLSCertificatesStatus {
+	if !mdb.Spec.Security.TLS.Enabled {
+		deleteCertificateExpiryMetrics(mdb.NamespacedName())
+		return nil
+	}
+
+	certificates := &mdbv1.TLSCertificatesStatus{}
+	if cert, err := readCertificate(r.client, mdb.TLSSecretNamespacedName(), mdb.Spec.Security.TLS.CertificatePEMKey); err == nil {
+		certificates.CertificateNotAfter = r.parseNotAfter(cert, serverCertificate)
+	}
+	if ca, err := readCA(r.client, mdb.Spec.Security.TLS, mdb.Namespace); err == nil {
+		certificates.CANotAfter = r.parseNotAfter(ca, caCertificate)
+	}
+	if mdb.Spec.Security.TLS.InternalClusterAuth {
+		if cert, err := readCertificate(r.client, mdb.TLSMemberSecretNamespacedName(), mdb.Spec.Security.TLS.CertificatePEMKey); err == nil {
+			certificates.MemberCertificateNotAfter = r.parseNotAfter(cert, memberCertificate)
+		}
+	}
+
+	named := namedCertificates(certificates)
+	for _, certificate := range []string{serverCertificate, caCertificate, memberCertificate} {
+		if notAfter, ok := named[certificate]; ok {
+			certificateExpiryTimestamp.WithLabelValues(mdb.Namespace, mdb.Name, certificate).Set(float64(notAfter.Unix()))
+		} else {
+			certificateExpiryTimestamp.DeleteLabelValues(mdb.Namespace, mdb.Name, certificate)
+		}
+	}
+	return certificates
+}
+
+func (r ReplicaSetReconciler) parseNotAfter(cert, certificate string) *metav1.Time {
+	notAfter, err := certificateNotAfter(cert)
+	if err != nil {
+		r.log.Debugf("Could not parse the %s certificate: %s", certificate, err)
+		return nil
+	}
+	t := metav1.NewTime(notAfter)
+	return &t
+}
+
+// deleteCertificateExpiryMetrics removes the certificate expiry metrics of the given resource.
+func deleteCertificateExpiryMetrics(nsName types.NamespacedName) {
+	for _, certificate := range []string{serverCertificate, caCertificate, memberCertificate} {
+		certificateExpiryTimestamp.DeleteLabelValues(nsName.Namespace, nsName.Name, certificate)
+	}
+}
+
+// namedCertificates returns the expiry times of the given certificates by certificate name.
+func namedCertificates(certificates *mdbv1.TLSCertificatesStatus) map[string]time.Time {
+	named := map[string]time.Time{}
+	if certificates == nil {
+		return named
+	}
+	if certificates.CertificateNotAfter != nil {
+		named[serverCertificate] = certificates.CertificateNotAfter.Time
+	}
+	if certificates.CANotAfter != nil {
+		named[caCertificate] = certificates.CANotAfter.Time
+	}
+	if certificates.MemberCertificateNotAfter != nil {
+		named[memberCertificate] = certificates.MemberCertificateNotAfter.Time
+	}
+	return named
+}
+
+// withCertificateExpiryConditions returns the given conditions with the TLSCertificateExpiry and
+// CertificateExpiringSoon conditions updated, or removed if TLS is disabled.
+func (r ReplicaSetReconciler) withCertificateExpiryConditions(mdb mdbv1.MongoDBCommunity, conditions []metav1.Condition, certificates *mdbv1.TLSCertificatesStatus, now time.Time) []metav1.Condition {
+	conditions = r.withTLSCertificateExpiryCondition(mdb, conditions, now)
+	if certificates == nil {
+		meta.RemoveStatusCondition(&conditions, mdbv1.ConditionCertificateExpiringSoon)
+		return conditions
+	}
+
+	condition := certificateExpiringSoonCondition(certificates, r.certificateExpiryWarning, now)
+	condition.Type = mdbv1.ConditionCertificateExpiringSoon
+	condition.ObservedGeneration = mdb.Generation
+	meta.SetStatusCondition(&conditions, condition)
+	return conditions
+}
+
+func certificateExpiringSoonCondition(certificates *mdbv1.TLSCertificatesStatus, warning time.Duration, now time.Time) metav1.Condition {
+	var expired, expiring []string
+	named := namedCertificates(certificates)
+	for _, certificate := range []string{serverCertificate, caCertificate, memberCertificate} {
+		notAfter, ok := named[certificate]
+		if !ok {
+			continue
+		}
+		expiry := notAfter.UTC().Format(time.RFC3339)
+		switch {
+		case !now.Before(notAfter):
+			expired = append(expired, fmt.Sprintf("the %s certificate expired at %s", certificate, expiry))
+		case notAfter.Sub(now) <= warning:
+			expiring = append(expiring, fmt.Sprintf("the %s certificate expires at %s", certificate, expiry))
+		}
+	}
+
+	if len(expired) > 0 {
+		return metav1.Condition{
+			Status:  metav1.ConditionTrue,
+			Reason:  tlsCertificateExpiredReason,
+			Message: strings.Join(append(expired, expiring...), ", "),
+		}
+	}
+	if len(expiring) > 0 {
+		return metav1.Condition{
+			Status:  metav1.ConditionTrue,
+			Reason:  certificateExpiringSoonReason,
+			Message: strings.Join(expiring, ", "),
+		}
+	}
+	return metav1.Condition{
+		Status:  metav1.ConditionFalse,
+		Reason:  tlsCertificateValidReason,
+		Message: fmt.Sprintf("No certificate expires within %s", warning),
+	}
+}
+
+// untilCertificateExpiryChange returns the time until the CertificateExpiringSoon condition changes next, which
+// is when a certificate enters the warning period or expires. It returns 0 if no change is ahead.
+func untilCertificateExpiryChange(certificates *mdbv1.TLSCertificatesStatus, warning time.Duration, now time.Time) time.Duration {
+	var next time.Duration
+	for _, notAfter := range namedCertificates(certificates) {
+		for _, change := range []time.Time{notAfter.Add(-warning), notAfter} {
+			if until := change.Sub(now); until > 0 && (next == 0 || until < next) {
+				next = until
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCertificateExpiryWarningFromEnv(t *testing.T) {
+	defer os.Unsetenv(CertificateExpiryWarningEnv)
+
+	warning, err := certificateExpiryWarningFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, warning)
+
+	os.Setenv(CertificateExpiryWarningEnv, "168h")
+	warning, err = certificateExpiryWarningFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, warning)
+
+	os.Setenv(CertificateExpiryWarningEnv, "7d")
+	assert.Error(t, ValidateEnv())
+}
+
+func TestCertificateExpiringSoonCondition(t *testing.T) {
+	now := time.Now()
+	notAfter := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(d))
+		return &t
+	}
+	warning := 30 * 24 * time.Hour
+
+	condition := certificateExpiringSoonCondition(&mdbv1.TLSCertificatesStatus{
+		CertificateNotAfter: notAfter(90 * 24 * time.Hour),
+		CANotAfter:          notAfter(365 * 24 * time.Hour),
+	}, warning, now)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, tlsCertificateValidReason, condition.Reason)
+
+	condition = certificateExpiringSoonCondition(&mdbv1.TLSCertificatesStatus{
+		CertificateNotAfter: notAfter(90 * 24 * time.Hour),
+		CANotAfter:          notAfter(10 * 24 * time.Hour),
+	}, warning, now)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, certificateExpiringSoonReason, condition.Reason)
+	assert.Contains(t, condition.Message, "the ca certificate expires at")
+	assert.NotContains(t, condition.Message, "server")
+
+	condition = certificateExpiringSoonCondition(&mdbv1.TLSCertificatesStatus{
+		CertificateNotAfter:       notAfter(-time.Hour),
+		MemberCertificateNotAfter: notAfter(24 * time.Hour),
+	}, warning, now)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, tlsCertificateExpiredReason, condition.Reason)
+	assert.Contains(t, condition.Message, "the server certificate expired at")
+	assert.Contains(t, condition.Message, "the member certificate expires at")
+}
+
+func TestUntilCertificateExpiryChange(t *testing.T) {
+	now := time.Now()
+	warning := 30 * 24 * time.Hour
+	notAfter := metav1.NewTime(now.Add(40 * 24 * time.Hour))
+	certificates := &mdbv1.TLSCertificatesStatus{CertificateNotAfter: &notAfter}
+
+	assert.Equal(t, 10*24*time.Hour, untilCertificateExpiryChange(certificates, warning, now), "the certificate enters the warning period")
+	assert.Equal(t, 5*24*time.Hour, untilCertificateExpiryChange(certificates, warning, now.Add(35*24*time.Hour)), "the certificate expires")
+	assert.Equal(t, time.Duration(0), untilCertificateExpiryChange(certificates, warning, now.Add(50*24*time.Hour)))
+	assert.Equal(t, time.Duration(0), untilCertificateExpiryChange(nil, warning, now))
+}
+
+func TestCertificateExpiry_IsReported(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	expiry := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+	setTLSCertificate(t, mgr.GetClient(), mdb, generateCertificate(t, expiry))
+
+	caExpiry := time.Now().Add(365 * 24 * time.Hour).Truncate(time.Second)
+	ca := corev1.ConfigMap{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.TLSConfigMapNamespacedName(), &ca))
+	ca.Data[tlsCACertName] = generateCertificate(t, caExpiry)
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &ca))
+
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	_, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	if assert.NotNil(t, mdb.Status.TLSCertificates) {
+		assert.True(t, expiry.Equal(mdb.Status.TLSCertificates.CertificateNotAfter.Time))
+		assert.True(t, caExpiry.Equal(mdb.Status.TLSCertificates.CANotAfter.Time))
+		assert.Nil(t, mdb.Status.TLSCertificates.MemberCertificateNotAfter)
+	}
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionCertificateExpiringSoon)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, certificateExpiringSoonReason, condition.Reason)
+	}
+	assert.Equal(t, float64(expiry.Unix()), testutil.ToFloat64(certificateExpiryTimestamp.WithLabelValues(mdb.Namespace, mdb.Name, serverCertificate)))
+	assert.Equal(t, float64(caExpiry.Unix()), testutil.ToFloat64(certificateExpiryTimestamp.WithLabelValues(mdb.Namespace, mdb.Name, caCertificate)))
+
+	t.Run("Metrics are removed with the resource", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assert.Equal(t, 0, testutil.CollectAndCount(certificateExpiryTimestamp))
+	})
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	defaultChangeStreamVerificationTimeout = 10 * time.Second
+	maxChangeStreamVerificationTimeout     = time.Minute
+
+	changeStreamsAvailableReason          = "Available"
+	changeStreamsOpenFailedReason         = "OpenFailed"
+	changeStreamsVerificationFailedReason = "VerificationFailed"
+)
+
+// validateChangeStreamVerification checks that the change stream verification refers to a SCRAM user
+// and has a valid timeout.
+func validateChangeStreamVerification(mdb mdbv1.MongoDBCommunity) error {
+	config := mdb.Spec.ChangeStreamVerification
+	if config == nil {
+		return nil
+	}
+	timeout, err := parsePositiveDuration(config.Timeout, defaultChangeStreamVerificationTimeout)
+	if err != nil {
+		return errors.Errorf("invalid changeStreamVerification.timeout: %s", err)
+	}
+	if timeout > maxChangeStreamVerificationTimeout {
+		return errors.Errorf("changeStreamVerification.timeout can not be longer than %s, as the verification blocks the reconciliation", maxChangeStreamVerificationTimeout)
+	}
+	if !mdb.IsAuthModeEnabled(mdbv1.AuthModeScram) {
+		return errors.New("changeStreamVerification requires SCRAM authentication to be enabled")
+	}
+	user, ok := changeStreamVerificationUser(mdb)
+	if !ok {
+		return errors.Errorf("changeStreamVerification.user %s is not a user of the deployment", config.User)
+	}
+	if user.GetDB() == externalDatabase {
+		return errors.Errorf("changeStreamVerification.user %s has no password, a SCRAM user is required", config.User)
+	}
+	return nil
+}
+
+func changeStreamVerificationUser(mdb mdbv1.MongoDBCommunity) (mdbv1.MongoDBUser, bool) {
+	for _, user := range mdb.Spec.Users {
+		if user.Name == mdb.Spec.ChangeStreamVerification.User {
+			return user, true
+		}
+	}
+	return mdbv1.MongoDBUser{}, false
+}
+
+// verifyChangeStreamsIfDue verifies change streams if the verification is configured and was not yet
+// successful for the current generation of the resource, and returns the given conditions updated with the outcome.
+func (r ReplicaSetReconciler) verifyChangeStreamsIfDue(mdb mdbv1.MongoDBCommunity, conditions []metav1.Condition) []metav1.Condition {
+	if mdb.Spec.ChangeStreamVerification == nil {
+		meta.RemoveStatusCondition(&conditions, mdbv1.ConditionChangeStreamsUnavailable)
+		return conditions
+	}
+	if last := meta.FindStatusCondition(conditions, mdbv1.ConditionChangeStreamsUnavailable); last != nil &&
+		last.Status == metav1.ConditionFalse && last.ObservedGeneration == mdb.Generation {
+		return conditions
+	}
+
+	condition := r.verifyChangeStreams(mdb)
+	condition.Type = mdbv1.ConditionChangeStreamsUnavailable
+	condition.ObservedGeneration = mdb.Generation
+	meta.SetStatusCondition(&conditions, condition)
+	return conditions
+}
+
+// verifyChangeStreams opens a change stream as the configured user with the password stored in its Secret.
+func (r ReplicaSetReconciler) verifyChangeStreams(mdb mdbv1.MongoDBCommunity) metav1.Condition {
+	config := *mdb.Spec.ChangeStreamVerification
+	unknown := func(format string, args ...interface{}) metav1.Condition {
+		return metav1.Condition{
+			Status:  metav1.ConditionUnknown,
+			Reason:  changeStreamsVerificationFailedReason,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	user, _ := changeStreamVerificationUser(mdb)
+	password, err := secret.ReadKey(r.client, user.GetPasswordSecretKey(), types.NamespacedName{Name: user.PasswordSecretRef.Name, Namespace: mdb.Namespace})
+	if err != nil {
+		return unknown("Could not read the password of user %s: %s", user.Name, err)
+	}
+	tlsConfig, err := r.operatorTLSConfig(mdb)
+	if err != nil {
+		return unknown("Could not build the TLS configuration: %s", err)
+	}
+	hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return unknown("Could not determine the member hostnames: %s", err)
+	}
+
+	database := config.Database
+	if database == "" {
+		database = user.GetDB()
+	}
+	timeout, _ := parsePositiveDuration(config.Timeout, defaultChangeStreamVerificationTimeout)
+	details := buildConnectionDetails(mdb, user, password, hostnames, nil, "")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := r.credentialVerifier.VerifyChangeStreams(ctx, details.URI, tlsConfig, database); err != nil {
+		r.log.Warnf("Could not open a change stream on database %s: %s", database, err)
+		return metav1.Condition{
+			Status:  metav1.ConditionTrue,
+			Reason:  changeStreamsOpenFailedReason,
+			Message: fmt.Sprintf("Could not open a change stream on database %s as user %s: %s", database, user.Name, err),
+		}
+	}
+	return metav1.Condition{
+		Status:  metav1.ConditionFalse,
+		Reason:  changeStreamsAvailableReason,
+		Message: fmt.Sprintf("A change stream with majority read concern was opened on database %s", database),
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newChangeStreamVerificationReplicaSet() mdbv1.MongoDBCommunity {
+	mdb := newCredentialVerificationReplicaSet()
+	mdb.Spec.Security.Authentication.CredentialVerification = nil
+	mdb.Spec.ChangeStreamVerification = &mdbv1.ChangeStreamVerification{User: "my-user", Database: "orders"}
+	return mdb
+}
+
+func TestValidateChangeStreamVerification(t *testing.T) {
+	t.Run("Valid configuration", func(t *testing.T) {
+		assert.NoError(t, validateChangeStreamVerification(newChangeStreamVerificationReplicaSet()))
+	})
+	t.Run("User does not exist", func(t *testing.T) {
+		mdb := newChangeStreamVerificationReplicaSet()
+		mdb.Spec.ChangeStreamVerification.User = "other-user"
+		assert.Error(t, validateChangeStreamVerification(mdb))
+	})
+	t.Run("User has no password", func(t *testing.T) {
+		mdb := newChangeStreamVerificationReplicaSet()
+		mdb.Spec.Users[0].DB = externalDatabase
+		assert.Error(t, validateChangeStreamVerification(mdb))
+	})
+	t.Run("Invalid timeout", func(t *testing.T) {
+		mdb := newChangeStreamVerificationReplicaSet()
+		mdb.Spec.ChangeStreamVerification.Timeout = "0s"
+		assert.Error(t, validateChangeStreamVerification(mdb))
+	})
+	t.Run("Timeout blocking the reconciliation for too long", func(t *testing.T) {
+		mdb := newChangeStreamVerificationReplicaSet()
+		mdb.Spec.ChangeStreamVerification.Timeout = "5m"
+		assert.EqualError(t, validateChangeStreamVerification(mdb), "changeStreamVerification.timeout can not be longer than 1m0s, as the verification blocks the reconciliation")
+	})
+}
+
+func TestChangeStreamVerification_IsReported(t *testing.T) {
+	mdb := newChangeStreamVerificationReplicaSet()
+	mgr := client.NewManager(&mdb)
+	createUserPasswordSecret(t, mgr, mdb, "my-password")
+	r := NewReconciler(mgr)
+	mockVerifier := &mockVerifier{passwords: map[string]string{"my-user": "my-password"}}
+	r.credentialVerifier = mockVerifier
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.Equal(t, []string{"orders"}, mockVerifier.changeStreamDatabases)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionChangeStreamsUnavailable)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, changeStreamsAvailableReason, condition.Reason)
+	}
+
+	t.Run("Change streams are verified once per generation", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assert.Len(t, mockVerifier.changeStreamDatabases, 1)
+	})
+
+	t.Run("Failures are reported", func(t *testing.T) {
+		mockVerifier.changeStreamErr = errors.New("majority read concern is not enabled")
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Generation++
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Len(t, mockVerifier.changeStreamDatabases, 2)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionChangeStreamsUnavailable)
+		if assert.NotNil(t, condition) {
+			assert.Equal(t, metav1.ConditionTrue, condition.Status)
+			assert.Equal(t, changeStreamsOpenFailedReason, condition.Reason)
+			assert.Contains(t, condition.Message, "majority read concern is not enabled")
+		}
+
+		_, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Len(t, mockVerifier.changeStreamDatabases, 3, "a failed verification is repeated")
+	})
+
+	t.Run("Disabling the verification removes the condition", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.ChangeStreamVerification = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Nil(t, meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionChangeStreamsUnavailable))
+	})
+}
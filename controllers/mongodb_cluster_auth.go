@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const tlsMemberSecretMountPath = "/var/lib/tls/member/" //nolint
+
+// clusterAuthModeOrder is the order in which the members are moved through the cluster authentication modes.
+// Each mode accepts the credentials sent by members configured with the adjacent modes.
+var clusterAuthModeOrder = []automationconfig.ClusterAuthMode{
+	automationconfig.ClusterAuthModeKeyFile,
+	automationconfig.ClusterAuthModeSendKeyFile,
+	automationconfig.ClusterAuthModeSendX509,
+	automationconfig.ClusterAuthModeX509,
+}
+
+// validateInternalClusterAuth checks that X.509 cluster authentication is only enabled together with a
+// TLS mode which uses TLS between the members.
+func validateInternalClusterAuth(mdb mdbv1.MongoDBCommunity) error {
+	tls := mdb.Spec.Security.TLS
+	if !tls.InternalClusterAuth {
+		return nil
+	}
+	if !tls.Enabled {
+		return errors.New("internal cluster authentication requires TLS to be enabled")
+	}
+	if !membersConnectWithTLS(tls.DesiredMode()) {
+		return errors.Errorf("internal cluster authentication requires the TLS mode %s or %s", automationconfig.TLSModePreferred, automationconfig.TLSModeRequired)
+	}
+	if tls.MemberCertificateSecret == nil || tls.MemberCertificateSecret.Name == "" {
+		return errors.New("internal cluster authentication requires memberCertificateSecretRef to be set")
+	}
+	return nil
+}
+
+// validateMemberCertificate checks that the member certificate Secret exists and has the correct fields.
+func (r *ReplicaSetReconciler) validateMemberCertificate(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.Spec.Security.TLS.InternalClusterAuth {
+		return true, nil
+	}
+
+	secretData, err := secret.ReadStringData(r.client, mdb.TLSMemberSecretNamespacedName())
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			r.log.Warnf(`Member certificate Secret "%s" not found`, mdb.TLSMemberSecretNamespacedName())
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, _, err := certificateAndKeyFromData(secretData, mdb.Spec.Security.TLS.CertificatePEMKey); err != nil {
+		r.log.Warnf(`Secret "%s" is not a valid certificate Secret: %s`, mdb.TLSMemberSecretNamespacedName(), err)
+		return false, nil
+	}
+
+	// Watch the member certificate secret to handle rotations
+	r.secretWatcher.Watch(mdb.TLSMemberSecretNamespacedName(), mdb.NamespacedName())
+	return true, nil
+}
+
+// ensureMemberCertificateSecret creates or updates the operator-managed Secret containing the concatenated
+// member certificate and key. The Secret is kept once internal cluster authentication is disabled, as the
+// members use it until they no longer send their certificate.
+func ensureMemberCertificateSecret(getUpdateCreator secret.GetUpdateCreator, mdb mdbv1.MongoDBCommunity) error {
+	if !mdb.Spec.Security.TLS.InternalClusterAuth {
+		return nil
+	}
+
+	certKey, err := readCertAndKey(getUpdateCreator, mdb.TLSMemberSecretNamespacedName(), mdb.Spec.Security.TLS.CertificatePEMKey)
+	if err != nil {
+		return errors.Errorf("could not get member cert and key: %s", err)
+	}
+
+	operatorSecret := secret.Builder().
+		SetName(mdb.TLSMemberOperatorSecretNamespacedName().Name).
+		SetNamespace(mdb.TLSMemberOperatorSecretNamespacedName().Namespace).
+		SetField(tlsOperatorSecretFileName(certKey), certKey).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+
+	return secret.CreateOrUpdate(getUpdateCreator, operatorSecret)
+}
+
+// memberCertificateFileName returns the name of the file in the operator-managed member certificate Secret.
+func memberCertificateFileName(getter secret.Getter, mdb mdbv1.MongoDBCommunity) (string, error) {
+	data, err := secret.ReadStringData(getter, mdb.TLSMemberOperatorSecretNamespacedName())
+	if err != nil {
+		return "", err
+	}
+	if len(data) != 1 {
+		return "", errors.Errorf("secret %s should contain exactly one certificate, found %d", mdb.TLSMemberOperatorSecretNamespacedName(), len(data))
+	}
+	for fileName := range data {
+		return fileName, nil
+	}
+	return "", nil
+}
+
+// clusterAuthModeThisReconciliation returns the cluster authentication mode which is published in the automation config.
+func clusterAuthModeThisReconciliation(mdb mdbv1.MongoDBCommunity) automationconfig.ClusterAuthMode {
+	if mdb.Status.ClusterAuthMode != "" {
+		return mdb.Status.ClusterAuthMode
+	}
+	return mdb.DesiredClusterAuthMode()
+}
+
+// sendsMemberCertificate returns true if members configured with the given mode authenticate with their certificate.
+func sendsMemberCertificate(mode automationconfig.ClusterAuthMode) bool {
+	return mode == automationconfig.ClusterAuthModeSendX509 || mode == automationconfig.ClusterAuthModeX509
+}
+
+// membersConnectWithTLS returns true if members configured with the given mode connect to each other using TLS.
+func membersConnectWithTLS(mode automationconfig.TLSMode) bool {
+	return mode == automationconfig.TLSModePreferred || mode == automationconfig.TLSModeRequired
+}
+
+// getClusterAuthConfigModification configures the cluster authentication mode of the members in the automation
+// config. The keyfile is used as long as the members do not accept X.509 certificates.
+func getClusterAuthConfigModification(getter secret.Getter, mdb mdbv1.MongoDBCommunity) (automationconfig.Modification, error) {
+	mode := clusterAuthModeThisReconciliation(mdb)
+	if !mdb.Spec.Security.TLS.Enabled || mode == automationconfig.ClusterAuthModeKeyFile {
+		return automationconfig.NOOP(), nil
+	}
+
+	clusterFile := ""
+	if sendsMemberCertificate(mode) {
+		fileName, err := memberCertificateFileName(getter, mdb)
+		if err != nil {
+			return automationconfig.NOOP(), errors.Errorf("could not read member certificate: %s", err)
+		}
+		clusterFile = tlsMemberSecretMountPath + fileName
+	}
+
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			args := config.Processes[i].Args26
+			args.Set("security.clusterAuthMode", mode)
+			if clusterFile != "" {
+				args.Set("net.tls.clusterFile", clusterFile)
+			}
+		}
+	}, nil
+}
+
+// buildClusterAuthPodSpecModification mounts the member certificate into the mongod container while the
+// members are configured with any mode but keyFile.
+func buildClusterAuthPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	if !mdb.Spec.Security.TLS.Enabled || clusterAuthModeThisReconciliation(mdb) == automationconfig.ClusterAuthModeKeyFile {
+		return podtemplatespec.NOOP()
+	}
+
+	memberSecretVolume := statefulset.CreateVolumeFromSecret("tls-member-secret", mdb.TLSMemberOperatorSecretNamespacedName().Name)
+	memberSecretVolumeMount := statefulset.CreateVolumeMount(memberSecretVolume.Name, tlsMemberSecretMountPath, statefulset.WithReadOnly(true))
+	return podtemplatespec.Apply(
+		podtemplatespec.WithVolume(memberSecretVolume),
+		podtemplatespec.WithVolumeMounts(construct.MongodbName, memberSecretVolumeMount),
+	)
+}
+
+// nextClusterAuthMode returns the mode following current on the way to desired.
+func nextClusterAuthMode(current, desired automationconfig.ClusterAuthMode) automationconfig.ClusterAuthMode {
+	currentIndex, desiredIndex := clusterAuthModeIndex(current), clusterAuthModeIndex(desired)
+	switch {
+	case currentIndex < desiredIndex:
+		return clusterAuthModeOrder[currentIndex+1]
+	case currentIndex > desiredIndex:
+		return clusterAuthModeOrder[currentIndex-1]
+	}
+	return desired
+}
+
+func clusterAuthModeIndex(mode automationconfig.ClusterAuthMode) int {
+	for i, m := range clusterAuthModeOrder {
+		if m == mode {
+			return i
+		}
+	}
+	return 0
+}
+
+// startClusterAuthModeTransition records the cluster authentication mode which is published in the automation
+// config if it is not known yet. Members of an existing deployment are moved one step from the mode they were
+// configured with by the last successful reconciliation, and advanceClusterAuthModeTransition moves them
+// towards the desired mode.
+func (r *ReplicaSetReconciler) startClusterAuthModeTransition(mdb *mdbv1.MongoDBCommunity) error {
+	if !mdb.Spec.Security.TLS.Enabled {
+		if mdb.Status.ClusterAuthMode == "" {
+			return nil
+		}
+		return r.updateClusterAuthModeStatus(mdb, "")
+	}
+	if mdb.Status.ClusterAuthMode != "" {
+		return nil
+	}
+
+	prevSpec, err := lastSuccessfulSpec(*mdb)
+	if err != nil {
+		return err
+	}
+	desired := mdb.DesiredClusterAuthMode()
+	current := desired
+	if prevSpec != nil {
+		previous := prevSpec.Security.TLS.DesiredClusterAuthMode()
+		current = nextClusterAuthMode(previous, desired)
+		if previous != desired {
+			r.log.Infof("Changing the cluster authentication mode from %s to %s, starting with %s", previous, desired, current)
+		}
+	}
+	if current == automationconfig.ClusterAuthModeKeyFile {
+		return nil
+	}
+	return r.updateClusterAuthModeStatus(mdb, current)
+}
+
+// advanceClusterAuthModeTransition moves the members to the next cluster authentication mode on the way to the
+// desired mode. It must only be called once all agents have reached goal state with the automation config of the
+// current mode. Members only send their certificate once they connect to each other using TLS.
+// The returned boolean is true if the cluster authentication mode requires more automation config changes.
+func (r *ReplicaSetReconciler) advanceClusterAuthModeTransition(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	current, desired := mdb.Status.ClusterAuthMode, mdb.DesiredClusterAuthMode()
+	if current == "" {
+		return false, nil
+	}
+	if current == desired {
+		if desired == automationconfig.ClusterAuthModeKeyFile {
+			return false, r.updateClusterAuthModeStatus(mdb, "")
+		}
+		return false, nil
+	}
+
+	next := nextClusterAuthMode(current, desired)
+	if sendsMemberCertificate(next) && !membersConnectWithTLS(tlsModeThisReconciliation(*mdb)) {
+		r.log.Debugf("Waiting for the members to use TLS before changing the cluster authentication mode to %s", next)
+		return false, nil
+	}
+	r.log.Infof("All members use cluster authentication mode %s, changing to %s on the way to %s", current, next, desired)
+	return true, r.updateClusterAuthModeStatus(mdb, next)
+}
+
+func (r *ReplicaSetReconciler) updateClusterAuthModeStatus(mdb *mdbv1.MongoDBCommunity, mode automationconfig.ClusterAuthMode) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withClusterAuthMode(mode))
+	return err
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestValidateInternalClusterAuth(t *testing.T) {
+	t.Run("Valid configuration", func(t *testing.T) {
+		assert.NoError(t, validateInternalClusterAuth(newTestReplicaSetWithInternalClusterAuth()))
+	})
+	t.Run("TLS is not enabled", func(t *testing.T) {
+		mdb := newTestReplicaSetWithInternalClusterAuth()
+		mdb.Spec.Security.TLS.Enabled = false
+		assert.Error(t, validateInternalClusterAuth(mdb))
+	})
+	t.Run("Members do not connect with TLS", func(t *testing.T) {
+		mdb := newTestReplicaSetWithInternalClusterAuth()
+		mdb.Spec.Security.TLS.Mode = automationconfig.TLSModeAllowed
+		assert.Error(t, validateInternalClusterAuth(mdb))
+	})
+	t.Run("Member certificate is not configured", func(t *testing.T) {
+		mdb := newTestReplicaSetWithInternalClusterAuth()
+		mdb.Spec.Security.TLS.MemberCertificateSecret = nil
+		assert.Error(t, validateInternalClusterAuth(mdb))
+	})
+}
+
+func TestNextClusterAuthMode(t *testing.T) {
+	assert.Equal(t, automationconfig.ClusterAuthModeSendKeyFile, nextClusterAuthMode(automationconfig.ClusterAuthModeKeyFile, automationconfig.ClusterAuthModeX509))
+	assert.Equal(t, automationconfig.ClusterAuthModeX509, nextClusterAuthMode(automationconfig.ClusterAuthModeSendX509, automationconfig.ClusterAuthModeX509))
+	assert.Equal(t, automationconfig.ClusterAuthModeSendX509, nextClusterAuthMode(automationconfig.ClusterAuthModeX509, automationconfig.ClusterAuthModeKeyFile))
+	assert.Equal(t, automationconfig.ClusterAuthModeKeyFile, nextClusterAuthMode(automationconfig.ClusterAuthModeKeyFile, automationconfig.ClusterAuthModeKeyFile))
+}
+
+func TestClusterAuth_NewDeploymentUsesX509(t *testing.T) {
+	mdb := newTestReplicaSetWithInternalClusterAuth()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	assert.NoError(t, createMemberCertificateSecret(mgr.GetClient(), mdb))
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	clusterFile := tlsMemberSecretMountPath + tlsOperatorSecretFileName(combineCertificateAndKey("MEMBER-CERT", "MEMBER-KEY"))
+	for _, process := range ac.Processes {
+		assert.Equal(t, "x509", process.Args26.Get("security.clusterAuthMode").Data())
+		assert.Equal(t, clusterFile, process.Args26.Get("net.tls.clusterFile").Data())
+	}
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	memberSecretMounted := false
+	for _, volume := range sts.Spec.Template.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == mdb.TLSMemberOperatorSecretNamespacedName().Name {
+			memberSecretMounted = true
+		}
+	}
+	assert.True(t, memberSecretMounted)
+}
+
+func TestClusterAuth_IsChangedOneStepAtATime(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	acNsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.TLS = newTestReplicaSetWithInternalClusterAuth().Spec.Security.TLS
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	assert.NoError(t, createMemberCertificateSecret(mgr.GetClient(), mdb))
+
+	// assertClusterAuthMode checks the mode in the automation config, and the mode which is published next.
+	assertClusterAuthMode := func(t *testing.T, configured string, next automationconfig.ClusterAuthMode) {
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+		assert.NoError(t, err)
+		for _, process := range ac.Processes {
+			assert.Equal(t, configured, process.Args26.Get("security.clusterAuthMode").Str())
+		}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, next, mdb.Status.ClusterAuthMode)
+	}
+
+	for _, mode := range []automationconfig.ClusterAuthMode{automationconfig.ClusterAuthModeSendKeyFile, automationconfig.ClusterAuthModeSendX509} {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertClusterAuthMode(t, string(mode), nextClusterAuthMode(mode, automationconfig.ClusterAuthModeX509))
+	}
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assertClusterAuthMode(t, "x509", automationconfig.ClusterAuthModeX509)
+
+	t.Run("Disabling passes through the intermediate modes", func(t *testing.T) {
+		mdb.Spec.Security.TLS.InternalClusterAuth = false
+		mdb.Spec.Security.TLS.MemberCertificateSecret = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertClusterAuthMode(t, "x509", automationconfig.ClusterAuthModeSendX509)
+
+		res, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertClusterAuthMode(t, "sendX509", automationconfig.ClusterAuthModeSendKeyFile)
+
+		res, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertClusterAuthMode(t, "sendKeyFile", automationconfig.ClusterAuthModeKeyFile)
+
+		res, err = r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assertClusterAuthMode(t, "", "")
+	})
+}
+
+func TestClusterAuth_WaitsForMembersToUseTLS(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.TLS = newTestReplicaSetWithInternalClusterAuth().Spec.Security.TLS
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	assert.NoError(t, createMemberCertificateSecret(mgr.GetClient(), mdb))
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, automationconfig.TLSModePreferred, mdb.Status.TLSMode)
+	assert.Equal(t, automationconfig.ClusterAuthModeSendKeyFile, mdb.Status.ClusterAuthMode, "members do not send their certificate before they use TLS")
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, automationconfig.TLSModeRequired, mdb.Status.TLSMode)
+	assert.Equal(t, automationconfig.ClusterAuthModeSendKeyFile, mdb.Status.ClusterAuthMode)
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, automationconfig.ClusterAuthModeSendX509, mdb.Status.ClusterAuthMode)
+}
+
+func newTestReplicaSetWithInternalClusterAuth() mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Spec.Security.TLS.InternalClusterAuth = true
+	mdb.Spec.Security.TLS.MemberCertificateSecret = &mdbv1.LocalObjectReference{Name: "memberCertificateSecret"}
+	return mdb
+}
+
+func createMemberCertificateSecret(c k8sClient.Client, mdb mdbv1.MongoDBCommunity) error {
+	s := secret.Builder().
+		SetName(mdb.TLSMemberSecretNamespacedName().Name).
+		SetNamespace(mdb.Namespace).
+		SetField("tls.crt", "MEMBER-CERT").
+		SetField("tls.key", "MEMBER-KEY").
+		Build()
+	return c.Create(context.TODO(), &s)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	concurrentSpecChangeQueuedReason   = "Queued"
+	concurrentSpecChangeRejectedReason = "Rejected"
+	concurrentSpecChangeAppliedReason  = "Applied"
+)
+
+// applyConcurrentChangePolicy handles a generation which arrived while the rollout of a previous generation is
+// in progress, so that the rollout does not act on a mixture of both. With the Queue and Reject policies the
+// spec of the resource is replaced with the spec being rolled out. A rollout is in progress while the resource
+// is Pending; a failed rollout does not hold back a change which may fix it.
+func (r ReplicaSetReconciler) applyConcurrentChangePolicy(mdb *mdbv1.MongoDBCommunity) error {
+	change := mdb.Status.ConcurrentChange
+	if change == nil || change.Generation == mdb.Generation {
+		setConcurrentSpecChangeCondition(mdb, metav1.ConditionFalse, concurrentSpecChangeAppliedReason, fmt.Sprintf("Generation %d has been applied", mdb.Generation))
+		return nil
+	}
+
+	if mdb.Status.Phase != mdbv1.Pending {
+		if change.Rejected && change.PendingGeneration == mdb.Generation {
+			return restoreInFlightSpec(mdb, *change)
+		}
+		r.log.Infof("Applying generation %d", mdb.Generation)
+		mdb.Status.ConcurrentChange = nil
+		setConcurrentSpecChangeCondition(mdb, metav1.ConditionFalse, concurrentSpecChangeAppliedReason, fmt.Sprintf("Generation %d has been applied", mdb.Generation))
+		return nil
+	}
+
+	policy := mdb.GetConcurrentChangePolicy()
+	if policy == mdbv1.ConcurrentChangeMerge || change.Spec == "" {
+		r.log.Infof("Merging generation %d into the rollout of generation %d", mdb.Generation, change.Generation)
+		r.recordConcurrentChangeEvent(mdb, corev1.EventTypeNormal, "SpecChangeMerged", fmt.Sprintf("Generation %d is merged into the rollout of generation %d", mdb.Generation, change.Generation))
+		mdb.Status.ConcurrentChange = &mdbv1.ConcurrentChangeStatus{Generation: mdb.Generation}
+		return nil
+	}
+
+	pending := *change
+	pending.PendingGeneration = mdb.Generation
+	pending.Rejected = policy == mdbv1.ConcurrentChangeReject
+	reason, message := concurrentSpecChangeQueuedReason, fmt.Sprintf("Generation %d is applied once the rollout of generation %d has completed", mdb.Generation, change.Generation)
+	if pending.Rejected {
+		reason, message = concurrentSpecChangeRejectedReason, fmt.Sprintf("Generation %d was rejected because the rollout of generation %d is in progress, change the spec again once it has completed", mdb.Generation, change.Generation)
+	}
+	if change.PendingGeneration != pending.PendingGeneration {
+		r.log.Info(message)
+		eventType := corev1.EventTypeNormal
+		if pending.Rejected {
+			eventType = corev1.EventTypeWarning
+		}
+		r.recordConcurrentChangeEvent(mdb, eventType, "SpecChange"+reason, message)
+	}
+	setConcurrentSpecChangeCondition(mdb, metav1.ConditionTrue, reason, message)
+	return restoreInFlightSpec(mdb, pending)
+}
+
+func (r ReplicaSetReconciler) recordConcurrentChangeEvent(mdb *mdbv1.MongoDBCommunity, eventType, reason, message string) {
+	if r.recorder != nil {
+		r.recorder.AnnotatedEventf(mdb, mdb.SchemaLabels(mdbv1.ComponentDatabase), eventType, reason, "%s", message)
+	}
+}
+
+// restoreInFlightSpec replaces the spec of the resource with the spec being rolled out.
+func restoreInFlightSpec(mdb *mdbv1.MongoDBCommunity, change mdbv1.ConcurrentChangeStatus) error {
+	spec := mdbv1.MongoDBCommunitySpec{}
+	if err := json.Unmarshal([]byte(change.Spec), &spec); err != nil {
+		return err
+	}
+	mdb.Spec = spec
+	mdb.Status.ConcurrentChange = &change
+	return nil
+}
+
+// inFlightChange returns the concurrent change status recording the generation being rolled out. The spec is
+// only recorded if the policy needs it to hold back a later generation.
+func inFlightChange(mdb mdbv1.MongoDBCommunity) (*mdbv1.ConcurrentChangeStatus, error) {
+	if mdb.Status.ConcurrentChange != nil {
+		return mdb.Status.ConcurrentChange, nil
+	}
+	change := &mdbv1.ConcurrentChangeStatus{Generation: mdb.Generation}
+	if mdb.GetConcurrentChangePolicy() == mdbv1.ConcurrentChangeMerge {
+		return change, nil
+	}
+	spec, err := json.Marshal(mdb.Spec)
+	if err != nil {
+		return nil, err
+	}
+	change.Spec = string(spec)
+	return change, nil
+}
+
+// completedChange returns the concurrent change status once the rollout has completed. It is only kept if a
+// generation has been rejected, so that it is not applied by later reconciliations.
+func completedChange(mdb mdbv1.MongoDBCommunity) *mdbv1.ConcurrentChangeStatus {
+	if change := mdb.Status.ConcurrentChange; change != nil && change.Rejected {
+		return change
+	}
+	return nil
+}
+
+// setConcurrentSpecChangeCondition sets the ConcurrentSpecChange condition. A condition which reports that a
+// generation has been applied is only set if a generation is currently reported as queued or rejected.
+func setConcurrentSpecChangeCondition(mdb *mdbv1.MongoDBCommunity, conditionStatus metav1.ConditionStatus, reason, message string) {
+	if conditionStatus == metav1.ConditionFalse && !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionConcurrentSpecChange) {
+		return
+	}
+	meta.SetStatusCondition(&mdb.Status.Conditions, metav1.Condition{
+		Type:               mdbv1.ConditionConcurrentSpecChange,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mdb.Generation,
+	})
+}
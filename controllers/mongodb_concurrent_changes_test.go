@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// startFCVChange reconciles a resource with the given policy, and changes its feature compatibility version
+// to 4.0 in generation 2, whose rollout is left in progress.
+func startFCVChange(t *testing.T, policy mdbv1.ConcurrentChangePolicy) (*mdbv1.MongoDBCommunity, *client.MockedManager, ReplicaSetReconciler) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ConcurrentChangePolicy = policy
+	mdb.Generation = 1
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	changeSpec(t, mgr, &mdb, 2, "4.0")
+	setStatefulSetRevision(t, mgr, mdb, 1)
+	reconcileConcurrentChange(t, mgr, r, &mdb)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	if assert.NotNil(t, mdb.Status.ConcurrentChange) {
+		assert.Equal(t, int64(2), mdb.Status.ConcurrentChange.Generation)
+	}
+	return &mdb, mgr, *r
+}
+
+func changeSpec(t *testing.T, mgr *client.MockedManager, mdb *mdbv1.MongoDBCommunity, generation int64, fcv string) {
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), mdb))
+	mdb.Generation = generation
+	mdb.Spec.FeatureCompatibilityVersion = fcv
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), mdb))
+}
+
+func reconcileConcurrentChange(t *testing.T, mgr *client.MockedManager, r *ReplicaSetReconciler, mdb *mdbv1.MongoDBCommunity) reconcile.Result {
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), mdb))
+	return res
+}
+
+func assertAutomationConfigFCV(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, fcv string) {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, process := range ac.Processes {
+		assert.Equal(t, fcv, process.FeatureCompatibilityVersion)
+	}
+}
+
+func TestConcurrentChange_IsMergedByDefault(t *testing.T) {
+	mdb, mgr, r := startFCVChange(t, "")
+	assert.Empty(t, mdb.Status.ConcurrentChange.Spec, "the spec is not kept for the Merge policy")
+
+	changeSpec(t, mgr, mdb, 3, "4.2")
+	setStatefulSetRevision(t, mgr, *mdb, 1)
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.2")
+	assert.Equal(t, int64(3), mdb.Status.ConcurrentChange.Generation)
+	assert.Nil(t, meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionConcurrentSpecChange))
+}
+
+func TestConcurrentChange_IsQueuedUntilTheRolloutCompletes(t *testing.T) {
+	mdb, mgr, r := startFCVChange(t, mdbv1.ConcurrentChangeQueue)
+
+	changeSpec(t, mgr, mdb, 3, "4.2")
+	setStatefulSetRevision(t, mgr, *mdb, 1)
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.0")
+	assert.Equal(t, "4.2", mdb.Spec.FeatureCompatibilityVersion, "the spec of the resource is not changed")
+	assert.Equal(t, int64(2), mdb.Status.ConcurrentChange.Generation)
+	assert.Equal(t, int64(3), mdb.Status.ConcurrentChange.PendingGeneration)
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionConcurrentSpecChange)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, concurrentSpecChangeQueuedReason, condition.Reason)
+		assert.Equal(t, int64(3), condition.ObservedGeneration)
+	}
+
+	makeStatefulSetReady(t, mgr.GetClient(), *mdb)
+	res := reconcileConcurrentChange(t, mgr, &r, mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.True(t, res.Requeue, "the queued generation is applied right away")
+	assert.Nil(t, mdb.Status.ConcurrentChange)
+	lastSpec, err := lastSuccessfulSpec(*mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, "4.0", lastSpec.FeatureCompatibilityVersion, "the spec of the completed rollout is recorded")
+
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.2")
+	condition = meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionConcurrentSpecChange)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, concurrentSpecChangeAppliedReason, condition.Reason)
+	}
+}
+
+func TestConcurrentChange_IsRejectedUntilTheSpecChangesAgain(t *testing.T) {
+	mdb, mgr, r := startFCVChange(t, mdbv1.ConcurrentChangeReject)
+
+	changeSpec(t, mgr, mdb, 3, "4.2")
+	setStatefulSetRevision(t, mgr, *mdb, 1)
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.0")
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionConcurrentSpecChange)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, concurrentSpecChangeRejectedReason, condition.Reason)
+	}
+
+	makeStatefulSetReady(t, mgr.GetClient(), *mdb)
+	res := reconcileConcurrentChange(t, mgr, &r, mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.False(t, res.Requeue)
+	if assert.NotNil(t, mdb.Status.ConcurrentChange) {
+		assert.True(t, mdb.Status.ConcurrentChange.Rejected)
+	}
+
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.0")
+
+	changeSpec(t, mgr, mdb, 4, "4.2")
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.2")
+	condition = meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionConcurrentSpecChange)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	}
+}
+
+func TestConcurrentChange_DoesNotHoldBackAFailedRollout(t *testing.T) {
+	mdb, mgr, r := startFCVChange(t, mdbv1.ConcurrentChangeQueue)
+	mdb.Status.Phase = mdbv1.Failed
+	assert.NoError(t, mgr.GetClient().Status().Update(context.TODO(), mdb))
+
+	changeSpec(t, mgr, mdb, 3, "4.2")
+	setStatefulSetRevision(t, mgr, *mdb, 1)
+	reconcileConcurrentChange(t, mgr, &r, mdb)
+
+	assertAutomationConfigFCV(t, mgr, *mdb, "4.2")
+	assert.Equal(t, int64(3), mdb.Status.ConcurrentChange.Generation)
+}
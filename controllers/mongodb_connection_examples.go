@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	connectionExamplesURIKey      = "uri"
+	connectionExamplesUsersKey    = "users"
+	connectionExamplesEnvKey      = "env.yaml"
+	connectionExamplesMongoshKey  = "mongosh.sh"
+	connectionExamplesPythonKey   = "python.py"
+	connectionExamplesNodeKey     = "node.js"
+	connectionExamplesGoKey       = "main.go"
+	connectionExamplesJavaKey     = "Main.java"
+	connectionExamplesCAMountPath = "/etc/mongodb/ca/"
+
+	// connectionExamplesURIEnv is the environment variable the examples read the connection string from.
+	connectionExamplesURIEnv = "MONGODB_URI"
+)
+
+// ensureConnectionExamples creates or updates the ConfigMap storing examples of connecting applications to the
+// deployment if they are enabled, or deletes it otherwise. The ConfigMap contains no credentials, the examples
+// read the connection string of a user from its connection string Secret.
+func (r ReplicaSetReconciler) ensureConnectionExamples(mdb mdbv1.MongoDBCommunity) error {
+	if !mdb.Spec.ConnectionExamples {
+		cm := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: mdb.ConnectionExamplesConfigMapName(), Namespace: mdb.Namespace}}
+		return r.deleteControlledObject(mdb, &cm)
+	}
+
+	hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return err
+	}
+
+	builder := configmap.Builder().
+		SetName(mdb.ConnectionExamplesConfigMapName()).
+		SetNamespace(mdb.Namespace).
+		SetOwnerReferences(mdb.GetOwnerReferences())
+	for key, value := range buildConnectionExamples(mdb, hostnames) {
+		builder.SetField(key, value)
+	}
+	return configmap.CreateOrUpdate(r.client, builder.Build())
+}
+
+// buildConnectionExamples returns the contents of the connection examples ConfigMap.
+func buildConnectionExamples(mdb mdbv1.MongoDBCommunity, hostnames []string) map[string]string {
+	tlsEnabled := mdb.Spec.Security.TLS.Enabled
+	caFile := connectionExamplesCAMountPath + tlsCACertName
+
+	return map[string]string{
+		connectionExamplesURIKey:     connectionExamplesURI(mdb, hostnames),
+		connectionExamplesUsersKey:   connectionExamplesUsers(mdb),
+		connectionExamplesEnvKey:     connectionExamplesEnv(mdb),
+		connectionExamplesMongoshKey: connectionExamplesMongosh(tlsEnabled, caFile),
+		connectionExamplesPythonKey:  connectionExamplesPython(tlsEnabled, caFile),
+		connectionExamplesNodeKey:    connectionExamplesNode(tlsEnabled, caFile),
+		connectionExamplesGoKey:      connectionExamplesGo(tlsEnabled, caFile),
+		connectionExamplesJavaKey:    connectionExamplesJava(tlsEnabled, caFile),
+	}
+}
+
+// connectionExamplesURI returns the connection string of the deployment without credentials.
+func connectionExamplesURI(mdb mdbv1.MongoDBCommunity, hostnames []string) string {
+	hosts := make([]string, len(hostnames))
+	for i, hostname := range hostnames {
+		hosts[i] = net.JoinHostPort(hostname, "27017")
+	}
+	query := url.Values{}
+	query.Set("replicaSet", mdb.GetReplicaSetName())
+	query.Set("tls", strconv.FormatBool(mdb.Spec.Security.TLS.Enabled))
+	return fmt.Sprintf("mongodb://%s/?%s", strings.Join(hosts, ","), query.Encode())
+}
+
+// connectionExamplesUsers lists the connection string Secret of every user with a password.
+func connectionExamplesUsers(mdb mdbv1.MongoDBCommunity) string {
+	b := strings.Builder{}
+	for _, user := range mdb.Spec.Users {
+		if user.GetDB() == externalDatabase {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: secret %s, key %s\n", user.Name, user.GetConnectionStringSecretName(mdb.Name), connectionStringStandardKey)
+	}
+	return b.String()
+}
+
+// connectionExamplesEnv returns the container configuration which sets the connection string environment
+// variable from the connection string Secret of each user, and mounts the CA certificate if TLS is enabled.
+func connectionExamplesEnv(mdb mdbv1.MongoDBCommunity) string {
+	b := strings.Builder{}
+	b.WriteString("# Add to the container of your application, keeping the entry of the user it connects as.\nenv:\n")
+	for _, user := range mdb.Spec.Users {
+		if user.GetDB() == externalDatabase {
+			continue
+		}
+		fmt.Fprintf(&b, `  # user %s
+  - name: %s
+    valueFrom:
+      secretKeyRef:
+        name: %s
+        key: %s
+`, user.Name, connectionExamplesURIEnv, user.GetConnectionStringSecretName(mdb.Name), connectionStringStandardKey)
+	}
+	if mdb.Spec.Security.TLS.Enabled {
+		fmt.Fprintf(&b, `volumeMounts:
+  - name: mongodb-ca
+    mountPath: %s
+    readOnly: true
+# Add to the volumes of the Pod.
+volumes:
+  - name: mongodb-ca
+%s`, connectionExamplesCAMountPath, connectionExamplesCAVolumeSource(mdb.Spec.Security.TLS))
+	}
+	return b.String()
+}
+
+// connectionExamplesCAVolumeSource returns the source of the CA volume, which mounts the CA certificate as "ca.crt"
+// from the configured ConfigMap or Secret.
+func connectionExamplesCAVolumeSource(tls mdbv1.TLS) string {
+	b := strings.Builder{}
+	if tls.HasCaCertificateSecret() {
+		fmt.Fprintf(&b, "    secret:\n      secretName: %s\n", tls.CaCertificateSecret.Name)
+	} else {
+		fmt.Fprintf(&b, "    configMap:\n      name: %s\n", tls.CaConfigMap.Name)
+	}
+	if key := tls.GetCaCertificateKey(); key != tlsCACertName {
+		fmt.Fprintf(&b, "      items:\n        - key: %s\n          path: %s\n", key, tlsCACertName)
+	}
+	return b.String()
+}
+
+func connectionExamplesMongosh(tlsEnabled bool, caFile string) string {
+	if tlsEnabled {
+		return fmt.Sprintf("mongosh \"$%s\" --tlsCAFile %s\n", connectionExamplesURIEnv, caFile)
+	}
+	return fmt.Sprintf("mongosh \"$%s\"\n", connectionExamplesURIEnv)
+}
+
+func connectionExamplesPython(tlsEnabled bool, caFile string) string {
+	options := ""
+	if tlsEnabled {
+		options = fmt.Sprintf(", tlsCAFile=%q", caFile)
+	}
+	return fmt.Sprintf(`import os
+
+from pymongo import MongoClient
+
+client = MongoClient(os.environ[%q]%s)
+print(client.admin.command("ping"))
+`, connectionExamplesURIEnv, options)
+}
+
+func connectionExamplesNode(tlsEnabled bool, caFile string) string {
+	options := ""
+	if tlsEnabled {
+		options = fmt.Sprintf(", { tlsCAFile: %q }", caFile)
+	}
+	return fmt.Sprintf(`const { MongoClient } = require("mongodb");
+
+async function main() {
+  const client = new MongoClient(process.env.%s%s);
+  await client.connect();
+  console.log(await client.db("admin").command({ ping: 1 }));
+  await client.close();
+}
+
+main();
+`, connectionExamplesURIEnv, options)
+}
+
+func connectionExamplesGo(tlsEnabled bool, caFile string) string {
+	uri := fmt.Sprintf("os.Getenv(%q)", connectionExamplesURIEnv)
+	if tlsEnabled {
+		uri = fmt.Sprintf("os.Getenv(%q) + \"&tlsCAFile=%s\"", connectionExamplesURIEnv, caFile)
+	}
+	return fmt.Sprintf(`package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func main() {
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(%s))
+	if err != nil {
+		panic(err)
+	}
+	defer client.Disconnect(ctx)
+
+	fmt.Println(client.Ping(ctx, readpref.Primary()))
+}
+`, uri)
+}
+
+func connectionExamplesJava(tlsEnabled bool, caFile string) string {
+	comment := ""
+	if tlsEnabled {
+		comment = fmt.Sprintf("// Import %s into a trust store and pass it with -Djavax.net.ssl.trustStore=<path>.\n", caFile)
+	}
+	return fmt.Sprintf(`import com.mongodb.client.MongoClient;
+import com.mongodb.client.MongoClients;
+import org.bson.Document;
+
+%spublic class Main {
+    public static void main(String[] args) {
+        try (MongoClient client = MongoClients.create(System.getenv(%q))) {
+            System.out.println(client.getDatabase("admin").runCommand(new Document("ping", 1)));
+        }
+    }
+}
+`, comment, connectionExamplesURIEnv)
+}
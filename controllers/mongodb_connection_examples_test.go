@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/stretchr/testify/assert"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestConnectionExamples_ArePublished(t *testing.T) {
+	mdb := newCredentialVerificationReplicaSet()
+	mdb.Spec.Security.Authentication.CredentialVerification = nil
+	mdb.Spec.ConnectionExamples = true
+	mgr := client.NewManager(&mdb)
+	createUserPasswordSecret(t, mgr, mdb, "my-password")
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	data, err := configmap.ReadData(mgr.Client, types.NamespacedName{Name: mdb.ConnectionExamplesConfigMapName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "mongodb://my-rs-0.my-rs-svc.my-ns.svc.cluster.local:27017,my-rs-1.my-rs-svc.my-ns.svc.cluster.local:27017,my-rs-2.my-rs-svc.my-ns.svc.cluster.local:27017/?replicaSet=my-rs&tls=false", data[connectionExamplesURIKey])
+	secretName := mdb.Spec.Users[0].GetConnectionStringSecretName(mdb.Name)
+	assert.Equal(t, "my-user: secret "+secretName+", key connectionString.standard\n", data[connectionExamplesUsersKey])
+	assert.Contains(t, data[connectionExamplesEnvKey], "name: "+secretName)
+	for _, key := range []string{connectionExamplesMongoshKey, connectionExamplesPythonKey, connectionExamplesNodeKey, connectionExamplesGoKey, connectionExamplesJavaKey} {
+		assert.Contains(t, data[key], connectionExamplesURIEnv)
+		assert.NotContains(t, data[key], "tlsCAFile")
+	}
+	for _, value := range data {
+		assert.NotContains(t, value, "my-password")
+	}
+
+	t.Run("The ConfigMap is deleted once the examples are disabled", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.ConnectionExamples = false
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		_, err = mgr.Client.GetConfigMap(types.NamespacedName{Name: mdb.ConnectionExamplesConfigMapName(), Namespace: mdb.Namespace})
+		assert.True(t, apiErrors.IsNotFound(err))
+	})
+}
+
+func TestConnectionExamples_UseTheCAWithTLS(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	examples := buildConnectionExamples(mdb, []string{"my-host"})
+
+	assert.Contains(t, examples[connectionExamplesURIKey], "tls=true")
+	assert.Contains(t, examples[connectionExamplesEnvKey], "name: "+mdb.Spec.Security.TLS.CaConfigMap.Name)
+	assert.Contains(t, examples[connectionExamplesEnvKey], "mountPath: /etc/mongodb/ca/")
+	for _, key := range []string{connectionExamplesMongoshKey, connectionExamplesPythonKey, connectionExamplesNodeKey, connectionExamplesGoKey} {
+		assert.Contains(t, examples[key], "tlsCAFile")
+		assert.Contains(t, examples[key], "/etc/mongodb/ca/ca.crt")
+	}
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// pauseRestartsAnnotation can be set on the MongoDBCommunity resource by other controllers to ask the operator
+	// not to restart any members until it is removed. Its value identifies the controller requesting the pause.
+	pauseRestartsAnnotation = "mongodbcommunity.mongodb.com/pause-restarts"
+
+	// coordinationLeaseHolder is the holder identity the operator acquires the coordination Lease with.
+	coordinationLeaseHolder = "mongodb-kubernetes-operator"
+)
+
+// restartsPausedBy returns the controller which asked the operator not to restart members, if any.
+func restartsPausedBy(mdb mdbv1.MongoDBCommunity) string {
+	return mdb.Annotations[pauseRestartsAnnotation]
+}
+
+// holdsCoordinationLease returns true if the operator currently holds the coordination Lease of the resource.
+// Leases are read from the API server, as the operator may only get them, not list or watch them.
+func (r *ReplicaSetReconciler) holdsCoordinationLease(mdb mdbv1.MongoDBCommunity, now time.Time) (bool, error) {
+	lease := coordinationv1.Lease{}
+	if err := r.apiReader.Get(context.TODO(), mdb.CoordinationLeaseNamespacedName(), &lease); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return leaseHolder(lease, now) == coordinationLeaseHolder, nil
+}
+
+// acquireCoordinationLease acquires or renews the coordination Lease of the resource. If the Lease is held by
+// another controller, the returned string is its holder identity.
+func (r *ReplicaSetReconciler) acquireCoordinationLease(mdb mdbv1.MongoDBCommunity, now time.Time) (bool, string, error) {
+	holder := coordinationLeaseHolder
+	duration := int32(mdb.CoordinationLeaseDurationSeconds())
+	renewTime := metav1.NewMicroTime(now)
+
+	lease := coordinationv1.Lease{}
+	err := r.apiReader.Get(context.TODO(), mdb.CoordinationLeaseNamespacedName(), &lease)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return false, "", errors.Errorf("could not get the coordination Lease: %s", err)
+	}
+
+	if apiErrors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            mdb.CoordinationLeaseNamespacedName().Name,
+				Namespace:       mdb.Namespace,
+				OwnerReferences: mdb.GetOwnerReferences(),
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &renewTime,
+				RenewTime:            &renewTime,
+			},
+		}
+		if err := r.client.Create(context.TODO(), &lease); err != nil {
+			return false, "", errors.Errorf("could not create the coordination Lease: %s", err)
+		}
+		return true, holder, nil
+	}
+
+	current := leaseHolder(lease, now)
+	if current != "" && current != holder {
+		return false, current, nil
+	}
+	if current != holder {
+		lease.Spec.AcquireTime = &renewTime
+	}
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &renewTime
+	if err := r.client.Update(context.TODO(), &lease); err != nil {
+		return false, "", errors.Errorf("could not update the coordination Lease: %s", err)
+	}
+	return true, holder, nil
+}
+
+// releaseCoordinationLease releases the coordination Lease of the resource if the operator holds it.
+func (r *ReplicaSetReconciler) releaseCoordinationLease(mdb mdbv1.MongoDBCommunity) error {
+	lease := coordinationv1.Lease{}
+	if err := r.apiReader.Get(context.TODO(), mdb.CoordinationLeaseNamespacedName(), &lease); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != coordinationLeaseHolder {
+		return nil
+	}
+	r.log.Infof("Releasing the coordination Lease %s", mdb.CoordinationLeaseNamespacedName())
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+	return r.client.Update(context.TODO(), &lease)
+}
+
+// leaseHolder returns the holder identity of the Lease, or an empty string if the Lease is not held or
+// its holder has not renewed it in time.
+func leaseHolder(lease coordinationv1.Lease, now time.Time) string {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return ""
+	}
+	if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+		expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		if now.After(expiry) {
+			return ""
+		}
+	}
+	return *lease.Spec.HolderIdentity
+}
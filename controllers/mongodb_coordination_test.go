@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestLeaseHolder(t *testing.T) {
+	now := time.Now()
+	holder := "service-mesh"
+	duration := int32(60)
+
+	lease := coordinationv1.Lease{}
+	assert.Equal(t, "", leaseHolder(lease, now))
+
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.LeaseDurationSeconds = &duration
+	renewTime := metav1.NewMicroTime(now.Add(-30 * time.Second))
+	lease.Spec.RenewTime = &renewTime
+	assert.Equal(t, holder, leaseHolder(lease, now))
+
+	expiredRenewTime := metav1.NewMicroTime(now.Add(-2 * time.Minute))
+	lease.Spec.RenewTime = &expiredRenewTime
+	assert.Equal(t, "", leaseHolder(lease, now), "a Lease which has not been renewed in time is not held")
+}
+
+func TestMemberRestarts_WaitForCoordinationLease(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Coordination = &mdbv1.Coordination{Lease: true}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	otherHolder := "backup-tool"
+	duration := int32(60)
+	renewTime := metav1.NewMicroTime(time.Now())
+	lease := coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: mdb.CoordinationLeaseNamespacedName().Name, Namespace: mdb.Namespace},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &otherHolder,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &renewTime,
+		},
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &lease))
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assertStatefulSetPartition(t, mgr.GetClient(), mdb, 3)
+	setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+
+	t.Run("Members are not restarted while another controller holds the Lease", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertStatefulSetPartition(t, mgr.GetClient(), mdb, 3)
+	})
+
+	t.Run("Members are restarted once the Lease has been released", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.CoordinationLeaseNamespacedName(), &lease))
+		lease.Spec.HolderIdentity = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &lease))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assertStatefulSetPartition(t, mgr.GetClient(), mdb, 0)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.CoordinationLeaseNamespacedName(), &lease))
+		assert.Equal(t, coordinationLeaseHolder, *lease.Spec.HolderIdentity)
+	})
+
+	t.Run("The Lease is released once all members have been restarted", func(t *testing.T) {
+		setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-2", "rev-2")
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.CoordinationLeaseNamespacedName(), &lease))
+		assert.Nil(t, lease.Spec.HolderIdentity)
+	})
+}
+
+func TestMemberRestarts_ArePausedOnRequest(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Annotations[pauseRestartsAnnotation] = "service-mesh"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+
+	t.Run("Members are not restarted while the pause is requested", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertStatefulSetPartition(t, mgr.GetClient(), mdb, 3)
+	})
+
+	t.Run("The rolling update is resumed once the annotation is removed", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		delete(mdb.Annotations, pauseRestartsAnnotation)
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.Nil(t, sts.Spec.UpdateStrategy.RollingUpdate)
+	})
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/verifier"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	defaultCredentialVerificationInterval = time.Hour
+	defaultCredentialVerificationTimeout  = 10 * time.Second
+
+	// externalDatabase is the database of users authenticated by LDAP or X.509, which have no password.
+	externalDatabase = "$external"
+
+	credentialsVerifiedReason             = "Verified"
+	credentialsAuthenticationFailedReason = "AuthenticationFailed"
+	credentialsVerificationFailedReason   = "VerificationFailed"
+)
+
+// credentialVerificationSettings returns the interval between two verifications of the user credentials and
+// the time a single user is given to authenticate. The returned boolean is false if verification is disabled.
+func credentialVerificationSettings(mdb mdbv1.MongoDBCommunity) (time.Duration, time.Duration, bool, error) {
+	config := mdb.Spec.Security.Authentication.CredentialVerification
+	if config == nil || !mdb.IsAuthModeEnabled(mdbv1.AuthModeScram) {
+		return 0, 0, false, nil
+	}
+
+	interval, err := parsePositiveDuration(config.Interval, defaultCredentialVerificationInterval)
+	if err != nil {
+		return 0, 0, false, errors.Errorf("invalid credentialVerification.interval: %s", err)
+	}
+	timeout, err := parsePositiveDuration(config.Timeout, defaultCredentialVerificationTimeout)
+	if err != nil {
+		return 0, 0, false, errors.Errorf("invalid credentialVerification.timeout: %s", err)
+	}
+	return interval, timeout, true, nil
+}
+
+func parsePositiveDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, errors.Errorf("%q must be positive", value)
+	}
+	return duration, nil
+}
+
+// untilNextCredentialVerification returns how long it is until the credentials of the users are due to be
+// verified again, which is zero or negative if they are due now.
+func untilNextCredentialVerification(mdb mdbv1.MongoDBCommunity, interval time.Duration, now time.Time) time.Duration {
+	last := mdb.Status.LastCredentialVerificationTime
+	if last == nil {
+		return 0
+	}
+	return last.Add(interval).Sub(now)
+}
+
+// verifyUserCredentialsIfDue verifies the credentials of the users if verification is enabled and due, and
+// returns the given conditions updated with the outcome. The returned boolean is true if the users were verified.
+func (r ReplicaSetReconciler) verifyUserCredentialsIfDue(mdb mdbv1.MongoDBCommunity, conditions []metav1.Condition, now time.Time) ([]metav1.Condition, bool) {
+	interval, timeout, enabled, _ := credentialVerificationSettings(mdb)
+	if !enabled {
+		meta.RemoveStatusCondition(&conditions, mdbv1.ConditionCredentialDrift)
+		return conditions, false
+	}
+	if untilNextCredentialVerification(mdb, interval, now) > 0 {
+		return conditions, false
+	}
+
+	condition := r.verifyUserCredentials(mdb, timeout)
+	condition.Type = mdbv1.ConditionCredentialDrift
+	condition.ObservedGeneration = mdb.Generation
+	meta.SetStatusCondition(&conditions, condition)
+	return conditions, true
+}
+
+// verifyUserCredentials connects to the deployment as every user with the password stored in its Secret.
+// Users which are rejected indicate that their Secret was changed without the automation config being
+// published, or that the published automation config was not applied by the members.
+func (r ReplicaSetReconciler) verifyUserCredentials(mdb mdbv1.MongoDBCommunity, timeout time.Duration) metav1.Condition {
+	tlsConfig, err := r.operatorTLSConfig(mdb)
+	if err != nil {
+		return metav1.Condition{
+			Status:  metav1.ConditionUnknown,
+			Reason:  credentialsVerificationFailedReason,
+			Message: fmt.Sprintf("Could not build the TLS configuration: %s", err),
+		}
+	}
+
+	hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return metav1.Condition{
+			Status:  metav1.ConditionUnknown,
+			Reason:  credentialsVerificationFailedReason,
+			Message: fmt.Sprintf("Could not determine the member hostnames: %s", err),
+		}
+	}
+	srvHost := getDomain(mdb.ServiceName(), mdb.Namespace, os.Getenv(clusterDNSName))
+
+	var drifted, unverified []string
+	verified := 0
+	for _, user := range mdb.Spec.Users {
+		if user.GetDB() == externalDatabase {
+			continue
+		}
+
+		password, err := secret.ReadKey(r.client, user.GetPasswordSecretKey(), types.NamespacedName{Name: user.PasswordSecretRef.Name, Namespace: mdb.Namespace})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				drifted = append(drifted, fmt.Sprintf("%s (password secret not found)", user.Name))
+			} else {
+				r.log.Warnf("Could not read password of user %s: %s", user.Name, err)
+				unverified = append(unverified, user.Name)
+			}
+			continue
+		}
+
+		details := buildConnectionDetails(mdb, user, password, hostnames, nil, srvHost)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err = r.credentialVerifier.Verify(ctx, details.URI, tlsConfig)
+		cancel()
+
+		switch {
+		case err == nil:
+			verified++
+		case verifier.IsAuthenticationError(err):
+			r.log.Warnf("User %s could not authenticate with the password stored in its secret: %s", user.Name, err)
+			drifted = append(drifted, user.Name)
+		default:
+			r.log.Warnf("Could not verify the credentials of user %s: %s", user.Name, err)
+			unverified = append(unverified, user.Name)
+		}
+	}
+
+	if len(drifted) > 0 {
+		return metav1.Condition{
+			Status:  metav1.ConditionTrue,
+			Reason:  credentialsAuthenticationFailedReason,
+			Message: fmt.Sprintf("Users could not authenticate with the password stored in their secret: %s", strings.Join(drifted, ", ")),
+		}
+	}
+	if len(unverified) > 0 {
+		return metav1.Condition{
+			Status:  metav1.ConditionUnknown,
+			Reason:  credentialsVerificationFailedReason,
+			Message: fmt.Sprintf("Could not verify the credentials of users: %s", strings.Join(unverified, ", ")),
+		}
+	}
+	return metav1.Condition{
+		Status:  metav1.ConditionFalse,
+		Reason:  credentialsVerifiedReason,
+		Message: fmt.Sprintf("%d users authenticated successfully", verified),
+	}
+}
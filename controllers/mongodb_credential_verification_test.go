@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/auth"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// mockVerifier accepts the users whose password matches the one it is configured with.
+type mockVerifier struct {
+	passwords map[string]string
+	err       error
+	calls     int
+
+	changeStreamErr       error
+	changeStreamDatabases []string
+}
+
+func (m *mockVerifier) Verify(_ context.Context, connectionString string, _ *tls.Config) error {
+	m.calls++
+	if m.err != nil {
+		return m.err
+	}
+	uri, err := url.Parse(connectionString)
+	if err != nil {
+		return err
+	}
+	password, _ := uri.User.Password()
+	if m.passwords[uri.User.Username()] != password {
+		return &auth.Error{}
+	}
+	return nil
+}
+
+func (m *mockVerifier) VerifyChangeStreams(ctx context.Context, connectionString string, tlsConfig *tls.Config, database string) error {
+	if err := m.Verify(ctx, connectionString, tlsConfig); err != nil {
+		return err
+	}
+	m.changeStreamDatabases = append(m.changeStreamDatabases, database)
+	return m.changeStreamErr
+}
+
+func newCredentialVerificationReplicaSet() mdbv1.MongoDBCommunity {
+	mdb := newScramReplicaSet(mdbv1.MongoDBUser{
+		Name: "my-user",
+		DB:   "admin",
+		PasswordSecretRef: mdbv1.SecretKeyReference{
+			Name: "my-user-password",
+		},
+		ScramCredentialsSecretName: "my-scram",
+	})
+	mdb.Spec.Security.Authentication.CredentialVerification = &mdbv1.CredentialVerification{Interval: "30m"}
+	return mdb
+}
+
+func createUserPasswordSecret(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, password string) {
+	passwordSecret := secret.Builder().
+		SetName("my-user-password").
+		SetNamespace(mdb.Namespace).
+		SetField("password", password).
+		Build()
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &passwordSecret))
+}
+
+func TestCredentialVerificationSettings(t *testing.T) {
+	mdb := newScramReplicaSet()
+	_, _, enabled, err := credentialVerificationSettings(mdb)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	mdb.Spec.Security.Authentication.CredentialVerification = &mdbv1.CredentialVerification{}
+	interval, timeout, enabled, err := credentialVerificationSettings(mdb)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, time.Hour, interval)
+	assert.Equal(t, 10*time.Second, timeout)
+
+	mdb.Spec.Security.Authentication.CredentialVerification = &mdbv1.CredentialVerification{Interval: "-5m"}
+	_, _, _, err = credentialVerificationSettings(mdb)
+	assert.Error(t, err)
+
+	mdb.Spec.Security.Authentication.CredentialVerification = &mdbv1.CredentialVerification{Timeout: "soon"}
+	_, _, _, err = credentialVerificationSettings(mdb)
+	assert.Error(t, err)
+}
+
+func TestCredentialVerification_ReportsDrift(t *testing.T) {
+	mdb := newCredentialVerificationReplicaSet()
+	mgr := client.NewManager(&mdb)
+	createUserPasswordSecret(t, mgr, mdb, "my-password")
+	r := NewReconciler(mgr)
+	mockVerifier := &mockVerifier{passwords: map[string]string{"my-user": "my-password"}}
+	r.credentialVerifier = mockVerifier
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(30*time.Minute), float64(res.RequeueAfter), float64(time.Minute), "the next verification must be scheduled")
+	assert.Equal(t, 1, mockVerifier.calls)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.NotNil(t, mdb.Status.LastCredentialVerificationTime)
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionCredentialDrift)
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, credentialsVerifiedReason, condition.Reason)
+
+	t.Run("Credentials are not verified again before the interval has passed", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, mockVerifier.calls)
+	})
+
+	t.Run("Users which are rejected are reported", func(t *testing.T) {
+		// the members still run with the previous password, e.g. because the automation config was not applied.
+		mockVerifier.passwords["my-user"] = "previous-password"
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Status.LastCredentialVerificationTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+		assert.NoError(t, mgr.GetClient().Status().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, mockVerifier.calls)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionCredentialDrift)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, credentialsAuthenticationFailedReason, condition.Reason)
+		assert.Contains(t, condition.Message, "my-user")
+	})
+
+	t.Run("Disabling the verification removes the condition", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Security.Authentication.CredentialVerification = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Nil(t, meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionCredentialDrift))
+	})
+}
+
+func TestCredentialVerification_UnreachableMembersAreNotReportedAsDrift(t *testing.T) {
+	mdb := newCredentialVerificationReplicaSet()
+	mgr := client.NewManager(&mdb)
+	createUserPasswordSecret(t, mgr, mdb, "my-password")
+	r := NewReconciler(mgr)
+	r.credentialVerifier = &mockVerifier{err: errors.New("server selection timeout")}
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionCredentialDrift)
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.Equal(t, credentialsVerificationFailedReason, condition.Reason)
+}
+
+func TestCredentialVerification_InvalidIntervalIsRejected(t *testing.T) {
+	mdb := newCredentialVerificationReplicaSet()
+	mdb.Spec.Security.Authentication.CredentialVerification.Interval = "hourly"
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "credentialVerification.interval")
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/loglevel"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/redact"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// debugUntilAnnotation makes the operator log the reconciliations of a single resource at all levels, together
+	// with the changes of its automation config and the plans of its agents, until the given RFC 3339 time.
+	debugUntilAnnotation = "mongodbcommunity.mongodb.com/debug-until"
+
+	// logLevelAnnotation sets the level the reconciliations of a single resource are logged with, regardless of
+	// the configured levels, e.g. "debug".
+	logLevelAnnotation = "mongodbcommunity.mongodb.com/log-level"
+
+	// maxDebugWindow is how far in the future the end of a debug window can be, so that a forgotten annotation
+	// does not keep a resource logging at all levels.
+	maxDebugWindow = 24 * time.Hour
+)
+
+// debugWindowEnd returns the end of the debug window of the resource, or the zero time if the resource has no
+// debug window or it has ended.
+func debugWindowEnd(mdb mdbv1.MongoDBCommunity, now time.Time) (time.Time, error) {
+	value, ok := mdb.Annotations[debugUntilAnnotation]
+	if !ok {
+		return time.Time{}, nil
+	}
+	end, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%q is not an RFC 3339 time", value)
+	}
+	if end.Sub(now) > maxDebugWindow {
+		return time.Time{}, errors.Errorf("%s is more than %s in the future", value, maxDebugWindow)
+	}
+	if !end.After(now) {
+		return time.Time{}, nil
+	}
+	return end, nil
+}
+
+// applyLogLevel makes the logger of the reconciliation log with the level of the log level annotation of the
+// resource, if it has one.
+func (r *ReplicaSetReconciler) applyLogLevel(mdb mdbv1.MongoDBCommunity) {
+	value, ok := mdb.Annotations[logLevelAnnotation]
+	if !ok {
+		return
+	}
+	level := zapcore.InfoLevel
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		r.log.Warnf("Ignoring the %s annotation: %q is not a log level", logLevelAnnotation, value)
+		return
+	}
+	r.log = r.log.With(loglevel.Level(level))
+}
+
+// startDebugWindow makes the logger of the reconciliation log at all levels if the resource is in a debug window.
+func (r *ReplicaSetReconciler) startDebugWindow(mdb mdbv1.MongoDBCommunity, now time.Time) {
+	end, err := debugWindowEnd(mdb, now)
+	if err != nil {
+		r.log.Warnf("Ignoring the %s annotation: %s", debugUntilAnnotation, err)
+		return
+	}
+	if end.IsZero() {
+		return
+	}
+	r.debugUntil = end
+	r.log = r.log.With(loglevel.DebugUntil(end))
+}
+
+// debugging returns true if the reconciliation happens in a debug window of the resource.
+func (r ReplicaSetReconciler) debugging() bool {
+	return !r.debugUntil.IsZero()
+}
+
+// logAutomationConfigChanges logs the fields of the automation config changed by the reconciliation, with the
+// credentials redacted.
+func (r ReplicaSetReconciler) logAutomationConfigChanges(previous, current automationconfig.AutomationConfig) {
+	changes := automationConfigChanges(previous, current)
+	if len(changes) == 0 {
+		r.log.Debugf("The automation config is unchanged at version %d", current.Version)
+		return
+	}
+	r.log.Debugw("Automation config changes", "version", current.Version, "changes", changes)
+}
+
+// automationConfigChanges returns a line for each field of the automation config which differs between the
+// given versions, e.g. `processes[0].args2_6.net.port: 27017 -> 27018`.
+func automationConfigChanges(previous, current automationconfig.AutomationConfig) []string {
+	before := map[string]string{}
+	after := map[string]string{}
+	flattenJSON("", redact.Object(previous), before)
+	flattenJSON("", redact.Object(current), after)
+
+	paths := map[string]bool{}
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+	var changes []string
+	for path := range paths {
+		oldValue, hadOld := before[path]
+		newValue, hasNew := after[path]
+		if hadOld && hasNew && oldValue == newValue {
+			continue
+		}
+		if !hadOld {
+			oldValue = "<none>"
+		}
+		if !hasNew {
+			newValue = "<none>"
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, oldValue, newValue))
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// flattenJSON adds the JSON representation of each scalar, empty object and empty list in the given decoded JSON
+// value to values, by its path.
+func flattenJSON(path string, value interface{}, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			for key, child := range v {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				flattenJSON(childPath, child, values)
+			}
+			return
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			for i, child := range v {
+				flattenJSON(fmt.Sprintf("%s[%d]", path, i), child, values)
+			}
+			return
+		}
+	}
+	bytes, _ := json.Marshal(value)
+	values[path] = string(bytes)
+}
+
+// logAgentPlans logs the last readiness probe failure of each member, which reports the processes and the current
+// step of the plan of its agent.
+func (r ReplicaSetReconciler) logAgentPlans(mdb mdbv1.MongoDBCommunity) {
+	pods := corev1.PodList{}
+	if err := r.client.List(context.TODO(), &pods, k8sClient.InNamespace(mdb.Namespace), k8sClient.MatchingLabels{"app": mdb.ServiceName()}); err != nil {
+		r.log.Debugf("Could not list the Pods of the members: %s", err)
+		return
+	}
+	// Events are not watched by the operator, so they are read from the apiserver
+	events := corev1.EventList{}
+	if err := r.apiReader.List(context.TODO(), &events, k8sClient.InNamespace(mdb.Namespace)); err != nil {
+		r.log.Debugf("Could not list the Events of the members: %s", err)
+		return
+	}
+
+	for _, pod := range pods.Items {
+		var last *corev1.Event
+		for i, event := range events.Items {
+			if event.InvolvedObject.Kind != "Pod" || event.InvolvedObject.Name != pod.Name || event.Reason != "Unhealthy" {
+				continue
+			}
+			if last == nil || last.LastTimestamp.Before(&event.LastTimestamp) {
+				last = &events.Items[i]
+			}
+		}
+		if last == nil {
+			r.log.Debugf("Agent plan of %s: no readiness probe failure reported", pod.Name)
+			continue
+		}
+		r.log.Debugf("Agent plan of %s at %s: %s", pod.Name, last.LastTimestamp.UTC().Format(time.RFC3339), last.Message)
+	}
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/loglevel"
+)
+
+func TestDebugWindowEnd(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mdb := newTestReplicaSet()
+
+	end, err := debugWindowEnd(mdb, now)
+	assert.NoError(t, err)
+	assert.True(t, end.IsZero())
+
+	mdb.Annotations = map[string]string{debugUntilAnnotation: "2024-03-01T14:00:00Z"}
+	end, err = debugWindowEnd(mdb, now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Hour), end)
+
+	mdb.Annotations[debugUntilAnnotation] = "2024-03-01T11:00:00Z"
+	end, err = debugWindowEnd(mdb, now)
+	assert.NoError(t, err)
+	assert.True(t, end.IsZero(), "the debug window has ended")
+
+	mdb.Annotations[debugUntilAnnotation] = "2024-03-05T12:00:00Z"
+	_, err = debugWindowEnd(mdb, now)
+	assert.EqualError(t, err, "2024-03-05T12:00:00Z is more than 24h0m0s in the future")
+
+	mdb.Annotations[debugUntilAnnotation] = "tomorrow"
+	_, err = debugWindowEnd(mdb, now)
+	assert.EqualError(t, err, `"tomorrow" is not an RFC 3339 time`)
+}
+
+func TestAutomationConfigChanges(t *testing.T) {
+	previous := automationconfig.AutomationConfig{
+		Version:   1,
+		Processes: []automationconfig.Process{{Name: "my-rs-0", Args26: objx.New(map[string]interface{}{"net": map[string]interface{}{"port": 27017}})}},
+		Auth:      automationconfig.Auth{Key: "keyfile-contents", AutoPwd: "agent-password"},
+	}
+	current := previous
+	current.Version = 2
+	current.Processes = []automationconfig.Process{{Name: "my-rs-0", Args26: objx.New(map[string]interface{}{"net": map[string]interface{}{"port": 27018}})}}
+	current.Auth = automationconfig.Auth{Key: "rotated-keyfile-contents", AutoPwd: "agent-password", Users: []automationconfig.MongoDBUser{{Username: "app-user"}}}
+
+	changes := automationConfigChanges(previous, current)
+	assert.Contains(t, changes, "version: 1 -> 2")
+	assert.Contains(t, changes, "processes[0].args2_6.net.port: 27017 -> 27018")
+	assert.Contains(t, changes, `auth.usersWanted[0].user: <none> -> "app-user"`)
+	for _, change := range changes {
+		assert.NotContains(t, change, "keyfile-contents")
+		assert.NotContains(t, change, "agent-password")
+	}
+}
+
+func countDebugEntries(logs *observer.ObservedLogs) int {
+	count := 0
+	for _, entry := range logs.All() {
+		if entry.Level == zapcore.DebugLevel {
+			count++
+		}
+	}
+	return count
+}
+
+func TestReplicaSet_LogsAtAllLevelsDuringTheDebugWindow(t *testing.T) {
+	levels := loglevel.New(zapcore.InfoLevel)
+	observedCore, logs := observer.New(zapcore.DebugLevel)
+	defer zap.ReplaceGlobals(zap.L())
+	zap.ReplaceGlobals(zap.New(levels.WrapCore(observedCore)))
+
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.Zero(t, countDebugEntries(logs), "debug entries are dropped at the info level")
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Annotations = map[string]string{debugUntilAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+	mdb.Spec.Members = 5
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NotZero(t, countDebugEntries(logs))
+	changes := logs.FilterMessage("Automation config changes").All()
+	if assert.Len(t, changes, 1) {
+		assert.Contains(t, changes[0].ContextMap(), "debugUntil")
+		assert.NotEmpty(t, changes[0].ContextMap()["changes"])
+	}
+}
+
+func TestReplicaSet_LogsWithTheLevelOfTheAnnotation(t *testing.T) {
+	levels := loglevel.New(zapcore.InfoLevel)
+	observedCore, logs := observer.New(zapcore.DebugLevel)
+	defer zap.ReplaceGlobals(zap.L())
+	zap.ReplaceGlobals(zap.New(levels.WrapCore(observedCore)))
+
+	mdb := newTestReplicaSet()
+	mdb.Annotations = map[string]string{logLevelAnnotation: "debug"}
+	mgr := client.NewManager(&mdb)
+	res, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.NotZero(t, countDebugEntries(logs))
+
+	other := newTestReplicaSet()
+	other.Name = "other-rs"
+	logs.TakeAll()
+	mgr = client.NewManager(&other)
+	res, err = NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: other.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.Zero(t, countDebugEntries(logs), "other resources are logged with the configured levels")
+}
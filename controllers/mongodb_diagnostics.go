@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/redact"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// diagnosticsCaptureTimeout bounds the time spent capturing diagnostic data, which happens during the
+	// reconciliation which moved the resource to the Failed phase.
+	diagnosticsCaptureTimeout = 30 * time.Second
+
+	// maxDiagnosticsSize keeps the data of a capture well below the size limit of a ConfigMap.
+	maxDiagnosticsSize = 900 * 1024
+
+	// diagnosticsCaptureTimeAnnotation records when the diagnostic data of a ConfigMap was captured.
+	diagnosticsCaptureTimeAnnotation = "mongodbcommunity.mongodb.com/capture-time"
+
+	// captureDiagnosticsAnnotation requests a capture of diagnostic data, which is made whenever its value changes.
+	captureDiagnosticsAnnotation = "mongodbcommunity.mongodb.com/capture-diagnostics"
+
+	diagnosticsStatusKey           = "status.json"
+	diagnosticsAutomationConfigKey = "automation-config.json"
+	diagnosticsStatefulSetKey      = "statefulset.yaml"
+	diagnosticsEventsKey           = "events"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+
+// The health status file of the agent is only written to the filesystem of the Pod and is not served by mongod
+// or the agent, so it is read by running cat in the agent container. pods/exec is only granted by the Role of the
+// namespace of the operator, not by the cluster-wide role, and the file is reported as unreadable without it.
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+
+// reconcileDiagnostics captures diagnostic data if the reconciliation moved the resource to the Failed phase,
+// and deletes the captures which are older than their retention. Failing to capture is logged and does not
+// affect the reconciliation.
+func (r ReplicaSetReconciler) reconcileDiagnostics(mdb *mdbv1.MongoDBCommunity, previousPhase mdbv1.Phase) {
+	if mdb.Spec.Diagnostics.CapturesOnFailure() && mdb.Status.Phase == mdbv1.Failed && previousPhase != mdbv1.Failed {
+		if _, err := r.captureDiagnostics(*mdb, time.Now()); err != nil {
+			r.log.Warnf("Could not capture diagnostic data: %s", err)
+		}
+	}
+	if err := r.expireDiagnostics(*mdb, time.Now()); err != nil {
+		r.log.Warnf("Could not delete expired diagnostic data: %s", err)
+	}
+}
+
+// captureRequestedDiagnostics captures diagnostic data if the value of the capture diagnostics annotation of the
+// resource differs from the request of the most recent capture, and reports the capture in the status.
+func (r ReplicaSetReconciler) captureRequestedDiagnostics(mdb *mdbv1.MongoDBCommunity, now time.Time) {
+	request, ok := mdb.Annotations[captureDiagnosticsAnnotation]
+	if !ok || (mdb.Status.DiagnosticsCapture != nil && mdb.Status.DiagnosticsCapture.Request == request) {
+		return
+	}
+	capture := mdbv1.DiagnosticsCaptureStatus{Request: request, CaptureTime: metav1.NewTime(now)}
+	name, err := r.captureDiagnostics(*mdb, now)
+	if err != nil {
+		r.log.Warnf("Could not capture the requested diagnostic data: %s", err)
+		capture.Error = err.Error()
+	}
+	capture.ConfigMapName = name
+	mdb.Status.DiagnosticsCapture = &capture
+}
+
+// captureDiagnostics stores the status of the resource, its automation config with the credentials redacted, its
+// StatefulSet and the Events of the resource and of the StatefulSet, as well as the Pod, the last lines of the logs
+// of the containers, the health status file of the agent, the Events and the output of replSetGetStatus of each
+// member in a ConfigMap owned by the resource. Data which could not be captured is replaced by the error which
+// prevented it. It returns the name of the ConfigMap.
+func (r ReplicaSetReconciler) captureDiagnostics(mdb mdbv1.MongoDBCommunity, now time.Time) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsCaptureTimeout)
+	defer cancel()
+
+	data := map[string]string{}
+	status, err := json.MarshalIndent(mdb.Status, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	data[diagnosticsStatusKey] = string(status)
+	data[diagnosticsAutomationConfigKey] = r.redactedAutomationConfig(mdb)
+	data[diagnosticsStatefulSetKey] = r.objectYAML(ctx, mdb.StatefulSetNamespacedName(), &appsv1.StatefulSet{})
+
+	pods := corev1.PodList{}
+	if err := r.client.List(ctx, &pods, k8sClient.InNamespace(mdb.Namespace), k8sClient.MatchingLabels{"app": mdb.ServiceName()}); err != nil {
+		return "", err
+	}
+	// Events are not watched by the operator, so they are read from the apiserver
+	events := corev1.EventList{}
+	if err := r.apiReader.List(ctx, &events, k8sClient.InNamespace(mdb.Namespace)); err != nil {
+		return "", err
+	}
+
+	lines := mdb.Spec.Diagnostics.GetLogLines()
+	data[diagnosticsEventsKey] = objectEvents(events.Items, "MongoDBCommunity", mdb.Name, lines) +
+		objectEvents(events.Items, "StatefulSet", mdb.StatefulSetNamespacedName().Name, lines)
+	for i := range pods.Items {
+		pod := pods.Items[i]
+		data[pod.Name+".events"] = objectEvents(events.Items, "Pod", pod.Name, lines)
+		data[pod.Name+".pod.yaml"] = marshalObjectYAML(&pod)
+		data[pod.Name+".agent-health-status.json"] = r.agentHealthStatus(ctx, pod)
+		for _, containerName := range []string{construct.MongodbName, construct.AgentName} {
+			data[fmt.Sprintf("%s.%s.log", pod.Name, containerName)] = r.containerLogs(ctx, pod, containerName, lines, false)
+			if containerRestarted(pod, containerName) {
+				data[fmt.Sprintf("%s.%s.previous.log", pod.Name, containerName)] = r.containerLogs(ctx, pod, containerName, lines, true)
+			}
+		}
+	}
+	for pod, replSetStatus := range r.replSetStatuses(ctx, mdb) {
+		data[pod+".replSetGetStatus.json"] = replSetStatus
+	}
+
+	builder := configmap.Builder().
+		SetName(mdb.DiagnosticsConfigMapName(now)).
+		SetNamespace(mdb.Namespace).
+		SetOwnerReferences(mdb.GetOwnerReferences())
+	for key, value := range truncateDiagnostics(data, maxDiagnosticsSize) {
+		builder.SetField(key, value)
+	}
+	cm := builder.Build()
+	cm.Labels = mdb.SchemaLabels(mdbv1.ComponentDiagnostics)
+	cm.Annotations = map[string]string{diagnosticsCaptureTimeAnnotation: now.UTC().Format(time.RFC3339)}
+	if err := r.client.CreateConfigMap(cm); err != nil {
+		return "", err
+	}
+
+	r.log.Infof("Captured diagnostic data in ConfigMap %s", cm.Name)
+	if r.recorder != nil {
+		r.recorder.Eventf(&mdb, corev1.EventTypeNormal, "DiagnosticsCaptured", "Captured diagnostic data in ConfigMap %s", cm.Name)
+	}
+	return cm.Name, nil
+}
+
+// expireDiagnostics deletes the ConfigMaps storing diagnostic data which was captured longer than the retention ago.
+func (r ReplicaSetReconciler) expireDiagnostics(mdb mdbv1.MongoDBCommunity, now time.Time) error {
+	configMaps := corev1.ConfigMapList{}
+	selector := k8sClient.MatchingLabels{mdbv1.LabelResource: mdb.Name, mdbv1.LabelAppComponent: mdbv1.ComponentDiagnostics}
+	if err := r.client.List(context.TODO(), &configMaps, k8sClient.InNamespace(mdb.Namespace), selector); err != nil {
+		return err
+	}
+	for i := range configMaps.Items {
+		cm := configMaps.Items[i]
+		captureTime, err := time.Parse(time.RFC3339, cm.Annotations[diagnosticsCaptureTimeAnnotation])
+		if err != nil || now.Sub(captureTime) < mdb.Spec.Diagnostics.GetRetention() {
+			continue
+		}
+		if err := r.client.Delete(context.TODO(), &cm); err != nil {
+			return err
+		}
+		r.log.Infof("Deleted expired diagnostic data in ConfigMap %s", cm.Name)
+	}
+	return nil
+}
+
+// containerLogs returns the last lines of the log of the given container, or the error reading it.
+func (r ReplicaSetReconciler) containerLogs(ctx context.Context, pod corev1.Pod, containerName string, lines int64, previous bool) string {
+	logs, err := r.diagnostics.ContainerLogs(ctx, pod.Namespace, pod.Name, containerName, lines, previous)
+	if err != nil {
+		return fmt.Sprintf("Could not read the log: %s", err)
+	}
+	return logs
+}
+
+// agentHealthStatus returns the health status file of the agent of the given member, which reports the progress
+// of the plan of the agent, or the error reading it.
+func (r ReplicaSetReconciler) agentHealthStatus(ctx context.Context, pod corev1.Pod) string {
+	contents, err := r.diagnostics.ReadFile(ctx, pod.Namespace, pod.Name, construct.AgentName, construct.AgentHealthStatusFilePath)
+	if err != nil {
+		return fmt.Sprintf("Could not read the health status file: %s", err)
+	}
+	return contents
+}
+
+// redactedAutomationConfig returns the automation config of the resource with the credentials redacted, or the
+// error reading it.
+func (r ReplicaSetReconciler) redactedAutomationConfig(mdb mdbv1.MongoDBCommunity) string {
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		return fmt.Sprintf("Could not read the automation config: %s", err)
+	}
+	data, err := json.MarshalIndent(redact.Object(ac), "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Could not marshal the automation config: %s", err)
+	}
+	return string(data)
+}
+
+// objectYAML returns the object with the given name as YAML, or the error reading it.
+func (r ReplicaSetReconciler) objectYAML(ctx context.Context, nsName types.NamespacedName, obj k8sClient.Object) string {
+	if err := r.client.Get(ctx, nsName, obj); err != nil {
+		return fmt.Sprintf("Could not get %s: %s", nsName.Name, err)
+	}
+	return marshalObjectYAML(obj)
+}
+
+// marshalObjectYAML returns the object as YAML, without its managed fields.
+func marshalObjectYAML(obj k8sClient.Object) string {
+	obj.SetManagedFields(nil)
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Sprintf("Could not marshal %s: %s", obj.GetName(), err)
+	}
+	return string(data)
+}
+
+// replSetStatuses returns the output of replSetGetStatus of each member, or the error running it, by Pod name.
+// The operator connects as the agent, which has the privileges to run replSetGetStatus.
+func (r ReplicaSetReconciler) replSetStatuses(ctx context.Context, mdb mdbv1.MongoDBCommunity) map[string]string {
+	statuses := map[string]string{}
+	hostnames, uri, tlsConfig, err := r.agentConnection(mdb, mdb.Spec.Members)
+	if err != nil {
+		return map[string]string{mdb.Name: fmt.Sprintf("Not captured: %s", err)}
+	}
+
+	for i, hostname := range hostnames {
+		status, err := r.diagnostics.ReplSetStatus(ctx, uri(hostname), tlsConfig)
+		if err != nil {
+			status = fmt.Sprintf("Could not run replSetGetStatus: %s", err)
+		}
+		statuses[mdb.PodName(i)] = status
+	}
+	return statuses
+}
+
+// objectEvents returns the last Events of the object of the given kind and name, one per line. The Events of the
+// Pods include the failures of the readiness probe, which report the state of the agent from its health status file.
+func objectEvents(events []corev1.Event, kind, name string, lines int64) string {
+	var objectEvents []corev1.Event
+	for _, event := range events {
+		if event.InvolvedObject.Kind == kind && event.InvolvedObject.Name == name {
+			objectEvents = append(objectEvents, event)
+		}
+	}
+	sort.SliceStable(objectEvents, func(i, j int) bool {
+		return objectEvents[i].LastTimestamp.Before(&objectEvents[j].LastTimestamp)
+	})
+	if int64(len(objectEvents)) > lines {
+		objectEvents = objectEvents[int64(len(objectEvents))-lines:]
+	}
+
+	sb := strings.Builder{}
+	for _, event := range objectEvents {
+		fmt.Fprintf(&sb, "%s %s %s (x%d): %s\n", event.LastTimestamp.UTC().Format(time.RFC3339), event.Type, event.Reason, event.Count, event.Message)
+	}
+	return sb.String()
+}
+
+// containerRestarted returns true if the given container of the Pod has been restarted, in which case the log of
+// its previous instance holds the reason.
+func containerRestarted(pod corev1.Pod, containerName string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return status.RestartCount > 0
+		}
+	}
+	return false
+}
+
+// truncateDiagnostics limits the total size of the data to maxSize. Each entry gets an equal share of the size
+// which is not used by the smaller entries, and larger entries keep their end, which holds the latest lines.
+func truncateDiagnostics(data map[string]string, maxSize int) map[string]string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(data[keys[i]]) < len(data[keys[j]])
+	})
+
+	truncated := map[string]string{}
+	remaining := maxSize
+	for i, key := range keys {
+		value := data[key]
+		share := remaining / (len(keys) - i)
+		if len(value) > share {
+			value = value[len(value)-share:]
+			if newline := strings.IndexByte(value, '\n'); newline >= 0 {
+				value = value[newline+1:]
+			}
+		}
+		truncated[key] = value
+		remaining -= len(value)
+	}
+	return truncated
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// mockCollector returns logs and files naming the container they were read from, and a replSetGetStatus output
+// naming the user it connected as.
+type mockCollector struct{}
+
+func (mockCollector) ContainerLogs(_ context.Context, _, podName, containerName string, lines int64, previous bool) (string, error) {
+	return fmt.Sprintf("%d lines of %s/%s, previous: %t\n", lines, podName, containerName, previous), nil
+}
+
+func (mockCollector) ReadFile(_ context.Context, _, podName, containerName, path string) (string, error) {
+	return fmt.Sprintf("%s of %s/%s", path, podName, containerName), nil
+}
+
+func (mockCollector) ReplSetStatus(_ context.Context, connectionString string, _ *tls.Config) (string, error) {
+	uri, err := url.Parse(connectionString)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{"host": %q, "user": %q}`, uri.Host, uri.User.Username()), nil
+}
+
+func diagnosticsConfigMaps(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) []corev1.ConfigMap {
+	configMaps := corev1.ConfigMapList{}
+	selector := k8sClient.MatchingLabels{mdbv1.LabelResource: mdb.Name, mdbv1.LabelAppComponent: mdbv1.ComponentDiagnostics}
+	assert.NoError(t, mgr.GetClient().List(context.TODO(), &configMaps, k8sClient.InNamespace(mdb.Namespace), selector))
+	return configMaps.Items
+}
+
+func TestDiagnostics_AreCapturedOnFailure(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Diagnostics = &mdbv1.Diagnostics{CaptureOnFailure: true, LogLines: 50}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.diagnostics = mockCollector{}
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.Empty(t, diagnosticsConfigMaps(t, mgr, mdb), "nothing is captured while the resource does not fail")
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-rs-0", Namespace: mdb.Namespace, Labels: map[string]string{"app": mdb.ServiceName()}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "mongod", RestartCount: 2},
+			{Name: "mongodb-agent"},
+		}},
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	event := corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "my-rs-0.1234", Namespace: mdb.Namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "my-rs-0"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Unhealthy",
+		Message:        "Readiness probe failed: Agent has not reached goal state",
+		Count:          3,
+		LastTimestamp:  metav1.NewTime(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &event))
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.ServerParameters = mdbv1.MongodConfiguration{Object: map[string]interface{}{"notAServerParameter": true}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+
+	configMaps := diagnosticsConfigMaps(t, mgr, mdb)
+	if assert.Len(t, configMaps, 1) {
+		cm := configMaps[0]
+		assert.True(t, strings.HasPrefix(cm.Name, "my-rs-diagnostics-"))
+		assert.Equal(t, mdb.GetOwnerReferences(), cm.OwnerReferences)
+		assert.NotEmpty(t, cm.Annotations[diagnosticsCaptureTimeAnnotation])
+		assert.Contains(t, cm.Data[diagnosticsStatusKey], mdb.Status.Message)
+		assert.Equal(t, "50 lines of my-rs-0/mongod, previous: false\n", cm.Data["my-rs-0.mongod.log"])
+		assert.Equal(t, "50 lines of my-rs-0/mongod, previous: true\n", cm.Data["my-rs-0.mongod.previous.log"])
+		assert.Equal(t, "50 lines of my-rs-0/mongodb-agent, previous: false\n", cm.Data["my-rs-0.mongodb-agent.log"])
+		assert.NotContains(t, cm.Data, "my-rs-0.mongodb-agent.previous.log", "the agent has not been restarted")
+		assert.Equal(t, "2021-06-01T12:00:00Z Warning Unhealthy (x3): Readiness probe failed: Agent has not reached goal state\n", cm.Data["my-rs-0.events"])
+		assert.Contains(t, cm.Data["my-rs-2.replSetGetStatus.json"], `"host": "my-rs-2.my-rs-svc.my-ns.svc.cluster.local:27017"`)
+		assert.Contains(t, cm.Data["my-rs-2.replSetGetStatus.json"], `"user": "mms-automation"`)
+	}
+
+	t.Run("Diagnostics are captured once per failure", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Len(t, diagnosticsConfigMaps(t, mgr, mdb), 1)
+	})
+
+	t.Run("Diagnostics are deleted after their retention", func(t *testing.T) {
+		cm := diagnosticsConfigMaps(t, mgr, mdb)[0]
+		cm.Annotations[diagnosticsCaptureTimeAnnotation] = time.Now().Add(-25 * time.Hour).UTC().Format(time.RFC3339)
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &cm))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Empty(t, diagnosticsConfigMaps(t, mgr, mdb))
+		err = mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, &corev1.ConfigMap{})
+		assert.Error(t, err)
+	})
+}
+
+func TestDiagnostics_AreCapturedOnRequest(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.diagnostics = mockCollector{}
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	event := corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "my-rs.1234", Namespace: mdb.Namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "StatefulSet", Name: "my-rs"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FailedCreate",
+		Message:        "create Pod my-rs-1 in StatefulSet my-rs failed",
+		Count:          1,
+		LastTimestamp:  metav1.NewTime(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &event))
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.Empty(t, diagnosticsConfigMaps(t, mgr, mdb), "nothing is captured until it is requested")
+
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "my-rs-0", Namespace: mdb.Namespace, Labels: map[string]string{"app": mdb.ServiceName()}}}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Annotations = map[string]string{captureDiagnosticsAnnotation: "case-1234"}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+
+	configMaps := diagnosticsConfigMaps(t, mgr, mdb)
+	if assert.Len(t, configMaps, 1) {
+		cm := configMaps[0]
+		if assert.NotNil(t, mdb.Status.DiagnosticsCapture) {
+			assert.Equal(t, "case-1234", mdb.Status.DiagnosticsCapture.Request)
+			assert.Equal(t, cm.Name, mdb.Status.DiagnosticsCapture.ConfigMapName)
+			assert.Empty(t, mdb.Status.DiagnosticsCapture.Error)
+		}
+		assert.Contains(t, cm.Data[diagnosticsAutomationConfigKey], `"name": "my-rs-0"`)
+		assert.Contains(t, cm.Data[diagnosticsStatefulSetKey], "name: my-rs")
+		assert.Contains(t, cm.Data["my-rs-0.pod.yaml"], "name: my-rs-0")
+		assert.Equal(t, "/var/log/mongodb-mms-automation/healthstatus/agent-health-status.json of my-rs-0/mongodb-agent", cm.Data["my-rs-0.agent-health-status.json"])
+		assert.Equal(t, "2021-06-01T12:00:00Z Warning FailedCreate (x1): create Pod my-rs-1 in StatefulSet my-rs failed\n", cm.Data[diagnosticsEventsKey])
+	}
+
+	t.Run("Diagnostics are captured once per request", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		configMaps := diagnosticsConfigMaps(t, mgr, mdb)
+		if assert.Len(t, configMaps, 1) {
+			assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &configMaps[0]))
+		}
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Annotations[captureDiagnosticsAnnotation] = "case-5678"
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		_, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, "case-5678", mdb.Status.DiagnosticsCapture.Request)
+		assert.Len(t, diagnosticsConfigMaps(t, mgr, mdb), 1)
+	})
+}
+
+func TestTruncateDiagnostics(t *testing.T) {
+	data := map[string]string{
+		"small":  "abc\n",
+		"large":  "first line\nsecond line\nthird line\n",
+		"medium": "one\ntwo\n",
+	}
+	truncated := truncateDiagnostics(data, 30)
+	assert.Equal(t, "abc\n", truncated["small"], "entries below their share are kept")
+	assert.Equal(t, "one\ntwo\n", truncated["medium"])
+	assert.Equal(t, "third line\n", truncated["large"], "the end of larger entries is kept, starting at a line")
+
+	size := 0
+	for _, value := range truncated {
+		size += len(value)
+	}
+	assert.True(t, size <= 30)
+}
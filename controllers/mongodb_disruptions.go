@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/semaphore"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// MaxParallelMemberDisruptionsEnv limits how many resources may restart their members at the same time.
+// Each resource restarts one member at a time, so this is the number of member restarts in flight across
+// all resources managed by the operator. Unset or zero means unlimited.
+const MaxParallelMemberDisruptionsEnv = "MAX_PARALLEL_MEMBER_DISRUPTIONS"
+
+// ValidateEnv checks the operator level configuration read by the controller.
+func ValidateEnv() error {
+	if _, err := maxParallelMemberDisruptionsFromEnv(); err != nil {
+		return err
+	}
+	if _, err := maxConcurrentReconcilesFromEnv(); err != nil {
+		return err
+	}
+	if _, err := certificateExpiryWarningFromEnv(); err != nil {
+		return err
+	}
+	if _, err := errorBudgetWindowFromEnv(); err != nil {
+		return err
+	}
+	_, err := passwordPolicyFromEnv()
+	return err
+}
+
+func maxParallelMemberDisruptionsFromEnv() (int, error) {
+	value := os.Getenv(MaxParallelMemberDisruptionsEnv)
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, errors.Errorf("%s must be a non-negative integer, got %q", MaxParallelMemberDisruptionsEnv, value)
+	}
+	return limit, nil
+}
+
+// newDisruptionSemaphore returns the semaphore limiting the resources which restart members at the same time.
+func newDisruptionSemaphore() *semaphore.Keyed {
+	limit, _ := maxParallelMemberDisruptionsFromEnv()
+	return semaphore.NewKeyed(limit)
+}
+
+// restartsGated returns true if the operator needs permission before it restarts the members of the resource,
+// either a disruption slot, the coordination Lease, an open maintenance window, or the absence of a request to
+// pause restarts.
+func (r *ReplicaSetReconciler) restartsGated(mdb mdbv1.MongoDBCommunity) bool {
+	return r.disruptions.Limited() || mdb.IsCoordinationLeaseEnabled() || restartsPausedBy(mdb) != "" || mdb.Spec.MaintenanceWindow != nil
+}
+
+// mayRestartMembers returns true if the resource holds every permission required to restart its members.
+func (r *ReplicaSetReconciler) mayRestartMembers(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	if restartsPausedBy(mdb) != "" {
+		return false, nil
+	}
+	if open, _, err := maintenanceWindowOpen(mdb, time.Now()); !open {
+		return false, err
+	}
+	if r.disruptions.Limited() && !r.disruptions.Holds(mdb.NamespacedName()) {
+		return false, nil
+	}
+	if mdb.IsCoordinationLeaseEnabled() {
+		return r.holdsCoordinationLease(mdb, time.Now())
+	}
+	return true, nil
+}
+
+// disruptionPartitionModification pauses the rolling update of an existing StatefulSet unless the resource
+// may restart its members, so that no member is restarted before the required permissions have been acquired.
+func (r *ReplicaSetReconciler) disruptionPartitionModification(mdb mdbv1.MongoDBCommunity, exists bool) (statefulset.Modification, error) {
+	if !exists || !r.restartsGated(mdb) {
+		return statefulset.NOOP(), nil
+	}
+	mayRestart, err := r.mayRestartMembers(mdb)
+	if err != nil {
+		return statefulset.NOOP(), err
+	}
+	return func(sts *appsv1.StatefulSet) {
+		if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			return
+		}
+		partition := int32(0)
+		if !mayRestart && sts.Spec.Replicas != nil {
+			partition = *sts.Spec.Replicas
+		}
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+	}, nil
+}
+
+// releaseRestartPermissions releases the disruption slot and the coordination Lease held by the resource.
+func (r *ReplicaSetReconciler) releaseRestartPermissions(mdb mdbv1.MongoDBCommunity) error {
+	holder := mdb.NamespacedName()
+	if r.disruptions.Holds(holder) {
+		r.log.Infof("Releasing the disruption slot")
+		r.disruptions.Release(holder)
+	}
+	if mdb.IsCoordinationLeaseEnabled() {
+		if err := r.releaseCoordinationLease(mdb); err != nil {
+			return errors.Errorf("could not release the coordination Lease: %s", err)
+		}
+	}
+	return nil
+}
+
+// acquireRestartPermissions acquires the coordination Lease and a disruption slot, if they are required to restart
+// the members. It returns false while the resource is waiting for either or for the maintenance window, or restarts
+// have been paused.
+func (r *ReplicaSetReconciler) acquireRestartPermissions(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	if pausedBy := restartsPausedBy(mdb); pausedBy != "" {
+		r.log.Infof("Members need to be restarted, but restarts have been paused by %s", pausedBy)
+		return false, r.releaseRestartPermissions(mdb)
+	}
+
+	open, next, err := maintenanceWindowOpen(mdb, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if !open {
+		r.log.Infof("Members need to be restarted, waiting for the maintenance window starting at %s", next.UTC().Format(time.RFC3339))
+		return false, r.releaseRestartPermissions(mdb)
+	}
+
+	if mdb.IsCoordinationLeaseEnabled() {
+		acquired, leaseHolder, err := r.acquireCoordinationLease(mdb, time.Now())
+		if err != nil {
+			return false, err
+		}
+		if !acquired {
+			r.log.Infof("Members need to be restarted, waiting for the coordination Lease held by %s", leaseHolder)
+			return false, nil
+		}
+	}
+
+	holder := mdb.NamespacedName()
+	if r.disruptions.Limited() && !r.disruptions.Holds(holder) {
+		if !r.disruptions.TryAcquire(holder) {
+			r.log.Infof("Members need to be restarted, waiting for one of %d disruption slots to be released", r.disruptions.InUse())
+			return false, nil
+		}
+		r.log.Infof("Acquired a disruption slot, restarting members")
+	}
+	return true, nil
+}
+
+// reconcileDisruptionSlot acquires a disruption slot and the coordination Lease once the StatefulSet has members
+// to restart, resuming its rolling update, and releases them once all members run the current revision.
+// Members are not restarted while another controller has asked for restarts to be paused.
+// The returned boolean is true while the resource is waiting for permission to restart its members.
+func (r *ReplicaSetReconciler) reconcileDisruptionSlot(mdb mdbv1.MongoDBCommunity, sts appsv1.StatefulSet) (bool, error) {
+	if !r.restartsGated(mdb) {
+		return false, r.releaseRestartPermissions(mdb)
+	}
+
+	holder := mdb.NamespacedName()
+	if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return false, r.releaseRestartPermissions(mdb)
+	}
+	// the StatefulSet controller has not yet observed the latest change, so the revisions are not up to date.
+	if sts.Generation != sts.Status.ObservedGeneration {
+		return false, nil
+	}
+
+	restarting := sts.Status.UpdateRevision != "" && sts.Status.UpdateRevision != sts.Status.CurrentRevision
+	if !restarting {
+		return false, r.releaseRestartPermissions(mdb)
+	}
+
+	acquired, err := r.acquireRestartPermissions(mdb)
+	if err != nil {
+		return false, err
+	}
+	if !acquired {
+		return true, nil
+	}
+
+	// while a canary update holds, the rolling update only resumes up to the canary member.
+	partition := canaryPartition(mdb)
+	if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate == nil || rollingUpdate.Partition == nil || *rollingUpdate.Partition <= partition {
+		return false, nil
+	}
+	_, err = statefulset.GetAndUpdate(r.client, mdb.StatefulSetNamespacedName(), func(sts *appsv1.StatefulSet) {
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+	})
+	if err != nil {
+		r.disruptions.Release(holder)
+		return false, errors.Errorf("could not resume the rolling update of the StatefulSet: %s", err)
+	}
+	return false, nil
+}
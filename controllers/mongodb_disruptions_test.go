@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/semaphore"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMaxParallelMemberDisruptionsFromEnv(t *testing.T) {
+	defer os.Unsetenv(MaxParallelMemberDisruptionsEnv)
+
+	limit, err := maxParallelMemberDisruptionsFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, limit)
+
+	os.Setenv(MaxParallelMemberDisruptionsEnv, "2")
+	limit, err = maxParallelMemberDisruptionsFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, limit)
+
+	os.Setenv(MaxParallelMemberDisruptionsEnv, "-1")
+	assert.Error(t, ValidateEnv())
+
+	os.Setenv(MaxParallelMemberDisruptionsEnv, "two")
+	assert.Error(t, ValidateEnv())
+}
+
+// setStatefulSetRevisions simulates the StatefulSet controller reporting the given revisions.
+func setStatefulSetRevisions(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCommunity, current, update string) {
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &sts))
+	sts.Status.CurrentRevision = current
+	sts.Status.UpdateRevision = update
+	assert.NoError(t, c.Update(context.TODO(), &sts))
+}
+
+func assertStatefulSetPartition(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCommunity, expected int32) {
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &sts))
+	if assert.NotNil(t, sts.Spec.UpdateStrategy.RollingUpdate) {
+		assert.Equal(t, expected, *sts.Spec.UpdateStrategy.RollingUpdate.Partition)
+	}
+}
+
+func TestMemberRestarts_AreLimitedAcrossResources(t *testing.T) {
+	first := newTestReplicaSet()
+	second := newTestReplicaSet()
+	second.Name = "my-other-rs"
+
+	mgr := client.NewManager(&first)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &second))
+	r := NewReconciler(mgr)
+	r.disruptions = semaphore.NewKeyed(1)
+
+	reconcileResource := func(mdb *mdbv1.MongoDBCommunity) reconcile.Result {
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), mdb))
+		return res
+	}
+
+	reconcileResource(&first)
+	reconcileResource(&second)
+	assert.Equal(t, mdbv1.Running, first.Status.Phase)
+	assert.Equal(t, mdbv1.Running, second.Status.Phase)
+
+	// both StatefulSets now have members to restart, e.g. after the agent image has been changed.
+	reconcileResource(&first)
+	reconcileResource(&second)
+	assertStatefulSetPartition(t, mgr.GetClient(), first, 3)
+	assertStatefulSetPartition(t, mgr.GetClient(), second, 3)
+	setStatefulSetRevisions(t, mgr.GetClient(), first, "rev-1", "rev-2")
+	setStatefulSetRevisions(t, mgr.GetClient(), second, "rev-1", "rev-2")
+
+	t.Run("The first resource acquires the only slot", func(t *testing.T) {
+		reconcileResource(&first)
+		assert.Equal(t, mdbv1.Running, first.Status.Phase)
+		assertStatefulSetPartition(t, mgr.GetClient(), first, 0)
+		assert.True(t, r.disruptions.Holds(first.NamespacedName()))
+	})
+
+	t.Run("The second resource waits for the slot", func(t *testing.T) {
+		res := reconcileResource(&second)
+		assert.True(t, res.Requeue)
+		assert.Equal(t, mdbv1.Pending, second.Status.Phase)
+		assertStatefulSetPartition(t, mgr.GetClient(), second, 3)
+	})
+
+	t.Run("The slot is released once all members have been restarted", func(t *testing.T) {
+		setStatefulSetRevisions(t, mgr.GetClient(), first, "rev-2", "rev-2")
+		reconcileResource(&first)
+		assert.False(t, r.disruptions.Holds(first.NamespacedName()))
+
+		reconcileResource(&second)
+		assert.Equal(t, mdbv1.Running, second.Status.Phase)
+		assert.True(t, r.disruptions.Holds(second.NamespacedName()))
+		assertStatefulSetPartition(t, mgr.GetClient(), second, 0)
+	})
+
+	t.Run("Deleting the resource releases its slot", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &second))
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: second.NamespacedName()})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, r.disruptions.InUse())
+	})
+}
+
+func TestMemberRestarts_AreNotLimitedByDefault(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.Nil(t, sts.Spec.UpdateStrategy.RollingUpdate)
+}
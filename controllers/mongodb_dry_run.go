@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/apierrors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	admissionRejectedReason = "Rejected"
+	admissionAcceptedReason = "Accepted"
+)
+
+// admissionError is the rejection of a generated object by the API server, e.g. by a ResourceQuota on the number
+// of objects or by a validating admission webhook. The Pods are created by the StatefulSet controller, so their
+// rejections, e.g. by Pod Security admission or by a ResourceQuota on compute resources, are not reported.
+type admissionError struct {
+	kind    string
+	name    string
+	message string
+}
+
+func (e admissionError) Error() string {
+	return fmt.Sprintf("%s %s was rejected: %s", e.kind, e.name, e.message)
+}
+
+// asAdmissionError returns the error of the API server for the given object as an admissionError if the request
+// was rejected by the admission or the validation of the API server, otherwise the error itself.
+func asAdmissionError(kind, name string, err error) error {
+	if !apiErrors.IsForbidden(err) && !apiErrors.IsInvalid(err) && !apiErrors.IsBadRequest(err) {
+		return err
+	}
+	message := err.Error()
+	if statusErr, ok := err.(apiErrors.APIStatus); ok && statusErr.Status().Message != "" {
+		message = statusErr.Status().Message
+	}
+	return admissionError{kind: kind, name: name, message: message}
+}
+
+// dryRunGeneratedObjects sends the Service and the StatefulSet the reconciliation is about to create or update to
+// the API server as dry-run requests, so that objects the admission of the API server rejects are reported before
+// any of the objects of the resource is changed. It sets the AdmissionRejected condition accordingly.
+func (r *ReplicaSetReconciler) dryRunGeneratedObjects(mdb *mdbv1.MongoDBCommunity) error {
+	err := r.dryRunService(*mdb)
+	if err == nil {
+		err = r.dryRunStatefulSet(*mdb)
+	}
+	if err == nil {
+		setAdmissionRejectedCondition(mdb, metav1.ConditionFalse, admissionAcceptedReason, "The generated objects were accepted by the API server")
+		return nil
+	}
+	if admissionErr, ok := err.(admissionError); ok {
+		setAdmissionRejectedCondition(mdb, metav1.ConditionTrue, admissionRejectedReason, admissionErr.Error())
+	}
+	return err
+}
+
+// dryRunService sends the Service as a dry-run request: a create if it does not exist yet, otherwise the update
+// createOrUpdateService is about to make.
+func (r *ReplicaSetReconciler) dryRunService(mdb mdbv1.MongoDBCommunity) error {
+	svc := buildService(mdb)
+	existing, err := r.client.GetService(types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace})
+	switch {
+	case apiErrors.IsNotFound(err):
+		err = r.client.Create(context.TODO(), &svc, k8sClient.DryRunAll)
+	case err != nil:
+		return errors.Errorf("error getting Service: %s", err)
+	case existing.DeletionTimestamp != nil:
+		return nil
+	default:
+		merged := mergeService(existing, svc)
+		err = r.client.Update(context.TODO(), &merged, k8sClient.DryRunAll)
+	}
+	if err == nil {
+		return nil
+	}
+	if apierrors.IsNamespaceTerminatingError(err) {
+		return err
+	}
+	return asAdmissionError("Service", svc.Name, err)
+}
+
+// dryRunStatefulSet sends the StatefulSet as a dry-run request. Changes of immutable fields are accepted, as the
+// StatefulSet is recreated for them. A StatefulSet whose Pods are not numbered from 0 is sent as an unstructured
+// object, as it is when it is applied, so that spec.ordinals is validated as well.
+func (r *ReplicaSetReconciler) dryRunStatefulSet(mdb mdbv1.MongoDBCommunity) error {
+	set := appsv1.StatefulSet{}
+	err := r.client.Get(context.TODO(), mdb.StatefulSetNamespacedName(), &set)
+	exists := err == nil
+	if err := k8sClient.IgnoreNotFound(err); err != nil {
+		return errors.Errorf("error getting StatefulSet: %s", err)
+	}
+	if exists && set.DeletionTimestamp != nil {
+		return nil
+	}
+	if err := r.applyStatefulSetModifications(mdb, &set, exists); err != nil {
+		return err
+	}
+	var obj k8sClient.Object = &set
+	if statefulset.OrdinalsStart(set) != 0 {
+		if obj, err = statefulset.ToUnstructured(set); err != nil {
+			return err
+		}
+	}
+	if exists {
+		err = r.client.Update(context.TODO(), obj, k8sClient.DryRunAll)
+	} else {
+		err = r.client.Create(context.TODO(), obj, k8sClient.DryRunAll)
+	}
+	if err == nil || (exists && statefulset.IsImmutableFieldError(err)) {
+		return nil
+	}
+	if apierrors.IsNamespaceTerminatingError(err) {
+		return err
+	}
+	return asAdmissionError("StatefulSet", set.Name, err)
+}
+
+// setAdmissionRejectedCondition sets the AdmissionRejected condition. A condition which reports that the objects
+// were accepted is only set if an object is currently reported as rejected.
+func setAdmissionRejectedCondition(mdb *mdbv1.MongoDBCommunity, conditionStatus metav1.ConditionStatus, reason, message string) {
+	if conditionStatus == metav1.ConditionFalse && !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionAdmissionRejected) {
+		return
+	}
+	meta.SetStatusCondition(&mdb.Status.Conditions, metav1.Condition{
+		Type:               mdbv1.ConditionAdmissionRejected,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mdb.Generation,
+	})
+}
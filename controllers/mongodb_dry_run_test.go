@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// quotaClient rejects the StatefulSets while reject is set, as the API server does when a ResourceQuota on the
+// number of StatefulSets is exceeded.
+type quotaClient struct {
+	k8sClient.Client
+	reject *bool
+}
+
+func (c quotaClient) exceeded(obj k8sClient.Object) error {
+	if _, ok := obj.(*appsv1.StatefulSet); !ok || !*c.reject {
+		return nil
+	}
+	return apiErrors.NewForbidden(appsv1.Resource("statefulsets"), obj.GetName(),
+		apiErrors.NewBadRequest("exceeded quota: object-counts, requested: count/statefulsets.apps=1, used: count/statefulsets.apps=10, limited: count/statefulsets.apps=10"))
+}
+
+func (c quotaClient) Create(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.CreateOption) error {
+	if err := c.exceeded(obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c quotaClient) Update(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.UpdateOption) error {
+	if err := c.exceeded(obj); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestReplicaSet_RejectedObjectsAreReportedBeforeTheyAreApplied(t *testing.T) {
+	mdb := newTestReplicaSet()
+	c := client.NewMockedClient()
+	assert.NoError(t, c.Create(context.TODO(), &mdb))
+	reject := true
+	r := NewReconciler(client.NewManagerWithClient(quotaClient{Client: c, reject: &reject}))
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "StatefulSet my-rs was rejected: ")
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionAdmissionRejected)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "exceeded quota: object-counts")
+	}
+	err = c.Get(context.TODO(), mdb.NamespacedName(), &appsv1.StatefulSet{})
+	assert.True(t, apiErrors.IsNotFound(err), "the StatefulSet is not created")
+
+	reject = false
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.True(t, meta.IsStatusConditionFalse(mdb.Status.Conditions, mdbv1.ConditionAdmissionRejected))
+}
+
+// serviceWebhookClient rejects the updates of Services, as a validating admission webhook does.
+type serviceWebhookClient struct {
+	k8sClient.Client
+}
+
+func (c serviceWebhookClient) Update(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.UpdateOption) error {
+	if _, ok := obj.(*corev1.Service); ok {
+		return apiErrors.NewForbidden(corev1.Resource("services"), obj.GetName(),
+			apiErrors.NewBadRequest(`admission webhook "validation.gatekeeper.sh" denied the request: the Service must not change`))
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestDryRunService_SendsTheUpdateOfAnExistingService(t *testing.T) {
+	mdb := newTestReplicaSet()
+	c := client.NewMockedClient()
+	assert.NoError(t, c.Create(context.TODO(), &mdb))
+	r := NewReconciler(client.NewManagerWithClient(c))
+	assert.NoError(t, r.dryRunService(mdb))
+
+	assert.NoError(t, r.ensureService(mdb))
+	r = NewReconciler(client.NewManagerWithClient(serviceWebhookClient{Client: c}))
+	err := r.dryRunService(mdb)
+	if assert.IsType(t, admissionError{}, err) {
+		assert.Contains(t, err.Error(), "Service my-rs-svc was rejected: ")
+		assert.Contains(t, err.Error(), "validation.gatekeeper.sh")
+	}
+}
+
+// dryRunRecorder records the objects sent as dry-run requests.
+type dryRunRecorder struct {
+	k8sClient.Client
+	objects *[]k8sClient.Object
+}
+
+func (c dryRunRecorder) Create(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.CreateOption) error {
+	createOpts := k8sClient.CreateOptions{}
+	createOpts.ApplyOptions(opts)
+	if len(createOpts.DryRun) > 0 {
+		*c.objects = append(*c.objects, obj)
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestDryRunStatefulSet_SendsTheOrdinals(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MemberOrdinalStart = 3
+	c := client.NewMockedClient()
+	assert.NoError(t, c.Create(context.TODO(), &mdb))
+	var objects []k8sClient.Object
+	r := NewReconciler(client.NewManagerWithClient(dryRunRecorder{Client: c, objects: &objects}))
+
+	assert.NoError(t, r.dryRunStatefulSet(mdb))
+	if assert.Len(t, objects, 1) {
+		u, ok := objects[0].(*unstructured.Unstructured)
+		if assert.True(t, ok, "the StatefulSet is sent as an unstructured object") {
+			start, _, err := unstructured.NestedInt64(u.Object, "spec", "ordinals", "start")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), start)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// ErrorBudgetWindowEnv is the length of the rolling window over which the time the resources spend outside
+	// the Running phase is reported, as a Go duration such as "168h". Defaults to 30 days.
+	ErrorBudgetWindowEnv = "ERROR_BUDGET_WINDOW"
+
+	defaultErrorBudgetWindow = 30 * 24 * time.Hour
+
+	// maxNonRunningPeriods limits the periods kept in the status. Once it is exceeded, the two oldest periods
+	// are merged, which counts the Running time between them as not Running.
+	maxNonRunningPeriods = 50
+)
+
+// nonRunningRatio exposes the fraction of the error budget window the resources spent outside the Running phase.
+var nonRunningRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "mongodbcommunity_non_running_ratio",
+	Help: "The fraction of the rolling error budget window a MongoDBCommunity resource spent in a phase other than Running.",
+}, []string{metricLabelNamespace, metricLabelName})
+
+func init() {
+	metrics.Registry.MustRegister(nonRunningRatio)
+}
+
+func errorBudgetWindowFromEnv() (time.Duration, error) {
+	value := os.Getenv(ErrorBudgetWindowEnv)
+	if value == "" {
+		return defaultErrorBudgetWindow, nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return 0, errors.Errorf("%s must be a positive duration, got %q", ErrorBudgetWindowEnv, value)
+	}
+	return window, nil
+}
+
+// updateErrorBudget records the phase the resource is moved to at the given time in its error budget, and
+// drops the periods which ended before the window.
+func updateErrorBudget(budget *mdbv1.ErrorBudgetStatus, phase mdbv1.Phase, now time.Time, window time.Duration) *mdbv1.ErrorBudgetStatus {
+	if budget == nil {
+		budget = &mdbv1.ErrorBudgetStatus{ObservedSince: metav1.NewTime(now)}
+	} else {
+		budget = budget.DeepCopy()
+	}
+	budget.Window = metav1.Duration{Duration: window}
+	windowStart := now.Add(-window)
+	if budget.ObservedSince.Time.Before(windowStart) {
+		budget.ObservedSince = metav1.NewTime(windowStart)
+	}
+
+	var periods []mdbv1.NonRunningPeriod
+	for _, period := range budget.Periods {
+		if period.End == nil || period.End.Time.After(windowStart) {
+			periods = append(periods, period)
+		}
+	}
+
+	open := len(periods) > 0 && periods[len(periods)-1].End == nil
+	switch {
+	case phase != mdbv1.Running && !open:
+		periods = append(periods, mdbv1.NonRunningPeriod{Start: metav1.NewTime(now)})
+	case phase == mdbv1.Running && open:
+		end := metav1.NewTime(now)
+		periods[len(periods)-1].End = &end
+	}
+	for len(periods) > maxNonRunningPeriods {
+		periods[1].Start = periods[0].Start
+		periods = periods[1:]
+	}
+	budget.Periods = periods
+
+	nonRunning, ratio := measureErrorBudget(*budget, now)
+	budget.NonRunningDuration = metav1.Duration{Duration: nonRunning}
+	budget.NonRunningRatio = strconv.FormatFloat(ratio, 'f', 6, 64)
+	return budget
+}
+
+// measureErrorBudget returns how long the resource was not Running since the start of the observed part of
+// the window, and which fraction of that part this is.
+func measureErrorBudget(budget mdbv1.ErrorBudgetStatus, now time.Time) (time.Duration, float64) {
+	since := budget.ObservedSince.Time
+	if windowStart := now.Add(-budget.Window.Duration); since.Before(windowStart) {
+		since = windowStart
+	}
+	var nonRunning time.Duration
+	for _, period := range budget.Periods {
+		start, end := period.Start.Time, now
+		if period.End != nil && period.End.Time.Before(now) {
+			end = period.End.Time
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(start) {
+			nonRunning += end.Sub(start)
+		}
+	}
+	observed := now.Sub(since)
+	if observed <= 0 {
+		return nonRunning, 0
+	}
+	return nonRunning, float64(nonRunning) / float64(observed)
+}
+
+// observeErrorBudget records the fraction of the window the resource spent outside the Running phase in the
+// error budget metric.
+func observeErrorBudget(mdb *mdbv1.MongoDBCommunity) {
+	if mdb.Status.ErrorBudget == nil {
+		return
+	}
+	_, ratio := measureErrorBudget(*mdb.Status.ErrorBudget, time.Now())
+	nonRunningRatio.WithLabelValues(mdb.Namespace, mdb.Name).Set(ratio)
+}
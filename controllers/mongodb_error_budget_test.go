@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestErrorBudgetWindowFromEnv(t *testing.T) {
+	defer os.Unsetenv(ErrorBudgetWindowEnv)
+
+	window, err := errorBudgetWindowFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultErrorBudgetWindow, window)
+
+	os.Setenv(ErrorBudgetWindowEnv, "168h")
+	window, err = errorBudgetWindowFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, window)
+
+	os.Setenv(ErrorBudgetWindowEnv, "0s")
+	assert.Error(t, ValidateEnv())
+}
+
+func TestUpdateErrorBudget(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := 10 * time.Hour
+
+	budget := updateErrorBudget(nil, mdbv1.Pending, start, window)
+	assert.Equal(t, start, budget.ObservedSince.Time)
+	assert.Len(t, budget.Periods, 1)
+	assert.Equal(t, "0.000000", budget.NonRunningRatio)
+
+	t.Run("The time until the resource is Running is counted", func(t *testing.T) {
+		budget = updateErrorBudget(budget, mdbv1.Running, start.Add(time.Hour), window)
+		assert.Len(t, budget.Periods, 1)
+		assert.NotNil(t, budget.Periods[0].End)
+		assert.Equal(t, time.Hour, budget.NonRunningDuration.Duration)
+		assert.Equal(t, "1.000000", budget.NonRunningRatio)
+
+		budget = updateErrorBudget(budget, mdbv1.Running, start.Add(4*time.Hour), window)
+		assert.Equal(t, "0.250000", budget.NonRunningRatio)
+	})
+
+	t.Run("An open period is counted until now", func(t *testing.T) {
+		budget = updateErrorBudget(budget, mdbv1.Failed, start.Add(8*time.Hour), window)
+		budget = updateErrorBudget(budget, mdbv1.Pending, start.Add(10*time.Hour), window)
+		assert.Len(t, budget.Periods, 2)
+		assert.Nil(t, budget.Periods[1].End)
+		assert.Equal(t, 3*time.Hour, budget.NonRunningDuration.Duration)
+		assert.Equal(t, "0.300000", budget.NonRunningRatio)
+	})
+
+	t.Run("Periods which ended before the window are dropped", func(t *testing.T) {
+		budget = updateErrorBudget(budget, mdbv1.Running, start.Add(12*time.Hour), window)
+		assert.Equal(t, start.Add(2*time.Hour), budget.ObservedSince.Time)
+		assert.Len(t, budget.Periods, 1)
+		assert.Equal(t, 4*time.Hour, budget.NonRunningDuration.Duration)
+		assert.Equal(t, "0.400000", budget.NonRunningRatio)
+	})
+
+	t.Run("The oldest periods are merged once there are too many", func(t *testing.T) {
+		now := start.Add(12 * time.Hour)
+		for i := 0; i < maxNonRunningPeriods; i++ {
+			now = now.Add(time.Second)
+			budget = updateErrorBudget(budget, mdbv1.Pending, now, window)
+			now = now.Add(time.Second)
+			budget = updateErrorBudget(budget, mdbv1.Running, now, window)
+		}
+		assert.Len(t, budget.Periods, maxNonRunningPeriods)
+		assert.Equal(t, start.Add(8*time.Hour), budget.Periods[0].Start.Time)
+	})
+}
+
+func TestErrorBudget_IsReportedInTheStatusAndMetric(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotNil(t, mdb.Status.ErrorBudget)
+	assert.Equal(t, defaultErrorBudgetWindow, mdb.Status.ErrorBudget.Window.Duration)
+	assert.Empty(t, mdb.Status.ErrorBudget.Periods)
+	assert.Equal(t, float64(0), testutil.ToFloat64(nonRunningRatio.WithLabelValues(mdb.Namespace, mdb.Name)))
+}
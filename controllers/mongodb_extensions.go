@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ExtensionPoint is a step of the reconciliation at which the registered extensions are run.
+type ExtensionPoint string
+
+const (
+	// AfterTLS runs the extensions once the TLS resources of the replica set have been created.
+	AfterTLS ExtensionPoint = "AfterTLS"
+
+	// BeforeAutomationConfig runs the extensions right before the automation config is published
+	// and the StatefulSet is updated.
+	BeforeAutomationConfig ExtensionPoint = "BeforeAutomationConfig"
+)
+
+// Extension is a step added to the reconciliation by builds of the operator which embed this package. It
+// returns false if the reconciliation must wait, in which case the resource is reconciled again in 10
+// seconds. An error moves the resource to the Failed phase.
+type Extension func(client kubernetesClient.Client, mdb mdbv1.MongoDBCommunity) (bool, error)
+
+type registeredExtension struct {
+	name      string
+	extension Extension
+}
+
+var (
+	extensionsMu sync.RWMutex
+	extensions   = map[ExtensionPoint][]registeredExtension{}
+)
+
+// RegisterExtension adds an extension to the given extension point. The extensions of an extension point
+// are run in the order in which they were registered. Extensions must be registered before the manager
+// is started.
+func RegisterExtension(point ExtensionPoint, name string, extension Extension) {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	extensions[point] = append(extensions[point], registeredExtension{name: name, extension: extension})
+}
+
+// registeredExtensions returns the extensions registered at the given extension point.
+func registeredExtensions(point ExtensionPoint) []registeredExtension {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	return append([]registeredExtension(nil), extensions[point]...)
+}
+
+// runExtensions runs the extensions registered at the given extension point. It returns true if the
+// reconciliation can continue, otherwise the status of the resource has been updated and the
+// returned result ends the reconciliation.
+func (r ReplicaSetReconciler) runExtensions(point ExtensionPoint, mdb *mdbv1.MongoDBCommunity) (bool, reconcile.Result, error) {
+	for _, ext := range registeredExtensions(point) {
+		span := r.startSpan("Extension " + ext.name)
+		done, err := ext.extension(r.client, *mdb)
+		endSpan(span, err)
+		if err != nil {
+			res, err := status.Update(r.client.Status(), mdb,
+				statusOptions().
+					withMessage(Error, fmt.Sprintf("Error running extension %s: %s", ext.name, err)).
+					withFailedPhase(),
+			)
+			return false, res, err
+		}
+		if !done {
+			res, err := status.Update(r.client.Status(), mdb,
+				statusOptions().
+					withMessage(Info, fmt.Sprintf("Waiting for extension %s, retrying in 10 seconds", ext.name)).
+					withPendingPhase(10),
+			)
+			return false, res, err
+		}
+	}
+	return true, reconcile.Result{}, nil
+}
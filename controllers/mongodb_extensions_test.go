@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// registerTestExtension registers an extension which is removed again at the end of the test.
+func registerTestExtension(t *testing.T, point ExtensionPoint, name string, extension Extension) {
+	RegisterExtension(point, name, extension)
+	t.Cleanup(func() {
+		extensionsMu.Lock()
+		defer extensionsMu.Unlock()
+		delete(extensions, point)
+	})
+}
+
+func TestExtensions_AreRunInOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Extension {
+		return func(client.Client, mdbv1.MongoDBCommunity) (bool, error) {
+			calls = append(calls, name)
+			return true, nil
+		}
+	}
+	registerTestExtension(t, BeforeAutomationConfig, "second", record("second"))
+	registerTestExtension(t, AfterTLS, "first", record("first"))
+	registerTestExtension(t, BeforeAutomationConfig, "third", record("third"))
+
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	res, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.Equal(t, []string{"first", "second", "third"}, calls)
+}
+
+func TestExtensions_BlockTheAutomationConfig(t *testing.T) {
+	done, failure := false, error(nil)
+	registerTestExtension(t, BeforeAutomationConfig, "approval", func(client.Client, mdbv1.MongoDBCommunity) (bool, error) {
+		return done, failure
+	})
+
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, "Waiting for extension approval, retrying in 10 seconds", mdb.Status.Message)
+	assert.Error(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &appsv1.StatefulSet{}), "the StatefulSet is not created")
+
+	failure = errors.New("denied")
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Equal(t, "Error running extension approval: denied", mdb.Status.Message)
+
+	done, failure = true, nil
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+}
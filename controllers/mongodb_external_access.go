@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// externalAddressInterval is how often the Services are checked while a member has no external address yet.
+const externalAddressInterval = 10 * time.Second
+
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;create;update;delete
+
+// validateExternalAccess checks that the horizon of the external addresses is not also configured in
+// spec.replicaSetHorizons, and that no port is configured for members running in the network of their nodes.
+func validateExternalAccess(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.ExternalAccess == nil {
+		return nil
+	}
+	if host := mdb.Spec.ExternalAccess.Host; host != nil && host.HostNetwork && host.Port != 0 && host.Port != automationconfig.DefaultDBPort {
+		return errors.Errorf("externalAccess.host.port can not be %d with hostNetwork, as mongod listens on port %d of the node", host.Port, automationconfig.DefaultDBPort)
+	}
+	horizon := mdb.Spec.ExternalAccess.GetHorizonName()
+	for i, horizons := range mdb.Spec.ReplicaSetHorizons {
+		if _, ok := horizons[horizon]; ok {
+			return errors.Errorf("replicaSetHorizons[%d] can not contain the horizon %s of externalAccess", i, horizon)
+		}
+	}
+	return nil
+}
+
+// ensureExternalAccessServices creates or updates the Service of each member, and deletes the Services of members
+// which have been removed, or all of them once external access is disabled or the members are exposed on their
+// nodes.
+func (r ReplicaSetReconciler) ensureExternalAccessServices(mdb mdbv1.MongoDBCommunity) error {
+	desired := map[string]bool{}
+	if mdb.Spec.ExternalAccess.UsesServices() {
+		for i := 0; i < mdb.StatefulSetReplicasThisReconciliation(); i++ {
+			svc := buildExternalService(mdb, i)
+			desired[svc.Name] = true
+			if err := r.createOrUpdateService(svc); err != nil {
+				return err
+			}
+		}
+	}
+
+	services := corev1.ServiceList{}
+	selector := k8sClient.MatchingLabels{
+		mdbv1.LabelResource:     mdb.Name,
+		mdbv1.LabelAppComponent: mdbv1.ComponentExternalAccess,
+	}
+	if err := r.client.List(context.TODO(), &services, k8sClient.InNamespace(mdb.Namespace), selector); err != nil {
+		return errors.Errorf("could not list the external Services: %s", err)
+	}
+	for i := range services.Items {
+		if desired[services.Items[i].Name] {
+			continue
+		}
+		r.log.Infof("Deleting Service %s, whose member is not exposed anymore", services.Items[i].Name)
+		if err := r.client.Delete(context.TODO(), &services.Items[i]); err != nil && !apiErrors.IsNotFound(err) {
+			return errors.Errorf("could not delete Service %s: %s", services.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// buildExternalService returns the Service exposing the member with the given index outside of the cluster.
+func buildExternalService(mdb mdbv1.MongoDBCommunity, index int) corev1.Service {
+	annotations := map[string]string{}
+	for k, v := range mdb.Spec.ExternalAccess.Annotations {
+		annotations[k] = v
+	}
+	svc := service.Builder().
+		SetName(mdb.ExternalServiceName(index)).
+		SetNamespace(mdb.Namespace).
+		SetSelector(map[string]string{appsv1.StatefulSetPodNameLabel: mdb.PodName(index)}).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentExternalAccess)).
+		SetAnnotations(annotations).
+		SetServiceType(mdb.Spec.ExternalAccess.GetType()).
+		SetPortName("mongodb").
+		SetPort(automationconfig.DefaultDBPort).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return withServiceMeshAppProtocol(mdb, withIPFamilies(mdb, svc), mongodAppProtocol)
+}
+
+// externalAddresses returns the addresses of the Services of the first n members, in the order of the members.
+// A member is left out until its Service has an address: a LoadBalancer Service once its load balancer has been
+// provisioned, a NodePort Service once the Pod of the member has been scheduled. The address of a NodePort
+// Service is the address of the node the member runs on, see nodeAddress. Members exposed on their nodes use the
+// address of the node as well, with the configured port.
+func (r ReplicaSetReconciler) externalAddresses(mdb mdbv1.MongoDBCommunity, n int) ([]mdbv1.ExternalAddress, error) {
+	if mdb.Spec.ExternalAccess == nil {
+		return nil, nil
+	}
+
+	var addresses []mdbv1.ExternalAddress
+	for i := 0; i < n; i++ {
+		if host := mdb.Spec.ExternalAccess.Host; host != nil {
+			address, err := r.nodeAddress(mdb, mdb.PodName(i))
+			if err != nil {
+				return nil, err
+			}
+			if address != "" {
+				addresses = append(addresses, mdbv1.ExternalAddress{Name: mdb.PodName(i), Address: net.JoinHostPort(address, strconv.Itoa(int(host.GetPort())))})
+			}
+			continue
+		}
+
+		svc, err := r.client.GetService(types.NamespacedName{Name: mdb.ExternalServiceName(i), Namespace: mdb.Namespace})
+		if apiErrors.IsNotFound(err) || (err == nil && len(svc.Spec.Ports) == 0) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Errorf("could not get Service %s: %s", mdb.ExternalServiceName(i), err)
+		}
+
+		host, port := "", int32(0)
+		switch svc.Spec.Type {
+		case corev1.ServiceTypeLoadBalancer:
+			host = loadBalancerAddress(svc)
+			port = svc.Spec.Ports[0].Port
+		case corev1.ServiceTypeNodePort:
+			if host, err = r.nodeAddress(mdb, mdb.PodName(i)); err != nil {
+				return nil, err
+			}
+			port = svc.Spec.Ports[0].NodePort
+		}
+		if host == "" || port == 0 {
+			continue
+		}
+		addresses = append(addresses, mdbv1.ExternalAddress{Name: mdb.PodName(i), Address: net.JoinHostPort(host, strconv.Itoa(int(port)))})
+	}
+	return addresses, nil
+}
+
+// loadBalancerAddress returns the address of the load balancer of the given Service. A hostname of any of its
+// ingress points is preferred over an IP, as only DNS names can select a horizon.
+func loadBalancerAddress(svc corev1.Service) string {
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+	}
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+	}
+	return ""
+}
+
+// buildHostAccessPodSpecModification runs the members in the network of their nodes, or maps the port of mongod
+// to a port of the node, and schedules the members on distinct nodes, as they would use the same port otherwise.
+// The settings are reverted once the members are not exposed on their nodes anymore, while settings of the
+// StatefulSet override are applied afterwards and take precedence.
+func buildHostAccessPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	selector := map[string]string{"app": mdb.ServiceName()}
+	return func(template *corev1.PodTemplateSpec) {
+		var host *mdbv1.HostAccess
+		if mdb.Spec.ExternalAccess != nil {
+			host = mdb.Spec.ExternalAccess.Host
+		}
+		template.Spec.HostNetwork = host != nil && host.HostNetwork
+		if template.Spec.HostNetwork {
+			// the members still resolve the hostnames of the other members with the DNS of the cluster.
+			template.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+		} else if template.Spec.DNSPolicy == corev1.DNSClusterFirstWithHostNet {
+			template.Spec.DNSPolicy = corev1.DNSClusterFirst
+		}
+
+		if mongod := podtemplatespec.FindContainerByName(construct.MongodbName, template); mongod != nil {
+			var ports []corev1.ContainerPort
+			for _, port := range mongod.Ports {
+				if port.Name != mongodbPortName {
+					ports = append(ports, port)
+				}
+			}
+			if host != nil && !host.HostNetwork {
+				ports = append(ports, corev1.ContainerPort{
+					Name:          mongodbPortName,
+					ContainerPort: automationconfig.DefaultDBPort,
+					HostPort:      host.GetPort(),
+					Protocol:      corev1.ProtocolTCP,
+				})
+			}
+			mongod.Ports = ports
+		}
+
+		var terms []corev1.PodAffinityTerm
+		if template.Spec.Affinity != nil && template.Spec.Affinity.PodAntiAffinity != nil {
+			for _, term := range template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+				if !isHostAccessAntiAffinityTerm(term, selector) {
+					terms = append(terms, term)
+				}
+			}
+		}
+		if host != nil {
+			terms = append(terms, corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: selector},
+				TopologyKey:   corev1.LabelHostname,
+			})
+		}
+		if len(terms) == 0 && (template.Spec.Affinity == nil || template.Spec.Affinity.PodAntiAffinity == nil) {
+			return
+		}
+		if template.Spec.Affinity == nil {
+			template.Spec.Affinity = &corev1.Affinity{}
+		}
+		if template.Spec.Affinity.PodAntiAffinity == nil {
+			template.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = terms
+	}
+}
+
+func isHostAccessAntiAffinityTerm(term corev1.PodAffinityTerm, selector map[string]string) bool {
+	return term.TopologyKey == corev1.LabelHostname && len(term.Namespaces) == 0 && term.LabelSelector != nil &&
+		len(term.LabelSelector.MatchExpressions) == 0 && reflect.DeepEqual(term.LabelSelector.MatchLabels, selector)
+}
+
+// nodeAddress returns the address of the node the Pod with the given name runs on, or an empty address if the
+// Pod is not scheduled. The external address of the node is preferred over its internal one, and a DNS name over
+// an IP, as only DNS names can select a horizon.
+func (r ReplicaSetReconciler) nodeAddress(mdb mdbv1.MongoDBCommunity, podName string) (string, error) {
+	node, err := r.nodeOfPod(mdb.Namespace, podName)
+	if err != nil || node == nil {
+		return "", err
+	}
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalDNS, corev1.NodeExternalIP, corev1.NodeInternalDNS, corev1.NodeInternalIP} {
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType {
+				return address.Address, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// externalAccessModification adds the external address of each member to its horizons, under the horizon name
+// of spec.externalAccess. Every member of a replica set must define the same horizons, so the horizon is only
+// added once each member has an external address which is a DNS name. MongoDB selects the horizon with the SNI of
+// the TLS connection, which clients don't send when connecting to an IP address.
+func externalAccessModification(mdb mdbv1.MongoDBCommunity, addresses []mdbv1.ExternalAddress) automationconfig.Modification {
+	if len(addresses) == 0 {
+		return automationconfig.NOOP()
+	}
+	byMember := map[string]string{}
+	for _, address := range addresses {
+		if host, _, err := net.SplitHostPort(address.Address); err == nil && net.ParseIP(host) != nil {
+			return automationconfig.NOOP()
+		}
+		byMember[address.Name] = address.Address
+	}
+	horizon := mdb.Spec.ExternalAccess.GetHorizonName()
+
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.ReplicaSets {
+			rs := &config.ReplicaSets[i]
+			for _, member := range rs.Members {
+				if _, ok := byMember[member.Host]; !ok {
+					return
+				}
+			}
+			for j := range rs.Members {
+				// the horizons of spec.replicaSetHorizons are copied, as they are shared with the spec.
+				horizons := automationconfig.ReplicaSetHorizons{horizon: byMember[rs.Members[j].Host]}
+				for name, address := range rs.Members[j].Horizons {
+					horizons[name] = address
+				}
+				rs.Members[j].Horizons = horizons
+			}
+		}
+	}
+}
+
+// externalHosts returns the "<host>:<port>" of the given external addresses.
+func externalHosts(addresses []mdbv1.ExternalAddress) []string {
+	hosts := make([]string, len(addresses))
+	for i, address := range addresses {
+		hosts[i] = address.Address
+	}
+	return hosts
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func TestExternalAccess_ExposesEachMember(t *testing.T) {
+	mdb := newScramReplicaSet(mdbv1.MongoDBUser{
+		Name:                       "my-user",
+		DB:                         "admin",
+		PasswordSecretRef:          mdbv1.SecretKeyReference{Name: "my-user-password"},
+		ScramCredentialsSecretName: "my-scram",
+	})
+	mdb.Spec.ExternalAccess = &mdbv1.ExternalAccess{Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"}}
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, generatePasswordsForAllUsers(mdb, mgr.Client))
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.Equal(t, externalAddressInterval, res.RequeueAfter, "the Services are checked until the load balancers are provisioned")
+	for i := 0; i < 3; i++ {
+		svc, err := mgr.Client.GetService(types.NamespacedName{Name: fmt.Sprintf("my-rs-%d-external", i), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Equal(t, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+		assert.Equal(t, map[string]string{appsv1.StatefulSetPodNameLabel: mdb.PodName(i)}, svc.Spec.Selector)
+		assert.Equal(t, "nlb", svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"])
+		assert.Equal(t, int32(27017), svc.Spec.Ports[0].Port)
+
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: fmt.Sprintf("203.0.113.%d", i)}, {Hostname: fmt.Sprintf("lb-%d.example.com", i)}}
+		assert.NoError(t, mgr.Client.UpdateService(svc))
+	}
+
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, automationconfig.ReplicaSetHorizons{"external": fmt.Sprintf("lb-%d.example.com:27017", i)}, ac.ReplicaSets[0].Members[i].Horizons)
+	}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, []mdbv1.ExternalAddress{
+		{Name: "my-rs-0", Address: "lb-0.example.com:27017"},
+		{Name: "my-rs-1", Address: "lb-1.example.com:27017"},
+		{Name: "my-rs-2", Address: "lb-2.example.com:27017"},
+	}, mdb.Status.ExternalAddresses)
+	data, err := secret.ReadStringData(mgr.Client, types.NamespacedName{Name: "my-rs-admin-my-user", Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Contains(t, data[connectionStringExternalKey], "@lb-0.example.com:27017,lb-1.example.com:27017,lb-2.example.com:27017/admin?authSource=admin&replicaSet=my-rs&tls=false")
+
+	t.Run("Load balancers with IP addresses only are not added to the horizon", func(t *testing.T) {
+		svc, err := mgr.Client.GetService(types.NamespacedName{Name: "my-rs-1-external", Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.1"}}
+		assert.NoError(t, mgr.Client.UpdateService(svc))
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, ac.ReplicaSets[0].Members[i].Horizons)
+		}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, "203.0.113.1:27017", mdb.Status.ExternalAddresses[1].Address)
+	})
+
+	t.Run("Disabling external access deletes the Services", func(t *testing.T) {
+		mdb.Spec.ExternalAccess = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+		for i := 0; i < 3; i++ {
+			_, err := mgr.Client.GetService(types.NamespacedName{Name: fmt.Sprintf("my-rs-%d-external", i), Namespace: mdb.Namespace})
+			assert.True(t, apiErrors.IsNotFound(err))
+		}
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Nil(t, ac.ReplicaSets[0].Members[0].Horizons)
+		data, err := secret.ReadStringData(mgr.Client, types.NamespacedName{Name: "my-rs-admin-my-user", Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.NotContains(t, data, connectionStringExternalKey)
+	})
+}
+
+func TestExternalAddresses_NodePort(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ExternalAccess = &mdbv1.ExternalAccess{Type: corev1.ServiceTypeNodePort}
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	nodeAddresses := map[string][]corev1.NodeAddress{
+		"node-zone-a": {{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}, {Type: corev1.NodeExternalIP, Address: "203.0.113.1"}},
+		"node-zone-b": {{Type: corev1.NodeInternalIP, Address: "fd00::2"}},
+	}
+	for name, addresses := range nodeAddresses {
+		node := corev1.Node{}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: name}, &node))
+		node.Status.Addresses = addresses
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &node))
+	}
+
+	r := NewReconciler(mgr)
+	assert.NoError(t, r.ensureExternalAccessServices(mdb))
+	for i := 0; i < 3; i++ {
+		svc, err := mgr.Client.GetService(types.NamespacedName{Name: mdb.ExternalServiceName(i), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Equal(t, corev1.ServiceTypeNodePort, svc.Spec.Type)
+		svc.Spec.Ports[0].NodePort = int32(30017 + i)
+		assert.NoError(t, mgr.Client.UpdateService(svc))
+	}
+
+	addresses, err := r.externalAddresses(mdb, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []mdbv1.ExternalAddress{
+		{Name: "my-rs-0", Address: "203.0.113.1:30017"},
+		{Name: "my-rs-1", Address: "[fd00::2]:30018"},
+	}, addresses, "the member whose node has no address is left out")
+
+	t.Run("The node port is kept when the Services are updated", func(t *testing.T) {
+		assert.NoError(t, r.ensureExternalAccessServices(mdb))
+		svc, err := mgr.Client.GetService(types.NamespacedName{Name: mdb.ExternalServiceName(0), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(30017), svc.Spec.Ports[0].NodePort)
+	})
+}
+
+func TestExternalAccess_ExposesTheMembersOnTheirNodes(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ExternalAccess = &mdbv1.ExternalAccess{Host: &mdbv1.HostAccess{Port: 37017}}
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	nodeAddresses := map[string][]corev1.NodeAddress{
+		"node-zone-a": {{Type: corev1.NodeInternalIP, Address: "192.0.2.1"}},
+		"node-zone-b": {{Type: corev1.NodeExternalIP, Address: "203.0.113.2"}, {Type: corev1.NodeExternalDNS, Address: "node-b.example.com"}},
+	}
+	for name, addresses := range nodeAddresses {
+		node := corev1.Node{}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: name}, &node))
+		node.Status.Addresses = addresses
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &node))
+	}
+
+	r := NewReconciler(mgr)
+	assert.NoError(t, r.ensureExternalAccessServices(mdb))
+	_, err := mgr.Client.GetService(types.NamespacedName{Name: mdb.ExternalServiceName(0), Namespace: mdb.Namespace})
+	assert.True(t, apiErrors.IsNotFound(err), "no Services are created for members exposed on their nodes")
+
+	addresses, err := r.externalAddresses(mdb, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []mdbv1.ExternalAddress{
+		{Name: "my-rs-0", Address: "192.0.2.1:37017"},
+		{Name: "my-rs-1", Address: "node-b.example.com:37017"},
+	}, addresses, "the DNS name of a node is preferred over its IP")
+
+	sts, err := buildStatefulSet(mdb)
+	assert.NoError(t, err)
+	assert.False(t, sts.Spec.Template.Spec.HostNetwork)
+	mongod := podtemplatespec.FindContainerByName(construct.MongodbName, &sts.Spec.Template)
+	assert.Equal(t, []corev1.ContainerPort{{Name: "mongodb", ContainerPort: 27017, HostPort: 37017, Protocol: corev1.ProtocolTCP}}, mongod.Ports)
+	terms := sts.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	assert.Len(t, terms, 1)
+	assert.Equal(t, "kubernetes.io/hostname", terms[0].TopologyKey)
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, terms[0].LabelSelector.MatchLabels)
+
+	t.Run("With host networking mongod listens on the port of the node", func(t *testing.T) {
+		mdb.Spec.ExternalAccess.Host = &mdbv1.HostAccess{HostNetwork: true}
+		buildStatefulSetModificationFunction(mdb)(&sts)
+		assert.True(t, sts.Spec.Template.Spec.HostNetwork)
+		assert.Equal(t, corev1.DNSClusterFirstWithHostNet, sts.Spec.Template.Spec.DNSPolicy)
+		assert.Empty(t, podtemplatespec.FindContainerByName(construct.MongodbName, &sts.Spec.Template).Ports)
+		assert.Len(t, sts.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	})
+
+	t.Run("The settings are reverted once the members are not exposed on their nodes", func(t *testing.T) {
+		mdb.Spec.ExternalAccess = nil
+		buildStatefulSetModificationFunction(mdb)(&sts)
+		assert.False(t, sts.Spec.Template.Spec.HostNetwork)
+		assert.Equal(t, corev1.DNSClusterFirst, sts.Spec.Template.Spec.DNSPolicy)
+		assert.Empty(t, sts.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	})
+}
+
+func TestExternalAccessModification(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ExternalAccess = &mdbv1.ExternalAccess{HorizonName: "public"}
+	mdb.Spec.ReplicaSetHorizons = mdbv1.ReplicaSetHorizonConfiguration{
+		{"vpn": "a.vpn.example.com:27017"},
+		{"vpn": "b.vpn.example.com:27017"},
+		{"vpn": "c.vpn.example.com:27017"},
+	}
+	addresses := []mdbv1.ExternalAddress{
+		{Name: "my-rs-0", Address: "a.example.com:27017"},
+		{Name: "my-rs-1", Address: "b.example.com:27017"},
+	}
+
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, externalAccessModification(mdb, addresses))
+	assert.NoError(t, err)
+	assert.Equal(t, automationconfig.ReplicaSetHorizons{"vpn": "a.vpn.example.com:27017"}, ac.ReplicaSets[0].Members[0].Horizons, "the horizon is only added once every member has an address")
+
+	addresses = append(addresses, mdbv1.ExternalAddress{Name: "my-rs-2", Address: "c.example.com:27017"})
+	ac, err = buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, externalAccessModification(mdb, addresses))
+	assert.NoError(t, err)
+	assert.Equal(t, automationconfig.ReplicaSetHorizons{"vpn": "c.vpn.example.com:27017", "public": "c.example.com:27017"}, ac.ReplicaSets[0].Members[2].Horizons)
+	assert.Len(t, mdb.Spec.ReplicaSetHorizons[2], 1, "the horizons of the spec are not modified")
+
+	t.Run("The horizon is not added while a member has an IP address", func(t *testing.T) {
+		addresses[1].Address = "203.0.113.2:27017"
+		ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, externalAccessModification(mdb, addresses))
+		assert.NoError(t, err)
+		assert.Equal(t, automationconfig.ReplicaSetHorizons{"vpn": "a.vpn.example.com:27017"}, ac.ReplicaSets[0].Members[0].Horizons)
+	})
+}
+
+func TestValidateExternalAccess(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateExternalAccess(mdb))
+
+	mdb.Spec.ExternalAccess = &mdbv1.ExternalAccess{}
+	mdb.Spec.ReplicaSetHorizons = mdbv1.ReplicaSetHorizonConfiguration{{"vpn": "a.vpn.example.com:27017"}}
+	assert.NoError(t, validateExternalAccess(mdb))
+
+	mdb.Spec.ReplicaSetHorizons = mdbv1.ReplicaSetHorizonConfiguration{{"external": "a.example.com:27017"}}
+	assert.EqualError(t, validateExternalAccess(mdb), "replicaSetHorizons[0] can not contain the horizon external of externalAccess")
+
+	mdb.Spec.ReplicaSetHorizons = nil
+	mdb.Spec.ExternalAccess.Host = &mdbv1.HostAccess{HostNetwork: true, Port: 27017}
+	assert.NoError(t, validateExternalAccess(mdb))
+	mdb.Spec.ExternalAccess.Host.Port = 37017
+	assert.EqualError(t, validateExternalAccess(mdb), "externalAccess.host.port can not be 37017 with hostNetwork, as mongod listens on port 27017 of the node")
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// validateExternalMembers checks that the external members have unique ids and hostnames which are not used by
+// the members in the cluster, that members without votes have no priority, that their horizons are horizons of
+// the members in the cluster, and that they configure their certificate if TLS is enabled.
+func validateExternalMembers(mdb mdbv1.MongoDBCommunity) error {
+	members := mdb.Spec.Members
+	if mdb.Status.CurrentMongoDBMembers > members {
+		members = mdb.Status.CurrentMongoDBMembers
+	}
+	firstID, lastID := mdb.MemberOrdinalStart(), mdb.MemberOrdinalStart()+members-1
+
+	horizons := map[string]bool{}
+	for _, memberHorizons := range mdb.Spec.ReplicaSetHorizons {
+		for name := range memberHorizons {
+			horizons[name] = true
+		}
+	}
+	if mdb.Spec.ExternalAccess != nil {
+		horizons[mdb.Spec.ExternalAccess.GetHorizonName()] = true
+	}
+
+	ids := map[int]bool{}
+	hosts := map[string]bool{}
+	for i, member := range mdb.Spec.ExternalMembers {
+		if member.ID >= firstID && member.ID <= lastID {
+			return errors.Errorf("externalMembers[%d] can not use the id %d, which is used by the members in the cluster from %d to %d", i, member.ID, firstID, lastID)
+		}
+		if ids[member.ID] {
+			return errors.Errorf("externalMembers[%d] can not use the id %d, which is already used", i, member.ID)
+		}
+		ids[member.ID] = true
+
+		if member.Hostname == "" {
+			return errors.Errorf("externalMembers[%d] must specify a hostname", i)
+		}
+		host := net.JoinHostPort(member.Hostname, strconv.Itoa(int(member.GetPort())))
+		if hosts[host] {
+			return errors.Errorf("externalMembers[%d] can not use the address %s, which is already used", i, host)
+		}
+		hosts[host] = true
+
+		if member.GetVotes() == 0 && member.GetPriority() != 0 {
+			return errors.Errorf("externalMembers[%d] must have priority 0, as it has no votes", i)
+		}
+		for name := range member.Horizons {
+			if !horizons[name] {
+				return errors.Errorf("externalMembers[%d] can not define the horizon %s, which the members in the cluster do not define", i, name)
+			}
+		}
+		if mdb.Spec.Security.TLS.Enabled && member.TLS == nil {
+			return errors.Errorf("externalMembers[%d] must specify tls, as TLS is enabled", i)
+		}
+	}
+	return nil
+}
+
+// externalMemberProcessName returns the name of the process of the given external member in the automation config.
+func externalMemberProcessName(mdb mdbv1.MongoDBCommunity, member mdbv1.ExternalMember) string {
+	return fmt.Sprintf("%s-external-%d", mdb.Name, member.ID)
+}
+
+// externalMemberTLSArgs are the TLS arguments of the members in the cluster which also apply to the external
+// members, unlike the paths of the certificates in the Pods.
+var externalMemberTLSArgs = []string{
+	"net.tls.mode",
+	"net.tls.allowConnectionsWithoutCertificates",
+	"net.tls.disabledProtocols",
+	"setParameter." + opensslCipherConfigParameter,
+}
+
+// externalMembersModification adds the processes of the external members to the automation config, and the
+// external members to the replica set. Horizons of the members in the cluster which the external member does not
+// define default to its hostname and port, as every member must define the same horizons. It must follow the
+// modifications setting paths in the Pods, such as the temporary directory, and precede forceReconfigModification,
+// which removes the votes of the external members as well.
+func externalMembersModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if len(mdb.Spec.ExternalMembers) == 0 {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		if len(config.Processes) == 0 || len(config.ReplicaSets) == 0 {
+			return
+		}
+		rs := &config.ReplicaSets[0]
+		var horizonNames []string
+		if len(rs.Members) > 0 {
+			for name := range rs.Members[0].Horizons {
+				horizonNames = append(horizonNames, name)
+			}
+		}
+
+		external := automationconfig.AutomationConfig{}
+		for _, member := range mdb.Spec.ExternalMembers {
+			external.Processes = append(external.Processes, externalMemberProcess(mdb, member, config.Processes[0], rs.Id))
+
+			var horizons automationconfig.ReplicaSetHorizons
+			for _, name := range horizonNames {
+				if horizons == nil {
+					horizons = automationconfig.ReplicaSetHorizons{}
+				}
+				horizons[name] = net.JoinHostPort(member.Hostname, strconv.Itoa(int(member.GetPort())))
+				if address, ok := member.Horizons[name]; ok {
+					horizons[name] = address
+				}
+			}
+			rs.Members = append(rs.Members, automationconfig.ReplicaSetMember{
+				Id:       member.ID,
+				Host:     externalMemberProcessName(mdb, member),
+				Priority: member.GetPriority(),
+				Votes:    member.GetVotes(),
+				Horizons: horizons,
+			})
+		}
+		getMongodConfigModification(mdb)(&external)
+		getServerParametersModification(mdb)(&external)
+		for i := range external.Processes {
+			for _, arg := range externalMemberTLSArgs {
+				if value := config.Processes[0].Args26.Get(arg); !value.IsNil() {
+					external.Processes[i].SetArgs26Field(arg, value.Data())
+				}
+			}
+		}
+		config.Processes = append(config.Processes, external.Processes...)
+	}
+}
+
+// externalMemberProcess returns the process of the given external member, with the version of the given process of
+// a member in the cluster, and the address, data directory and certificate of the external member. None of the
+// paths in the Pods of the members in the cluster apply to it, so mongod logs to the default path of the agent.
+func externalMemberProcess(mdb mdbv1.MongoDBCommunity, member mdbv1.ExternalMember, first automationconfig.Process, replSetName string) automationconfig.Process {
+	process := automationconfig.Process{
+		Name:                        externalMemberProcessName(mdb, member),
+		HostName:                    member.Hostname,
+		FeatureCompatibilityVersion: first.FeatureCompatibilityVersion,
+		ProcessType:                 first.ProcessType,
+		Version:                     first.Version,
+		AuthSchemaVersion:           first.AuthSchemaVersion,
+	}
+	process.SetSystemLog(automationconfig.SystemLog{
+		Destination: "file",
+		Path:        path.Join(automationconfig.DefaultAgentLogPath, "mongodb.log"),
+		LogAppend:   true,
+	})
+	process.SetPort(int(member.GetPort()))
+	dbPath := automationconfig.DefaultMongoDBDataDir
+	if member.DBPath != "" {
+		dbPath = member.DBPath
+	}
+	process.SetStoragePath(dbPath)
+	process.SetReplicaSetName(replSetName)
+	if mdb.Spec.Network != nil && mdb.Spec.Network.Listeners != nil {
+		addresses := []string{"0.0.0.0"}
+		if mdb.Spec.Network.UsesIPv6() {
+			addresses = append(addresses, "::")
+		}
+		process.SetArgs26Field(mongodBindIP, strings.Join(addresses, ","))
+	}
+	if member.TLS != nil {
+		process.SetArgs26Field("net.tls.certificateKeyFile", member.TLS.CertificateKeyFile)
+		process.SetArgs26Field("net.tls.CAFile", member.TLS.CAFile)
+	}
+	return process
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestExternalMembers_AreAddedToTheAutomationConfig(t *testing.T) {
+	mdb := newTestReplicaSet()
+	noVotes := 0
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{
+		{ID: 10, Hostname: "vm-0.example.com", DBPath: "/var/lib/mongodb"},
+		{ID: 11, Hostname: "vm-1.example.com", Port: 27018, Votes: &noVotes, Priority: &noVotes},
+	}
+	mdb.Spec.TemporaryDirectory.Path = "/scratch"
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Len(t, ac.Processes, 5)
+	vm0, vm1 := ac.Processes[3], ac.Processes[4]
+	assert.Equal(t, "my-rs-external-10", vm0.Name)
+	assert.Equal(t, "vm-0.example.com", vm0.HostName)
+	assert.Equal(t, "/var/lib/mongodb", vm0.Args26.Get("storage.dbPath").Data())
+	assert.Equal(t, "my-rs", vm0.Args26.Get("replication.replSetName").Data())
+	assert.Equal(t, ac.Processes[0].Version, vm0.Version)
+	assert.Equal(t, float64(27018), vm1.Args26.Get("net.port").Data())
+	assert.Equal(t, "/data", vm1.Args26.Get("storage.dbPath").Data())
+	assert.Equal(t, "my-rs-0.my-rs-svc.my-ns.svc.cluster.local", ac.Processes[0].HostName, "the process of the first member is not modified")
+	assert.Equal(t, "/scratch", ac.Processes[0].Args26.Get(unixDomainSocketPathPrefix).Data())
+	assert.False(t, vm0.Args26.Has(unixDomainSocketPathPrefix), "the paths in the Pods do not apply to the external members")
+	assert.Equal(t, "/var/log/mongodb-mms-automation/mongodb.log", vm0.Args26.Get("systemLog.path").Data())
+
+	members := ac.ReplicaSets[0].Members
+	assert.Len(t, members, 5)
+	assert.Equal(t, automationconfig.ReplicaSetMember{Id: 10, Host: "my-rs-external-10", Priority: 1, Votes: 1}, members[3])
+	assert.Equal(t, automationconfig.ReplicaSetMember{Id: 11, Host: "my-rs-external-11", Priority: 0, Votes: 0}, members[4])
+}
+
+func TestExternalMembersModification_DefaultsTheHorizons(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ReplicaSetHorizons = mdbv1.ReplicaSetHorizonConfiguration{
+		{"vpn": "a.vpn.example.com:27017", "dc": "a.dc.example.com:27017"},
+		{"vpn": "b.vpn.example.com:27017", "dc": "b.dc.example.com:27017"},
+		{"vpn": "c.vpn.example.com:27017", "dc": "c.dc.example.com:27017"},
+	}
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{
+		ID:       10,
+		Hostname: "vm-0.example.com",
+		Horizons: map[string]string{"vpn": "vm-0.vpn.example.com:27017"},
+		TLS:      &mdbv1.ExternalMemberTLS{CertificateKeyFile: "/etc/mongodb/server.pem", CAFile: "/etc/mongodb/ca.crt"},
+	}}
+
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, externalMembersModification(mdb))
+	assert.NoError(t, err)
+	assert.Equal(t, automationconfig.ReplicaSetHorizons{"vpn": "vm-0.vpn.example.com:27017", "dc": "vm-0.example.com:27017"}, ac.ReplicaSets[0].Members[3].Horizons)
+	assert.Equal(t, "/etc/mongodb/server.pem", ac.Processes[3].Args26.Get("net.tls.certificateKeyFile").Data())
+	assert.Equal(t, "/etc/mongodb/ca.crt", ac.Processes[3].Args26.Get("net.tls.CAFile").Data())
+	assert.False(t, ac.Processes[0].Args26.Has("net.tls.certificateKeyFile"), "the arguments of the first member are not modified")
+}
+
+func TestExternalMembers_LoseTheirVotesInAForcedReconfiguration(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 10, Hostname: "vm-0.example.com"}}
+	mdb.Spec.ForceReconfig = &mdbv1.ForceReconfig{Members: []string{"my-rs-0"}, AcknowledgeDataLoss: true}
+	mdb.Status.ForceReconfig = &mdbv1.ForceReconfigStatus{Request: "1", Members: []string{"my-rs-0"}}
+
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, externalMembersModification(mdb), forceReconfigModification(mdb))
+	assert.NoError(t, err)
+	members := ac.ReplicaSets[0].Members
+	assert.Equal(t, 1, members[0].Votes)
+	assert.Equal(t, "my-rs-external-10", members[3].Host)
+	assert.Equal(t, 0, members[3].Votes)
+	assert.Equal(t, 0, members[3].Priority)
+}
+
+func TestValidateExternalMembers(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 3, Hostname: "vm-0.example.com"}}
+	assert.NoError(t, validateExternalMembers(mdb))
+
+	mdb.Spec.ExternalMembers[0].ID = 2
+	assert.EqualError(t, validateExternalMembers(mdb), "externalMembers[0] can not use the id 2, which is used by the members in the cluster from 0 to 2")
+
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 3, Hostname: "vm-0.example.com"}, {ID: 3, Hostname: "vm-1.example.com"}}
+	assert.EqualError(t, validateExternalMembers(mdb), "externalMembers[1] can not use the id 3, which is already used")
+
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 3, Hostname: "vm-0.example.com"}, {ID: 4, Hostname: "vm-0.example.com", Port: 27017}}
+	assert.EqualError(t, validateExternalMembers(mdb), "externalMembers[1] can not use the address vm-0.example.com:27017, which is already used")
+
+	noVotes := 0
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 3, Hostname: "vm-0.example.com", Votes: &noVotes}}
+	assert.EqualError(t, validateExternalMembers(mdb), "externalMembers[0] must have priority 0, as it has no votes")
+
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 3, Hostname: "vm-0.example.com", Horizons: map[string]string{"vpn": "vm-0.vpn.example.com:27017"}}}
+	assert.EqualError(t, validateExternalMembers(mdb), "externalMembers[0] can not define the horizon vpn, which the members in the cluster do not define")
+
+	mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{{ID: 3, Hostname: "vm-0.example.com"}}
+	mdb.Spec.Security.TLS.Enabled = true
+	assert.EqualError(t, validateExternalMembers(mdb), "externalMembers[0] must specify tls, as TLS is enabled")
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/agent"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// forceReconfigAnnotation requests a forced reconfiguration of the replica set around the members listed in
+// spec.forceReconfig, which is performed whenever its value changes.
+const forceReconfigAnnotation = "mongodbcommunity.mongodb.com/force-reconfig"
+
+// reconcileForceReconfig starts a forced reconfiguration when it is requested with the force reconfig
+// annotation, and records its completion once the agents of the surviving members have reached the goal state
+// of the forced configuration.
+func (r *ReplicaSetReconciler) reconcileForceReconfig(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	if err := r.startForceReconfig(mdb, now); err != nil {
+		return err
+	}
+	return r.completeForceReconfig(mdb, now)
+}
+
+// startForceReconfig records the surviving members of a forced reconfiguration in the status if the value of
+// the force reconfig annotation differs from the request of the most recent forced reconfiguration.
+func (r *ReplicaSetReconciler) startForceReconfig(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	request, ok := mdb.Annotations[forceReconfigAnnotation]
+	if !ok || (mdb.Status.ForceReconfig != nil && mdb.Status.ForceReconfig.Request == request) {
+		return nil
+	}
+	members, err := forceReconfigMembers(*mdb)
+	if err != nil {
+		return err
+	}
+
+	r.log.Warnf("Forcing the reconfiguration of the replica set around members %s", strings.Join(members, ", "))
+	if r.recorder != nil {
+		r.recorder.Eventf(mdb, corev1.EventTypeWarning, "ForceReconfig", "Forcing the reconfiguration of the replica set around members %s", strings.Join(members, ", "))
+	}
+	return r.updateForceReconfigStatus(mdb, &mdbv1.ForceReconfigStatus{
+		Request:   request,
+		Members:   members,
+		StartTime: metav1.NewTime(now),
+	})
+}
+
+// forceReconfigMembers returns the surviving members of the forced reconfiguration acknowledged in the spec.
+func forceReconfigMembers(mdb mdbv1.MongoDBCommunity) ([]string, error) {
+	reconfig := mdb.Spec.ForceReconfig
+	if reconfig == nil {
+		return nil, errors.Errorf("the %s annotation requires spec.forceReconfig to list the surviving members", forceReconfigAnnotation)
+	}
+	if !reconfig.AcknowledgeDataLoss {
+		return nil, errors.New("spec.forceReconfig.acknowledgeDataLoss must be true")
+	}
+
+	var podNames []string
+	for i := 0; i < mdb.AutomationConfigMembersThisReconciliation(); i++ {
+		podNames = append(podNames, mdb.PodName(i))
+	}
+	var members []string
+	for _, member := range reconfig.GetMembers() {
+		if !contains.String(podNames, member) {
+			return nil, errors.Errorf("%s in spec.forceReconfig.members is not a member of the replica set", member)
+		}
+		if contains.String(members, member) {
+			return nil, errors.Errorf("%s is listed more than once in spec.forceReconfig.members", member)
+		}
+		members = append(members, member)
+	}
+	if len(members) == 0 {
+		return nil, errors.New("spec.forceReconfig.members must list at least one member")
+	}
+	return members, nil
+}
+
+// completeForceReconfig records the completion of the forced reconfiguration in progress once the agents of all
+// surviving members have reached the goal state of the deployed automation config, which forces the
+// reconfiguration. The agents of the lost members are not waited for.
+func (r *ReplicaSetReconciler) completeForceReconfig(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	reconfig := mdb.Status.ForceReconfig
+	if reconfig == nil || reconfig.CompletionTime != nil {
+		return nil
+	}
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		return errors.Errorf("could not read existing automation config: %s", err)
+	}
+	if len(ac.ReplicaSets) == 0 || ac.ReplicaSets[0].Force == nil {
+		// the forced configuration has not been deployed yet.
+		return nil
+	}
+	for _, member := range reconfig.Members {
+		pod, err := r.client.GetPod(types.NamespacedName{Name: member, Namespace: mdb.Namespace})
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Errorf("could not get Pod %s: %s", member, err)
+		}
+		if !agent.ReachedGoalState(pod, ac.Version, r.log) {
+			return nil
+		}
+	}
+
+	r.log.Infof("The replica set has been forcibly reconfigured around members %s", strings.Join(reconfig.Members, ", "))
+	if r.recorder != nil {
+		r.recorder.Eventf(mdb, corev1.EventTypeNormal, "ForceReconfigCompleted", "The replica set has been forcibly reconfigured around members %s", strings.Join(reconfig.Members, ", "))
+	}
+	completed := reconfig.DeepCopy()
+	completionTime := metav1.NewTime(now)
+	completed.CompletionTime = &completionTime
+	return r.updateForceReconfigStatus(mdb, completed)
+}
+
+func (r *ReplicaSetReconciler) updateForceReconfigStatus(mdb *mdbv1.MongoDBCommunity, reconfig *mdbv1.ForceReconfigStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withForceReconfig(reconfig))
+	return err
+}
+
+// forceReconfigModification removes the votes and the priority of the members lost in a forced reconfiguration,
+// and of the external members, for as long as spec.forceReconfig is set, and forces the reconfiguration until it
+// has completed. It must follow externalMembersModification.
+func forceReconfigModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	reconfig := mdb.Status.ForceReconfig
+	if reconfig == nil || mdb.Spec.ForceReconfig == nil {
+		return automationconfig.NOOP()
+	}
+
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.ReplicaSets {
+			rs := &config.ReplicaSets[i]
+			for j := range rs.Members {
+				if !contains.String(reconfig.Members, mdb.PodName(j)) {
+					rs.Members[j].Votes = 0
+					rs.Members[j].Priority = 0
+				}
+			}
+			if reconfig.CompletionTime == nil {
+				rs.Force = &automationconfig.ReplicaSetForce{CurrentVersion: -1}
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestForceReconfigMembers(t *testing.T) {
+	mdb := newTestReplicaSet()
+
+	_, err := forceReconfigMembers(mdb)
+	assert.EqualError(t, err, "the mongodbcommunity.mongodb.com/force-reconfig annotation requires spec.forceReconfig to list the surviving members")
+
+	mdb.Spec.ForceReconfig = &mdbv1.ForceReconfig{Members: []string{mdb.PodName(0)}}
+	_, err = forceReconfigMembers(mdb)
+	assert.EqualError(t, err, "spec.forceReconfig.acknowledgeDataLoss must be true")
+
+	mdb.Spec.ForceReconfig.AcknowledgeDataLoss = true
+	members, err := forceReconfigMembers(mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mdb.PodName(0)}, members)
+
+	mdb.Spec.ForceReconfig.Members = []string{mdb.PodName(0), mdb.PodName(0)}
+	_, err = forceReconfigMembers(mdb)
+	assert.Error(t, err)
+
+	mdb.Spec.ForceReconfig.Members = []string{mdb.PodName(mdb.Spec.Members)}
+	_, err = forceReconfigMembers(mdb)
+	assert.Error(t, err)
+}
+
+// setAgentGoalState simulates the agent of the member reaching the goal state of the published automation config.
+func setAgentGoalState(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, member int) {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	pod := corev1.Pod{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(member), Namespace: mdb.Namespace}, &pod))
+	pod.Annotations = map[string]string{"agent.mongodb.com/version": fmt.Sprint(ac.Version)}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+}
+
+func TestForceReconfig_ReconfiguresAroundTheSurvivingMembers(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.ForceReconfig = &mdbv1.ForceReconfig{Members: []string{mdb.PodName(0)}, AcknowledgeDataLoss: true}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	t.Run("The reconfiguration is only forced when it is requested", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Nil(t, mdb.Status.ForceReconfig)
+	})
+
+	mdb.Annotations = map[string]string{forceReconfigAnnotation: "1"}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotNil(t, mdb.Status.ForceReconfig)
+	assert.Equal(t, "1", mdb.Status.ForceReconfig.Request)
+	assert.Equal(t, []string{mdb.PodName(0)}, mdb.Status.ForceReconfig.Members)
+	assert.Nil(t, mdb.Status.ForceReconfig.CompletionTime)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, &automationconfig.ReplicaSetForce{CurrentVersion: -1}, ac.ReplicaSets[0].Force)
+	votes, priorities := memberVotes(t, mgr, mdb)
+	assert.Equal(t, []int{1, 0, 0}, votes)
+	assert.Equal(t, []int{1, 0, 0}, priorities)
+
+	t.Run("The lost members are not waited for", func(t *testing.T) {
+		setAgentGoalState(t, mgr, mdb, 0)
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.NotNil(t, mdb.Status.ForceReconfig.CompletionTime)
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Nil(t, ac.ReplicaSets[0].Force)
+		votes, _ := memberVotes(t, mgr, mdb)
+		assert.Equal(t, []int{1, 0, 0}, votes)
+	})
+
+	t.Run("The votes are restored once spec.forceReconfig is removed", func(t *testing.T) {
+		mdb.Spec.ForceReconfig = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+		votes, _ := memberVotes(t, mgr, mdb)
+		assert.Equal(t, []int{1, 1, 1}, votes)
+	})
+}
+
+func TestForceReconfig_RequiresTheAcknowledgement(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Annotations = map[string]string{forceReconfigAnnotation: "1"}
+	mdb.Spec.ForceReconfig = &mdbv1.ForceReconfig{Members: []string{mdb.PodName(0)}}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "acknowledgeDataLoss must be true")
+	assert.Nil(t, mdb.Status.ForceReconfig)
+}
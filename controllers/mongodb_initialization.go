@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// validateInitialization checks that the initialization has exactly one source and runs as a user of the resource.
+func validateInitialization(mdb mdbv1.MongoDBCommunity) error {
+	init := mdb.Spec.Initialization
+	if init == nil {
+		return nil
+	}
+	if (init.ScriptsConfigMapRef == nil) == (init.BackupRef == nil) {
+		return errors.New("exactly one of initialization.scriptsConfigMapRef and initialization.backupRef must be set")
+	}
+	if _, ok := findUser(mdb, init.User); !ok {
+		return errors.Errorf("initialization.user %q is not a user of the resource", init.User)
+	}
+	return nil
+}
+
+// reconcileInitialization initializes the data of the deployment once the replica set is ready for the first time,
+// and returns the status of the initialization. The initialization is only run for a resource which has never
+// reached the Running phase, and is never run again once it has completed.
+func (r ReplicaSetReconciler) reconcileInitialization(mdb mdbv1.MongoDBCommunity) (*mdbv1.InitializationStatus, error) {
+	current := mdb.Status.Initialization
+	if current != nil && (current.Phase == mdbv1.InitializationCompleted || current.Phase == mdbv1.InitializationSkipped) {
+		return current, nil
+	}
+	init := mdb.Spec.Initialization
+	if init == nil {
+		return nil, nil
+	}
+	if current == nil && mdb.Annotations[lastSuccessfulConfiguration] != "" {
+		r.log.Infof("Not initializing the data, the resource has already been deployed")
+		return &mdbv1.InitializationStatus{
+			Phase:   mdbv1.InitializationSkipped,
+			Message: "The resource had already been deployed when the initialization was set",
+		}, nil
+	}
+
+	if init.BackupRef != nil {
+		return r.reconcileInitializationRestore(mdb)
+	}
+	return r.reconcileInitializationJob(mdb)
+}
+
+// reconcileInitializationJob creates the Job running the initialization scripts and reports its outcome. A
+// failed Job is created again once it has been deleted.
+func (r ReplicaSetReconciler) reconcileInitializationJob(mdb mdbv1.MongoDBCommunity) (*mdbv1.InitializationStatus, error) {
+	init := *mdb.Spec.Initialization
+	status := &mdbv1.InitializationStatus{Phase: mdbv1.InitializationRunning, JobName: mdb.InitializationName()}
+
+	job := batchv1.Job{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: status.JobName, Namespace: mdb.Namespace}, &job)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return nil, err
+	}
+	if apiErrors.IsNotFound(err) {
+		if _, err := r.client.GetConfigMap(types.NamespacedName{Name: init.ScriptsConfigMapRef.Name, Namespace: mdb.Namespace}); err != nil {
+			if apiErrors.IsNotFound(err) {
+				status.Message = fmt.Sprintf("Waiting for ConfigMap %s to exist", init.ScriptsConfigMapRef.Name)
+				return status, nil
+			}
+			return nil, err
+		}
+
+		user, _ := findUser(mdb, init.User)
+		opts := construct.InitializationJobOptions{
+			Name:                       status.JobName,
+			Namespace:                  mdb.Namespace,
+			ConnectionStringSecretName: user.GetConnectionStringSecretName(mdb.Name),
+			ConnectionStringKey:        connectionStringStandardKey,
+			ScriptsConfigMapName:       init.ScriptsConfigMapRef.Name,
+		}
+		if mdb.Spec.Security.TLS.Enabled {
+			opts.CAConfigMapName = mdb.Spec.Security.TLS.CaConfigMap.Name
+		}
+		job = construct.BuildInitializationJob(&mdb, opts)
+		job.OwnerReferences = mdb.GetOwnerReferences()
+		setJobMetadata(mdb, &job)
+		job.Labels[mdbv1.JobResourceLabel] = mdb.Name
+		job.Labels[mdbv1.JobTypeLabel] = string(mdbv1.InitializationJob)
+		if err := r.client.Create(context.TODO(), &job); err != nil && !apiErrors.IsAlreadyExists(err) {
+			return nil, errors.Errorf("could not create the initialization Job: %s", err)
+		}
+		r.log.Infof("Running the initialization scripts of ConfigMap %s", init.ScriptsConfigMapRef.Name)
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			completionTime := c.LastTransitionTime
+			status.Phase = mdbv1.InitializationCompleted
+			status.CompletionTime = &completionTime
+			return status, nil
+		case batchv1.JobFailed:
+			status.Phase = mdbv1.InitializationFailed
+			status.Message = fmt.Sprintf("Job %s failed: %s. Delete the Job to run the scripts again", job.Name, c.Message)
+			return status, nil
+		}
+	}
+	status.Message = fmt.Sprintf("Job %s is running the scripts of ConfigMap %s", job.Name, init.ScriptsConfigMapRef.Name)
+	return status, nil
+}
+
+// reconcileInitializationRestore creates the MongoDBCommunityRestore resource restoring the backup and reports
+// its outcome. A failed restore is created again once it has been deleted.
+func (r ReplicaSetReconciler) reconcileInitializationRestore(mdb mdbv1.MongoDBCommunity) (*mdbv1.InitializationStatus, error) {
+	init := *mdb.Spec.Initialization
+	status := &mdbv1.InitializationStatus{Phase: mdbv1.InitializationRunning, RestoreName: mdb.InitializationName()}
+
+	restore := mdbv1.MongoDBCommunityRestore{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: status.RestoreName, Namespace: mdb.Namespace}, &restore)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return nil, err
+	}
+	if apiErrors.IsNotFound(err) {
+		restore = mdbv1.MongoDBCommunityRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            status.RestoreName,
+				Namespace:       mdb.Namespace,
+				Labels:          mdb.SchemaLabels(mdbv1.ComponentJob),
+				OwnerReferences: mdb.GetOwnerReferences(),
+			},
+			Spec: mdbv1.MongoDBCommunityRestoreSpec{
+				MongoDBResourceRef: mdbv1.LocalObjectReference{Name: mdb.Name},
+				User:               init.User,
+				BackupRef:          &mdbv1.LocalObjectReference{Name: init.BackupRef.Name},
+			},
+		}
+		if err := r.client.Create(context.TODO(), &restore); err != nil && !apiErrors.IsAlreadyExists(err) {
+			return nil, errors.Errorf("could not create the initialization restore: %s", err)
+		}
+		r.log.Infof("Restoring backup %s to initialize the data", init.BackupRef.Name)
+	}
+
+	switch restore.Status.Phase {
+	case mdbv1.RestoreCompleted:
+		status.Phase = mdbv1.InitializationCompleted
+		status.CompletionTime = restore.Status.CompletionTime
+	case mdbv1.RestoreFailed:
+		status.Phase = mdbv1.InitializationFailed
+		status.Message = fmt.Sprintf("Restore %s failed: %s. Delete the MongoDBCommunityRestore resource to restore the backup again", restore.Name, restore.Status.Message)
+	default:
+		status.Message = fmt.Sprintf("Restore %s is restoring backup %s", restore.Name, init.BackupRef.Name)
+		if restore.Status.Message != "" {
+			status.Message = fmt.Sprintf("Restore %s of backup %s is %s: %s", restore.Name, init.BackupRef.Name, restore.Status.Phase, restore.Status.Message)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func newTestReplicaSetWithInitialization(init mdbv1.Initialization) mdbv1.MongoDBCommunity {
+	mdb := newScramReplicaSet(mdbv1.MongoDBUser{
+		Name:              "app-user",
+		DB:                "admin",
+		PasswordSecretRef: mdbv1.SecretKeyReference{Name: "app-user-password"},
+		Roles:             []mdbv1.Role{{Name: "readWrite", DB: "app"}},
+	})
+	init.User = "app-user"
+	mdb.Spec.Initialization = &init
+	return mdb
+}
+
+func setupInitialization(t *testing.T, mdb mdbv1.MongoDBCommunity) (*ReplicaSetReconciler, *client.MockedManager) {
+	mgr := client.NewManager(&mdb)
+	password := secret.Builder().SetName("app-user-password").SetNamespace(mdb.Namespace).SetField("password", "password").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(password))
+	return NewReconciler(mgr), mgr
+}
+
+func reconcileInitialization(t *testing.T, r *ReplicaSetReconciler, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) mdbv1.MongoDBCommunity {
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	return mdb
+}
+
+func createScriptsConfigMap(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) {
+	cm := configmap.Builder().
+		SetName("seed-scripts").
+		SetNamespace(mdb.Namespace).
+		SetField("01-collections.js", `db.getSiblingDB("app").createCollection("orders")`).
+		Build()
+	assert.NoError(t, mgr.Client.CreateConfigMap(cm))
+}
+
+func setJobCondition(t *testing.T, mgr *client.MockedManager, name, namespace string, conditionType batchv1.JobConditionType, message string) {
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, &job))
+	job.Status.Conditions = []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue, Message: message, LastTransitionTime: metav1.Now()}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+}
+
+func TestInitialization_RunsTheScriptsBeforeTheResourceIsRunning(t *testing.T) {
+	mdb := newTestReplicaSetWithInitialization(mdbv1.Initialization{ScriptsConfigMapRef: &mdbv1.LocalObjectReference{Name: "seed-scripts"}})
+	r, mgr := setupInitialization(t, mdb)
+
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, "Initializing the data: Waiting for ConfigMap seed-scripts to exist, retrying in 10 seconds", mdb.Status.Message)
+
+	createScriptsConfigMap(t, mgr, mdb)
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, &mdbv1.InitializationStatus{
+		Phase:   mdbv1.InitializationRunning,
+		Message: "Job my-rs-initialization is running the scripts of ConfigMap seed-scripts",
+		JobName: "my-rs-initialization",
+	}, mdb.Status.Initialization)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-rs-initialization", Namespace: mdb.Namespace}, &job))
+	assert.Equal(t, string(mdbv1.InitializationJob), job.Labels[mdbv1.JobTypeLabel])
+	initialize := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, construct.InitializationContainerName, initialize.Name)
+	assert.Equal(t, "my-rs-admin-app-user", initialize.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "seed-scripts", job.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	setJobCondition(t, mgr, job.Name, job.Namespace, batchv1.JobComplete, "")
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, mdbv1.InitializationCompleted, mdb.Status.Initialization.Phase)
+	assert.NotNil(t, mdb.Status.Initialization.CompletionTime)
+
+	t.Run("The scripts are not run again", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &job))
+		mdb = reconcileInitialization(t, r, mgr, mdb)
+		assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+		err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, &batchv1.Job{})
+		assert.Error(t, err)
+	})
+}
+
+func TestInitialization_FailedScriptsFailTheResource(t *testing.T) {
+	mdb := newTestReplicaSetWithInitialization(mdbv1.Initialization{ScriptsConfigMapRef: &mdbv1.LocalObjectReference{Name: "seed-scripts"}})
+	r, mgr := setupInitialization(t, mdb)
+	createScriptsConfigMap(t, mgr, mdb)
+
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	setJobCondition(t, mgr, mdb.InitializationName(), mdb.Namespace, batchv1.JobFailed, "BackoffLimitExceeded")
+
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Equal(t, mdbv1.InitializationFailed, mdb.Status.Initialization.Phase)
+	assert.Equal(t, "Initialization failed: Job my-rs-initialization failed: BackoffLimitExceeded. Delete the Job to run the scripts again", mdb.Status.Message)
+}
+
+func TestInitialization_RestoresABackup(t *testing.T) {
+	mdb := newTestReplicaSetWithInitialization(mdbv1.Initialization{BackupRef: &mdbv1.LocalObjectReference{Name: "seed-backup"}})
+	r, mgr := setupInitialization(t, mdb)
+
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, "my-rs-initialization", mdb.Status.Initialization.RestoreName)
+
+	restore := mdbv1.MongoDBCommunityRestore{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-rs-initialization", Namespace: mdb.Namespace}, &restore))
+	assert.Equal(t, mdbv1.MongoDBCommunityRestoreSpec{
+		MongoDBResourceRef: mdbv1.LocalObjectReference{Name: "my-rs"},
+		User:               "app-user",
+		BackupRef:          &mdbv1.LocalObjectReference{Name: "seed-backup"},
+	}, restore.Spec)
+
+	now := metav1.Now()
+	restore.Status.Phase = mdbv1.RestoreCompleted
+	restore.Status.CompletionTime = &now
+	assert.NoError(t, mgr.GetClient().Status().Update(context.TODO(), &restore))
+
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, mdbv1.InitializationCompleted, mdb.Status.Initialization.Phase)
+}
+
+func TestInitialization_IsSkippedForDeployedResources(t *testing.T) {
+	mdb := newTestReplicaSetWithInitialization(mdbv1.Initialization{ScriptsConfigMapRef: &mdbv1.LocalObjectReference{Name: "seed-scripts"}})
+	mdb.Spec.Initialization = nil
+	r, mgr := setupInitialization(t, mdb)
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+
+	mdb.Spec.Initialization = &mdbv1.Initialization{User: "app-user", ScriptsConfigMapRef: &mdbv1.LocalObjectReference{Name: "seed-scripts"}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	mdb = reconcileInitialization(t, r, mgr, mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, mdbv1.InitializationSkipped, mdb.Status.Initialization.Phase)
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.InitializationName(), Namespace: mdb.Namespace}, &batchv1.Job{})
+	assert.Error(t, err)
+}
+
+func TestRestore_StartsForTheInitializationOfAResource(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	mdb.Status.Phase = mdbv1.Pending
+	mdb.Status.Initialization = &mdbv1.InitializationStatus{Phase: mdbv1.InitializationRunning, RestoreName: "my-restore"}
+	backup := newCompletedBackup()
+	restore := newTestRestore()
+	r, mgr := setupRestore(t, restore, &mdb, &backup)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+}
+
+func TestValidateInitialization(t *testing.T) {
+	mdb := newTestReplicaSetWithInitialization(mdbv1.Initialization{ScriptsConfigMapRef: &mdbv1.LocalObjectReference{Name: "seed-scripts"}})
+	assert.NoError(t, validateInitialization(mdb))
+
+	mdb.Spec.Initialization.BackupRef = &mdbv1.LocalObjectReference{Name: "seed-backup"}
+	assert.EqualError(t, validateInitialization(mdb), "exactly one of initialization.scriptsConfigMapRef and initialization.backupRef must be set")
+
+	mdb.Spec.Initialization.ScriptsConfigMapRef = nil
+	mdb.Spec.Initialization.User = "unknown"
+	assert.EqualError(t, validateInitialization(mdb), `initialization.user "unknown" is not a user of the resource`)
+}
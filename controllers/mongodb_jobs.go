@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	jobSucceededReason = "JobSucceeded"
+	jobFailedReason    = "JobFailed"
+	jobRunningReason   = "JobRunning"
+
+	backupScheduledReason = "Scheduled"
+	backupSuspendedReason = "Suspended"
+)
+
+// jobConditionTypes maps every JobType to the condition which reports the outcome of its most recent Job.
+var jobConditionTypes = map[mdbv1.JobType]string{
+	mdbv1.BackupJob:      mdbv1.ConditionLastBackup,
+	mdbv1.RestoreJob:     mdbv1.ConditionLastRestore,
+	mdbv1.MaintenanceJob: mdbv1.ConditionLastMaintenance,
+}
+
+// getJobConditions lists the Jobs and CronJobs belonging to the MongoDBCommunity resource
+// and returns the status conditions summarizing their outcome.
+func (r ReplicaSetReconciler) getJobConditions(mdb mdbv1.MongoDBCommunity) ([]metav1.Condition, error) {
+	selector := []k8sClient.ListOption{
+		k8sClient.InNamespace(mdb.Namespace),
+		k8sClient.MatchingLabels{mdbv1.JobResourceLabel: mdb.Name},
+	}
+
+	jobs := batchv1.JobList{}
+	if err := r.client.List(context.TODO(), &jobs, selector...); err != nil {
+		return nil, errors.Errorf("could not list jobs: %s", err)
+	}
+
+	cronJobs := batchv1beta1.CronJobList{}
+	if err := r.client.List(context.TODO(), &cronJobs, selector...); err != nil {
+		return nil, errors.Errorf("could not list cron jobs: %s", err)
+	}
+
+	return buildJobConditions(mdb, jobs.Items, cronJobs.Items, time.Now()), nil
+}
+
+// buildJobConditions returns the existing conditions of the resource updated with the outcome
+// of the given Jobs and the next scheduled backup of the given CronJobs.
+func buildJobConditions(mdb mdbv1.MongoDBCommunity, jobs []batchv1.Job, cronJobs []batchv1beta1.CronJob, now time.Time) []metav1.Condition {
+	conditions := make([]metav1.Condition, len(mdb.Status.Conditions))
+	copy(conditions, mdb.Status.Conditions)
+
+	for jobType, conditionType := range jobConditionTypes {
+		latest := latestJobOfType(jobs, jobType)
+		if latest == nil {
+			meta.RemoveStatusCondition(&conditions, conditionType)
+			continue
+		}
+		condition := jobCondition(*latest)
+		condition.Type = conditionType
+		condition.ObservedGeneration = mdb.Generation
+		meta.SetStatusCondition(&conditions, condition)
+	}
+
+	if condition, ok := backupScheduleCondition(cronJobs, now); ok {
+		condition.ObservedGeneration = mdb.Generation
+		meta.SetStatusCondition(&conditions, condition)
+	} else {
+		meta.RemoveStatusCondition(&conditions, mdbv1.ConditionBackupScheduled)
+	}
+
+	return conditions
+}
+
+// latestJobOfType returns the most recently created Job with the given type, or nil if there is none.
+func latestJobOfType(jobs []batchv1.Job, jobType mdbv1.JobType) *batchv1.Job {
+	var latest *batchv1.Job
+	for i := range jobs {
+		if jobs[i].Labels[mdbv1.JobTypeLabel] != string(jobType) {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&jobs[i].CreationTimestamp) {
+			latest = &jobs[i]
+		}
+	}
+	return latest
+}
+
+// jobCondition returns a condition without type describing the state of the given Job.
+func jobCondition(job batchv1.Job) metav1.Condition {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return metav1.Condition{
+				Status:  metav1.ConditionTrue,
+				Reason:  jobSucceededReason,
+				Message: fmt.Sprintf("Job %s completed at %s", job.Name, c.LastTransitionTime.UTC().Format(time.RFC3339)),
+			}
+		case batchv1.JobFailed:
+			return metav1.Condition{
+				Status:  metav1.ConditionFalse,
+				Reason:  jobFailedReason,
+				Message: fmt.Sprintf("Job %s failed at %s: %s", job.Name, c.LastTransitionTime.UTC().Format(time.RFC3339), c.Message),
+			}
+		}
+	}
+	return metav1.Condition{
+		Status:  metav1.ConditionUnknown,
+		Reason:  jobRunningReason,
+		Message: fmt.Sprintf("Job %s is running", job.Name),
+	}
+}
+
+// backupScheduleCondition returns a condition reporting the next time any of the backup CronJobs will run.
+// The returned boolean is false if there are no backup CronJobs.
+func backupScheduleCondition(cronJobs []batchv1beta1.CronJob, now time.Time) (metav1.Condition, bool) {
+	var next time.Time
+	found := false
+	for _, cj := range cronJobs {
+		if cj.Labels[mdbv1.JobTypeLabel] != string(mdbv1.BackupJob) {
+			continue
+		}
+		found = true
+		if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+			continue
+		}
+		schedule, err := cron.ParseStandard(cj.Spec.Schedule)
+		if err != nil {
+			continue
+		}
+		if n := schedule.Next(now); next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	if !found {
+		return metav1.Condition{}, false
+	}
+
+	if next.IsZero() {
+		return metav1.Condition{
+			Type:    mdbv1.ConditionBackupScheduled,
+			Status:  metav1.ConditionFalse,
+			Reason:  backupSuspendedReason,
+			Message: "No backup is scheduled",
+		}, true
+	}
+
+	return metav1.Condition{
+		Type:    mdbv1.ConditionBackupScheduled,
+		Status:  metav1.ConditionTrue,
+		Reason:  backupScheduledReason,
+		Message: fmt.Sprintf("Next backup scheduled at %s", next.UTC().Format(time.RFC3339)),
+	}, true
+}
+
+// jobOwnerRequests maps a Job or CronJob to a reconcile request for the MongoDBCommunity resource it belongs to.
+func jobOwnerRequests(obj k8sClient.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[mdbv1.JobResourceLabel]
+	if !ok || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
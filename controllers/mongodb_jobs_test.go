@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestBuildJobConditions(t *testing.T) {
+	mdb := newTestReplicaSet()
+	now := time.Date(2021, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	jobs := []batchv1.Job{
+		newJob(mdb, "backup-old", mdbv1.BackupJob, now.Add(-2*time.Hour), batchv1.JobFailed),
+		newJob(mdb, "backup-new", mdbv1.BackupJob, now.Add(-time.Hour), batchv1.JobComplete),
+		newJob(mdb, "restore", mdbv1.RestoreJob, now.Add(-time.Hour), batchv1.JobFailed),
+		newJob(mdb, "compact", mdbv1.MaintenanceJob, now.Add(-time.Minute), ""),
+	}
+	cronJobs := []batchv1beta1.CronJob{
+		newCronJob(mdb, "nightly", "0 2 * * *", false),
+		newCronJob(mdb, "hourly", "0 * * * *", false),
+		newCronJob(mdb, "suspended", "* * * * *", true),
+	}
+
+	conditions := buildJobConditions(mdb, jobs, cronJobs, now)
+
+	backup := meta.FindStatusCondition(conditions, mdbv1.ConditionLastBackup)
+	assert.NotNil(t, backup)
+	assert.Equal(t, metav1.ConditionTrue, backup.Status)
+	assert.Contains(t, backup.Message, "backup-new")
+
+	restore := meta.FindStatusCondition(conditions, mdbv1.ConditionLastRestore)
+	assert.NotNil(t, restore)
+	assert.Equal(t, metav1.ConditionFalse, restore.Status)
+	assert.Equal(t, jobFailedReason, restore.Reason)
+
+	maintenance := meta.FindStatusCondition(conditions, mdbv1.ConditionLastMaintenance)
+	assert.NotNil(t, maintenance)
+	assert.Equal(t, metav1.ConditionUnknown, maintenance.Status)
+
+	scheduled := meta.FindStatusCondition(conditions, mdbv1.ConditionBackupScheduled)
+	assert.NotNil(t, scheduled)
+	assert.Equal(t, metav1.ConditionTrue, scheduled.Status)
+	assert.Equal(t, "Next backup scheduled at 2021-05-01T11:00:00Z", scheduled.Message)
+
+	t.Run("Conditions are removed when the jobs are gone", func(t *testing.T) {
+		mdb.Status.Conditions = conditions
+		conditions := buildJobConditions(mdb, nil, []batchv1beta1.CronJob{newCronJob(mdb, "suspended", "* * * * *", true)}, now)
+		assert.Len(t, conditions, 1)
+		assert.Equal(t, metav1.ConditionFalse, conditions[0].Status)
+		assert.Equal(t, mdbv1.ConditionBackupScheduled, conditions[0].Type)
+	})
+}
+
+func TestJobConditions_AreAddedToStatus(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+
+	job := newJob(mdb, "backup", mdbv1.BackupJob, time.Now(), batchv1.JobComplete)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &job))
+
+	otherJob := newJob(mdb, "other-backup", mdbv1.BackupJob, time.Now(), batchv1.JobFailed)
+	otherJob.Labels[mdbv1.JobResourceLabel] = "other-resource"
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &otherJob))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
+	assertReconciliationSuccessful(t, res, err)
+
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Len(t, mdb.Status.Conditions, 1)
+	assert.True(t, meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionLastBackup))
+}
+
+func TestJobOwnerRequests(t *testing.T) {
+	mdb := newTestReplicaSet()
+	job := newJob(mdb, "backup", mdbv1.BackupJob, time.Now(), "")
+	assert.Equal(t, []reconcile.Request{{NamespacedName: mdb.NamespacedName()}}, jobOwnerRequests(&job))
+
+	delete(job.Labels, mdbv1.JobResourceLabel)
+	assert.Empty(t, jobOwnerRequests(&job))
+}
+
+func newJob(mdb mdbv1.MongoDBCommunity, name string, jobType mdbv1.JobType, created time.Time, conditionType batchv1.JobConditionType) batchv1.Job {
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         mdb.Namespace,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				mdbv1.JobResourceLabel: mdb.Name,
+				mdbv1.JobTypeLabel:     string(jobType),
+			},
+		},
+	}
+	if conditionType != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type:               conditionType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(created.Add(time.Minute)),
+		}}
+	}
+	return job
+}
+
+func newCronJob(mdb mdbv1.MongoDBCommunity, name, schedule string, suspend bool) batchv1beta1.CronJob {
+	return batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mdb.Namespace,
+			Labels: map[string]string{
+				mdbv1.JobResourceLabel: mdb.Name,
+				mdbv1.JobTypeLabel:     string(mdbv1.BackupJob),
+			},
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule: schedule,
+			Suspend:  &suspend,
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// keyfileSecretRotationID identifies the rotations started because the keyfile in keyfileSecretRef changed.
+const keyfileSecretRotationID = "keyfileSecretRef"
+
+// keyfilePattern matches the characters a keyfile may consist of, the base64 set.
+// See https://docs.mongodb.com/manual/core/security-internal-authentication/#keyfiles
+var keyfilePattern = regexp.MustCompile(`^[A-Za-z0-9+/=]*$`)
+
+// reconcileKeyfileSecret copies the keyfile supplied in keyfileSecretRef into the agent keyfile Secret. The
+// keyfile is copied directly if the agent keyfile Secret does not exist yet, otherwise the new keyfile is
+// rolled out by a keyfile rotation, so that the members can authenticate to each other throughout. The returned
+// boolean is false if the Secret does not exist yet.
+func (r *ReplicaSetReconciler) reconcileKeyfileSecret(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	if mdb.Spec.Security.Authentication.KeyfileSecretRef == nil {
+		return true, nil
+	}
+	if mdb.Spec.Security.KeyfileRotation != nil {
+		return false, errors.New("keyfileRotation can not be combined with keyfileSecretRef, update the keyfile in the Secret instead")
+	}
+
+	// watch the keyfile Secret to roll out its changes
+	r.secretWatcher.Watch(mdb.KeyfileSecretNamespacedName(), mdb.NamespacedName())
+	desired, err := secret.ReadKey(r.client, scram.AgentKeyfileKey, mdb.KeyfileSecretNamespacedName())
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			r.log.Warnf(`Keyfile Secret "%s" not found`, mdb.KeyfileSecretNamespacedName())
+			return false, nil
+		}
+		return false, err
+	}
+	desired = strings.TrimSpace(desired)
+	if err := validateKeyfile(desired); err != nil {
+		return false, errors.Errorf(`invalid keyfile in Secret "%s": %s`, mdb.KeyfileSecretNamespacedName(), err)
+	}
+
+	current, err := secret.EnsureSecretWithKey(r.client, mdb.GetAgentKeyfileSecretNamespacedName(), mdb.GetOwnerReferences(), scram.AgentKeyfileKey, desired)
+	if err != nil {
+		return false, errors.Errorf("could not store the keyfile: %s", err)
+	}
+	if strings.TrimSpace(current) == desired || mdb.IsRotatingKeyfile() {
+		// a change during a rotation is rolled out once the rotation has completed.
+		return true, nil
+	}
+
+	if err := secret.UpdateField(r.client, mdb.GetAgentKeyfileSecretNamespacedName(), nextKeyfileKey, desired); err != nil {
+		return false, errors.Errorf("could not store the new keyfile: %s", err)
+	}
+	r.log.Infof(`The keyfile in Secret "%s" has changed, rotating the keyfile`, mdb.KeyfileSecretNamespacedName())
+	return true, r.updateKeyfileRotationStatus(mdb, mdbv1.KeyfileRotationStatus{
+		RotationID:       keyfileSecretRotationID,
+		Phase:            mdbv1.KeyfileRotationAddingNewKey,
+		LastRotationTime: lastKeyfileRotationTime(*mdb),
+	})
+}
+
+// validateKeyfile returns an error if the given contents are not accepted by mongod as a keyfile.
+func validateKeyfile(contents string) error {
+	key := strings.Join(strings.Fields(contents), "")
+	if len(key) < 6 || len(key) > 1024 || !keyfilePattern.MatchString(key) {
+		return errors.New("the keyfile must consist of 6 to 1024 characters of the base64 set")
+	}
+	return nil
+}
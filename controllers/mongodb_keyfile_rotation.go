@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/generate"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// nextKeyfileKey is the key in the agent keyfile Secret which stores the new key during a rotation.
+const nextKeyfileKey = "keyfile-next"
+
+// agentPasswordLength is the length of the password of the agent, as generated by scram.Enable.
+const agentPasswordLength = 20
+
+// startKeyfileRotation generates the new key and moves the rotation to the AddingNewKey phase
+// if a rotation has been requested, or the rotation interval has elapsed, and no other rotation
+// is in progress.
+func (r *ReplicaSetReconciler) startKeyfileRotation(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	if mdb.IsRotatingKeyfile() {
+		return nil
+	}
+	requested := mdb.IsKeyfileRotationRequested()
+	scheduled := !requested && isKeyfileRotationScheduled(*mdb) && untilScheduledKeyfileRotation(*mdb, now) <= 0
+	if !requested && !scheduled {
+		return nil
+	}
+
+	rotationID := mdb.Spec.Security.KeyfileRotation.RotationID
+	keyfileSecret, err := r.client.GetSecret(mdb.GetAgentKeyfileSecretNamespacedName())
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		if scheduled {
+			// the keyfile of a resource which has not been deployed yet is generated later in the reconciliation,
+			// the rotation starts once it exists. Otherwise the Secret has been deleted, and the rotation, which
+			// stays due, is retried with backoff.
+			prevSpec, err := lastSuccessfulSpec(*mdb)
+			if err != nil || prevSpec == nil {
+				return err
+			}
+			return errors.Errorf("the rotation interval has elapsed, but the keyfile Secret %s does not exist", mdb.GetAgentKeyfileSecretNamespacedName())
+		}
+		// the keyfile has not been generated yet, there is nothing to rotate.
+		r.log.Infof("Keyfile has not been created yet, skipping rotation %s", rotationID)
+		return r.updateKeyfileRotationStatus(mdb, mdbv1.KeyfileRotationStatus{
+			RotationID:       rotationID,
+			Phase:            mdbv1.KeyfileRotationCompleted,
+			LastRotationTime: lastKeyfileRotationTime(*mdb),
+			Scheduled:        scheduled,
+		})
+	}
+
+	if _, ok := keyfileSecret.Data[nextKeyfileKey]; !ok {
+		newKey, err := generate.KeyFileContents()
+		if err != nil {
+			return errors.Errorf("could not generate keyfile contents: %s", err)
+		}
+		if err := secret.UpdateField(r.client, mdb.GetAgentKeyfileSecretNamespacedName(), nextKeyfileKey, newKey); err != nil {
+			return errors.Errorf("could not store the new keyfile: %s", err)
+		}
+	}
+
+	if scheduled {
+		r.log.Infof("Rotation interval has elapsed, starting scheduled keyfile rotation")
+	} else {
+		r.log.Infof("Starting keyfile rotation %s", rotationID)
+	}
+	return r.updateKeyfileRotationStatus(mdb, mdbv1.KeyfileRotationStatus{
+		RotationID:       rotationID,
+		Phase:            mdbv1.KeyfileRotationAddingNewKey,
+		LastRotationTime: lastKeyfileRotationTime(*mdb),
+		Scheduled:        scheduled,
+	})
+}
+
+// validateKeyfileRotation checks the rotation interval of the keyfile.
+func validateKeyfileRotation(mdb mdbv1.MongoDBCommunity) error {
+	rotation := mdb.Spec.Security.KeyfileRotation
+	if rotation == nil {
+		return nil
+	}
+	if _, err := parsePositiveDuration(rotation.RotationInterval, 0); err != nil {
+		return errors.Errorf("invalid keyfileRotation.rotationInterval: %s", err)
+	}
+	return nil
+}
+
+// isKeyfileRotationScheduled returns true if the keyfile is rotated once the rotation interval has elapsed.
+func isKeyfileRotationScheduled(mdb mdbv1.MongoDBCommunity) bool {
+	rotation := mdb.Spec.Security.KeyfileRotation
+	return rotation != nil && rotation.RotationInterval != "" && mdb.Spec.Security.Authentication.KeyfileSecretRef == nil
+}
+
+// untilScheduledKeyfileRotation returns how long it is until the next scheduled rotation of the keyfile, which
+// is zero or negative if it is due now. The interval is counted from the last rotation or, if the keyfile has
+// never been rotated, from the creation of the resource.
+func untilScheduledKeyfileRotation(mdb mdbv1.MongoDBCommunity, now time.Time) time.Duration {
+	interval, err := parsePositiveDuration(mdb.Spec.Security.KeyfileRotation.RotationInterval, 0)
+	if err != nil {
+		return 0
+	}
+	last := mdb.CreationTimestamp.Time
+	if t := lastKeyfileRotationTime(mdb); t != nil {
+		last = t.Time
+	}
+	return last.Add(interval).Sub(now)
+}
+
+// untilNextKeyfileRotation returns how long it is until the keyfile is due to be rotated, zero if no rotation
+// is scheduled.
+func untilNextKeyfileRotation(mdb mdbv1.MongoDBCommunity, now time.Time) time.Duration {
+	if !isKeyfileRotationScheduled(mdb) || mdb.IsRotatingKeyfile() {
+		return 0
+	}
+	until := untilScheduledKeyfileRotation(mdb, now)
+	if until <= 0 {
+		return time.Second
+	}
+	return until
+}
+
+// advanceKeyfileRotation moves an in progress rotation to its next phase. It must only be called
+// once all agents have reached goal state with the automation config of the current phase. The password of the
+// agent is replaced in its own phase once the old key is removed, so the agents never apply a new key and a new
+// password at once. The returned boolean is true if the rotation requires more automation config changes.
+func (r *ReplicaSetReconciler) advanceKeyfileRotation(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.IsRotatingKeyfile() {
+		return false, nil
+	}
+
+	rotation := *mdb.Status.KeyfileRotation
+	switch rotation.Phase {
+	case mdbv1.KeyfileRotationAddingNewKey:
+		// all members accept both keys, the new key can replace the old one.
+		if err := r.promoteNextKeyfile(*mdb); err != nil {
+			return false, errors.Errorf("could not promote the new keyfile: %s", err)
+		}
+		rotation.Phase = mdbv1.KeyfileRotationRemovingOldKey
+		r.log.Infof("New key has been added to all members, removing the old key")
+		return true, r.updateKeyfileRotationStatus(mdb, rotation)
+	case mdbv1.KeyfileRotationRemovingOldKey:
+		if rotation.RotationID != keyfileSecretRotationID && !rotation.AgentPasswordRotated {
+			rotated, err := r.rotateAgentPassword(*mdb)
+			if err != nil {
+				return false, errors.Errorf("could not rotate the password of the agent: %s", err)
+			}
+			if rotated {
+				rotation.AgentPasswordRotated = true
+				rotation.Phase = mdbv1.KeyfileRotationReplacingAgentPassword
+				r.log.Infof("Old key has been removed from all members, replacing the password of the agent")
+				return true, r.updateKeyfileRotationStatus(mdb, rotation)
+			}
+		}
+		return false, r.completeKeyfileRotation(mdb, rotation)
+	case mdbv1.KeyfileRotationReplacingAgentPassword:
+		return false, r.completeKeyfileRotation(mdb, rotation)
+	}
+	return false, nil
+}
+
+// completeKeyfileRotation moves the rotation to the Completed phase and records when it completed.
+func (r *ReplicaSetReconciler) completeKeyfileRotation(mdb *mdbv1.MongoDBCommunity, rotation mdbv1.KeyfileRotationStatus) error {
+	now := metav1.Now()
+	rotation.Phase = mdbv1.KeyfileRotationCompleted
+	rotation.LastRotationTime = &now
+	r.log.Infof("Keyfile rotation %s completed", rotation.RotationID)
+	return r.updateKeyfileRotationStatus(mdb, rotation)
+}
+
+// promoteNextKeyfile replaces the current keyfile with the new one generated for the rotation.
+func (r *ReplicaSetReconciler) promoteNextKeyfile(mdb mdbv1.MongoDBCommunity) error {
+	keyfileSecret, err := r.client.GetSecret(mdb.GetAgentKeyfileSecretNamespacedName())
+	if err != nil {
+		return err
+	}
+	nextKey, ok := keyfileSecret.Data[nextKeyfileKey]
+	if !ok {
+		// the new key has already been promoted.
+		return nil
+	}
+	keyfileSecret.Data[scram.AgentKeyfileKey] = nextKey
+	delete(keyfileSecret.Data, nextKeyfileKey)
+	return r.client.UpdateSecret(keyfileSecret)
+}
+
+// rotateAgentPassword replaces the password of the agent generated by the operator. The password is not
+// rotated if it is provided in agentCredentialsSecretRef or has not been generated. The returned boolean is
+// true if the password was replaced.
+func (r *ReplicaSetReconciler) rotateAgentPassword(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	if mdb.Spec.Security.Authentication.AgentCredentialsSecretRef != nil {
+		return false, nil
+	}
+	if _, err := secret.ReadKey(r.client, scram.AgentPasswordKey, mdb.GetAgentPasswordSecretNamespacedName()); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	password, err := r.passwords.Password(agentPasswordLength)
+	if err != nil {
+		return false, err
+	}
+	if err := secret.UpdateField(r.client, mdb.GetAgentPasswordSecretNamespacedName(), scram.AgentPasswordKey, password); err != nil {
+		return false, err
+	}
+	r.log.Infof("Replaced the password of the agent")
+	return true, nil
+}
+
+func (r *ReplicaSetReconciler) updateKeyfileRotationStatus(mdb *mdbv1.MongoDBCommunity, rotation mdbv1.KeyfileRotationStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withKeyfileRotation(rotation))
+	return err
+}
+
+// getKeyfileRotationModification returns a modification which configures the members with both the
+// old and the new key while the new key is being added.
+func getKeyfileRotationModification(getter secret.Getter, mdb mdbv1.MongoDBCommunity) (automationconfig.Modification, error) {
+	if !mdb.IsRotatingKeyfile() || mdb.Status.KeyfileRotation.Phase != mdbv1.KeyfileRotationAddingNewKey {
+		return automationconfig.NOOP(), nil
+	}
+
+	data, err := secret.ReadStringData(getter, mdb.GetAgentKeyfileSecretNamespacedName())
+	if err != nil {
+		return automationconfig.NOOP(), err
+	}
+
+	nextKey, ok := data[nextKeyfileKey]
+	if !ok {
+		// the new key has already been promoted, the current key is the new key.
+		return automationconfig.NOOP(), nil
+	}
+
+	return func(config *automationconfig.AutomationConfig) {
+		config.Auth.Key = multipleKeyfileContents(data[scram.AgentKeyfileKey], nextKey)
+	}, nil
+}
+
+// multipleKeyfileContents returns the contents of a keyfile containing multiple keys,
+// mongod accepts connections authenticated with any of them.
+// See https://docs.mongodb.com/manual/tutorial/rotate-key-replica-set/
+func multipleKeyfileContents(keys ...string) string {
+	contents := ""
+	for _, key := range keys {
+		contents += fmt.Sprintf("- %s\n", key)
+	}
+	return contents
+}
+
+func lastKeyfileRotationTime(mdb mdbv1.MongoDBCommunity) *metav1.Time {
+	if mdb.Status.KeyfileRotation == nil {
+		return nil
+	}
+	return mdb.Status.KeyfileRotation.LastRotationTime
+}
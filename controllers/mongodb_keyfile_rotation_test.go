@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestKeyfileRotation(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	oldKey, err := secret.ReadKey(mgr.Client, scram.AgentKeyfileKey, mdb.GetAgentKeyfileSecretNamespacedName())
+	assert.NoError(t, err)
+
+	// request a rotation
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	mdb.Spec.Security.KeyfileRotation = &mdbv1.KeyfileRotation{RotationID: "1"}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	// first phase: both keys are configured
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 0)
+
+	newKey, err := secret.ReadKey(mgr.Client, scram.AgentKeyfileKey, mdb.GetAgentKeyfileSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldKey, newKey, "the new key should have been promoted once all agents accepted both keys")
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "- "+oldKey+"\n- "+newKey+"\n", ac.Auth.Key)
+
+	assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationRemovingOldKey)
+
+	// second phase: only the new key is configured
+	res, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 0)
+
+	ac, err = automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, newKey, ac.Auth.Key)
+	assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationReplacingAgentPassword)
+
+	// third phase: the password of the agent is replaced
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	rotation := assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationCompleted)
+	assert.NotNil(t, rotation.LastRotationTime)
+
+	// reconciling again does not start another rotation
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	key, err := secret.ReadKey(mgr.Client, scram.AgentKeyfileKey, mdb.GetAgentKeyfileSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, newKey, key)
+}
+
+func TestKeyfileRotation_IsScheduled(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.CreationTimestamp = metav1.Now()
+	mdb.Spec.Security.KeyfileRotation = &mdbv1.KeyfileRotation{RotationInterval: "720h"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 719*time.Hour, "the resource is reconciled again when the rotation is due")
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Nil(t, mdb.Status.KeyfileRotation, "the keyfile is not rotated before the interval has elapsed")
+
+	oldKey, err := secret.ReadKey(mgr.Client, scram.AgentKeyfileKey, mdb.GetAgentKeyfileSecretNamespacedName())
+	assert.NoError(t, err)
+	oldPassword, err := secret.ReadKey(mgr.Client, scram.AgentPasswordKey, mdb.GetAgentPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.startKeyfileRotation(&mdb, time.Now().Add(721*time.Hour)))
+	rotation := assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationAddingNewKey)
+	assert.True(t, rotation.Scheduled)
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	rotation = assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationRemovingOldKey)
+	assert.False(t, rotation.AgentPasswordRotated, "the password is not replaced while the old key is removed")
+	password, err := secret.ReadKey(mgr.Client, scram.AgentPasswordKey, mdb.GetAgentPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, oldPassword, password)
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	rotation = assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationReplacingAgentPassword)
+	assert.True(t, rotation.AgentPasswordRotated)
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, oldPassword, ac.Auth.AutoPwd, "the password is replaced once the agents applied the new key only")
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	rotation = assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationCompleted)
+	assert.True(t, rotation.AgentPasswordRotated)
+	if assert.NotNil(t, rotation.LastRotationTime) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.InDelta(t, 720*time.Hour, untilNextKeyfileRotation(mdb, time.Now()), float64(time.Minute))
+	}
+
+	newKey, err := secret.ReadKey(mgr.Client, scram.AgentKeyfileKey, mdb.GetAgentKeyfileSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldKey, newKey)
+	newPassword, err := secret.ReadKey(mgr.Client, scram.AgentPasswordKey, mdb.GetAgentPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldPassword, newPassword)
+
+	ac, err = automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, newKey, ac.Auth.Key)
+	assert.Equal(t, newPassword, ac.Auth.AutoPwd)
+}
+
+func TestValidateKeyfileRotation(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.KeyfileRotation = &mdbv1.KeyfileRotation{RotationInterval: "720h"}
+	assert.NoError(t, validateKeyfileRotation(mdb))
+
+	mdb.Spec.Security.KeyfileRotation.RotationInterval = "monthly"
+	assert.Error(t, validateKeyfileRotation(mdb))
+}
+
+func TestKeyfileRotation_IsSkippedWithoutKeyfile(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.KeyfileRotation = &mdbv1.KeyfileRotation{RotationID: "1"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationCompleted)
+}
+
+func TestKeyfileRotation_ScheduledWithoutKeyfile(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	mdb.Spec.Security.KeyfileRotation = &mdbv1.KeyfileRotation{RotationInterval: "1h"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	assert.NoError(t, r.startKeyfileRotation(&mdb, time.Now()), "the keyfile of a new resource is generated later")
+	assert.Nil(t, mdb.Status.KeyfileRotation)
+
+	mdb.Annotations = map[string]string{lastSuccessfulConfiguration: "{}"}
+	err := r.startKeyfileRotation(&mdb, time.Now())
+	assert.EqualError(t, err, "the rotation interval has elapsed, but the keyfile Secret my-ns/my-rs-keyfile does not exist")
+	assert.Nil(t, mdb.Status.KeyfileRotation, "the rotation is not marked as completed")
+}
+
+func TestMultipleKeyfileContents(t *testing.T) {
+	assert.Equal(t, "- a\n- b\n", multipleKeyfileContents("a", "b"))
+}
+
+func assertKeyfileRotationPhase(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, phase mdbv1.KeyfileRotationPhase) mdbv1.KeyfileRotationStatus {
+	err := mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	if assert.NotNil(t, mdb.Status.KeyfileRotation) {
+		assert.Equal(t, phase, mdb.Status.KeyfileRotation.Phase)
+		return *mdb.Status.KeyfileRotation
+	}
+	return mdbv1.KeyfileRotationStatus{}
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func createKeyfileSecret(t *testing.T, c secret.GetUpdateCreator, mdb mdbv1.MongoDBCommunity, key string) {
+	s := secret.Builder().
+		SetName(mdb.KeyfileSecretNamespacedName().Name).
+		SetNamespace(mdb.Namespace).
+		SetField(scram.AgentKeyfileKey, key).
+		Build()
+	assert.NoError(t, secret.CreateOrUpdate(c, s))
+}
+
+func TestKeyfileSecret_IsUsedAndRotated(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.Authentication.KeyfileSecretRef = &mdbv1.LocalObjectReference{Name: "my-keyfile"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	readACKey := func() string {
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		return ac.Auth.Key
+	}
+
+	t.Run("The reconciliation waits for the Secret", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.RequeueAfter > 0)
+	})
+
+	oldKey := strings.Repeat("a", 756)
+	createKeyfileSecret(t, mgr.Client, mdb, oldKey+"\n")
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.Equal(t, oldKey, readACKey(), "the supplied keyfile is used instead of a generated one")
+
+	t.Run("A changed keyfile is rolled out in two phases", func(t *testing.T) {
+		newKey := strings.Repeat("b", 756)
+		createKeyfileSecret(t, mgr.Client, mdb, newKey)
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.RequeueAfter > 0)
+		assert.Equal(t, "- "+oldKey+"\n- "+newKey+"\n", readACKey())
+
+		res, err = r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assert.Equal(t, newKey, readACKey())
+		assertKeyfileRotationPhase(t, mgr, mdb, mdbv1.KeyfileRotationCompleted)
+	})
+
+	t.Run("An invalid keyfile is rejected", func(t *testing.T) {
+		createKeyfileSecret(t, mgr.Client, mdb, "not a keyfile!")
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "invalid keyfile")
+	})
+}
+
+func TestValidateKeyfile(t *testing.T) {
+	assert.NoError(t, validateKeyfile("abcdef"))
+	assert.NoError(t, validateKeyfile("abc+/=\nDEF123"))
+	assert.Error(t, validateKeyfile("abc"))
+	assert.Error(t, validateKeyfile(strings.Repeat("a", 1025)))
+	assert.Error(t, validateKeyfile("abc-def"))
+}
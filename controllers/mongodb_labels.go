@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// Label names of the metrics of a resource, which correspond to the namespace and the mongodb.com/resource label.
+const (
+	metricLabelNamespace = "namespace"
+	metricLabelName      = "name"
+)
+
+// labelSchemaModification sets the labels of the label schema on the StatefulSet and its Pod template. The Pod
+// template of a StatefulSet created by an older operator version is not changed, as that would restart all
+// members; ensureLabelSchema labels its Pods directly instead.
+func labelSchemaModification(mdb mdbv1.MongoDBCommunity, previousPodLabels map[string]string, exists bool) statefulset.Modification {
+	labels := mdb.SchemaLabels(mdbv1.ComponentDatabase)
+	_, podsLabelled := previousPodLabels[mdbv1.LabelResource]
+	return func(set *appsv1.StatefulSet) {
+		mergeLabels(&set.ObjectMeta, labels)
+		if !exists || podsLabelled {
+			mergeLabels(&set.Spec.Template.ObjectMeta, labels)
+		}
+	}
+}
+
+// ensureLabelSchema sets the labels of the label schema on the objects owned by the resource and the Pods of its
+// members which do not have them yet, such as the objects created by older operator versions. Jobs are labelled
+// when they are created, as they only exist for the duration of an operation.
+func (r ReplicaSetReconciler) ensureLabelSchema(mdb mdbv1.MongoDBCommunity) error {
+	lists := []k8sClient.ObjectList{&corev1.SecretList{}, &corev1.ConfigMapList{}, &corev1.ServiceList{}, &appsv1.StatefulSetList{}}
+	for _, list := range lists {
+		if err := r.client.List(context.TODO(), list, k8sClient.InNamespace(mdb.Namespace)); err != nil {
+			return err
+		}
+		if err := r.labelObjects(mdb, list, func(obj k8sClient.Object) bool { return metav1.IsControlledBy(obj, &mdb) }); err != nil {
+			return err
+		}
+	}
+
+	pods := corev1.PodList{}
+	if err := r.client.List(context.TODO(), &pods, k8sClient.InNamespace(mdb.Namespace), k8sClient.MatchingLabels{"app": mdb.ServiceName()}); err != nil {
+		return err
+	}
+	return r.labelObjects(mdb, &pods, func(k8sClient.Object) bool { return true })
+}
+
+// labelObjects sets the labels of the label schema on the objects in the given list matching the filter.
+func (r ReplicaSetReconciler) labelObjects(mdb mdbv1.MongoDBCommunity, list k8sClient.ObjectList, filter func(k8sClient.Object) bool) error {
+	for _, obj := range listItems(list) {
+		if !filter(obj) {
+			continue
+		}
+		meta := metav1.ObjectMeta{Labels: obj.GetLabels()}
+		if !mergeLabels(&meta, mdb.SchemaLabels(schemaComponent(mdb, obj))) {
+			continue
+		}
+		obj.SetLabels(meta.Labels)
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return errors.Errorf("could not label %s: %s", obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// listItems returns the objects of the given list.
+func listItems(list k8sClient.ObjectList) []k8sClient.Object {
+	var items []k8sClient.Object
+	switch l := list.(type) {
+	case *corev1.SecretList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *corev1.ConfigMapList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *corev1.ServiceList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *appsv1.StatefulSetList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *corev1.PodList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	default:
+		panic(fmt.Sprintf("unsupported list type %T", list))
+	}
+	return items
+}
+
+// schemaComponent returns the value of the app.kubernetes.io/component label of the given object.
+func schemaComponent(mdb mdbv1.MongoDBCommunity, obj k8sClient.Object) string {
+	switch obj.(type) {
+	case *corev1.Secret:
+		switch obj.GetName() {
+		case mdb.AutomationConfigSecretName():
+			return mdbv1.ComponentAutomationConfig
+		case mdb.TLSOperatorSecretNamespacedName().Name, mdb.TLSMemberOperatorSecretNamespacedName().Name:
+			return mdbv1.ComponentTLS
+		}
+		return mdbv1.ComponentCredentials
+	case *corev1.ConfigMap:
+		if obj.GetName() == mdb.CABundleConfigMapNamespacedName().Name {
+			return mdbv1.ComponentTLS
+		}
+		if obj.GetName() == mdb.PrometheusWebConfigMapName() {
+			return mdbv1.ComponentMetrics
+		}
+		if obj.GetLabels()[mdbv1.LabelAppComponent] == mdbv1.ComponentDiagnostics {
+			return mdbv1.ComponentDiagnostics
+		}
+		return mdbv1.ComponentConfiguration
+	case *corev1.Service:
+		if obj.GetName() == mdb.PrometheusServiceName() {
+			return mdbv1.ComponentMetrics
+		}
+		if obj.GetLabels()[mdbv1.LabelAppComponent] == mdbv1.ComponentExternalAccess {
+			return mdbv1.ComponentExternalAccess
+		}
+	}
+	return mdbv1.ComponentDatabase
+}
+
+// mergeLabels adds the given labels to the object, and returns true if any label was added or changed.
+func mergeLabels(meta *metav1.ObjectMeta, labels map[string]string) bool {
+	changed := false
+	for k, v := range labels {
+		if existing, ok := meta.Labels[k]; ok && existing == v {
+			continue
+		}
+		if meta.Labels == nil {
+			meta.Labels = map[string]string{}
+		}
+		meta.Labels[k] = v
+		changed = true
+	}
+	return changed
+}
+
+// recordPhaseEvent records an Event if the reconciliation moved the resource to the Failed or the Running phase.
+// The Event is annotated with the labels of the label schema.
+func (r ReplicaSetReconciler) recordPhaseEvent(mdb *mdbv1.MongoDBCommunity, previousPhase mdbv1.Phase) {
+	if r.recorder == nil || mdb.Status.Phase == previousPhase {
+		return
+	}
+	annotations := mdb.SchemaLabels(mdbv1.ComponentDatabase)
+	switch mdb.Status.Phase {
+	case mdbv1.Failed:
+		r.recorder.AnnotatedEventf(mdb, annotations, corev1.EventTypeWarning, "ReconcileFailed", "%s", mdb.Status.Message)
+	case mdbv1.Running:
+		r.recorder.AnnotatedEventf(mdb, annotations, corev1.EventTypeNormal, "Running", "MongoDB replica set %s is running", mdb.GetReplicaSetName())
+	}
+}
+
+// setJobMetadata sets the labels of the label schema on the given Job and on its Pod template, so that the Pods
+// of the Job are admitted by the NetworkPolicy of the members, and disables the injection of a service mesh
+// proxy into them.
+func setJobMetadata(mdb mdbv1.MongoDBCommunity, job *batchv1.Job) {
+	job.Labels = mdb.SchemaLabels(mdbv1.ComponentJob)
+	mergeLabels(&job.Spec.Template.ObjectMeta, mdb.SchemaLabels(mdbv1.ComponentJob))
+	disableSidecarInjection(mdb, &job.Spec.Template.ObjectMeta)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func assertSchemaLabels(t *testing.T, mdb mdbv1.MongoDBCommunity, component string, labels map[string]string) {
+	for k, v := range mdb.SchemaLabels(component) {
+		assert.Equal(t, v, labels[k], "label %s", k)
+	}
+}
+
+func TestLabelSchema_IsAppliedToGeneratedObjects(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	assertSchemaLabels(t, mdb, mdbv1.ComponentDatabase, sts.Labels)
+	assertSchemaLabels(t, mdb, mdbv1.ComponentDatabase, sts.Spec.Template.Labels)
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, sts.Spec.Selector.MatchLabels, "the selector is not changed")
+
+	svc := corev1.Service{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.ServiceName(), Namespace: mdb.Namespace}, &svc))
+	assertSchemaLabels(t, mdb, mdbv1.ComponentDatabase, svc.Labels)
+
+	acSecret := corev1.Secret{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}, &acSecret))
+	assertSchemaLabels(t, mdb, mdbv1.ComponentAutomationConfig, acSecret.Labels)
+}
+
+func TestLabelSchema_MigratesObjectsOfOlderOperatorVersions(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+
+	replicas := int32(mdb.Spec.Members)
+	// a StatefulSet and Pods as created by an operator version without the label schema
+	sts := appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: mdb.Name, Namespace: mdb.Namespace, OwnerReferences: mdb.GetOwnerReferences()},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": mdb.ServiceName()}},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": mdb.ServiceName()}}},
+		},
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &sts))
+	for i := 0; i < mdb.Spec.Members; i++ {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(i), Namespace: mdb.Namespace, Labels: map[string]string{"app": mdb.ServiceName()}}}
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	}
+
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	assertSchemaLabels(t, mdb, mdbv1.ComponentDatabase, sts.Labels)
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, sts.Spec.Template.Labels, "the Pod template is not changed to avoid restarting the members")
+
+	pod := corev1.Pod{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(0), Namespace: mdb.Namespace}, &pod))
+	assertSchemaLabels(t, mdb, mdbv1.ComponentDatabase, pod.Labels)
+	assert.Equal(t, mdb.ServiceName(), pod.Labels["app"])
+}
+
+func TestRecordPhaseEvent(t *testing.T) {
+	mdb := newTestReplicaSet()
+	recorder := record.NewFakeRecorder(10)
+	r := ReplicaSetReconciler{recorder: recorder}
+
+	mdb.Status.Phase = mdbv1.Running
+	r.recordPhaseEvent(&mdb, mdbv1.Running)
+	assert.Empty(t, recorder.Events, "no Event is recorded if the phase does not change")
+
+	mdb.Status.Phase = mdbv1.Failed
+	mdb.Status.Message = "Error ensuring the keyfile"
+	r.recordPhaseEvent(&mdb, mdbv1.Running)
+	assert.Equal(t, "Warning ReconcileFailed Error ensuring the keyfile", <-recorder.Events)
+
+	mdb.Status.Phase = mdbv1.Running
+	r.recordPhaseEvent(&mdb, mdbv1.Failed)
+	assert.Equal(t, "Normal Running MongoDB replica set my-rs is running", <-recorder.Events)
+}
+
+func TestSchemaComponent(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	secret := func(name string) *corev1.Secret { return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}} }
+
+	assert.Equal(t, mdbv1.ComponentAutomationConfig, schemaComponent(mdb, secret(mdb.AutomationConfigSecretName())))
+	assert.Equal(t, mdbv1.ComponentTLS, schemaComponent(mdb, secret(mdb.TLSOperatorSecretNamespacedName().Name)))
+	assert.Equal(t, mdbv1.ComponentCredentials, schemaComponent(mdb, secret(mdb.GetAgentKeyfileSecretNamespacedName().Name)))
+	assert.Equal(t, mdbv1.ComponentTLS, schemaComponent(mdb, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: mdb.CABundleConfigMapNamespacedName().Name}}))
+	assert.Equal(t, mdbv1.ComponentDatabase, schemaComponent(mdb, &corev1.Service{}))
+}
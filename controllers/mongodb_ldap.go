@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// ldapAuthMechanism is the mechanism mongod uses to authenticate users against LDAP.
+	ldapAuthMechanism = "PLAIN"
+	ldapBindMethod    = "simple"
+)
+
+// validateLDAPConfig will check that the configured bind query password Secret and the optional
+// CA ConfigMap exist and contain the expected fields.
+func (r *ReplicaSetReconciler) validateLDAPConfig(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.Spec.Security.Authentication.IsLDAPEnabled() {
+		return true, nil
+	}
+
+	ldap := mdb.Spec.Security.Authentication.LDAP
+	if ldap == nil {
+		return false, errors.New(`spec.security.authentication.ldap must be specified when "LDAP" authentication is enabled`)
+	}
+
+	if len(ldap.Servers) == 0 {
+		return false, errors.New("at least one LDAP server must be specified")
+	}
+
+	r.log.Info("Ensuring LDAP is correctly configured")
+
+	// Watch the bind query password secret to handle password changes
+	r.secretWatcher.Watch(mdb.LDAPBindQueryPasswordSecretNamespacedName(), mdb.NamespacedName())
+
+	secretData, err := secret.ReadStringData(r.client, mdb.LDAPBindQueryPasswordSecretNamespacedName())
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			r.log.Warnf(`LDAP bind query password Secret "%s" not found`, mdb.LDAPBindQueryPasswordSecretNamespacedName())
+			return false, nil
+		}
+		return false, err
+	}
+
+	if password, ok := secretData[ldap.GetBindQueryPasswordSecretKey()]; !ok || password == "" {
+		r.log.Warnf(`Secret "%s" should have the LDAP bind query password in field "%s"`, mdb.LDAPBindQueryPasswordSecretNamespacedName(), ldap.GetBindQueryPasswordSecretKey())
+		return false, nil
+	}
+
+	if ldap.CaConfigMap != nil {
+		caData, err := configmap.ReadData(r.client, mdb.LDAPConfigMapNamespacedName())
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				r.log.Warnf(`LDAP CA ConfigMap "%s" not found`, mdb.LDAPConfigMapNamespacedName())
+				return false, nil
+			}
+			return false, err
+		}
+
+		if cert, ok := caData[tlsCACertName]; !ok || cert == "" {
+			r.log.Warnf(`ConfigMap "%s" should have a CA certificate in field "%s"`, mdb.LDAPConfigMapNamespacedName(), tlsCACertName)
+			return false, nil
+		}
+	}
+
+	r.log.Infof("Successfully validated LDAP config")
+	return true, nil
+}
+
+// getLDAPConfigModification creates a modification function which enables LDAP authentication
+// in the automation config.
+func getLDAPConfigModification(client kubernetesClient.Client, mdb mdbv1.MongoDBCommunity) (automationconfig.Modification, error) {
+	if !mdb.IsAuthModeEnabled(mdbv1.AuthModeLDAP) || mdb.Spec.Security.Authentication.LDAP == nil {
+		return automationconfig.NOOP(), nil
+	}
+
+	ldap := *mdb.Spec.Security.Authentication.LDAP
+
+	password, err := secret.ReadKey(client, ldap.GetBindQueryPasswordSecretKey(), mdb.LDAPBindQueryPasswordSecretNamespacedName())
+	if err != nil {
+		return automationconfig.NOOP(), errors.Errorf("could not read LDAP bind query password: %s", err)
+	}
+
+	caContents := ""
+	if ldap.CaConfigMap != nil {
+		caContents, err = configmap.ReadKey(client, tlsCACertName, mdb.LDAPConfigMapNamespacedName())
+		if err != nil {
+			return automationconfig.NOOP(), errors.Errorf("could not read LDAP CA certificate: %s", err)
+		}
+	}
+
+	return ldapConfigModification(automationconfig.Ldap{
+		Servers:                  strings.Join(ldap.Servers, ","),
+		TransportSecurity:        string(ldap.GetTransportSecurity()),
+		BindMethod:               ldapBindMethod,
+		BindQueryUser:            ldap.BindQueryUser,
+		BindQueryPassword:        password,
+		UserToDNMapping:          ldap.UserToDNMapping,
+		AuthzQueryTemplate:       ldap.AuthzQueryTemplate,
+		ValidateLDAPServerConfig: ldap.ShouldValidateLDAPServerConfig(),
+		TimeoutMS:                ldap.TimeoutMS,
+		CaFileContents:           caContents,
+	}), nil
+}
+
+// ldapConfigModification will configure the LDAP servers and enable the LDAP mechanism in the automation config.
+func ldapConfigModification(ldap automationconfig.Ldap) automationconfig.Modification {
+	return func(config *automationconfig.AutomationConfig) {
+		config.Ldap = &ldap
+		if !contains.String(config.Auth.DeploymentAuthMechanisms, ldapAuthMechanism) {
+			config.Auth.DeploymentAuthMechanisms = append(config.Auth.DeploymentAuthMechanisms, ldapAuthMechanism)
+		}
+	}
+}
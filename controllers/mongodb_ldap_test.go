@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestLDAP_ReconciliationIsPendingWithoutBindSecret(t *testing.T) {
+	mdb := newTestReplicaSetWithLDAP()
+	mgr := client.NewManager(&mdb)
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 0)
+
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+
+	// the automation config must not be published before the bind secret exists
+	_, err = mgr.Client.GetSecret(types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.Error(t, err)
+}
+
+func TestLDAP_ReconciliationFailsWithoutLDAPConfiguration(t *testing.T) {
+	mdb := newTestReplicaSetWithLDAP()
+	mdb.Spec.Security.Authentication.LDAP = nil
+	mgr := client.NewManager(&mdb)
+
+	r := NewReconciler(mgr)
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
+	assert.NoError(t, err)
+
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+}
+
+func TestLDAP_AutomationConfigIsConfigured(t *testing.T) {
+	mdb := newTestReplicaSetWithLDAP()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createLDAPSecretAndConfigMap(mgr.GetClient(), mdb))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+
+	assert.NotNil(t, ac.Ldap)
+	assert.Equal(t, "ldap-0.example.com:636,ldap-1.example.com:636", ac.Ldap.Servers)
+	assert.Equal(t, "tls", ac.Ldap.TransportSecurity)
+	assert.Equal(t, "simple", ac.Ldap.BindMethod)
+	assert.Equal(t, "cn=admin,dc=example,dc=com", ac.Ldap.BindQueryUser)
+	assert.Equal(t, "bind-password", ac.Ldap.BindQueryPassword)
+	assert.Equal(t, `[{match: "(.+)", substitution: "uid={0},dc=example,dc=com"}]`, ac.Ldap.UserToDNMapping)
+	assert.True(t, ac.Ldap.ValidateLDAPServerConfig)
+	assert.Equal(t, "CERT", ac.Ldap.CaFileContents)
+
+	assert.Contains(t, ac.Auth.DeploymentAuthMechanisms, "PLAIN")
+	assert.Contains(t, ac.Auth.DeploymentAuthMechanisms, "SCRAM-SHA-256")
+}
+
+func TestLDAP_ConfigModificationIsIdempotent(t *testing.T) {
+	ac := automationconfig.AutomationConfig{}
+	modification := ldapConfigModification(automationconfig.Ldap{Servers: "ldap.example.com"})
+	modification(&ac)
+	modification(&ac)
+	assert.Equal(t, []string{"PLAIN"}, ac.Auth.DeploymentAuthMechanisms)
+	assert.Equal(t, "ldap.example.com", ac.Ldap.Servers)
+}
+
+func newTestReplicaSetWithLDAP() mdbv1.MongoDBCommunity {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.Authentication.Modes = []mdbv1.AuthMode{mdbv1.AuthModeScram, mdbv1.AuthModeLDAP}
+	mdb.Spec.Security.Authentication.LDAP = &mdbv1.LDAP{
+		Servers:       []string{"ldap-0.example.com:636", "ldap-1.example.com:636"},
+		BindQueryUser: "cn=admin,dc=example,dc=com",
+		BindQueryPasswordSecretRef: mdbv1.SecretKeyReference{
+			Name: "ldap-bind-password",
+		},
+		UserToDNMapping: `[{match: "(.+)", substitution: "uid={0},dc=example,dc=com"}]`,
+		CaConfigMap: &mdbv1.LocalObjectReference{
+			Name: "ldap-ca",
+		},
+	}
+	return mdb
+}
+
+func createLDAPSecretAndConfigMap(c k8sClient.Client, mdb mdbv1.MongoDBCommunity) error {
+	s := secret.Builder().
+		SetName(mdb.Spec.Security.Authentication.LDAP.BindQueryPasswordSecretRef.Name).
+		SetNamespace(mdb.Namespace).
+		SetField("password", "bind-password").
+		Build()
+
+	if err := c.Create(context.TODO(), &s); err != nil {
+		return err
+	}
+
+	configMap := configmap.Builder().
+		SetName(mdb.Spec.Security.Authentication.LDAP.CaConfigMap.Name).
+		SetNamespace(mdb.Namespace).
+		SetField("ca.crt", "CERT").
+		Build()
+
+	return c.Create(context.TODO(), &configMap)
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/probes"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// buildMongodLivenessProbePodSpecModification configures the liveness probe of the mongod container. The probe
+// is removed if it is not enabled, unless it is configured in the StatefulSet override.
+func buildMongodLivenessProbePodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	config := mdb.Spec.MongodLivenessProbe
+	if config == nil || !config.Enabled {
+		return podtemplatespec.WithContainer(construct.MongodbName, func(c *corev1.Container) {
+			c.LivenessProbe = nil
+		})
+	}
+
+	return podtemplatespec.WithContainer(construct.MongodbName, container.WithLivenessProbe(probes.Apply(
+		probes.WithExecCommand([]string{"/bin/sh", "-c", mongodLivenessProbeScript(mdb)}),
+		probes.WithInitialDelaySeconds(mongodLivenessInitialDelaySeconds(mdb)),
+		probes.WithPeriodSeconds(config.GetPeriodSeconds()),
+		probes.WithTimeoutSeconds(config.GetTimeoutSeconds()),
+		probes.WithFailureThreshold(config.GetFailureThreshold()),
+	)))
+}
+
+// mongodLivenessProbeScript returns the script which runs the "ping" command against the local mongod. The
+// command does not require authentication. The probe succeeds while the container waits for the agent to
+// write the configuration of mongod, as mongod has not been started yet.
+func mongodLivenessProbeScript(mdb mdbv1.MongoDBCommunity) string {
+	mongoTLSOptions, mongoshTLSOptions := "", ""
+	if mdb.Spec.Security.TLS.Enabled {
+		caFile := tlsCAFilePath(mdb)
+		// the certificate of the member is not issued for localhost.
+		mongoTLSOptions = fmt.Sprintf("--ssl --sslCAFile %s --sslAllowInvalidHostnames", caFile)
+		mongoshTLSOptions = fmt.Sprintf("--tls --tlsCAFile %s --tlsAllowInvalidHostnames", caFile)
+	}
+	ping := "quit(db.adminCommand({ping: 1}).ok == 1 ? 0 : 1)"
+
+	return fmt.Sprintf(`[ -f %s ] || exit 0
+if command -v mongosh > /dev/null; then
+  exec mongosh --quiet --port 27017 %s --eval '%s'
+fi
+exec mongo --quiet --port 27017 %s --eval '%s'
+`, construct.AutomationConfFilePath, mongoshTLSOptions, ping, mongoTLSOptions, ping)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMongodLivenessProbeScript(t *testing.T) {
+	mdb := newTestReplicaSet()
+	script := mongodLivenessProbeScript(mdb)
+	assert.Contains(t, script, "[ -f /data/automation-mongod.conf ] || exit 0\n")
+	assert.Contains(t, script, "db.adminCommand({ping: 1})")
+	assert.NotContains(t, script, "--tls")
+	assert.NotContains(t, script, "--ssl")
+
+	mdb = newTestReplicaSetWithTLS()
+	script = mongodLivenessProbeScript(mdb)
+	assert.Contains(t, script, "mongosh --quiet --port 27017 --tls --tlsCAFile /var/lib/tls/ca/ca.crt --tlsAllowInvalidHostnames")
+	assert.Contains(t, script, "mongo --quiet --port 27017 --ssl --sslCAFile /var/lib/tls/ca/ca.crt --sslAllowInvalidHostnames")
+}
+
+func TestMongodLivenessProbe(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.Nil(t, mongodLivenessProbe(t, mgr, mdb), "the probe is not added by default")
+
+	t.Run("Probe is added with the default settings", func(t *testing.T) {
+		setMongodLivenessProbe(t, mgr, &mdb, &mdbv1.MongodLivenessProbe{Enabled: true})
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		probe := mongodLivenessProbe(t, mgr, mdb)
+		if assert.NotNil(t, probe) {
+			assert.Equal(t, []string{"/bin/sh", "-c", mongodLivenessProbeScript(mdb)}, probe.Exec.Command)
+			assert.Equal(t, int32(60), probe.InitialDelaySeconds)
+			assert.Equal(t, int32(30), probe.PeriodSeconds)
+			assert.Equal(t, int32(10), probe.TimeoutSeconds)
+			assert.Equal(t, int32(6), probe.FailureThreshold)
+		}
+	})
+
+	t.Run("Probe settings can be changed", func(t *testing.T) {
+		initialDelaySeconds := 0
+		setMongodLivenessProbe(t, mgr, &mdb, &mdbv1.MongodLivenessProbe{Enabled: true, InitialDelaySeconds: &initialDelaySeconds, FailureThreshold: 3})
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		probe := mongodLivenessProbe(t, mgr, mdb)
+		if assert.NotNil(t, probe) {
+			assert.Equal(t, int32(0), probe.InitialDelaySeconds)
+			assert.Equal(t, int32(3), probe.FailureThreshold)
+		}
+	})
+
+	t.Run("Probe is removed when it is disabled", func(t *testing.T) {
+		setMongodLivenessProbe(t, mgr, &mdb, &mdbv1.MongodLivenessProbe{Enabled: false})
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assert.Nil(t, mongodLivenessProbe(t, mgr, mdb))
+	})
+}
+
+func setMongodLivenessProbe(t *testing.T, mgr *client.MockedManager, mdb *mdbv1.MongoDBCommunity, probe *mdbv1.MongodLivenessProbe) {
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), mdb))
+	mdb.Spec.MongodLivenessProbe = probe
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), mdb))
+}
+
+func mongodLivenessProbe(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) *corev1.Probe {
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	mongod := container.GetByName(construct.MongodbName, sts.Spec.Template.Spec.Containers)
+	if !assert.NotNil(t, mongod) {
+		return nil
+	}
+	return mongod.LivenessProbe
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const disruptiveChangesQueuedReason = "OutsideMaintenanceWindow"
+
+// maintenanceWindowOpen returns true if disruptive changes may be made at the given time, which is always the case
+// if no maintenance window is configured. Otherwise the returned time is the start of the next window.
+func maintenanceWindowOpen(mdb mdbv1.MongoDBCommunity, now time.Time) (bool, time.Time, error) {
+	window := mdb.Spec.MaintenanceWindow
+	if window == nil {
+		return true, time.Time{}, nil
+	}
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return false, time.Time{}, errors.Errorf("invalid spec.maintenanceWindow.schedule %q: %s", window.Schedule, err)
+	}
+	// the most recent window which is still open started after now - duration.
+	start := schedule.Next(now.Add(-window.GetDuration()))
+	if !start.After(now) {
+		return true, time.Time{}, nil
+	}
+	return false, start, nil
+}
+
+// applyMaintenanceWindow holds back a change of the MongoDB version outside of the maintenance window, by replacing
+// the version in the spec of the resource with the version the members run. A change whose rollout has already
+// started is not held back, as the members would be downgraded. The DisruptiveChangesQueued condition reports the
+// held back change. The version is only replaced in memory: the resource must never be updated afterwards, only
+// its status and, with patches, its annotations.
+func (r ReplicaSetReconciler) applyMaintenanceWindow(mdb *mdbv1.MongoDBCommunity, now time.Time) error {
+	open, next, err := maintenanceWindowOpen(*mdb, now)
+	if err != nil {
+		return err
+	}
+	if open || !mdb.IsChangingVersion() {
+		setDisruptiveChangesQueuedCondition(mdb, metav1.ConditionFalse, "", "")
+		return nil
+	}
+
+	started, err := r.versionRolloutStarted(*mdb)
+	if err != nil || started {
+		return err
+	}
+	message := fmt.Sprintf("The change of the MongoDB version from %s to %s is queued until the maintenance window starting at %s",
+		mdb.GetPreviousVersion(), mdb.Spec.Version, next.UTC().Format(time.RFC3339))
+	if !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionDisruptiveChangesQueued) {
+		r.log.Info(message)
+	}
+	setDisruptiveChangesQueuedCondition(mdb, metav1.ConditionTrue, disruptiveChangesQueuedReason, message)
+	mdb.Spec.Version = mdb.GetPreviousVersion()
+	return nil
+}
+
+// versionRolloutStarted returns true if the automation config already configures any member with the version of
+// the spec.
+func (r ReplicaSetReconciler) versionRolloutStarted(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		return false, errors.Errorf("could not read existing automation config: %s", err)
+	}
+	for _, process := range ac.Processes {
+		if process.Version == mdb.Spec.Version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// setDisruptiveChangesQueuedCondition sets the DisruptiveChangesQueued condition. A condition which reports that
+// no change is queued is only set if a change is currently reported as queued.
+func setDisruptiveChangesQueuedCondition(mdb *mdbv1.MongoDBCommunity, conditionStatus metav1.ConditionStatus, reason, message string) {
+	if conditionStatus == metav1.ConditionFalse {
+		if !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionDisruptiveChangesQueued) {
+			return
+		}
+		reason, message = "InsideMaintenanceWindow", "No disruptive change is queued"
+	}
+	meta.SetStatusCondition(&mdb.Status.Conditions, metav1.Condition{
+		Type:               mdbv1.ConditionDisruptiveChangesQueued,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mdb.Generation,
+	})
+}
+
+// untilMaintenanceWindow returns the time until the next maintenance window starts if a disruptive change is
+// queued until then, and 0 otherwise.
+func untilMaintenanceWindow(mdb mdbv1.MongoDBCommunity, now time.Time) time.Duration {
+	if !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionDisruptiveChangesQueued) {
+		return 0
+	}
+	open, next, err := maintenanceWindowOpen(mdb, now)
+	if err != nil || open {
+		return 0
+	}
+	return next.Sub(now)
+}
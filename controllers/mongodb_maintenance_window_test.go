@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// closedMaintenanceWindow returns a daily maintenance window of an hour which starts 12 hours from now.
+func closedMaintenanceWindow() *mdbv1.MaintenanceWindow {
+	start := time.Now().UTC().Add(12 * time.Hour)
+	return &mdbv1.MaintenanceWindow{
+		Schedule: fmt.Sprintf("%d %d * * *", start.Minute(), start.Hour()),
+		Duration: &metav1.Duration{Duration: time.Hour},
+	}
+}
+
+func TestMaintenanceWindowOpen(t *testing.T) {
+	mdb := newTestReplicaSet()
+	now := time.Date(2021, 6, 1, 2, 30, 0, 0, time.UTC)
+
+	open, _, err := maintenanceWindowOpen(mdb, now)
+	assert.NoError(t, err)
+	assert.True(t, open)
+
+	mdb.Spec.MaintenanceWindow = &mdbv1.MaintenanceWindow{Schedule: "0 2 * * *", Duration: &metav1.Duration{Duration: time.Hour}}
+	open, _, err = maintenanceWindowOpen(mdb, now)
+	assert.NoError(t, err)
+	assert.True(t, open)
+
+	open, next, err := maintenanceWindowOpen(mdb, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, open)
+	assert.Equal(t, time.Date(2021, 6, 2, 2, 0, 0, 0, time.UTC), next)
+
+	mdb.Spec.MaintenanceWindow.Schedule = "CRON_TZ=Europe/Berlin 0 2 * * *"
+	open, _, err = maintenanceWindowOpen(mdb, now)
+	assert.NoError(t, err)
+	assert.False(t, open)
+
+	mdb.Spec.MaintenanceWindow.Schedule = "every night"
+	_, _, err = maintenanceWindowOpen(mdb, now)
+	assert.Error(t, err)
+}
+
+func TestMaintenanceWindow_QueuesVersionChange(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MaintenanceWindow = closedMaintenanceWindow()
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Version = "4.4.8"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 11*time.Hour)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.True(t, meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionDisruptiveChangesQueued))
+	assert.Equal(t, []string{"4.2.2", "4.2.2", "4.2.2"}, processVersions(t, mgr, mdb))
+	assert.Equal(t, "4.4.8", mdb.Spec.Version)
+
+	t.Run("The queued version is not written back", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, "4.4.8", mdb.Spec.Version)
+		assert.Equal(t, "4.2.2", mdb.GetPreviousVersion())
+		assert.True(t, meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionDisruptiveChangesQueued))
+	})
+
+	t.Run("The version is changed once the window opens", func(t *testing.T) {
+		mdb.Spec.MaintenanceWindow.Duration = &metav1.Duration{Duration: 24 * time.Hour}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, []string{"4.4.8", "4.4.8", "4.4.8"}, processVersions(t, mgr, mdb))
+		assert.False(t, meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionDisruptiveChangesQueued))
+	})
+}
+
+func TestMaintenanceWindow_PausesMemberRestarts(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MaintenanceWindow = closedMaintenanceWindow()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assertStatefulSetPartition(t, mgr.GetClient(), mdb, 3)
+
+	t.Run("The members are restarted once the window opens", func(t *testing.T) {
+		mdb.Spec.MaintenanceWindow.Duration = &metav1.Duration{Duration: 24 * time.Hour}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		assertStatefulSetPartition(t, mgr.GetClient(), mdb, 0)
+	})
+}
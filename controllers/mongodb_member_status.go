@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"net"
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// memberHealthInterval is how often the health of the members is read while it is reported in the status.
+const memberHealthInterval = 30 * time.Second
+
+// observeMembers reads the replica set status and reports the state of the members in the status, if the member
+// health or the replication lag readiness gate is enabled. It also sets the condition of the readiness gate of the
+// Pods of the members.
+func (r ReplicaSetReconciler) observeMembers(mdb *mdbv1.MongoDBCommunity) {
+	health := mdb.Spec.MemberHealth.IsEnabled()
+	gate := mdb.Spec.ReplicationLagGate.IsEnabled()
+	if !health && !gate {
+		mdb.Status.Members = nil
+		return
+	}
+
+	members := mdb.StatefulSetReplicasThisReconciliation()
+	if mdb.Status.CurrentStatefulSetReplicas > members {
+		members = mdb.Status.CurrentStatefulSetReplicas
+	}
+	status, hostnames, err := r.memberReplicationStatus(*mdb, members)
+	if err != nil {
+		r.log.Debugf("Could not read the replica set status to observe the members: %s", err)
+	}
+
+	var statuses []mdbv1.MemberStatus
+	for i := 0; i < members; i++ {
+		memberStatus := mdbv1.MemberStatus{Name: mdb.PodName(i)}
+		var lag *time.Duration
+		if status != nil {
+			name := net.JoinHostPort(hostnames[i], "27017")
+			if l, ok := replicationLag(*status, name); ok {
+				lag = &l
+				memberStatus.ReplicationLag = &metav1.Duration{Duration: l}
+			}
+			if member, ok := status.Member(name); ok && health {
+				memberStatus.State = member.StateStr
+				if !member.LastHeartbeat.IsZero() {
+					heartbeat := metav1.NewTime(member.LastHeartbeat)
+					memberStatus.LastHeartbeat = &heartbeat
+				}
+			}
+		}
+
+		if gate {
+			pod, err := r.client.GetPod(types.NamespacedName{Name: memberStatus.Name, Namespace: mdb.Namespace})
+			switch {
+			case err == nil:
+				condition := replicationLagGate(*mdb, pod, lag, status != nil)
+				if err := r.setPodCondition(pod, condition); err != nil {
+					r.log.Warnf("Could not set the replication lag readiness gate of Pod %s: %s", pod.Name, err)
+				}
+				memberStatus.ReplicationLagGate = condition.Status
+			case !apiErrors.IsNotFound(err):
+				r.log.Warnf("Could not get Pod %s to set its replication lag readiness gate: %s", memberStatus.Name, err)
+			case !health:
+				continue
+			}
+		}
+		statuses = append(statuses, memberStatus)
+	}
+	mdb.Status.Members = statuses
+}
+
+// memberReplicationStatus returns the replica set status as seen by a member which knows the primary, and the
+// hostnames of the first n members.
+func (r ReplicaSetReconciler) memberReplicationStatus(mdb mdbv1.MongoDBCommunity, n int) (*replication.Status, []string, error) {
+	hostnames, uri, tlsConfig, err := r.agentConnection(mdb, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	status, err := r.primaryReplSetStatus(hostnames, uri, tlsConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &status, hostnames, nil
+}
+
+// replicationLag returns how far the secondary with the given name is behind the primary, or false if the
+// member is not a healthy secondary or the replica set has no primary.
+func replicationLag(status replication.Status, name string) (time.Duration, bool) {
+	primary, ok := status.Primary()
+	if !ok {
+		return 0, false
+	}
+	member, ok := status.Member(name)
+	if !ok || member.Health != 1 || member.StateStr != replication.StateSecondary {
+		return 0, false
+	}
+	lag := primary.OptimeDate.Sub(member.OptimeDate)
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, true
+}
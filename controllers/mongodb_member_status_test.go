@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestMemberHealth_IsReportedInTheStatus(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MemberHealth = &mdbv1.MemberHealth{Enabled: true}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	reader := &mockStatusReader{err: errors.New("connection refused")}
+	r.replicationStatus = reader
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.Equal(t, memberHealthInterval, res.RequeueAfter)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, []mdbv1.MemberStatus{{Name: "my-rs-0"}, {Name: "my-rs-1"}, {Name: "my-rs-2"}}, mdb.Status.Members, "the health of the members is not known")
+
+	heartbeat := time.Date(2021, 6, 1, 11, 59, 58, 0, time.UTC)
+	status := replicationStatus(mdb, 3, map[int]time.Duration{1: 3 * time.Second})
+	status.Members[1].LastHeartbeat = heartbeat
+	status.Members[2].StateStr = "RECOVERING"
+	status.Members[2].LastHeartbeat = heartbeat
+	reader.status, reader.err = status, nil
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Len(t, mdb.Status.Members, 3)
+	assert.Equal(t, mdbv1.MemberStatus{Name: "my-rs-0", State: "PRIMARY"}, mdb.Status.Members[0])
+	assert.Equal(t, "SECONDARY", mdb.Status.Members[1].State)
+	assert.Equal(t, &metav1.Duration{Duration: 3 * time.Second}, mdb.Status.Members[1].ReplicationLag)
+	assert.True(t, heartbeat.Equal(mdb.Status.Members[1].LastHeartbeat.Time))
+	assert.Equal(t, "RECOVERING", mdb.Status.Members[2].State)
+	assert.Nil(t, mdb.Status.Members[2].ReplicationLag, "the lag is only reported for secondaries")
+
+	t.Run("The members are removed from the status when the health is not reported", func(t *testing.T) {
+		mdb.Spec.MemberHealth.Enabled = false
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Empty(t, mdb.Status.Members)
+	})
+}
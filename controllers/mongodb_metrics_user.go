@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// metricsUserRotationTimeKey is the key in the password Secret of the metrics user storing when the
+	// password was generated.
+	metricsUserRotationTimeKey = "lastRotationTime"
+
+	metricsUserPasswordLength = 32
+)
+
+// validateMetricsUser checks that the metrics user can authenticate with SCRAM, has a valid rotation interval
+// and does not clash with a user of the spec.
+func validateMetricsUser(mdb mdbv1.MongoDBCommunity) error {
+	config := mdb.Spec.Security.Authentication.MetricsUser
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if !mdb.IsAuthModeEnabled(mdbv1.AuthModeScram) {
+		return errors.New("metricsUser requires SCRAM authentication to be enabled")
+	}
+	if _, err := parsePositiveDuration(config.RotationInterval, 0); err != nil {
+		return errors.Errorf("invalid metricsUser.rotationInterval: %s", err)
+	}
+	for _, user := range mdb.Spec.Users {
+		if user.Name == mdb.GetMetricsUserName() && user.GetDB() == "admin" {
+			return errors.Errorf("metricsUser.name %s is also the name of a user of the deployment", user.Name)
+		}
+	}
+	return nil
+}
+
+// ensureMetricsUserPassword generates the password of the metrics user, and replaces it once the rotation
+// interval has elapsed. The new password is rolled out like a changed password of any other user.
+func (r ReplicaSetReconciler) ensureMetricsUserPassword(mdb mdbv1.MongoDBCommunity, now time.Time) error {
+	if !mdb.IsMetricsUserEnabled() {
+		return nil
+	}
+	data, err := secret.ReadStringData(r.client, mdb.MetricsUserPasswordSecretNamespacedName())
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if until, scheduled := untilMetricsUserRotation(mdb, data, now); !scheduled || until > 0 {
+			return nil
+		}
+		r.log.Infof("Rotating the password of metrics user %s", mdb.GetMetricsUserName())
+	}
+
+	password, err := r.passwords.Password(metricsUserPasswordLength)
+	if err != nil {
+		return err
+	}
+	passwordSecret := secret.Builder().
+		SetName(mdb.MetricsUserPasswordSecretNamespacedName().Name).
+		SetNamespace(mdb.Namespace).
+		SetField(mdbv1.MetricsUserPasswordKey, password).
+		SetField(metricsUserRotationTimeKey, now.UTC().Format(time.RFC3339)).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentDatabase)).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return secret.CreateOrUpdate(r.client, passwordSecret)
+}
+
+// untilMetricsUserRotation returns how long it is until the password stored in the given Secret data is due to
+// be rotated, which is zero or negative if it is due now. The returned boolean is false if no rotation is scheduled.
+func untilMetricsUserRotation(mdb mdbv1.MongoDBCommunity, data map[string]string, now time.Time) (time.Duration, bool) {
+	interval, err := parsePositiveDuration(mdb.Spec.Security.Authentication.MetricsUser.RotationInterval, 0)
+	if err != nil || interval == 0 {
+		return 0, false
+	}
+	last, err := time.Parse(time.RFC3339, data[metricsUserRotationTimeKey])
+	if err != nil {
+		return 0, true
+	}
+	return last.Add(interval).Sub(now), true
+}
+
+// untilNextMetricsUserRotation returns how long it is until the password of the metrics user is due to be
+// rotated, zero if no rotation is scheduled.
+func (r ReplicaSetReconciler) untilNextMetricsUserRotation(mdb mdbv1.MongoDBCommunity, now time.Time) time.Duration {
+	if !mdb.IsMetricsUserEnabled() {
+		return 0
+	}
+	data, err := secret.ReadStringData(r.client, mdb.MetricsUserPasswordSecretNamespacedName())
+	if err != nil {
+		return 0
+	}
+	until, scheduled := untilMetricsUserRotation(mdb, data, now)
+	if !scheduled {
+		return 0
+	}
+	if until <= 0 {
+		return time.Second
+	}
+	return until
+}
+
+// ensureMetricsUserSecret stores the credentials and the connection string of the metrics user in its Secret,
+// once the password has been applied to the members. The Secrets of the metrics user created by the operator are
+// deleted if it is disabled.
+func (r ReplicaSetReconciler) ensureMetricsUserSecret(mdb mdbv1.MongoDBCommunity) error {
+	if !mdb.IsMetricsUserEnabled() {
+		for _, nsName := range []k8sClient.ObjectKey{mdb.MetricsUserSecretNamespacedName(), mdb.MetricsUserPasswordSecretNamespacedName()} {
+			s := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: nsName.Name, Namespace: nsName.Namespace}}
+			if err := r.deleteControlledObject(mdb, &s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	password, err := secret.ReadKey(r.client, mdbv1.MetricsUserPasswordKey, mdb.MetricsUserPasswordSecretNamespacedName())
+	if err != nil {
+		return errors.Errorf("could not read the password of the metrics user: %s", err)
+	}
+	hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return err
+	}
+	user := mdbv1.MongoDBUser{Name: mdb.GetMetricsUserName(), DB: "admin"}
+	data, err := connectionStringSecretData(user.ConnectionStringSecret, buildConnectionDetails(mdb, user, password, hostnames, nil, ""))
+	if err != nil {
+		return err
+	}
+	metricsUserSecret := secret.Builder().
+		SetName(mdb.MetricsUserSecretNamespacedName().Name).
+		SetNamespace(mdb.Namespace).
+		SetStringData(data).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentDatabase)).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return secret.CreateOrUpdate(r.client, metricsUserSecret)
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func newTestReplicaSetWithMetricsUser(rotationInterval string) mdbv1.MongoDBCommunity {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.Authentication.MetricsUser = &mdbv1.MetricsUser{Enabled: true, RotationInterval: rotationInterval}
+	return mdb
+}
+
+func TestMetricsUser_IsCreatedWithClusterMonitor(t *testing.T) {
+	mdb := newTestReplicaSetWithMetricsUser("")
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	if assert.Len(t, ac.Auth.Users, 1) {
+		assert.Equal(t, "metrics", ac.Auth.Users[0].Username)
+		assert.Equal(t, "admin", ac.Auth.Users[0].Database)
+		assert.Equal(t, []automationconfig.Role{{Role: "clusterMonitor", Database: "admin"}}, ac.Auth.Users[0].Roles)
+	}
+
+	password, err := secret.ReadKey(mgr.Client, mdbv1.MetricsUserPasswordKey, mdb.MetricsUserPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Len(t, password, metricsUserPasswordLength)
+
+	data, err := secret.ReadStringData(mgr.Client, mdb.MetricsUserSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, "metrics", data["username"])
+	assert.Equal(t, password, data["password"])
+	assert.Contains(t, data["connectionString.standard"], "metrics:"+password+"@")
+	assert.Zero(t, res.RequeueAfter, "the password is not rotated without a rotation interval")
+}
+
+func TestMetricsUser_PasswordIsRotatedOnSchedule(t *testing.T) {
+	mdb := newTestReplicaSetWithMetricsUser("24h")
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	now := time.Now().Truncate(time.Second)
+	assert.NoError(t, r.ensureMetricsUserPassword(mdb, now))
+	first, err := secret.ReadKey(mgr.Client, mdbv1.MetricsUserPasswordKey, mdb.MetricsUserPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, r.untilNextMetricsUserRotation(mdb, now))
+
+	assert.NoError(t, r.ensureMetricsUserPassword(mdb, now.Add(time.Hour)))
+	unchanged, err := secret.ReadKey(mgr.Client, mdbv1.MetricsUserPasswordKey, mdb.MetricsUserPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, first, unchanged, "the password is kept until the rotation interval has elapsed")
+	assert.Equal(t, time.Second, r.untilNextMetricsUserRotation(mdb, now.Add(25*time.Hour)))
+
+	assert.NoError(t, r.ensureMetricsUserPassword(mdb, now.Add(25*time.Hour)))
+	rotated, err := secret.ReadKey(mgr.Client, mdbv1.MetricsUserPasswordKey, mdb.MetricsUserPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, rotated)
+	assert.Equal(t, 24*time.Hour, r.untilNextMetricsUserRotation(mdb, now.Add(25*time.Hour)))
+}
+
+func TestMetricsUser_SecretsAreDeletedWhenDisabled(t *testing.T) {
+	mdb := newTestReplicaSetWithMetricsUser("")
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.Authentication.MetricsUser.Enabled = false
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	for _, nsName := range []types.NamespacedName{mdb.MetricsUserSecretNamespacedName(), mdb.MetricsUserPasswordSecretNamespacedName()} {
+		err := mgr.GetClient().Get(context.TODO(), nsName, &corev1.Secret{})
+		assert.True(t, apiErrors.IsNotFound(err), "secret %s should be deleted", nsName.Name)
+	}
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Empty(t, ac.Auth.Users)
+}
+
+func TestMetricsUser_SecretsOfUsersAreKeptWhenDisabled(t *testing.T) {
+	mdb := newTestReplicaSetWithMetricsUser("")
+	mdb.Spec.Security.Authentication.MetricsUser.Enabled = false
+	mgr := client.NewManager(&mdb)
+	userSecret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: mdb.MetricsUserPasswordSecretNamespacedName().Name, Namespace: mdb.Namespace}}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &userSecret))
+	r := NewReconciler(mgr)
+
+	assert.NoError(t, r.ensureMetricsUserSecret(mdb))
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.MetricsUserPasswordSecretNamespacedName(), &corev1.Secret{}), "secrets not created by the operator should be kept")
+}
+
+func TestValidateMetricsUser(t *testing.T) {
+	mdb := newTestReplicaSetWithMetricsUser("720h")
+	assert.NoError(t, validateMetricsUser(mdb))
+
+	mdb.Spec.Security.Authentication.MetricsUser.RotationInterval = "monthly"
+	assert.Error(t, validateMetricsUser(mdb))
+
+	mdb = newTestReplicaSetWithMetricsUser("")
+	mdb.Spec.Users = []mdbv1.MongoDBUser{{Name: "metrics", DB: "admin"}}
+	assert.EqualError(t, validateMetricsUser(mdb), "metricsUser.name metrics is also the name of a user of the deployment")
+
+	mdb = newTestReplicaSet()
+	mdb.Spec.Security.Authentication.MetricsUser = &mdbv1.MetricsUser{Enabled: true}
+	assert.EqualError(t, validateMetricsUser(mdb), "metricsUser requires SCRAM authentication to be enabled")
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// isNamespaceTerminating returns true if the namespace of the resource is being deleted. Creating an object in a
+// terminating namespace fails, which the reconciliation detects from the error, so the namespace is only read if
+// the previous reconciliation failed, so that other failures are not reported again. The namespace is read from
+// the apiserver, as the operator does not watch namespaces. It is only allowed to read them when it is deployed
+// with a ClusterRole, otherwise a terminating namespace is only detected once creating an object in it fails.
+func (r ReplicaSetReconciler) isNamespaceTerminating(mdb mdbv1.MongoDBCommunity) bool {
+	if mdb.Status.Phase != mdbv1.Failed {
+		return false
+	}
+	namespace := corev1.Namespace{}
+	if err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: mdb.Namespace}, &namespace); err != nil {
+		r.log.Debugf("Could not read namespace %s: %s", mdb.Namespace, err)
+		return false
+	}
+	return namespace.DeletionTimestamp != nil || namespace.Status.Phase == corev1.NamespaceTerminating
+}
+
+// skipTerminatingNamespace stops reconciling a resource whose namespace is being deleted. The resource
+// and everything it owns is removed with the namespace, so only the state the operator keeps in memory
+// is released and the status is left untouched.
+func (r ReplicaSetReconciler) skipTerminatingNamespace(mdb mdbv1.MongoDBCommunity) (reconcile.Result, error) {
+	r.log.Infof("Namespace %s is being terminated, skipping reconciliation", mdb.Namespace)
+	r.disruptions.Release(mdb.NamespacedName())
+	return result.OK()
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcile_SkipsTerminatingNamespace(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Status.Phase = mdbv1.Failed
+	mgr := client.NewManager(&mdb)
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: mdb.Namespace},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &namespace))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts)
+	assert.True(t, apiErrors.IsNotFound(err), "the StatefulSet should not be created in a terminating namespace")
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase, "the status should not be updated in a terminating namespace")
+}
+
+func TestReconcile_NamespaceIsOnlyReadAfterAFailure(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	reader := &countingReader{Reader: r.apiReader}
+	r.apiReader = reader
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.Zero(t, reader.namespaceReads)
+}
+
+// countingReader counts the namespaces read through it.
+type countingReader struct {
+	k8sClient.Reader
+	namespaceReads int
+}
+
+func (c *countingReader) Get(ctx context.Context, key k8sClient.ObjectKey, obj k8sClient.Object) error {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		c.namespaceReads++
+	}
+	return c.Reader.Get(ctx, key, obj)
+}
+
+func TestReconcile_ActiveNamespaceIsReconciled(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: mdb.Namespace},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &namespace))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/objx"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// mongod options which make it listen on the IPv6 addresses of the Pod.
+const (
+	mongodIPv6      = "net.ipv6"
+	mongodBindIP    = "net.bindIp"
+	mongodBindIPAll = "net.bindIpAll"
+)
+
+// validateNetwork checks that the IP families of spec.network are unique, that a single stack policy is not
+// combined with two IP families, and that the listeners are not also configured in AdditionalMongodConfig.
+func validateNetwork(mdb mdbv1.MongoDBCommunity) error {
+	network := mdb.Spec.Network
+	if network == nil {
+		return nil
+	}
+	if len(network.IPFamilies) == 2 && network.IPFamilies[0] == network.IPFamilies[1] {
+		return errors.Errorf("ipFamilies can not contain %s twice", network.IPFamilies[0])
+	}
+	if len(network.IPFamilies) == 2 && network.IPFamilyPolicy == corev1.IPFamilyPolicySingleStack {
+		return errors.Errorf("ipFamilyPolicy %s can not be used with two ipFamilies", network.IPFamilyPolicy)
+	}
+	for i, family := range network.IPFamilies {
+		if family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+			return errors.Errorf("ipFamilies[%d] must be IPv4 or IPv6, not %s", i, family)
+		}
+	}
+	if network.Listeners != nil {
+		additionalConfig := objx.New(mdb.Spec.AdditionalMongodConfig.Object)
+		if additionalConfig.Has(mongodBindIP) || additionalConfig.Has(mongodBindIPAll) {
+			return errors.Errorf("listeners can not be combined with %s or %s in additionalMongodConfig", mongodBindIP, mongodBindIPAll)
+		}
+		for i, address := range network.Listeners.AdditionalAddresses {
+			if address == "" || strings.ContainsAny(address, ", ") {
+				return errors.Errorf("listeners.additionalAddresses[%d] must be a single address, not %q", i, address)
+			}
+			// mongod could not bind an IP address or hostname on port 27017 next to all IP addresses.
+			if !path.IsAbs(address) {
+				return errors.Errorf("listeners.additionalAddresses[%d] must be the path of a Unix domain socket, mongod already listens on all IP addresses, not %q", i, address)
+			}
+		}
+	}
+	return nil
+}
+
+// withIPFamilies sets the IP family policy and the IP families of spec.network on the given Service. The
+// fields are left empty if they are not configured, so that the API server applies the defaults of the cluster.
+func withIPFamilies(mdb mdbv1.MongoDBCommunity, svc corev1.Service) corev1.Service {
+	if mdb.Spec.Network == nil {
+		return svc
+	}
+	if mdb.Spec.Network.IPFamilyPolicy != "" {
+		policy := mdb.Spec.Network.IPFamilyPolicy
+		svc.Spec.IPFamilyPolicy = &policy
+	}
+	if len(mdb.Spec.Network.IPFamilies) > 0 {
+		svc.Spec.IPFamilies = append([]corev1.IPFamily{}, mdb.Spec.Network.IPFamilies...)
+	}
+	return svc
+}
+
+// mergeIPFamilies sets the configured IP family policy and IP families of the given Service on the existing
+// one, keeping the values the API server defaulted if they are not configured.
+func mergeIPFamilies(existing *corev1.Service, svc corev1.Service) {
+	if svc.Spec.IPFamilyPolicy != nil {
+		existing.Spec.IPFamilyPolicy = svc.Spec.IPFamilyPolicy
+	}
+	if len(svc.Spec.IPFamilies) > 0 {
+		existing.Spec.IPFamilies = svc.Spec.IPFamilies
+	}
+}
+
+// listenersModification makes mongod listen on all IPv4 addresses, on all IPv6 addresses if the Services may have
+// IPv6 addresses, and on the additional addresses of the listeners. The hostname of the member is not bound, as
+// mongod would fail to start before the headless Service publishes its DNS record. It must precede
+// ipv6Modification, which makes mongod listen on all addresses unless net.bindIp is set.
+func listenersModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if mdb.Spec.Network == nil || mdb.Spec.Network.Listeners == nil {
+		return automationconfig.NOOP()
+	}
+	addresses := []string{"0.0.0.0"}
+	if mdb.Spec.Network.UsesIPv6() {
+		addresses = append(addresses, "::")
+	}
+	addresses = append(addresses, mdb.Spec.Network.Listeners.AdditionalAddresses...)
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			config.Processes[i].Args26.Set(mongodBindIP, strings.Join(addresses, ","))
+		}
+	}
+}
+
+// ipv6Modification enables IPv6 in mongod and makes it listen on all addresses if the Services may have IPv6
+// addresses, as mongod only listens on IPv4 addresses by default. Options set in the additional mongod
+// configuration take precedence.
+func ipv6Modification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if !mdb.Spec.Network.UsesIPv6() {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			args := config.Processes[i].Args26
+			if !args.Has(mongodIPv6) {
+				args.Set(mongodIPv6, true)
+			}
+			if !args.Has(mongodBindIP) && !args.Has(mongodBindIPAll) {
+				args.Set(mongodBindIPAll, true)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// OperatorNamespaceEnv is the namespace the operator runs in. The NetworkPolicies of the members admit the
+	// operator Pods from any namespace if it is not set.
+	OperatorNamespaceEnv = "OPERATOR_NAMESPACE"
+	// OperatorNameEnv is the value of the "name" label of the operator Pods.
+	OperatorNameEnv = "OPERATOR_NAME"
+
+	defaultOperatorName = "mongodb-kubernetes-operator"
+	// namespaceNameLabel is the label Kubernetes sets on every namespace to its name.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;create;update;delete
+
+// buildNetworkPolicy returns the NetworkPolicy of the members. It admits all traffic from the other members and
+// from the Jobs of the resource, such as backups and restores, the traffic to mongod from the operator, the
+// external members and the configured clients, and the traffic to the Prometheus exporters from the configured
+// metrics clients. All other traffic to the members is denied. Egress is not restricted.
+func buildNetworkPolicy(mdb mdbv1.MongoDBCommunity) networkingv1.NetworkPolicy {
+	mongodPort := []networkingv1.NetworkPolicyPort{networkPolicyPort(27017)}
+	rules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": mdb.ServiceName()}}},
+				{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{mdbv1.LabelResource: mdb.Name}}},
+			},
+		},
+		{
+			From:  []networkingv1.NetworkPolicyPeer{operatorPeer()},
+			Ports: mongodPort,
+		},
+	}
+	if peers := externalMemberPeers(mdb); len(peers) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  peers,
+			Ports: mongodPort,
+		})
+	}
+	if clients := mdb.Spec.NetworkPolicy.Clients; len(clients) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  clients,
+			Ports: mongodPort,
+		})
+	}
+	if mdb.IsPrometheusEnabled() {
+		metricsClients := mdb.Spec.NetworkPolicy.MetricsClients
+		if len(metricsClients) == 0 {
+			metricsClients = []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}}
+		}
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  metricsClients,
+			Ports: []networkingv1.NetworkPolicyPort{networkPolicyPort(int32(mdb.Spec.Prometheus.GetPort()))},
+		})
+	}
+
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            mdb.NetworkPolicyName(),
+			Namespace:       mdb.Namespace,
+			Labels:          mdb.SchemaLabels(mdbv1.ComponentDatabase),
+			OwnerReferences: mdb.GetOwnerReferences(),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": mdb.ServiceName()}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     rules,
+		},
+	}
+}
+
+// externalMemberPeers returns a peer for each external member whose hostname is an IP address. The addresses of
+// external members with a DNS name must be admitted in spec.networkPolicy.clients, as a NetworkPolicy can only
+// select peers outside of the cluster by their IP address.
+func externalMemberPeers(mdb mdbv1.MongoDBCommunity) []networkingv1.NetworkPolicyPeer {
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, member := range mdb.Spec.ExternalMembers {
+		ip := net.ParseIP(member.Hostname)
+		if ip == nil {
+			continue
+		}
+		cidr := ip.String() + "/32"
+		if ip.To4() == nil {
+			cidr = ip.String() + "/128"
+		}
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+	return peers
+}
+
+// operatorPeer returns the peer selecting the operator Pods by their "name" label, in the namespace of the
+// operator if it is known.
+func operatorPeer() networkingv1.NetworkPolicyPeer {
+	namespaceSelector := &metav1.LabelSelector{}
+	if namespace := os.Getenv(OperatorNamespaceEnv); namespace != "" {
+		namespaceSelector.MatchLabels = map[string]string{namespaceNameLabel: namespace}
+	}
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"name": envvar.GetEnvOrDefault(OperatorNameEnv, defaultOperatorName)}},
+		NamespaceSelector: namespaceSelector,
+	}
+}
+
+func networkPolicyPort(port int32) networkingv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+	target := intstr.FromInt(int(port))
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &target}
+}
+
+// ensureNetworkPolicy creates or updates the NetworkPolicy of the members, or deletes it once it is disabled. The
+// NetworkPolicy is read from the API server, as the operator does not watch NetworkPolicies, and is only looked up
+// while it is enabled or recorded in the status as created.
+func (r ReplicaSetReconciler) ensureNetworkPolicy(mdb *mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.NetworkPolicy == nil && mdb.Status.NetworkPolicy == "" {
+		return nil
+	}
+	existing := networkingv1.NetworkPolicy{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: mdb.NetworkPolicyName(), Namespace: mdb.Namespace}, &existing)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Errorf("could not get NetworkPolicy %s: %s", mdb.NetworkPolicyName(), err)
+	}
+	exists := err == nil
+
+	if mdb.Spec.NetworkPolicy == nil {
+		if exists && metav1.IsControlledBy(&existing, mdb) {
+			r.log.Infof("Deleting NetworkPolicy %s, as it is disabled", existing.Name)
+			if err := r.client.Delete(context.TODO(), &existing); err != nil && !apiErrors.IsNotFound(err) {
+				return errors.Errorf("could not delete NetworkPolicy %s: %s", existing.Name, err)
+			}
+		}
+		mdb.Status.NetworkPolicy = ""
+		return nil
+	}
+
+	policy := buildNetworkPolicy(*mdb)
+	if exists {
+		mergeLabels(&existing.ObjectMeta, policy.Labels)
+		existing.Spec = policy.Spec
+		err = r.client.Update(context.TODO(), &existing)
+	} else {
+		err = r.client.Create(context.TODO(), &policy)
+	}
+	if err != nil {
+		return errors.Errorf("could not ensure NetworkPolicy %s: %s", policy.Name, err)
+	}
+	mdb.Status.NetworkPolicy = policy.Name
+	return nil
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestNetworkPolicy_AdmitsTheMembersTheOperatorAndTheClients(t *testing.T) {
+	os.Setenv(OperatorNamespaceEnv, "mongodb-operator")
+	defer os.Unsetenv(OperatorNamespaceEnv)
+
+	mdb := newTestReplicaSet()
+	clients := []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "app"}}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "203.0.113.0/24"}},
+	}
+	mdb.Spec.NetworkPolicy = &mdbv1.NetworkPolicyConfiguration{Clients: clients}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	policy := networkingv1.NetworkPolicy{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-rs-members", Namespace: mdb.Namespace}, &policy))
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, policy.Spec.PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+	assert.Len(t, policy.Spec.Ingress, 3)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, "my-rs-members", mdb.Status.NetworkPolicy)
+
+	members := policy.Spec.Ingress[0]
+	assert.Empty(t, members.Ports, "the members and Jobs may use all ports")
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, members.From[0].PodSelector.MatchLabels)
+	assert.Equal(t, map[string]string{mdbv1.LabelResource: mdb.Name}, members.From[1].PodSelector.MatchLabels)
+
+	operator := policy.Spec.Ingress[1]
+	assert.Equal(t, map[string]string{"name": "mongodb-kubernetes-operator"}, operator.From[0].PodSelector.MatchLabels)
+	assert.Equal(t, map[string]string{"kubernetes.io/metadata.name": "mongodb-operator"}, operator.From[0].NamespaceSelector.MatchLabels)
+	assert.Equal(t, intstr.FromInt(27017), *operator.Ports[0].Port)
+	assert.Equal(t, corev1.ProtocolTCP, *operator.Ports[0].Protocol)
+
+	assert.Equal(t, clients, policy.Spec.Ingress[2].From)
+	assert.Equal(t, intstr.FromInt(27017), *policy.Spec.Ingress[2].Ports[0].Port)
+
+	t.Run("The exporters may be scraped from all namespaces by default", func(t *testing.T) {
+		mdb.Spec.Prometheus = &mdbv1.Prometheus{Enabled: true}
+		policy := buildNetworkPolicy(mdb)
+		assert.Len(t, policy.Spec.Ingress, 4)
+		assert.Equal(t, []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}}, policy.Spec.Ingress[3].From)
+		assert.Equal(t, intstr.FromInt(9216), *policy.Spec.Ingress[3].Ports[0].Port)
+	})
+
+	t.Run("External members with an IP address are admitted", func(t *testing.T) {
+		mdb.Spec.Prometheus = nil
+		mdb.Spec.ExternalMembers = []mdbv1.ExternalMember{
+			{ID: 10, Hostname: "198.51.100.7"},
+			{ID: 11, Hostname: "vm-1.example.com"},
+			{ID: 12, Hostname: "2001:db8::7"},
+		}
+		policy := buildNetworkPolicy(mdb)
+		assert.Len(t, policy.Spec.Ingress, 4)
+		external := policy.Spec.Ingress[2]
+		assert.Equal(t, []networkingv1.NetworkPolicyPeer{
+			{IPBlock: &networkingv1.IPBlock{CIDR: "198.51.100.7/32"}},
+			{IPBlock: &networkingv1.IPBlock{CIDR: "2001:db8::7/128"}},
+		}, external.From)
+		assert.Equal(t, intstr.FromInt(27017), *external.Ports[0].Port)
+		mdb.Spec.ExternalMembers = nil
+	})
+
+	t.Run("Disabling the NetworkPolicy deletes it", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.NetworkPolicy = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		err = mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-rs-members", Namespace: mdb.Namespace}, &networkingv1.NetworkPolicy{})
+		assert.True(t, apiErrors.IsNotFound(err))
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Empty(t, mdb.Status.NetworkPolicy)
+	})
+}
+
+// failingReader fails every read, as a reader without the permission to read the object would.
+type failingReader struct {
+	k8sClient.Reader
+}
+
+func (failingReader) Get(context.Context, k8sClient.ObjectKey, k8sClient.Object) error {
+	return errors.New("forbidden")
+}
+
+func TestEnsureNetworkPolicy_IsNotReadUnlessItWasCreated(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.apiReader = failingReader{}
+	assert.NoError(t, r.ensureNetworkPolicy(&mdb))
+
+	mdb.Status.NetworkPolicy = mdb.NetworkPolicyName()
+	assert.Error(t, r.ensureNetworkPolicy(&mdb), "a NetworkPolicy which was created is looked up to be deleted")
+}
+
+func TestOperatorPeer_AdmitsAllNamespacesIfTheNamespaceOfTheOperatorIsUnknown(t *testing.T) {
+	os.Setenv(OperatorNameEnv, "my-operator")
+	defer os.Unsetenv(OperatorNameEnv)
+
+	peer := operatorPeer()
+	assert.Equal(t, map[string]string{"name": "my-operator"}, peer.PodSelector.MatchLabels)
+	assert.Equal(t, &metav1.LabelSelector{}, peer.NamespaceSelector)
+}
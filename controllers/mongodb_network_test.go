@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestNetwork_ConfiguresTheServicesAndMongod(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.False(t, ac.Processes[0].Args26.Has(mongodIPv6), "IPv6 is not enabled by default")
+
+	mdb.Spec.Network = &mdbv1.NetworkConfiguration{
+		IPFamilyPolicy: corev1.IPFamilyPolicyPreferDualStack,
+		IPFamilies:     []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+	}
+	mdb.Spec.Service = &mdbv1.ServiceConfiguration{ClientService: &mdbv1.ClientService{}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	for _, name := range []string{mdb.ServiceName(), mdb.ClientServiceName()} {
+		svc, err := mgr.Client.GetService(types.NamespacedName{Name: name, Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Equal(t, corev1.IPFamilyPolicyPreferDualStack, *svc.Spec.IPFamilyPolicy)
+		assert.Equal(t, []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}, svc.Spec.IPFamilies)
+	}
+	ac, err = automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, process := range ac.Processes {
+		assert.Equal(t, true, process.Args26.Get(mongodIPv6).Data())
+		assert.Equal(t, true, process.Args26.Get(mongodBindIPAll).Data())
+	}
+
+	t.Run("The addresses of the additional mongod configuration take precedence", func(t *testing.T) {
+		mdb.Spec.AdditionalMongodConfig = mdbv1.MongodConfiguration{Object: map[string]interface{}{
+			"net": map[string]interface{}{"bindIp": "::,0.0.0.0"},
+		}}
+		ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, ipv6Modification(mdb))
+		assert.NoError(t, err)
+		assert.Equal(t, "::,0.0.0.0", ac.Processes[0].Args26.Get(mongodBindIP).Data())
+		assert.False(t, ac.Processes[0].Args26.Has(mongodBindIPAll))
+		assert.Equal(t, true, ac.Processes[0].Args26.Get(mongodIPv6).Data())
+	})
+}
+
+func TestListeners_AddTheAddressesOfMongod(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Network = &mdbv1.NetworkConfiguration{
+		IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol},
+		Listeners:  &mdbv1.ListenersConfiguration{AdditionalAddresses: []string{"/tmp/mongod.sock"}},
+	}
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, listenersModification(mdb), ipv6Modification(mdb))
+	assert.NoError(t, err)
+	for _, process := range ac.Processes {
+		assert.Equal(t, "0.0.0.0,::,/tmp/mongod.sock", process.Args26.Get(mongodBindIP).Data(), "the hostname of the member is not bound")
+		assert.False(t, process.Args26.Has(mongodBindIPAll))
+		assert.Equal(t, true, process.Args26.Get(mongodIPv6).Data())
+	}
+
+	mdb.Spec.Network.IPFamilies = nil
+	ac, err = buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, listenersModification(mdb), ipv6Modification(mdb))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.0.0,/tmp/mongod.sock", ac.Processes[0].Args26.Get(mongodBindIP).Data())
+}
+
+func TestNetworkConfiguration_UsesIPv6(t *testing.T) {
+	assert.False(t, (*mdbv1.NetworkConfiguration)(nil).UsesIPv6())
+	assert.False(t, (&mdbv1.NetworkConfiguration{IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol}}).UsesIPv6())
+	assert.True(t, (&mdbv1.NetworkConfiguration{IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol}}).UsesIPv6())
+	assert.True(t, (&mdbv1.NetworkConfiguration{IPFamilyPolicy: corev1.IPFamilyPolicyRequireDualStack}).UsesIPv6())
+}
+
+func TestValidateNetwork(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateNetwork(mdb))
+
+	mdb.Spec.Network = &mdbv1.NetworkConfiguration{IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv4Protocol}}
+	assert.EqualError(t, validateNetwork(mdb), "ipFamilies can not contain IPv4 twice")
+
+	mdb.Spec.Network = &mdbv1.NetworkConfiguration{
+		IPFamilyPolicy: corev1.IPFamilyPolicySingleStack,
+		IPFamilies:     []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+	}
+	assert.EqualError(t, validateNetwork(mdb), "ipFamilyPolicy SingleStack can not be used with two ipFamilies")
+
+	mdb.Spec.Network = &mdbv1.NetworkConfiguration{IPFamilies: []corev1.IPFamily{"IPv5"}}
+	assert.EqualError(t, validateNetwork(mdb), "ipFamilies[0] must be IPv4 or IPv6, not IPv5")
+
+	mdb.Spec.Network = &mdbv1.NetworkConfiguration{Listeners: &mdbv1.ListenersConfiguration{AdditionalAddresses: []string{"10.0.0.1,10.0.0.2"}}}
+	assert.EqualError(t, validateNetwork(mdb), `listeners.additionalAddresses[0] must be a single address, not "10.0.0.1,10.0.0.2"`)
+
+	mdb.Spec.Network.Listeners.AdditionalAddresses = []string{"10.0.0.1"}
+	assert.EqualError(t, validateNetwork(mdb), `listeners.additionalAddresses[0] must be the path of a Unix domain socket, mongod already listens on all IP addresses, not "10.0.0.1"`)
+
+	mdb.Spec.Network.Listeners.AdditionalAddresses = []string{"/tmp/mongod-app.sock"}
+	assert.NoError(t, validateNetwork(mdb))
+	mdb.Spec.AdditionalMongodConfig = mdbv1.MongodConfiguration{Object: map[string]interface{}{
+		"net": map[string]interface{}{"bindIpAll": true},
+	}}
+	assert.EqualError(t, validateNetwork(mdb), "listeners can not be combined with net.bindIp or net.bindIpAll in additionalMongodConfig")
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scramcredentials"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// maxSecretDataSize is the largest data the API server accepts in a Secret.
+	maxSecretDataSize = corev1.MaxSecretSize
+
+	// maxAnnotationsSize is the largest total size of the annotations the API server accepts on an object.
+	maxAnnotationsSize = 256 * 1024
+
+	// significantObjectSizeShare is the share of a limit from which a field is reported as contributing to an
+	// object above the limit.
+	significantObjectSizeShare = 10
+)
+
+// placeholderScramCreds has the size of the SCRAM credentials the Operator generates for a user, the salts and
+// keys are base64 encoded.
+var placeholderScramCreds = scramcredentials.ScramCreds{
+	IterationCount: 15000,
+	Salt:           strings.Repeat("s", 40),
+	ServerKey:      strings.Repeat("k", 44),
+	StoredKey:      strings.Repeat("k", 44),
+}
+
+// validateObjectSizes checks that the objects the Operator renders from the spec stay under the size limits of the
+// API server, before any of them is written. The automation config is estimated from the spec with credentials of
+// the size the Operator generates, as the credentials are only generated when the users are reconciled.
+func validateObjectSizes(mdb mdbv1.MongoDBCommunity) error {
+	var errs field.ErrorList
+
+	spec, err := json.Marshal(mdb.Spec)
+	if err != nil {
+		return err
+	}
+	annotationsSize := len(lastSuccessfulConfiguration) + len(spec)
+	for k, v := range mdb.Annotations {
+		if k != lastSuccessfulConfiguration {
+			annotationsSize += len(k) + len(v)
+		}
+	}
+	if annotationsSize > maxAnnotationsSize {
+		errs = append(errs, &field.Error{
+			Type:  field.ErrorTypeTooLong,
+			Field: field.NewPath("spec").String(),
+			Detail: fmt.Sprintf("the spec is %d bytes, the annotations of the resource, which store the last successful configuration, must have at most %d bytes",
+				len(spec), maxAnnotationsSize),
+		})
+	}
+
+	users := make([]automationconfig.MongoDBUser, 0, len(mdb.GetScramUsers()))
+	for _, u := range mdb.GetScramUsers() {
+		acUser := automationconfig.MongoDBUser{
+			Username:                   u.Username,
+			Database:                   u.Database,
+			Mechanisms:                 []string{},
+			AuthenticationRestrictions: []automationconfig.AuthenticationRestriction{},
+			ScramSha1Creds:             &placeholderScramCreds,
+			ScramSha256Creds:           &placeholderScramCreds,
+		}
+		for _, role := range u.Roles {
+			acUser.Roles = append(acUser.Roles, automationconfig.Role{Role: role.Name, Database: role.Database})
+		}
+		acUser.AuthenticationRestrictions = append(acUser.AuthenticationRestrictions, u.AuthenticationRestrictions...)
+		users = append(users, acUser)
+	}
+	customRolesModification, err := getCustomRolesModification(mdb)
+	if err != nil {
+		return err
+	}
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{Users: users}, automationconfig.AutomationConfig{}, customRolesModification)
+	if err != nil {
+		// the spec is checked again once the automation config can be built
+		return nil
+	}
+	errs = append(errs, automationConfigSizeErrors(mdb, ac)...)
+	return objectSizeError(errs)
+}
+
+// objectSizeError returns an error listing the given field errors, or nil if there are none.
+func objectSizeError(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// automationConfigSizeErrors returns an error for each field of the spec which contributes at least a tenth of the
+// limit to an automation config too large to be stored in its Secret.
+func automationConfigSizeErrors(mdb mdbv1.MongoDBCommunity, ac automationconfig.AutomationConfig) field.ErrorList {
+	size, err := jsonSize(ac)
+	if err != nil || size <= maxSecretDataSize {
+		return nil
+	}
+
+	type contribution struct {
+		path        *field.Path
+		size        int
+		description string
+	}
+	usersSize, _ := jsonSize(ac.Auth.Users)
+	rolesSize, _ := jsonSize(ac.Roles)
+	mongodConfigSize, _ := jsonSize(mdb.Spec.AdditionalMongodConfig.Object)
+	serverParametersSize, _ := jsonSize(mdb.Spec.ServerParameters.Object)
+	contributions := []contribution{
+		{field.NewPath("spec", "users"), usersSize, fmt.Sprintf("%d users", len(ac.Auth.Users))},
+		{field.NewPath("spec", "security", "roles"), rolesSize, fmt.Sprintf("%d roles", len(ac.Roles))},
+		{field.NewPath("spec", "additionalMongodConfig"), mongodConfigSize * len(ac.Processes), fmt.Sprintf("copied to %d members", len(ac.Processes))},
+		{field.NewPath("spec", "serverParameters"), serverParametersSize * len(ac.Processes), fmt.Sprintf("copied to %d members", len(ac.Processes))},
+	}
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return contributions[i].size > contributions[j].size
+	})
+
+	var errs field.ErrorList
+	for _, c := range contributions {
+		if c.size*significantObjectSizeShare < maxSecretDataSize {
+			continue
+		}
+		errs = append(errs, &field.Error{
+			Type:  field.ErrorTypeTooLong,
+			Field: c.path.String(),
+			Detail: fmt.Sprintf("renders %d bytes (%s) of an automation config of %d bytes, the automation config Secret must have at most %d bytes",
+				c.size, c.description, size, maxSecretDataSize),
+		})
+	}
+	if len(errs) == 0 {
+		errs = append(errs, &field.Error{
+			Type:   field.ErrorTypeTooLong,
+			Field:  field.NewPath("spec").String(),
+			Detail: fmt.Sprintf("renders an automation config of %d bytes, the automation config Secret must have at most %d bytes", size, maxSecretDataSize),
+		})
+	}
+	return errs
+}
+
+func jsonSize(v interface{}) (int, error) {
+	bytes, err := json.Marshal(v)
+	return len(bytes), err
+}
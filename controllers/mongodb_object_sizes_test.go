@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestValidateObjectSizes(t *testing.T) {
+	t.Run("A regular spec is accepted", func(t *testing.T) {
+		mdb := newScramReplicaSet(mdbv1.MongoDBUser{Name: "app-user", DB: "admin", Roles: []mdbv1.Role{{Name: "readWrite", DB: "app"}}})
+		assert.NoError(t, validateObjectSizes(mdb))
+	})
+	t.Run("The additional mongod configuration is copied to every member", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{"processManagement": map[string]interface{}{"pidFilePath": strings.Repeat("a", 150*1024)}}
+		assert.NoError(t, validateObjectSizes(mdb))
+
+		mdb.Spec.Members = 8
+		err := validateObjectSizes(mdb)
+		if assert.Error(t, err) {
+			assert.True(t, strings.HasPrefix(err.Error(), "spec.additionalMongodConfig: Too long: renders 1229120 bytes (copied to 8 members) of an automation config of "), err.Error())
+			assert.NotContains(t, err.Error(), "spec.users")
+		}
+	})
+	t.Run("Too many users", func(t *testing.T) {
+		var users []mdbv1.MongoDBUser
+		for i := 0; i < 3000; i++ {
+			users = append(users, mdbv1.MongoDBUser{Name: fmt.Sprintf("user-%d", i), DB: "admin", Roles: []mdbv1.Role{{Name: "readWrite", DB: "app"}}})
+		}
+		err := validateObjectSizes(newScramReplicaSet(users...))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "spec: Too long: the spec is ")
+			assert.Contains(t, err.Error(), "spec.users: Too long: renders ")
+			assert.Contains(t, err.Error(), "(3000 users)")
+		}
+	})
+}
+
+func TestReplicaSet_TooLargeAutomationConfigFailsBeforeAnyObjectIsCreated(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Members = 8
+	mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{"processManagement": map[string]interface{}{"pidFilePath": strings.Repeat("a", 150*1024)}}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.True(t, strings.HasPrefix(mdb.Status.Message, "Error validating the size of the generated objects: spec.additionalMongodConfig: Too long"), mdb.Status.Message)
+
+	_, err = mgr.Client.GetSecret(types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.Error(t, err)
+}
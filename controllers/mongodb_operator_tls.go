@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+func (r ReplicaSetReconciler) operatorTLSConfig(mdb mdbv1.MongoDBCommunity) (*tls.Config, error) {
+	return operatorTLSConfig(r.client, mdb)
+}
+
+// operatorTLSConfig returns the TLS configuration of the connections the operator opens to the members, or nil
+// if TLS is disabled. Unless the operator has its own CA, it trusts the CA of the deployment, and the previous
+// CA while the CA is rotated.
+func operatorTLSConfig(c kubernetesClient.Client, mdb mdbv1.MongoDBCommunity) (*tls.Config, error) {
+	tlsSpec := mdb.Spec.Security.TLS
+	if !tlsSpec.Enabled {
+		return nil, nil
+	}
+
+	var ca string
+	var err error
+	switch {
+	case tlsSpec.OperatorClient.HasCaConfigMap():
+		ca, err = configmap.ReadKey(c, tlsCACertName, types.NamespacedName{Name: tlsSpec.OperatorClient.CaConfigMap.Name, Namespace: mdb.Namespace})
+	case mdb.IsServingCABundle():
+		ca, err = configmap.ReadKey(c, tlsCACertName, mdb.CABundleConfigMapNamespacedName())
+	default:
+		ca, err = readCA(c, tlsSpec, mdb.Namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, errors.New("the CA does not contain a valid certificate")
+	}
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if tlsSpec.MinimumTLSVersion == "TLS1_3" {
+		config.MinVersion = tls.VersionTLS13
+	}
+
+	if tlsSpec.OperatorClient.HasCertificateKeySecret() {
+		cert, key, err := readCertificateAndKey(c, mdb.OperatorClientSecretNamespacedName(), "")
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := tls.X509KeyPair([]byte(cert), []byte(key))
+		if err != nil {
+			return nil, errors.Errorf("the operator client certificate is not valid: %s", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// validateOperatorClientTLS ensures the CA and client certificate configured for the connections of the operator
+// exist and are valid. The returned boolean is false if they do not exist yet.
+func (r *ReplicaSetReconciler) validateOperatorClientTLS(mdb mdbv1.MongoDBCommunity) (bool, error) {
+	operatorClient := mdb.Spec.Security.TLS.OperatorClient
+	if operatorClient.HasCaConfigMap() {
+		ca, err := configmap.ReadKey(r.client, tlsCACertName, types.NamespacedName{Name: operatorClient.CaConfigMap.Name, Namespace: mdb.Namespace})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				r.log.Warnf(`The CA ConfigMap "%s" of the operator was not found`, operatorClient.CaConfigMap.Name)
+				return false, nil
+			}
+			return false, err
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(ca)) {
+			return false, errors.Errorf(`the CA ConfigMap "%s" of the operator does not contain a valid certificate`, operatorClient.CaConfigMap.Name)
+		}
+	}
+
+	if operatorClient.HasCertificateKeySecret() {
+		// watch the client certificate to handle rotations
+		r.secretWatcher.Watch(mdb.OperatorClientSecretNamespacedName(), mdb.NamespacedName())
+		cert, key, err := readCertificateAndKey(r.client, mdb.OperatorClientSecretNamespacedName(), "")
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				r.log.Warnf(`The client certificate Secret "%s" of the operator was not found`, mdb.OperatorClientSecretNamespacedName())
+				return false, nil
+			}
+			return false, err
+		}
+		if _, err := tls.X509KeyPair([]byte(cert), []byte(key)); err != nil {
+			return false, errors.Errorf("the operator client certificate is not valid: %s", err)
+		}
+	}
+	return true, nil
+}
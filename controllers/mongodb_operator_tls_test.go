@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// issueClientCertificate returns a PEM encoded client certificate issued by the CA and its private key.
+func (ca testCA) issueClientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "mongodb-kubernetes-operator"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// assertTrusts asserts that the given pool trusts the certificates issued by the CA.
+func assertTrusts(t *testing.T, pool *x509.CertPool, ca testCA) {
+	block, _ := pem.Decode([]byte(ca.issue(t)))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	assert.NoError(t, err)
+}
+
+func TestOperatorTLSConfig(t *testing.T) {
+	deploymentCA, operatorCA := newTestCA(t, "deployment-ca"), newTestCA(t, "operator-ca")
+
+	setup := func(t *testing.T, mdb mdbv1.MongoDBCommunity) ReplicaSetReconciler {
+		mgr := client.NewManager(&mdb)
+		createCAConfigMap(t, mgr.Client, types.NamespacedName{Name: mdb.Spec.Security.TLS.CaConfigMap.Name, Namespace: mdb.Namespace}, deploymentCA.pem)
+		createCAConfigMap(t, mgr.Client, types.NamespacedName{Name: "operator-ca", Namespace: mdb.Namespace}, operatorCA.pem)
+		return *NewReconciler(mgr)
+	}
+
+	t.Run("TLS disabled", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		r := NewReconciler(client.NewManager(&mdb))
+		config, err := r.operatorTLSConfig(mdb)
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("Trusts the CA of the deployment by default", func(t *testing.T) {
+		mdb := newTestReplicaSetWithTLS()
+		r := setup(t, mdb)
+		config, err := r.operatorTLSConfig(mdb)
+		assert.NoError(t, err)
+		assertTrusts(t, config.RootCAs, deploymentCA)
+		assert.Empty(t, config.Certificates)
+	})
+
+	t.Run("Trusts its own CA and presents a client certificate", func(t *testing.T) {
+		mdb := newTestReplicaSetWithTLS()
+		mdb.Spec.Security.TLS.MinimumTLSVersion = "TLS1_3"
+		mdb.Spec.Security.TLS.OperatorClient = &mdbv1.OperatorClientTLS{
+			CaConfigMap:          &mdbv1.LocalObjectReference{Name: "operator-ca"},
+			CertificateKeySecret: &mdbv1.LocalObjectReference{Name: "operator-client"},
+		}
+		r := setup(t, mdb)
+		cert, key := deploymentCA.issueClientCertificate(t)
+		s := secret.Builder().
+			SetName("operator-client").
+			SetNamespace(mdb.Namespace).
+			SetField("tls.crt", cert).
+			SetField("tls.key", key).
+			Build()
+		assert.NoError(t, r.client.CreateSecret(s))
+
+		config, err := r.operatorTLSConfig(mdb)
+		assert.NoError(t, err)
+		assertTrusts(t, config.RootCAs, operatorCA)
+		assert.Len(t, config.Certificates, 1)
+		assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	})
+
+	t.Run("A missing client certificate is waited for", func(t *testing.T) {
+		mdb := newTestReplicaSetWithTLS()
+		mdb.Spec.Security.TLS.OperatorClient = &mdbv1.OperatorClientTLS{
+			CertificateKeySecret: &mdbv1.LocalObjectReference{Name: "missing"},
+		}
+		r := setup(t, mdb)
+		valid, err := r.validateOperatorClientTLS(mdb)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("An invalid client certificate is rejected", func(t *testing.T) {
+		mdb := newTestReplicaSetWithTLS()
+		mdb.Spec.Security.TLS.OperatorClient = &mdbv1.OperatorClientTLS{
+			CertificateKeySecret: &mdbv1.LocalObjectReference{Name: "operator-client"},
+		}
+		r := setup(t, mdb)
+		s := secret.Builder().
+			SetName("operator-client").
+			SetNamespace(mdb.Namespace).
+			SetField("tls.crt", "CERT").
+			SetField("tls.key", "KEY").
+			Build()
+		assert.NoError(t, r.client.CreateSecret(s))
+
+		valid, err := r.validateOperatorClientTLS(mdb)
+		assert.Error(t, err)
+		assert.False(t, valid)
+	})
+}
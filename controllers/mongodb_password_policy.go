@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/generate"
+)
+
+const (
+	// PasswordMinLengthEnv is the minimum length of the passwords the operator generates. Unset or zero keeps the
+	// length of each credential, 20 characters for the agent and 32 for the metrics user.
+	PasswordMinLengthEnv = "PASSWORD_MIN_LENGTH"
+
+	// PasswordCharacterClassesEnv is a comma separated list of the character classes every generated password
+	// contains, out of lowercase, uppercase, digits and symbols. Unset means URL-safe base64 passwords.
+	PasswordCharacterClassesEnv = "PASSWORD_CHARACTER_CLASSES"
+
+	// PasswordRequireFIPSEnv refuses to generate passwords unless the kernel random number generator runs in FIPS mode.
+	PasswordRequireFIPSEnv = "PASSWORD_REQUIRE_FIPS"
+)
+
+// passwordPolicyFromEnv returns the policy of the passwords generated by the operator.
+func passwordPolicyFromEnv() (generate.PasswordPolicy, error) {
+	policy := generate.PasswordPolicy{RequireFIPS: envvar.ReadBool(PasswordRequireFIPSEnv)}
+	if value := os.Getenv(PasswordMinLengthEnv); value != "" {
+		length, err := strconv.Atoi(value)
+		if err != nil || length < 0 {
+			return generate.PasswordPolicy{}, errors.Errorf("%s must be a non-negative integer, got %q", PasswordMinLengthEnv, value)
+		}
+		policy.MinLength = length
+	}
+	if value := os.Getenv(PasswordCharacterClassesEnv); value != "" {
+		for _, class := range strings.Split(value, ",") {
+			policy.CharacterClasses = append(policy.CharacterClasses, generate.CharacterClass(strings.TrimSpace(class)))
+		}
+	}
+	if err := policy.Validate(); err != nil {
+		return generate.PasswordPolicy{}, errors.Errorf("invalid password policy: %s", err)
+	}
+	return policy, nil
+}
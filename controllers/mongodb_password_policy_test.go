@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/generate"
+)
+
+func TestPasswordPolicyFromEnv(t *testing.T) {
+	defer os.Unsetenv(PasswordMinLengthEnv)
+	defer os.Unsetenv(PasswordCharacterClassesEnv)
+
+	policy, err := passwordPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, generate.PasswordPolicy{}, policy)
+
+	os.Setenv(PasswordMinLengthEnv, "32")
+	os.Setenv(PasswordCharacterClassesEnv, "lowercase, uppercase,digits")
+	policy, err = passwordPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, generate.PasswordPolicy{MinLength: 32, CharacterClasses: []generate.CharacterClass{generate.Lowercase, generate.Uppercase, generate.Digits}}, policy)
+
+	os.Setenv(PasswordCharacterClassesEnv, "lowercase,kanji")
+	assert.Error(t, ValidateEnv())
+
+	os.Setenv(PasswordCharacterClassesEnv, "lowercase")
+	os.Setenv(PasswordMinLengthEnv, "long")
+	assert.Error(t, ValidateEnv())
+}
+
+func TestMetricsUserPassword_FollowsThePasswordPolicy(t *testing.T) {
+	defer os.Unsetenv(PasswordMinLengthEnv)
+	defer os.Unsetenv(PasswordCharacterClassesEnv)
+	os.Setenv(PasswordMinLengthEnv, "48")
+	os.Setenv(PasswordCharacterClassesEnv, "digits")
+
+	mdb := newTestReplicaSetWithMetricsUser("")
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	password, err := secret.ReadKey(mgr.Client, mdbv1.MetricsUserPasswordKey, mdb.MetricsUserPasswordSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Len(t, password, 48)
+	assert.Equal(t, "", strings.Trim(password, "0123456789"))
+}
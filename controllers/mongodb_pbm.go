@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	pbmAgentName = "pbm-agent"
+
+	pbmAgentSecretMountPath = "/etc/pbm-agent"
+)
+
+// pbmAgentCommand starts pbm-agent with the connection string of the member of the Pod, and restarts it if it
+// exits, e.g. because its user has not been created yet, without restarting the Pod.
+const pbmAgentCommand = `while true; do
+  export PBM_MONGODB_URI="$(cat ` + pbmAgentSecretMountPath + `/$POD_NAME)"
+  pbm-agent
+  echo "pbm-agent exited, restarting it in 10 seconds"
+  sleep 10
+done
+`
+
+// validatePBM checks that the PBM agents have a user to connect as and a storage to store the backups in.
+func validatePBM(mdb mdbv1.MongoDBCommunity) error {
+	if !mdb.IsPBMEnabled() {
+		return nil
+	}
+	pbm := mdb.Spec.PBM
+	if _, ok := findUser(mdb, pbm.User); !ok {
+		return errors.Errorf("pbm.user %q is not a user of the resource", pbm.User)
+	}
+	if pbm.Storage.Bucket == "" {
+		return errors.New("pbm.storage.bucket is required")
+	}
+	if pbm.Storage.CredentialsSecretRef.Name == "" {
+		return errors.New("pbm.storage.credentialsSecretRef is required")
+	}
+	return nil
+}
+
+// ensurePBMResources creates or updates the Secret storing the PBM configuration, and the Secret storing the
+// connection string of the agent of each member. Both are deleted if PBM is not enabled and they were created by
+// the operator.
+func (r ReplicaSetReconciler) ensurePBMResources(mdb mdbv1.MongoDBCommunity) error {
+	if !mdb.IsPBMEnabled() {
+		for _, name := range []string{mdb.PBMConfigSecretName(), mdb.PBMAgentSecretName()} {
+			s := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: mdb.Namespace}}
+			if err := r.deleteControlledObject(mdb, &s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	storage := mdb.Spec.PBM.Storage
+	credentials, err := secret.ReadStringData(r.client, types.NamespacedName{Name: storage.CredentialsSecretRef.Name, Namespace: mdb.Namespace})
+	if err != nil {
+		return errors.Errorf("could not read the credentials of the storage: %s", err)
+	}
+	configSecret := secret.Builder().
+		SetName(mdb.PBMConfigSecretName()).
+		SetNamespace(mdb.Namespace).
+		SetField(construct.PBMConfigKey, buildPBMConfig(*mdb.Spec.PBM, credentials[mdbv1.S3AccessKeyIDKey], credentials[mdbv1.S3SecretAccessKeyKey])).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentDatabase)).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	if err := secret.CreateOrUpdate(r.client, configSecret); err != nil {
+		return err
+	}
+
+	user, _ := findUser(mdb, mdb.Spec.PBM.User)
+	password, err := secret.ReadKey(r.client, user.GetPasswordSecretKey(), types.NamespacedName{Name: user.PasswordSecretRef.Name, Namespace: mdb.Namespace})
+	if err != nil {
+		return errors.Errorf("could not read the password of user %s: %s", user.Name, err)
+	}
+	// the members being removed by a scale down keep their agent until their Pod is deleted
+	members := mdb.Spec.Members
+	if mdb.Status.CurrentStatefulSetReplicas > members {
+		members = mdb.Status.CurrentStatefulSetReplicas
+	}
+	hostnames, err := mdb.MemberHostnames(members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return err
+	}
+	uris := map[string]string{}
+	for i, hostname := range hostnames {
+		uris[mdb.PodName(i)] = pbmAgentURI(mdb, user, password, hostname)
+	}
+	agentSecret := secret.Builder().
+		SetName(mdb.PBMAgentSecretName()).
+		SetNamespace(mdb.Namespace).
+		SetStringData(uris).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentDatabase)).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return secret.CreateOrUpdate(r.client, agentSecret)
+}
+
+// pbmAgentURI returns the connection string the agent of a member connects to that member only with.
+func pbmAgentURI(mdb mdbv1.MongoDBCommunity, user mdbv1.MongoDBUser, password, hostname string) string {
+	query := url.Values{}
+	query.Set("authSource", user.GetDB())
+	query.Set("directConnection", "true")
+	if mdb.Spec.Security.TLS.Enabled {
+		query.Set("tls", "true")
+		query.Set("tlsCAFile", tlsCAMountPath+tlsCACertName)
+	}
+	credentials := url.UserPassword(user.Name, password).String()
+	return fmt.Sprintf("mongodb://%s@%s/?%s", credentials, net.JoinHostPort(hostname, "27017"), query.Encode())
+}
+
+// buildPBMConfig returns the configuration applied with "pbm config" before every backup and restore.
+func buildPBMConfig(pbm mdbv1.PBMConfiguration, accessKeyID, secretAccessKey string) string {
+	b := strings.Builder{}
+	b.WriteString("storage:\n  type: s3\n  s3:\n")
+	fmt.Fprintf(&b, "    bucket: %s\n", strconv.Quote(pbm.Storage.Bucket))
+	if prefix := strings.Trim(pbm.Storage.Prefix, "/"); prefix != "" {
+		fmt.Fprintf(&b, "    prefix: %s\n", strconv.Quote(prefix))
+	}
+	if pbm.Storage.Region != "" {
+		fmt.Fprintf(&b, "    region: %s\n", strconv.Quote(pbm.Storage.Region))
+	}
+	if pbm.Storage.Endpoint != "" {
+		fmt.Fprintf(&b, "    endpointUrl: %s\n", strconv.Quote(pbm.Storage.Endpoint))
+	}
+	fmt.Fprintf(&b, "    credentials:\n      access-key-id: %s\n      secret-access-key: %s\n", strconv.Quote(accessKeyID), strconv.Quote(secretAccessKey))
+
+	pitr := pbm.PointInTimeRecovery
+	if pitr == nil {
+		pitr = &mdbv1.PBMPointInTimeRecovery{}
+	}
+	fmt.Fprintf(&b, "pitr:\n  enabled: %t\n  oplogSpanMin: %d\n", pitr.Enabled, pitr.GetOplogSpanMinutes())
+	return b.String()
+}
+
+// buildPBMAgentPodSpecModification adds the pbm-agent sidecar to the pod template if PBM is enabled. The agent
+// mounts the data volume, which physical backups copy the data files from. Otherwise the sidecar is removed
+// from the existing template, as it mounts a Secret which is deleted with the configuration.
+func buildPBMAgentPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	if !mdb.IsPBMEnabled() {
+		return removeSidecar(pbmAgentName, pbmAgentName)
+	}
+
+	agentVolume := statefulset.CreateVolumeFromSecret(pbmAgentName, mdb.PBMAgentSecretName())
+	volumeMounts := []corev1.VolumeMount{statefulset.CreateVolumeMount(agentVolume.Name, pbmAgentSecretMountPath, statefulset.WithReadOnly(true))}
+	if mdb.HasSeparateDataAndLogsVolumes() {
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(mdb.DataVolumeName(), "/data", statefulset.WithReadOnly(true)))
+	} else {
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(mdb.DataVolumeName(), "/data", statefulset.WithSubPath("data"), statefulset.WithReadOnly(true)))
+	}
+	if mdb.Spec.Security.TLS.Enabled {
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(tlsCAVolumeName, tlsCAMountPath, statefulset.WithReadOnly(true)))
+	}
+
+	return podtemplatespec.Apply(
+		podtemplatespec.WithVolume(agentVolume),
+		podtemplatespec.WithContainer(pbmAgentName, container.Apply(
+			container.WithName(pbmAgentName),
+			container.WithImage(mdb.Spec.PBM.GetImage()),
+			container.WithCommand([]string{"/bin/sh", "-c", pbmAgentCommand}),
+			container.WithEnvs(corev1.EnvVar{
+				Name:      "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+			}),
+			container.WithVolumeMounts(volumeMounts),
+		)),
+	)
+}
+
+// removeSidecar removes the container with the given name and the given volumes from the pod template.
+func removeSidecar(name string, volumeNames ...string) podtemplatespec.Modification {
+	return func(template *corev1.PodTemplateSpec) {
+		containers := template.Spec.Containers[:0]
+		for _, c := range template.Spec.Containers {
+			if c.Name != name {
+				containers = append(containers, c)
+			}
+		}
+		template.Spec.Containers = containers
+
+		volumes := template.Spec.Volumes[:0]
+		for _, v := range template.Spec.Volumes {
+			if !contains.String(volumeNames, v.Name) {
+				volumes = append(volumes, v)
+			}
+		}
+		template.Spec.Volumes = volumes
+	}
+}
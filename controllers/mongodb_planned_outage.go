@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// maxVotingMembers is the maximum number of voting members of a replica set.
+const maxVotingMembers = 7
+
+// startPlannedOutage determines the members running in the zones scheduled for maintenance whenever the zones
+// change, and records them in the status. The votes of these members are removed by plannedOutageModification
+// until the zones are removed from the spec again.
+func (r *ReplicaSetReconciler) startPlannedOutage(mdb *mdbv1.MongoDBCommunity) error {
+	zones, err := plannedOutageZones(*mdb)
+	if err != nil {
+		return err
+	}
+	current := mdb.Status.PlannedOutage
+	if len(zones) == 0 {
+		if current == nil {
+			return nil
+		}
+		r.log.Infof("The planned outage of zones %s has ended, restoring the votes of members %s", strings.Join(current.Zones, ", "), strings.Join(current.Members, ", "))
+		return r.updatePlannedOutageStatus(mdb, nil)
+	}
+	if current != nil && strings.Join(current.Zones, ",") == strings.Join(zones, ",") {
+		return nil
+	}
+
+	members, err := r.membersInZones(*mdb, zones)
+	if err != nil {
+		return err
+	}
+	if len(members) >= mdb.AutomationConfigMembersThisReconciliation() {
+		return errors.Errorf("all members run in the zones %s, at least one member must keep its vote", strings.Join(zones, ", "))
+	}
+
+	r.log.Infof("Removing the votes of members %s for the planned outage of zones %s", strings.Join(members, ", "), strings.Join(zones, ", "))
+	return r.updatePlannedOutageStatus(mdb, &mdbv1.PlannedOutageStatus{Zones: zones, Members: members})
+}
+
+// plannedOutageZones returns the sorted zones scheduled for maintenance.
+func plannedOutageZones(mdb mdbv1.MongoDBCommunity) ([]string, error) {
+	var zones []string
+	for _, zone := range mdb.Spec.PlannedOutage.GetZones() {
+		if zone == "" {
+			return nil, errors.New("plannedOutage.zones must not contain empty zones")
+		}
+		if contains.String(zones, zone) {
+			return nil, errors.Errorf("zone %s is listed more than once in plannedOutage.zones", zone)
+		}
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+// membersInZones returns the names of the Pods running on nodes in the given zones. Pods which do not exist or
+// are not scheduled yet are not in any zone.
+func (r ReplicaSetReconciler) membersInZones(mdb mdbv1.MongoDBCommunity, zones []string) ([]string, error) {
+	var members []string
+	for i := 0; i < mdb.AutomationConfigMembersThisReconciliation(); i++ {
+		podName := mdb.PodName(i)
+		node, err := r.nodeOfPod(mdb.Namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil && contains.String(zones, node.Labels[corev1.LabelTopologyZone]) {
+			members = append(members, podName)
+		}
+	}
+	return members, nil
+}
+
+// nodeOfPod returns the node the Pod with the given name runs on, or nil if the Pod does not exist or is not
+// scheduled yet. Nodes are not watched by the operator, so they are read from the API server.
+func (r ReplicaSetReconciler) nodeOfPod(namespace, podName string) (*corev1.Node, error) {
+	pod, err := r.client.GetPod(types.NamespacedName{Name: podName, Namespace: namespace})
+	if apiErrors.IsNotFound(err) || (err == nil && pod.Spec.NodeName == "") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Errorf("could not get Pod %s: %s", podName, err)
+	}
+
+	node := corev1.Node{}
+	if err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		return nil, errors.Errorf("could not get node %s of Pod %s: %s", pod.Spec.NodeName, podName, err)
+	}
+	return &node, nil
+}
+
+func (r *ReplicaSetReconciler) updatePlannedOutageStatus(mdb *mdbv1.MongoDBCommunity, outage *mdbv1.PlannedOutageStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withPlannedOutage(outage))
+	return err
+}
+
+// plannedOutageModification removes the votes and the priority of the members of a planned outage. Members
+// outside of the outage which do not vote because of the limit of voting members get the freed votes.
+func plannedOutageModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	outage := mdb.Status.PlannedOutage
+	if outage == nil || len(outage.Members) == 0 {
+		return automationconfig.NOOP()
+	}
+
+	return func(config *automationconfig.AutomationConfig) {
+		for _, rs := range config.ReplicaSets {
+			voting := 0
+			for i := range rs.Members {
+				if contains.String(outage.Members, mdb.PodName(i)) {
+					rs.Members[i].Votes = 0
+					rs.Members[i].Priority = 0
+				}
+				voting += rs.Members[i].Votes
+			}
+			for i := range rs.Members {
+				if voting >= maxVotingMembers {
+					break
+				}
+				if rs.Members[i].Votes == 0 && !rs.Members[i].ArbiterOnly && !contains.String(outage.Members, mdb.PodName(i)) {
+					rs.Members[i].Votes = 1
+					rs.Members[i].Priority = 1
+					voting++
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// createPodsInZones creates a node in each given zone, and a Pod of each member scheduled on the node of its zone.
+func createPodsInZones(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCommunity, zones ...string) {
+	for i, zone := range zones {
+		nodeName := fmt.Sprintf("node-%s", zone)
+		node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{corev1.LabelTopologyZone: zone}}}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: nodeName}, &corev1.Node{}); err != nil {
+			assert.NoError(t, c.Create(context.TODO(), &node))
+		}
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(i), Namespace: mdb.Namespace},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+		assert.NoError(t, c.Create(context.TODO(), &pod))
+	}
+}
+
+// reconcileWithAgentsInGoalState reconciles the resource, simulates the agents reaching the goal state of the
+// published automation config, and reconciles the resource again.
+func reconcileWithAgentsInGoalState(t *testing.T, r *ReplicaSetReconciler, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) {
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for i := 0; i < mdb.Spec.Members; i++ {
+		pod := corev1.Pod{}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace}, &pod))
+		pod.Annotations = map[string]string{"agent.mongodb.com/version": fmt.Sprint(ac.Version)}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+	}
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+}
+
+func memberVotes(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) ([]int, []int) {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	var votes, priorities []int
+	for _, member := range ac.ReplicaSets[0].Members {
+		votes = append(votes, member.Votes)
+		priorities = append(priorities, member.Priority)
+	}
+	return votes, priorities
+}
+
+func TestPlannedOutage_RemovesAndRestoresVotes(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Members = 5
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+	votes, _ := memberVotes(t, mgr, mdb)
+	assert.Equal(t, []int{1, 1, 1, 1, 1}, votes)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.PlannedOutage = &mdbv1.PlannedOutage{Zones: []string{"zone-a"}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+	votes, priorities := memberVotes(t, mgr, mdb)
+	assert.Equal(t, []int{0, 1, 0, 1, 1}, votes)
+	assert.Equal(t, []int{0, 1, 0, 1, 1}, priorities)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, &mdbv1.PlannedOutageStatus{Zones: []string{"zone-a"}, Members: []string{"my-rs-0", "my-rs-2"}}, mdb.Status.PlannedOutage)
+
+	t.Run("Votes are restored once the outage has ended", func(t *testing.T) {
+		mdb.Spec.PlannedOutage = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+		votes, _ := memberVotes(t, mgr, mdb)
+		assert.Equal(t, []int{1, 1, 1, 1, 1}, votes)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Nil(t, mdb.Status.PlannedOutage)
+	})
+
+	t.Run("An outage of all members is rejected", func(t *testing.T) {
+		mdb.Spec.PlannedOutage = &mdbv1.PlannedOutage{Zones: []string{"zone-a", "zone-b", "zone-c"}}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "at least one member must keep its vote")
+	})
+}
+
+func TestPlannedOutageModification_RedistributesVotes(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Members = 9
+	mdb.Status.PlannedOutage = &mdbv1.PlannedOutageStatus{Zones: []string{"zone-a"}, Members: []string{"my-rs-0", "my-rs-1"}}
+
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, plannedOutageModification(mdb))
+	assert.NoError(t, err)
+
+	votes := 0
+	for i, member := range ac.ReplicaSets[0].Members {
+		if i < 2 {
+			assert.Equal(t, 0, member.Votes)
+			assert.Equal(t, 0, member.Priority)
+		}
+		votes += member.Votes
+	}
+	assert.Equal(t, maxVotingMembers, votes, "the votes of the outage members are given to non-voting members")
+}
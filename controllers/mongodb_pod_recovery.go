@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// podRecoveryModification sets the pod management policy of the StatefulSet. With the Parallel policy, the
+// StatefulSet controller recreates missing Pods at the same time, instead of waiting for the Pod of each lower
+// ordinal to be ready. Recreating a Pod never removes a healthy member, and the operator still adds and removes
+// members one at a time, so the policy does not put the majority of the replica set at risk. Rolling updates are
+// not affected by the policy. A policy configured in the StatefulSet override takes precedence.
+func podRecoveryModification(mdb mdbv1.MongoDBCommunity) statefulset.Modification {
+	if mdb.Spec.PodRecovery.IsParallel() {
+		return statefulset.WithPodManagementPolicyType(appsv1.ParallelPodManagement)
+	}
+	return statefulset.WithPodManagementPolicyType(appsv1.OrderedReadyPodManagement)
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestParallelPodRecovery_SetsThePodManagementPolicy(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.PodRecovery = &mdbv1.PodRecovery{Parallel: true}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, appsv1.ParallelPodManagement, sts.Spec.PodManagementPolicy)
+
+	t.Run("The policy of the StatefulSet override takes precedence", func(t *testing.T) {
+		mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+		sts, err := buildStatefulSet(mdb)
+		assert.NoError(t, err)
+		assert.Equal(t, appsv1.OrderedReadyPodManagement, sts.Spec.PodManagementPolicy)
+	})
+
+	t.Run("Pods are recreated one at a time by default", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		sts, err := buildStatefulSet(mdb)
+		assert.NoError(t, err)
+		assert.Equal(t, appsv1.OrderedReadyPodManagement, sts.Spec.PodManagementPolicy)
+	})
+}
+
+func TestParallelPodRecovery_ResyncsStuckMembersWhileAMajorityRemainsHealthy(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Members = 5
+	mdb.Spec.PodRecovery = &mdbv1.PodRecovery{Parallel: true}
+	r, mgr, reader, recorder, mdb := setupStuckMembers(t, mdb, mdbv1.StuckMemberRemediation{Threshold: "10m", Resync: true})
+	since := mdb.Status.StuckMembers[0].Since
+
+	reader.status.Members[3].StateStr = "RECOVERING"
+	r.remediateStuckMembers(&mdb, since.Time)
+	r.remediateStuckMembers(&mdb, since.Add(11*time.Minute))
+	assert.Len(t, recorder.Events, 4)
+	for _, i := range []int{3, 4} {
+		podExists, pvcExists := podAndClaimExist(t, mgr, mdb, i)
+		assert.False(t, podExists)
+		assert.False(t, pvcExists)
+	}
+	assert.Empty(t, mdb.Status.StuckMembers)
+
+	t.Run("Members are not resynced if fewer than a majority would remain healthy", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mdb.Spec.PodRecovery = &mdbv1.PodRecovery{Parallel: true}
+		r, mgr, reader, _, mdb := setupStuckMembers(t, mdb, mdbv1.StuckMemberRemediation{Threshold: "10m", Resync: true})
+		since := mdb.Status.StuckMembers[0].Since
+
+		reader.status.Members[1].StateStr = "RECOVERING"
+		r.remediateStuckMembers(&mdb, since.Time)
+		r.remediateStuckMembers(&mdb, since.Add(11*time.Minute))
+		for _, i := range []int{1, 2} {
+			podExists, pvcExists := podAndClaimExist(t, mgr, mdb, i)
+			assert.True(t, podExists && pvcExists)
+		}
+		assert.Len(t, mdb.Status.StuckMembers, 2)
+	})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"github.com/blang/semver"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// probeDefaults are the defaults of the probes of the members, which depend on the MongoDB version.
+type probeDefaults struct {
+	// startupSeconds is how long mongod may take to start before the liveness probe is run.
+	startupSeconds int
+	// readinessFailureThreshold is the number of consecutive failed readiness probes after which a member
+	// is removed from the endpoints of the Services.
+	readinessFailureThreshold int32
+}
+
+// versionProbeDefaults lists the probe defaults by the first version they apply to, from the newest version.
+// Versions which can not be parsed use the defaults of the newest version.
+var versionProbeDefaults = []struct {
+	since    string
+	defaults probeDefaults
+}{
+	// 6.0 and later load the catalog of all collections and indexes when mongod starts, which takes several
+	// minutes with large catalogs, and their initial syncs spend longer without progress in the health status.
+	{since: "6.0.0", defaults: probeDefaults{startupSeconds: 300, readinessFailureThreshold: 90}},
+	{since: "5.0.0", defaults: probeDefaults{startupSeconds: 120, readinessFailureThreshold: 60}},
+	{since: "0.0.0", defaults: probeDefaults{startupSeconds: 60, readinessFailureThreshold: 60}},
+}
+
+// probeDefaultsFor returns the probe defaults of the given MongoDB version.
+func probeDefaultsFor(version string) probeDefaults {
+	v, err := semver.Make(version)
+	if err != nil {
+		return versionProbeDefaults[0].defaults
+	}
+	// pre-release and build suffixes, such as -ent, do not change the defaults
+	v = semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	for _, entry := range versionProbeDefaults {
+		if v.GTE(semver.MustParse(entry.since)) {
+			return entry.defaults
+		}
+	}
+	return versionProbeDefaults[len(versionProbeDefaults)-1].defaults
+}
+
+// mongodLivenessInitialDelaySeconds returns the number of seconds after the mongod container has started before
+// the liveness probe is first run, which defaults to the startup time of the MongoDB version.
+func mongodLivenessInitialDelaySeconds(mdb mdbv1.MongoDBCommunity) int {
+	if mdb.Spec.MongodLivenessProbe != nil && mdb.Spec.MongodLivenessProbe.InitialDelaySeconds != nil {
+		return *mdb.Spec.MongodLivenessProbe.InitialDelaySeconds
+	}
+	return probeDefaultsFor(mdb.GetMongoDBVersion()).startupSeconds
+}
+
+// buildReadinessProbeDefaultsPodSpecModification sets the failure threshold of the readiness probe of the members
+// to the default of the MongoDB version. A threshold configured in the StatefulSet override takes precedence.
+func buildReadinessProbeDefaultsPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	threshold := probeDefaultsFor(mdb.GetMongoDBVersion()).readinessFailureThreshold
+	return podtemplatespec.WithContainer(construct.AgentName, func(c *corev1.Container) {
+		if c.ReadinessProbe != nil {
+			c.ReadinessProbe.FailureThreshold = threshold
+		}
+	})
+}
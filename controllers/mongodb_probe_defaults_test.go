@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+)
+
+func TestProbeDefaultsFor(t *testing.T) {
+	assert.Equal(t, 60, probeDefaultsFor("4.4.10").startupSeconds)
+	assert.Equal(t, 120, probeDefaultsFor("5.0.0").startupSeconds)
+	assert.Equal(t, 120, probeDefaultsFor("5.3.1").startupSeconds)
+	assert.Equal(t, 300, probeDefaultsFor("6.0.0-ent").startupSeconds)
+	assert.Equal(t, int32(90), probeDefaultsFor("7.0.2").readinessFailureThreshold)
+	assert.Equal(t, probeDefaultsFor("7.0.2"), probeDefaultsFor("latest"), "unparseable versions use the defaults of the newest version")
+}
+
+func agentReadinessProbe(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) *corev1.Probe {
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	return container.GetByName(construct.AgentName, sts.Spec.Template.Spec.Containers).ReadinessProbe
+}
+
+func TestProbeDefaults_DependOnTheVersion(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Version = "6.0.5"
+	mdb.Spec.MongodLivenessProbe = &mdbv1.MongodLivenessProbe{Enabled: true}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.Equal(t, int32(300), mongodLivenessProbe(t, mgr, mdb).InitialDelaySeconds)
+	assert.Equal(t, int32(90), agentReadinessProbe(t, mgr, mdb).FailureThreshold)
+
+	t.Run("The defaults can be overridden", func(t *testing.T) {
+		initialDelaySeconds := 600
+		mdb.Spec.MongodLivenessProbe.InitialDelaySeconds = &initialDelaySeconds
+		mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec = appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:           construct.AgentName,
+						ReadinessProbe: &corev1.Probe{FailureThreshold: 20},
+					}},
+				},
+			},
+		}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.Equal(t, int32(600), mongodLivenessProbe(t, mgr, mdb).InitialDelaySeconds)
+		assert.Equal(t, int32(20), agentReadinessProbe(t, mgr, mdb).FailureThreshold)
+	})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/agent"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/scale"
+	"go.uber.org/zap"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// observeProgress returns the progress of the rollout the resource is waiting for. A rollout starts when it is
+// first observed, and its members are counted from the highest ordinal down, which is the order the StatefulSet
+// controller restarts them in. The progress is not changed if the members could not be observed.
+func (r ReplicaSetReconciler) observeProgress(mdb mdbv1.MongoDBCommunity, now time.Time) *mdbv1.ProgressStatus {
+	sts, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	if err != nil {
+		r.log.Debugf("Could not observe the progress of the rollout: %s", err)
+		return mdb.Status.Progress
+	}
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		r.log.Debugf("Could not observe the progress of the rollout: %s", err)
+		return mdb.Status.Progress
+	}
+
+	members := mdb.StatefulSetReplicasThisReconciliation()
+	if members == 0 {
+		return mdb.Status.Progress
+	}
+	completed := 0
+	currentMember := ""
+	for i := members - 1; i >= 0; i-- {
+		pod, err := r.client.GetPod(types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace})
+		if err == nil && memberCompletedRollout(pod, sts, ac.Version, r.log) {
+			completed++
+		} else if currentMember == "" {
+			currentMember = mdb.PodName(i)
+		}
+	}
+
+	progress := startedProgress(mdb, now)
+	progress.Members = members
+	progress.MembersCompleted = completed
+	progress.Percent = completed * 100 / members
+	progress.CurrentMember = currentMember
+	progress.EstimatedCompletionTime = estimatedCompletionTime(*progress, now)
+	return progress
+}
+
+// completedProgress returns the progress of the rollout once the resource is running, which is complete. The
+// average time a member took is kept to estimate the duration of the next rollout.
+func completedProgress(mdb mdbv1.MongoDBCommunity, now time.Time) *mdbv1.ProgressStatus {
+	if mdb.Status.Progress == nil || mdb.Status.Progress.CompletionTime != nil {
+		return mdb.Status.Progress
+	}
+	progress := mdb.Status.Progress.DeepCopy()
+	members := mdb.StatefulSetReplicasThisReconciliation()
+	progress.Members = members
+	progress.MembersCompleted = members
+	progress.Percent = 100
+	progress.CurrentMember = ""
+	progress.EstimatedCompletionTime = nil
+	completionTime := metav1.NewTime(now)
+	progress.CompletionTime = &completionTime
+	if members > 0 {
+		progress.MemberDuration = &metav1.Duration{Duration: now.Sub(progress.StartTime.Time) / time.Duration(members)}
+	}
+	return progress
+}
+
+// startedProgress returns the progress of the current rollout, starting a new rollout if the previous one has completed.
+func startedProgress(mdb mdbv1.MongoDBCommunity, now time.Time) *mdbv1.ProgressStatus {
+	if mdb.Status.Progress != nil && mdb.Status.Progress.CompletionTime == nil {
+		return mdb.Status.Progress.DeepCopy()
+	}
+	progress := &mdbv1.ProgressStatus{
+		Operation: progressOperation(mdb),
+		StartTime: metav1.NewTime(now),
+	}
+	if mdb.Status.Progress != nil {
+		progress.MemberDuration = mdb.Status.Progress.MemberDuration
+	}
+	return progress
+}
+
+// progressOperation returns the kind of change which is being rolled out.
+func progressOperation(mdb mdbv1.MongoDBCommunity) string {
+	if mdb.IsChangingVersion() {
+		return mdbv1.ProgressOperationUpgrade
+	}
+	if scale.IsStillScaling(mdb) {
+		return mdbv1.ProgressOperationScaling
+	}
+	return mdbv1.ProgressOperationRollout
+}
+
+// estimatedCompletionTime extrapolates the time the completed members took in the current rollout, or the time
+// the members took in the previous rollout if no member has completed yet. No estimate is returned if the
+// rollout takes longer than estimated.
+func estimatedCompletionTime(progress mdbv1.ProgressStatus, now time.Time) *metav1.Time {
+	var memberDuration time.Duration
+	switch {
+	case progress.MembersCompleted > 0:
+		memberDuration = now.Sub(progress.StartTime.Time) / time.Duration(progress.MembersCompleted)
+	case progress.MemberDuration != nil:
+		memberDuration = progress.MemberDuration.Duration
+	default:
+		return nil
+	}
+	estimate := progress.StartTime.Add(memberDuration * time.Duration(progress.Members))
+	if !estimate.After(now) {
+		return nil
+	}
+	estimatedTime := metav1.NewTime(estimate)
+	return &estimatedTime
+}
+
+// memberCompletedRollout returns true if the Pod runs the current revision of the StatefulSet, is ready and its
+// agent has reached the goal state of the given automation config version.
+func memberCompletedRollout(pod corev1.Pod, sts appsv1.StatefulSet, acVersion int, log *zap.SugaredLogger) bool {
+	if sts.Status.UpdateRevision != "" && pod.Labels[appsv1.StatefulSetRevisionLabel] != sts.Status.UpdateRevision {
+		return false
+	}
+	ready := false
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	return ready && agent.ReachedGoalState(pod, acVersion, log)
+}
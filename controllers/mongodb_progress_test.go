@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// setMemberRevision creates or updates the Pod of the member with the given StatefulSet revision, as ready with
+// its agent in goal state if it runs the update revision.
+func setMemberRevision(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, ordinal int, revision string) {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(ordinal), Namespace: mdb.Namespace}}
+	exists := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, &pod) == nil
+	pod.Labels = map[string]string{"app": mdb.ServiceName(), appsv1.StatefulSetRevisionLabel: revision}
+	pod.Annotations = map[string]string{"agent.mongodb.com/version": strconv.Itoa(ac.Version)}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if revision != "rev-2" {
+		pod.Status.Conditions[0].Status = corev1.ConditionFalse
+	}
+	if exists {
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+	} else {
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	}
+}
+
+func setStatefulSetRevision(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, updated int) {
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.StatefulSetNamespacedName(), &sts))
+	sts.Status.CurrentRevision = "rev-1"
+	sts.Status.UpdateRevision = "rev-2"
+	sts.Status.UpdatedReplicas = int32(updated)
+	sts.Status.ReadyReplicas = int32(updated)
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &sts))
+}
+
+func TestProgress_IsReportedDuringARollout(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Nil(t, mdb.Status.Progress, "no progress is reported before the first rollout")
+
+	// the member with the highest ordinal has been restarted ten minutes into the rollout
+	setStatefulSetRevision(t, mgr, mdb, 1)
+	setMemberRevision(t, mgr, mdb, 2, "rev-2")
+	setMemberRevision(t, mgr, mdb, 1, "rev-1")
+	setMemberRevision(t, mgr, mdb, 0, "rev-1")
+	startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	mdb.Status.Progress = &mdbv1.ProgressStatus{Operation: mdbv1.ProgressOperationRollout, StartTime: startTime}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	if assert.NotNil(t, mdb.Status.Progress) {
+		progress := *mdb.Status.Progress
+		assert.Equal(t, mdbv1.ProgressOperationRollout, progress.Operation)
+		assert.Equal(t, 3, progress.Members)
+		assert.Equal(t, 1, progress.MembersCompleted)
+		assert.Equal(t, 33, progress.Percent)
+		assert.Equal(t, "my-rs-1", progress.CurrentMember)
+		assert.True(t, progress.StartTime.Equal(&startTime))
+		if assert.NotNil(t, progress.EstimatedCompletionTime) {
+			assert.WithinDuration(t, startTime.Add(30*time.Minute), progress.EstimatedCompletionTime.Time, time.Minute)
+		}
+		assert.Nil(t, progress.CompletionTime)
+	}
+
+	t.Run("The rollout completes once all members run the update revision", func(t *testing.T) {
+		setStatefulSetRevision(t, mgr, mdb, 3)
+		setMemberRevision(t, mgr, mdb, 1, "rev-2")
+		setMemberRevision(t, mgr, mdb, 0, "rev-2")
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+		if assert.NotNil(t, mdb.Status.Progress) {
+			progress := *mdb.Status.Progress
+			assert.Equal(t, 3, progress.MembersCompleted)
+			assert.Equal(t, 100, progress.Percent)
+			assert.Empty(t, progress.CurrentMember)
+			assert.Nil(t, progress.EstimatedCompletionTime)
+			assert.NotNil(t, progress.CompletionTime)
+			if assert.NotNil(t, progress.MemberDuration) {
+				assert.InDelta(t, float64(10*time.Minute/3), float64(progress.MemberDuration.Duration), float64(time.Minute))
+			}
+		}
+	})
+}
+
+func TestProgress_EstimatesFromThePreviousRollout(t *testing.T) {
+	mdb := newTestReplicaSet()
+	completionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	mdb.Status.Progress = &mdbv1.ProgressStatus{
+		Operation:        mdbv1.ProgressOperationRollout,
+		Members:          3,
+		MembersCompleted: 3,
+		Percent:          100,
+		StartTime:        metav1.NewTime(completionTime.Add(-15 * time.Minute)),
+		CompletionTime:   &completionTime,
+		MemberDuration:   &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	now := time.Now()
+
+	progress := startedProgress(mdb, now)
+	assert.Equal(t, metav1.NewTime(now), progress.StartTime, "a new rollout is started")
+	assert.Nil(t, progress.CompletionTime)
+	progress.Members = 3
+
+	estimate := estimatedCompletionTime(*progress, now)
+	if assert.NotNil(t, estimate) {
+		assert.Equal(t, now.Add(15*time.Minute).Unix(), estimate.Unix())
+	}
+
+	progress.StartTime = metav1.NewTime(now.Add(-20 * time.Minute))
+	assert.Nil(t, estimatedCompletionTime(*progress, now), "no estimate is given once the rollout takes longer than estimated")
+}
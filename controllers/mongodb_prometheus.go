@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	prometheusExporterName = "mongodb-exporter"
+
+	prometheusWebConfigVolumeName = "prometheus-web-config"
+	prometheusWebConfigMountPath  = "/etc/mongodb-exporter/config/"
+	prometheusWebConfigKey        = "web-config.yml"
+	prometheusTLSVolumeName       = "prometheus-tls"
+	prometheusTLSMountPath        = "/etc/mongodb-exporter/tls/"
+
+	prometheusPortName = "prometheus"
+)
+
+// prometheusWebConfig makes the exporter serve the metrics over HTTPS with the mounted certificate and key.
+const prometheusWebConfig = `tls_server_config:
+  cert_file: ` + prometheusTLSMountPath + `tls.crt
+  key_file: ` + prometheusTLSMountPath + `tls.key
+`
+
+// validatePrometheus checks that the exporters have a metrics user to connect as, whose password does not change
+// while they run.
+func validatePrometheus(mdb mdbv1.MongoDBCommunity) error {
+	if !mdb.IsPrometheusEnabled() {
+		return nil
+	}
+	if !mdb.IsMetricsUserEnabled() {
+		return errors.New("prometheus requires security.authentication.metricsUser to be enabled")
+	}
+	// the exporter reads the password of the metrics user from its environment, which is only updated when the
+	// Pod is restarted.
+	if mdb.Spec.Security.Authentication.MetricsUser.RotationInterval != "" {
+		return errors.New("prometheus can not be combined with security.authentication.metricsUser.rotationInterval")
+	}
+	if tls := mdb.Spec.Prometheus.TLS; tls != nil && tls.SecretRef.Name == "" {
+		return errors.New("prometheus.tls.secretRef is required")
+	}
+	if rules := mdb.Spec.Prometheus.Rules; rules.IsEnabled() {
+		return validatePrometheusRules(*rules)
+	}
+	return nil
+}
+
+// ensurePrometheusWebConfig creates the ConfigMap storing the web configuration of the exporters if they serve
+// the metrics over HTTPS, or deletes the ConfigMap it created otherwise.
+func (r ReplicaSetReconciler) ensurePrometheusWebConfig(mdb mdbv1.MongoDBCommunity) error {
+	nsName := types.NamespacedName{Name: mdb.PrometheusWebConfigMapName(), Namespace: mdb.Namespace}
+	if !mdb.IsPrometheusEnabled() || mdb.Spec.Prometheus.TLS == nil {
+		cm := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: nsName.Name, Namespace: nsName.Namespace}}
+		return r.deleteControlledObject(mdb, &cm)
+	}
+
+	cm := configmap.Builder().
+		SetName(nsName.Name).
+		SetNamespace(nsName.Namespace).
+		SetField(prometheusWebConfigKey, prometheusWebConfig).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentMetrics)).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return configmap.CreateOrUpdate(r.client, cm)
+}
+
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors;prometheusrules,verbs=get;create;update;delete
+
+// ensurePrometheusMonitoring creates the Service exposing the exporters, the ServiceMonitor or PodMonitor scraping
+// them and the PrometheusRule with the alerts of the replica set, or deletes them once they are disabled. The
+// objects of the Prometheus Operator are skipped if its CRDs are not installed.
+func (r ReplicaSetReconciler) ensurePrometheusMonitoring(mdb mdbv1.MongoDBCommunity) error {
+	nsName := types.NamespacedName{Name: mdb.PrometheusServiceName(), Namespace: mdb.Namespace}
+	kind := mdbv1.NoMonitor
+	if mdb.IsPrometheusEnabled() {
+		kind = mdb.Spec.Prometheus.GetMonitorKind()
+	}
+
+	// the monitor of the other kind is deleted before the monitor is created, as both have the same name
+	for _, gvk := range []schema.GroupVersionKind{construct.ServiceMonitorGVK, construct.PodMonitorGVK} {
+		if mdbv1.PrometheusMonitorKind(gvk.Kind) == kind {
+			continue
+		}
+		if err := r.deletePrometheusOperatorObject(mdb, gvk, nsName); err != nil {
+			return err
+		}
+	}
+	if !mdb.IsPrometheusEnabled() || !mdb.Spec.Prometheus.Rules.IsEnabled() {
+		if err := r.deletePrometheusOperatorObject(mdb, construct.PrometheusRuleGVK, types.NamespacedName{Name: mdb.PrometheusRuleName(), Namespace: mdb.Namespace}); err != nil {
+			return err
+		}
+	}
+
+	if !mdb.IsPrometheusEnabled() {
+		svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: nsName.Name, Namespace: nsName.Namespace}}
+		return r.deleteControlledObject(mdb, svc)
+	}
+
+	svc := buildPrometheusService(mdb)
+	existing, err := r.client.GetService(nsName)
+	if apiErrors.IsNotFound(err) {
+		err = r.client.CreateService(svc)
+	} else if err == nil {
+		existing.Spec.Ports = svc.Spec.Ports
+		existing.Spec.Selector = svc.Spec.Selector
+		mergeIPFamilies(&existing, svc)
+		err = r.client.UpdateService(existing)
+	}
+	if err != nil {
+		return errors.Errorf("could not ensure Service %s: %s", nsName.Name, err)
+	}
+
+	if kind != mdbv1.NoMonitor {
+		if err := r.applyPrometheusOperatorObject(buildPrometheusMonitor(mdb)); err != nil {
+			return err
+		}
+	}
+	if mdb.Spec.Prometheus.Rules.IsEnabled() {
+		rule, err := buildPrometheusRule(mdb)
+		if err != nil {
+			return err
+		}
+		return r.applyPrometheusOperatorObject(rule)
+	}
+	return nil
+}
+
+// applyPrometheusOperatorObject creates or updates the given object of the Prometheus Operator. It is skipped if
+// the CRD of its kind is not installed.
+func (r ReplicaSetReconciler) applyPrometheusOperatorObject(obj *unstructured.Unstructured) error {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(obj.GroupVersionKind())
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, current)
+	if meta.IsNoMatchError(err) {
+		r.log.Debugf("The %s CRD of the Prometheus Operator is not installed, not creating %s", obj.GetKind(), obj.GetName())
+		return nil
+	}
+	if apiErrors.IsNotFound(err) {
+		err = r.client.Create(context.TODO(), obj)
+	} else if err == nil {
+		obj.SetResourceVersion(current.GetResourceVersion())
+		err = r.client.Update(context.TODO(), obj)
+	}
+	if err != nil {
+		return errors.Errorf("could not ensure %s %s: %s", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// deletePrometheusOperatorObject deletes the object of the Prometheus Operator with the given kind and name, if
+// it exists and is owned by the resource. It is skipped if the CRD of its kind is not installed.
+func (r ReplicaSetReconciler) deletePrometheusOperatorObject(mdb mdbv1.MongoDBCommunity, gvk schema.GroupVersionKind, nsName types.NamespacedName) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(nsName.Name)
+	obj.SetNamespace(nsName.Namespace)
+	return r.deleteControlledObject(mdb, obj)
+}
+
+// buildPrometheusService returns the headless Service whose endpoints are the exporters of the members.
+func buildPrometheusService(mdb mdbv1.MongoDBCommunity) corev1.Service {
+	svc := service.Builder().
+		SetName(mdb.PrometheusServiceName()).
+		SetNamespace(mdb.Namespace).
+		SetSelector(map[string]string{"app": mdb.ServiceName()}).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentMetrics)).
+		SetServiceType(corev1.ServiceTypeClusterIP).
+		SetClusterIP("None").
+		SetPortName(prometheusPortName).
+		SetPort(int32(mdb.Spec.Prometheus.GetPort())).
+		SetPublishNotReadyAddresses(true).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return withServiceMeshAppProtocol(mdb, withIPFamilies(mdb, svc), metricsAppProtocol)
+}
+
+// buildPrometheusMonitor returns the ServiceMonitor or PodMonitor scraping the exporters. The instance label of the
+// samples is the name of the Pod rather than its address, which changes whenever the Pod is recreated, and the
+// samples are labelled with the name of the replica set.
+func buildPrometheusMonitor(mdb mdbv1.MongoDBCommunity) *unstructured.Unstructured {
+	prometheus := *mdb.Spec.Prometheus
+	endpoint := map[string]interface{}{
+		"port":   prometheusPortName,
+		"path":   "/metrics",
+		"scheme": "http",
+		"relabelings": []interface{}{
+			map[string]interface{}{
+				"sourceLabels": []interface{}{"__meta_kubernetes_pod_name"},
+				"targetLabel":  "instance",
+			},
+			map[string]interface{}{
+				"targetLabel": "replica_set",
+				"replacement": mdb.GetReplicaSetName(),
+			},
+		},
+	}
+	if prometheus.Monitor != nil && prometheus.Monitor.Interval != "" {
+		endpoint["interval"] = prometheus.Monitor.Interval
+	}
+	if prometheus.TLS != nil {
+		endpoint["scheme"] = "https"
+		endpoint["tlsConfig"] = map[string]interface{}{
+			"ca": map[string]interface{}{
+				"secret": map[string]interface{}{"name": prometheus.TLS.SecretRef.Name, "key": "ca.crt"},
+			},
+			"serverName": fmt.Sprintf("%s.%s.svc", mdb.PrometheusServiceName(), mdb.Namespace),
+		}
+	}
+
+	spec := map[string]interface{}{
+		"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{mdb.Namespace}},
+	}
+	gvk := construct.ServiceMonitorGVK
+	selectedComponent := mdbv1.ComponentMetrics
+	endpointsField := "endpoints"
+	if prometheus.GetMonitorKind() == mdbv1.PodMonitor {
+		gvk = construct.PodMonitorGVK
+		selectedComponent = mdbv1.ComponentDatabase
+		endpointsField = "podMetricsEndpoints"
+	}
+	spec["selector"] = map[string]interface{}{
+		"matchLabels": map[string]interface{}{
+			mdbv1.LabelResource:     mdb.Name,
+			mdbv1.LabelAppComponent: selectedComponent,
+		},
+	}
+	spec[endpointsField] = []interface{}{endpoint}
+
+	labels := mdb.SchemaLabels(mdbv1.ComponentMetrics)
+	if prometheus.Monitor != nil {
+		for k, v := range prometheus.Monitor.Labels {
+			labels[k] = v
+		}
+	}
+	monitor := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	monitor.SetGroupVersionKind(gvk)
+	monitor.SetName(mdb.PrometheusServiceName())
+	monitor.SetNamespace(mdb.Namespace)
+	monitor.SetLabels(labels)
+	monitor.SetOwnerReferences(mdb.GetOwnerReferences())
+	return monitor
+}
+
+// prometheusExporterURI returns the connection string the exporter connects to the member of its Pod with. The
+// credentials of the metrics user are passed in the environment of the exporter.
+func prometheusExporterURI(mdb mdbv1.MongoDBCommunity) string {
+	query := url.Values{}
+	query.Set("authSource", "admin")
+	query.Set("directConnection", "true")
+	if mdb.Spec.Security.TLS.Enabled {
+		query.Set("tls", "true")
+		query.Set("tlsCAFile", tlsCAMountPath+tlsCACertName)
+		// the certificate of the member is not issued for localhost, and the connection does not leave the Pod
+		query.Set("tlsAllowInvalidHostnames", "true")
+	}
+	return "mongodb://localhost:27017/?" + query.Encode()
+}
+
+// buildPrometheusExporterPodSpecModification adds the exporter sidecar to the pod template if Prometheus is enabled,
+// and annotates the Pods so that Prometheus discovers them. Otherwise the sidecar is removed from the existing
+// template.
+func buildPrometheusExporterPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	if !mdb.IsPrometheusEnabled() {
+		return podtemplatespec.Apply(
+			removeSidecar(prometheusExporterName, prometheusWebConfigVolumeName, prometheusTLSVolumeName),
+			func(template *corev1.PodTemplateSpec) {
+				for _, annotation := range []string{"prometheus.io/scrape", "prometheus.io/port", "prometheus.io/scheme"} {
+					delete(template.Annotations, annotation)
+				}
+			},
+		)
+	}
+	prometheus := *mdb.Spec.Prometheus
+
+	args := []string{
+		"--mongodb.uri=" + prometheusExporterURI(mdb),
+		fmt.Sprintf("--web.listen-address=:%d", prometheus.GetPort()),
+	}
+	var volumeMounts []corev1.VolumeMount
+	if mdb.Spec.Security.TLS.Enabled {
+		volumeMounts = append(volumeMounts, statefulset.CreateVolumeMount(tlsCAVolumeName, tlsCAMountPath, statefulset.WithReadOnly(true)))
+	}
+	scheme := "http"
+	tlsVolumes := podtemplatespec.NOOP()
+	if prometheus.TLS != nil {
+		scheme = "https"
+		args = append(args, "--web.config="+prometheusWebConfigMountPath+prometheusWebConfigKey)
+		webConfigVolume := statefulset.CreateVolumeFromConfigMap(prometheusWebConfigVolumeName, mdb.PrometheusWebConfigMapName())
+		tlsVolume := statefulset.CreateVolumeFromSecret(prometheusTLSVolumeName, prometheus.TLS.SecretRef.Name)
+		volumeMounts = append(volumeMounts,
+			statefulset.CreateVolumeMount(webConfigVolume.Name, prometheusWebConfigMountPath, statefulset.WithReadOnly(true)),
+			statefulset.CreateVolumeMount(tlsVolume.Name, prometheusTLSMountPath, statefulset.WithReadOnly(true)),
+		)
+		tlsVolumes = podtemplatespec.Apply(podtemplatespec.WithVolume(webConfigVolume), podtemplatespec.WithVolume(tlsVolume))
+	}
+	args = append(args, prometheus.Args...)
+
+	return podtemplatespec.Apply(
+		podtemplatespec.WithAnnotations(map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   strconv.Itoa(prometheus.GetPort()),
+			"prometheus.io/scheme": scheme,
+		}),
+		tlsVolumes,
+		podtemplatespec.WithContainer(prometheusExporterName, container.Apply(
+			container.WithName(prometheusExporterName),
+			container.WithImage(prometheus.GetImage()),
+			container.WithArgs(args),
+			container.WithEnvs(
+				corev1.EnvVar{Name: "MONGODB_USER", Value: mdb.GetMetricsUserName()},
+				corev1.EnvVar{
+					Name: "MONGODB_PASSWORD",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: mdb.MetricsUserPasswordSecretNamespacedName().Name},
+							Key:                  mdbv1.MetricsUserPasswordKey,
+						},
+					},
+				},
+			),
+			container.WithVolumeMounts(volumeMounts),
+			container.WithPorts([]corev1.ContainerPort{{
+				Name:          prometheusPortName,
+				ContainerPort: int32(prometheus.GetPort()),
+			}}),
+		)),
+	)
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	defaultMaxReplicationLag = 30 * time.Second
+	defaultCertificateExpiry = 14 * 24 * time.Hour
+	defaultFailedFor         = 15 * time.Minute
+
+	// prometheusAlertFor is how long the member, replication and disk conditions hold before their alerts fire,
+	// so that restarts and rollouts do not fire them.
+	prometheusAlertFor = 5 * time.Minute
+)
+
+// validatePrometheusRules checks the thresholds of the alerts.
+func validatePrometheusRules(rules mdbv1.PrometheusRules) error {
+	thresholds := []struct{ field, value string }{
+		{"maxReplicationLag", rules.MaxReplicationLag},
+		{"certificateExpiry", rules.CertificateExpiry},
+		{"failedFor", rules.FailedFor},
+	}
+	for _, threshold := range thresholds {
+		if _, err := parsePositiveDuration(threshold.value, time.Second); err != nil {
+			return errors.Errorf("prometheus.rules.%s is invalid: %s", threshold.field, err)
+		}
+	}
+	return nil
+}
+
+// buildPrometheusRule returns the PrometheusRule with the alerts of the replica set. The alerts on the members
+// select the series of the exporters by the namespace and pod labels, which are set by the ServiceMonitor, the
+// PodMonitor and the usual Kubernetes service discovery configurations. The alerts on the phase and the
+// certificates use the metrics of the Operator.
+func buildPrometheusRule(mdb mdbv1.MongoDBCommunity) (*unstructured.Unstructured, error) {
+	rules := *mdb.Spec.Prometheus.Rules
+	maxLag, err := parsePositiveDuration(rules.MaxReplicationLag, defaultMaxReplicationLag)
+	if err != nil {
+		return nil, errors.Errorf("invalid maxReplicationLag: %s", err)
+	}
+	certificateExpiry, err := parsePositiveDuration(rules.CertificateExpiry, defaultCertificateExpiry)
+	if err != nil {
+		return nil, errors.Errorf("invalid certificateExpiry: %s", err)
+	}
+	failedFor, err := parsePositiveDuration(rules.FailedFor, defaultFailedFor)
+	if err != nil {
+		return nil, errors.Errorf("invalid failedFor: %s", err)
+	}
+
+	resource := fmt.Sprintf("%s/%s", mdb.Namespace, mdb.Name)
+	members := fmt.Sprintf(`namespace=%q,pod=~"%s-[0-9]+"`, mdb.Namespace, mdb.StatefulSetName())
+	operator := fmt.Sprintf(`namespace=%q,name=%q`, mdb.Namespace, mdb.Name)
+	volumes := fmt.Sprintf(`namespace=%q,persistentvolumeclaim=~"(%s|%s)-%s-[0-9]+"`, mdb.Namespace, mdb.DataVolumeName(), mdb.LogsVolumeName(), mdb.StatefulSetName())
+
+	alerts := []interface{}{
+		prometheusAlert("MongoDBMemberDown", "critical", prometheusAlertFor,
+			fmt.Sprintf(`(count(mongodb_up{%s} == 1) or vector(0)) < %d`, members, mdb.Spec.Members),
+			fmt.Sprintf("{{ $value }} of the %d members of %s are up.", mdb.Spec.Members, resource)),
+		prometheusAlert("MongoDBReplicationLag", "warning", prometheusAlertFor,
+			fmt.Sprintf(`(max(mongodb_rs_members_optimeDate{%[1]s,member_state="PRIMARY"}) - min(mongodb_rs_members_optimeDate{%[1]s,member_state="SECONDARY"})) / 1000 > %[2]g`, members, maxLag.Seconds()),
+			fmt.Sprintf("A secondary of %s lags {{ $value }} seconds behind the primary.", resource)),
+		prometheusAlert("MongoDBLowDiskSpace", "warning", prometheusAlertFor,
+			fmt.Sprintf(`kubelet_volume_stats_available_bytes{%[1]s} / kubelet_volume_stats_capacity_bytes{%[1]s} * 100 < %[2]d`, volumes, rules.GetMinFreeDiskPercent()),
+			fmt.Sprintf("Volume {{ $labels.persistentvolumeclaim }} of %s has {{ $value | humanize }}%% free space left.", resource)),
+		prometheusAlert("MongoDBCertificateExpiring", "warning", 0,
+			fmt.Sprintf(`min by (certificate) (mongodbcommunity_certificate_expiry_timestamp_seconds{%s}) - time() < %g`, operator, certificateExpiry.Seconds()),
+			fmt.Sprintf("The {{ $labels.certificate }} certificate of %s expires in {{ $value | humanizeDuration }}.", resource)),
+		prometheusAlert("MongoDBResourceFailed", "critical", failedFor,
+			fmt.Sprintf(`mongodbcommunity_phase{%s,phase="Failed"} == 1`, operator),
+			fmt.Sprintf("%s has been in the Failed phase for more than %s, see its status.message.", resource, failedFor)),
+	}
+
+	labels := mdb.SchemaLabels(mdbv1.ComponentMetrics)
+	for k, v := range rules.Labels {
+		labels[k] = v
+	}
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  fmt.Sprintf("mongodbcommunity.%s.%s", mdb.Namespace, mdb.Name),
+					"rules": alerts,
+				},
+			},
+		},
+	}}
+	rule.SetGroupVersionKind(construct.PrometheusRuleGVK)
+	rule.SetName(mdb.PrometheusRuleName())
+	rule.SetNamespace(mdb.Namespace)
+	rule.SetLabels(labels)
+	rule.SetOwnerReferences(mdb.GetOwnerReferences())
+	return rule, nil
+}
+
+// prometheusAlert returns an alerting rule which fires once the expression has held for the given duration.
+func prometheusAlert(name, severity string, forDuration time.Duration, expr, description string) map[string]interface{} {
+	alert := map[string]interface{}{
+		"alert":       name,
+		"expr":        expr,
+		"labels":      map[string]interface{}{"severity": severity},
+		"annotations": map[string]interface{}{"description": description},
+	}
+	if forDuration > 0 {
+		alert["for"] = fmt.Sprintf("%ds", int64(forDuration.Seconds()))
+	}
+	return alert
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// prometheusAlerts returns the alerting rules of the PrometheusRule by alert name.
+func prometheusAlerts(t *testing.T, rule *unstructured.Unstructured) map[string]map[string]interface{} {
+	groups, _, _ := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	alerts := map[string]map[string]interface{}{}
+	if assert.Len(t, groups, 1) {
+		for _, alert := range groups[0].(map[string]interface{})["rules"].([]interface{}) {
+			alert := alert.(map[string]interface{})
+			alerts[alert["alert"].(string)] = alert
+		}
+	}
+	return alerts
+}
+
+func TestBuildPrometheusRule(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.Rules = &mdbv1.PrometheusRules{Enabled: true, Labels: map[string]string{"role": "alert-rules"}, MaxReplicationLag: "1m", MinFreeDiskPercent: 20}
+
+	rule, err := buildPrometheusRule(mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-rs-alerts", rule.GetName())
+	assert.Equal(t, "alert-rules", rule.GetLabels()["role"])
+
+	alerts := prometheusAlerts(t, rule)
+	assert.Len(t, alerts, 5)
+	assert.Equal(t, `(count(mongodb_up{namespace="my-ns",pod=~"my-rs-[0-9]+"} == 1) or vector(0)) < 3`, alerts["MongoDBMemberDown"]["expr"])
+	assert.Equal(t, "300s", alerts["MongoDBMemberDown"]["for"])
+	assert.Contains(t, alerts["MongoDBReplicationLag"]["expr"], "/ 1000 > 60")
+	assert.Equal(t, `kubelet_volume_stats_available_bytes{namespace="my-ns",persistentvolumeclaim=~"(data-volume|logs-volume)-my-rs-[0-9]+"} / kubelet_volume_stats_capacity_bytes{namespace="my-ns",persistentvolumeclaim=~"(data-volume|logs-volume)-my-rs-[0-9]+"} * 100 < 20`, alerts["MongoDBLowDiskSpace"]["expr"])
+	assert.Equal(t, `min by (certificate) (mongodbcommunity_certificate_expiry_timestamp_seconds{namespace="my-ns",name="my-rs"}) - time() < 1.2096e+06`, alerts["MongoDBCertificateExpiring"]["expr"])
+	assert.NotContains(t, alerts["MongoDBCertificateExpiring"], "for")
+	assert.Equal(t, `mongodbcommunity_phase{namespace="my-ns",name="my-rs",phase="Failed"} == 1`, alerts["MongoDBResourceFailed"]["expr"])
+	assert.Equal(t, "900s", alerts["MongoDBResourceFailed"]["for"])
+	assert.Equal(t, map[string]interface{}{"severity": "critical"}, alerts["MongoDBResourceFailed"]["labels"])
+}
+
+func TestPrometheus_RuleIsCreatedAndDeleted(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.Rules = &mdbv1.PrometheusRules{Enabled: true}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(construct.PrometheusRuleGVK)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PrometheusRuleName(), Namespace: mdb.Namespace}, rule))
+	assert.Len(t, rule.GetOwnerReferences(), 1)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Prometheus.Rules.Enabled = false
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.Error(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PrometheusRuleName(), Namespace: mdb.Namespace}, rule))
+}
+
+func TestValidatePrometheus_Rules(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.Rules = &mdbv1.PrometheusRules{Enabled: true, FailedFor: "1h"}
+	assert.NoError(t, validatePrometheus(mdb))
+
+	mdb.Spec.Prometheus.Rules.FailedFor = "an hour"
+	assert.EqualError(t, validatePrometheus(mdb), `prometheus.rules.failedFor is invalid: time: invalid duration "an hour"`)
+
+	mdb.Spec.Prometheus.Rules.FailedFor = ""
+	mdb.Spec.Prometheus.Rules.CertificateExpiry = "-1h"
+	assert.EqualError(t, validatePrometheus(mdb), `prometheus.rules.certificateExpiry is invalid: "-1h" must be positive`)
+}
@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+)
+
+func newTestReplicaSetWithPrometheus() mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSetWithMetricsUser("")
+	mdb.Spec.Prometheus = &mdbv1.Prometheus{Enabled: true}
+	return mdb
+}
+
+func TestPrometheus_ExporterIsDeployedNextToEachMember(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	exporter := container.GetByName(prometheusExporterName, sts.Spec.Template.Spec.Containers)
+	if assert.NotNil(t, exporter) {
+		assert.Equal(t, "percona/mongodb_exporter:0.40.0", exporter.Image)
+		assert.Equal(t, []string{"--mongodb.uri=mongodb://localhost:27017/?authSource=admin&directConnection=true", "--web.listen-address=:9216"}, exporter.Args)
+		assert.Contains(t, exporter.Env, corev1.EnvVar{Name: "MONGODB_USER", Value: "metrics"})
+		assert.Contains(t, exporter.Env, corev1.EnvVar{Name: "MONGODB_PASSWORD", ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "my-rs-metrics-user-password"}, Key: "password"},
+		}})
+		assert.Equal(t, []corev1.ContainerPort{{Name: "prometheus", ContainerPort: 9216}}, exporter.Ports)
+	}
+	assert.Equal(t, "true", sts.Spec.Template.Annotations["prometheus.io/scrape"])
+	assert.Equal(t, "9216", sts.Spec.Template.Annotations["prometheus.io/port"])
+	assert.Equal(t, "http", sts.Spec.Template.Annotations["prometheus.io/scheme"])
+
+	t.Run("The exporter is removed once Prometheus is disabled", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Prometheus.Enabled = false
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+		assert.Nil(t, container.GetByName(prometheusExporterName, sts.Spec.Template.Spec.Containers))
+		assert.NotContains(t, sts.Spec.Template.Annotations, "prometheus.io/scrape")
+	})
+}
+
+func TestPrometheus_ExporterServesTheMetricsOverHTTPS(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.Port = 9300
+	mdb.Spec.Prometheus.TLS = &mdbv1.PrometheusTLS{SecretRef: mdbv1.LocalObjectReference{Name: "exporter-cert"}}
+	mdb.Spec.Prometheus.Args = []string{"--collect-all"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	exporter := container.GetByName(prometheusExporterName, sts.Spec.Template.Spec.Containers)
+	if assert.NotNil(t, exporter) {
+		assert.Equal(t, []string{
+			"--mongodb.uri=mongodb://localhost:27017/?authSource=admin&directConnection=true",
+			"--web.listen-address=:9300",
+			"--web.config=/etc/mongodb-exporter/config/web-config.yml",
+			"--collect-all",
+		}, exporter.Args)
+		assert.Contains(t, exporter.VolumeMounts, corev1.VolumeMount{Name: prometheusTLSVolumeName, MountPath: prometheusTLSMountPath, ReadOnly: true})
+	}
+	assert.Equal(t, "https", sts.Spec.Template.Annotations["prometheus.io/scheme"])
+
+	cm, err := mgr.Client.GetConfigMap(types.NamespacedName{Name: mdb.PrometheusWebConfigMapName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, prometheusWebConfig, cm.Data[prometheusWebConfigKey])
+	assert.Equal(t, mdbv1.ComponentMetrics, cm.Labels[mdbv1.LabelAppComponent])
+
+	t.Run("The web configuration is deleted once HTTPS is disabled", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Prometheus.TLS = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		_, err = mgr.Client.GetConfigMap(types.NamespacedName{Name: mdb.PrometheusWebConfigMapName(), Namespace: mdb.Namespace})
+		assert.True(t, apiErrors.IsNotFound(err))
+	})
+}
+
+func TestPrometheusExporterURI_WithTLS(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Security.TLS.Enabled = true
+	assert.Equal(t, "mongodb://localhost:27017/?authSource=admin&directConnection=true&tls=true&tlsAllowInvalidHostnames=true&tlsCAFile=%2Fvar%2Flib%2Ftls%2Fca%2Fca.crt", prometheusExporterURI(mdb))
+}
+
+func TestValidatePrometheus(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	assert.NoError(t, validatePrometheus(mdb))
+
+	mdb.Spec.Security.Authentication.MetricsUser.RotationInterval = "720h"
+	assert.EqualError(t, validatePrometheus(mdb), "prometheus can not be combined with security.authentication.metricsUser.rotationInterval")
+
+	mdb.Spec.Security.Authentication.MetricsUser = nil
+	assert.EqualError(t, validatePrometheus(mdb), "prometheus requires security.authentication.metricsUser to be enabled")
+}
+
+func getPrometheusMonitor(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(gvk)
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PrometheusServiceName(), Namespace: mdb.Namespace}, monitor)
+	if apiErrors.IsNotFound(err) {
+		return nil
+	}
+	assert.NoError(t, err)
+	return monitor
+}
+
+func TestPrometheus_ServiceMonitorScrapesTheExporters(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.Monitor = &mdbv1.PrometheusMonitor{Labels: map[string]string{"release": "prometheus"}, Interval: "30s"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	svc, err := mgr.Client.GetService(types.NamespacedName{Name: "my-rs-prometheus", Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.ServicePort{{Name: "prometheus", Port: 9216}}, svc.Spec.Ports)
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, svc.Spec.Selector)
+	assert.Equal(t, mdbv1.ComponentMetrics, svc.Labels[mdbv1.LabelAppComponent])
+
+	monitor := getPrometheusMonitor(t, mgr, mdb, construct.ServiceMonitorGVK)
+	if assert.NotNil(t, monitor) {
+		assert.Equal(t, "ServiceMonitor", monitor.GetKind())
+		assert.Equal(t, "prometheus", monitor.GetLabels()["release"])
+		assert.Len(t, monitor.GetOwnerReferences(), 1)
+		selector, _, _ := unstructured.NestedStringMap(monitor.Object, "spec", "selector", "matchLabels")
+		assert.Equal(t, map[string]string{mdbv1.LabelResource: mdb.Name, mdbv1.LabelAppComponent: mdbv1.ComponentMetrics}, selector)
+		endpoints, _, _ := unstructured.NestedSlice(monitor.Object, "spec", "endpoints")
+		if assert.Len(t, endpoints, 1) {
+			endpoint := endpoints[0].(map[string]interface{})
+			assert.Equal(t, "prometheus", endpoint["port"])
+			assert.Equal(t, "http", endpoint["scheme"])
+			assert.Equal(t, "30s", endpoint["interval"])
+			assert.Contains(t, endpoint["relabelings"], map[string]interface{}{"targetLabel": "replica_set", "replacement": "my-rs"})
+			assert.NotContains(t, endpoint, "tlsConfig")
+		}
+	}
+
+	t.Run("A PodMonitor replaces the ServiceMonitor", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Prometheus.Monitor.Kind = mdbv1.PodMonitor
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		monitor := getPrometheusMonitor(t, mgr, mdb, construct.PodMonitorGVK)
+		if assert.NotNil(t, monitor) {
+			assert.Equal(t, "PodMonitor", monitor.GetKind())
+			selector, _, _ := unstructured.NestedStringMap(monitor.Object, "spec", "selector", "matchLabels")
+			assert.Equal(t, mdbv1.ComponentDatabase, selector[mdbv1.LabelAppComponent])
+			endpoints, _, _ := unstructured.NestedSlice(monitor.Object, "spec", "podMetricsEndpoints")
+			assert.Len(t, endpoints, 1)
+		}
+	})
+
+	t.Run("The Service and the monitor are deleted once Prometheus is disabled", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Prometheus.Enabled = false
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		makeStatefulSetReady(t, mgr.GetClient(), mdb)
+
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+		assert.Nil(t, getPrometheusMonitor(t, mgr, mdb, construct.PodMonitorGVK))
+		_, err = mgr.Client.GetService(types.NamespacedName{Name: "my-rs-prometheus", Namespace: mdb.Namespace})
+		assert.True(t, apiErrors.IsNotFound(err))
+	})
+}
+
+func TestPrometheus_MonitorsOfUsersAreKept(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.Enabled = false
+	mgr := client.NewManager(&mdb)
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(construct.ServiceMonitorGVK)
+	monitor.SetName(mdb.PrometheusServiceName())
+	monitor.SetNamespace(mdb.Namespace)
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), monitor))
+	r := NewReconciler(mgr)
+
+	assert.NoError(t, r.ensurePrometheusMonitoring(mdb))
+
+	assert.NotNil(t, getPrometheusMonitor(t, mgr, mdb, construct.ServiceMonitorGVK), "monitors not created by the operator should be kept")
+}
+
+func TestBuildPrometheusMonitor_WithTLS(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	mdb.Spec.Prometheus.TLS = &mdbv1.PrometheusTLS{SecretRef: mdbv1.LocalObjectReference{Name: "exporter-cert"}}
+
+	endpoints, _, _ := unstructured.NestedSlice(buildPrometheusMonitor(mdb).Object, "spec", "endpoints")
+	if assert.Len(t, endpoints, 1) {
+		endpoint := endpoints[0].(map[string]interface{})
+		assert.Equal(t, "https", endpoint["scheme"])
+		assert.Equal(t, map[string]interface{}{
+			"ca":         map[string]interface{}{"secret": map[string]interface{}{"name": "exporter-cert", "key": "ca.crt"}},
+			"serverName": "my-rs-prometheus.my-ns.svc",
+		}, endpoint["tlsConfig"])
+	}
+}
+
+// noMonitorCRDsClient behaves like a cluster without the CRDs of the Prometheus Operator.
+type noMonitorCRDsClient struct {
+	k8sClient.Client
+}
+
+func (c noMonitorCRDsClient) noMatch(obj k8sClient.Object) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Group == construct.ServiceMonitorGVK.Group {
+		return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+	}
+	return nil
+}
+
+func (c noMonitorCRDsClient) Get(ctx context.Context, key k8sClient.ObjectKey, obj k8sClient.Object) error {
+	if err := c.noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c noMonitorCRDsClient) Create(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.CreateOption) error {
+	if err := c.noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c noMonitorCRDsClient) Delete(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.DeleteOption) error {
+	if err := c.noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func TestPrometheus_MonitorIsSkippedWithoutThePrometheusOperator(t *testing.T) {
+	mdb := newTestReplicaSetWithPrometheus()
+	c := client.NewMockedClient()
+	assert.NoError(t, c.Create(context.TODO(), &mdb))
+	mgr := client.NewManagerWithClient(noMonitorCRDsClient{Client: c})
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	_, err = mgr.Client.GetService(types.NamespacedName{Name: "my-rs-prometheus", Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+}
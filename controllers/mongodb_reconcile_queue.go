@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// MaxConcurrentReconcilesEnv is the number of resources which are reconciled at the same time, defaults to 1.
+const MaxConcurrentReconcilesEnv = "MAX_CONCURRENT_RECONCILES"
+
+var (
+	// reconcileQueueWait exposes how long resources waited in the queue before their reconciliation started.
+	reconcileQueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongodbcommunity_reconcile_queue_wait_seconds",
+		Help:    "How long a MongoDBCommunity resource waited in the queue of the operator before its reconciliation started.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{metricLabelNamespace, metricLabelName})
+
+	// lastReconcileTimestamp exposes when resources were last reconciled into the Running phase.
+	lastReconcileTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodbcommunity_last_reconcile_timestamp_seconds",
+		Help: "The time at which the reconciliation of a MongoDBCommunity resource last completed in the Running phase, in seconds since the epoch.",
+	}, []string{metricLabelNamespace, metricLabelName})
+
+	// resourcePhase exposes the phase of the resources, with one series per phase which is 1 for the current phase.
+	resourcePhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodbcommunity_phase",
+		Help: "Whether a MongoDBCommunity resource is in the given phase (1) or not (0).",
+	}, []string{metricLabelNamespace, metricLabelName, "phase"})
+
+	// reconcileQueueDepth exposes how many resources are waiting to be reconciled.
+	reconcileQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "mongodbcommunity_reconcile_queue_depth",
+		Help: "The number of MongoDBCommunity resources waiting to be reconciled, including the resources scheduled to be reconciled again later.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileQueueWait, lastReconcileTimestamp, resourcePhase, reconcileQueueDepth)
+}
+
+func maxConcurrentReconcilesFromEnv() (int, error) {
+	value := os.Getenv(MaxConcurrentReconcilesEnv)
+	if value == "" {
+		return 1, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, errors.Errorf("%s must be a positive integer, got %q", MaxConcurrentReconcilesEnv, value)
+	}
+	return limit, nil
+}
+
+// deleteReconcileMetrics removes the reconciliation metrics of the given resource.
+func deleteReconcileMetrics(nsName types.NamespacedName) {
+	reconcileQueueWait.DeleteLabelValues(nsName.Namespace, nsName.Name)
+	lastReconcileTimestamp.DeleteLabelValues(nsName.Namespace, nsName.Name)
+	nonRunningRatio.DeleteLabelValues(nsName.Namespace, nsName.Name)
+	for _, phase := range phases {
+		resourcePhase.DeleteLabelValues(nsName.Namespace, nsName.Name, string(phase))
+	}
+}
+
+var phases = []mdbv1.Phase{mdbv1.Running, mdbv1.Pending, mdbv1.Failed}
+
+// observePhase records the phase of the resource in the phase metric, and the time it spent outside the
+// Running phase in the error budget metric.
+func observePhase(mdb *mdbv1.MongoDBCommunity) {
+	observeErrorBudget(mdb)
+	for _, phase := range phases {
+		value := 0.0
+		if mdb.Status.Phase == phase {
+			value = 1
+		}
+		resourcePhase.WithLabelValues(mdb.Namespace, mdb.Name, string(phase)).Set(value)
+	}
+}
+
+// reconcileQueue records when resources were added to the work queue of the controller, to measure how long
+// they waited for a worker. A resource which is added again before it is reconciled keeps the earliest time,
+// as the work queue only holds it once.
+type reconcileQueue struct {
+	mu       sync.Mutex
+	enqueued map[types.NamespacedName]time.Time
+}
+
+func newReconcileQueue() *reconcileQueue {
+	return &reconcileQueue{enqueued: map[types.NamespacedName]time.Time{}}
+}
+
+// add records that the resource is due to be reconciled at the given time.
+func (q *reconcileQueue) add(nsName types.NamespacedName, at time.Time) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if existing, ok := q.enqueued[nsName]; !ok || at.Before(existing) {
+		q.enqueued[nsName] = at
+	}
+	reconcileQueueDepth.Set(float64(len(q.enqueued)))
+}
+
+// take removes the resource from the queue when its reconciliation starts at the given time, and returns how
+// long it waited. The wait is zero if it is not known when the resource was added.
+func (q *reconcileQueue) take(nsName types.NamespacedName, now time.Time) time.Duration {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	at, ok := q.enqueued[nsName]
+	delete(q.enqueued, nsName)
+	reconcileQueueDepth.Set(float64(len(q.enqueued)))
+	if !ok || now.Before(at) {
+		return 0
+	}
+	return now.Sub(at)
+}
+
+// requeue records when the resource is reconciled again, according to the result of its reconciliation.
+func (q *reconcileQueue) requeue(nsName types.NamespacedName, res reconcile.Result, err error, now time.Time) {
+	switch {
+	case res.RequeueAfter > 0 && err == nil:
+		q.add(nsName, now.Add(res.RequeueAfter))
+	case res.Requeue || err != nil:
+		q.add(nsName, now)
+	}
+}
+
+// predicate returns a predicate which records the resources of the events accepted by the preceding
+// predicates, and must therefore be the last predicate of a watch.
+func (q *reconcileQueue) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			q.add(types.NamespacedName{Name: e.Object.GetName(), Namespace: e.Object.GetNamespace()}, time.Now())
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			q.add(types.NamespacedName{Name: e.ObjectNew.GetName(), Namespace: e.ObjectNew.GetNamespace()}, time.Now())
+			return true
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			q.add(types.NamespacedName{Name: e.Object.GetName(), Namespace: e.Object.GetNamespace()}, time.Now())
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			q.add(types.NamespacedName{Name: e.Object.GetName(), Namespace: e.Object.GetNamespace()}, time.Now())
+			return true
+		},
+	}
+}
+
+// handler returns an event handler which records the resources the given handler adds to the work queue.
+func (q *reconcileQueue) handler(h handler.EventHandler) handler.EventHandler {
+	return queueRecordingHandler{handler: h, queue: q}
+}
+
+type queueRecordingHandler struct {
+	handler handler.EventHandler
+	queue   *reconcileQueue
+}
+
+func (h queueRecordingHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Create(e, recordingWorkQueue{RateLimitingInterface: q, queue: h.queue})
+}
+
+func (h queueRecordingHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Update(e, recordingWorkQueue{RateLimitingInterface: q, queue: h.queue})
+}
+
+func (h queueRecordingHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Delete(e, recordingWorkQueue{RateLimitingInterface: q, queue: h.queue})
+}
+
+func (h queueRecordingHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Generic(e, recordingWorkQueue{RateLimitingInterface: q, queue: h.queue})
+}
+
+// recordingWorkQueue records the requests added to the wrapped work queue.
+type recordingWorkQueue struct {
+	workqueue.RateLimitingInterface
+	queue *reconcileQueue
+}
+
+func (w recordingWorkQueue) record(item interface{}, at time.Time) {
+	if req, ok := item.(reconcile.Request); ok {
+		w.queue.add(req.NamespacedName, at)
+	}
+}
+
+func (w recordingWorkQueue) Add(item interface{}) {
+	w.record(item, time.Now())
+	w.RateLimitingInterface.Add(item)
+}
+
+func (w recordingWorkQueue) AddAfter(item interface{}, duration time.Duration) {
+	w.record(item, time.Now().Add(duration))
+	w.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (w recordingWorkQueue) AddRateLimited(item interface{}) {
+	w.record(item, time.Now())
+	w.RateLimitingInterface.AddRateLimited(item)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestMaxConcurrentReconcilesFromEnv(t *testing.T) {
+	defer os.Unsetenv(MaxConcurrentReconcilesEnv)
+
+	limit, err := maxConcurrentReconcilesFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, limit)
+
+	os.Setenv(MaxConcurrentReconcilesEnv, "4")
+	limit, err = maxConcurrentReconcilesFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, limit)
+
+	os.Setenv(MaxConcurrentReconcilesEnv, "0")
+	assert.Error(t, ValidateEnv())
+}
+
+func TestReconcileQueue(t *testing.T) {
+	nsName := types.NamespacedName{Name: "my-rs", Namespace: "my-ns"}
+	now := time.Now()
+
+	t.Run("The earliest time a resource was added is kept", func(t *testing.T) {
+		q := newReconcileQueue()
+		q.add(nsName, now.Add(-time.Minute))
+		q.add(nsName, now.Add(-time.Second))
+		assert.Equal(t, float64(1), testutil.ToFloat64(reconcileQueueDepth))
+		assert.Equal(t, time.Minute, q.take(nsName, now))
+		assert.Equal(t, float64(0), testutil.ToFloat64(reconcileQueueDepth))
+		assert.Equal(t, time.Duration(0), q.take(nsName, now), "the wait of a resource which was not added is unknown")
+	})
+
+	t.Run("A resource requeued after a delay waits from the end of the delay", func(t *testing.T) {
+		q := newReconcileQueue()
+		q.requeue(nsName, reconcile.Result{RequeueAfter: 10 * time.Second}, nil, now)
+		assert.Equal(t, time.Duration(0), q.take(nsName, now.Add(5*time.Second)))
+
+		q.requeue(nsName, reconcile.Result{RequeueAfter: 10 * time.Second}, nil, now)
+		assert.Equal(t, 5*time.Second, q.take(nsName, now.Add(15*time.Second)))
+	})
+
+	t.Run("A failed resource is requeued immediately", func(t *testing.T) {
+		q := newReconcileQueue()
+		q.requeue(nsName, reconcile.Result{}, errors.New("failed"), now)
+		assert.Equal(t, time.Second, q.take(nsName, now.Add(time.Second)))
+
+		q.requeue(nsName, reconcile.Result{}, nil, now)
+		assert.Equal(t, time.Duration(0), q.take(nsName, now.Add(time.Second)), "a successful resource is not requeued")
+	})
+
+	t.Run("Requests added by event handlers are recorded", func(t *testing.T) {
+		q := newReconcileQueue()
+		wq := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer wq.ShutDown()
+
+		mdb := newTestReplicaSet()
+		q.handler(&handler.EnqueueRequestForObject{}).Create(event.CreateEvent{Object: &mdb}, wq)
+		assert.Equal(t, 1, wq.Len())
+		assert.True(t, q.take(mdb.NamespacedName(), time.Now().Add(time.Second)) > 0)
+	})
+}
+
+func TestReconcile_ReportsLastReconcile(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.queue.add(mdb.NamespacedName(), time.Now().Add(-time.Minute))
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotNil(t, mdb.Status.LastReconcile)
+	assert.True(t, mdb.Status.LastReconcile.QueueWait.Duration >= time.Minute)
+	assert.Equal(t, float64(mdb.Status.LastReconcile.Time.Unix()), testutil.ToFloat64(lastReconcileTimestamp.WithLabelValues(mdb.Namespace, mdb.Name)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(resourcePhase.WithLabelValues(mdb.Namespace, mdb.Name, "Running")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(resourcePhase.WithLabelValues(mdb.Namespace, mdb.Name, "Failed")))
+
+	t.Run("The metrics of a deleted resource are removed", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &mdb))
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.False(t, lastReconcileTimestamp.DeleteLabelValues(mdb.Namespace, mdb.Name), "the metric has already been removed")
+		assert.False(t, resourcePhase.DeleteLabelValues(mdb.Namespace, mdb.Name, "Running"), "the metric has already been removed")
+	})
+}
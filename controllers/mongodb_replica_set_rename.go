@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// reconcileReplicaSetRename moves a change of the replica set name through its phases. The StatefulSet is torn
+// down, the replica set name stored on each member is rewritten and the StatefulSet is recreated with the new name.
+// The returned boolean is true while the StatefulSet must not be deployed.
+func (r *ReplicaSetReconciler) reconcileReplicaSetRename(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.IsRenamingReplicaSet() {
+		started, err := r.startReplicaSetRename(mdb)
+		if err != nil || !started {
+			return false, err
+		}
+	}
+
+	rename := *mdb.Status.ReplicaSetRename
+	if rename.To != mdb.GetReplicaSetName() {
+		return true, errors.Errorf("the replica set is being renamed from %q to %q, its name can't be changed until the rename has completed", rename.From, rename.To)
+	}
+
+	switch rename.Phase {
+	case mdbv1.ReplicaSetRenameTearingDown:
+		removed, err := r.tearDownStatefulSet(*mdb, rename.Members)
+		if err != nil || !removed {
+			return true, err
+		}
+		r.log.Infof("StatefulSet has been removed, rewriting the replica set name of %d members", rename.Members)
+		rename.Phase = mdbv1.ReplicaSetRenameRewritingMetadata
+		if err := r.updateReplicaSetRenameStatus(mdb, rename); err != nil {
+			return true, err
+		}
+		fallthrough
+	case mdbv1.ReplicaSetRenameRewritingMetadata:
+		rewritten, err := r.rewriteReplicaSetName(*mdb, rename)
+		if err != nil || !rewritten {
+			return true, err
+		}
+		r.log.Infof("Replica set name has been rewritten on all members, recreating the StatefulSet")
+		rename.Phase = mdbv1.ReplicaSetRenameReprovisioning
+		return false, r.updateReplicaSetRenameStatus(mdb, rename)
+	}
+	return false, nil
+}
+
+// startReplicaSetRename moves the rename to the TearingDown phase if the replica set name differs from the one
+// the resource was last successfully reconciled with. Changes of the name which have not been opted into
+// are rejected by validateUpdate.
+func (r *ReplicaSetReconciler) startReplicaSetRename(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	prevSpec, err := lastSuccessfulSpec(*mdb)
+	if err != nil || prevSpec == nil {
+		return false, err
+	}
+
+	from := mdbv1.MongoDBCommunity{ObjectMeta: mdb.ObjectMeta, Spec: *prevSpec}.GetReplicaSetName()
+	to := mdb.GetReplicaSetName()
+	if from == to {
+		return false, nil
+	}
+	if last := mdb.Status.ReplicaSetRename; last != nil && last.To == to {
+		// the rename has completed, but the resource has not been reconciled successfully since.
+		return false, nil
+	}
+
+	members := mdb.Status.CurrentStatefulSetReplicas
+	if members == 0 {
+		members = mdb.Spec.Members
+	}
+
+	r.log.Infof("Renaming the replica set from %s to %s, tearing down the StatefulSet", from, to)
+	return true, r.updateReplicaSetRenameStatus(mdb, mdbv1.ReplicaSetRenameStatus{
+		From:    from,
+		To:      to,
+		Members: members,
+		Phase:   mdbv1.ReplicaSetRenameTearingDown,
+	})
+}
+
+// tearDownStatefulSet deletes the StatefulSet, which retains the PersistentVolumeClaims of all members.
+// The returned boolean is true once all pods have been removed.
+func (r *ReplicaSetReconciler) tearDownStatefulSet(mdb mdbv1.MongoDBCommunity, members int) (bool, error) {
+	if err := r.client.DeleteStatefulSet(mdb.StatefulSetNamespacedName()); err != nil && !apiErrors.IsNotFound(err) {
+		return false, errors.Errorf("could not delete StatefulSet: %s", err)
+	}
+
+	for i := 0; i < members; i++ {
+		podName := types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace}
+		if _, err := r.client.GetPod(podName); err == nil {
+			r.log.Debugf("Waiting for pod %s to be removed", podName)
+			return false, nil
+		} else if !apiErrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// rewriteReplicaSetName runs a Job for each member which rewrites the replica set name stored in its local database.
+// The returned boolean is true once all Jobs have succeeded, at which point they are removed.
+func (r *ReplicaSetReconciler) rewriteReplicaSetName(mdb mdbv1.MongoDBCommunity, rename mdbv1.ReplicaSetRenameStatus) (bool, error) {
+	allSucceeded := true
+	for i := 0; i < rename.Members; i++ {
+		job := batchv1.Job{}
+		jobName := types.NamespacedName{Name: construct.ReplicaSetRenameJobName(&mdb, i), Namespace: mdb.Namespace}
+		err := r.client.Get(context.TODO(), jobName, &job)
+		if apiErrors.IsNotFound(err) {
+			job = construct.BuildReplicaSetRenameJob(&mdb, i, rename.From, rename.To)
+			job.OwnerReferences = mdb.GetOwnerReferences()
+			setJobMetadata(mdb, &job)
+			if err := r.client.Create(context.TODO(), &job); err != nil {
+				return false, errors.Errorf("could not create Job %s: %s", jobName, err)
+			}
+			allSucceeded = false
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if isJobFailed(job) {
+			return false, errors.Errorf("could not rewrite the replica set name of member %d, see the logs of Job %s", i, jobName)
+		}
+		if job.Status.Succeeded == 0 {
+			allSucceeded = false
+		}
+	}
+	if !allSucceeded {
+		return false, nil
+	}
+
+	for i := 0; i < rename.Members; i++ {
+		job := batchv1.Job{}
+		job.Name = construct.ReplicaSetRenameJobName(&mdb, i)
+		job.Namespace = mdb.Namespace
+		if err := r.client.Delete(context.TODO(), &job, k8sClient.PropagationPolicy("Background")); err != nil && !apiErrors.IsNotFound(err) {
+			return false, errors.Errorf("could not delete Job %s: %s", job.Name, err)
+		}
+	}
+	return true, nil
+}
+
+func isJobFailed(job batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ReplicaSetReconciler) updateReplicaSetRenameStatus(mdb *mdbv1.MongoDBCommunity, rename mdbv1.ReplicaSetRenameStatus) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withReplicaSetRename(rename))
+	return err
+}
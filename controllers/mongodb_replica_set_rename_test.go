@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReplicaSetRename_IsRejectedByDefault(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	t.Run("Explicitly setting the default name is not a change", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.ReplicaSetName = mdb.Name
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+	})
+
+	t.Run("Changing the name is rejected", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.ReplicaSetName = "renamed-rs"
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "replicaSetNameChangePolicy")
+		assert.Nil(t, mdb.Status.ReplicaSetRename)
+
+		_, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err, "the StatefulSet must not be removed")
+	})
+}
+
+func TestReplicaSetRename_RecreatesRetainingData(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.ReplicaSetName = "renamed-rs"
+	mdb.Spec.ReplicaSetNameChangePolicy = mdbv1.ReplicaSetNameChangeRecreateRetainingData
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, &mdbv1.ReplicaSetRenameStatus{
+		From:    "my-rs",
+		To:      "renamed-rs",
+		Members: 3,
+		Phase:   mdbv1.ReplicaSetRenameRewritingMetadata,
+	}, mdb.Status.ReplicaSetRename)
+
+	_, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.Error(t, err, "the StatefulSet must be removed")
+
+	for i := 0; i < 3; i++ {
+		job := batchv1.Job{}
+		err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: construct.ReplicaSetRenameJobName(&mdb, i), Namespace: mdb.Namespace}, &job)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("data-volume-my-rs-%d", i), job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+		assert.Contains(t, job.Spec.Template.Spec.Containers[0].Command[2], `config._id = "renamed-rs";`)
+
+		job.Status.Succeeded = 1
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+	}
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, mdbv1.ReplicaSetRenameCompleted, mdb.Status.ReplicaSetRename.Phase)
+
+	_, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err, "the StatefulSet must be recreated")
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed-rs", ac.ReplicaSets[0].Id)
+	for _, p := range ac.Processes {
+		assert.Equal(t, "renamed-rs", p.Args26.Get("replication.replSetName").Data())
+	}
+
+	job := batchv1.Job{}
+	err = mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: construct.ReplicaSetRenameJobName(&mdb, 0), Namespace: mdb.Namespace}, &job)
+	assert.Error(t, err, "the Jobs must be removed")
+
+	t.Run("The resource is reconciled normally afterwards", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+		assert.Equal(t, mdbv1.ReplicaSetRenameCompleted, mdb.Status.ReplicaSetRename.Phase)
+	})
+}
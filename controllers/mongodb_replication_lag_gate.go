@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// replicationLagGateConditionType is the condition type of the readiness gate, which the operator sets
+	// on the Pods of the members.
+	replicationLagGateConditionType corev1.PodConditionType = "mongodbcommunity.mongodb.com/replication-lag"
+
+	defaultReplicationLagGateMaxLag = 10 * time.Second
+
+	// replicationLagGateInterval is how often the replication lag of the members is checked while the gate
+	// is enabled.
+	replicationLagGateInterval = 15 * time.Second
+
+	replicationLagCaughtUpReason = "CaughtUp"
+	replicationLagLaggingReason  = "Lagging"
+	replicationLagUnknownReason  = "LagUnknown"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=update
+
+// validateReplicationLagGate checks that the maximum replication lag of the readiness gate is a valid duration.
+func validateReplicationLagGate(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.ReplicationLagGate == nil {
+		return nil
+	}
+	if _, err := parsePositiveDuration(mdb.Spec.ReplicationLagGate.MaxReplicationLag, defaultReplicationLagGateMaxLag); err != nil {
+		return errors.Errorf("invalid replicationLagGate.maxReplicationLag: %s", err)
+	}
+	return nil
+}
+
+// buildReplicationLagGatePodSpecModification adds the readiness gate to the Pods of the members, or removes it
+// if it is not enabled. Readiness gates configured in the StatefulSet override are kept.
+func buildReplicationLagGatePodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	return func(template *corev1.PodTemplateSpec) {
+		var gates []corev1.PodReadinessGate
+		for _, gate := range template.Spec.ReadinessGates {
+			if gate.ConditionType != replicationLagGateConditionType {
+				gates = append(gates, gate)
+			}
+		}
+		if mdb.Spec.ReplicationLagGate.IsEnabled() {
+			gates = append(gates, corev1.PodReadinessGate{ConditionType: replicationLagGateConditionType})
+		}
+		template.Spec.ReadinessGates = gates
+	}
+}
+
+// replicationLagGate returns the condition of the readiness gate of the Pod of a member with the given replication
+// lag, which is nil if the member is not a secondary of a replica set with a primary. known is false if the replica
+// set status could not be read.
+//
+// Only secondaries which are more than the maximum replication lag behind the primary are marked unready. The
+// gate of every other member is set, as the readiness probe already reports members which are not up: a member
+// being initialized would otherwise never become ready. If the replica set status can not be read, Pods which
+// have the condition keep it, and Pods which do not have it yet get it set.
+func replicationLagGate(mdb mdbv1.MongoDBCommunity, pod corev1.Pod, lag *time.Duration, known bool) corev1.PodCondition {
+	if existing := podCondition(pod, replicationLagGateConditionType); existing != nil && !known {
+		return *existing
+	}
+	condition := corev1.PodCondition{
+		Type:    replicationLagGateConditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  replicationLagUnknownReason,
+		Message: "The replication lag of the member is not known",
+	}
+	if !known {
+		return condition
+	}
+	if lag == nil {
+		condition.Message = "The member is not a secondary of a replica set with a primary"
+		return condition
+	}
+
+	maxLag, _ := parsePositiveDuration(mdb.Spec.ReplicationLagGate.MaxReplicationLag, defaultReplicationLagGateMaxLag)
+	condition.Reason = replicationLagCaughtUpReason
+	condition.Message = fmt.Sprintf("The member is %s behind the primary", *lag)
+	if *lag > maxLag {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = replicationLagLaggingReason
+		condition.Message = fmt.Sprintf("The member is %s behind the primary, more than the maximum lag of %s", *lag, maxLag)
+	}
+	return condition
+}
+
+// podCondition returns the condition of the given type of the Pod, or nil if it does not have it.
+func podCondition(pod corev1.Pod, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setPodCondition updates the status of the Pod with the given condition, unless the Pod already has it.
+func (r ReplicaSetReconciler) setPodCondition(pod corev1.Pod, condition corev1.PodCondition) error {
+	existing := podCondition(pod, condition.Type)
+	if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason {
+		return nil
+	}
+	if existing == nil || existing.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Now()
+		if condition.Status == corev1.ConditionFalse {
+			r.log.Infof("Marking Pod %s unready: %s", pod.Name, condition.Message)
+		} else if existing != nil {
+			r.log.Infof("Marking Pod %s ready again: %s", pod.Name, condition.Message)
+		}
+	} else {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+
+	if existing != nil {
+		*existing = condition
+	} else {
+		pod.Status.Conditions = append(pod.Status.Conditions, condition)
+	}
+	return r.client.Status().Update(context.TODO(), &pod)
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// setupReplicationLagGate creates the Pods of the members of a replica set with the replication lag readiness gate,
+// and deploys the replica set. The replication lag is not known before the automation config has been created.
+func setupReplicationLagGate(t *testing.T, reader *mockStatusReader) (*ReplicaSetReconciler, *client.MockedManager, mdbv1.MongoDBCommunity) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ReplicationLagGate = &mdbv1.ReplicationLagGate{Enabled: true}
+	mgr := client.NewManager(&mdb)
+	for i := 0; i < mdb.Spec.Members; i++ {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(i), Namespace: mdb.Namespace}}
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	}
+	r := NewReconciler(mgr)
+	r.replicationStatus = reader
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.Equal(t, replicationLagGateInterval, res.RequeueAfter)
+	return r, mgr, mdb
+}
+
+func replicationLagGateCondition(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, member int) *corev1.PodCondition {
+	pod := corev1.Pod{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(member), Namespace: mdb.Namespace}, &pod))
+	return podCondition(pod, replicationLagGateConditionType)
+}
+
+func TestReplicationLagGate_IsAddedToThePods(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	assert.Empty(t, sts.Spec.Template.Spec.ReadinessGates)
+
+	mdb.Spec.ReplicationLagGate = &mdbv1.ReplicationLagGate{Enabled: true}
+	sts.Spec.Template.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: "example.com/custom"}}
+	buildReplicationLagGatePodSpecModification(mdb)(&sts.Spec.Template)
+	assert.Equal(t, []corev1.PodReadinessGate{{ConditionType: "example.com/custom"}, {ConditionType: replicationLagGateConditionType}}, sts.Spec.Template.Spec.ReadinessGates)
+
+	mdb.Spec.ReplicationLagGate.Enabled = false
+	buildReplicationLagGatePodSpecModification(mdb)(&sts.Spec.Template)
+	assert.Equal(t, []corev1.PodReadinessGate{{ConditionType: "example.com/custom"}}, sts.Spec.Template.Spec.ReadinessGates)
+}
+
+func TestReplicationLagGate_MarksLaggingMembersUnready(t *testing.T) {
+	reader := &mockStatusReader{}
+	r, mgr, mdb := setupReplicationLagGate(t, reader)
+	reader.status = replicationStatus(mdb, 3, map[int]time.Duration{1: 2 * time.Second, 2: 30 * time.Second})
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	for i, expected := range []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionTrue, corev1.ConditionFalse} {
+		condition := replicationLagGateCondition(t, mgr, mdb, i)
+		if assert.NotNil(t, condition) {
+			assert.Equal(t, expected, condition.Status)
+		}
+	}
+	condition := replicationLagGateCondition(t, mgr, mdb, 2)
+	assert.Equal(t, replicationLagLaggingReason, condition.Reason)
+	assert.Equal(t, "The member is 30s behind the primary, more than the maximum lag of 10s", condition.Message)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, []mdbv1.MemberStatus{
+		{Name: "my-rs-0", ReplicationLagGate: corev1.ConditionTrue},
+		{Name: "my-rs-1", ReplicationLag: &metav1.Duration{Duration: 2 * time.Second}, ReplicationLagGate: corev1.ConditionTrue},
+		{Name: "my-rs-2", ReplicationLag: &metav1.Duration{Duration: 30 * time.Second}, ReplicationLagGate: corev1.ConditionFalse},
+	}, mdb.Status.Members)
+
+	t.Run("The member is ready again once it has caught up", func(t *testing.T) {
+		reader.status = replicationStatus(mdb, 3, nil)
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		condition := replicationLagGateCondition(t, mgr, mdb, 2)
+		assert.Equal(t, corev1.ConditionTrue, condition.Status)
+		assert.Equal(t, replicationLagCaughtUpReason, condition.Reason)
+	})
+}
+
+func TestReplicationLagGate_KeepsTheGateIfTheLagIsUnknown(t *testing.T) {
+	reader := &mockStatusReader{err: errors.New("connection refused")}
+	r, mgr, mdb := setupReplicationLagGate(t, reader)
+	for i := 0; i < mdb.Spec.Members; i++ {
+		condition := replicationLagGateCondition(t, mgr, mdb, i)
+		if assert.NotNil(t, condition, "new members are not blocked from becoming ready") {
+			assert.Equal(t, corev1.ConditionTrue, condition.Status)
+			assert.Equal(t, replicationLagUnknownReason, condition.Reason)
+		}
+	}
+
+	reader.err = nil
+	reader.status = replicationStatus(mdb, 3, map[int]time.Duration{2: time.Minute})
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	reader.err = errors.New("connection refused")
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, replicationLagGateCondition(t, mgr, mdb, 2).Status, "the lagging member stays unready")
+	assert.Equal(t, corev1.ConditionTrue, replicationLagGateCondition(t, mgr, mdb, 1).Status)
+}
+
+func TestValidateReplicationLagGate(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ReplicationLagGate = &mdbv1.ReplicationLagGate{Enabled: true, MaxReplicationLag: "1m"}
+	assert.NoError(t, validateReplicationLagGate(mdb))
+
+	mdb.Spec.ReplicationLagGate.MaxReplicationLag = "0s"
+	assert.EqualError(t, validateReplicationLagGate(mdb), `invalid replicationLagGate.maxReplicationLag: "0s" must be positive`)
+}
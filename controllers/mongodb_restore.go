@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// restoreInProgress returns the name of the MongoDBCommunityRestore resource restoring data into the resource,
+// if any. The annotation of a restore which no longer exists or has finished is removed, so that the
+// reconciliation does not stay paused if the restore is deleted while it runs.
+func (r ReplicaSetReconciler) restoreInProgress(mdb *mdbv1.MongoDBCommunity) (string, error) {
+	name := mdb.Annotations[mdbv1.RestoreInProgressAnnotation]
+	if name == "" {
+		return "", nil
+	}
+
+	restore := mdbv1.MongoDBCommunityRestore{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mdb.Namespace}, &restore)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return "", err
+	}
+	if err == nil && !restore.IsFinished() {
+		return name, nil
+	}
+
+	r.log.Infof("Restore %s is no longer in progress, resuming the reconciliation", name)
+	if err := r.removeAnnotation(*mdb, mdbv1.RestoreInProgressAnnotation); err != nil {
+		return "", err
+	}
+	delete(mdb.Annotations, mdbv1.RestoreInProgressAnnotation)
+	return "", nil
+}
+
+// removeAnnotation removes the annotation from the resource with a merge patch. Only the annotation is sent, as
+// the spec of the resource may have been replaced during the reconciliation, e.g. by a held back change, which
+// must not be written back. A copy is patched, as the patched object is read back from the API server.
+func (r ReplicaSetReconciler) removeAnnotation(mdb mdbv1.MongoDBCommunity, key string) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{key: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	patched := mdbv1.MongoDBCommunity{}
+	patched.Name, patched.Namespace = mdb.Name, mdb.Namespace
+	return r.client.Patch(context.TODO(), &patched, k8sClient.RawPatch(types.MergePatchType, data))
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	defaultScaleDownMaxReplicationLag = 10 * time.Second
+
+	// scaleDownStatusTimeout bounds the time spent reading the replica set status from a single member.
+	scaleDownStatusTimeout = 10 * time.Second
+
+	scaleDownUnblockedReason     = "Unblocked"
+	scaleDownLaggingReason       = "MembersLagging"
+	scaleDownNoPrimaryReason     = "NoPrimary"
+	scaleDownStatusUnknownReason = "StatusUnavailable"
+)
+
+// validateScaleDown checks that the maximum replication lag of a scale down is a valid duration.
+func validateScaleDown(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.ScaleDown == nil {
+		return nil
+	}
+	if _, err := parsePositiveDuration(mdb.Spec.ScaleDown.MaxReplicationLag, defaultScaleDownMaxReplicationLag); err != nil {
+		return errors.Errorf("invalid scaleDown.maxReplicationLag: %s", err)
+	}
+	return nil
+}
+
+// checkScaleDown checks whether the member removed by this reconciliation can be removed without losing
+// durability, and sets the ScaleDownBlocked condition accordingly. It returns the reason the removal is blocked,
+// or an empty string if the member can be removed.
+//
+// A member can only be removed while there is a primary, and every remaining secondary is healthy and no more
+// than the maximum replication lag behind the primary. Otherwise the removed member may be the only copy of the
+// most recent writes besides the primary.
+func (r ReplicaSetReconciler) checkScaleDown(mdb *mdbv1.MongoDBCommunity) string {
+	remaining := mdb.AutomationConfigMembersThisReconciliation()
+	current := mdb.Status.CurrentMongoDBMembers
+	if remaining >= current || (mdb.Spec.ScaleDown != nil && mdb.Spec.ScaleDown.SkipSafetyCheck) {
+		setScaleDownBlockedCondition(mdb, metav1.ConditionFalse, scaleDownUnblockedReason, "No member removal is blocked")
+		return ""
+	}
+
+	reason, message := r.scaleDownBlockedReason(*mdb, remaining, current)
+	if reason == "" {
+		setScaleDownBlockedCondition(mdb, metav1.ConditionFalse, scaleDownUnblockedReason, "The remaining members are caught up with the primary")
+		return ""
+	}
+	r.log.Warnf("Removal of member %s is blocked: %s", mdb.PodName(current-1), message)
+	setScaleDownBlockedCondition(mdb, metav1.ConditionTrue, reason, message)
+	return message
+}
+
+// scaleDownBlockedReason returns the reason and the message the removal of the members from remaining to current
+// is blocked with, or an empty reason if they can be removed.
+func (r ReplicaSetReconciler) scaleDownBlockedReason(mdb mdbv1.MongoDBCommunity, remaining, current int) (string, string) {
+	maxLag := defaultScaleDownMaxReplicationLag
+	if mdb.Spec.ScaleDown != nil {
+		maxLag, _ = parsePositiveDuration(mdb.Spec.ScaleDown.MaxReplicationLag, defaultScaleDownMaxReplicationLag)
+	}
+
+	hostnames, uri, tlsConfig, err := r.agentConnection(mdb, current)
+	if err != nil {
+		return scaleDownStatusUnknownReason, fmt.Sprintf("Could not connect to the members to check the replication lag: %s", err)
+	}
+	status, err := r.primaryReplSetStatus(hostnames, uri, tlsConfig)
+	if err != nil {
+		return scaleDownStatusUnknownReason, fmt.Sprintf("Could not read the replica set status to check the replication lag: %s", err)
+	}
+	primary, ok := status.Primary()
+	if !ok {
+		return scaleDownNoPrimaryReason, "The replica set has no primary, members are not removed until it has one"
+	}
+
+	removed := false
+	for i := remaining; i < current; i++ {
+		if _, ok := status.Member(net.JoinHostPort(hostnames[i], "27017")); ok {
+			removed = true
+		}
+	}
+	if !removed {
+		// the members have already been removed from the replica set by a previous reconciliation
+		return "", ""
+	}
+
+	var lagging []string
+	for i := 0; i < remaining; i++ {
+		name := net.JoinHostPort(hostnames[i], "27017")
+		if name == primary.Name {
+			continue
+		}
+		member, ok := status.Member(name)
+		switch {
+		case !ok:
+			lagging = append(lagging, fmt.Sprintf("%s is not a member of the replica set", mdb.PodName(i)))
+		case member.Health != 1 || member.StateStr != replication.StateSecondary:
+			lagging = append(lagging, fmt.Sprintf("%s is %s", mdb.PodName(i), member.StateStr))
+		case primary.OptimeDate.Sub(member.OptimeDate) > maxLag:
+			lagging = append(lagging, fmt.Sprintf("%s is %s behind the primary", mdb.PodName(i), primary.OptimeDate.Sub(member.OptimeDate)))
+		}
+	}
+	if len(lagging) > 0 {
+		return scaleDownLaggingReason, fmt.Sprintf("Member %s is not removed while the remaining members are not caught up with the primary (maximum lag %s): %s",
+			mdb.PodName(current-1), maxLag, strings.Join(lagging, ", "))
+	}
+	return "", ""
+}
+
+// primaryReplSetStatus returns the replica set status of the first member which knows the primary, or of the
+// last member which could be reached if none does.
+func (r ReplicaSetReconciler) primaryReplSetStatus(hostnames []string, uri func(string) string, tlsConfig *tls.Config) (replication.Status, error) {
+	var status replication.Status
+	lastErr := errors.New("no member could be reached")
+	for _, hostname := range hostnames {
+		ctx, cancel := context.WithTimeout(context.Background(), scaleDownStatusTimeout)
+		s, err := r.replicationStatus.ReplSetStatus(ctx, uri(hostname), tlsConfig)
+		cancel()
+		if err != nil {
+			r.log.Debugf("Could not read the replica set status from %s: %s", hostname, err)
+			lastErr = err
+			continue
+		}
+		if _, ok := s.Primary(); ok {
+			return s, nil
+		}
+		status, lastErr = s, nil
+	}
+	return status, lastErr
+}
+
+// agentConnection returns the hostnames of the first n members, a function returning the connection string of the
+// member with the given hostname, and the TLS configuration of the connection. The operator connects as the agent,
+// which has the privileges to run replSetGetStatus.
+func (r ReplicaSetReconciler) agentConnection(mdb mdbv1.MongoDBCommunity, n int) ([]string, func(string) string, *tls.Config, error) {
+	hostnames, err := mdb.MemberHostnames(n, os.Getenv(clusterDNSName))
+	if err != nil {
+		return nil, nil, nil, errors.Errorf("could not determine the member hostnames: %s", err)
+	}
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		return nil, nil, nil, errors.Errorf("could not read the automation config: %s", err)
+	}
+	credentials := ""
+	if !ac.Auth.Disabled {
+		if ac.Auth.AutoPwd == "" {
+			return nil, nil, nil, errors.New("the agent does not authenticate with a password")
+		}
+		credentials = url.UserPassword(ac.Auth.AutoUser, ac.Auth.AutoPwd).String() + "@"
+	}
+	tlsConfig, err := operatorTLSConfig(r.client, mdb)
+	if err != nil {
+		return nil, nil, nil, errors.Errorf("could not build the TLS configuration: %s", err)
+	}
+	uri := func(hostname string) string {
+		return fmt.Sprintf("mongodb://%s%s/?authSource=admin", credentials, net.JoinHostPort(hostname, "27017"))
+	}
+	return hostnames, uri, tlsConfig, nil
+}
+
+// setScaleDownBlockedCondition sets the ScaleDownBlocked condition. A condition which reports that no removal
+// is blocked is only set if a removal is currently reported as blocked.
+func setScaleDownBlockedCondition(mdb *mdbv1.MongoDBCommunity, conditionStatus metav1.ConditionStatus, reason, message string) {
+	if conditionStatus == metav1.ConditionFalse && !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionScaleDownBlocked) {
+		return
+	}
+	meta.SetStatusCondition(&mdb.Status.Conditions, metav1.Condition{
+		Type:               mdbv1.ConditionScaleDownBlocked,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mdb.Generation,
+	})
+}
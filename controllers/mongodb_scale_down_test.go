@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
+)
+
+// mockStatusReader returns the same replica set status whichever member it connects to.
+type mockStatusReader struct {
+	status replication.Status
+	err    error
+}
+
+func (m *mockStatusReader) ReplSetStatus(context.Context, string, *tls.Config) (replication.Status, error) {
+	return m.status, m.err
+}
+
+// replicationStatus returns the status of a replica set of the given number of members, whose first member
+// is the primary and whose secondaries are behind the primary by the given lag.
+func replicationStatus(mdb mdbv1.MongoDBCommunity, members int, lag map[int]time.Duration) replication.Status {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	status := replication.Status{}
+	for i := 0; i < members; i++ {
+		state := replication.StateSecondary
+		if i == 0 {
+			state = replication.StatePrimary
+		}
+		status.Members = append(status.Members, replication.MemberStatus{
+			Name:       fmt.Sprintf("%s.%s.%s.svc.cluster.local:27017", mdb.PodName(i), mdb.ServiceName(), mdb.Namespace),
+			Health:     1,
+			StateStr:   state,
+			OptimeDate: now.Add(-lag[i]),
+		})
+	}
+	return status
+}
+
+// setupScaleDown deploys a replica set of three members and scales it down to two members.
+func setupScaleDown(t *testing.T, mdb mdbv1.MongoDBCommunity, reader *mockStatusReader) (*ReplicaSetReconciler, *client.MockedManager, mdbv1.MongoDBCommunity) {
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.replicationStatus = reader
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Members = 2
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	makeStatefulSetReady(t, mgr.GetClient(), mdb)
+	return r, mgr, mdb
+}
+
+func automationConfigMembers(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity) int {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	return len(ac.Processes)
+}
+
+func TestScaleDown_IsBlockedWhileTheRemainingSecondaryIsLagging(t *testing.T) {
+	mdb := newTestReplicaSet()
+	reader := &mockStatusReader{status: replicationStatus(mdb, 3, map[int]time.Duration{1: 30 * time.Second})}
+	r, mgr, mdb := setupScaleDown(t, mdb, reader)
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, 3, automationConfigMembers(t, mgr, mdb), "the member is not removed from the replica set")
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionScaleDownBlocked)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, scaleDownLaggingReason, condition.Reason)
+		assert.Equal(t, "Member my-rs-2 is not removed while the remaining members are not caught up with the primary (maximum lag 10s): my-rs-1 is 30s behind the primary", condition.Message)
+	}
+
+	t.Run("The member is removed once the secondary has caught up", func(t *testing.T) {
+		reader.status = replicationStatus(mdb, 3, map[int]time.Duration{1: time.Second})
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, 2, mdb.Status.CurrentMongoDBMembers)
+		assert.Equal(t, 2, automationConfigMembers(t, mgr, mdb))
+		assert.False(t, meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionScaleDownBlocked))
+	})
+}
+
+func TestScaleDown_IsBlockedIfTheRemainingSecondaryIsNotHealthy(t *testing.T) {
+	mdb := newTestReplicaSet()
+	status := replicationStatus(mdb, 3, nil)
+	status.Members[1].Health = 0
+	status.Members[1].StateStr = "(not reachable/healthy)"
+	r, mgr, mdb := setupScaleDown(t, mdb, &mockStatusReader{status: status})
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionScaleDownBlocked)
+	if assert.NotNil(t, condition) {
+		assert.Contains(t, condition.Message, "my-rs-1 is (not reachable/healthy)")
+	}
+}
+
+func TestScaleDown_IsBlockedWithoutAPrimary(t *testing.T) {
+	mdb := newTestReplicaSet()
+	status := replicationStatus(mdb, 3, nil)
+	status.Members[0].StateStr = replication.StateSecondary
+	r, mgr, mdb := setupScaleDown(t, mdb, &mockStatusReader{status: status})
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionScaleDownBlocked)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, scaleDownNoPrimaryReason, condition.Reason)
+	}
+}
+
+func TestScaleDown_SafetyCheckCanBeSkipped(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ScaleDown = &mdbv1.ScaleDownConfiguration{SkipSafetyCheck: true}
+	reader := &mockStatusReader{status: replicationStatus(mdb, 3, map[int]time.Duration{1: time.Hour})}
+	r, mgr, mdb := setupScaleDown(t, mdb, reader)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, 2, mdb.Status.CurrentMongoDBMembers)
+}
+
+func TestScaleDown_MaxReplicationLagIsConfigurable(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ScaleDown = &mdbv1.ScaleDownConfiguration{MaxReplicationLag: "1m"}
+	reader := &mockStatusReader{status: replicationStatus(mdb, 3, map[int]time.Duration{1: 30 * time.Second})}
+	r, mgr, mdb := setupScaleDown(t, mdb, reader)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, 2, mdb.Status.CurrentMongoDBMembers)
+}
+
+func TestValidateScaleDown(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateScaleDown(mdb))
+
+	mdb.Spec.ScaleDown = &mdbv1.ScaleDownConfiguration{MaxReplicationLag: "30s"}
+	assert.NoError(t, validateScaleDown(mdb))
+
+	mdb.Spec.ScaleDown.MaxReplicationLag = "-1s"
+	assert.EqualError(t, validateScaleDown(mdb), `invalid scaleDown.maxReplicationLag: "-1s" must be positive`)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/objx"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/validation"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+type serverParameterType string
+
+const (
+	serverParameterInt    serverParameterType = "integer"
+	serverParameterBool   serverParameterType = "boolean"
+	serverParameterString serverParameterType = "string"
+)
+
+// knownServerParameters are the server parameters the operator validates, with the type of their values.
+var knownServerParameters = map[string]serverParameterType{
+	"cursorTimeoutMillis":                     serverParameterInt,
+	"diagnosticDataCollectionEnabled":         serverParameterBool,
+	"disableJavaScriptJIT":                    serverParameterBool,
+	"enableFlowControl":                       serverParameterBool,
+	"flowControlTargetLagSeconds":             serverParameterInt,
+	"internalQueryExecMaxBlockingSortBytes":   serverParameterInt,
+	"logLevel":                                serverParameterInt,
+	"maxIndexBuildMemoryUsageMegabytes":       serverParameterInt,
+	"maxSessions":                             serverParameterInt,
+	"maxTransactionLockRequestTimeoutMillis":  serverParameterInt,
+	"notablescan":                             serverParameterBool,
+	"replWriterThreadCount":                   serverParameterInt,
+	"saslauthdPath":                           serverParameterString,
+	"storageEngineConcurrentReadTransactions": serverParameterInt,
+	"tcmallocAggressiveMemoryDecommit":        serverParameterInt,
+	"transactionLifetimeLimitSeconds":         serverParameterInt,
+	"ttlMonitorEnabled":                       serverParameterBool,
+	"ttlMonitorSleepSecs":                     serverParameterInt,
+	"wiredTigerConcurrentReadTransactions":    serverParameterInt,
+	"wiredTigerConcurrentWriteTransactions":   serverParameterInt,
+	"wiredTigerEngineRuntimeConfig":           serverParameterString,
+}
+
+// validateServerParameters checks the values of the known server parameters, and that unknown parameters
+// are only configured if they are allowed. A parameter can not be configured both as a server parameter
+// and in the setParameter section of AdditionalMongodConfig.
+func validateServerParameters(mdb mdbv1.MongoDBCommunity) error {
+	additionalParameters := objx.New(mdb.Spec.AdditionalMongodConfig.Object).Get("setParameter").ObjxMap()
+
+	names := make([]string, 0, len(mdb.Spec.ServerParameters.Object))
+	for name := range mdb.Spec.ServerParameters.Object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := mdb.Spec.ServerParameters.Object[name]
+		if additionalParameters.Has(name) {
+			return errors.Errorf("server parameter %s is also configured in additionalMongodConfig.setParameter", name)
+		}
+		parameterType, ok := knownServerParameters[name]
+		if !ok {
+			if !mdb.Spec.AllowUnknownServerParameters {
+				return errors.Errorf("server parameter %s is not known, set allowUnknownServerParameters to configure it", name)
+			}
+			continue
+		}
+		if !hasServerParameterType(value, parameterType) {
+			return errors.Errorf("server parameter %s must be of type %s, got %v", name, parameterType, value)
+		}
+	}
+	return nil
+}
+
+// validateMongodOptionsForVersion checks that the version the members are moved to accepts the options of
+// additionalMongodConfig and the server parameters, so that the members do not fail to start after an upgrade.
+// The options are only checked if the version or the options have changed since the last successful
+// reconciliation, so that a running deployment is not failed by a change of the catalog.
+func validateMongodOptionsForVersion(mdb mdbv1.MongoDBCommunity) error {
+	prevSpec, err := lastSuccessfulSpec(mdb)
+	if err != nil {
+		return err
+	}
+	if prevSpec != nil && prevSpec.Version == mdb.Spec.Version &&
+		reflect.DeepEqual(prevSpec.AdditionalMongodConfig.Object, mdb.Spec.AdditionalMongodConfig.Object) &&
+		reflect.DeepEqual(prevSpec.ServerParameters.Object, mdb.Spec.ServerParameters.Object) {
+		return nil
+	}
+	return validation.ValidateMongodOptions(mdb.Spec.Version, mdb.Spec.AdditionalMongodConfig.Object, mdb.Spec.ServerParameters.Object)
+}
+
+func hasServerParameterType(value interface{}, parameterType serverParameterType) bool {
+	switch parameterType {
+	case serverParameterBool:
+		_, ok := value.(bool)
+		return ok
+	case serverParameterString:
+		_, ok := value.(string)
+		return ok
+	case serverParameterInt:
+		switch v := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+	}
+	return false
+}
+
+// getServerParametersModification renders the server parameters into the setParameter section of the
+// configuration of each process.
+func getServerParametersModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if len(mdb.Spec.ServerParameters.Object) == 0 {
+		return automationconfig.NOOP()
+	}
+	return func(ac *automationconfig.AutomationConfig) {
+		for i := range ac.Processes {
+			for name, value := range mdb.Spec.ServerParameters.Object {
+				if v, ok := value.(float64); ok && v == math.Trunc(v) {
+					// JSON numbers are decoded as floats, integers are passed on as such.
+					value = int64(v)
+				}
+				ac.Processes[i].SetArgs26Field("setParameter."+name, value)
+			}
+		}
+	}
+}
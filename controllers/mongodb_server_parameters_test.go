@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestValidateServerParameters(t *testing.T) {
+	t.Run("Valid configuration", func(t *testing.T) {
+		assert.NoError(t, validateServerParameters(newTestReplicaSetWithServerParameters()))
+	})
+	t.Run("No server parameters", func(t *testing.T) {
+		assert.NoError(t, validateServerParameters(newTestReplicaSet()))
+	})
+	t.Run("Value has the wrong type", func(t *testing.T) {
+		mdb := newTestReplicaSetWithServerParameters()
+		mdb.Spec.ServerParameters.Object["cursorTimeoutMillis"] = "600000"
+		assert.Error(t, validateServerParameters(mdb))
+
+		mdb.Spec.ServerParameters.Object["cursorTimeoutMillis"] = 600000.5
+		assert.Error(t, validateServerParameters(mdb))
+	})
+	t.Run("Unknown parameters are rejected unless allowed", func(t *testing.T) {
+		mdb := newTestReplicaSetWithServerParameters()
+		mdb.Spec.ServerParameters.Object["someNewParameter"] = 1.5
+		assert.Error(t, validateServerParameters(mdb))
+
+		mdb.Spec.AllowUnknownServerParameters = true
+		assert.NoError(t, validateServerParameters(mdb))
+	})
+	t.Run("Parameter is also configured in additionalMongodConfig", func(t *testing.T) {
+		mdb := newTestReplicaSetWithServerParameters()
+		mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{
+			"setParameter": map[string]interface{}{"transactionLifetimeLimitSeconds": float64(30)},
+		}
+		assert.Error(t, validateServerParameters(mdb))
+	})
+}
+
+func TestServerParameters_AreRenderedIntoAutomationConfig(t *testing.T) {
+	mdb := newTestReplicaSetWithServerParameters()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, p := range ac.Processes {
+		assert.Equal(t, float64(120), p.Args26.Get("setParameter.transactionLifetimeLimitSeconds").Data())
+		assert.Equal(t, float64(600000), p.Args26.Get("setParameter.cursorTimeoutMillis").Data())
+		assert.Equal(t, false, p.Args26.Get("setParameter.ttlMonitorEnabled").Data())
+	}
+}
+
+func TestMongodOptions_AreValidatedAgainstTheTargetVersion(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{
+		"setParameter": map[string]interface{}{"failIndexKeyTooLong": false},
+	}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Version = "4.4.6"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "additionalMongodConfig.setParameter.failIndexKeyTooLong (removed in 4.4.0)")
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "4.2.2", ac.Processes[0].Version, "the upgrade is not applied")
+}
+
+func newTestReplicaSetWithServerParameters() mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ServerParameters = mdbv1.MongodConfiguration{Object: map[string]interface{}{
+		"transactionLifetimeLimitSeconds": float64(120),
+		"cursorTimeoutMillis":             float64(600000),
+		"ttlMonitorEnabled":               false,
+	}}
+	return mdb
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// mongodbPortName is the name of the port of the Services which forwards to mongod. Kubernetes only publishes
+// SRV records for named ports, and drivers look up the "mongodb" service.
+const mongodbPortName = "mongodb"
+
+// validateService checks that the ports of spec.service have unique names and numbers, and that the port of the
+// client Service is only set with the client Service.
+func validateService(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.Service == nil {
+		return nil
+	}
+	if mdb.Spec.Service.Port != 0 && mdb.Spec.Service.ClientService == nil {
+		return errors.New("service.port can only be set with service.clientService, the headless Service always uses port 27017")
+	}
+	names := map[string]bool{mongodbPortName: true}
+	ports := map[int32]bool{automationconfig.DefaultDBPort: true, mdb.Spec.Service.GetPort(): true}
+	for i, port := range mdb.Spec.Service.AdditionalPorts {
+		if names[port.Name] {
+			return errors.Errorf("additionalPorts[%d] can not use the name %s, which is already used", i, port.Name)
+		}
+		if ports[port.Port] {
+			return errors.Errorf("additionalPorts[%d] can not use the port %d, which is already used", i, port.Port)
+		}
+		names[port.Name] = true
+		ports[port.Port] = true
+	}
+	return nil
+}
+
+// servicePorts returns the ports of the headless Service and of the client Service, whose mongodb port is the
+// given one. The port of a Service created without spec.service is left unnamed, as it was by earlier operator
+// versions, unless a user requested a mongodb+srv:// connection string, which needs the SRV records Kubernetes
+// only publishes for named ports.
+func servicePorts(mdb mdbv1.MongoDBCommunity, port int32) []corev1.ServicePort {
+	if mdb.Spec.Service == nil && !srvRequested(mdb) {
+		return []corev1.ServicePort{{Port: port}}
+	}
+	ports := []corev1.ServicePort{{
+		Name:       mongodbPortName,
+		Port:       port,
+		TargetPort: intstr.FromInt(automationconfig.DefaultDBPort),
+	}}
+	if mdb.Spec.Service == nil {
+		return ports
+	}
+	for _, port := range mdb.Spec.Service.AdditionalPorts {
+		targetPort := port.TargetPort
+		if targetPort == 0 {
+			targetPort = port.Port
+		}
+		ports = append(ports, corev1.ServicePort{
+			Name:       port.Name,
+			Port:       port.Port,
+			TargetPort: intstr.FromInt(int(targetPort)),
+		})
+	}
+	return ports
+}
+
+// withServiceConfiguration sets the ports, labels and annotations of spec.service on the given Service, whose
+// mongodb port is the given one. The labels of the label schema take precedence over the configured labels.
+func withServiceConfiguration(mdb mdbv1.MongoDBCommunity, svc corev1.Service, port int32) corev1.Service {
+	svc.Spec.Ports = servicePorts(mdb, port)
+	if mdb.Spec.Service == nil {
+		return svc
+	}
+	labels := map[string]string{}
+	for k, v := range mdb.Spec.Service.Labels {
+		labels[k] = v
+	}
+	for k, v := range svc.Labels {
+		labels[k] = v
+	}
+	svc.Labels = labels
+	for k, v := range mdb.Spec.Service.Annotations {
+		svc.Annotations[k] = v
+	}
+	return svc
+}
+
+// buildClientService returns the Service with a cluster IP, which routes to the ready members only. It is the only
+// Service which uses spec.service.port.
+func buildClientService(mdb mdbv1.MongoDBCommunity) corev1.Service {
+	annotations := map[string]string{}
+	for k, v := range mdb.Spec.Service.ClientService.Annotations {
+		annotations[k] = v
+	}
+	svc := service.Builder().
+		SetName(mdb.ClientServiceName()).
+		SetNamespace(mdb.Namespace).
+		SetSelector(map[string]string{"app": mdb.ServiceName()}).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentDatabase)).
+		SetAnnotations(annotations).
+		SetServiceType(mdb.Spec.Service.ClientService.GetType()).
+		SetOwnerReferences(mdb.GetOwnerReferences()).
+		Build()
+	return withServiceMeshAppProtocol(mdb, withIPFamilies(mdb, withServiceConfiguration(mdb, svc, mdb.Spec.Service.GetPort())), mongodAppProtocol)
+}
+
+// ensureClientService creates or updates the client Service, or deletes it once it is disabled.
+func (r ReplicaSetReconciler) ensureClientService(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.Service != nil && mdb.Spec.Service.ClientService != nil {
+		return r.createOrUpdateService(buildClientService(mdb))
+	}
+
+	svc, err := r.client.GetService(types.NamespacedName{Name: mdb.ClientServiceName(), Namespace: mdb.Namespace})
+	if apiErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Errorf("could not get Service %s: %s", mdb.ClientServiceName(), err)
+	}
+	if !metav1.IsControlledBy(&svc, &mdb) {
+		return nil
+	}
+	r.log.Infof("Deleting Service %s, as the client Service is disabled", svc.Name)
+	if err := r.client.Delete(context.TODO(), &svc); err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Errorf("could not delete Service %s: %s", svc.Name, err)
+	}
+	return nil
+}
+
+// createOrUpdateService creates the given Service, or merges it into the existing one. Labels and annotations
+// which are not set on the given Service are kept, as are the node ports allocated to the existing one.
+func (r ReplicaSetReconciler) createOrUpdateService(svc corev1.Service) error {
+	existing, err := r.client.GetService(types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace})
+	if apiErrors.IsNotFound(err) {
+		err = r.client.CreateService(svc)
+	} else if err == nil {
+		err = r.client.UpdateService(mergeService(existing, svc))
+	}
+	if err != nil {
+		return errors.Errorf("could not ensure Service %s: %s", svc.Name, err)
+	}
+	return nil
+}
+
+// mergeService merges the given Service into the existing one, as it is updated by createOrUpdateService.
+func mergeService(existing, svc corev1.Service) corev1.Service {
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	if existing.Labels == nil {
+		existing.Labels = map[string]string{}
+	}
+	merged := service.Merge(existing, svc)
+	merged.Spec.Selector = svc.Spec.Selector
+	mergeIPFamilies(&merged, svc)
+	return merged
+}
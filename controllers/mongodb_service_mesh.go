@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// Annotations of the Pods which configure the injected sidecar proxies.
+const (
+	istioExcludeInboundPorts  = "traffic.sidecar.istio.io/excludeInboundPorts"
+	istioExcludeOutboundPorts = "traffic.sidecar.istio.io/excludeOutboundPorts"
+	istioProxyConfig          = "proxy.istio.io/config"
+	istioInject               = "sidecar.istio.io/inject"
+
+	linkerdSkipInboundPorts  = "config.linkerd.io/skip-inbound-ports"
+	linkerdSkipOutboundPorts = "config.linkerd.io/skip-outbound-ports"
+	linkerdProxyAwait        = "config.linkerd.io/proxy-await"
+	linkerdInject            = "linkerd.io/inject"
+
+	// defaultContainerAnnotation is the container kubectl logs and kubectl exec use if none is given, which
+	// would be the injected proxy if it is the first container.
+	defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+	// Application protocols of the ports of the Services, which the proxies use instead of guessing the
+	// protocol from the name of the port or by sniffing the first bytes of a connection.
+	mongodAppProtocol  = "tcp"
+	metricsAppProtocol = "http"
+)
+
+// serviceMeshAnnotations returns the annotations of the member Pods for the configured service mesh. The mongod
+// port is excluded from the redirection to the proxy unless it is configured otherwise, and the containers of
+// the members start once the proxy is ready, so that the agents can reach the other members and the readiness
+// probe does not report a member whose traffic can not be routed yet.
+func serviceMeshAnnotations(mdb mdbv1.MongoDBCommunity) map[string]string {
+	mesh := mdb.Spec.ServiceMesh
+	port := "27017"
+	annotations := map[string]string{}
+	switch {
+	case mesh.IsIstio():
+		annotations[istioProxyConfig] = `{"holdApplicationUntilProxyStarts": true}`
+		if !mesh.RedirectMongod {
+			annotations[istioExcludeInboundPorts] = port
+			annotations[istioExcludeOutboundPorts] = port
+		}
+	case mesh.IsLinkerd():
+		annotations[linkerdProxyAwait] = "enabled"
+		if !mesh.RedirectMongod {
+			annotations[linkerdSkipInboundPorts] = port
+			annotations[linkerdSkipOutboundPorts] = port
+		}
+	default:
+		return annotations
+	}
+	annotations[defaultContainerAnnotation] = construct.MongodbName
+	return annotations
+}
+
+// buildServiceMeshPodSpecModification sets the annotations of the configured service mesh on the member Pods.
+// The annotations the operator manages are removed first, so that disabling the mesh mode or the redirection of
+// the mongod port restores the defaults of the mesh. Annotations set in the StatefulSet override are applied
+// afterwards and take precedence.
+func buildServiceMeshPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	return func(template *corev1.PodTemplateSpec) {
+		for _, key := range []string{
+			istioExcludeInboundPorts, istioExcludeOutboundPorts, istioProxyConfig,
+			linkerdSkipInboundPorts, linkerdSkipOutboundPorts, linkerdProxyAwait,
+			defaultContainerAnnotation,
+		} {
+			delete(template.Annotations, key)
+		}
+		annotations := serviceMeshAnnotations(mdb)
+		if len(annotations) == 0 {
+			return
+		}
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			template.Annotations[k] = v
+		}
+	}
+}
+
+// withServiceMeshAppProtocol sets the given application protocol on the first port of the Service, which is
+// the port of mongod or of the exporter, if the members run in a service mesh.
+func withServiceMeshAppProtocol(mdb mdbv1.MongoDBCommunity, svc corev1.Service, appProtocol string) corev1.Service {
+	if mdb.Spec.ServiceMesh == nil || len(svc.Spec.Ports) == 0 {
+		return svc
+	}
+	protocol := appProtocol
+	svc.Spec.Ports[0].AppProtocol = &protocol
+	return svc
+}
+
+// disableSidecarInjection disables the injection of the proxy into the Pods of a Job. The proxy does not exit
+// once the containers of the Job have completed, which would keep the Job running forever.
+func disableSidecarInjection(mdb mdbv1.MongoDBCommunity, meta *metav1.ObjectMeta) {
+	var key, value string
+	switch {
+	case mdb.Spec.ServiceMesh.IsIstio():
+		key, value = istioInject, "false"
+	case mdb.Spec.ServiceMesh.IsLinkerd():
+		key, value = linkerdInject, "disabled"
+	default:
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[key] = value
+}
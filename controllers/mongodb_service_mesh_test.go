@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestServiceMesh_ConfiguresTheMembersAndTheServices(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ServiceMesh = &mdbv1.ServiceMesh{Type: mdbv1.ServiceMeshIstio}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	annotations := sts.Spec.Template.Annotations
+	assert.Equal(t, "27017", annotations[istioExcludeInboundPorts])
+	assert.Equal(t, "27017", annotations[istioExcludeOutboundPorts])
+	assert.Equal(t, `{"holdApplicationUntilProxyStarts": true}`, annotations[istioProxyConfig])
+	assert.Equal(t, "mongod", annotations[defaultContainerAnnotation])
+
+	headless, err := mgr.Client.GetService(types.NamespacedName{Name: mdb.ServiceName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", *headless.Spec.Ports[0].AppProtocol)
+	mdb.Spec.Prometheus = &mdbv1.Prometheus{Enabled: true}
+	metrics := buildPrometheusService(mdb)
+	assert.Equal(t, "http", *metrics.Spec.Ports[0].AppProtocol)
+
+	t.Run("Redirecting mongod through the Linkerd proxy", func(t *testing.T) {
+		mdb.Spec.Prometheus = nil
+		mdb.Spec.ServiceMesh = &mdbv1.ServiceMesh{Type: mdbv1.ServiceMeshLinkerd, RedirectMongod: true}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		annotations := sts.Spec.Template.Annotations
+		assert.Equal(t, "enabled", annotations[linkerdProxyAwait])
+		assert.NotContains(t, annotations, linkerdSkipInboundPorts)
+		assert.NotContains(t, annotations, linkerdSkipOutboundPorts)
+		assert.NotContains(t, annotations, istioProxyConfig, "the annotations of the previous mesh are removed")
+		assert.NotContains(t, annotations, istioExcludeInboundPorts)
+	})
+
+	t.Run("Disabling the mesh mode removes the annotations", func(t *testing.T) {
+		mdb.Spec.ServiceMesh = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.NotContains(t, sts.Spec.Template.Annotations, linkerdProxyAwait)
+		assert.NotContains(t, sts.Spec.Template.Annotations, defaultContainerAnnotation)
+	})
+}
+
+func TestSetJobMetadata_DisablesSidecarInjection(t *testing.T) {
+	mdb := newTestReplicaSet()
+	job := batchv1.Job{}
+	setJobMetadata(mdb, &job)
+	assert.Empty(t, job.Spec.Template.Annotations)
+
+	mdb.Spec.ServiceMesh = &mdbv1.ServiceMesh{Type: mdbv1.ServiceMeshIstio}
+	setJobMetadata(mdb, &job)
+	assert.Equal(t, "false", job.Spec.Template.Annotations[istioInject])
+
+	job = batchv1.Job{}
+	mdb.Spec.ServiceMesh = &mdbv1.ServiceMesh{Type: mdbv1.ServiceMeshLinkerd}
+	setJobMetadata(mdb, &job)
+	assert.Equal(t, "disabled", job.Spec.Template.Annotations[linkerdInject])
+	assert.Equal(t, mdb.Name, job.Spec.Template.Labels[mdbv1.LabelResource])
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestService_IsConfigured(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	mdb.Spec.Service = &mdbv1.ServiceConfiguration{
+		Port:            27018,
+		AdditionalPorts: []mdbv1.ServicePort{{Name: "metrics", Port: 9216}, {Name: "proxy", Port: 8080, TargetPort: 8443}},
+		Labels:          map[string]string{"team": "payments", mdbv1.LabelResource: "other"},
+		Annotations:     map[string]string{"example.com/owner": "payments"},
+		ClientService: &mdbv1.ClientService{
+			Type:        corev1.ServiceTypeLoadBalancer,
+			Annotations: map[string]string{"networking.gke.io/load-balancer-type": "Internal"},
+		},
+	}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	expectedPorts := []corev1.ServicePort{
+		{Name: "mongodb", Port: 27017, TargetPort: intstr.FromInt(27017)},
+		{Name: "metrics", Port: 9216, TargetPort: intstr.FromInt(9216)},
+		{Name: "proxy", Port: 8080, TargetPort: intstr.FromInt(8443)},
+	}
+	headless, err := mgr.Client.GetService(types.NamespacedName{Name: mdb.ServiceName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "None", headless.Spec.ClusterIP)
+	assert.Equal(t, expectedPorts, headless.Spec.Ports, "the headless Service keeps port 27017")
+	assert.Equal(t, "payments", headless.Labels["team"])
+	assert.Equal(t, mdb.Name, headless.Labels[mdbv1.LabelResource], "the labels of the label schema take precedence")
+	assert.Equal(t, "payments", headless.Annotations["example.com/owner"])
+	assert.NotContains(t, headless.Annotations, "networking.gke.io/load-balancer-type")
+
+	clientService, err := mgr.Client.GetService(types.NamespacedName{Name: "my-rs-client", Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, clientService.Spec.Type)
+	assert.Empty(t, clientService.Spec.ClusterIP)
+	assert.False(t, clientService.Spec.PublishNotReadyAddresses)
+	assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, clientService.Spec.Selector)
+	expectedPorts[0].Port = 27018
+	assert.Equal(t, expectedPorts, clientService.Spec.Ports)
+	assert.Equal(t, "payments", clientService.Labels["team"])
+	assert.Equal(t, "Internal", clientService.Annotations["networking.gke.io/load-balancer-type"])
+
+	t.Run("Disabling the client Service deletes it", func(t *testing.T) {
+		mdb.Spec.Service.ClientService = nil
+		mdb.Spec.Service.Port = 0
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		_, err = mgr.Client.GetService(types.NamespacedName{Name: "my-rs-client", Namespace: mdb.Namespace})
+		assert.True(t, apiErrors.IsNotFound(err))
+	})
+}
+
+func TestValidateService(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateService(mdb))
+
+	mdb.Spec.Service = &mdbv1.ServiceConfiguration{AdditionalPorts: []mdbv1.ServicePort{{Name: "metrics", Port: 9216}}}
+	assert.NoError(t, validateService(mdb))
+
+	mdb.Spec.Service.AdditionalPorts = []mdbv1.ServicePort{{Name: "mongodb", Port: 9216}}
+	assert.EqualError(t, validateService(mdb), "additionalPorts[0] can not use the name mongodb, which is already used")
+
+	mdb.Spec.Service.AdditionalPorts = []mdbv1.ServicePort{{Name: "metrics", Port: 9216}, {Name: "other", Port: 9216}}
+	assert.EqualError(t, validateService(mdb), "additionalPorts[1] can not use the port 9216, which is already used")
+
+	mdb.Spec.Service = &mdbv1.ServiceConfiguration{Port: 9216, AdditionalPorts: []mdbv1.ServicePort{{Name: "metrics", Port: 9216}}, ClientService: &mdbv1.ClientService{}}
+	assert.EqualError(t, validateService(mdb), "additionalPorts[0] can not use the port 9216, which is already used")
+
+	mdb.Spec.Service = &mdbv1.ServiceConfiguration{Port: 9216, ClientService: &mdbv1.ClientService{}, AdditionalPorts: []mdbv1.ServicePort{{Name: "metrics", Port: 27017}}}
+	assert.EqualError(t, validateService(mdb), "additionalPorts[0] can not use the port 27017, which is already used")
+
+	mdb.Spec.Service = &mdbv1.ServiceConfiguration{Port: 27018}
+	assert.EqualError(t, validateService(mdb), "service.port can only be set with service.clientService, the headless Service always uses port 27017")
+}
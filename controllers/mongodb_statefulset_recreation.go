@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// recreateStatefulSet replaces a StatefulSet whose update was rejected because it changes immutable fields, such
+// as the selector or the volume claim templates, e.g. after an upgrade of the operator. The StatefulSet is deleted
+// without its Pods, whose labels are first updated to match the new selector, so that the new StatefulSet adopts
+// them. The PersistentVolumeClaims are not owned by the StatefulSet and are used by the Pods again.
+//
+// It returns true if the new StatefulSet can not be created yet, because the existing one is still being deleted.
+func (r ReplicaSetReconciler) recreateStatefulSet(mdb mdbv1.MongoDBCommunity, existing, desired appsv1.StatefulSet, updateErr error) (bool, error) {
+	r.log.Infof("Recreating StatefulSet %s, as its update changes immutable fields: %s", existing.Name, updateErr)
+	if err := r.adoptPods(existing, desired); err != nil {
+		return false, errors.Errorf("could not label the Pods for the new StatefulSet: %s", err)
+	}
+
+	uid := existing.UID
+	if err := r.client.Delete(context.TODO(), &existing, k8sClient.PropagationPolicy(metav1.DeletePropagationOrphan), k8sClient.Preconditions{UID: &uid}); err != nil && !apiErrors.IsNotFound(err) {
+		return false, errors.Errorf("could not delete the StatefulSet: %s", err)
+	}
+
+	desired.ResourceVersion = ""
+	desired.UID = ""
+	desired.Status = appsv1.StatefulSetStatus{}
+	if err := r.client.CreateStatefulSet(desired); err != nil {
+		if apiErrors.IsAlreadyExists(err) {
+			return true, nil
+		}
+		return false, errors.Errorf("could not create the StatefulSet: %s", err)
+	}
+	if r.recorder != nil {
+		r.recorder.AnnotatedEventf(&mdb, mdb.SchemaLabels(mdbv1.ComponentDatabase), corev1.EventTypeNormal, "StatefulSetRecreated", "Recreated StatefulSet %s, as its update changes immutable fields", existing.Name)
+	}
+	return false, nil
+}
+
+// adoptPods adds the labels of the selector of the desired StatefulSet to the Pods of the existing one.
+func (r ReplicaSetReconciler) adoptPods(existing, desired appsv1.StatefulSet) error {
+	current, err := metav1.LabelSelectorAsSelector(existing.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(desired.Spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	pods := corev1.PodList{}
+	if err := r.client.List(context.TODO(), &pods, k8sClient.InNamespace(existing.Namespace), k8sClient.MatchingLabelsSelector{Selector: current}); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !metav1.IsControlledBy(pod, &existing) {
+			continue
+		}
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		for key, value := range desired.Spec.Selector.MatchLabels {
+			pod.Labels[key] = value
+		}
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			return errors.Errorf("Pod %s does not match the selector %s of the new StatefulSet", pod.Name, selector)
+		}
+		if err := r.client.Update(context.TODO(), pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
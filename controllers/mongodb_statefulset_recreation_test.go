@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// immutableSelectorClient rejects updates of the selector of the StatefulSets with the recorded selectors, as the
+// API server does. The selectors are recorded by UID, as the mocked client shares them with the stored objects.
+type immutableSelectorClient struct {
+	k8sClient.Client
+	selectors map[types.UID]metav1.LabelSelector
+}
+
+func (c immutableSelectorClient) Update(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.UpdateOption) error {
+	if sts, ok := obj.(*appsv1.StatefulSet); ok {
+		if selector, ok := c.selectors[sts.UID]; ok && !reflect.DeepEqual(&selector, sts.Spec.Selector) {
+			return apiErrors.NewInvalid(appsv1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind(), sts.Name, field.ErrorList{
+				field.Invalid(field.NewPath("spec", "selector"), sts.Spec.Selector, "field is immutable"),
+			})
+		}
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// setupLegacyStatefulSet deploys a replica set, and replaces the selector of its StatefulSet and the labels of the
+// Pods of its members with a label the operator does not set anymore.
+func setupLegacyStatefulSet(t *testing.T) (*ReplicaSetReconciler, k8sClient.Client, mdbv1.MongoDBCommunity) {
+	mdb := newTestReplicaSet()
+	c := client.NewMockedClient()
+	assert.NoError(t, c.Create(context.TODO(), &mdb))
+	selectors := map[types.UID]metav1.LabelSelector{}
+	r := NewReconciler(client.NewManagerWithClient(immutableSelectorClient{Client: c, selectors: selectors}))
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &sts))
+	sts.UID = "legacy-uid"
+	sts.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"legacy": mdb.Name}}
+	assert.NoError(t, c.Update(context.TODO(), &sts))
+	selectors[sts.UID] = *sts.Spec.Selector.DeepCopy()
+
+	for i := 0; i < mdb.Spec.Members; i++ {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            mdb.PodName(i),
+			Namespace:       mdb.Namespace,
+			Labels:          map[string]string{"legacy": mdb.Name},
+			Annotations:     map[string]string{"agent.mongodb.com/version": "1"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(&sts, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))},
+		}}
+		assert.NoError(t, c.Create(context.TODO(), &pod))
+	}
+	return r, c, mdb
+}
+
+func TestStatefulSet_IsRecreatedIfItsSelectorChanges(t *testing.T) {
+	r, c, mdb := setupLegacyStatefulSet(t)
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &sts))
+	assert.NotEqual(t, types.UID("legacy-uid"), sts.UID, "the StatefulSet has been recreated")
+	assert.Equal(t, mdb.ServiceName(), sts.Spec.Selector.MatchLabels["app"])
+
+	for i := 0; i < mdb.Spec.Members; i++ {
+		pod := corev1.Pod{}
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace}, &pod), "the Pods are not deleted")
+		for key, value := range sts.Spec.Selector.MatchLabels {
+			assert.Equal(t, value, pod.Labels[key], "the Pods are adopted by the new StatefulSet")
+		}
+		assert.Equal(t, mdb.Name, pod.Labels["legacy"])
+	}
+}
+
+func TestStatefulSet_IsNotUpdatedWhileBeingDeleted(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	now := metav1.Now()
+	sts.DeletionTimestamp = &now
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &sts))
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Members = 5
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	assert.Equal(t, int32(3), *sts.Spec.Replicas, "the StatefulSet being deleted is not updated")
+}
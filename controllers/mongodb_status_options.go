@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"time"
+
 	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/apierrors"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -112,6 +116,139 @@ func (o *optionBuilder) withStatefulSetReplicas(members int) *optionBuilder {
 	return o
 }
 
+func (o *optionBuilder) withConditions(conditions []metav1.Condition) *optionBuilder {
+	o.options = append(o.options, conditionsOption{
+		conditions: conditions,
+	})
+	return o
+}
+
+func (o *optionBuilder) withKeyfileRotation(rotation mdbv1.KeyfileRotationStatus) *optionBuilder {
+	o.options = append(o.options, keyfileRotationOption{
+		rotation: rotation,
+	})
+	return o
+}
+
+func (o *optionBuilder) withAuthenticationMigration(migration mdbv1.AuthenticationMigrationStatus) *optionBuilder {
+	o.options = append(o.options, authenticationMigrationOption{
+		migration: migration,
+	})
+	return o
+}
+
+func (o *optionBuilder) withCARotation(rotation mdbv1.CARotationStatus) *optionBuilder {
+	o.options = append(o.options, caRotationOption{
+		rotation: rotation,
+	})
+	return o
+}
+
+func (o *optionBuilder) withReplicaSetRename(rename mdbv1.ReplicaSetRenameStatus) *optionBuilder {
+	o.options = append(o.options, replicaSetRenameOption{
+		rename: rename,
+	})
+	return o
+}
+
+func (o *optionBuilder) withCredentialVerificationTime(verificationTime metav1.Time) *optionBuilder {
+	o.options = append(o.options, credentialVerificationTimeOption{
+		verificationTime: verificationTime,
+	})
+	return o
+}
+
+func (o *optionBuilder) withLastReconcile(lastReconcile mdbv1.LastReconcileStatus) *optionBuilder {
+	o.options = append(o.options, lastReconcileOption{
+		lastReconcile: lastReconcile,
+	})
+	return o
+}
+
+func (o *optionBuilder) withTLSCertificates(certificates *mdbv1.TLSCertificatesStatus) *optionBuilder {
+	o.options = append(o.options, tlsCertificatesOption{
+		certificates: certificates,
+	})
+	return o
+}
+
+func (o *optionBuilder) withPlannedOutage(outage *mdbv1.PlannedOutageStatus) *optionBuilder {
+	o.options = append(o.options, plannedOutageOption{
+		outage: outage,
+	})
+	return o
+}
+
+func (o *optionBuilder) withForceReconfig(reconfig *mdbv1.ForceReconfigStatus) *optionBuilder {
+	o.options = append(o.options, forceReconfigOption{
+		reconfig: reconfig,
+	})
+	return o
+}
+
+func (o *optionBuilder) withCanary(canary *mdbv1.CanaryStatus) *optionBuilder {
+	o.options = append(o.options, canaryOption{
+		canary: canary,
+	})
+	return o
+}
+
+func (o *optionBuilder) withProgress(progress *mdbv1.ProgressStatus) *optionBuilder {
+	o.options = append(o.options, progressOption{
+		progress: progress,
+	})
+	return o
+}
+
+func (o *optionBuilder) withConcurrentChange(change *mdbv1.ConcurrentChangeStatus) *optionBuilder {
+	o.options = append(o.options, concurrentChangeOption{
+		change: change,
+	})
+	return o
+}
+
+func (o *optionBuilder) withTLSMode(mode automationconfig.TLSMode) *optionBuilder {
+	o.options = append(o.options, tlsModeOption{
+		mode: mode,
+	})
+	return o
+}
+
+func (o *optionBuilder) withClusterAuthMode(mode automationconfig.ClusterAuthMode) *optionBuilder {
+	o.options = append(o.options, clusterAuthModeOption{
+		mode: mode,
+	})
+	return o
+}
+
+func (o *optionBuilder) withOnDeleteUpdateStrategy(onDelete *mdbv1.OnDeleteUpdateStrategyStatus) *optionBuilder {
+	o.options = append(o.options, onDeleteUpdateStrategyOption{
+		onDelete: onDelete,
+	})
+	return o
+}
+
+func (o *optionBuilder) withPendingRestarts(pending []string) *optionBuilder {
+	o.options = append(o.options, pendingRestartsOption{
+		pending: pending,
+	})
+	return o
+}
+
+func (o *optionBuilder) withExternalAddresses(addresses []mdbv1.ExternalAddress) *optionBuilder {
+	o.options = append(o.options, externalAddressesOption{
+		addresses: addresses,
+	})
+	return o
+}
+
+func (o *optionBuilder) withInitialization(initialization *mdbv1.InitializationStatus) *optionBuilder {
+	o.options = append(o.options, initializationOption{
+		initialization: initialization,
+	})
+	return o
+}
+
 func (o *optionBuilder) withMessage(severityLevel severity, msg string) *optionBuilder {
 	if apierrors.IsTransientMessage(msg) {
 		severityLevel = Debug
@@ -145,6 +282,11 @@ type phaseOption struct {
 
 func (p phaseOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
 	mdb.Status.Phase = p.phase
+	window, err := errorBudgetWindowFromEnv()
+	if err != nil {
+		window = defaultErrorBudgetWindow
+	}
+	mdb.Status.ErrorBudget = updateErrorBudget(mdb.Status.ErrorBudget, p.phase, time.Now(), window)
 }
 
 func (p phaseOption) GetResult() (reconcile.Result, error) {
@@ -183,3 +325,231 @@ func (s statefulSetReplicasOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
 func (s statefulSetReplicasOption) GetResult() (reconcile.Result, error) {
 	return result.OK()
 }
+
+type conditionsOption struct {
+	conditions []metav1.Condition
+}
+
+func (c conditionsOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.Conditions = c.conditions
+}
+
+func (c conditionsOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type keyfileRotationOption struct {
+	rotation mdbv1.KeyfileRotationStatus
+}
+
+func (k keyfileRotationOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.KeyfileRotation = &k.rotation
+}
+
+func (k keyfileRotationOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type authenticationMigrationOption struct {
+	migration mdbv1.AuthenticationMigrationStatus
+}
+
+func (a authenticationMigrationOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.AuthenticationMigration = &a.migration
+}
+
+func (a authenticationMigrationOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type caRotationOption struct {
+	rotation mdbv1.CARotationStatus
+}
+
+func (c caRotationOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.CARotation = &c.rotation
+}
+
+func (c caRotationOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type replicaSetRenameOption struct {
+	rename mdbv1.ReplicaSetRenameStatus
+}
+
+func (r replicaSetRenameOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.ReplicaSetRename = &r.rename
+}
+
+func (r replicaSetRenameOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type credentialVerificationTimeOption struct {
+	verificationTime metav1.Time
+}
+
+func (c credentialVerificationTimeOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.LastCredentialVerificationTime = &c.verificationTime
+}
+
+func (c credentialVerificationTimeOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type lastReconcileOption struct {
+	lastReconcile mdbv1.LastReconcileStatus
+}
+
+func (o lastReconcileOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.LastReconcile = &o.lastReconcile
+}
+
+func (o lastReconcileOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type onDeleteUpdateStrategyOption struct {
+	onDelete *mdbv1.OnDeleteUpdateStrategyStatus
+}
+
+func (o onDeleteUpdateStrategyOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.OnDeleteUpdateStrategy = o.onDelete
+}
+
+func (o onDeleteUpdateStrategyOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type pendingRestartsOption struct {
+	pending []string
+}
+
+func (o pendingRestartsOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.PendingRestarts = o.pending
+}
+
+func (o pendingRestartsOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type externalAddressesOption struct {
+	addresses []mdbv1.ExternalAddress
+}
+
+func (o externalAddressesOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.ExternalAddresses = o.addresses
+}
+
+func (o externalAddressesOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type initializationOption struct {
+	initialization *mdbv1.InitializationStatus
+}
+
+func (o initializationOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.Initialization = o.initialization
+}
+
+func (o initializationOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type plannedOutageOption struct {
+	outage *mdbv1.PlannedOutageStatus
+}
+
+func (o plannedOutageOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.PlannedOutage = o.outage
+}
+
+func (o plannedOutageOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type forceReconfigOption struct {
+	reconfig *mdbv1.ForceReconfigStatus
+}
+
+func (o forceReconfigOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.ForceReconfig = o.reconfig
+}
+
+func (o forceReconfigOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type canaryOption struct {
+	canary *mdbv1.CanaryStatus
+}
+
+func (o canaryOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.Canary = o.canary
+}
+
+func (o canaryOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type tlsCertificatesOption struct {
+	certificates *mdbv1.TLSCertificatesStatus
+}
+
+func (o tlsCertificatesOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.TLSCertificates = o.certificates
+}
+
+func (o tlsCertificatesOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type progressOption struct {
+	progress *mdbv1.ProgressStatus
+}
+
+func (o progressOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.Progress = o.progress
+}
+
+func (o progressOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type concurrentChangeOption struct {
+	change *mdbv1.ConcurrentChangeStatus
+}
+
+func (o concurrentChangeOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.ConcurrentChange = o.change
+}
+
+func (o concurrentChangeOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type tlsModeOption struct {
+	mode automationconfig.TLSMode
+}
+
+func (o tlsModeOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.TLSMode = o.mode
+}
+
+func (o tlsModeOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
+
+type clusterAuthModeOption struct {
+	mode automationconfig.ClusterAuthMode
+}
+
+func (o clusterAuthModeOption) ApplyOption(mdb *mdbv1.MongoDBCommunity) {
+	mdb.Status.ClusterAuthMode = o.mode
+}
+
+func (o clusterAuthModeOption) GetResult() (reconcile.Result, error) {
+	return result.OK()
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/validation"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	// StrictSpecValidationEnv enables the strict validation of the spec of all resources if set to "true".
+	StrictSpecValidationEnv = "STRICT_SPEC_VALIDATION"
+
+	// strictValidationAnnotation enables ("true") or disables ("false") the strict validation of the spec of
+	// a single resource, overriding StrictSpecValidationEnv.
+	strictValidationAnnotation = "mongodbcommunity.mongodb.com/strict-validation"
+)
+
+// strictValidationEnabled returns true if the spec of the resource is validated strictly.
+func strictValidationEnabled(mdb mdbv1.MongoDBCommunity) bool {
+	if value, ok := mdb.Annotations[strictValidationAnnotation]; ok {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			return enabled
+		}
+	}
+	return envvar.ReadBool(StrictSpecValidationEnv)
+}
+
+// validateStrict rejects the spec of the resource if strict validation is enabled and the spec, as stored in
+// the API server, contains unknown or deprecated fields. These are dropped when the spec is decoded, so the
+// resource is read again without decoding it into a MongoDBCommunity.
+func (r ReplicaSetReconciler) validateStrict(mdb mdbv1.MongoDBCommunity) error {
+	if !strictValidationEnabled(mdb) {
+		return nil
+	}
+
+	raw := unstructured.Unstructured{}
+	raw.SetGroupVersionKind(mdbv1.GroupVersion.WithKind("MongoDBCommunity"))
+	if err := r.apiReader.Get(context.TODO(), mdb.NamespacedName(), &raw); err != nil {
+		return errors.Errorf("could not read the spec: %s", err)
+	}
+	spec, _, err := unstructured.NestedMap(raw.Object, "spec")
+	if err != nil {
+		return errors.Errorf("could not read the spec: %s", err)
+	}
+	return validation.ValidateStrict(spec)
+}
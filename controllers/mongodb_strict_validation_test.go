@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// rawReader returns the given spec for any resource read as unstructured, as the API server does for fields
+// which are not part of the MongoDBCommunity type. Other objects are read from the wrapped reader.
+type rawReader struct {
+	k8sClient.Reader
+	spec map[string]interface{}
+}
+
+func (r rawReader) Get(ctx context.Context, key k8sClient.ObjectKey, obj k8sClient.Object) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.Object["spec"] = r.spec
+		return nil
+	}
+	return r.Reader.Get(ctx, key, obj)
+}
+
+func TestStrictValidation(t *testing.T) {
+	spec := map[string]interface{}{"members": int64(3), "member": int64(3), "version": "4.2.2"}
+
+	t.Run("Unknown fields are ignored by default", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		r := NewReconciler(client.NewManager(&mdb))
+		r.apiReader = rawReader{Reader: r.apiReader, spec: spec}
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+	})
+
+	t.Run("Unknown fields fail the reconciliation in strict mode", func(t *testing.T) {
+		os.Setenv(StrictSpecValidationEnv, "true")
+		defer os.Unsetenv(StrictSpecValidationEnv)
+
+		mdb := newTestReplicaSet()
+		mgr := client.NewManager(&mdb)
+		r := NewReconciler(mgr)
+		r.apiReader = rawReader{Reader: r.apiReader, spec: spec}
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Equal(t, "error validating new Spec: unknown fields spec.member", mdb.Status.Message)
+	})
+
+	t.Run("The annotation overrides the operator setting", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mdb.Annotations = map[string]string{strictValidationAnnotation: "true"}
+		assert.True(t, strictValidationEnabled(mdb))
+
+		os.Setenv(StrictSpecValidationEnv, "true")
+		defer os.Unsetenv(StrictSpecValidationEnv)
+		mdb.Annotations[strictValidationAnnotation] = "false"
+		assert.False(t, strictValidationEnabled(mdb))
+	})
+}
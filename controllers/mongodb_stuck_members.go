@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	defaultStuckMemberThreshold         = 30 * time.Minute
+	defaultStuckMemberMaxReplicationLag = time.Hour
+
+	// stuckMemberInterval is how often the members are checked while stuck member remediation is enabled.
+	stuckMemberInterval = time.Minute
+
+	// stuckMemberLaggingState is the state reported for a secondary which is too far behind the primary.
+	stuckMemberLaggingState = "Lagging"
+)
+
+// +kubebuilder:rbac:groups=core,resources=pods;persistentvolumeclaims,verbs=delete
+
+// validateStuckMemberRemediation checks that the threshold and the maximum replication lag are valid durations.
+func validateStuckMemberRemediation(mdb mdbv1.MongoDBCommunity) error {
+	_, _, err := stuckMemberSettings(mdb)
+	return err
+}
+
+// stuckMemberSettings returns how long a member may be stuck, and how far behind the primary a secondary may be.
+func stuckMemberSettings(mdb mdbv1.MongoDBCommunity) (time.Duration, time.Duration, error) {
+	remediation := mdb.Spec.StuckMemberRemediation
+	if remediation == nil {
+		return 0, 0, nil
+	}
+	threshold, err := parsePositiveDuration(remediation.Threshold, defaultStuckMemberThreshold)
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid stuckMemberRemediation.threshold: %s", err)
+	}
+	maxLag, err := parsePositiveDuration(remediation.MaxReplicationLag, defaultStuckMemberMaxReplicationLag)
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid stuckMemberRemediation.maxReplicationLag: %s", err)
+	}
+	return threshold, maxLag, nil
+}
+
+// remediateStuckMembers reports the members which are stuck in the status, and records an event once a member has
+// been stuck for longer than the threshold. If resync is enabled, such a member is then resynced, one member at a
+// time unless the Pods of lost members are recovered in parallel. The stuck members are kept as they are if the
+// replica set status can not be read.
+func (r *ReplicaSetReconciler) remediateStuckMembers(mdb *mdbv1.MongoDBCommunity, now time.Time) {
+	threshold, maxLag, err := stuckMemberSettings(*mdb)
+	if err != nil || mdb.Spec.StuckMemberRemediation == nil {
+		mdb.Status.StuckMembers = nil
+		return
+	}
+
+	members := mdb.AutomationConfigMembersThisReconciliation()
+	if mdb.Spec.StuckMemberRemediation.Resync {
+		r.recreatePodsOfDeletedClaims(*mdb, members)
+	}
+	status, hostnames, err := r.memberReplicationStatus(*mdb, members)
+	if err != nil {
+		r.log.Debugf("Could not read the replica set status to detect stuck members: %s", err)
+		return
+	}
+
+	previous := map[string]mdbv1.StuckMemberStatus{}
+	for _, stuck := range mdb.Status.StuckMembers {
+		previous[stuck.Name] = stuck
+	}
+
+	var stuckMembers []mdbv1.StuckMemberStatus
+	var resyncing []string
+	for i := 0; i < members; i++ {
+		name := net.JoinHostPort(hostnames[i], "27017")
+		state, ok := stuckMemberState(*status, name, maxLag)
+		if !ok {
+			continue
+		}
+		stuck := mdbv1.StuckMemberStatus{Name: mdb.PodName(i), State: state, Since: metav1.NewTime(now)}
+		if p, ok := previous[stuck.Name]; ok && p.State == state {
+			stuck = p
+		}
+
+		if now.Sub(stuck.Since.Time) >= threshold {
+			if !stuck.ThresholdExceeded {
+				stuck.ThresholdExceeded = true
+				r.recordStuckMemberEvent(mdb, corev1.EventTypeWarning, "MemberStuck", fmt.Sprintf("Member %s has been %s since %s",
+					stuck.Name, state, stuck.Since.UTC().Format(time.RFC3339)))
+			}
+			mayResyncAnother := len(resyncing) == 0 || mdb.Spec.PodRecovery.IsParallel()
+			if mdb.Spec.StuckMemberRemediation.Resync && mayResyncAnother && r.mayResync(*mdb, *status, name, resyncing) {
+				resyncing = append(resyncing, name)
+				if err := r.resyncMember(*mdb, stuck.Name); err != nil {
+					r.log.Warnf("Could not resync member %s: %s", stuck.Name, err)
+					r.recordStuckMemberEvent(mdb, corev1.EventTypeWarning, "MemberResyncFailed", fmt.Sprintf("Could not resync member %s: %s", stuck.Name, err))
+				} else {
+					r.log.Infof("Resyncing member %s, which has been %s since %s", stuck.Name, state, stuck.Since.UTC().Format(time.RFC3339))
+					r.recordStuckMemberEvent(mdb, corev1.EventTypeNormal, "MemberResynced", fmt.Sprintf("Deleted the data and the Pod of member %s to rebuild it with an initial sync", stuck.Name))
+					continue
+				}
+			}
+		}
+		stuckMembers = append(stuckMembers, stuck)
+	}
+	mdb.Status.StuckMembers = stuckMembers
+}
+
+// stuckMemberState returns the state of the member with the given name if it is stuck: if it is RECOVERING or in
+// ROLLBACK, or if it is a secondary which is more than the maximum replication lag behind the primary.
+func stuckMemberState(status replication.Status, name string, maxLag time.Duration) (string, bool) {
+	member, ok := status.Member(name)
+	if !ok || member.Health != 1 {
+		return "", false
+	}
+	switch member.StateStr {
+	case replication.StateRecovering, replication.StateRollback:
+		return member.StateStr, true
+	}
+	if lag, ok := replicationLag(status, name); ok && lag > maxLag {
+		return stuckMemberLaggingState, true
+	}
+	return "", false
+}
+
+// mayResync returns true if the member with the given name may be resynced in addition to the members being
+// resynced: the replica set must have a primary, and the members may be restarted. Every other member must be a
+// healthy primary or secondary, or, if the Pods of lost members are recovered in parallel, a majority of the
+// members must remain a healthy primary or secondary.
+func (r *ReplicaSetReconciler) mayResync(mdb mdbv1.MongoDBCommunity, status replication.Status, name string, resyncing []string) bool {
+	if _, ok := status.Primary(); !ok {
+		r.log.Infof("Not resyncing member %s while the replica set has no primary", name)
+		return false
+	}
+	healthy := 0
+	for _, member := range status.Members {
+		if member.Name == name || contains.String(resyncing, member.Name) {
+			continue
+		}
+		if member.Health == 1 && (member.StateStr == replication.StatePrimary || member.StateStr == replication.StateSecondary) {
+			healthy++
+			continue
+		}
+		if !mdb.Spec.PodRecovery.IsParallel() {
+			r.log.Infof("Not resyncing member %s while member %s is %s", name, member.Name, member.StateStr)
+			return false
+		}
+	}
+	if mdb.Spec.PodRecovery.IsParallel() && healthy < len(status.Members)/2+1 {
+		r.log.Infof("Not resyncing member %s, as fewer than a majority of the members would remain healthy", name)
+		return false
+	}
+	if !r.restartsGated(mdb) {
+		return true
+	}
+	mayRestart, err := r.mayRestartMembers(mdb)
+	if err != nil {
+		r.log.Warnf("Could not determine whether member %s may be resynced: %s", name, err)
+	}
+	if !mayRestart {
+		r.log.Infof("Not resyncing member %s until the members may be restarted", name)
+	}
+	return mayRestart
+}
+
+// resyncMember deletes the PersistentVolumeClaims and the Pod of the member with the given name. The StatefulSet
+// recreates both, and the member rebuilds its data with an initial sync from the other members. The claims are
+// only removed once the Pod is gone.
+func (r *ReplicaSetReconciler) resyncMember(mdb mdbv1.MongoDBCommunity, podName string) error {
+	sts, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	if err != nil {
+		return errors.Errorf("could not get the StatefulSet: %s", err)
+	}
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		pvc := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s", template.Name, podName), Namespace: mdb.Namespace}}
+		if err := r.client.Delete(context.TODO(), &pvc); err != nil && !apiErrors.IsNotFound(err) {
+			return errors.Errorf("could not delete PersistentVolumeClaim %s: %s", pvc.Name, err)
+		}
+	}
+	pod, err := r.client.GetPod(types.NamespacedName{Name: podName, Namespace: mdb.Namespace})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Errorf("could not get Pod %s: %s", podName, err)
+	}
+	if err := r.client.Delete(context.TODO(), &pod); err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Errorf("could not delete Pod %s: %s", podName, err)
+	}
+	return nil
+}
+
+// recreatePodsOfDeletedClaims deletes the unscheduled Pods of the first n members whose PersistentVolumeClaims
+// have been deleted. The StatefulSet can recreate the Pod of a resynced member before its claims are removed, and
+// only creates the claims of a Pod when it creates the Pod.
+func (r *ReplicaSetReconciler) recreatePodsOfDeletedClaims(mdb mdbv1.MongoDBCommunity, n int) {
+	for i := 0; i < n; i++ {
+		pod, err := r.client.GetPod(types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace})
+		if err != nil || pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName != "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil {
+				continue
+			}
+			pvc := corev1.PersistentVolumeClaim{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: volume.PersistentVolumeClaim.ClaimName, Namespace: mdb.Namespace}, &pvc)
+			if err == nil && pvc.DeletionTimestamp == nil || err != nil && !apiErrors.IsNotFound(err) {
+				continue
+			}
+			r.log.Infof("Deleting Pod %s, whose PersistentVolumeClaim %s has been deleted", pod.Name, volume.PersistentVolumeClaim.ClaimName)
+			if err := r.client.Delete(context.TODO(), &pod); err != nil && !apiErrors.IsNotFound(err) {
+				r.log.Warnf("Could not delete Pod %s: %s", pod.Name, err)
+			}
+			break
+		}
+	}
+}
+
+func (r *ReplicaSetReconciler) recordStuckMemberEvent(mdb *mdbv1.MongoDBCommunity, eventType, reason, message string) {
+	if r.recorder != nil {
+		r.recorder.Event(mdb, eventType, reason, message)
+	}
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// setupStuckMembers deploys the given replica set whose last member is RECOVERING, and creates the Pods and the
+// PersistentVolumeClaims of the members.
+func setupStuckMembers(t *testing.T, mdb mdbv1.MongoDBCommunity, remediation mdbv1.StuckMemberRemediation) (*ReplicaSetReconciler, *client.MockedManager, *mockStatusReader, *record.FakeRecorder, mdbv1.MongoDBCommunity) {
+	mdb.Spec.StuckMemberRemediation = &remediation
+	mgr := client.NewManager(&mdb)
+	var zones []string
+	for i := 0; i < mdb.Spec.Members; i++ {
+		zones = append(zones, fmt.Sprintf("zone-%d", i))
+	}
+	createPodsInZones(t, mgr.GetClient(), mdb, zones...)
+	for i := 0; i < mdb.Spec.Members; i++ {
+		for _, volume := range []string{"data-volume", "logs-volume"} {
+			pvc := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: volume + "-" + mdb.PodName(i), Namespace: mdb.Namespace}}
+			assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pvc))
+		}
+	}
+
+	r := NewReconciler(mgr)
+	reader := &mockStatusReader{status: replicationStatus(mdb, mdb.Spec.Members, nil)}
+	reader.status.Members[mdb.Spec.Members-1].StateStr = "RECOVERING"
+	r.replicationStatus = reader
+	for i := 0; i < 2; i++ {
+		// the replica set status can only be read once the automation config has been published.
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r.recorder = recorder
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	return r, mgr, reader, recorder, mdb
+}
+
+func podAndClaimExist(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, i int) (bool, bool) {
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace}, &corev1.Pod{})
+	podExists := !apiErrors.IsNotFound(err)
+	err = mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "data-volume-" + mdb.PodName(i), Namespace: mdb.Namespace}, &corev1.PersistentVolumeClaim{})
+	return podExists, !apiErrors.IsNotFound(err)
+}
+
+func TestStuckMembers_AreReportedOnceTheThresholdIsExceeded(t *testing.T) {
+	r, mgr, reader, recorder, mdb := setupStuckMembers(t, newTestReplicaSet(), mdbv1.StuckMemberRemediation{Threshold: "10m"})
+	if assert.Len(t, mdb.Status.StuckMembers, 1) {
+		assert.Equal(t, "my-rs-2", mdb.Status.StuckMembers[0].Name)
+		assert.Equal(t, "RECOVERING", mdb.Status.StuckMembers[0].State)
+		assert.False(t, mdb.Status.StuckMembers[0].ThresholdExceeded)
+	}
+	since := mdb.Status.StuckMembers[0].Since
+
+	r.remediateStuckMembers(&mdb, since.Add(11*time.Minute))
+	assert.True(t, mdb.Status.StuckMembers[0].ThresholdExceeded)
+	assert.True(t, since.Equal(&mdb.Status.StuckMembers[0].Since))
+	assert.Contains(t, <-recorder.Events, "Warning MemberStuck Member my-rs-2 has been RECOVERING since")
+	podExists, pvcExists := podAndClaimExist(t, mgr, mdb, 2)
+	assert.True(t, podExists)
+	assert.True(t, pvcExists, "members are not resynced unless enabled")
+
+	r.remediateStuckMembers(&mdb, since.Add(12*time.Minute))
+	assert.Empty(t, recorder.Events, "a stuck member is only reported once")
+
+	t.Run("A member which falls too far behind the primary is stuck", func(t *testing.T) {
+		reader.status = replicationStatus(mdb, 3, map[int]time.Duration{1: 2 * time.Hour})
+		r.remediateStuckMembers(&mdb, since.Add(13*time.Minute))
+		if assert.Len(t, mdb.Status.StuckMembers, 1) {
+			assert.Equal(t, "my-rs-1", mdb.Status.StuckMembers[0].Name)
+			assert.Equal(t, stuckMemberLaggingState, mdb.Status.StuckMembers[0].State)
+		}
+	})
+
+	t.Run("The stuck members are removed when remediation is disabled", func(t *testing.T) {
+		mdb.Spec.StuckMemberRemediation = nil
+		r.remediateStuckMembers(&mdb, since.Add(14*time.Minute))
+		assert.Empty(t, mdb.Status.StuckMembers)
+	})
+}
+
+func TestStuckMembers_AreResyncedIfEnabled(t *testing.T) {
+	r, mgr, reader, recorder, mdb := setupStuckMembers(t, newTestReplicaSet(), mdbv1.StuckMemberRemediation{Threshold: "10m", Resync: true})
+	since := mdb.Status.StuckMembers[0].Since
+
+	reader.status.Members[1].StateStr = "STARTUP2"
+	r.remediateStuckMembers(&mdb, since.Add(11*time.Minute))
+	<-recorder.Events
+	podExists, pvcExists := podAndClaimExist(t, mgr, mdb, 2)
+	assert.True(t, podExists && pvcExists, "the member is not resynced while another member is not healthy")
+
+	reader.status.Members[1].StateStr = "SECONDARY"
+	r.remediateStuckMembers(&mdb, since.Add(12*time.Minute))
+	assert.Contains(t, <-recorder.Events, "Normal MemberResynced Deleted the data and the Pod of member my-rs-2")
+	podExists, pvcExists = podAndClaimExist(t, mgr, mdb, 2)
+	assert.False(t, podExists)
+	assert.False(t, pvcExists)
+	assert.Empty(t, mdb.Status.StuckMembers)
+	podExists, pvcExists = podAndClaimExist(t, mgr, mdb, 1)
+	assert.True(t, podExists && pvcExists)
+}
+
+func TestStuckMembers_AreResyncedWithAPodHostnamePrefix(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.PodHostnamePrefix = "legacy-db"
+	r, mgr, _, recorder, mdb := setupStuckMembers(t, mdb, mdbv1.StuckMemberRemediation{Threshold: "10m", Resync: true})
+	since := mdb.Status.StuckMembers[0].Since
+
+	r.remediateStuckMembers(&mdb, since.Add(11*time.Minute))
+	assert.Contains(t, <-recorder.Events, "Warning MemberStuck Member legacy-db-2 has been RECOVERING since")
+	assert.Contains(t, <-recorder.Events, "Normal MemberResynced Deleted the data and the Pod of member legacy-db-2")
+	podExists, pvcExists := podAndClaimExist(t, mgr, mdb, 2)
+	assert.False(t, podExists)
+	assert.False(t, pvcExists)
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "logs-volume-legacy-db-2", Namespace: mdb.Namespace}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, apiErrors.IsNotFound(err))
+}
+
+func TestRecreatePodsOfDeletedClaims(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	for i := 0; i < 2; i++ {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(i), Namespace: mdb.Namespace},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "data-volume",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-volume-" + mdb.PodName(i)}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	}
+	pvc := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-volume-" + mdb.PodName(0), Namespace: mdb.Namespace}}
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pvc))
+
+	r.recreatePodsOfDeletedClaims(mdb, 2)
+	podExists, _ := podAndClaimExist(t, mgr, mdb, 0)
+	assert.True(t, podExists)
+	podExists, _ = podAndClaimExist(t, mgr, mdb, 1)
+	assert.False(t, podExists, "the Pod whose claim has been deleted is recreated")
+}
+
+func TestValidateStuckMemberRemediation(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateStuckMemberRemediation(mdb))
+
+	mdb.Spec.StuckMemberRemediation = &mdbv1.StuckMemberRemediation{}
+	threshold, maxLag, err := stuckMemberSettings(mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultStuckMemberThreshold, threshold)
+	assert.Equal(t, defaultStuckMemberMaxReplicationLag, maxLag)
+
+	mdb.Spec.StuckMemberRemediation.Threshold = "0s"
+	assert.EqualError(t, validateStuckMemberRemediation(mdb), `invalid stuckMemberRemediation.threshold: "0s" must be positive`)
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+
+	"github.com/stretchr/objx"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	defaultLogRotateTimeThresholdHours = 24
+	maxLogVerbosity                    = 5
+)
+
+// logComponents are the components of mongod whose verbosity can be configured in systemLog.components.
+var logComponents = map[string]bool{
+	"accessControl":           true,
+	"command":                 true,
+	"control":                 true,
+	"ftdc":                    true,
+	"geo":                     true,
+	"index":                   true,
+	"network":                 true,
+	"query":                   true,
+	"recovery":                true,
+	"replication":             true,
+	"replication.election":    true,
+	"replication.heartbeats":  true,
+	"replication.initialSync": true,
+	"replication.rollback":    true,
+	"sharding":                true,
+	"storage":                 true,
+	"storage.journal":         true,
+	"storage.recovery":        true,
+	"transaction":             true,
+	"write":                   true,
+}
+
+// validateSystemLog checks the log configuration in spec.systemLog.
+func validateSystemLog(mdb mdbv1.MongoDBCommunity) error {
+	systemLog := mdb.Spec.SystemLog
+	if systemLog == nil {
+		return nil
+	}
+	if objx.New(mdb.Spec.AdditionalMongodConfig.Object).Has("systemLog") {
+		return errors.New("systemLog can not be combined with systemLog in additionalMongodConfig")
+	}
+	if systemLog.Verbosity != nil && (*systemLog.Verbosity < 0 || *systemLog.Verbosity > maxLogVerbosity) {
+		return errors.Errorf("systemLog.verbosity must be between 0 and %d, got %d", maxLogVerbosity, *systemLog.Verbosity)
+	}
+	for component, verbosity := range systemLog.Components {
+		if !logComponents[component] {
+			return errors.Errorf("systemLog.components: unknown component %q", component)
+		}
+		if verbosity < 0 || verbosity > maxLogVerbosity {
+			return errors.Errorf("systemLog.components: the verbosity of %s must be between 0 and %d, got %d", component, maxLogVerbosity, verbosity)
+		}
+	}
+	return nil
+}
+
+// systemLogModification configures the log of mongod and the rotation of its log files as specified in
+// spec.systemLog.
+func systemLogModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	systemLog := mdb.Spec.SystemLog
+	if systemLog == nil {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			process := &config.Processes[i]
+			if systemLog.GetDestination() == mdbv1.SystemLogDestinationStdout {
+				// without a destination, mongod writes its log to the standard output.
+				delete(process.Args26, "systemLog")
+			} else if systemLog.LogRotate != nil {
+				process.LogRotate = &automationconfig.LogRotate{
+					SizeThresholdMB:  systemLog.LogRotate.MaxLogSizeMB,
+					TimeThresholdHrs: logRotateTimeThresholdHours(*systemLog.LogRotate),
+					NumUncompressed:  systemLog.LogRotate.NumUncompressed,
+					NumTotal:         systemLog.LogRotate.NumTotal,
+				}
+			}
+			if systemLog.Verbosity != nil {
+				process.SetArgs26Field("systemLog.verbosity", *systemLog.Verbosity)
+			}
+			for component, verbosity := range systemLog.Components {
+				process.SetArgs26Field(fmt.Sprintf("systemLog.component.%s.verbosity", component), verbosity)
+			}
+		}
+	}
+}
+
+// buildSystemLogPodSpecModification configures the agent to rotate its own log files as specified in
+// spec.systemLog.logRotate.
+func buildSystemLogPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	if mdb.Spec.SystemLog == nil || mdb.Spec.SystemLog.LogRotate == nil {
+		return podtemplatespec.NOOP()
+	}
+	logRotate := *mdb.Spec.SystemLog.LogRotate
+	return podtemplatespec.WithContainer(construct.AgentName, container.WithCommand(construct.AutomationAgentCommand(
+		fmt.Sprintf("-maxLogFileSize=%d", logRotate.MaxLogSizeMB*1024*1024),
+		fmt.Sprintf("-maxLogFileDurationHrs=%d", logRotateTimeThresholdHours(logRotate)),
+	)))
+}
+
+func logRotateTimeThresholdHours(logRotate mdbv1.LogRotate) int {
+	if logRotate.TimeThresholdHours == 0 {
+		return defaultLogRotateTimeThresholdHours
+	}
+	return logRotate.TimeThresholdHours
+}
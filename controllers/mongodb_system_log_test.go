@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
+)
+
+func TestValidateSystemLog(t *testing.T) {
+	newReplicaSet := func(systemLog mdbv1.SystemLog) mdbv1.MongoDBCommunity {
+		mdb := newTestReplicaSet()
+		mdb.Spec.SystemLog = &systemLog
+		return mdb
+	}
+	verbosity := func(v int) *int { return &v }
+
+	t.Run("Valid configuration", func(t *testing.T) {
+		assert.NoError(t, validateSystemLog(newReplicaSet(mdbv1.SystemLog{Verbosity: verbosity(1), Components: map[string]int{"replication.election": 2}})))
+	})
+	t.Run("System log is also configured in additionalMongodConfig", func(t *testing.T) {
+		mdb := newReplicaSet(mdbv1.SystemLog{Destination: "stdout"})
+		mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{"systemLog": map[string]interface{}{"verbosity": 1}}
+		assert.EqualError(t, validateSystemLog(mdb), "systemLog can not be combined with systemLog in additionalMongodConfig")
+	})
+	t.Run("Verbosity is out of range", func(t *testing.T) {
+		assert.EqualError(t, validateSystemLog(newReplicaSet(mdbv1.SystemLog{Verbosity: verbosity(6)})), "systemLog.verbosity must be between 0 and 5, got 6")
+		assert.Error(t, validateSystemLog(newReplicaSet(mdbv1.SystemLog{Components: map[string]int{"query": -1}})))
+	})
+	t.Run("Component is not known", func(t *testing.T) {
+		assert.EqualError(t, validateSystemLog(newReplicaSet(mdbv1.SystemLog{Components: map[string]int{"replica": 1}})), `systemLog.components: unknown component "replica"`)
+	})
+}
+
+func TestSystemLog_IsConfigured(t *testing.T) {
+	mdb := newTestReplicaSet()
+	verbosity := 1
+	mdb.Spec.SystemLog = &mdbv1.SystemLog{
+		Verbosity:  &verbosity,
+		Components: map[string]int{"replication.heartbeats": 2},
+		LogRotate:  &mdbv1.LogRotate{MaxLogSizeMB: 100, NumTotal: 10},
+	}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, p := range ac.Processes {
+		assert.Equal(t, "file", p.Args26.Get("systemLog.destination").Str())
+		assert.Equal(t, float64(1), p.Args26.Get("systemLog.verbosity").Data())
+		assert.Equal(t, float64(2), p.Args26.Get("systemLog.component.replication.heartbeats.verbosity").Data())
+		assert.Equal(t, &automationconfig.LogRotate{SizeThresholdMB: 100, TimeThresholdHrs: 24, NumTotal: 10}, p.LogRotate)
+	}
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+	agent := container.GetByName(construct.AgentName, sts.Spec.Template.Spec.Containers)
+	assert.Contains(t, agent.Command[2], "-maxLogFileSize=104857600 -maxLogFileDurationHrs=24")
+
+	t.Run("mongod writes its log to the standard output", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.SystemLog = &mdbv1.SystemLog{Destination: mdbv1.SystemLogDestinationStdout}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		for _, p := range ac.Processes {
+			assert.False(t, p.Args26.Has("systemLog"))
+			assert.Nil(t, p.LogRotate)
+		}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &sts))
+		agent := container.GetByName(construct.AgentName, sts.Spec.Template.Spec.Containers)
+		assert.NotContains(t, agent.Command[2], "-maxLogFileSize")
+	})
+}
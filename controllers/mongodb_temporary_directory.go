@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// unixDomainSocketPathPrefix is the mongod option setting the directory of the UNIX domain socket, which
+// defaults to /tmp.
+const unixDomainSocketPathPrefix = "net.unixDomainSocket.pathPrefix"
+
+// validateWritablePaths checks that the StatefulSet of the resource, including its StatefulSet configuration,
+// mounts writable volumes at all paths the members write to.
+func validateWritablePaths(mdb mdbv1.MongoDBCommunity) error {
+	sts, err := buildStatefulSet(mdb)
+	if err != nil {
+		return err
+	}
+	return construct.ValidateWritablePaths(&mdb, sts.Spec.Template)
+}
+
+// temporaryDirectoryModification creates the UNIX domain socket of mongod in the temporary directory, unless
+// its directory is set in the additional mongod configuration. The socket is left in /tmp, the default of
+// mongod, if that is the temporary directory, so that existing members are not restarted.
+func temporaryDirectoryModification(mdb mdbv1.MongoDBCommunity) automationconfig.Modification {
+	if mdb.TemporaryDirectory() == "/tmp" {
+		return automationconfig.NOOP()
+	}
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.Processes {
+			args := config.Processes[i].Args26
+			if !args.Has(unixDomainSocketPathPrefix) {
+				args.Set(unixDomainSocketPathPrefix, mdb.TemporaryDirectory())
+			}
+		}
+	}
+}
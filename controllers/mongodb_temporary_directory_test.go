@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestTemporaryDirectory_IsUsedForTheUnixDomainSocket(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.TemporaryDirectory.Path = "/scratch"
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, p := range ac.Processes {
+		assert.Equal(t, "/scratch", p.Args26.Get(unixDomainSocketPathPrefix).Str())
+	}
+
+	t.Run("The default temporary directory does not change the automation config", func(t *testing.T) {
+		mdb := newTestReplicaSet()
+		mgr := client.NewManager(&mdb)
+		res, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assertReconciliationSuccessful(t, res, err)
+
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		for _, p := range ac.Processes {
+			assert.False(t, p.Args26.Has(unixDomainSocketPathPrefix))
+		}
+	})
+}
+
+func TestTemporaryDirectory_ReadOnlyOverrideIsRejected(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec = appsv1.StatefulSetSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:         construct.MongodbName,
+					VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp", ReadOnly: true}},
+				}},
+			},
+		},
+	}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Equal(t, "error validating new Spec: container mongod writes to /tmp, which is on the read-only volume tmp", mdb.Status.Message)
+
+	_, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.Error(t, err, "the StatefulSet is not created")
+}
@@ -2,13 +2,19 @@ package controllers
 
 import (
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+	"github.com/stretchr/objx"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
 
@@ -16,8 +22,13 @@ import (
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
 
+	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
 )
@@ -28,30 +39,56 @@ const (
 	tlsOperatorSecretMountPath = "/var/lib/tls/server/" //nolint
 	tlsSecretCertName          = "tls.crt"              //nolint
 	tlsSecretKeyName           = "tls.key"
+	tlsSecretPEMName           = "tls.pem"
+	tlsCAVolumeName            = "tls-ca"
+
+	// opensslCipherConfigParameter is the server parameter which configures the cipher suites of TLS 1.2 and older.
+	opensslCipherConfigParameter = "opensslCipherConfig"
+
+	// tlsCertificateHashAnnotation is set on the Pod template to the hash of the TLS certificate. Renewing the
+	// certificate changes the Pod template, so the StatefulSet controller restarts the members one at a time.
+	tlsCertificateHashAnnotation = "mongodb.com/v1.tlsCertificateHash"
+
+	tlsCertificateValidReason          = "Valid"
+	tlsCertificateRollingRestartReason = "RollingRestart"
+	tlsCertificateExpiredReason        = "Expired"
+	tlsCertificateInvalidReason        = "Invalid"
 )
 
-// validateTLSConfig will check that the configured ConfigMap and Secret exist and that they have the correct fields.
+// validateTLSConfig will check that the configured CA ConfigMap or Secret and the certificate Secret exist and that
+// they have the correct fields.
 func (r *ReplicaSetReconciler) validateTLSConfig(mdb mdbv1.MongoDBCommunity) (bool, error) {
-	if !mdb.Spec.Security.TLS.Enabled {
+	tls := mdb.Spec.Security.TLS
+	if !tls.Enabled {
+		if tls.MinimumTLSVersion != "" || len(tls.CipherSuites) > 0 {
+			return false, errors.New("minimumTLSVersion and cipherSuites can only be set if TLS is enabled")
+		}
 		return true, nil
 	}
 
 	r.log.Info("Ensuring TLS is correctly configured")
 
-	// Ensure CA ConfigMap exists
-	caData, err := configmap.ReadData(r.client, mdb.TLSConfigMapNamespacedName())
+	if tls.HasCaCertificateSecret() && tls.CaConfigMap.Name != "" {
+		return false, errors.New("only one of caConfigMapRef and caCertificateSecretRef can be set")
+	}
+	if err := validateTLSProtocolSettings(mdb); err != nil {
+		return false, err
+	}
+
+	// Ensure the CA ConfigMap or Secret exists
+	caData, err := readCAData(r.client, tls, mdb.Namespace)
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
-			r.log.Warnf(`CA ConfigMap "%s" not found`, mdb.TLSConfigMapNamespacedName())
+			r.log.Warnf(`CA %s not found`, caReference(tls))
 			return false, nil
 		}
 
 		return false, err
 	}
 
-	// Ensure ConfigMap has a "ca.crt" field
-	if cert, ok := caData[tlsCACertName]; !ok || cert == "" {
-		r.log.Warnf(`ConfigMap "%s" should have a CA certificate in field "%s"`, mdb.TLSConfigMapNamespacedName(), tlsCACertName)
+	// Ensure the CA ConfigMap or Secret has a "ca.crt" field, or the configured custom field
+	if cert, ok := caData[tls.GetCaCertificateKey()]; !ok || cert == "" {
+		r.log.Warnf(`CA %s should have a CA certificate in field "%s"`, caReference(tls), tls.GetCaCertificateKey())
 		return false, nil
 	}
 
@@ -66,23 +103,79 @@ func (r *ReplicaSetReconciler) validateTLSConfig(mdb mdbv1.MongoDBCommunity) (bo
 		return false, err
 	}
 
-	// Ensure Secret has "tls.crt" and "tls.key" fields
-	if key, ok := secretData[tlsSecretKeyName]; !ok || key == "" {
-		r.log.Warnf(`Secret "%s" should have a key in field "%s"`, mdb.TLSSecretNamespacedName(), tlsSecretKeyName)
+	// Ensure Secret has "tls.crt" and "tls.key" fields, or a combined PEM file
+	cert, _, err := certificateAndKeyFromData(secretData, tls.CertificatePEMKey)
+	if err != nil {
+		r.log.Warnf(`Secret "%s" is not a valid certificate Secret: %s`, mdb.TLSSecretNamespacedName(), err)
 		return false, nil
 	}
-	if cert, ok := secretData[tlsSecretCertName]; !ok || cert == "" {
-		r.log.Warnf(`Secret "%s" should have a certificate in field "%s"`, mdb.TLSSecretNamespacedName(), tlsSecretKeyName)
-		return false, nil
+
+	// The hostnames depend on the Pod subdomain, hostname prefix and hostname template, so a certificate
+	// issued before one of them was set may no longer match.
+	if hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName)); err == nil {
+		if missing := certificateMissingHostnames(cert, hostnames); len(missing) > 0 {
+			r.log.Warnf(`The certificate in Secret "%s" is not valid for the member hostnames %s`, mdb.TLSSecretNamespacedName(), strings.Join(missing, ", "))
+		}
+	}
+
+	if valid, err := r.validateMemberCertificate(mdb); !valid || err != nil {
+		return valid, err
+	}
+
+	if valid, err := r.validateOperatorClientTLS(mdb); !valid || err != nil {
+		return valid, err
 	}
 
 	// Watch certificate-key secret to handle rotations
 	r.secretWatcher.Watch(mdb.TLSSecretNamespacedName(), mdb.NamespacedName())
+	if tls.HasCaCertificateSecret() {
+		r.secretWatcher.Watch(mdb.TLSCASecretNamespacedName(), mdb.NamespacedName())
+	}
 
 	r.log.Infof("Successfully validated TLS config")
 	return true, nil
 }
 
+// caGetter can read the CA certificate from either a ConfigMap or a Secret.
+type caGetter interface {
+	configmap.Getter
+	secret.Getter
+}
+
+// readCAData reads the data of the ConfigMap or Secret containing the CA certificate.
+func readCAData(getter caGetter, tls mdbv1.TLS, namespace string) (map[string]string, error) {
+	if tls.HasCaCertificateSecret() {
+		return secret.ReadStringData(getter, types.NamespacedName{Name: tls.CaCertificateSecret.Name, Namespace: namespace})
+	}
+	return configmap.ReadData(getter, types.NamespacedName{Name: tls.CaConfigMap.Name, Namespace: namespace})
+}
+
+// readCA reads the CA certificate from the ConfigMap or Secret configured in the given TLS configuration.
+func readCA(getter caGetter, tls mdbv1.TLS, namespace string) (string, error) {
+	data, err := readCAData(getter, tls, namespace)
+	if err != nil {
+		return "", err
+	}
+	ca, ok := data[tls.GetCaCertificateKey()]
+	if !ok {
+		return "", errors.Errorf(`key "%s" not present in CA %s`, tls.GetCaCertificateKey(), caReference(tls))
+	}
+	return ca, nil
+}
+
+// caReference identifies the source of the CA certificate. A ConfigMap storing the certificate under the
+// default key is identified by its name only.
+func caReference(tls mdbv1.TLS) string {
+	reference := tls.CaConfigMap.Name
+	if tls.HasCaCertificateSecret() {
+		reference = "Secret/" + tls.CaCertificateSecret.Name
+	}
+	if key := tls.GetCaCertificateKey(); key != tlsCACertName {
+		reference += ":" + key
+	}
+	return reference
+}
+
 // getTLSConfigModification creates a modification function which enables TLS in the automation config.
 // It will also ensure that the combined cert-key secret is created.
 func getTLSConfigModification(getUpdateCreator secret.GetUpdateCreator, mdb mdbv1.MongoDBCommunity) (automationconfig.Modification, error) {
@@ -100,17 +193,86 @@ func getTLSConfigModification(getUpdateCreator secret.GetUpdateCreator, mdb mdbv
 
 // getCertAndKey will fetch the certificate and key from the user-provided Secret.
 func getCertAndKey(getter secret.Getter, mdb mdbv1.MongoDBCommunity) (string, error) {
-	cert, err := secret.ReadKey(getter, tlsSecretCertName, mdb.TLSSecretNamespacedName())
+	return readCertAndKey(getter, mdb.TLSSecretNamespacedName(), mdb.Spec.Security.TLS.CertificatePEMKey)
+}
+
+// readCertAndKey reads the certificate and key from the given Secret and combines them.
+func readCertAndKey(getter secret.Getter, secretName types.NamespacedName, pemKey string) (string, error) {
+	cert, key, err := readCertificateAndKey(getter, secretName, pemKey)
 	if err != nil {
 		return "", err
 	}
 
-	key, err := secret.ReadKey(getter, tlsSecretKeyName, mdb.TLSSecretNamespacedName())
+	return combineCertificateAndKey(cert, key), nil
+}
+
+// readCertificate reads the certificate, followed by any intermediate certificates, from the given Secret.
+func readCertificate(getter secret.Getter, secretName types.NamespacedName, pemKey string) (string, error) {
+	cert, _, err := readCertificateAndKey(getter, secretName, pemKey)
+	return cert, err
+}
+
+// readCertificateAndKey reads the PEM encoded certificate and private key from the given Secret.
+func readCertificateAndKey(getter secret.Getter, secretName types.NamespacedName, pemKey string) (string, string, error) {
+	data, err := secret.ReadStringData(getter, secretName)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	cert, key, err := certificateAndKeyFromData(data, pemKey)
+	if err != nil {
+		return "", "", errors.Errorf("secret %s: %s", secretName, err)
+	}
+	return cert, key, nil
+}
 
-	return combineCertificateAndKey(cert, key), nil
+// certificateAndKeyFromData returns the certificate and key stored in the given Secret data. They are either
+// stored in separate fields, "tls.crt" and "tls.key", or in a single PEM file under pemKey. If pemKey is empty,
+// the separate fields are used if present, and "tls.pem" otherwise.
+func certificateAndKeyFromData(data map[string]string, pemKey string) (string, string, error) {
+	if pemKey == "" {
+		cert, key := data[tlsSecretCertName], data[tlsSecretKeyName]
+		if cert != "" && key != "" {
+			return cert, key, nil
+		}
+		if data[tlsSecretPEMName] == "" {
+			return "", "", errors.Errorf(`expected a certificate and key in fields "%s" and "%s", or both in field "%s"`, tlsSecretCertName, tlsSecretKeyName, tlsSecretPEMName)
+		}
+		pemKey = tlsSecretPEMName
+	}
+
+	combined := data[pemKey]
+	if combined == "" {
+		return "", "", errors.Errorf(`expected a certificate and key in field "%s"`, pemKey)
+	}
+	return splitCertificateAndKey(combined)
+}
+
+// splitCertificateAndKey splits a PEM file containing certificates and a single private key, in any order,
+// into the certificates and the key.
+func splitCertificateAndKey(data string) (string, string, error) {
+	var certs, keys []string
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			certs = append(certs, string(pem.EncodeToMemory(block)))
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			keys = append(keys, string(pem.EncodeToMemory(block)))
+		}
+	}
+
+	if len(certs) == 0 {
+		return "", "", errors.New("no certificate found in the PEM file")
+	}
+	if len(keys) != 1 {
+		return "", "", errors.Errorf("expected exactly one private key in the PEM file, found %d", len(keys))
+	}
+	return strings.Join(certs, ""), keys[0], nil
 }
 
 func combineCertificateAndKey(cert, key string) string {
@@ -152,14 +314,12 @@ func tlsOperatorSecretFileName(certKey string) string {
 
 // tlsConfigModification will enable TLS in the automation config.
 func tlsConfigModification(mdb mdbv1.MongoDBCommunity, certKey string) automationconfig.Modification {
-	caCertificatePath := tlsCAMountPath + tlsCACertName
+	caCertificatePath := tlsCAFilePath(mdb)
 	certificateKeyPath := tlsOperatorSecretMountPath + tlsOperatorSecretFileName(certKey)
 
-	mode := automationconfig.TLSModeRequired
-	if mdb.Spec.Security.TLS.Optional {
-		// TLSModePreferred requires server-server connections to use TLS but makes it optional for clients.
-		mode = automationconfig.TLSModePreferred
-	}
+	mode := tlsModeThisReconciliation(mdb)
+	disabledProtocols := tlsDisabledProtocols(mdb.Spec.Security.TLS.MinimumTLSVersion)
+	cipherConfig := strings.Join(mdb.Spec.Security.TLS.CipherSuites, ":")
 
 	return func(config *automationconfig.AutomationConfig) {
 		// Configure CA certificate for agent
@@ -172,19 +332,168 @@ func tlsConfigModification(mdb mdbv1.MongoDBCommunity, certKey string) automatio
 			args.Set("net.tls.CAFile", caCertificatePath)
 			args.Set("net.tls.certificateKeyFile", certificateKeyPath)
 			args.Set("net.tls.allowConnectionsWithoutCertificates", true)
+			if disabledProtocols != "" {
+				args.Set("net.tls.disabledProtocols", disabledProtocols)
+			}
+			if cipherConfig != "" {
+				config.Processes[i].SetArgs26Field("setParameter."+opensslCipherConfigParameter, cipherConfig)
+			}
 		}
 	}
 }
 
+// tlsProtocolVersions are the TLS protocol versions in the format of net.tls.disabledProtocols, from oldest to newest.
+var tlsProtocolVersions = []string{"TLS1_0", "TLS1_1", "TLS1_2", "TLS1_3"}
+
+// tlsDisabledProtocols returns the value of net.tls.disabledProtocols which disables all protocol versions older
+// than the given minimum version, or an empty string if no version needs to be disabled.
+func tlsDisabledProtocols(minimumVersion string) string {
+	var disabled []string
+	for _, version := range tlsProtocolVersions {
+		if version == minimumVersion {
+			return strings.Join(disabled, ",")
+		}
+		disabled = append(disabled, version)
+	}
+	return ""
+}
+
+// validateTLSProtocolSettings checks the minimum TLS version and the cipher suites, and that the settings they are
+// rendered into are not also configured in AdditionalMongodConfig or as server parameters.
+func validateTLSProtocolSettings(mdb mdbv1.MongoDBCommunity) error {
+	tls := mdb.Spec.Security.TLS
+	additionalConfig := objx.New(mdb.Spec.AdditionalMongodConfig.Object)
+
+	if tls.MinimumTLSVersion != "" {
+		if !contains.String(tlsProtocolVersions, tls.MinimumTLSVersion) {
+			return errors.Errorf("minimumTLSVersion must be one of %s, got %s", strings.Join(tlsProtocolVersions, ", "), tls.MinimumTLSVersion)
+		}
+		if additionalConfig.Has("net.tls.disabledProtocols") {
+			return errors.New("minimumTLSVersion can not be combined with net.tls.disabledProtocols in additionalMongodConfig")
+		}
+	}
+
+	if len(tls.CipherSuites) > 0 {
+		for _, cipherSuite := range tls.CipherSuites {
+			if cipherSuite == "" || strings.ContainsAny(cipherSuite, ": ,") {
+				return errors.Errorf("invalid cipher suite %q, each entry must be a single OpenSSL cipher suite name", cipherSuite)
+			}
+		}
+		if additionalConfig.Has("setParameter." + opensslCipherConfigParameter) {
+			return errors.Errorf("cipherSuites can not be combined with setParameter.%s in additionalMongodConfig", opensslCipherConfigParameter)
+		}
+		if _, ok := mdb.Spec.ServerParameters.Object[opensslCipherConfigParameter]; ok {
+			return errors.Errorf("cipherSuites can not be combined with the %s server parameter", opensslCipherConfigParameter)
+		}
+	}
+	return nil
+}
+
+// tlsModeOrder is the order in which the members are moved through the TLS modes. Each mode accepts the
+// connections made by members configured with the adjacent modes, so the members never need to be changed
+// by more than one step at a time.
+var tlsModeOrder = []automationconfig.TLSMode{
+	automationconfig.TLSModeDisabled,
+	automationconfig.TLSModeAllowed,
+	automationconfig.TLSModePreferred,
+	automationconfig.TLSModeRequired,
+}
+
+// tlsModeThisReconciliation returns the TLS mode which is published in the automation config.
+func tlsModeThisReconciliation(mdb mdbv1.MongoDBCommunity) automationconfig.TLSMode {
+	if mdb.Status.TLSMode != "" {
+		return mdb.Status.TLSMode
+	}
+	return mdb.DesiredTLSMode()
+}
+
+// nextTLSMode returns the mode following current on the way to desired.
+func nextTLSMode(current, desired automationconfig.TLSMode) automationconfig.TLSMode {
+	currentIndex, desiredIndex := tlsModeIndex(current), tlsModeIndex(desired)
+	switch {
+	case currentIndex < desiredIndex:
+		return tlsModeOrder[currentIndex+1]
+	case currentIndex > desiredIndex:
+		return tlsModeOrder[currentIndex-1]
+	}
+	return desired
+}
+
+func tlsModeIndex(mode automationconfig.TLSMode) int {
+	for i, m := range tlsModeOrder {
+		if m == mode {
+			return i
+		}
+	}
+	return 0
+}
+
+// startTLSModeTransition records the TLS mode which is published in the automation config if it is not known yet.
+// Enabling TLS on an existing deployment starts with allowTLS, otherwise the members keep the mode they were
+// configured with by the last successful reconciliation, and advanceTLSModeTransition moves them towards the
+// desired mode.
+func (r *ReplicaSetReconciler) startTLSModeTransition(mdb *mdbv1.MongoDBCommunity) error {
+	desired := mdb.DesiredTLSMode()
+	if desired == automationconfig.TLSModeDisabled {
+		if mdb.Status.TLSMode == "" {
+			return nil
+		}
+		return r.updateTLSModeStatus(mdb, "")
+	}
+	if mdb.Status.TLSMode != "" {
+		return nil
+	}
+
+	prevSpec, err := lastSuccessfulSpec(*mdb)
+	if err != nil {
+		return err
+	}
+	current := desired
+	if prevSpec != nil {
+		previous := prevSpec.Security.TLS.DesiredMode()
+		current = previous
+		if previous == automationconfig.TLSModeDisabled {
+			current = nextTLSMode(previous, desired)
+		}
+		if current != desired {
+			r.log.Infof("Changing the TLS mode from %s to %s, starting with %s", previous, desired, current)
+		}
+	}
+	return r.updateTLSModeStatus(mdb, current)
+}
+
+// advanceTLSModeTransition moves the members to the next TLS mode on the way to the desired mode. It must only be
+// called once all agents have reached goal state with the automation config of the current mode.
+// The returned boolean is true if the TLS mode requires more automation config changes.
+func (r *ReplicaSetReconciler) advanceTLSModeTransition(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	current, desired := mdb.Status.TLSMode, mdb.DesiredTLSMode()
+	if current == "" || current == desired {
+		return false, nil
+	}
+
+	next := nextTLSMode(current, desired)
+	if !membersConnectWithTLS(next) && sendsMemberCertificate(clusterAuthModeThisReconciliation(*mdb)) {
+		r.log.Debugf("Waiting for the members to stop sending their certificate before changing the TLS mode to %s", next)
+		return false, nil
+	}
+	r.log.Infof("All members use TLS mode %s, changing to %s on the way to %s", current, next, desired)
+	return true, r.updateTLSModeStatus(mdb, next)
+}
+
+func (r *ReplicaSetReconciler) updateTLSModeStatus(mdb *mdbv1.MongoDBCommunity, mode automationconfig.TLSMode) error {
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withTLSMode(mode))
+	return err
+}
+
 // buildTLSPodSpecModification will add the TLS init container and volumes to the pod template if TLS is enabled.
 func buildTLSPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
 	if !mdb.Spec.Security.TLS.Enabled {
 		return podtemplatespec.NOOP()
 	}
 
-	// Configure a volume which mounts the CA certificate from a ConfigMap
+	// Configure a volume which mounts the CA certificate from a ConfigMap or Secret
 	// The certificate is used by both mongod and the agent
-	caVolume := statefulset.CreateVolumeFromConfigMap("tls-ca", mdb.Spec.Security.TLS.CaConfigMap.Name)
+	caVolume := tlsCAVolume(mdb.Spec.Security.TLS)
 	caVolumeMount := statefulset.CreateVolumeMount(caVolume.Name, tlsCAMountPath, statefulset.WithReadOnly(true))
 
 	// Configure a volume which mounts the secret holding the server key and certificate
@@ -202,3 +511,167 @@ func buildTLSPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Mod
 		podtemplatespec.WithVolumeMounts(construct.MongodbName, tlsSecretVolumeMount, caVolumeMount),
 	)
 }
+
+// tlsCAVolume returns the volume mounting the CA certificate from the configured ConfigMap or Secret. A
+// certificate stored under a custom key is mounted as "ca.crt".
+func tlsCAVolume(tls mdbv1.TLS) corev1.Volume {
+	var volume corev1.Volume
+	if tls.HasCaCertificateSecret() {
+		volume = statefulset.CreateVolumeFromSecret(tlsCAVolumeName, tls.CaCertificateSecret.Name)
+	} else {
+		volume = statefulset.CreateVolumeFromConfigMap(tlsCAVolumeName, tls.CaConfigMap.Name)
+	}
+
+	if key := tls.GetCaCertificateKey(); key != tlsCACertName {
+		items := []corev1.KeyToPath{{Key: key, Path: tlsCACertName}}
+		if volume.Secret != nil {
+			volume.Secret.Items = items
+		} else {
+			volume.ConfigMap.Items = items
+		}
+	}
+	return volume
+}
+
+// tlsCertificateHash returns the hash of the given PEM encoded certificate.
+func tlsCertificateHash(cert string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(cert)))
+}
+
+// getTLSCertificateHashModification annotates the Pod template with the hash of the TLS certificate.
+func getTLSCertificateHashModification(getter secret.Getter, mdb mdbv1.MongoDBCommunity) (statefulset.Modification, error) {
+	if !mdb.Spec.Security.TLS.Enabled {
+		return statefulset.NOOP(), nil
+	}
+
+	cert, err := readCertificate(getter, mdb.TLSSecretNamespacedName(), mdb.Spec.Security.TLS.CertificatePEMKey)
+	if err != nil {
+		return nil, errors.Errorf("could not read TLS certificate: %s", err)
+	}
+	hash := tlsCertificateHash(cert)
+	return statefulset.WithPodSpecTemplate(func(podTemplate *corev1.PodTemplateSpec) {
+		if podTemplate.Annotations == nil {
+			podTemplate.Annotations = map[string]string{}
+		}
+		podTemplate.Annotations[tlsCertificateHashAnnotation] = hash
+	}), nil
+}
+
+// certificateMissingHostnames returns the hostnames the first certificate in the given PEM data is not valid for.
+// It returns nil if the PEM data does not contain a certificate.
+func certificateMissingHostnames(certPEM string, hostnames []string) []string {
+	cert, err := firstCertificate(certPEM)
+	if err != nil {
+		return nil
+	}
+	var missing []string
+	for _, hostname := range hostnames {
+		if err := cert.VerifyHostname(hostname); err != nil {
+			missing = append(missing, hostname)
+		}
+	}
+	return missing
+}
+
+// certificateNotAfter returns the expiry time of the first certificate in the given PEM data.
+func certificateNotAfter(data string) (time.Time, error) {
+	cert, err := firstCertificate(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// firstCertificate parses the first certificate in the given PEM data.
+func firstCertificate(data string) (*x509.Certificate, error) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		return x509.ParseCertificate(block.Bytes)
+	}
+}
+
+// withTLSCertificateExpiryCondition returns the given conditions with the TLSCertificateExpiry condition
+// updated, or removed if TLS is disabled.
+func (r ReplicaSetReconciler) withTLSCertificateExpiryCondition(mdb mdbv1.MongoDBCommunity, conditions []metav1.Condition, now time.Time) []metav1.Condition {
+	if !mdb.Spec.Security.TLS.Enabled {
+		meta.RemoveStatusCondition(&conditions, mdbv1.ConditionTLSCertificateExpiry)
+		return conditions
+	}
+
+	condition := r.tlsCertificateExpiryCondition(mdb, now)
+	condition.Type = mdbv1.ConditionTLSCertificateExpiry
+	condition.ObservedGeneration = mdb.Generation
+	meta.SetStatusCondition(&conditions, condition)
+	return conditions
+}
+
+func (r ReplicaSetReconciler) tlsCertificateExpiryCondition(mdb mdbv1.MongoDBCommunity, now time.Time) metav1.Condition {
+	cert, err := readCertificate(r.client, mdb.TLSSecretNamespacedName(), mdb.Spec.Security.TLS.CertificatePEMKey)
+	if err != nil {
+		return metav1.Condition{
+			Status:  metav1.ConditionUnknown,
+			Reason:  tlsCertificateInvalidReason,
+			Message: fmt.Sprintf("Could not read the certificate: %s", err),
+		}
+	}
+	notAfter, err := certificateNotAfter(cert)
+	if err != nil {
+		return metav1.Condition{
+			Status:  metav1.ConditionUnknown,
+			Reason:  tlsCertificateInvalidReason,
+			Message: fmt.Sprintf("Could not parse the certificate: %s", err),
+		}
+	}
+
+	expiry := notAfter.UTC().Format(time.RFC3339)
+	if !now.Before(notAfter) {
+		return metav1.Condition{
+			Status:  metav1.ConditionTrue,
+			Reason:  tlsCertificateExpiredReason,
+			Message: fmt.Sprintf("The certificate expired at %s", expiry),
+		}
+	}
+
+	restarted, remaining := r.countMembersByCertificate(mdb, tlsCertificateHash(cert))
+	if remaining > 0 {
+		return metav1.Condition{
+			Status:  metav1.ConditionFalse,
+			Reason:  tlsCertificateRollingRestartReason,
+			Message: fmt.Sprintf("Restarting members to load the renewed certificate which expires at %s, %d of %d members restarted", expiry, restarted, restarted+remaining),
+		}
+	}
+	return metav1.Condition{
+		Status:  metav1.ConditionFalse,
+		Reason:  tlsCertificateValidReason,
+		Message: fmt.Sprintf("The certificate expires at %s", expiry),
+	}
+}
+
+// countMembersByCertificate returns how many members have been started with the certificate with the given hash,
+// and how many still run with a previous certificate.
+func (r ReplicaSetReconciler) countMembersByCertificate(mdb mdbv1.MongoDBCommunity, hash string) (int, int) {
+	current, previous := 0, 0
+	for i := 0; i < mdb.StatefulSetReplicasThisReconciliation(); i++ {
+		p, err := r.client.GetPod(types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace})
+		if err != nil {
+			continue
+		}
+		switch p.Annotations[tlsCertificateHashAnnotation] {
+		case hash:
+			current++
+		case "":
+			// the member was started before the certificate hash was recorded.
+		default:
+			previous++
+		}
+	}
+	return current, previous
+}
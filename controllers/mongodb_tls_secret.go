@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"fmt"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	tlsSecretDeletedReason  = "Deleted"
+	tlsSecretRestoredReason = "Restored"
+	tlsSecretDisabledReason = "TLSDisabled"
+
+	// tlsSecretMissingRetrySeconds is how often the deletion of the certificate Secret is checked, in addition
+	// to the watch of the Secret which reconciles the resource as soon as it is restored.
+	tlsSecretMissingRetrySeconds = 30
+)
+
+// checkTLSSecret reports whether the certificate Secret of a deployment which already uses TLS was deleted. The
+// members keep the certificate which is mounted from the Secret managed by the Operator, so the reconciliation is
+// held until the Secret is restored or TLS is disabled, rather than rolling the members into a state where they
+// can not start. The TLSSecretMissing condition reports the deletion.
+func (r *ReplicaSetReconciler) checkTLSSecret(mdb *mdbv1.MongoDBCommunity) (bool, error) {
+	if !mdb.Spec.Security.TLS.Enabled {
+		setTLSSecretMissingCondition(mdb, metav1.ConditionFalse, tlsSecretDisabledReason, "TLS is disabled")
+		return false, nil
+	}
+
+	_, err := r.client.GetSecret(mdb.TLSSecretNamespacedName())
+	if err == nil {
+		setTLSSecretMissingCondition(mdb, metav1.ConditionFalse, tlsSecretRestoredReason, fmt.Sprintf("Secret %s was restored", mdb.TLSSecretNamespacedName()))
+		return false, nil
+	}
+	if !apiErrors.IsNotFound(err) {
+		return false, err
+	}
+
+	// Without the Secret managed by the Operator TLS has not been configured yet, and validating the TLS config
+	// waits for the certificate Secret to be created.
+	if _, err := r.client.GetSecret(mdb.TLSOperatorSecretNamespacedName()); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	r.log.Warnf("The TLS certificate Secret %s was deleted, keeping the mounted certificate", mdb.TLSSecretNamespacedName())
+	r.secretWatcher.Watch(mdb.TLSSecretNamespacedName(), mdb.NamespacedName())
+	setTLSSecretMissingCondition(mdb, metav1.ConditionTrue, tlsSecretDeletedReason, tlsSecretMissingMessage(*mdb))
+	return true, nil
+}
+
+// holdForMissingTLSSecret keeps the resource in the Pending phase while its TLS certificate Secret is missing.
+func (r *ReplicaSetReconciler) holdForMissingTLSSecret(mdb *mdbv1.MongoDBCommunity) (reconcile.Result, error) {
+	return status.Update(r.client.Status(), mdb,
+		statusOptions().
+			withMessage(Info, tlsSecretMissingMessage(*mdb)).
+			withPendingPhase(tlsSecretMissingRetrySeconds),
+	)
+}
+
+func tlsSecretMissingMessage(mdb mdbv1.MongoDBCommunity) string {
+	return fmt.Sprintf("Secret %s was deleted, the members keep their mounted certificate until it is restored or TLS is disabled", mdb.TLSSecretNamespacedName())
+}
+
+// setTLSSecretMissingCondition sets the TLSSecretMissing condition. A condition which reports that the Secret is
+// not missing is only set if it is currently reported as missing.
+func setTLSSecretMissingCondition(mdb *mdbv1.MongoDBCommunity, conditionStatus metav1.ConditionStatus, reason, message string) {
+	if conditionStatus == metav1.ConditionFalse && !meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionTLSSecretMissing) {
+		return
+	}
+	meta.SetStatusCondition(&mdb.Status.Conditions, metav1.Condition{
+		Type:               mdbv1.ConditionTLSSecretMissing,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mdb.Generation,
+	})
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestTLSSecretDeletion_HoldsTheDeployment(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	cert := generateCertificate(t, time.Now().Add(30*24*time.Hour))
+	setTLSCertificate(t, mgr.GetClient(), mdb, cert)
+
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	_, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+
+	tlsSecret, err := mgr.Client.GetSecret(mdb.TLSSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &tlsSecret))
+	tlsSecret.ResourceVersion = ""
+
+	assertTLSSecretMissingCondition := func(t *testing.T, status metav1.ConditionStatus, reason string) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionTLSSecretMissing)
+		if assert.NotNil(t, condition) {
+			assert.Equal(t, status, condition.Status)
+			assert.Equal(t, reason, condition.Reason)
+		}
+	}
+
+	t.Run("Changes are held while the Secret is missing", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Version = "4.4.0"
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, tlsSecretMissingRetrySeconds*time.Second, res.RequeueAfter)
+		assertTLSSecretMissingCondition(t, metav1.ConditionTrue, tlsSecretDeletedReason)
+		assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "certificateKeySecret was deleted")
+
+		heldSts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.Equal(t, sts.Spec.Template, heldSts.Spec.Template, "the members are not restarted")
+		heldAC, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Equal(t, ac.Version, heldAC.Version)
+		_, err = mgr.Client.GetSecret(mdb.TLSOperatorSecretNamespacedName())
+		assert.NoError(t, err, "the mounted certificate is kept")
+	})
+
+	t.Run("The reconciliation resumes once the Secret is restored", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &tlsSecret))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assertTLSSecretMissingCondition(t, metav1.ConditionFalse, tlsSecretRestoredReason)
+		resumedAC, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Equal(t, "4.4.0", resumedAC.Processes[0].Version)
+	})
+}
+
+func TestTLSSecretDeletion_IsResolvedByDisablingTLS(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	setTLSCertificate(t, mgr.GetClient(), mdb, generateCertificate(t, time.Now().Add(30*24*time.Hour)))
+
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	_, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+
+	tlsSecret := corev1.Secret{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.TLSSecretNamespacedName(), &tlsSecret))
+	assert.NoError(t, mgr.GetClient().Delete(context.TODO(), &tlsSecret))
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.True(t, meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionTLSSecretMissing))
+	mdb.Spec.Security.TLS.Enabled = false
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionTLSSecretMissing)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, tlsSecretDisabledReason, condition.Reason)
+	}
+}
+
+func TestTLSSecretDeletion_NewDeploymentWaitsForTheSecret(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, res.RequeueAfter)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Nil(t, meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionTLSSecretMissing))
+	assert.Equal(t, "TLS config is not yet valid, retrying in 10 seconds", mdb.Status.Message)
+}
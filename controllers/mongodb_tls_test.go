@@ -2,7 +2,16 @@ package controllers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -14,6 +23,8 @@ import (
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -35,7 +46,7 @@ func TestStatefulSet_IsCorrectlyConfiguredWithTLS(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Assert that all TLS volumes have been added.
-	assert.Len(t, sts.Spec.Template.Spec.Volumes, 7)
+	assert.Len(t, sts.Spec.Template.Spec.Volumes, 8)
 	assert.Contains(t, sts.Spec.Template.Spec.Volumes, corev1.Volume{
 		Name: "tls-ca",
 		VolumeSource: corev1.VolumeSource{
@@ -241,3 +252,407 @@ func createTLSSecretAndConfigMap(c k8sClient.Client, mdb mdbv1.MongoDBCommunity)
 
 	return nil
 }
+
+// generateCertificate returns a self-signed PEM encoded certificate which expires at the given time.
+func generateCertificate(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "my-rs-svc.my-ns.svc.cluster.local"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func setTLSCertificate(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCommunity, cert string) {
+	s := corev1.Secret{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.TLSSecretNamespacedName(), &s))
+	s.Data["tls.crt"] = []byte(cert)
+	assert.NoError(t, c.Update(context.TODO(), &s))
+}
+
+// createMemberPods simulates the StatefulSet controller creating the Pods of the members with the given annotations.
+func createMemberPods(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCommunity, annotations map[string]string) {
+	for i := 0; i < mdb.Spec.Members; i++ {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", mdb.Name, i), Namespace: mdb.Namespace}}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: p.Name, Namespace: p.Namespace}, &p)
+		p.Annotations = annotations
+		if err == nil {
+			assert.NoError(t, c.Update(context.TODO(), &p))
+		} else {
+			assert.NoError(t, c.Create(context.TODO(), &p))
+		}
+	}
+}
+
+func TestTLSCertificateRenewal_RestartsMembers(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	expiry := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	cert := generateCertificate(t, expiry)
+	setTLSCertificate(t, mgr.GetClient(), mdb, cert)
+
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	res, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(time.Until(expiry)), float64(res.RequeueAfter), float64(time.Minute), "the reconciliation is repeated when the certificate expires")
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, tlsCertificateHash(cert), sts.Spec.Template.Annotations[tlsCertificateHashAnnotation])
+	// the members are not reported to have reached goal state once their Pods exist, the resource stays Pending.
+	createMemberPods(t, mgr.GetClient(), mdb, sts.Spec.Template.Annotations)
+
+	assertTLSCertificateCondition := func(t *testing.T, status metav1.ConditionStatus, reason, message string) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		condition := meta.FindStatusCondition(mdb.Status.Conditions, mdbv1.ConditionTLSCertificateExpiry)
+		if assert.NotNil(t, condition) {
+			assert.Equal(t, status, condition.Status)
+			assert.Equal(t, reason, condition.Reason)
+			assert.Contains(t, condition.Message, message)
+		}
+	}
+	assertTLSCertificateCondition(t, metav1.ConditionFalse, tlsCertificateValidReason, expiry.UTC().Format(time.RFC3339))
+
+	renewedExpiry := expiry.Add(90 * 24 * time.Hour)
+	renewedCert := generateCertificate(t, renewedExpiry)
+	setTLSCertificate(t, mgr.GetClient(), mdb, renewedCert)
+
+	t.Run("The Pod template is updated with the renewed certificate", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.Equal(t, tlsCertificateHash(renewedCert), sts.Spec.Template.Annotations[tlsCertificateHashAnnotation])
+		assertTLSCertificateCondition(t, metav1.ConditionFalse, tlsCertificateRollingRestartReason, "0 of 3 members restarted")
+	})
+
+	t.Run("The progress of the restarts is reported", func(t *testing.T) {
+		p := corev1.Pod{}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "my-rs-0", Namespace: mdb.Namespace}, &p))
+		p.Annotations = map[string]string{tlsCertificateHashAnnotation: tlsCertificateHash(renewedCert)}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &p))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assertTLSCertificateCondition(t, metav1.ConditionFalse, tlsCertificateRollingRestartReason, "1 of 3 members restarted")
+	})
+
+	t.Run("The renewed certificate is reported once all members have been restarted", func(t *testing.T) {
+		createMemberPods(t, mgr.GetClient(), mdb, map[string]string{tlsCertificateHashAnnotation: tlsCertificateHash(renewedCert)})
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assertTLSCertificateCondition(t, metav1.ConditionFalse, tlsCertificateValidReason, renewedExpiry.UTC().Format(time.RFC3339))
+	})
+
+	t.Run("An expired certificate is reported", func(t *testing.T) {
+		setTLSCertificate(t, mgr.GetClient(), mdb, generateCertificate(t, time.Now().Add(-time.Hour)))
+
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assertTLSCertificateCondition(t, metav1.ConditionTrue, tlsCertificateExpiredReason, "expired")
+	})
+}
+
+func TestNextTLSMode(t *testing.T) {
+	assert.Equal(t, automationconfig.TLSModeAllowed, nextTLSMode(automationconfig.TLSModeDisabled, automationconfig.TLSModeRequired))
+	assert.Equal(t, automationconfig.TLSModePreferred, nextTLSMode(automationconfig.TLSModeAllowed, automationconfig.TLSModeRequired))
+	assert.Equal(t, automationconfig.TLSModeRequired, nextTLSMode(automationconfig.TLSModePreferred, automationconfig.TLSModeRequired))
+	assert.Equal(t, automationconfig.TLSModePreferred, nextTLSMode(automationconfig.TLSModeRequired, automationconfig.TLSModeAllowed))
+	assert.Equal(t, automationconfig.TLSModeAllowed, nextTLSMode(automationconfig.TLSModeAllowed, automationconfig.TLSModeAllowed))
+}
+
+func TestTLSMode_IsChangedOneStepAtATime(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+	acNsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.TLS = newTestReplicaSetWithTLS().Spec.Security.TLS
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+
+	// assertTLSMode checks the mode in the automation config, and the mode which is published next.
+	assertTLSMode := func(t *testing.T, configured, next automationconfig.TLSMode) {
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+		assert.NoError(t, err)
+		for _, process := range ac.Processes {
+			assert.Equal(t, string(configured), process.Args26.Get("net.tls.mode").Data())
+		}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, next, mdb.Status.TLSMode)
+	}
+
+	t.Run("Enabling TLS starts with allowTLS", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertTLSMode(t, automationconfig.TLSModeAllowed, automationconfig.TLSModePreferred)
+		assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	})
+
+	t.Run("preferTLS is configured once all members accept TLS connections", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertTLSMode(t, automationconfig.TLSModePreferred, automationconfig.TLSModeRequired)
+	})
+
+	t.Run("requireTLS is configured last", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assertTLSMode(t, automationconfig.TLSModeRequired, automationconfig.TLSModeRequired)
+		assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	})
+
+	t.Run("Lowering the mode passes through the intermediate modes", func(t *testing.T) {
+		mdb.Spec.Security.TLS.Mode = automationconfig.TLSModeAllowed
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		res, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertTLSMode(t, automationconfig.TLSModeRequired, automationconfig.TLSModePreferred)
+
+		res, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assertTLSMode(t, automationconfig.TLSModePreferred, automationconfig.TLSModeAllowed)
+
+		res, err = r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+		assertTLSMode(t, automationconfig.TLSModeAllowed, automationconfig.TLSModeAllowed)
+	})
+}
+
+func TestTLSMode_NewDeploymentUsesDesiredMode(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Spec.Security.TLS.Mode = automationconfig.TLSModeAllowed
+	mgr := client.NewManager(&mdb)
+	assert.NoError(t, createTLSSecretAndConfigMap(mgr.GetClient(), mdb))
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for _, process := range ac.Processes {
+		assert.Equal(t, string(automationconfig.TLSModeAllowed), process.Args26.Get("net.tls.mode").Data())
+	}
+}
+
+func pemBlock(blockType, content string) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: []byte(content)}))
+}
+
+func TestCertificateAndKeyFromData(t *testing.T) {
+	cert, intermediate, key := pemBlock("CERTIFICATE", "cert"), pemBlock("CERTIFICATE", "intermediate"), pemBlock("EC PRIVATE KEY", "key")
+
+	tests := []struct {
+		name         string
+		data         map[string]string
+		pemKey       string
+		expectedCert string
+		expectedKey  string
+		expectError  bool
+	}{
+		{name: "separate fields", data: map[string]string{"tls.crt": "CERT", "tls.key": "KEY"}, expectedCert: "CERT", expectedKey: "KEY"},
+		{name: "tls.pem", data: map[string]string{"tls.pem": cert + intermediate + key}, expectedCert: cert + intermediate, expectedKey: key},
+		{name: "key before the certificates", data: map[string]string{"tls.pem": key + cert}, expectedCert: cert, expectedKey: key},
+		{name: "separate fields are preferred", data: map[string]string{"tls.crt": "CERT", "tls.key": "KEY", "tls.pem": cert + key}, expectedCert: "CERT", expectedKey: "KEY"},
+		{name: "custom key", data: map[string]string{"tls.crt": "CERT", "tls.key": "KEY", "server.pem": cert + key}, pemKey: "server.pem", expectedCert: cert, expectedKey: key},
+		{name: "missing custom key", data: map[string]string{"tls.pem": cert + key}, pemKey: "server.pem", expectError: true},
+		{name: "missing key", data: map[string]string{"tls.crt": "CERT"}, expectError: true},
+		{name: "combined without key", data: map[string]string{"tls.pem": cert}, expectError: true},
+		{name: "combined with two keys", data: map[string]string{"tls.pem": cert + key + key}, expectError: true},
+		{name: "combined without certificate", data: map[string]string{"tls.pem": key}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, key, err := certificateAndKeyFromData(tt.data, tt.pemKey)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCert, cert)
+			assert.Equal(t, tt.expectedKey, key)
+		})
+	}
+}
+
+func TestTLS_CAFromSecretAndCombinedPEM(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Spec.Security.TLS.CaConfigMap = mdbv1.LocalObjectReference{}
+	mdb.Spec.Security.TLS.CaCertificateSecret = &mdbv1.LocalObjectReference{Name: "ca-secret"}
+	mdb.Spec.Security.TLS.CaCertificateKey = "ca.pem"
+	mgr := client.NewManager(&mdb)
+	c := mgr.Client
+
+	certKey := pemBlock("CERTIFICATE", "cert") + pemBlock("PRIVATE KEY", "key")
+	caSecret := secret.Builder().SetName("ca-secret").SetNamespace(mdb.Namespace).SetField("ca.pem", "CA").Build()
+	assert.NoError(t, c.Create(context.TODO(), &caSecret))
+	certSecret := secret.Builder().SetName(mdb.Spec.Security.TLS.CertificateKeySecret.Name).SetNamespace(mdb.Namespace).SetField("tls.pem", certKey).Build()
+	assert.NoError(t, c.Create(context.TODO(), &certSecret))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &sts))
+	permission := int32(416)
+	assert.Contains(t, sts.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "tls-ca",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  "ca-secret",
+				DefaultMode: &permission,
+				Items:       []corev1.KeyToPath{{Key: "ca.pem", Path: "ca.crt"}},
+			},
+		},
+	})
+
+	expectedCertificateKey := combineCertificateAndKey(pemBlock("CERTIFICATE", "cert"), pemBlock("PRIVATE KEY", "key"))
+	certificateKey, err := secret.ReadKey(c, tlsOperatorSecretFileName(expectedCertificateKey), mdb.TLSOperatorSecretNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCertificateKey, certificateKey)
+
+	t.Run("Only one CA source can be set", func(t *testing.T) {
+		assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.Security.TLS.CaConfigMap = mdbv1.LocalObjectReference{Name: "caConfigMap"}
+		assert.NoError(t, c.Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "only one of caConfigMapRef and caCertificateSecretRef")
+	})
+}
+
+func TestTLSCAVolume_ReplacesTheCASource(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mgr := client.NewManager(&mdb)
+	c := mgr.Client
+	assert.NoError(t, createTLSSecretAndConfigMap(c, mdb))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	caSecret := secret.Builder().SetName("ca-secret").SetNamespace(mdb.Namespace).SetField("ca.crt", "CERT").Build()
+	assert.NoError(t, c.Create(context.TODO(), &caSecret))
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Security.TLS.CaConfigMap = mdbv1.LocalObjectReference{}
+	mdb.Spec.Security.TLS.CaCertificateSecret = &mdbv1.LocalObjectReference{Name: "ca-secret"}
+	assert.NoError(t, c.Update(context.TODO(), &mdb))
+
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts := appsv1.StatefulSet{}
+	assert.NoError(t, c.Get(context.TODO(), mdb.NamespacedName(), &sts))
+	for _, volume := range sts.Spec.Template.Spec.Volumes {
+		if volume.Name == "tls-ca" {
+			assert.Nil(t, volume.ConfigMap)
+			assert.Equal(t, "ca-secret", volume.Secret.SecretName)
+			assert.Nil(t, volume.Secret.Items)
+		}
+	}
+}
+
+func TestCertificateMissingHostnames(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"*.mongo.my-ns.svc.cluster.local"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	hostnames := []string{"db-0.mongo.my-ns.svc.cluster.local", "db-0.my-rs-svc.my-ns.svc.cluster.local"}
+	assert.Equal(t, []string{"db-0.my-rs-svc.my-ns.svc.cluster.local"}, certificateMissingHostnames(cert, hostnames))
+	assert.Nil(t, certificateMissingHostnames("CERT", hostnames))
+}
+
+func TestAutomationConfig_TLSProtocolSettings(t *testing.T) {
+	mdb := newTestReplicaSetWithTLS()
+	mdb.Spec.Security.TLS.MinimumTLSVersion = "TLS1_2"
+	mdb.Spec.Security.TLS.CipherSuites = []string{"ECDHE-RSA-AES256-GCM-SHA384", "ECDHE-ECDSA-AES256-GCM-SHA384"}
+
+	client := mdbClient.NewClient(client.NewManager(&mdb).GetClient())
+	assert.NoError(t, createTLSSecretAndConfigMap(client, mdb))
+	tlsModification, err := getTLSConfigModification(client, mdb)
+	assert.NoError(t, err)
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, tlsModification)
+	assert.NoError(t, err)
+
+	for _, process := range ac.Processes {
+		assert.Equal(t, "TLS1_0,TLS1_1", process.Args26.Get("net.tls.disabledProtocols").Data())
+		assert.Equal(t, "ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES256-GCM-SHA384", process.Args26.Get("setParameter.opensslCipherConfig").Data())
+	}
+}
+
+func TestTLSDisabledProtocols(t *testing.T) {
+	assert.Equal(t, "", tlsDisabledProtocols(""))
+	assert.Equal(t, "", tlsDisabledProtocols("TLS1_0"))
+	assert.Equal(t, "TLS1_0", tlsDisabledProtocols("TLS1_1"))
+	assert.Equal(t, "TLS1_0,TLS1_1", tlsDisabledProtocols("TLS1_2"))
+	assert.Equal(t, "TLS1_0,TLS1_1,TLS1_2", tlsDisabledProtocols("TLS1_3"))
+}
+
+func TestValidateTLSProtocolSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(mdb *mdbv1.MongoDBCommunity)
+		expectError bool
+	}{
+		{name: "valid", modify: func(mdb *mdbv1.MongoDBCommunity) {}},
+		{name: "unknown version", modify: func(mdb *mdbv1.MongoDBCommunity) { mdb.Spec.Security.TLS.MinimumTLSVersion = "TLS1.2" }, expectError: true},
+		{name: "empty cipher suite", modify: func(mdb *mdbv1.MongoDBCommunity) { mdb.Spec.Security.TLS.CipherSuites = []string{""} }, expectError: true},
+		{name: "cipher string instead of a suite", modify: func(mdb *mdbv1.MongoDBCommunity) {
+			mdb.Spec.Security.TLS.CipherSuites = []string{"HIGH:!aNULL"}
+		}, expectError: true},
+		{name: "disabledProtocols in additionalMongodConfig", modify: func(mdb *mdbv1.MongoDBCommunity) {
+			mdb.Spec.AdditionalMongodConfig.Object = map[string]interface{}{"net": map[string]interface{}{"tls": map[string]interface{}{"disabledProtocols": "TLS1_0"}}}
+		}, expectError: true},
+		{name: "opensslCipherConfig server parameter", modify: func(mdb *mdbv1.MongoDBCommunity) {
+			mdb.Spec.ServerParameters.Object = map[string]interface{}{"opensslCipherConfig": "HIGH"}
+		}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := newTestReplicaSetWithTLS()
+			mdb.Spec.Security.TLS.MinimumTLSVersion = "TLS1_2"
+			mdb.Spec.Security.TLS.CipherSuites = []string{"ECDHE-RSA-AES256-GCM-SHA384"}
+			tt.modify(&mdb)
+			err := validateTLSProtocolSettings(mdb)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	tracerName = "github.com/mongodb/mongodb-kubernetes-operator/controllers"
+
+	namespaceAttribute               = attribute.Key("mongodbcommunity.namespace")
+	nameAttribute                    = attribute.Key("mongodbcommunity.name")
+	generationAttribute              = attribute.Key("mongodbcommunity.generation")
+	phaseAttribute                   = attribute.Key("mongodbcommunity.phase")
+	automationConfigVersionAttribute = attribute.Key("mongodbcommunity.automation_config.version")
+	requeueAfterAttribute            = attribute.Key("reconcile.requeue_after")
+)
+
+// startReconcileSpan starts the span of the reconciliation of a resource. The spans of the steps of the
+// reconciliation are its children.
+func startReconcileSpan(ctx context.Context, request reconcile.Request) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "Reconcile", trace.WithAttributes(
+		namespaceAttribute.String(request.Namespace),
+		nameAttribute.String(request.Name),
+	))
+}
+
+// endReconcileSpan records the result of the reconciliation and ends its span.
+func endReconcileSpan(span trace.Span, res reconcile.Result, err error) {
+	if res.RequeueAfter > 0 {
+		span.SetAttributes(requeueAfterAttribute.String(res.RequeueAfter.String()))
+	}
+	endSpan(span, err)
+}
+
+// traceResource records the generation and the phase of the resource in the span of the reconciliation.
+func (r ReplicaSetReconciler) traceResource(mdb *mdbv1.MongoDBCommunity) {
+	trace.SpanFromContext(r.traceContext()).SetAttributes(
+		generationAttribute.Int64(mdb.Generation),
+		phaseAttribute.String(string(mdb.Status.Phase)),
+	)
+}
+
+// traceAutomationConfigVersion records the version of the automation config in the span of the reconciliation.
+func (r ReplicaSetReconciler) traceAutomationConfigVersion(version int) {
+	trace.SpanFromContext(r.traceContext()).SetAttributes(automationConfigVersionAttribute.Int(version))
+}
+
+// startSpan starts the span of a step of the reconciliation.
+func (r ReplicaSetReconciler) startSpan(name string) trace.Span {
+	_, span := otel.Tracer(tracerName).Start(r.traceContext(), name)
+	return span
+}
+
+func (r ReplicaSetReconciler) traceContext() context.Context {
+	if r.tracingContext == nil {
+		return context.TODO()
+	}
+	return r.tracingContext
+}
+
+// endSpan records the error of a step, if any, and ends its span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traced runs a step of the reconciliation in its own span.
+func (r ReplicaSetReconciler) traced(name string, step func() error) error {
+	span := r.startSpan(name)
+	err := step()
+	endSpan(span, err)
+	return err
+}
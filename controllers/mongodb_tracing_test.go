@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// recordSpans records the spans ended during the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	return recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestReconcile_IsTraced(t *testing.T) {
+	recorder := recordSpans(t)
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	res, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	root, ok := spans["Reconcile"]
+	if !assert.True(t, ok) {
+		return
+	}
+	attributes := spanAttributes(root)
+	assert.Equal(t, mdb.Namespace, attributes[namespaceAttribute].AsString())
+	assert.Equal(t, mdb.Name, attributes[nameAttribute].AsString())
+	assert.Equal(t, string(mdbv1.Running), attributes[phaseAttribute].AsString())
+	assert.Equal(t, int64(1), attributes[automationConfigVersionAttribute].AsInt64())
+
+	for _, name := range []string{"EnsureService", "EnsureTLSResources", "DeployAutomationConfig", "DeployStatefulSet", "ResetUpdateStrategy"} {
+		if span, ok := spans[name]; assert.True(t, ok, name) {
+			assert.Equal(t, root.SpanContext().SpanID(), span.Parent().SpanID(), "%s is a step of the reconciliation", name)
+			assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID())
+		}
+	}
+}
+
+func TestReconcile_TracesTheErrorsOfTheSteps(t *testing.T) {
+	recorder := recordSpans(t)
+	registerTestExtension(t, AfterTLS, "approval", func(client.Client, mdbv1.MongoDBCommunity) (bool, error) {
+		return false, errors.New("denied")
+	})
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	_, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "Extension approval":
+			assert.Equal(t, codes.Error, span.Status().Code)
+			assert.Equal(t, "denied", span.Status().Description)
+		case "Reconcile":
+			assert.Equal(t, string(mdbv1.Failed), spanAttributes(span)[phaseAttribute].AsString())
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// validateUpdateStrategy checks that canary updates are not combined with the OnDelete update strategy, as the
+// operator does not restart the canary member.
+func validateUpdateStrategy(mdb mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.UpdateStrategy.IsOnDelete() && mdb.Spec.UpdateStrategy.GetCanary() != nil {
+		return errors.New("updateStrategy.canary can not be combined with updateStrategy.type OnDelete")
+	}
+	return nil
+}
+
+// recordOnDeleteUpdateStrategy records in the status why and since when the StatefulSet uses the OnDelete
+// update strategy. It must be called before the StatefulSet is switched, so the status never misses the reason.
+// Nothing is recorded if the OnDelete update strategy is configured in the spec, as the operator then does not
+// restart the members when their resources change.
+func (r *ReplicaSetReconciler) recordOnDeleteUpdateStrategy(mdb *mdbv1.MongoDBCommunity) error {
+	if mdb.Spec.UpdateStrategy.IsOnDelete() {
+		return nil
+	}
+
+	var reason, message string
+	switch {
+	case mdb.IsChangingVersion():
+		reason = mdbv1.OnDeleteUpdateStrategyReasonVersionChange
+		message = fmt.Sprintf("Changing version from %s to %s", mdb.GetPreviousVersion(), mdb.Spec.Version)
+	case mdb.IsScalingVertically():
+		return nil
+	default:
+		change, err := r.verticalScalingChange(*mdb)
+		if err != nil {
+			return err
+		}
+		if change == "" {
+			return nil
+		}
+		reason = mdbv1.OnDeleteUpdateStrategyReasonVerticalScaling
+		message = change
+	}
+
+	if current := mdb.Status.OnDeleteUpdateStrategy; current != nil && current.Reason == reason && current.Message == message {
+		return nil
+	}
+
+	r.log.Infof("Switching the StatefulSet to the OnDelete update strategy: %s", message)
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withOnDeleteUpdateStrategy(&mdbv1.OnDeleteUpdateStrategyStatus{
+		Reason:  reason,
+		Message: message,
+		Since:   metav1.Now(),
+	}))
+	return err
+}
+
+// resetUpdateStrategy switches the StatefulSet back to the RollingUpdate strategy, unless the OnDelete strategy
+// is configured in the spec, and removes the reason for using the OnDelete strategy from the status.
+func (r *ReplicaSetReconciler) resetUpdateStrategy(mdb *mdbv1.MongoDBCommunity) error {
+	if !mdb.Spec.UpdateStrategy.IsOnDelete() {
+		if err := statefulset.ResetUpdateStrategy(mdb.StatefulSetNamespacedName(), r.client); err != nil {
+			return err
+		}
+	}
+	if mdb.Status.OnDeleteUpdateStrategy == nil {
+		return nil
+	}
+
+	r.log.Infof("The StatefulSet uses the RollingUpdate strategy again, it used the OnDelete strategy since %s: %s",
+		mdb.Status.OnDeleteUpdateStrategy.Since, mdb.Status.OnDeleteUpdateStrategy.Message)
+	_, err := status.Update(r.client.Status(), mdb, statusOptions().withOnDeleteUpdateStrategy(nil))
+	return err
+}
+
+// pendingRestarts returns the names of the Pods which do not run the current revision of the StatefulSet, if the
+// OnDelete update strategy is configured in the spec. Pods which do not exist are recreated with the current
+// revision, so they are not pending a restart.
+func (r *ReplicaSetReconciler) pendingRestarts(mdb mdbv1.MongoDBCommunity) ([]string, error) {
+	if !mdb.Spec.UpdateStrategy.IsOnDelete() {
+		return nil, nil
+	}
+	sts, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	if err != nil {
+		return nil, err
+	}
+	if sts.Status.UpdateRevision == "" {
+		return nil, nil
+	}
+
+	var pending []string
+	for i := 0; i < mdb.StatefulSetReplicasThisReconciliation(); i++ {
+		pod, err := r.client.GetPod(types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if pod.Labels[appsv1.StatefulSetRevisionLabel] != sts.Status.UpdateRevision {
+			pending = append(pending, pod.Name)
+		}
+	}
+	return pending, nil
+}
+
+// pendingRestartsMessage returns the message of a resource whose members must be restarted to run the current
+// revision of the StatefulSet.
+func pendingRestartsMessage(pending []string) string {
+	return fmt.Sprintf("The changes are applied to the members %s once their Pods are deleted", strings.Join(pending, ", "))
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestOnDeleteUpdateStrategy_IsRecordedInStatus(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	previousVersion := mdb.Spec.Version
+	mdb.Spec.Version = "4.2.3"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	assert.NoError(t, r.recordOnDeleteUpdateStrategy(&mdb))
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	onDelete := mdb.Status.OnDeleteUpdateStrategy
+	if assert.NotNil(t, onDelete) {
+		assert.Equal(t, mdbv1.OnDeleteUpdateStrategyReasonVersionChange, onDelete.Reason)
+		assert.Equal(t, "Changing version from "+previousVersion+" to 4.2.3", onDelete.Message)
+		assert.False(t, onDelete.Since.IsZero())
+	}
+
+	t.Run("The activation time is kept while the same change is in progress", func(t *testing.T) {
+		since := mdb.Status.OnDeleteUpdateStrategy.Since
+		assert.NoError(t, r.recordOnDeleteUpdateStrategy(&mdb))
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, since, mdb.Status.OnDeleteUpdateStrategy.Since)
+	})
+
+	t.Run("The status is removed once the StatefulSet has been reset", func(t *testing.T) {
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Nil(t, mdb.Status.OnDeleteUpdateStrategy)
+	})
+}
+
+func TestOnDeleteUpdateStrategy_IsResetAfterInterruptedVersionChange(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	// the operator stopped after the version had been recorded, but before the StatefulSet was reset.
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &sts))
+
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	sts, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+}
+
+// setPodRevision simulates the Pod of the member with the given ordinal running the given revision of the
+// StatefulSet.
+func setPodRevision(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, i int, revision string) {
+	pod := corev1.Pod{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace}, &pod))
+	pod.Labels = map[string]string{appsv1.StatefulSetRevisionLabel: revision}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+}
+
+func TestOnDeleteUpdateStrategy_ReportsPodsPendingRestart(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.UpdateStrategy = &mdbv1.UpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, appsv1.OnDeleteStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+
+	setStatefulSetRevisions(t, mgr.GetClient(), mdb, "rev-1", "rev-2")
+	for i := 0; i < 3; i++ {
+		setPodRevision(t, mgr, mdb, i, "rev-1")
+	}
+	setPodRevision(t, mgr, mdb, 2, "rev-2")
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, []string{"my-rs-0", "my-rs-1"}, mdb.Status.PendingRestarts)
+	assert.Equal(t, "The changes are applied to the members my-rs-0, my-rs-1 once their Pods are deleted", mdb.Status.Message)
+	assert.Nil(t, mdb.Status.OnDeleteUpdateStrategy)
+	sts, err = mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, appsv1.OnDeleteStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type, "the StatefulSet is not reset")
+
+	t.Run("Restarted Pods are no longer pending a restart", func(t *testing.T) {
+		setPodRevision(t, mgr, mdb, 0, "rev-2")
+		setPodRevision(t, mgr, mdb, 1, "rev-2")
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Empty(t, mdb.Status.PendingRestarts)
+		assert.Empty(t, mdb.Status.Message)
+	})
+
+	t.Run("The StatefulSet is reset once the RollingUpdate strategy is configured", func(t *testing.T) {
+		mdb.Spec.UpdateStrategy = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+	})
+}
+
+func TestValidateUpdateStrategy(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateUpdateStrategy(mdb))
+
+	mdb.Spec.UpdateStrategy = &mdbv1.UpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	assert.NoError(t, validateUpdateStrategy(mdb))
+
+	mdb.Spec.UpdateStrategy.Canary = &mdbv1.CanaryUpdate{}
+	assert.EqualError(t, validateUpdateStrategy(mdb), "updateStrategy.canary can not be combined with updateStrategy.type OnDelete")
+}
@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/dns"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// Keys of the connection string Secret of a user.
+const (
+	connectionStringUsernameKey    = "username"
+	connectionStringPasswordKey    = "password"
+	connectionStringStandardKey    = "connectionString.standard"
+	connectionStringStandardSrvKey = "connectionString.standardSrv"
+	connectionStringSrvHostKey     = "srvHost"
+	connectionStringExternalKey    = "connectionString.external"
+	connectionStringHostsKey       = "hosts"
+	connectionStringPropertiesKey  = "connection.properties"
+	connectionStringJSONKey        = "connection.json"
+)
+
+const (
+	srvRecordsVerifiedReason     = "Verified"
+	srvRecordsUnresolvableReason = "Unresolvable"
+
+	// srvLookupTimeout bounds the time the reconciliation waits for the SRV and TXT records to be resolved.
+	srvLookupTimeout = 5 * time.Second
+)
+
+// connectionDetails contains everything an application needs to connect to the deployment as a given user.
+type connectionDetails struct {
+	URI         string   `json:"uri"`
+	SrvURI      string   `json:"srvUri,omitempty"`
+	SrvHost     string   `json:"srvHost,omitempty"`
+	ExternalURI string   `json:"externalUri,omitempty"`
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	Hosts       []string `json:"hosts"`
+	ReplicaSet  string   `json:"replicaSet"`
+	AuthSource  string   `json:"authSource"`
+	TLS         bool     `json:"tls"`
+}
+
+// validateUsers checks that the authentication restrictions of the users only contain IP addresses and CIDR ranges,
+// that SCRAM-SHA-1 is only enabled for users which authenticate with a password and that read-only users
+// don't specify any other roles.
+func validateUsers(mdb mdbv1.MongoDBCommunity) error {
+	for _, user := range mdb.Spec.Users {
+		if user.ReadOnly && len(user.Roles) > 0 {
+			return errors.Errorf("user %s can not specify roles if readOnly is set", user.Name)
+		}
+		if !user.ReadOnly && len(user.ReadOnlyDatabases) > 0 {
+			return errors.Errorf("user %s can only specify readOnlyDatabases if readOnly is set", user.Name)
+		}
+		if user.ScramSha1 && user.GetDB() == externalDatabase {
+			return errors.Errorf("user %s in the %s database can not enable scramSha1", user.Name, externalDatabase)
+		}
+		for _, restriction := range user.AuthenticationRestrictions {
+			if len(restriction.ClientSource) == 0 && len(restriction.ServerAddress) == 0 {
+				return errors.Errorf("authentication restrictions of user %s must specify clientSource or serverAddress", user.Name)
+			}
+			addresses := append(append([]string{}, restriction.ClientSource...), restriction.ServerAddress...)
+			for _, address := range addresses {
+				if !isIPOrCIDR(address) {
+					return errors.Errorf("authentication restrictions of user %s contain %q, which is neither an IP address nor a CIDR range", user.Name, address)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isIPOrCIDR(address string) bool {
+	if net.ParseIP(address) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(address)
+	return err == nil
+}
+
+// srvRequested returns true if any user requested a mongodb+srv:// connection string.
+func srvRequested(mdb mdbv1.MongoDBCommunity) bool {
+	for _, user := range mdb.Spec.Users {
+		if user.ConnectionStringSecret.HasFormat(mdbv1.ConnectionStringFormatSRV) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySRVRecords checks that drivers can use the SRV records of the Service if any user requested
+// a mongodb+srv:// connection string. The records carry the port of the headless Service, which must be the port
+// mongod listens on. The returned condition is nil if no user did.
+func (r ReplicaSetReconciler) verifySRVRecords(mdb mdbv1.MongoDBCommunity) *metav1.Condition {
+	if !srvRequested(mdb) {
+		return nil
+	}
+
+	srvHost := getDomain(mdb.ServiceName(), mdb.Namespace, os.Getenv(clusterDNSName))
+	ctx, cancel := context.WithTimeout(context.Background(), srvLookupTimeout)
+	defer cancel()
+	if err := dns.VerifySRV(ctx, r.resolver, srvHost, automationconfig.DefaultDBPort); err != nil {
+		r.log.Warnf("Storing standard connection strings instead of mongodb+srv:// ones: %s", err)
+		return &metav1.Condition{
+			Type:    mdbv1.ConditionSRVUnavailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  srvRecordsUnresolvableReason,
+			Message: fmt.Sprintf("Standard connection strings are stored instead of mongodb+srv:// ones: %s", err),
+		}
+	}
+	return &metav1.Condition{
+		Type:    mdbv1.ConditionSRVUnavailable,
+		Status:  metav1.ConditionFalse,
+		Reason:  srvRecordsVerifiedReason,
+		Message: fmt.Sprintf("The SRV records of %s can be used by drivers", srvHost),
+	}
+}
+
+// ensureUserConnectionStringSecrets creates or updates the connection string Secret of every user.
+// Users whose password Secret does not exist anymore are skipped, keeping their existing connection string Secret.
+// If srvAvailable is false, the standard connection string is stored in place of the mongodb+srv:// one.
+func (r ReplicaSetReconciler) ensureUserConnectionStringSecrets(mdb mdbv1.MongoDBCommunity, srvAvailable bool) error {
+	hostnames, err := mdb.MemberHostnames(mdb.Spec.Members, os.Getenv(clusterDNSName))
+	if err != nil {
+		return err
+	}
+	srvHost := ""
+	if srvAvailable {
+		srvHost = getDomain(mdb.ServiceName(), mdb.Namespace, os.Getenv(clusterDNSName))
+	}
+	externalAddresses, err := r.externalAddresses(mdb, mdb.Spec.Members)
+	if err != nil {
+		return err
+	}
+	if len(externalAddresses) < mdb.Spec.Members {
+		// a connection string missing some members would be rejected by replica set discovery.
+		externalAddresses = nil
+	}
+
+	for _, user := range mdb.Spec.Users {
+		password, err := secret.ReadKey(r.client, user.GetPasswordSecretKey(), types.NamespacedName{Name: user.PasswordSecretRef.Name, Namespace: mdb.Namespace})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				r.log.Debugf("Password secret of user %s does not exist, not updating its connection string secret", user.Name)
+				continue
+			}
+			return errors.Errorf("could not read password of user %s: %s", user.Name, err)
+		}
+
+		details := buildConnectionDetails(mdb, user, password, hostnames, externalHosts(externalAddresses), srvHost)
+		data, err := connectionStringSecretData(user.ConnectionStringSecret, details)
+		if err != nil {
+			return errors.Errorf("could not build connection string secret of user %s: %s", user.Name, err)
+		}
+
+		connectionStringSecret := secret.Builder().
+			SetName(user.GetConnectionStringSecretName(mdb.Name)).
+			SetNamespace(mdb.Namespace).
+			SetStringData(data).
+			SetOwnerReferences(mdb.GetOwnerReferences()).
+			Build()
+
+		if err := secret.CreateOrUpdate(r.client, connectionStringSecret); err != nil {
+			return errors.Errorf("could not create or update connection string secret of user %s: %s", user.Name, err)
+		}
+	}
+	return nil
+}
+
+// buildConnectionDetails returns the connection details of the given user. The members are identified by
+// the given hostnames, or by the "<host>:<port>" of their external Services, while srvHost is the name of the
+// Service used to look up the members in SRV records. No mongodb+srv:// connection string is built if srvHost is
+// empty, and no external one if there are no external hosts.
+func buildConnectionDetails(mdb mdbv1.MongoDBCommunity, user mdbv1.MongoDBUser, password string, hostnames, externalHosts []string, srvHost string) connectionDetails {
+	hosts := make([]string, len(hostnames))
+	for i, hostname := range hostnames {
+		// JoinHostPort encloses IPv6 addresses in brackets.
+		hosts[i] = net.JoinHostPort(hostname, "27017")
+	}
+
+	query := url.Values{}
+	query.Set("replicaSet", mdb.GetReplicaSetName())
+	query.Set("authSource", user.GetDB())
+	query.Set("tls", strconv.FormatBool(mdb.Spec.Security.TLS.Enabled))
+	if user.ReadOnly {
+		// read-only users are typically used for analytics, which should not compete with the primary.
+		query.Set("readPreference", "secondaryPreferred")
+	}
+	for k, v := range user.ConnectionStringSecret.Options {
+		query.Set(k, v)
+	}
+
+	credentials := url.UserPassword(user.Name, password).String()
+	path := "/" + url.PathEscape(user.GetDB())
+
+	details := connectionDetails{
+		URI:        fmt.Sprintf("mongodb://%s@%s%s?%s", credentials, strings.Join(hosts, ","), path, query.Encode()),
+		Username:   user.Name,
+		Password:   password,
+		Hosts:      hosts,
+		ReplicaSet: mdb.Name,
+		AuthSource: user.GetDB(),
+		TLS:        mdb.Spec.Security.TLS.Enabled,
+	}
+	if user.ConnectionStringSecret.HasFormat(mdbv1.ConnectionStringFormatSRV) && srvHost != "" {
+		// Kubernetes does not publish TXT records, so the options drivers would read from them, replicaSet and
+		// authSource, are part of the connection string.
+		details.SrvURI = fmt.Sprintf("mongodb+srv://%s@%s%s?%s", credentials, srvHost, path, query.Encode())
+		details.SrvHost = srvHost
+	}
+	if len(externalHosts) > 0 {
+		details.ExternalURI = fmt.Sprintf("mongodb://%s@%s%s?%s", credentials, strings.Join(externalHosts, ","), path, query.Encode())
+	}
+	return details
+}
+
+// connectionStringSecretData returns the contents of the connection string Secret with the standard keys
+// and the keys of every additional format.
+func connectionStringSecretData(config mdbv1.ConnectionStringSecret, details connectionDetails) (map[string]string, error) {
+	data := map[string]string{
+		connectionStringUsernameKey: details.Username,
+		connectionStringPasswordKey: details.Password,
+		connectionStringStandardKey: details.URI,
+	}
+	if details.ExternalURI != "" {
+		data[connectionStringExternalKey] = details.ExternalURI
+	}
+
+	for _, format := range config.AdditionalFormats {
+		switch format {
+		case mdbv1.ConnectionStringFormatSRV:
+			data[connectionStringStandardSrvKey] = details.SrvURI
+			if details.SrvURI == "" {
+				// the SRV records can not be used, applications must not be handed a connection string which doesn't work.
+				data[connectionStringStandardSrvKey] = details.URI
+			} else {
+				data[connectionStringSrvHostKey] = details.SrvHost
+			}
+		case mdbv1.ConnectionStringFormatHosts:
+			data[connectionStringHostsKey] = strings.Join(details.Hosts, ",")
+		case mdbv1.ConnectionStringFormatProperties:
+			data[connectionStringPropertiesKey] = propertiesFileContents(details)
+		case mdbv1.ConnectionStringFormatJSON:
+			contents, err := json.Marshal(details)
+			if err != nil {
+				return nil, err
+			}
+			data[connectionStringJSONKey] = string(contents)
+		default:
+			return nil, errors.Errorf("unknown connection string format %q", format)
+		}
+	}
+	return data, nil
+}
+
+// propertiesFileContents returns the connection details in the .properties format.
+func propertiesFileContents(details connectionDetails) string {
+	properties := map[string]string{
+		"uri":        details.URI,
+		"username":   details.Username,
+		"password":   details.Password,
+		"hosts":      strings.Join(details.Hosts, ","),
+		"replicaSet": details.ReplicaSet,
+		"authSource": details.AuthSource,
+		"tls":        strconv.FormatBool(details.TLS),
+	}
+	if details.SrvURI != "" {
+		properties["srvUri"] = details.SrvURI
+		properties["srvHost"] = details.SrvHost
+	}
+	if details.ExternalURI != "" {
+		properties["externalUri"] = details.ExternalURI
+	}
+
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	contents := ""
+	for _, k := range keys {
+		contents += fmt.Sprintf("%s=%s\n", k, escaper.Replace(properties[k]))
+	}
+	return contents
+}
+
+// withSRVCondition returns the given conditions with the outcome of the SRV record verification,
+// removing it if no user requested a mongodb+srv:// connection string.
+func withSRVCondition(conditions []metav1.Condition, srvCondition *metav1.Condition, generation int64) []metav1.Condition {
+	if srvCondition == nil {
+		meta.RemoveStatusCondition(&conditions, mdbv1.ConditionSRVUnavailable)
+		return conditions
+	}
+	srvCondition.ObservedGeneration = generation
+	meta.SetStatusCondition(&conditions, *srvCondition)
+	return conditions
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/agent"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// observeVersionRollout reports the MongoDB version each member runs in the status. The agents do not report the
+// version of mongod, but the readiness probe records the version of the automation config each agent has reached
+// the goal state of in the annotations of its Pod: a member runs the MongoDB version of the automation config once
+// its agent has reached the automation config which changed the version.
+func (r ReplicaSetReconciler) observeVersionRollout(mdb *mdbv1.MongoDBCommunity) {
+	ac, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil || len(ac.Processes) == 0 {
+		// the automation config has not been published yet.
+		return
+	}
+
+	rollout := mdbv1.VersionRolloutStatus{}
+	if mdb.Status.VersionRollout != nil {
+		rollout = *mdb.Status.VersionRollout
+	}
+	if version := ac.Processes[0].Version; rollout.Version != version {
+		rollout = newVersionRollout(*mdb, version, ac.Version)
+		r.log.Infof("Rolling out MongoDB version %s with automation config version %d", version, ac.Version)
+	}
+
+	members := mdb.StatefulSetReplicasThisReconciliation()
+	if mdb.Status.CurrentStatefulSetReplicas > members {
+		members = mdb.Status.CurrentStatefulSetReplicas
+	}
+	rollout.UpdatedMembers = 0
+	rollout.Members = nil
+	for i := 0; i < members; i++ {
+		member := mdbv1.MemberVersion{Name: mdb.PodName(i)}
+		pod, err := r.client.GetPod(types.NamespacedName{Name: member.Name, Namespace: mdb.Namespace})
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				r.log.Debugf("Could not get Pod %s to observe its MongoDB version: %s", member.Name, err)
+			}
+			continue
+		}
+		if goalStateVersion, ok := agent.GoalStateVersion(pod); ok && goalStateVersion >= rollout.AutomationConfigVersion {
+			member.Version = rollout.Version
+			rollout.UpdatedMembers++
+		} else {
+			member.Version = rollout.PreviousVersion
+		}
+		rollout.Members = append(rollout.Members, member)
+	}
+	mdb.Status.VersionRollout = &rollout
+}
+
+// newVersionRollout returns the rollout of the given MongoDB version, which the automation config of the given
+// version changed to. If the previous version is not known, e.g. for a new resource, the members run the version
+// as soon as their agents report any automation config.
+func newVersionRollout(mdb mdbv1.MongoDBCommunity, version string, acVersion int) mdbv1.VersionRolloutStatus {
+	previous := mdb.GetPreviousVersion()
+	if mdb.Status.VersionRollout != nil {
+		previous = mdb.Status.VersionRollout.Version
+	}
+	if previous == "" || previous == version {
+		return mdbv1.VersionRolloutStatus{Version: version}
+	}
+	return mdbv1.VersionRolloutStatus{Version: version, PreviousVersion: previous, AutomationConfigVersion: acVersion}
+}
+
+// notReadyMessage returns the message of a resource whose members are not ready yet, which reports the progress of
+// a change of the MongoDB version, e.g. "2/3 members run MongoDB 6.0.14", or of the canary update in progress.
+func notReadyMessage(mdb mdbv1.MongoDBCommunity) string {
+	if canaryHolds(mdb) {
+		return fmt.Sprintf("%s, retrying in 10 seconds", mdb.Status.Canary.Message)
+	}
+	rollout := mdb.Status.VersionRollout
+	if rollout == nil || rollout.PreviousVersion == "" || rollout.UpdatedMembers >= len(rollout.Members) {
+		return "ReplicaSet is not yet ready, retrying in 10 seconds"
+	}
+	return fmt.Sprintf("ReplicaSet is not yet ready, %d/%d members run MongoDB %s, retrying in 10 seconds", rollout.UpdatedMembers, len(rollout.Members), rollout.Version)
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+// setAgentGoalStateVersion sets the version of the automation config the agent of the member has reached, as the
+// readiness probe does.
+func setAgentGoalStateVersion(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, member, version int) {
+	pod := corev1.Pod{}
+	err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: mdb.PodName(member), Namespace: mdb.Namespace}, &pod)
+	if err != nil {
+		pod = corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(member), Namespace: mdb.Namespace}}
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	}
+	pod.Annotations = map[string]string{"agent.mongodb.com/version": fmt.Sprint(version)}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+}
+
+func TestVersionRollout_IsReportedInTheStatus(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	for i := 0; i < 3; i++ {
+		setAgentGoalStateVersion(t, mgr, mdb, i, 1)
+	}
+	res, err = r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, &mdbv1.VersionRolloutStatus{
+		Version:        "4.2.2",
+		UpdatedMembers: 3,
+		Members:        []mdbv1.MemberVersion{{Name: "my-rs-0", Version: "4.2.2"}, {Name: "my-rs-1", Version: "4.2.2"}, {Name: "my-rs-2", Version: "4.2.2"}},
+	}, mdb.Status.VersionRollout)
+
+	mdb.Spec.Version = "4.4.0"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	for i := 0; i < 2; i++ {
+		_, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	rollout := mdb.Status.VersionRollout
+	if assert.NotNil(t, rollout) {
+		assert.Equal(t, "4.4.0", rollout.Version)
+		assert.Equal(t, "4.2.2", rollout.PreviousVersion)
+		assert.Equal(t, 0, rollout.UpdatedMembers, "no agent has reached the automation config with the new version")
+	}
+
+	setAgentGoalStateVersion(t, mgr, mdb, 2, rollout.AutomationConfigVersion)
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, 1, mdb.Status.VersionRollout.UpdatedMembers)
+	assert.Equal(t, []mdbv1.MemberVersion{{Name: "my-rs-0", Version: "4.2.2"}, {Name: "my-rs-1", Version: "4.2.2"}, {Name: "my-rs-2", Version: "4.4.0"}}, mdb.Status.VersionRollout.Members)
+	assert.Equal(t, "ReplicaSet is not yet ready, 1/3 members run MongoDB 4.4.0, retrying in 10 seconds", mdb.Status.Message)
+}
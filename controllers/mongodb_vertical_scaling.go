@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// verticalScalingChange returns a description of the change of the resources or the ephemeral storage of the
+// containers the StatefulSet is updated with, or an empty string if there is none. Such changes restart all
+// members, which the operator then does in order, the primary last.
+func (r *ReplicaSetReconciler) verticalScalingChange(mdb mdbv1.MongoDBCommunity) (string, error) {
+	existing, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	desired := *existing.DeepCopy()
+	if err := r.applyStatefulSetModifications(mdb, &desired, true); err != nil {
+		return "", err
+	}
+
+	var changes []string
+	if containers := changedContainerResources(existing.Spec.Template.Spec, desired.Spec.Template.Spec); len(containers) > 0 {
+		changes = append(changes, fmt.Sprintf("the resources of the containers %s", strings.Join(containers, ", ")))
+	}
+	if volumes := changedEmptyDirVolumes(existing.Spec.Template.Spec, desired.Spec.Template.Spec); len(volumes) > 0 {
+		changes = append(changes, fmt.Sprintf("the emptyDir volumes %s", strings.Join(volumes, ", ")))
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Changing %s", strings.Join(changes, " and ")), nil
+}
+
+// changedContainerResources returns the names of the containers of both Pod specs whose resources differ.
+func changedContainerResources(existing, desired corev1.PodSpec) []string {
+	var changed []string
+	for _, containers := range [][]corev1.Container{desired.InitContainers, desired.Containers} {
+		for _, c := range containers {
+			previous, ok := podSpecContainer(existing, c.Name)
+			if ok && !reflect.DeepEqual(previous.Resources, c.Resources) {
+				changed = append(changed, c.Name)
+			}
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// changedEmptyDirVolumes returns the names of the emptyDir volumes of both Pod specs whose medium or size differ.
+func changedEmptyDirVolumes(existing, desired corev1.PodSpec) []string {
+	var changed []string
+	for _, v := range desired.Volumes {
+		if v.EmptyDir == nil {
+			continue
+		}
+		for _, previous := range existing.Volumes {
+			if previous.Name == v.Name && previous.EmptyDir != nil && !reflect.DeepEqual(*previous.EmptyDir, *v.EmptyDir) {
+				changed = append(changed, v.Name)
+			}
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// podSpecContainer returns the container or the init container of the Pod spec with the given name.
+func podSpecContainer(spec corev1.PodSpec, name string) (corev1.Container, bool) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, c := range containers {
+			if c.Name == name {
+				return c, true
+			}
+		}
+	}
+	return corev1.Container{}, false
+}
+
+// advanceVerticalScaling restarts the next member which does not run the current revision of the StatefulSet,
+// which uses the OnDelete update strategy while the resources of the members change. The secondaries are
+// restarted first, from the highest ordinal down, and the primary is stepped down before it is restarted last.
+// Only one member is restarted at a time, once all members are ready.
+// No member is restarted once the OnDelete update strategy is configured in the spec, e.g. during the rollout, as
+// the Pods are then deleted by the user, and the members pending a restart are reported in the status instead.
+// The returned boolean is true once all members run the current revision and are ready.
+func (r *ReplicaSetReconciler) advanceVerticalScaling(mdb mdbv1.MongoDBCommunity, sts appsv1.StatefulSet) (bool, error) {
+	if mdb.Spec.UpdateStrategy.IsOnDelete() {
+		return true, r.releaseRestartPermissions(mdb)
+	}
+
+	// the StatefulSet controller has not yet observed the latest change, so the revisions are not up to date.
+	if sts.Generation != sts.Status.ObservedGeneration {
+		return false, nil
+	}
+
+	members := mdb.StatefulSetReplicasThisReconciliation()
+	var outdated []int
+	for i := 0; i < members; i++ {
+		pod, err := r.client.GetPod(types.NamespacedName{Name: mdb.PodName(i), Namespace: mdb.Namespace})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				r.log.Debugf("Waiting for member %s to be recreated", mdb.PodName(i))
+				return false, nil
+			}
+			return false, err
+		}
+		if pod.DeletionTimestamp != nil || !podReady(pod) {
+			r.log.Debugf("Waiting for member %s to be ready before the next member is restarted", pod.Name)
+			return false, nil
+		}
+		if pod.Labels[appsv1.StatefulSetRevisionLabel] != sts.Status.UpdateRevision {
+			outdated = append(outdated, i)
+		}
+	}
+	if len(outdated) == 0 {
+		return statefulset.IsReady(sts, members), r.releaseRestartPermissions(mdb)
+	}
+
+	acquired, err := r.acquireRestartPermissions(mdb)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	hostnames, uri, tlsConfig, err := r.agentConnection(mdb, members)
+	if err != nil {
+		return false, errors.Errorf("could not connect to the members to find the primary: %s", err)
+	}
+	status, err := r.primaryReplSetStatus(hostnames, uri, tlsConfig)
+	if err != nil {
+		return false, errors.Errorf("could not read the replica set status to find the primary: %s", err)
+	}
+	primaryIndex := -1
+	if primary, ok := status.Primary(); ok {
+		for i, hostname := range hostnames {
+			if net.JoinHostPort(hostname, "27017") == primary.Name {
+				primaryIndex = i
+			}
+		}
+	}
+
+	for j := len(outdated) - 1; j >= 0; j-- {
+		if i := outdated[j]; i != primaryIndex {
+			return false, r.restartMember(mdb, i)
+		}
+	}
+
+	r.log.Infof("Stepping down the primary %s before it is restarted", mdb.PodName(primaryIndex))
+	ctx, cancel := context.WithTimeout(context.Background(), scaleDownStatusTimeout)
+	defer cancel()
+	if err := r.stepDowner.StepDown(ctx, uri(hostnames[primaryIndex]), tlsConfig); err != nil {
+		return false, errors.Errorf("could not step down the primary %s: %s", mdb.PodName(primaryIndex), err)
+	}
+	// the member is restarted by a later reconciliation, once another member has been elected primary.
+	return false, nil
+}
+
+// restartMember deletes the Pod of the member with the given index, which the StatefulSet controller recreates
+// with the current revision. It must not be called if the OnDelete update strategy is configured in the spec.
+func (r *ReplicaSetReconciler) restartMember(mdb mdbv1.MongoDBCommunity, index int) error {
+	r.log.Infof("Restarting member %s", mdb.PodName(index))
+	pod := corev1.Pod{}
+	pod.Name = mdb.PodName(index)
+	pod.Namespace = mdb.Namespace
+	if err := r.client.Delete(context.TODO(), &pod); err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Errorf("could not restart member %s: %s", pod.Name, err)
+	}
+	return nil
+}
+
+// podReady returns true if the Pod reports the Ready condition.
+func podReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
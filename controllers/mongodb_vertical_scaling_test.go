@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
+)
+
+// mockStepDowner records the connection strings of the primaries it stepped down.
+type mockStepDowner struct {
+	steppedDown []string
+}
+
+func (m *mockStepDowner) StepDown(_ context.Context, connectionString string, _ *tls.Config) error {
+	m.steppedDown = append(m.steppedDown, connectionString)
+	return nil
+}
+
+// setReadyMember creates or updates the Pod of the member with the given StatefulSet revision, as ready with its
+// agent in goal state.
+func setReadyMember(t *testing.T, mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, ordinal int, revision string) {
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: mdb.PodName(ordinal), Namespace: mdb.Namespace}}
+	exists := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, &pod) == nil
+	pod.Labels = map[string]string{"app": mdb.ServiceName(), appsv1.StatefulSetRevisionLabel: revision}
+	pod.Annotations = map[string]string{"agent.mongodb.com/version": strconv.Itoa(ac.Version)}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if exists {
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &pod))
+	} else {
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), &pod))
+	}
+}
+
+func memberExists(mgr *client.MockedManager, mdb mdbv1.MongoDBCommunity, ordinal int) bool {
+	_, err := mgr.Client.GetPod(types.NamespacedName{Name: mdb.PodName(ordinal), Namespace: mdb.Namespace})
+	return !apiErrors.IsNotFound(err)
+}
+
+func TestVerticalScaling_RestartsThePrimaryLast(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	reader := &mockStatusReader{status: replicationStatus(mdb, 3, nil)}
+	r.replicationStatus = reader
+	stepDowner := &mockStepDowner{}
+	r.stepDowner = stepDowner
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	for i := 0; i < 3; i++ {
+		setReadyMember(t, mgr, mdb, i, "rev-1")
+	}
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name: "mongod",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		},
+	}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotNil(t, mdb.Status.OnDeleteUpdateStrategy)
+	assert.Equal(t, mdbv1.OnDeleteUpdateStrategyReasonVerticalScaling, mdb.Status.OnDeleteUpdateStrategy.Reason)
+	assert.Equal(t, "Changing the resources of the containers mongod", mdb.Status.OnDeleteUpdateStrategy.Message)
+	sts, err := mgr.Client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, appsv1.OnDeleteStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+
+	setStatefulSetRevision(t, mgr, mdb, 0)
+
+	t.Run("The secondaries are restarted first, from the highest ordinal down", func(t *testing.T) {
+		for _, ordinal := range []int{2, 1} {
+			_, err := r.Reconcile(context.TODO(), req)
+			assert.NoError(t, err)
+			assert.False(t, memberExists(mgr, mdb, ordinal))
+			assert.True(t, memberExists(mgr, mdb, 0))
+
+			setReadyMember(t, mgr, mdb, ordinal, "rev-2")
+		}
+		assert.Empty(t, stepDowner.steppedDown)
+	})
+
+	t.Run("The primary is stepped down before it is restarted", func(t *testing.T) {
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.True(t, memberExists(mgr, mdb, 0))
+		assert.Len(t, stepDowner.steppedDown, 1)
+		assert.Contains(t, stepDowner.steppedDown[0], mdb.PodName(0))
+
+		reader.status.Members[0].StateStr = replication.StateSecondary
+		reader.status.Members[1].StateStr = replication.StatePrimary
+		_, err = r.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.False(t, memberExists(mgr, mdb, 0))
+		setReadyMember(t, mgr, mdb, 0, "rev-2")
+	})
+
+	t.Run("The RollingUpdate strategy is used again once all members are restarted", func(t *testing.T) {
+		setStatefulSetRevision(t, mgr, mdb, 3)
+		res, err := r.Reconcile(context.TODO(), req)
+		assertReconciliationSuccessful(t, res, err)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Nil(t, mdb.Status.OnDeleteUpdateStrategy)
+		sts, err := mgr.Client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+		assert.NoError(t, err)
+		assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+	})
+}
+
+func TestVerticalScaling_StopsRestartingMembersWithTheOnDeleteUpdateStrategy(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.replicationStatus = &mockStatusReader{status: replicationStatus(mdb, 3, nil)}
+	r.stepDowner = &mockStepDowner{}
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+	for i := 0; i < 3; i++ {
+		setReadyMember(t, mgr, mdb, i, "rev-1")
+	}
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name: "mongod",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		},
+	}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.False(t, memberExists(mgr, mdb, 2))
+	setReadyMember(t, mgr, mdb, 2, "rev-2")
+	setStatefulSetRevision(t, mgr, mdb, 1)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.True(t, mdb.IsScalingVertically())
+	mdb.Spec.UpdateStrategy = &mdbv1.UpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.True(t, memberExists(mgr, mdb, i), "the members are not restarted by the operator")
+	}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, []string{mdb.PodName(0), mdb.PodName(1)}, mdb.Status.PendingRestarts)
+}
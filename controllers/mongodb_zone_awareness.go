@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/podtemplatespec"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// zoneMajorityWriteConcernSuffix is appended to the name of a tag to form the name of the write concern which
+// requires writes to be acknowledged in a majority of the domains of the tag.
+const zoneMajorityWriteConcernSuffix = "Majority"
+
+// validateZoneAwareness checks that the topology keys are not empty and result in distinct tags.
+func validateZoneAwareness(mdb mdbv1.MongoDBCommunity) error {
+	var tags []string
+	for _, key := range mdb.Spec.ZoneAwareness.GetTopologyKeys() {
+		tag := zoneTagName(key)
+		if tag == "" {
+			return errors.New("zoneAwareness.topologyKeys must not contain empty keys")
+		}
+		if contains.String(tags, tag) {
+			return errors.Errorf("zoneAwareness.topologyKeys contains more than one key with the name %s", tag)
+		}
+		tags = append(tags, tag)
+	}
+	return nil
+}
+
+// zoneTagName returns the name of the tag of the given topology key, which is the name of the label without its
+// prefix, e.g. zone for topology.kubernetes.io/zone.
+func zoneTagName(topologyKey string) string {
+	return topologyKey[strings.LastIndex(topologyKey, "/")+1:]
+}
+
+// buildZoneAwarenessPodSpecModification spreads the Pods of the members evenly across the domains of each
+// topology key, or removes the constraints if zone awareness is not enabled. Constraints configured in the
+// StatefulSet override are kept.
+func buildZoneAwarenessPodSpecModification(mdb mdbv1.MongoDBCommunity) podtemplatespec.Modification {
+	selector := map[string]string{"app": mdb.ServiceName()}
+	return func(template *corev1.PodTemplateSpec) {
+		var constraints []corev1.TopologySpreadConstraint
+		for _, constraint := range template.Spec.TopologySpreadConstraints {
+			if !isZoneAwarenessConstraint(constraint, selector) {
+				constraints = append(constraints, constraint)
+			}
+		}
+		for _, key := range mdb.Spec.ZoneAwareness.GetTopologyKeys() {
+			constraints = append(constraints, corev1.TopologySpreadConstraint{
+				MaxSkew:           1,
+				TopologyKey:       key,
+				WhenUnsatisfiable: mdb.Spec.ZoneAwareness.GetWhenUnsatisfiable(),
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: selector},
+			})
+		}
+		template.Spec.TopologySpreadConstraints = constraints
+	}
+}
+
+func isZoneAwarenessConstraint(constraint corev1.TopologySpreadConstraint, selector map[string]string) bool {
+	labelSelector := constraint.LabelSelector
+	if labelSelector == nil || len(labelSelector.MatchExpressions) > 0 || len(labelSelector.MatchLabels) != len(selector) {
+		return false
+	}
+	for key, value := range selector {
+		if labelSelector.MatchLabels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// memberZoneTags returns the tags of each member by the name of its Pod, which are the values of the topology
+// keys in the labels of the node the member runs on. A member whose Pod is not scheduled keeps the tags of the
+// current automation config, so that a rescheduled member does not cause two reconfigurations of the replica set.
+func (r ReplicaSetReconciler) memberZoneTags(mdb mdbv1.MongoDBCommunity, currentAC automationconfig.AutomationConfig) (map[string]map[string]string, error) {
+	keys := mdb.Spec.ZoneAwareness.GetTopologyKeys()
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	currentTags := map[string]map[string]string{}
+	for _, rs := range currentAC.ReplicaSets {
+		for _, member := range rs.Members {
+			currentTags[member.Host] = member.Tags
+		}
+	}
+
+	memberTags := map[string]map[string]string{}
+	for i := 0; i < mdb.AutomationConfigMembersThisReconciliation(); i++ {
+		podName := mdb.PodName(i)
+		node, err := r.nodeOfPod(mdb.Namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			if tags, ok := currentTags[podName]; ok {
+				memberTags[podName] = tags
+			}
+			continue
+		}
+		tags := map[string]string{}
+		for _, key := range keys {
+			if value, ok := node.Labels[key]; ok {
+				tags[zoneTagName(key)] = value
+			}
+		}
+		if len(tags) > 0 {
+			memberTags[podName] = tags
+		}
+	}
+	return memberTags, nil
+}
+
+// zoneAwarenessModification tags the members with the given tags by the names of their Pods, and defines a write
+// concern for each tag which requires writes to be acknowledged by the members in a majority of its domains. The
+// majority is taken of the domains the members are tagged with, so that the write concern can always be satisfied.
+func zoneAwarenessModification(memberTags map[string]map[string]string) automationconfig.Modification {
+	if len(memberTags) == 0 {
+		return automationconfig.NOOP()
+	}
+
+	return func(config *automationconfig.AutomationConfig) {
+		for i := range config.ReplicaSets {
+			rs := &config.ReplicaSets[i]
+			domains := map[string]map[string]bool{}
+			for j := range rs.Members {
+				tags, ok := memberTags[rs.Members[j].Host]
+				if !ok {
+					continue
+				}
+				rs.Members[j].Tags = tags
+				for tag, value := range tags {
+					if domains[tag] == nil {
+						domains[tag] = map[string]bool{}
+					}
+					domains[tag][value] = true
+				}
+			}
+
+			if len(domains) == 0 {
+				continue
+			}
+			modes := map[string]map[string]int{}
+			for tag, values := range domains {
+				modes[tag+zoneMajorityWriteConcernSuffix] = map[string]int{tag: len(values)/2 + 1}
+			}
+			rs.Settings = &automationconfig.ReplicaSetSettings{GetLastErrorModes: modes}
+		}
+	}
+}
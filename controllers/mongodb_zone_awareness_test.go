@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+)
+
+func TestZoneAwareness_SpreadsAndTagsMembers(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.ZoneAwareness = &mdbv1.ZoneAwareness{TopologyKeys: []string{corev1.LabelTopologyZone}}
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+	assert.NoError(t, err)
+	if assert.Len(t, sts.Spec.Template.Spec.TopologySpreadConstraints, 1) {
+		constraint := sts.Spec.Template.Spec.TopologySpreadConstraints[0]
+		assert.Equal(t, corev1.LabelTopologyZone, constraint.TopologyKey)
+		assert.Equal(t, int32(1), constraint.MaxSkew)
+		assert.Equal(t, corev1.DoNotSchedule, constraint.WhenUnsatisfiable)
+		assert.Equal(t, map[string]string{"app": mdb.ServiceName()}, constraint.LabelSelector.MatchLabels)
+	}
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for i, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+		assert.Equal(t, map[string]string{"zone": zone}, ac.ReplicaSets[0].Members[i].Tags)
+	}
+	if assert.NotNil(t, ac.ReplicaSets[0].Settings) {
+		assert.Equal(t, map[string]map[string]int{"zoneMajority": {"zone": 2}}, ac.ReplicaSets[0].Settings.GetLastErrorModes)
+	}
+
+	t.Run("Disabling zone awareness removes the constraints and the tags", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.ZoneAwareness = nil
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+		reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+		sts, err := mgr.Client.GetStatefulSet(mdb.NamespacedName())
+		assert.NoError(t, err)
+		assert.Empty(t, sts.Spec.Template.Spec.TopologySpreadConstraints)
+		ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Nil(t, ac.ReplicaSets[0].Members[0].Tags)
+		assert.Nil(t, ac.ReplicaSets[0].Settings)
+	})
+}
+
+func TestZoneAwarenessModification_MajorityOfTaggedDomains(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Members = 5
+	ac, err := buildAutomationConfig(mdb, automationconfig.Auth{}, automationconfig.AutomationConfig{}, zoneAwarenessModification(map[string]map[string]string{
+		mdb.PodName(0): {"zone": "zone-a", "region": "eu"},
+		mdb.PodName(1): {"zone": "zone-b", "region": "eu"},
+		mdb.PodName(2): {"zone": "zone-c", "region": "us"},
+		mdb.PodName(3): {"zone": "zone-d", "region": "us"},
+	}))
+	assert.NoError(t, err)
+
+	assert.Nil(t, ac.ReplicaSets[0].Members[4].Tags)
+	assert.Equal(t, map[string]map[string]int{"zoneMajority": {"zone": 3}, "regionMajority": {"region": 2}}, ac.ReplicaSets[0].Settings.GetLastErrorModes)
+}
+
+func TestValidateZoneAwareness(t *testing.T) {
+	mdb := newTestReplicaSet()
+	assert.NoError(t, validateZoneAwareness(mdb))
+
+	mdb.Spec.ZoneAwareness = &mdbv1.ZoneAwareness{TopologyKeys: []string{corev1.LabelTopologyZone, corev1.LabelTopologyRegion}}
+	assert.NoError(t, validateZoneAwareness(mdb))
+
+	mdb.Spec.ZoneAwareness.TopologyKeys = []string{corev1.LabelTopologyZone, "example.com/zone"}
+	assert.EqualError(t, validateZoneAwareness(mdb), "zoneAwareness.topologyKeys contains more than one key with the name zone")
+
+	mdb.Spec.ZoneAwareness.TopologyKeys = []string{"example.com/"}
+	assert.EqualError(t, validateZoneAwareness(mdb), "zoneAwareness.topologyKeys must not contain empty keys")
+}
@@ -0,0 +1,573 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/watch"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/agent"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/generate"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const (
+	multiClusterLoggerName = "multicluster"
+
+	// multiClusterFinalizer makes sure the objects created in the member clusters are deleted together with the
+	// resource, as they can not be owned by a resource in another cluster.
+	multiClusterFinalizer = "mongodbcommunity.mongodb.com/member-clusters"
+
+	// multiClusterRetryInterval is how often a resource is reconciled again while its members are not ready,
+	// as the objects in the member clusters are not watched.
+	multiClusterRetryInterval = 10
+)
+
+// MultiClusterReconciler deploys the members of MongoDBCommunityMultiCluster resources to their member clusters.
+// The automation config, spanning the members of all clusters, is built in the cluster the operator runs in
+// and copied to every member cluster, where it is read by the agents.
+type MultiClusterReconciler struct {
+	client        kubernetesClient.Client
+	log           *zap.SugaredLogger
+	passwords     generate.PasswordGenerator
+	secretWatcher *watch.ResourceWatcher
+
+	// clusterClients creates the clients of the member clusters from their kubeconfigs.
+	clusterClients *memberClusterClients
+}
+
+func NewMultiClusterReconciler(mgr manager.Manager) *MultiClusterReconciler {
+	// the password policy is validated when the operator starts.
+	passwordPolicy, _ := passwordPolicyFromEnv()
+	secretWatcher := watch.New()
+	return &MultiClusterReconciler{
+		client:         kubernetesClient.NewClient(mgr.GetClient()),
+		log:            zap.S().Named(multiClusterLoggerName),
+		passwords:      generate.NewPasswordGenerator(passwordPolicy),
+		secretWatcher:  &secretWatcher,
+		clusterClients: newMemberClusterClients(mgr.GetScheme()),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager and configures the necessary watches. The kubeconfigs
+// of the member clusters and the Secrets of the agent are watched, so that changes to them reach the member
+// clusters.
+func (r *MultiClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mdbv1.MongoDBCommunityMultiCluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, r.secretWatcher).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitymulticluster,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitymulticluster/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitymulticluster/finalizers,verbs=update
+
+// Reconcile deploys the members of the resource to its member clusters. Members are added or removed one at a
+// time, in the order of the clusters, and the objects in the member clusters are deleted with the resource.
+func (r MultiClusterReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	mdb := mdbv1.MongoDBCommunityMultiCluster{}
+	if err := r.client.Get(ctx, request.NamespacedName, &mdb); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return result.OK()
+		}
+		r.log.Errorf("Error reconciling MongoDBCommunityMultiCluster resource: %s", err)
+		return result.Failed()
+	}
+	r.log = zap.S().Named(multiClusterLoggerName).With("MultiCluster", request.NamespacedName)
+
+	if mdb.DeletionTimestamp != nil {
+		return r.deleteMemberClusterObjects(mdb)
+	}
+	if !controllerutil.ContainsFinalizer(&mdb, multiClusterFinalizer) {
+		controllerutil.AddFinalizer(&mdb, multiClusterFinalizer)
+		if err := r.client.Update(ctx, &mdb); err != nil {
+			r.log.Errorf("Could not add the finalizer: %s", err)
+			return result.Failed()
+		}
+	}
+
+	if err := validateMemberClusters(mdb); err != nil {
+		return r.updateMultiClusterStatus(mdb, mdbv1.Failed, err.Error(), nil)
+	}
+
+	clients, err := r.memberClusterClients(mdb)
+	if err != nil {
+		return r.updateMultiClusterStatus(mdb, mdbv1.Pending, err.Error(), nil)
+	}
+
+	members, scalingUp := membersThisReconciliation(mdb)
+	ac, err := r.buildMultiClusterAutomationConfig(mdb, members)
+	if err != nil {
+		return r.updateMultiClusterStatus(mdb, mdbv1.Failed, fmt.Sprintf("Error building the automation config: %s", err), nil)
+	}
+	if err := r.deployMemberClusterAgentSecrets(mdb, clients); err != nil {
+		return r.updateMultiClusterStatus(mdb, mdbv1.Pending, err.Error(), nil)
+	}
+
+	// new members are added to the automation config once their Pods exist, and removed members are removed
+	// from it before their Pods are deleted.
+	if scalingUp {
+		err = r.deployMemberClusterStatefulSets(mdb, clients, members)
+		if err == nil {
+			err = r.deployMemberClusterAutomationConfigs(mdb, clients, ac)
+		}
+	} else {
+		err = r.deployMemberClusterAutomationConfigs(mdb, clients, ac)
+		if err == nil {
+			err = r.deployMemberClusterStatefulSets(mdb, clients, members)
+		}
+	}
+	if err != nil {
+		return r.updateMultiClusterStatus(mdb, mdbv1.Pending, err.Error(), nil)
+	}
+
+	for _, cluster := range mdb.Spec.Clusters {
+		ready, err := r.memberClusterReady(mdb.ClusterMembers(cluster), clients[cluster.Name], members[cluster.Name], ac.Version)
+		if err != nil {
+			return r.updateMultiClusterStatus(mdb, mdbv1.Pending, fmt.Sprintf("Error checking the members of cluster %s: %s", cluster.Name, err), nil)
+		}
+		if !ready {
+			// the members are only recorded once they are ready, so that the next change waits for them.
+			return r.updateMultiClusterStatus(mdb, mdbv1.Pending, fmt.Sprintf("Waiting for the members of cluster %s to be ready", cluster.Name), nil)
+		}
+	}
+
+	for _, cluster := range mdb.Spec.Clusters {
+		if members[cluster.Name] != cluster.Members {
+			return r.updateMultiClusterStatus(mdb, mdbv1.Pending, fmt.Sprintf("Scaling the members of cluster %s from %d to %d", cluster.Name, members[cluster.Name], cluster.Members), members)
+		}
+	}
+	return r.updateMultiClusterStatus(mdb, mdbv1.Running, "", members)
+}
+
+// validateMemberClusters returns an error if the clusters of the resource can not be deployed.
+func validateMemberClusters(mdb mdbv1.MongoDBCommunityMultiCluster) error {
+	names := map[string]bool{}
+	total := 0
+	for _, cluster := range mdb.Spec.Clusters {
+		if names[cluster.Name] {
+			return errors.Errorf("cluster %s is listed more than once", cluster.Name)
+		}
+		names[cluster.Name] = true
+		total += cluster.Members
+	}
+	if total == 0 {
+		return errors.New("at least one cluster must have members")
+	}
+	for _, cluster := range mdb.Status.Clusters {
+		if !names[cluster.Name] && cluster.Members > 0 {
+			return errors.Errorf("cluster %s still has %d members, set its members to 0 before removing it", cluster.Name, cluster.Members)
+		}
+	}
+	return nil
+}
+
+// membersThisReconciliation returns the number of members of each cluster which are part of the replica set
+// configuration after this reconciliation, and whether a member is being added. All members are added at once
+// when the resource is created, and afterwards one member is added or removed at a time, in the order of the
+// clusters.
+func membersThisReconciliation(mdb mdbv1.MongoDBCommunityMultiCluster) (map[string]int, bool) {
+	members := map[string]int{}
+	if len(mdb.Status.Clusters) == 0 {
+		for _, cluster := range mdb.Spec.Clusters {
+			members[cluster.Name] = cluster.Members
+		}
+		return members, false
+	}
+
+	for _, cluster := range mdb.Status.Clusters {
+		members[cluster.Name] = cluster.Members
+	}
+	for _, cluster := range mdb.Spec.Clusters {
+		current := members[cluster.Name]
+		switch {
+		case current < cluster.Members:
+			members[cluster.Name] = current + 1
+			return members, true
+		case current > cluster.Members:
+			members[cluster.Name] = current - 1
+			return members, false
+		}
+	}
+	return members, false
+}
+
+// buildMultiClusterAutomationConfig builds the automation config of the replica set formed by the given number
+// of members of each cluster. The previous automation config is read from the cluster the operator runs in.
+func (r MultiClusterReconciler) buildMultiClusterAutomationConfig(mdb mdbv1.MongoDBCommunityMultiCluster, members map[string]int) (automationconfig.AutomationConfig, error) {
+	currentAC, err := automationconfig.ReadFromSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not read existing automation config: %s", err)
+	}
+
+	auth := automationconfig.Auth{}
+	if err := scram.Enable(&auth, r.client, mdb, r.passwords); err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure scram authentication: %s", err)
+	}
+
+	var podNames []string
+	for _, cluster := range mdb.Spec.Clusters {
+		clusterMembers := mdb.ClusterMembers(cluster)
+		for i := 0; i < members[cluster.Name]; i++ {
+			podNames = append(podNames, clusterMembers.PodName(i))
+		}
+	}
+	hostnames := mdb.MemberHostnames(members)
+
+	ac, err := automationconfig.NewBuilder().
+		SetTopology(automationconfig.ReplicaSetTopology).
+		SetName(mdb.Name).
+		SetReplicaSetName(mdb.GetReplicaSetName()).
+		SetMembers(len(hostnames)).
+		SetPreviousAutomationConfig(currentAC).
+		SetMongoDBVersion(mdb.Spec.Version).
+		SetFCV(mdb.Spec.FeatureCompatibilityVersion).
+		SetOptions(automationconfig.Options{DownloadBase: "/var/lib/mongodb-mms-automation"}).
+		SetAuth(auth).
+		AddProcessModification(func(i int, p *automationconfig.Process) {
+			p.Name = podNames[i]
+			p.HostName = hostnames[i]
+		}).
+		AddModifications(multiClusterMembersModification(currentAC)).
+		Build()
+	if err != nil {
+		return automationconfig.AutomationConfig{}, err
+	}
+
+	// the automation config of the operator cluster is the source of the version of the next one.
+	return automationconfig.EnsureSecret(r.client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}, mdb.GetOwnerReferences(), ac)
+}
+
+// multiClusterMembersModification keeps the ids of the members of the previous automation config, as the
+// position of a member changes when members are added to a cluster listed before its own, and gives the new
+// members the lowest unused ids. Only the first seven members vote.
+func multiClusterMembersModification(previous automationconfig.AutomationConfig) automationconfig.Modification {
+	previousIds := map[string]int{}
+	for _, rs := range previous.ReplicaSets {
+		for _, m := range rs.Members {
+			previousIds[m.Host] = m.Id
+		}
+	}
+	return func(ac *automationconfig.AutomationConfig) {
+		for i := range ac.ReplicaSets {
+			members := ac.ReplicaSets[i].Members
+			used := map[int]bool{}
+			for _, m := range members {
+				if id, ok := previousIds[m.Host]; ok {
+					used[id] = true
+				}
+			}
+			next := 0
+			for j := range members {
+				if id, ok := previousIds[members[j].Host]; ok {
+					members[j].Id = id
+				} else {
+					for used[next] {
+						next++
+					}
+					members[j].Id = next
+					used[next] = true
+				}
+				if j >= maxVotingMembers {
+					members[j].Votes = 0
+					members[j].Priority = 0
+				}
+			}
+		}
+	}
+}
+
+// deployMemberClusterAutomationConfigs copies the automation config to every member cluster.
+func (r MultiClusterReconciler) deployMemberClusterAutomationConfigs(mdb mdbv1.MongoDBCommunityMultiCluster, clients map[string]kubernetesClient.Client, ac automationconfig.AutomationConfig) error {
+	for _, cluster := range mdb.Spec.Clusters {
+		nsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+		if _, err := automationconfig.EnsureSecret(clients[cluster.Name], nsName, nil, ac); err != nil {
+			return errors.Errorf("could not deploy the automation config to cluster %s: %s", cluster.Name, err)
+		}
+	}
+	return nil
+}
+
+// agentSecretNames returns the names of the Secrets holding the keyfile and the password of the agent.
+func agentSecretNames(mdb mdbv1.MongoDBCommunityMultiCluster) []types.NamespacedName {
+	return []types.NamespacedName{mdb.GetAgentKeyfileSecretNamespacedName(), mdb.GetAgentPasswordSecretNamespacedName()}
+}
+
+// deployMemberClusterAgentSecrets copies the Secrets holding the keyfile and the password of the agent from the
+// cluster the operator runs in to the other member clusters, so that the credentials the agents authenticate
+// with are available in every cluster running members.
+func (r MultiClusterReconciler) deployMemberClusterAgentSecrets(mdb mdbv1.MongoDBCommunityMultiCluster, clients map[string]kubernetesClient.Client) error {
+	for _, nsName := range agentSecretNames(mdb) {
+		r.secretWatcher.Watch(nsName, mdb.NamespacedName())
+		data, err := secret.ReadByteData(r.client, nsName)
+		if err != nil {
+			return errors.Errorf("could not read Secret %s: %s", nsName.Name, err)
+		}
+		copied := secret.Builder().
+			SetName(nsName.Name).
+			SetNamespace(nsName.Namespace).
+			SetLabels(map[string]string{mdbv1.MultiClusterLabel: mdb.Name}).
+			SetByteData(data).
+			Build()
+		for _, cluster := range mdb.Spec.Clusters {
+			if cluster.KubeconfigSecretRef == nil {
+				continue
+			}
+			if err := secret.CreateOrUpdate(clients[cluster.Name], copied); err != nil {
+				return errors.Errorf("could not deploy Secret %s to cluster %s: %s", nsName.Name, cluster.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deployMemberClusterStatefulSets creates or updates the headless Service and the StatefulSet of the members
+// of every member cluster.
+func (r MultiClusterReconciler) deployMemberClusterStatefulSets(mdb mdbv1.MongoDBCommunityMultiCluster, clients map[string]kubernetesClient.Client, members map[string]int) error {
+	for _, cluster := range mdb.Spec.Clusters {
+		clusterMembers := mdb.ClusterMembers(cluster)
+		c := clients[cluster.Name]
+
+		if err := service.CreateOrUpdate(c, buildMemberClusterService(mdb, clusterMembers)); err != nil {
+			return errors.Errorf("could not create or update the Service of cluster %s: %s", cluster.Name, err)
+		}
+
+		sts := appsv1.StatefulSet{}
+		if err := c.Get(context.TODO(), clusterMembers.StatefulSetNamespacedName(), &sts); k8sClient.IgnoreNotFound(err) != nil {
+			return errors.Errorf("could not get the StatefulSet of cluster %s: %s", cluster.Name, err)
+		}
+		statefulset.Apply(
+			construct.BuildMongoDBReplicaSetStatefulSetModificationFunction(clusterMembers, fixedReplicas(members[cluster.Name])),
+			statefulset.WithLabels(map[string]string{"app": clusterMembers.ServiceName(), mdbv1.MultiClusterLabel: mdb.Name}),
+		)(&sts)
+		if _, err := statefulset.CreateOrUpdate(c, sts); err != nil {
+			return errors.Errorf("could not create or update the StatefulSet of cluster %s: %s", cluster.Name, err)
+		}
+	}
+	return nil
+}
+
+// buildMemberClusterService builds the headless Service governing the StatefulSet of the members of a cluster.
+func buildMemberClusterService(mdb mdbv1.MongoDBCommunityMultiCluster, clusterMembers mdbv1.ClusterMembers) corev1.Service {
+	return service.Builder().
+		SetName(clusterMembers.ServiceName()).
+		SetNamespace(mdb.Namespace).
+		SetSelector(map[string]string{"app": clusterMembers.ServiceName()}).
+		SetLabels(map[string]string{mdbv1.MultiClusterLabel: mdb.Name}).
+		SetServiceType(corev1.ServiceTypeClusterIP).
+		SetClusterIP("None").
+		SetPort(automationconfig.DefaultDBPort).
+		SetPublishNotReadyAddresses(true).
+		Build()
+}
+
+// memberClusterReady returns true once the StatefulSet of the cluster has the given number of ready members
+// whose agents reached the goal state of the automation config.
+func (r MultiClusterReconciler) memberClusterReady(clusterMembers mdbv1.ClusterMembers, c kubernetesClient.Client, members, acVersion int) (bool, error) {
+	sts, err := c.GetStatefulSet(clusterMembers.StatefulSetNamespacedName())
+	if err != nil {
+		return false, err
+	}
+	if !statefulset.IsReady(sts, members) {
+		return false, nil
+	}
+	return agent.AllReachedGoalState(sts, c, members, acVersion, r.log)
+}
+
+// deleteMemberClusterObjects deletes the objects created in the member clusters and removes the finalizer. The
+// clients of the member clusters are discarded afterwards.
+func (r MultiClusterReconciler) deleteMemberClusterObjects(mdb mdbv1.MongoDBCommunityMultiCluster) (reconcile.Result, error) {
+	if !controllerutil.ContainsFinalizer(&mdb, multiClusterFinalizer) {
+		return result.OK()
+	}
+	clients, err := r.memberClusterClients(mdb)
+	if err != nil {
+		r.log.Errorf("Could not delete the objects in the member clusters: %s", err)
+		return result.Retry(multiClusterRetryInterval)
+	}
+	for _, cluster := range mdb.Spec.Clusters {
+		clusterMembers := mdb.ClusterMembers(cluster)
+		c := clients[cluster.Name]
+		sts := appsv1.StatefulSet{}
+		sts.Name, sts.Namespace = clusterMembers.StatefulSetName(), mdb.Namespace
+		svc := corev1.Service{}
+		svc.Name, svc.Namespace = clusterMembers.ServiceName(), mdb.Namespace
+		acSecret := corev1.Secret{}
+		acSecret.Name, acSecret.Namespace = mdb.AutomationConfigSecretName(), mdb.Namespace
+		objects := []k8sClient.Object{&sts, &svc, &acSecret}
+		if cluster.KubeconfigSecretRef != nil {
+			// the Secrets of the agent in the cluster the operator runs in are owned by the resource.
+			for _, nsName := range agentSecretNames(mdb) {
+				agentSecret := corev1.Secret{}
+				agentSecret.Name, agentSecret.Namespace = nsName.Name, nsName.Namespace
+				objects = append(objects, &agentSecret)
+			}
+		}
+		for _, obj := range objects {
+			if err := c.Delete(context.TODO(), obj); err != nil && !apiErrors.IsNotFound(err) {
+				r.log.Errorf("Could not delete %s in cluster %s: %s", obj.GetName(), cluster.Name, err)
+				return result.Retry(multiClusterRetryInterval)
+			}
+		}
+	}
+	controllerutil.RemoveFinalizer(&mdb, multiClusterFinalizer)
+	if err := r.client.Update(context.TODO(), &mdb); err != nil {
+		r.log.Errorf("Could not remove the finalizer: %s", err)
+		return result.Failed()
+	}
+	r.clusterClients.forget(mdb.NamespacedName())
+	return result.OK()
+}
+
+// updateMultiClusterStatus updates the phase and the message of the resource, and the members of each cluster
+// which are part of the replica set configuration if they are given. Pending resources are reconciled again later.
+func (r MultiClusterReconciler) updateMultiClusterStatus(mdb mdbv1.MongoDBCommunityMultiCluster, phase mdbv1.Phase, message string, members map[string]int) (reconcile.Result, error) {
+	mdb.Status.Phase = phase
+	mdb.Status.Message = message
+	if members != nil {
+		mdb.Status.Clusters = nil
+		for _, cluster := range mdb.Spec.Clusters {
+			mdb.Status.Clusters = append(mdb.Status.Clusters, mdbv1.MemberClusterStatus{Name: cluster.Name, Members: members[cluster.Name]})
+		}
+	}
+	if phase == mdbv1.Running {
+		mdb.Status.MongoURI = mdb.MongoURI()
+	}
+	if err := r.client.Status().Update(context.TODO(), &mdb); err != nil {
+		r.log.Errorf("Error updating the status of the MongoDBCommunityMultiCluster resource: %s", err)
+		return reconcile.Result{}, err
+	}
+	switch phase {
+	case mdbv1.Pending:
+		r.log.Infof("%s", message)
+		return result.Retry(multiClusterRetryInterval)
+	case mdbv1.Failed:
+		r.log.Errorf("%s", message)
+		return result.Failed()
+	}
+	return result.OK()
+}
+
+// fixedReplicas scales a StatefulSet to the given number of replicas at once.
+type fixedReplicas int
+
+func (f fixedReplicas) DesiredReplicas() int {
+	return int(f)
+}
+
+func (f fixedReplicas) CurrentReplicas() int {
+	return int(f)
+}
+
+// memberClusterClients returns the clients of the member clusters of the resource, by the name of the cluster.
+func (r MultiClusterReconciler) memberClusterClients(mdb mdbv1.MongoDBCommunityMultiCluster) (map[string]kubernetesClient.Client, error) {
+	clients := map[string]kubernetesClient.Client{}
+	for _, cluster := range mdb.Spec.Clusters {
+		if cluster.KubeconfigSecretRef == nil {
+			clients[cluster.Name] = r.client
+			continue
+		}
+		kubeconfigNsName := types.NamespacedName{Name: cluster.KubeconfigSecretRef.Name, Namespace: mdb.Namespace}
+		r.secretWatcher.Watch(kubeconfigNsName, mdb.NamespacedName())
+		kubeconfigSecret, err := r.client.GetSecret(kubeconfigNsName)
+		if err != nil {
+			return nil, errors.Errorf("could not read the kubeconfig of cluster %s: %s", cluster.Name, err)
+		}
+		kubeconfig, ok := kubeconfigSecret.Data[mdbv1.KubeconfigKey]
+		if !ok {
+			return nil, errors.Errorf("Secret %s of cluster %s has no %q key", kubeconfigSecret.Name, cluster.Name, mdbv1.KubeconfigKey)
+		}
+		c, err := r.clusterClients.get(mdb.NamespacedName(), cluster.Name, kubeconfig)
+		if err != nil {
+			return nil, errors.Errorf("could not connect to cluster %s: %s", cluster.Name, err)
+		}
+		clients[cluster.Name] = c
+	}
+	return clients, nil
+}
+
+// memberClusterClients keeps the client of each member cluster of each resource, so that it is only created
+// again when the kubeconfig of the cluster changes. The client created with the previous kubeconfig is
+// discarded then, and the clients of a resource are discarded once it is deleted.
+type memberClusterClients struct {
+	mu        sync.Mutex
+	clients   map[types.NamespacedName]map[string]memberClusterClient
+	newClient func(kubeconfig []byte) (kubernetesClient.Client, error)
+}
+
+// memberClusterClient is a client of a member cluster, and the kubeconfig it was created with.
+type memberClusterClient struct {
+	kubeconfig []byte
+	client     kubernetesClient.Client
+}
+
+func newMemberClusterClients(scheme *runtime.Scheme) *memberClusterClients {
+	return &memberClusterClients{
+		clients: map[types.NamespacedName]map[string]memberClusterClient{},
+		newClient: func(kubeconfig []byte) (kubernetesClient.Client, error) {
+			cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+			if err != nil {
+				return nil, err
+			}
+			c, err := k8sClient.New(cfg, k8sClient.Options{Scheme: scheme})
+			if err != nil {
+				return nil, err
+			}
+			return kubernetesClient.NewClient(c), nil
+		},
+	}
+}
+
+// get returns the client of the given cluster of the resource, accessed with the given kubeconfig.
+func (m *memberClusterClients) get(mdbNsName types.NamespacedName, cluster string, kubeconfig []byte) (kubernetesClient.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.clients[mdbNsName][cluster]; ok && bytes.Equal(c.kubeconfig, kubeconfig) {
+		return c.client, nil
+	}
+	c, err := m.newClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if m.clients[mdbNsName] == nil {
+		m.clients[mdbNsName] = map[string]memberClusterClient{}
+	}
+	m.clients[mdbNsName][cluster] = memberClusterClient{kubeconfig: kubeconfig, client: c}
+	return c, nil
+}
+
+// forget discards the clients of the member clusters of the resource.
+func (m *memberClusterClients) forget(mdbNsName types.NamespacedName) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.clients, mdbNsName)
+}
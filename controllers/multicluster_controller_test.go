@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func newTestMultiCluster() mdbv1.MongoDBCommunityMultiCluster {
+	return mdbv1.MongoDBCommunityMultiCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-rs",
+			Namespace: "my-ns",
+		},
+		Spec: mdbv1.MongoDBCommunityMultiClusterSpec{
+			Version: "4.4.6",
+			Clusters: []mdbv1.MemberCluster{
+				{Name: "east", Members: 2, ClusterDomain: "east.local"},
+				{Name: "west", Members: 1, ClusterDomain: "west.local", KubeconfigSecretRef: &mdbv1.LocalObjectReference{Name: "west-kubeconfig"}},
+			},
+			Users: []mdbv1.MongoDBUser{},
+		},
+	}
+}
+
+// setupMultiCluster returns a reconciler for the given resource, the manager of the cluster the operator runs
+// in and the client of the west cluster.
+func setupMultiCluster(t *testing.T, mdb mdbv1.MongoDBCommunityMultiCluster) (*MultiClusterReconciler, *client.MockedManager, client.Client) {
+	mgr := client.NewManager(&mdb)
+	kubeconfig := secret.Builder().SetName("west-kubeconfig").SetNamespace(mdb.Namespace).SetField(mdbv1.KubeconfigKey, "west").Build()
+	assert.NoError(t, mgr.Client.CreateSecret(kubeconfig))
+
+	west := client.NewClient(client.NewMockedClient())
+	r := NewMultiClusterReconciler(mgr)
+	r.clusterClients.newClient = func(kubeconfig []byte) (client.Client, error) {
+		assert.Equal(t, "west", string(kubeconfig))
+		return west, nil
+	}
+	return r, mgr, west
+}
+
+func reconcileMultiCluster(t *testing.T, r *MultiClusterReconciler, mgr *client.MockedManager, mdb *mdbv1.MongoDBCommunityMultiCluster) {
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), mdb))
+}
+
+func TestMultiCluster_DeploysTheMembersToEachCluster(t *testing.T) {
+	mdb := newTestMultiCluster()
+	r, mgr, west := setupMultiCluster(t, mdb)
+
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Contains(t, mdb.Finalizers, multiClusterFinalizer)
+	assert.Equal(t, []mdbv1.MemberClusterStatus{{Name: "east", Members: 2}, {Name: "west", Members: 1}}, mdb.Status.Clusters)
+	assert.Equal(t, "mongodb://my-rs-east-0.my-rs-east-svc.my-ns.svc.east.local:27017,my-rs-east-1.my-rs-east-svc.my-ns.svc.east.local:27017,my-rs-west-0.my-rs-west-svc.my-ns.svc.west.local:27017/?replicaSet=my-rs", mdb.Status.MongoURI)
+
+	for c, replicas := range map[client.Client]int32{mgr.Client: 2, west: 1} {
+		for _, cluster := range mdb.Spec.Clusters {
+			if (c == west) != (cluster.KubeconfigSecretRef != nil) {
+				continue
+			}
+			sts, err := c.GetStatefulSet(mdb.ClusterMembers(cluster).StatefulSetNamespacedName())
+			assert.NoError(t, err)
+			assert.Equal(t, replicas, *sts.Spec.Replicas)
+			assert.Equal(t, mdb.ClusterMembers(cluster).ServiceName(), sts.Spec.ServiceName)
+
+			_, err = c.GetService(types.NamespacedName{Name: mdb.ClusterMembers(cluster).ServiceName(), Namespace: mdb.Namespace})
+			assert.NoError(t, err)
+		}
+
+		ac, err := automationconfig.ReadFromSecret(c, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+		assert.NoError(t, err)
+		assert.Len(t, ac.Processes, 3)
+		assert.Equal(t, "my-rs-west-0", ac.Processes[2].Name)
+		assert.Equal(t, "my-rs-west-0.my-rs-west-svc.my-ns.svc.west.local", ac.Processes[2].HostName)
+		assert.Equal(t, "my-rs-west-0", ac.ReplicaSets[0].Members[2].Host)
+	}
+
+	for _, nsName := range []types.NamespacedName{mdb.GetAgentKeyfileSecretNamespacedName(), mdb.GetAgentPasswordSecretNamespacedName()} {
+		expected, err := secret.ReadByteData(mgr.Client, nsName)
+		assert.NoError(t, err)
+		copied, err := secret.ReadByteData(west, nsName)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, copied, "the Secrets of the agent are copied to the member clusters")
+	}
+}
+
+func TestMultiCluster_ClientsAreCreatedAgainWhenTheKubeconfigChanges(t *testing.T) {
+	mdb := newTestMultiCluster()
+	r, mgr, _ := setupMultiCluster(t, mdb)
+	created := 0
+	r.clusterClients.newClient = func(kubeconfig []byte) (client.Client, error) {
+		created++
+		return client.NewClient(client.NewMockedClient()), nil
+	}
+
+	_, err := r.memberClusterClients(mdb)
+	assert.NoError(t, err)
+	_, err = r.memberClusterClients(mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	assert.NoError(t, secret.UpdateField(mgr.Client, types.NamespacedName{Name: "west-kubeconfig", Namespace: mdb.Namespace}, mdbv1.KubeconfigKey, "rotated"))
+	_, err = r.memberClusterClients(mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Len(t, r.clusterClients.clients[mdb.NamespacedName()], 1, "the client of the previous kubeconfig is discarded")
+
+	r.clusterClients.forget(mdb.NamespacedName())
+	assert.Empty(t, r.clusterClients.clients)
+}
+
+func TestMultiCluster_ScalesOneMemberAtATime(t *testing.T) {
+	mdb := newTestMultiCluster()
+	r, mgr, west := setupMultiCluster(t, mdb)
+	reconcileMultiCluster(t, r, mgr, &mdb)
+
+	mdb.Spec.Clusters[0].Members = 3
+	mdb.Spec.Clusters[1].Members = 2
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, "Waiting for the members of cluster east to be ready", mdb.Status.Message)
+	assert.Equal(t, []mdbv1.MemberClusterStatus{{Name: "east", Members: 2}, {Name: "west", Members: 1}}, mdb.Status.Clusters)
+
+	t.Run("The next member is only added once the previous one is ready", func(t *testing.T) {
+		reconcileMultiCluster(t, r, mgr, &mdb)
+		assert.Equal(t, []mdbv1.MemberClusterStatus{{Name: "east", Members: 2}, {Name: "west", Members: 1}}, mdb.Status.Clusters)
+	})
+
+	makeMemberClusterReady(t, mgr.Client, mdb, mdb.Spec.Clusters[0], 3)
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Equal(t, "Scaling the members of cluster west from 1 to 2", mdb.Status.Message)
+	assert.Equal(t, []mdbv1.MemberClusterStatus{{Name: "east", Members: 3}, {Name: "west", Members: 1}}, mdb.Status.Clusters)
+
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.Equal(t, "Waiting for the members of cluster west to be ready", mdb.Status.Message)
+
+	makeMemberClusterReady(t, west, mdb, mdb.Spec.Clusters[1], 2)
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+	assert.Equal(t, []mdbv1.MemberClusterStatus{{Name: "east", Members: 3}, {Name: "west", Members: 2}}, mdb.Status.Clusters)
+
+	ac, err := automationconfig.ReadFromSecret(west, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	ids := map[string]int{}
+	for _, m := range ac.ReplicaSets[0].Members {
+		ids[m.Host] = m.Id
+	}
+	assert.Equal(t, map[string]int{"my-rs-east-0": 0, "my-rs-east-1": 1, "my-rs-west-0": 2, "my-rs-east-2": 3, "my-rs-west-1": 4}, ids)
+}
+
+// makeMemberClusterReady marks the StatefulSet of the members of the cluster as ready with the given replicas.
+func makeMemberClusterReady(t *testing.T, c client.Client, mdb mdbv1.MongoDBCommunityMultiCluster, cluster mdbv1.MemberCluster, replicas int) {
+	sts, err := c.GetStatefulSet(mdb.ClusterMembers(cluster).StatefulSetNamespacedName())
+	assert.NoError(t, err)
+	sts.Status.ReadyReplicas = int32(replicas)
+	sts.Status.UpdatedReplicas = int32(replicas)
+	assert.NoError(t, c.Update(context.TODO(), &sts))
+}
+
+func TestMultiCluster_ClustersWithMembersCanNotBeRemoved(t *testing.T) {
+	mdb := newTestMultiCluster()
+	r, mgr, _ := setupMultiCluster(t, mdb)
+	reconcileMultiCluster(t, r, mgr, &mdb)
+
+	mdb.Spec.Clusters = mdb.Spec.Clusters[:1]
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "cluster west still has 1 members")
+}
+
+func TestMultiCluster_DeletesTheObjectsOfTheMemberClusters(t *testing.T) {
+	mdb := newTestMultiCluster()
+	r, mgr, west := setupMultiCluster(t, mdb)
+	reconcileMultiCluster(t, r, mgr, &mdb)
+
+	now := metav1.Now()
+	mdb.DeletionTimestamp = &now
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+	reconcileMultiCluster(t, r, mgr, &mdb)
+	assert.NotContains(t, mdb.Finalizers, multiClusterFinalizer)
+
+	err := west.Get(context.TODO(), mdb.ClusterMembers(mdb.Spec.Clusters[1]).StatefulSetNamespacedName(), &appsv1.StatefulSet{})
+	assert.True(t, apiErrors.IsNotFound(err))
+	for _, name := range []string{mdb.AutomationConfigSecretName(), mdb.GetAgentKeyfileSecretNamespacedName().Name, mdb.GetAgentPasswordSecretNamespacedName().Name} {
+		err = west.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mdb.Namespace}, &corev1.Secret{})
+		assert.True(t, apiErrors.IsNotFound(err))
+	}
+	assert.Empty(t, r.clusterClients.clients, "the clients of the member clusters are discarded")
+}
+
+func TestMembersThisReconciliation(t *testing.T) {
+	mdb := newTestMultiCluster()
+
+	members, scalingUp := membersThisReconciliation(mdb)
+	assert.Equal(t, map[string]int{"east": 2, "west": 1}, members)
+	assert.False(t, scalingUp)
+
+	mdb.Status.Clusters = []mdbv1.MemberClusterStatus{{Name: "east", Members: 3}, {Name: "west", Members: 0}}
+	members, scalingUp = membersThisReconciliation(mdb)
+	assert.Equal(t, map[string]int{"east": 2, "west": 0}, members)
+	assert.False(t, scalingUp)
+
+	mdb.Status.Clusters[0].Members = 2
+	members, scalingUp = membersThisReconciliation(mdb)
+	assert.Equal(t, map[string]int{"east": 2, "west": 1}, members)
+	assert.True(t, scalingUp)
+}
@@ -5,19 +5,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/controllers/predicates"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/container"
 
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/dns"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/functions"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/generate"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/redact"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/agent"
 
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/apierrors"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/scale"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/semaphore"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/status"
 
@@ -25,6 +36,9 @@ import (
 
 	"github.com/imdario/mergo"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/verifier"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/diagnostics"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/replication"
 	"github.com/stretchr/objx"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
@@ -39,12 +53,18 @@ import (
 	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/secretbackend"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -53,179 +73,1054 @@ import (
 
 const (
 	clusterDNSName = "CLUSTER_DNS_NAME"
+	// clusterDNSServer is the address of the DNS server used to verify the hostnames of the members.
+	// Defaults to the resolver of the host the operator is running on.
+	clusterDNSServer = "CLUSTER_DNS_SERVER"
+	// verifyMemberDNS enables the verification that the hostnames of all members can be resolved.
+	verifyMemberDNS = "VERIFY_MEMBER_DNS"
 
 	lastSuccessfulConfiguration = "mongodb.com/v1.lastSuccessfulConfiguration"
+
+	// loggerName is the name of the logger of the controller, which can be given its own log level.
+	loggerName = "controllers"
 )
 
 func init() {
-	logger, err := zap.NewDevelopment()
+	logger, err := zap.NewDevelopment(zap.WrapCore(redact.NewCore))
 	if err != nil {
 		os.Exit(1)
 	}
-	zap.ReplaceGlobals(logger)
-}
+	zap.ReplaceGlobals(logger)
+}
+
+func NewReconciler(mgr manager.Manager) *ReplicaSetReconciler {
+	mgrClient := mgr.GetClient()
+	secretWatcher := watch.New()
+
+	// the configuration of the secret backend is validated when the operator starts.
+	backend, err := secretbackend.FromEnv()
+	if err != nil {
+		backend = secretbackend.Unavailable(err)
+	}
+	secretRefreshInterval, _ := secretbackend.RefreshIntervalFromEnv(backend)
+	// the warning period is validated when the operator starts.
+	certificateExpiryWarning, _ := certificateExpiryWarningFromEnv()
+	// the password policy is validated when the operator starts.
+	passwordPolicy, _ := passwordPolicyFromEnv()
+
+	return &ReplicaSetReconciler{
+		client:                   secretbackend.NewClient(kubernetesClient.NewClient(mgrClient), backend),
+		scheme:                   mgr.GetScheme(),
+		log:                      zap.S().Named(loggerName),
+		secretWatcher:            &secretWatcher,
+		apiReader:                mgr.GetAPIReader(),
+		resolver:                 dns.NewResolver(os.Getenv(clusterDNSServer)),
+		verifyMemberDNS:          envvar.ReadBool(verifyMemberDNS),
+		secretRefreshInterval:    secretRefreshInterval,
+		credentialVerifier:       verifier.New(),
+		disruptions:              newDisruptionSemaphore(),
+		certificateExpiryWarning: certificateExpiryWarning,
+		recorder:                 mgr.GetEventRecorderFor("mongodbcommunity-controller"),
+		queue:                    newReconcileQueue(),
+		diagnostics:              diagnostics.New(mgr.GetConfig()),
+		replicationStatus:        replication.NewStatusReader(),
+		stepDowner:               replication.NewStepDowner(),
+		passwords:                generate.NewPasswordGenerator(passwordPolicy),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager and configures the necessary watches.
+func (r *ReplicaSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	maxConcurrentReconciles, _ := maxConcurrentReconcilesFromEnv()
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		For(&mdbv1.MongoDBCommunity{}, builder.WithPredicates(predicates.OnlyOnSpecChange(), r.queue.predicate())).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, r.queue.handler(r.secretWatcher)).
+		Watches(&source.Kind{Type: &batchv1.Job{}}, r.queue.handler(handler.EnqueueRequestsFromMapFunc(jobOwnerRequests))).
+		Watches(&source.Kind{Type: &batchv1beta1.CronJob{}}, r.queue.handler(handler.EnqueueRequestsFromMapFunc(jobOwnerRequests))).
+		Complete(r)
+}
+
+// ReplicaSetReconciler reconciles a MongoDB ReplicaSet
+type ReplicaSetReconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client        kubernetesClient.Client
+	scheme        *runtime.Scheme
+	log           *zap.SugaredLogger
+	secretWatcher *watch.ResourceWatcher
+
+	// apiReader reads objects directly from the apiserver, for objects the operator does not watch
+	apiReader k8sClient.Reader
+
+	// resolver is used to verify that the hostnames of the members can be resolved
+	resolver        dns.Resolver
+	verifyMemberDNS bool
+
+	// secretRefreshInterval is the interval in which resources are reconciled again when the credentials
+	// are read from a secret backend which can not be watched.
+	secretRefreshInterval time.Duration
+
+	// credentialVerifier is used to verify that the users can authenticate with their passwords
+	credentialVerifier verifier.Verifier
+
+	// disruptions limits how many resources may restart their members at the same time
+	disruptions *semaphore.Keyed
+
+	// certificateExpiryWarning is how long before a certificate expires the CertificateExpiringSoon condition is reported
+	certificateExpiryWarning time.Duration
+
+	// recorder records the Events of the resources
+	recorder record.EventRecorder
+
+	// queue records when resources were added to the work queue of the controller
+	queue *reconcileQueue
+
+	// diagnostics collects the diagnostic data captured when a resource fails
+	diagnostics diagnostics.Collector
+
+	// replicationStatus reads the replication lag of the members before a member is removed
+	replicationStatus replication.StatusReader
+
+	// stepDowner steps down the primary before it is restarted when the resources of the members change
+	stepDowner replication.StepDowner
+
+	// passwords generates the passwords of the agent and of the metrics user.
+	passwords generate.PasswordGenerator
+
+	// debugUntil is the end of the debug window of the resource being reconciled, zero outside of debug windows.
+	debugUntil time.Time
+
+	// tracingContext carries the span of the reconciliation of the resource being reconciled.
+	tracingContext context.Context
+}
+
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;update
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
+// +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;delete
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunityrestore,verbs=get;create
+
+// Reconcile reads that state of the cluster for a MongoDB object and makes changes based on the state read
+// and what is in the MongoDB.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r ReplicaSetReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	queueWait := r.queue.take(request.NamespacedName, time.Now())
+	reconcileQueueWait.WithLabelValues(request.Namespace, request.Name).Observe(queueWait.Seconds())
+
+	ctx, span := startReconcileSpan(ctx, request)
+	r.tracingContext = ctx
+	res, err := r.reconcile(ctx, request, queueWait)
+	endReconcileSpan(span, res, err)
+	r.queue.requeue(request.NamespacedName, res, err, time.Now())
+	return res, err
+}
+
+// reconcile reconciles the resource, which waited for queueWait in the work queue.
+func (r ReplicaSetReconciler) reconcile(ctx context.Context, request reconcile.Request, queueWait time.Duration) (reconcile.Result, error) {
+
+	// TODO: generalize preparation for resource
+	// Fetch the MongoDB instance
+	mdb := mdbv1.MongoDBCommunity{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, &mdb)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.disruptions.Release(request.NamespacedName)
+			deleteCertificateExpiryMetrics(request.NamespacedName)
+			deleteReconcileMetrics(request.NamespacedName)
+			deleteBackupMetrics(request.NamespacedName)
+			return result.OK()
+		}
+		r.log.Errorf("Error reconciling MongoDB resource: %s", err)
+		// Error reading the object - requeue the request.
+		return result.Failed()
+	}
+
+	defer observePhase(&mdb)
+	defer r.traceResource(&mdb)
+	defer r.recordPhaseEvent(&mdb, mdb.Status.Phase)
+	defer r.reconcileDiagnostics(&mdb, mdb.Status.Phase)
+
+	r.log = zap.S().Named(loggerName).With("ReplicaSet", request.NamespacedName)
+	r.applyLogLevel(mdb)
+	r.startDebugWindow(mdb, time.Now())
+	r.captureRequestedDiagnostics(&mdb, time.Now())
+	r.log.Infow("Reconciling MongoDB", "MongoDB.Spec", mdb.Spec, "MongoDB.Status", mdb.Status)
+
+	if r.isNamespaceTerminating(mdb) {
+		return r.skipTerminatingNamespace(mdb)
+	}
+
+	if err := r.applyConcurrentChangePolicy(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error applying the concurrent change policy: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.applyMaintenanceWindow(&mdb, time.Now()); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error applying the maintenance window: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	r.log.Debug("Validating MongoDB.Spec")
+	if err := r.validateUpdate(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("error validating new Spec: %s", err)).
+				withFailedPhase(),
+		)
+	}
+	if err := r.validateStrict(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("error validating new Spec: %s", err)).
+				withFailedPhase(),
+		)
+	}
+	if err := validateWritablePaths(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("error validating new Spec: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateService(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the Services: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateNetwork(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the network configuration: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateMemberOrdinals(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("error validating new Spec: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateExternalMembers(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the external members: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	restoreName, err := r.restoreInProgress(&mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error checking for a restore in progress: %s", err)).
+				withFailedPhase(),
+		)
+	}
+	if restoreName != "" {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, fmt.Sprintf("Reconciliation is paused while restore %s is in progress", restoreName)).
+				withPendingPhase(10),
+		)
+	}
+
+	r.log.Debug("Ensuring the service exists")
+	if err := r.traced("EnsureService", func() error { return r.ensureService(mdb) }); err != nil {
+		if apierrors.IsNamespaceTerminatingError(err) {
+			return r.skipTerminatingNamespace(mdb)
+		}
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the service exists: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.traced("EnsureExternalAccessServices", func() error { return r.ensureExternalAccessServices(mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the external Services of the members: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.traced("EnsureNetworkPolicy", func() error { return r.ensureNetworkPolicy(&mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the NetworkPolicy of the members: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	r.watchCredentialSecrets(mdb)
+
+	tlsSecretMissing, err := r.checkTLSSecret(&mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error checking the TLS certificate Secret: %s", err)).
+				withFailedPhase(),
+		)
+	}
+	if tlsSecretMissing {
+		return r.holdForMissingTLSSecret(&mdb)
+	}
+
+	isTLSValid, err := r.validateTLSConfig(mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating TLS config: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if !isTLSValid {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, "TLS config is not yet valid, retrying in 10 seconds").
+				withPendingPhase(10),
+		)
+	}
+
+	isLDAPValid, err := r.validateLDAPConfig(mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating LDAP config: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if !isLDAPValid {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, "LDAP config is not yet valid, retrying in 10 seconds").
+				withPendingPhase(10),
+		)
+	}
+
+	if err := validateSystemLog(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the system log: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateAuditLog(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the audit log: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateAuditLogForwarder(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating audit log forwarder: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validatePBM(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the PBM configuration: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validatePrometheus(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the Prometheus exporter: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateScaleDown(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating scale down: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateReplicationLagGate(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the replication lag readiness gate: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateUpdateStrategy(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the update strategy: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateExternalAccess(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating external access: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateStuckMemberRemediation(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating stuck member remediation: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateZoneAwareness(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating zone awareness: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateServerParameters(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating server parameters: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateMongodOptionsForVersion(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating mongod options: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateAuthenticationModes(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating authentication modes: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateInternalClusterAuth(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating internal cluster authentication: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateUsers(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating users: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateObjectSizes(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the size of the generated objects: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateInitialization(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the initialization: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateMetricsUser(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the metrics user: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateKeyfileRotation(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the keyfile rotation: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := validateChangeStreamVerification(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating change stream verification: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if _, _, _, err := credentialVerificationSettings(mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating credential verification: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.traced("EnsureTLSResources", func() error { return r.ensureTLSResources(mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring TLS resources: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if ok, res, err := r.runExtensions(AfterTLS, &mdb); !ok {
+		return res, err
+	}
+
+	if err := r.traced("EnsureAuditLogForwarderConfig", func() error { return r.ensureAuditLogForwarderConfig(mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring audit log forwarder configuration: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.traced("EnsurePBMResources", func() error { return r.ensurePBMResources(mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the PBM agent resources: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.traced("EnsurePrometheusWebConfig", func() error { return r.ensurePrometheusWebConfig(mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the web configuration of the Prometheus exporter: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.traced("EnsurePrometheusMonitoring", func() error { return r.ensurePrometheusMonitoring(mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the Prometheus monitoring of the exporters: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	renaming, err := r.reconcileReplicaSetRename(&mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error renaming the replica set: %s", err)).
+				withFailedPhase(),
+		)
+	}
+	if renaming {
+		rename := mdb.Status.ReplicaSetRename
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, fmt.Sprintf("Renaming the replica set from %s to %s, phase=%s", rename.From, rename.To, rename.Phase)).
+				withPendingPhase(10),
+		)
+	}
+
+	keyfileReady, err := r.reconcileKeyfileSecret(&mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the keyfile: %s", err)).
+				withFailedPhase(),
+		)
+	}
+	if !keyfileReady {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, "Keyfile Secret does not exist yet, retrying in 10 seconds").
+				withPendingPhase(10),
+		)
+	}
+
+	if err := r.ensureMetricsUserPassword(mdb, time.Now()); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error ensuring the password of the metrics user: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.startKeyfileRotation(&mdb, time.Now()); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error starting keyfile rotation: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.startAuthenticationMigration(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error starting authentication mode migration: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.startCARotation(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error starting CA rotation: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.startTLSModeTransition(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error starting TLS mode change: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.startClusterAuthModeTransition(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error starting cluster authentication mode change: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.startPlannedOutage(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error starting planned outage: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.reconcileForceReconfig(&mdb, time.Now()); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error forcing the reconfiguration of the replica set: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.recordOnDeleteUpdateStrategy(&mdb); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error recording the StatefulSet update strategy: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if err := r.reconcileCanary(&mdb, time.Now()); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error reconciling the canary update: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if blocked := r.checkScaleDown(&mdb); blocked != "" {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, fmt.Sprintf("%s, retrying in 10 seconds", blocked)).
+				withPendingPhase(10),
+		)
+	}
+
+	r.observeMembers(&mdb)
+	r.remediateStuckMembers(&mdb, time.Now())
+	r.observeVersionRollout(&mdb)
+
+	if ok, res, err := r.runExtensions(BeforeAutomationConfig, &mdb); !ok {
+		return res, err
+	}
+
+	if err := r.traced("DryRunGeneratedObjects", func() error { return r.dryRunGeneratedObjects(&mdb) }); err != nil {
+		if apierrors.IsNamespaceTerminatingError(err) {
+			return r.skipTerminatingNamespace(mdb)
+		}
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error validating the generated objects: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	ready, err := r.deployMongoDBReplicaSet(mdb)
+	if err != nil {
+		if apierrors.IsNamespaceTerminatingError(err) {
+			return r.skipTerminatingNamespace(mdb)
+		}
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error deploying MongoDB ReplicaSet: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	inFlight, err := inFlightChange(mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error recording the generation being rolled out: %s", err)).
+				withFailedPhase(),
+		)
+	}
+
+	if !ready {
+		certificates := r.observeCertificateExpiry(mdb)
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withConditions(r.withCertificateExpiryConditions(mdb, mdb.Status.Conditions, certificates, time.Now())).
+				withTLSCertificates(certificates).
+				withProgress(r.observeProgress(mdb, time.Now())).
+				withMessage(Info, notReadyMessage(mdb)).
+				withConcurrentChange(inFlight).
+				withPendingPhase(10),
+		)
+	}
+
+	if canaryHolds(mdb) {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withProgress(r.observeProgress(mdb, time.Now())).
+				withMessage(Info, fmt.Sprintf("%s, retrying in 10 seconds", mdb.Status.Canary.Message)).
+				withConcurrentChange(inFlight).
+				withPendingPhase(10),
+		)
+	}
+
+	r.log.Debug("Resetting StatefulSet UpdateStrategy to RollingUpdate")
+	if err := r.traced("ResetUpdateStrategy", func() error { return r.resetUpdateStrategy(&mdb) }); err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error resetting StatefulSet UpdateStrategyType: %s", err)).
+				withFailedPhase(),
+		)
+	}
 
-func NewReconciler(mgr manager.Manager) *ReplicaSetReconciler {
-	mgrClient := mgr.GetClient()
-	secretWatcher := watch.New()
+	if scale.IsStillScaling(mdb) {
+		return status.Update(r.client.Status(), &mdb, statusOptions().
+			withMongoDBMembers(mdb.AutomationConfigMembersThisReconciliation()).
+			withMessage(Info, fmt.Sprintf("Performing scaling operation, currentMembers=%d, desiredMembers=%d",
+				mdb.CurrentReplicas(), mdb.DesiredReplicas())).
+			withStatefulSetReplicas(mdb.StatefulSetReplicasThisReconciliation()).
+			withConcurrentChange(inFlight).
+			withPendingPhase(10),
+		)
+	}
 
-	return &ReplicaSetReconciler{
-		client:        kubernetesClient.NewClient(mgrClient),
-		scheme:        mgr.GetScheme(),
-		log:           zap.S(),
-		secretWatcher: &secretWatcher,
+	if r.verifyMemberDNS {
+		unresolvable, err := r.unresolvableMemberHostnames(mdb)
+		if err != nil {
+			return status.Update(r.client.Status(), &mdb,
+				statusOptions().
+					withMessage(Error, fmt.Sprintf("Error verifying member hostnames: %s", err)).
+					withFailedPhase(),
+			)
+		}
+		if len(unresolvable) > 0 {
+			return status.Update(r.client.Status(), &mdb,
+				statusOptions().
+					withMessage(Info, fmt.Sprintf("Member hostnames can not be resolved yet: %s, retrying in 10 seconds", strings.Join(unresolvable, ", "))).
+					withConcurrentChange(inFlight).
+					withPendingPhase(10),
+			)
+		}
 	}
-}
 
-// SetupWithManager sets up the controller with the Manager and configures the necessary watches.
-func (r *ReplicaSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&mdbv1.MongoDBCommunity{}, builder.WithPredicates(predicates.OnlyOnSpecChange())).
-		Complete(r)
-}
+	rotating, err := r.advanceKeyfileRotation(&mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error rotating keyfile: %s", err)).
+				withFailedPhase(),
+		)
+	}
 
-// ReplicaSetReconciler reconciles a MongoDB ReplicaSet
-type ReplicaSetReconciler struct {
-	// This client, initialized using mgr.Client() above, is a split client
-	// that reads objects from the cache and writes to the apiserver
-	client        kubernetesClient.Client
-	scheme        *runtime.Scheme
-	log           *zap.SugaredLogger
-	secretWatcher *watch.ResourceWatcher
-}
+	if rotating {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, fmt.Sprintf("Rotating keyfile, phase=%s", mdb.Status.KeyfileRotation.Phase)).
+				withConcurrentChange(inFlight).
+				withPendingPhase(10),
+		)
+	}
 
-// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity/finalizers,verbs=update
-// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+	migrating, err := r.advanceAuthenticationMigration(&mdb)
+	if err != nil {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error migrating authentication modes: %s", err)).
+				withFailedPhase(),
+		)
+	}
 
-// Reconcile reads that state of the cluster for a MongoDB object and makes changes based on the state read
-// and what is in the MongoDB.Spec
-// Note:
-// The Controller will requeue the Request to be processed again if the returned error is non-nil or
-// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
-func (r ReplicaSetReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if migrating {
+		migration := mdb.Status.AuthenticationMigration
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, fmt.Sprintf("Migrating authentication modes from %v to %v, phase=%s", migration.From, migration.To, migration.Phase)).
+				withConcurrentChange(inFlight).
+				withPendingPhase(10),
+		)
+	}
 
-	// TODO: generalize preparation for resource
-	// Fetch the MongoDB instance
-	mdb := mdbv1.MongoDBCommunity{}
-	err := r.client.Get(context.TODO(), request.NamespacedName, &mdb)
+	changingTLSMode, err := r.advanceTLSModeTransition(&mdb)
 	if err != nil {
-		if apiErrors.IsNotFound(err) {
-			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			return result.OK()
-		}
-		r.log.Errorf("Error reconciling MongoDB resource: %s", err)
-		// Error reading the object - requeue the request.
-		return result.Failed()
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Error, fmt.Sprintf("Error changing TLS mode: %s", err)).
+				withFailedPhase(),
+		)
 	}
 
-	r.log = zap.S().With("ReplicaSet", request.NamespacedName)
-	r.log.Infow("Reconciling MongoDB", "MongoDB.Spec", mdb.Spec, "MongoDB.Status", mdb.Status)
+	if changingTLSMode {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withMessage(Info, fmt.Sprintf("Changing TLS mode to %s, currentMode=%s", mdb.DesiredTLSMode(), mdb.Status.TLSMode)).
+				withConcurrentChange(inFlight).
+				withPendingPhase(10),
+		)
+	}
 
-	r.log.Debug("Validating MongoDB.Spec")
-	if err := r.validateUpdate(mdb); err != nil {
+	changingClusterAuthMode, err := r.advanceClusterAuthModeTransition(&mdb)
+	if err != nil {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Error, fmt.Sprintf("error validating new Spec: %s", err)).
+				withMessage(Error, fmt.Sprintf("Error changing cluster authentication mode: %s", err)).
 				withFailedPhase(),
 		)
 	}
 
-	r.log.Debug("Ensuring the service exists")
-	if err := r.ensureService(mdb); err != nil {
+	if changingClusterAuthMode {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Error, fmt.Sprintf("Error ensuring the service exists: %s", err)).
-				withFailedPhase(),
+				withMessage(Info, fmt.Sprintf("Changing cluster authentication mode to %s, currentMode=%s", mdb.DesiredClusterAuthMode(), mdb.Status.ClusterAuthMode)).
+				withConcurrentChange(inFlight).
+				withPendingPhase(10),
 		)
 	}
 
-	isTLSValid, err := r.validateTLSConfig(mdb)
+	rotatingCA, err := r.advanceCARotation(&mdb)
 	if err != nil {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Error, fmt.Sprintf("Error validating TLS config: %s", err)).
+				withMessage(Error, fmt.Sprintf("Error rotating the CA: %s", err)).
 				withFailedPhase(),
 		)
 	}
 
-	if !isTLSValid {
+	if rotatingCA {
+		rotation := mdb.Status.CARotation
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Info, "TLS config is not yet valid, retrying in 10 seconds").
+				withMessage(Info, fmt.Sprintf("Rotating the CA from %s to %s, phase=%s", rotation.From, rotation.To, rotation.Phase)).
+				withConcurrentChange(inFlight).
 				withPendingPhase(10),
 		)
 	}
 
-	if err := r.ensureTLSResources(mdb); err != nil {
+	srvCondition := r.verifySRVRecords(mdb)
+	if err := r.ensureUserConnectionStringSecrets(mdb, srvCondition == nil || srvCondition.Status == metav1.ConditionFalse); err != nil {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Error, fmt.Sprintf("Error ensuring TLS resources: %s", err)).
+				withMessage(Error, fmt.Sprintf("Error ensuring user connection string secrets: %s", err)).
 				withFailedPhase(),
 		)
 	}
 
-	ready, err := r.deployMongoDBReplicaSet(mdb)
-	if err != nil {
+	if err := r.ensureMetricsUserSecret(mdb); err != nil {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Error, fmt.Sprintf("Error deploying MongoDB ReplicaSet: %s", err)).
+				withMessage(Error, fmt.Sprintf("Error ensuring the metrics user secret: %s", err)).
 				withFailedPhase(),
 		)
 	}
 
-	if !ready {
+	if err := r.ensureConnectionExamples(mdb); err != nil {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Info, "ReplicaSet is not yet ready, retrying in 10 seconds").
-				withPendingPhase(10),
+				withMessage(Error, fmt.Sprintf("Error ensuring connection examples: %s", err)).
+				withFailedPhase(),
 		)
 	}
 
-	r.log.Debug("Resetting StatefulSet UpdateStrategy to RollingUpdate")
-	if err := statefulset.ResetUpdateStrategy(&mdb, r.client); err != nil {
+	initialization, err := r.reconcileInitialization(mdb)
+	if err != nil {
 		return status.Update(r.client.Status(), &mdb,
 			statusOptions().
-				withMessage(Error, fmt.Sprintf("Error resetting StatefulSet UpdateStrategyType: %s", err)).
+				withMessage(Error, fmt.Sprintf("Error initializing the data: %s", err)).
 				withFailedPhase(),
 		)
 	}
-
-	if scale.IsStillScaling(mdb) {
-		return status.Update(r.client.Status(), &mdb, statusOptions().
-			withMongoDBMembers(mdb.AutomationConfigMembersThisReconciliation()).
-			withMessage(Info, fmt.Sprintf("Performing scaling operation, currentMembers=%d, desiredMembers=%d",
-				mdb.CurrentReplicas(), mdb.DesiredReplicas())).
-			withStatefulSetReplicas(mdb.StatefulSetReplicasThisReconciliation()).
-			withPendingPhase(10),
+	if initialization != nil && initialization.Phase == mdbv1.InitializationFailed {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withInitialization(initialization).
+				withMessage(Error, fmt.Sprintf("Initialization failed: %s", initialization.Message)).
+				withFailedPhase(),
+		)
+	}
+	if initialization != nil && initialization.Phase == mdbv1.InitializationRunning {
+		return status.Update(r.client.Status(), &mdb,
+			statusOptions().
+				withInitialization(initialization).
+				withMessage(Info, fmt.Sprintf("Initializing the data: %s, retrying in 10 seconds", initialization.Message)).
+				withPendingPhase(10),
 		)
 	}
 
-	res, err := status.Update(r.client.Status(), &mdb,
-		statusOptions().
-			withMongoURI(mdb.MongoURI()).
-			withMongoDBMembers(mdb.AutomationConfigMembersThisReconciliation()).
-			withStatefulSetReplicas(mdb.StatefulSetReplicasThisReconciliation()).
-			withMessage(None, "").
-			withRunningPhase(),
-	)
+	jobConditions, err := r.getJobConditions(mdb)
+	if err != nil {
+		r.log.Warnf("Could not aggregate the status of backup, restore and maintenance jobs: %s", err)
+		jobConditions = mdb.Status.Conditions
+	}
+
+	conditions, verified := r.verifyUserCredentialsIfDue(mdb, jobConditions, time.Now())
+	conditions = r.verifyChangeStreamsIfDue(mdb, conditions)
+	conditions = withSRVCondition(conditions, srvCondition, mdb.Generation)
+	certificates := r.observeCertificateExpiry(mdb)
+	conditions = r.withCertificateExpiryConditions(mdb, conditions, certificates, time.Now())
+
+	pendingRestarts, err := r.pendingRestarts(mdb)
+	if err != nil {
+		r.log.Warnf("Could not determine the members pending a restart: %s", err)
+		pendingRestarts = mdb.Status.PendingRestarts
+	}
+	messageSeverity, message := None, ""
+	if len(pendingRestarts) > 0 {
+		messageSeverity, message = Info, pendingRestartsMessage(pendingRestarts)
+	}
+	externalAddresses, err := r.externalAddresses(mdb, mdb.Spec.Members)
+	if err != nil {
+		r.log.Warnf("Could not determine the external addresses of the members: %s", err)
+		externalAddresses = mdb.Status.ExternalAddresses
+	}
+
+	change := mdb.Status.ConcurrentChange
+	queued := change != nil && change.PendingGeneration != 0 && !change.Rejected
+	runningOptions := statusOptions().
+		withMongoURI(mdb.MongoURI()).
+		withConditions(conditions).
+		withTLSCertificates(certificates).
+		withProgress(completedProgress(mdb, time.Now())).
+		withMongoDBMembers(mdb.AutomationConfigMembersThisReconciliation()).
+		withStatefulSetReplicas(mdb.StatefulSetReplicasThisReconciliation()).
+		withLastReconcile(mdbv1.LastReconcileStatus{Time: metav1.Now(), QueueWait: metav1.Duration{Duration: queueWait}}).
+		withConcurrentChange(completedChange(mdb)).
+		withInitialization(initialization).
+		withPendingRestarts(pendingRestarts).
+		withExternalAddresses(externalAddresses).
+		withMessage(messageSeverity, message).
+		withRunningPhase()
+	if mdb.IsRenamingReplicaSet() {
+		rename := *mdb.Status.ReplicaSetRename
+		rename.Phase = mdbv1.ReplicaSetRenameCompleted
+		r.log.Infof("Replica set has been renamed from %s to %s", rename.From, rename.To)
+		runningOptions = runningOptions.withReplicaSetRename(rename)
+	}
+	if verified {
+		runningOptions = runningOptions.withCredentialVerificationTime(metav1.Now())
+	}
+
+	res, err := status.Update(r.client.Status(), &mdb, runningOptions)
 	if err != nil {
 		r.log.Errorf("Error updating the status of the MongoDB resource: %s", err)
 		return res, err
 	}
 
+	lastReconcileTimestamp.WithLabelValues(mdb.Namespace, mdb.Name).Set(float64(mdb.Status.LastReconcile.Time.Unix()))
+
 	// the last version will be duplicated in two annotations.
-	// This is needed to reuse the update strategy logic in enterprise
-	if err := annotations.UpdateLastAppliedMongoDBVersion(&mdb, r.client); err != nil {
+	// This is needed to reuse the update strategy logic in enterprise.
+	// A copy is annotated, as the resource is read again, which would replace a spec held back by the
+	// concurrent change policy.
+	applied := mdb
+	if err := annotations.UpdateLastAppliedMongoDBVersion(&applied, r.client); err != nil {
 		r.log.Errorf("Could not save current version as an annotation: %s", err)
 	}
 	if err := r.updateLastSuccessfulConfiguration(mdb); err != nil {
 		r.log.Errorf("Could not save current spec as an annotation: %s", err)
 	}
+	if err := r.ensureLabelSchema(mdb); err != nil {
+		r.log.Warnf("Could not label the objects of the resource: %s", err)
+	}
+
+	if queued {
+		r.log.Infof("Rollout of generation %d has completed, applying the queued generation %d", change.Generation, change.PendingGeneration)
+		res.Requeue = true
+	}
+
+	if r.secretRefreshInterval > 0 && !res.Requeue && res.RequeueAfter == 0 {
+		res.RequeueAfter = r.secretRefreshInterval
+	}
+	if interval, _, enabled, _ := credentialVerificationSettings(mdb); enabled {
+		requeueNoLaterThan(&res, untilNextCredentialVerification(mdb, interval, time.Now()))
+	}
+
+	requeueNoLaterThan(&res, r.untilNextMetricsUserRotation(mdb, time.Now()))
+
+	requeueNoLaterThan(&res, untilNextKeyfileRotation(mdb, time.Now()))
+
+	if mdb.Spec.MemberHealth.IsEnabled() {
+		requeueNoLaterThan(&res, memberHealthInterval)
+	}
+
+	if mdb.Spec.StuckMemberRemediation != nil {
+		requeueNoLaterThan(&res, stuckMemberInterval)
+	}
+
+	if mdb.Spec.ExternalAccess != nil && len(mdb.Status.ExternalAddresses) < mdb.Spec.Members {
+		requeueNoLaterThan(&res, externalAddressInterval)
+	}
+
+	if mdb.Spec.ReplicationLagGate.IsEnabled() {
+		requeueNoLaterThan(&res, replicationLagGateInterval)
+	}
+
+	requeueNoLaterThan(&res, untilCertificateExpiryChange(certificates, r.certificateExpiryWarning, time.Now()))
+
+	requeueNoLaterThan(&res, untilMaintenanceWindow(mdb, time.Now()))
 
 	if res.RequeueAfter > 0 || res.Requeue {
 		r.log.Infow("Requeuing reconciliation", "MongoDB.Spec:", mdb.Spec, "MongoDB.Status:", mdb.Status)
@@ -236,6 +1131,59 @@ func (r ReplicaSetReconciler) Reconcile(ctx context.Context, request reconcile.R
 	return res, err
 }
 
+// requeueNoLaterThan shortens the requeue delay of the result to the given duration, so that the earliest of the
+// periodic checks runs in time. A result which is requeued immediately, or a duration which is not positive,
+// leaves the result unchanged.
+func requeueNoLaterThan(res *reconcile.Result, after time.Duration) {
+	if res.Requeue || after <= 0 {
+		return
+	}
+	if res.RequeueAfter == 0 || after < res.RequeueAfter {
+		res.RequeueAfter = after
+	}
+}
+
+// deleteControlledObject deletes the given object, which only needs its name and namespace set, if it exists and
+// is controlled by the resource. Objects of the same name created by users are kept, and no request is sent to
+// delete objects which do not exist, or whose kind the API server does not serve.
+func (r ReplicaSetReconciler) deleteControlledObject(mdb mdbv1.MongoDBCommunity, obj k8sClient.Object) error {
+	err := r.client.Get(context.TODO(), k8sClient.ObjectKeyFromObject(obj), obj)
+	if apiErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Errorf("could not get %s: %s", obj.GetName(), err)
+	}
+	if !metav1.IsControlledBy(obj, &mdb) {
+		return nil
+	}
+	if err := r.client.Delete(context.TODO(), obj); err != nil && !apiErrors.IsNotFound(err) {
+		return errors.Errorf("could not delete %s: %s", obj.GetName(), err)
+	}
+	return nil
+}
+
+// watchCredentialSecrets ensures a change to the password Secret of any user triggers a reconciliation
+// of the resource, so that the SCRAM credentials are updated with the new password. The same applies
+// to the agent credentials if they are provided by the user.
+func (r *ReplicaSetReconciler) watchCredentialSecrets(mdb mdbv1.MongoDBCommunity) {
+	for _, user := range mdb.Spec.Users {
+		r.secretWatcher.Watch(types.NamespacedName{Name: user.PasswordSecretRef.Name, Namespace: mdb.Namespace}, mdb.NamespacedName())
+	}
+	if mdb.Spec.Security.Authentication.AgentCredentialsSecretRef != nil {
+		r.secretWatcher.Watch(mdb.GetAgentPasswordSecretNamespacedName(), mdb.NamespacedName())
+	}
+}
+
+// unresolvableMemberHostnames returns the hostnames of the members which can not be resolved.
+func (r *ReplicaSetReconciler) unresolvableMemberHostnames(mdb mdbv1.MongoDBCommunity) ([]string, error) {
+	hostnames, err := mdb.MemberHostnames(mdb.AutomationConfigMembersThisReconciliation(), os.Getenv(clusterDNSName))
+	if err != nil {
+		return nil, err
+	}
+	return dns.UnresolvableHostnames(context.TODO(), r.resolver, hostnames), nil
+}
+
 // updateLastSuccessfulConfiguration annotates the MongoDBCommunity resource with the latest configuration
 func (r *ReplicaSetReconciler) updateLastSuccessfulConfiguration(mdb mdbv1.MongoDBCommunity) error {
 	currentSpec, err := json.Marshal(mdb.Spec)
@@ -263,6 +1211,9 @@ func (r *ReplicaSetReconciler) ensureTLSResources(mdb mdbv1.MongoDBCommunity) er
 			return errors.Errorf("could not ensure TLS secret: %s", err)
 		}
 	}
+	if err := ensureMemberCertificateSecret(r.client, mdb); err != nil {
+		return errors.Errorf("could not ensure member certificate secret: %s", err)
+	}
 	return nil
 }
 
@@ -270,15 +1221,32 @@ func (r *ReplicaSetReconciler) ensureTLSResources(mdb mdbv1.MongoDBCommunity) er
 // The returned boolean indicates that the StatefulSet is ready.
 func (r *ReplicaSetReconciler) deployStatefulSet(mdb mdbv1.MongoDBCommunity) (bool, error) {
 	r.log.Info("Creating/Updating StatefulSet")
-	if err := r.createOrUpdateStatefulSet(mdb); err != nil {
+	recreating, err := r.createOrUpdateStatefulSet(mdb)
+	if err != nil {
 		return false, errors.Errorf("error creating/updating StatefulSet: %s", err)
 	}
+	if recreating {
+		r.log.Info("Waiting for the StatefulSet to be deleted before it is recreated")
+		return false, nil
+	}
 
-	currentSts, err := r.client.GetStatefulSet(mdb.NamespacedName())
+	currentSts, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
 	if err != nil {
 		return false, errors.Errorf("error getting StatefulSet: %s", err)
 	}
 
+	if mdb.IsScalingVertically() && currentSts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return r.advanceVerticalScaling(mdb, currentSts)
+	}
+
+	waitingForSlot, err := r.reconcileDisruptionSlot(mdb, currentSts)
+	if err != nil {
+		return false, err
+	}
+	if waitingForSlot {
+		return false, nil
+	}
+
 	r.log.Debugf("Ensuring StatefulSet is ready, with type: %s", mdb.GetUpdateStrategyType())
 
 	isReady := statefulset.IsReady(currentSts, mdb.StatefulSetReplicasThisReconciliation())
@@ -300,7 +1268,7 @@ func (r *ReplicaSetReconciler) deployStatefulSet(mdb mdbv1.MongoDBCommunity) (bo
 func (r *ReplicaSetReconciler) deployAutomationConfig(mdb mdbv1.MongoDBCommunity) (bool, error) {
 	r.log.Infof("Creating/Updating AutomationConfig")
 
-	sts, err := r.client.GetStatefulSet(mdb.NamespacedName())
+	sts, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
 	if err != nil && !apiErrors.IsNotFound(err) {
 		return false, fmt.Errorf("failed to get StatefulSet: %s", err)
 	}
@@ -309,6 +1277,7 @@ func (r *ReplicaSetReconciler) deployAutomationConfig(mdb mdbv1.MongoDBCommunity
 	if err != nil {
 		return false, fmt.Errorf("failed to ensure AutomationConfig: %s", err)
 	}
+	r.traceAutomationConfigVersion(ac.Version)
 
 	// the StatefulSet has not yet been created, so the next stage of reconciliation will be
 	// creating the StatefulSet and ensuring it reaches the Running phase.
@@ -328,6 +1297,9 @@ func (r *ReplicaSetReconciler) deployAutomationConfig(mdb mdbv1.MongoDBCommunity
 	if err != nil {
 		return false, fmt.Errorf("failed to ensure agents have reached goal state: %s", err)
 	}
+	if !ready && r.debugging() {
+		r.logAgentPlans(mdb)
+	}
 
 	return ready, nil
 }
@@ -336,7 +1308,7 @@ func (r *ReplicaSetReconciler) deployAutomationConfig(mdb mdbv1.MongoDBCommunity
 // functions should be sequential or not. A value of false indicates they will run in reversed order.
 func (r *ReplicaSetReconciler) shouldRunInOrder(mdb mdbv1.MongoDBCommunity) bool {
 	// The only case when we push the StatefulSet first is when we are ensuring TLS for the already existing ReplicaSet
-	_, err := r.client.GetStatefulSet(mdb.NamespacedName())
+	_, err := r.client.GetStatefulSet(mdb.StatefulSetNamespacedName())
 	if err == nil && mdb.Spec.Security.TLS.Enabled {
 		r.log.Debug("Enabling TLS on an existing deployment, the StatefulSet must be updated first")
 		return false
@@ -369,34 +1341,70 @@ func (r *ReplicaSetReconciler) shouldRunInOrder(mdb mdbv1.MongoDBCommunity) bool
 func (r *ReplicaSetReconciler) deployMongoDBReplicaSet(mdb mdbv1.MongoDBCommunity) (bool, error) {
 	return functions.RunSequentially(r.shouldRunInOrder(mdb),
 		func() (bool, error) {
-			return r.deployAutomationConfig(mdb)
+			span := r.startSpan("DeployAutomationConfig")
+			ready, err := r.deployAutomationConfig(mdb)
+			endSpan(span, err)
+			return ready, err
 		},
 		func() (bool, error) {
-			return r.deployStatefulSet(mdb)
+			span := r.startSpan("DeployStatefulSet")
+			ready, err := r.deployStatefulSet(mdb)
+			endSpan(span, err)
+			return ready, err
 		})
 }
 
+// ensureService creates or updates the headless Service, and the client Service if it is enabled.
 func (r *ReplicaSetReconciler) ensureService(mdb mdbv1.MongoDBCommunity) error {
-	svc := buildService(mdb)
-	err := r.client.Create(context.TODO(), &svc)
-	if err != nil && apiErrors.IsAlreadyExists(err) {
-		r.log.Infof("The service already exists... moving forward: %s", err)
-		return nil
+	if err := r.createOrUpdateService(buildService(mdb)); err != nil {
+		return err
 	}
-	return err
+	return r.ensureClientService(mdb)
 }
 
-func (r *ReplicaSetReconciler) createOrUpdateStatefulSet(mdb mdbv1.MongoDBCommunity) error {
+// createOrUpdateStatefulSet creates or updates the StatefulSet. The StatefulSet is recreated if the update changes
+// immutable fields. It returns true while the StatefulSet is being deleted to be recreated.
+func (r *ReplicaSetReconciler) createOrUpdateStatefulSet(mdb mdbv1.MongoDBCommunity) (bool, error) {
 	set := appsv1.StatefulSet{}
-	err := r.client.Get(context.TODO(), mdb.NamespacedName(), &set)
+	err := r.client.Get(context.TODO(), mdb.StatefulSetNamespacedName(), &set)
+	exists := err == nil
 	err = k8sClient.IgnoreNotFound(err)
 	if err != nil {
-		return errors.Errorf("error getting StatefulSet: %s", err)
+		return false, errors.Errorf("error getting StatefulSet: %s", err)
+	}
+	if exists && set.DeletionTimestamp != nil {
+		return true, nil
+	}
+	partitionModification, err := r.disruptionPartitionModification(mdb, exists)
+	if err != nil {
+		return false, errors.Errorf("error checking whether members may be restarted: %s", err)
 	}
-	buildStatefulSetModificationFunction(mdb)(&set)
+	existing := *set.DeepCopy()
+	if err := r.applyStatefulSetModifications(mdb, &set, exists); err != nil {
+		return false, err
+	}
+	partitionModification(&set)
+	r.canaryPartitionModification(mdb)(&set)
 	if _, err = statefulset.CreateOrUpdate(r.client, set); err != nil {
-		return errors.Errorf("error creating/updating StatefulSet: %s", err)
+		if exists && statefulset.IsImmutableFieldError(err) {
+			return r.recreateStatefulSet(mdb, existing, set, err)
+		}
+		return false, errors.Errorf("error creating/updating StatefulSet: %s", err)
+	}
+	return false, nil
+}
+
+// applyStatefulSetModifications applies the desired state of the MongoDBCommunity resource to the given StatefulSet,
+// except for the partition which gates the restarts of the members.
+func (r *ReplicaSetReconciler) applyStatefulSetModifications(mdb mdbv1.MongoDBCommunity, set *appsv1.StatefulSet, exists bool) error {
+	tlsCertificateHashModification, err := getTLSCertificateHashModification(r.client, mdb)
+	if err != nil {
+		return err
 	}
+	previousPodLabels := set.Spec.Template.Labels
+	buildStatefulSetModificationFunction(mdb)(set)
+	tlsCertificateHashModification(set)
+	labelSchemaModification(mdb, previousPodLabels, exists)(set)
 	return nil
 }
 
@@ -407,32 +1415,51 @@ func (r ReplicaSetReconciler) ensureAutomationConfig(mdb mdbv1.MongoDBCommunity)
 	if err != nil {
 		return automationconfig.AutomationConfig{}, errors.Errorf("could not build automation config: %s", err)
 	}
+	if err := objectSizeError(automationConfigSizeErrors(mdb, ac)); err != nil {
+		return automationconfig.AutomationConfig{}, err
+	}
 
-	return automationconfig.EnsureSecret(
-		r.client,
-		types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace},
-		mdb.GetOwnerReferences(),
-		ac,
-	)
-
+	nsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+	var previous automationconfig.AutomationConfig
+	if r.debugging() {
+		if previous, err = automationconfig.ReadFromSecret(r.client, nsName); err != nil {
+			r.log.Debugf("Could not read the current automation config: %s", err)
+		}
+	}
+	ac, err = automationconfig.EnsureSecret(r.client, nsName, mdb.GetOwnerReferences(), ac)
+	if err == nil && r.debugging() {
+		r.logAutomationConfigChanges(previous, ac)
+	}
+	return ac, err
 }
 
 func buildAutomationConfig(mdb mdbv1.MongoDBCommunity, auth automationconfig.Auth, currentAc automationconfig.AutomationConfig, modifications ...automationconfig.Modification) (automationconfig.AutomationConfig, error) {
 	domain := getDomain(mdb.ServiceName(), mdb.Namespace, os.Getenv(clusterDNSName))
 	zap.S().Debugw("AutomationConfigMembersThisReconciliation", "mdb.AutomationConfigMembersThisReconciliation()", mdb.AutomationConfigMembersThisReconciliation())
 
+	hostnames, err := mdb.MemberHostnames(mdb.AutomationConfigMembersThisReconciliation(), os.Getenv(clusterDNSName))
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not generate member hostnames: %s", err)
+	}
+
 	return automationconfig.NewBuilder().
 		SetTopology(automationconfig.ReplicaSetTopology).
 		SetName(mdb.Name).
+		SetReplicaSetName(mdb.GetReplicaSetName()).
 		SetDomain(domain).
 		SetMembers(mdb.AutomationConfigMembersThisReconciliation()).
+		SetMemberOrdinalStart(mdb.MemberOrdinalStart()).
 		SetReplicaSetHorizons(mdb.Spec.ReplicaSetHorizons).
 		SetPreviousAutomationConfig(currentAc).
 		SetMongoDBVersion(mdb.Spec.Version).
 		SetFCV(mdb.Spec.FeatureCompatibilityVersion).
 		SetOptions(automationconfig.Options{DownloadBase: "/var/lib/mongodb-mms-automation"}).
 		SetAuth(auth).
+		AddProcessModification(func(i int, p *automationconfig.Process) {
+			p.HostName = hostnames[i]
+		}).
 		AddModifications(getMongodConfigModification(mdb)).
+		AddModifications(getServerParametersModification(mdb)).
 		AddModifications(modifications...).
 		Build()
 }
@@ -444,35 +1471,79 @@ func buildAutomationConfig(mdb mdbv1.MongoDBCommunity, auth automationconfig.Aut
 func buildService(mdb mdbv1.MongoDBCommunity) corev1.Service {
 	label := make(map[string]string)
 	label["app"] = mdb.ServiceName()
-	return service.Builder().
+	svc := service.Builder().
 		SetName(mdb.ServiceName()).
 		SetNamespace(mdb.Namespace).
 		SetSelector(label).
+		SetLabels(mdb.SchemaLabels(mdbv1.ComponentDatabase)).
 		SetServiceType(corev1.ServiceTypeClusterIP).
 		SetClusterIP("None").
-		SetPort(27017).
 		SetPublishNotReadyAddresses(true).
 		SetOwnerReferences(mdb.GetOwnerReferences()).
 		Build()
+	// the SRV records of mongodb+srv:// connection strings carry the port of this Service, which must be the port of mongod.
+	return withServiceMeshAppProtocol(mdb, withIPFamilies(mdb, withServiceConfiguration(mdb, svc, automationconfig.DefaultDBPort)), mongodAppProtocol)
 }
 
 // validateUpdate validates that the new Spec, corresponding to the existing one
 // is still valid. If there is no a previous Spec, then the function assumes this is
 // the first version of the MongoDB resource and skips.
 func (r ReplicaSetReconciler) validateUpdate(mdb mdbv1.MongoDBCommunity) error {
-	lastSuccessfulConfigurationSaved, ok := mdb.Annotations[lastSuccessfulConfiguration]
-	if !ok {
+	prevSpec, err := lastSuccessfulSpec(mdb)
+	if err != nil {
+		return err
+	}
+	if prevSpec == nil {
 		// First version of Spec, no need to validate
 		return nil
 	}
 
-	prevSpec := mdbv1.MongoDBCommunitySpec{}
-	err := json.Unmarshal([]byte(lastSuccessfulConfigurationSaved), &prevSpec)
-	if err != nil {
-		return err
+	// the replica set names, Pod subdomains and hostname prefixes are compared with their defaults applied,
+	// which depend on the resource name.
+	prev := mdbv1.MongoDBCommunity{ObjectMeta: mdb.ObjectMeta, Spec: *prevSpec}
+	prevSpec.ReplicaSetName = prev.GetReplicaSetName()
+	prevSpec.PodSubdomain = prev.ServiceName()
+	prevSpec.PodHostnamePrefix = prev.StatefulSetName()
+	newSpec := mdb.Spec
+	newSpec.ReplicaSetName = mdb.GetReplicaSetName()
+	newSpec.PodSubdomain = mdb.ServiceName()
+	newSpec.PodHostnamePrefix = mdb.StatefulSetName()
+	// TLS can be disabled while its certificate Secret is missing, which otherwise holds the reconciliation
+	// until the Secret is restored.
+	if meta.IsStatusConditionTrue(mdb.Status.Conditions, mdbv1.ConditionTLSSecretMissing) {
+		prevSpec.Security.TLS.Enabled = newSpec.Security.TLS.Enabled
+	}
+
+	return validation.Validate(*prevSpec, newSpec)
+}
+
+// validateMemberOrdinals checks that the ordinal of the last member, which is also its id in the replica set
+// configuration, does not exceed the highest id MongoDB accepts.
+func validateMemberOrdinals(mdb mdbv1.MongoDBCommunity) error {
+	members := mdb.Spec.Members
+	if mdb.Status.CurrentMongoDBMembers > members {
+		members = mdb.Status.CurrentMongoDBMembers
+	}
+	if last := mdb.MemberOrdinalStart() + members - 1; last > automationconfig.MaxMemberID {
+		return errors.Errorf("the ordinal of the last member can't exceed %d, as it is its id in the replica set, but spec.memberOrdinalStart %d and %d members end at %d",
+			automationconfig.MaxMemberID, mdb.MemberOrdinalStart(), members, last)
+	}
+	return nil
+}
+
+// lastSuccessfulSpec returns the Spec the resource was last reconciled successfully with,
+// or nil if it has never been reconciled successfully.
+func lastSuccessfulSpec(mdb mdbv1.MongoDBCommunity) (*mdbv1.MongoDBCommunitySpec, error) {
+	lastSuccessfulConfigurationSaved, ok := mdb.Annotations[lastSuccessfulConfiguration]
+	if !ok {
+		return nil, nil
 	}
 
-	return validation.Validate(prevSpec, mdb.Spec)
+	prevSpec := mdbv1.MongoDBCommunitySpec{}
+	if err := json.Unmarshal([]byte(lastSuccessfulConfigurationSaved), &prevSpec); err != nil {
+		return nil, err
+	}
+	return &prevSpec, nil
 }
 
 func getCustomRolesModification(mdb mdbv1.MongoDBCommunity) (automationconfig.Modification, error) {
@@ -492,6 +1563,21 @@ func (r ReplicaSetReconciler) buildAutomationConfig(mdb mdbv1.MongoDBCommunity)
 		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure TLS modification: %s", err)
 	}
 
+	clusterAuthModification, err := getClusterAuthConfigModification(r.client, mdb)
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure cluster authentication: %s", err)
+	}
+
+	ldapModification, err := getLDAPConfigModification(r.client, mdb)
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure LDAP modification: %s", err)
+	}
+
+	keyfileRotationModification, err := getKeyfileRotationModification(r.client, mdb)
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure keyfile rotation: %s", err)
+	}
+
 	customRolesModification, err := getCustomRolesModification(mdb)
 	if err != nil {
 		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure custom roles: %s", err)
@@ -502,8 +1588,24 @@ func (r ReplicaSetReconciler) buildAutomationConfig(mdb mdbv1.MongoDBCommunity)
 		return automationconfig.AutomationConfig{}, errors.Errorf("could not read existing automation config: %s", err)
 	}
 
+	memberZoneTags, err := r.memberZoneTags(mdb, currentAC)
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure zone awareness: %s", err)
+	}
+
+	externalAddresses, err := r.externalAddresses(mdb, mdb.AutomationConfigMembersThisReconciliation())
+	if err != nil {
+		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure external access: %s", err)
+	}
+
 	auth := automationconfig.Auth{}
-	if err := scram.Enable(&auth, r.client, mdb); err != nil {
+	agentX509Modification := automationconfig.NOOP()
+	if agentAuthenticatesWithX509(mdb) {
+		agentX509Modification, err = enableX509AgentAuthentication(&auth, r.client, mdb)
+		if err != nil {
+			return automationconfig.AutomationConfig{}, errors.Errorf("could not configure X.509 authentication of the agent: %s", err)
+		}
+	} else if err := scram.Enable(&auth, r.client, mdb, r.passwords); err != nil {
 		return automationconfig.AutomationConfig{}, errors.Errorf("could not configure scram authentication: %s", err)
 	}
 
@@ -512,7 +1614,24 @@ func (r ReplicaSetReconciler) buildAutomationConfig(mdb mdbv1.MongoDBCommunity)
 		auth,
 		currentAC,
 		tlsModification,
+		clusterAuthModification,
+		ldapModification,
+		x509ConfigModification(mdb),
+		agentX509Modification,
+		keyfileRotationModification,
 		customRolesModification,
+		systemLogModification(mdb),
+		auditLogModification(mdb),
+		auditLogForwarderModification(mdb),
+		zoneAwarenessModification(memberZoneTags),
+		externalAccessModification(mdb, externalAddresses),
+		plannedOutageModification(mdb),
+		temporaryDirectoryModification(mdb),
+		listenersModification(mdb),
+		externalMembersModification(mdb),
+		canaryVersionModification(mdb, currentAC),
+		ipv6Modification(mdb),
+		forceReconfigModification(mdb),
 	)
 }
 
@@ -544,8 +1663,22 @@ func buildStatefulSetModificationFunction(mdb mdbv1.MongoDBCommunity) statefulse
 		statefulset.WithPodSpecTemplate(
 			podtemplatespec.Apply(
 				buildTLSPodSpecModification(mdb),
+				buildCABundlePodSpecModification(mdb),
+				buildClusterAuthPodSpecModification(mdb),
+				buildSystemLogPodSpecModification(mdb),
+				buildAuditLogForwarderPodSpecModification(mdb),
+				buildPBMAgentPodSpecModification(mdb),
+				buildPrometheusExporterPodSpecModification(mdb),
+				buildMongodLivenessProbePodSpecModification(mdb),
+				buildReadinessProbeDefaultsPodSpecModification(mdb),
+				buildReplicationLagGatePodSpecModification(mdb),
+				buildZoneAwarenessPodSpecModification(mdb),
+				buildServiceMeshPodSpecModification(mdb),
+				buildHostAccessPodSpecModification(mdb),
+				construct.BuildSecurityContextPresetModification(&mdb),
 			),
 		),
+		podRecoveryModification(mdb),
 
 		statefulset.WithCustomSpecs(mdb.Spec.StatefulSetConfiguration.SpecWrapper.Spec),
 	)
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -22,6 +24,8 @@ import (
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/authentication/scram"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/annotations"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/secretbackend"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/probes"
@@ -36,6 +40,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -363,6 +370,88 @@ func TestAutomationConfig_CustomMongodConfig(t *testing.T) {
 	}
 }
 
+func TestAutomationConfig_HostnameTemplate(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.HostnameTemplate = "{{.PodName}}.{{.Namespace}}.example.com"
+
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
+	assertReconciliationSuccessful(t, res, err)
+
+	currentAc, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+
+	for i, p := range currentAc.Processes {
+		assert.Equal(t, fmt.Sprintf("%s-%d.%s.example.com", mdb.Name, i, mdb.Namespace), p.HostName)
+	}
+}
+
+func TestAutomationConfig_InvalidHostnameTemplate(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.HostnameTemplate = "{{.PodName"
+
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
+	assert.NoError(t, err)
+
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+}
+
+// mockResolver maps hostnames to their addresses and "_service._proto.name" to the targets of the SRV records.
+type mockResolver map[string][]string
+
+func (m mockResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := m[host]; ok {
+		return addrs, nil
+	}
+	return nil, fmt.Errorf("no such host: %s", host)
+}
+
+func (m mockResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	targets, ok := m[fmt.Sprintf("_%s._%s.%s", service, proto, name)]
+	if !ok {
+		return "", nil, fmt.Errorf("no such host: %s", name)
+	}
+	var records []*net.SRV
+	for _, target := range targets {
+		records = append(records, &net.SRV{Target: target, Port: 27017})
+	}
+	return name, records, nil
+}
+
+func (m mockResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestMemberHostnames_AreVerified(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	r.verifyMemberDNS = true
+	resolver := mockResolver{
+		"my-rs-0.my-rs-svc.my-ns.svc.cluster.local": {"10.0.0.1"},
+		"my-rs-1.my-rs-svc.my-ns.svc.cluster.local": {"10.0.0.2"},
+	}
+	r.resolver = resolver
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 0)
+
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+	assert.Contains(t, mdb.Status.Message, "my-rs-2.my-rs-svc.my-ns.svc.cluster.local")
+
+	resolver["my-rs-2.my-rs-svc.my-ns.svc.cluster.local"] = []string{"10.0.0.3"}
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+}
+
 func TestExistingPasswordAndKeyfile_AreUsedWhenTheSecretExists(t *testing.T) {
 	mdb := newScramReplicaSet()
 	mgr := client.NewManager(&mdb)
@@ -404,6 +493,79 @@ func TestExistingPasswordAndKeyfile_AreUsedWhenTheSecretExists(t *testing.T) {
 
 }
 
+func TestExistingAgentCredentialsAreUsed(t *testing.T) {
+	mdb := newScramReplicaSet()
+	mdb.Spec.Security.Authentication.AgentCredentialsSecretRef = &mdbv1.LocalObjectReference{Name: "agent-credentials"}
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: mdb.NamespacedName()}
+
+	// the reconciliation fails until the agent credentials exist
+	_, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+	_, err = mgr.Client.GetSecret(types.NamespacedName{Name: mdb.Name + "-agent-password", Namespace: mdb.Namespace})
+	assert.Error(t, err, "agent credentials must not be generated")
+
+	err = secret.CreateOrUpdate(mgr.Client,
+		secret.Builder().
+			SetName("agent-credentials").
+			SetNamespace(mdb.Namespace).
+			SetField(scram.AgentUsernameKey, "iam-agent").
+			SetField(scram.AgentPasswordKey, "externally-managed").
+			Build(),
+	)
+	assert.NoError(t, err)
+
+	res, err := r.Reconcile(context.TODO(), req)
+	assertReconciliationSuccessful(t, res, err)
+
+	currentAc, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "iam-agent", currentAc.Auth.AutoUser)
+	assert.Equal(t, "externally-managed", currentAc.Auth.AutoPwd)
+}
+
+func TestUserPasswordIsReadFromSecretBackend(t *testing.T) {
+	secretsPath := t.TempDir()
+	passwordDir := filepath.Join(secretsPath, "my-ns", "my-user-password")
+	assert.NoError(t, os.MkdirAll(passwordDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(passwordDir, "password"), []byte("csi-password"), 0600))
+
+	os.Setenv(secretbackend.SecretBackendEnv, secretbackend.CSI)
+	os.Setenv(secretbackend.CSISecretsPathEnv, secretsPath)
+	defer os.Unsetenv(secretbackend.SecretBackendEnv)
+	defer os.Unsetenv(secretbackend.CSISecretsPathEnv)
+
+	mdb := newScramReplicaSet(mdbv1.MongoDBUser{
+		Name: "my-user",
+		DB:   "admin",
+		PasswordSecretRef: mdbv1.SecretKeyReference{
+			Name: "my-user-password",
+		},
+		ScramCredentialsSecretName: "my-scram",
+	})
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, res.RequeueAfter, "changes in the secret backend can not be watched")
+
+	err = mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb)
+	assert.NoError(t, err)
+	assert.Equal(t, mdbv1.Running, mdb.Status.Phase)
+
+	_, err = mgr.Client.GetSecret(types.NamespacedName{Name: "my-user-password", Namespace: mdb.Namespace})
+	assert.Error(t, err, "the password must not be copied to a native Secret")
+
+	currentAc, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Len(t, currentAc.Auth.Users, 1)
+}
+
 func TestScramIsConfigured(t *testing.T) {
 	assertReplicaSetIsConfiguredWithScram(t, newScramReplicaSet())
 }
@@ -412,12 +574,58 @@ func TestScramIsConfiguredWhenNotSpecified(t *testing.T) {
 	assertReplicaSetIsConfiguredWithScram(t, newTestReplicaSet())
 }
 
+func TestUserPasswordChange_IsPropagated(t *testing.T) {
+	mdb := newScramReplicaSet(mdbv1.MongoDBUser{
+		Name: "my-user",
+		DB:   "admin",
+		PasswordSecretRef: mdbv1.SecretKeyReference{
+			Name: "my-user-password",
+		},
+		ScramCredentialsSecretName: "my-scram",
+	})
+	mgr := client.NewManager(&mdb)
+	passwordSecret := secret.Builder().
+		SetName("my-user-password").
+		SetNamespace(mdb.Namespace).
+		SetField("password", "old-password").
+		Build()
+	assert.NoError(t, mgr.GetClient().Create(context.TODO(), &passwordSecret))
+
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	acNsName := types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace}
+	oldAc, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+	assert.NoError(t, err)
+
+	t.Run("Changing the password secret triggers a reconciliation", func(t *testing.T) {
+		queue := controllertest.Queue{Interface: workqueue.New()}
+		r.secretWatcher.Update(event.UpdateEvent{ObjectOld: &passwordSecret, ObjectNew: &passwordSecret}, queue)
+		assert.Equal(t, 1, queue.Len())
+		item, _ := queue.Get()
+		assert.Equal(t, reconcile.Request{NamespacedName: mdb.NamespacedName()}, item)
+	})
+
+	passwordSecret.Data["password"] = []byte("new-password")
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &passwordSecret))
+
+	res, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	newAc, err := automationconfig.ReadFromSecret(mgr.Client, acNsName)
+	assert.NoError(t, err)
+	assert.Equal(t, oldAc.Version+1, newAc.Version)
+	assert.NotEqual(t, oldAc.Auth.Users[0].ScramSha256Creds, newAc.Auth.Users[0].ScramSha256Creds)
+}
+
 func TestReplicaSet_IsScaledDown_OneMember_AtATime_WhenItAlreadyExists(t *testing.T) {
 	mdb := newTestReplicaSet()
 	mdb.Spec.Members = 5
 
 	mgr := client.NewManager(&mdb)
 	r := NewReconciler(mgr)
+	r.replicationStatus = &mockStatusReader{status: replicationStatus(mdb, 5, nil)}
 	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mdb.Namespace, Name: mdb.Name}})
 	assertReconciliationSuccessful(t, res, err)
 
@@ -573,6 +781,86 @@ func assertReplicaSetIsConfiguredWithScram(t *testing.T, mdb mdbv1.MongoDBCommun
 	})
 }
 
+func TestReplicaSet_PodSubdomainAndHostnamePrefix(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.PodSubdomain = "mongo"
+	mdb.Spec.PodHostnamePrefix = "legacy-db"
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts, err := mgr.Client.GetStatefulSet(types.NamespacedName{Name: "legacy-db", Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	assert.Equal(t, "mongo", sts.Spec.ServiceName)
+
+	svc := corev1.Service{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "mongo", Namespace: mdb.Namespace}, &svc))
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for i, process := range ac.Processes {
+		assert.Equal(t, fmt.Sprintf("legacy-db-%d.mongo.my-ns.svc.cluster.local", i), process.HostName)
+	}
+
+	t.Run("The Pod subdomain can not be changed", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.PodSubdomain = ""
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "the Pod subdomain can't be changed")
+	})
+}
+
+func TestReplicaSet_MemberOrdinalStart(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MemberOrdinalStart = 3
+	mgr := client.NewManager(&mdb)
+	r := NewReconciler(mgr)
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assertReconciliationSuccessful(t, res, err)
+
+	sts, err := mgr.Client.GetStatefulSet(mdb.StatefulSetNamespacedName())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, statefulset.OrdinalsStart(sts))
+
+	ac, err := automationconfig.ReadFromSecret(mgr.Client, types.NamespacedName{Name: mdb.AutomationConfigSecretName(), Namespace: mdb.Namespace})
+	assert.NoError(t, err)
+	for i, process := range ac.Processes {
+		assert.Equal(t, fmt.Sprintf("%s-%d.%s.%s.svc.cluster.local", mdb.Name, i+3, mdb.ServiceName(), mdb.Namespace), process.HostName)
+		assert.Equal(t, fmt.Sprintf("%s-%d", mdb.Name, i+3), process.Name)
+	}
+
+	t.Run("The member ordinal start can not be changed", func(t *testing.T) {
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		mdb.Spec.MemberOrdinalStart = 0
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Failed, mdb.Status.Phase)
+		assert.Contains(t, mdb.Status.Message, "the member ordinal start can't be changed")
+	})
+}
+
+func TestValidateMemberOrdinals(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MemberOrdinalStart = 253
+	assert.NoError(t, validateMemberOrdinals(mdb))
+
+	mdb.Spec.Members = 4
+	assert.EqualError(t, validateMemberOrdinals(mdb), "the ordinal of the last member can't exceed 255, as it is its id in the replica set, but spec.memberOrdinalStart 253 and 4 members end at 256")
+
+	mdb.Spec.Members = 3
+	mdb.Status.CurrentMongoDBMembers = 4
+	assert.Error(t, validateMemberOrdinals(mdb), "members which are still being removed count as well")
+}
+
 func TestReplicaSet_IsScaledUpToDesiredMembers_WhenFirstCreated(t *testing.T) {
 	mdb := newTestReplicaSet()
 
@@ -602,6 +890,25 @@ func TestVolumeClaimTemplates_Configuration(t *testing.T) {
 	assert.Contains(t, pvcSpec.AccessModes, corev1.ReadWriteOnce)
 }
 
+func TestRequeueNoLaterThan(t *testing.T) {
+	res := reconcile.Result{}
+	requeueNoLaterThan(&res, 0)
+	assert.Equal(t, reconcile.Result{}, res)
+
+	requeueNoLaterThan(&res, time.Minute)
+	assert.Equal(t, time.Minute, res.RequeueAfter)
+	requeueNoLaterThan(&res, time.Hour)
+	assert.Equal(t, time.Minute, res.RequeueAfter, "a later requeue does not replace an earlier one")
+	requeueNoLaterThan(&res, time.Second)
+	assert.Equal(t, time.Second, res.RequeueAfter)
+	requeueNoLaterThan(&res, -time.Second)
+	assert.Equal(t, time.Second, res.RequeueAfter)
+
+	res = reconcile.Result{Requeue: true}
+	requeueNoLaterThan(&res, time.Minute)
+	assert.Equal(t, reconcile.Result{Requeue: true}, res, "an immediate requeue is kept")
+}
+
 func TestChangeDataVolume_Configuration(t *testing.T) {
 	sts := performReconciliationAndGetStatefulSet(t, "change_data_volume.yaml")
 	assert.Len(t, sts.Spec.VolumeClaimTemplates, 2)
@@ -694,7 +1001,7 @@ func makeStatefulSetReady(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCom
 
 func setStatefulSetReadyReplicas(t *testing.T, c k8sClient.Client, mdb mdbv1.MongoDBCommunity, readyReplicas int) {
 	sts := appsv1.StatefulSet{}
-	err := c.Get(context.TODO(), mdb.NamespacedName(), &sts)
+	err := c.Get(context.TODO(), mdb.StatefulSetNamespacedName(), &sts)
 	assert.NoError(t, err)
 	sts.Status.ReadyReplicas = int32(readyReplicas)
 	sts.Status.UpdatedReplicas = int32(mdb.StatefulSetReplicasThisReconciliation())
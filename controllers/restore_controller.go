@@ -0,0 +1,434 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/result"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+const restoreLoggerName = "restore"
+
+// restoreSource is the archive restored by a restore and the bucket it is stored in, and the oplog slices
+// replayed after the archive has been restored if the restore is to a point in time.
+type restoreSource struct {
+	location             string
+	endpoint             string
+	region               string
+	credentialsSecretRef string
+	oplog                *construct.OplogReplayOptions
+	// encryption is the key the archive and the oplog slices were encrypted with, nil if they are not encrypted
+	encryption *mdbv1.BackupEncryption
+	// pbm is the backup or the point in time restored by the PBM agents of the resource, nil for an archive
+	pbm *pbmRestoreSource
+}
+
+// pbmRestoreSource is a backup, or a point in time if the name is empty, restored by the PBM agents.
+type pbmRestoreSource struct {
+	backupName string
+	targetTime time.Time
+}
+
+// RestoreReconciler restores the archives described by MongoDBCommunityRestore resources.
+type RestoreReconciler struct {
+	client kubernetesClient.Client
+	log    *zap.SugaredLogger
+}
+
+func NewRestoreReconciler(mgr manager.Manager) *RestoreReconciler {
+	return &RestoreReconciler{
+		client: kubernetesClient.NewClient(mgr.GetClient()),
+		log:    zap.S().Named(restoreLoggerName),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager and configures the necessary watches.
+func (r *RestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mdbv1.MongoDBCommunityRestore{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunityrestore,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunityrestore/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunity,verbs=create;update
+// +kubebuilder:rbac:groups=mongodbcommunity.mongodb.com,resources=mongodbcommunitybackupschedule,verbs=get
+
+// Reconcile restores the archive once the MongoDBCommunity resource is running, creating the resource first if
+// it does not exist. The resource is annotated while the restore Job runs, so that it is not reconciled
+// meanwhile, and the annotation is removed once the Job has finished. Finished restores are not reconciled anymore.
+func (r RestoreReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	restore := mdbv1.MongoDBCommunityRestore{}
+	if err := r.client.Get(ctx, request.NamespacedName, &restore); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return result.OK()
+		}
+		r.log.Errorf("Error reconciling MongoDBCommunityRestore resource: %s", err)
+		return result.Failed()
+	}
+	r.log = zap.S().Named(restoreLoggerName).With("Restore", request.NamespacedName)
+
+	if restore.IsFinished() {
+		return result.OK()
+	}
+
+	job := batchv1.Job{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job)
+	if apiErrors.IsNotFound(err) {
+		return r.startRestore(restore)
+	}
+	if err != nil {
+		r.log.Errorf("Could not get the restore Job: %s", err)
+		return result.Failed()
+	}
+	return r.observeRestoreJob(restore, job)
+}
+
+// startRestore pauses the reconciliation of the MongoDBCommunity resource and creates the Job restoring the
+// archive. The restore stays pending while the archive, the resource or any Secret used by the Job is not available.
+func (r RestoreReconciler) startRestore(restore mdbv1.MongoDBCommunityRestore) (reconcile.Result, error) {
+	source, phase, message := r.resolveRestoreSource(restore)
+	if phase != "" {
+		return r.updateRestoreStatus(restore, phase, message)
+	}
+
+	mdb := mdbv1.MongoDBCommunity{}
+	if err := r.client.Get(context.TODO(), restore.MongoDBResourceNamespacedName(), &mdb); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Could not get MongoDBCommunity resource %s: %s", restore.Spec.MongoDBResourceRef.Name, err))
+		}
+		if restore.Spec.MongoDBResourceSpec == nil {
+			return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("MongoDBCommunity resource %s does not exist", restore.Spec.MongoDBResourceRef.Name))
+		}
+		return r.createMongoDBResource(restore)
+	}
+
+	if inProgress := mdb.Annotations[mdbv1.RestoreInProgressAnnotation]; inProgress != restore.Name {
+		if inProgress != "" {
+			return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Waiting for restore %s of MongoDBCommunity resource %s to finish", inProgress, mdb.Name))
+		}
+		// the data of a new deployment is restored before the resource reaches the Running phase
+		initializing := mdb.Status.Initialization != nil && mdb.Status.Initialization.RestoreName == restore.Name
+		if mdb.Status.Phase != mdbv1.Running && !initializing {
+			return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Waiting for MongoDBCommunity resource %s to be running", mdb.Name))
+		}
+	}
+
+	user, ok := findUser(mdb, restore.Spec.User)
+	if !ok {
+		return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("User %s is not a user of MongoDBCommunity resource %s", restore.Spec.User, mdb.Name))
+	}
+	connectionStringSecret := types.NamespacedName{Name: user.GetConnectionStringSecretName(mdb.Name), Namespace: mdb.Namespace}
+	secrets := []types.NamespacedName{connectionStringSecret}
+	if source.pbm != nil {
+		if !mdb.IsPBMEnabled() {
+			return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Waiting for PBM to be enabled in MongoDBCommunity resource %s", mdb.Name))
+		}
+		if source.pbm.backupName == "" && (mdb.Spec.PBM.PointInTimeRecovery == nil || !mdb.Spec.PBM.PointInTimeRecovery.Enabled) {
+			return r.updateRestoreStatus(restore, mdbv1.RestoreFailed, fmt.Sprintf("Point-in-time recovery is not enabled in MongoDBCommunity resource %s", mdb.Name))
+		}
+		secrets = append(secrets, types.NamespacedName{Name: mdb.PBMConfigSecretName(), Namespace: mdb.Namespace})
+	} else {
+		secrets = append(secrets, types.NamespacedName{Name: source.credentialsSecretRef, Namespace: restore.Namespace})
+	}
+	if source.encryption != nil && source.encryption.KeySecretRef != nil {
+		secrets = append(secrets, types.NamespacedName{Name: source.encryption.KeySecretRef.Name, Namespace: restore.Namespace})
+	}
+	for _, nsName := range secrets {
+		if _, err := r.client.GetSecret(nsName); err != nil {
+			if apiErrors.IsNotFound(err) {
+				return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Waiting for Secret %s to exist", nsName.Name))
+			}
+			return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Could not get Secret %s: %s", nsName.Name, err))
+		}
+	}
+
+	if err := r.setRestoreInProgress(mdb, restore.Name); err != nil {
+		r.log.Errorf("Could not pause the reconciliation of MongoDBCommunity resource %s: %s", mdb.Name, err)
+		return result.Failed()
+	}
+
+	job := buildRestoreJob(restore, mdb, source, connectionStringSecret.Name)
+	job.OwnerReferences = restore.GetOwnerReferences()
+	setJobMetadata(mdb, &job)
+	job.Labels[mdbv1.JobResourceLabel] = mdb.Name
+	job.Labels[mdbv1.JobTypeLabel] = string(mdbv1.RestoreJob)
+	if err := r.client.Create(context.TODO(), &job); err != nil && !apiErrors.IsAlreadyExists(err) {
+		r.log.Errorf("Could not create the restore Job: %s", err)
+		return result.Failed()
+	}
+
+	if source.pbm != nil {
+		source.location = mdb.Spec.PBM.StorageLocation()
+	}
+	r.log.Infof("Restoring %s into MongoDBCommunity resource %s", source.location, mdb.Name)
+	now := metav1.Now()
+	restore.Status.Location = source.location
+	if source.oplog != nil {
+		restore.Status.OplogLocation = source.oplog.URL
+	}
+	restore.Status.StartTime = &now
+	if _, err := r.updateRestoreStatus(restore, mdbv1.RestoreRestoring, ""); err != nil {
+		return result.Failed()
+	}
+	return result.Retry(backupPollInterval)
+}
+
+// buildRestoreJob returns the Job restoring the source into the resource, as the user of the given connection
+// string Secret.
+func buildRestoreJob(restore mdbv1.MongoDBCommunityRestore, mdb mdbv1.MongoDBCommunity, source restoreSource, connectionStringSecretName string) batchv1.Job {
+	if source.pbm != nil {
+		return construct.BuildPBMRestoreJob(&mdb, pbmJobOptions(mdb, restore.JobName(), connectionStringSecretName), source.pbm.backupName, source.pbm.targetTime)
+	}
+	opts := construct.RestoreJobOptions{
+		BackupJobOptions: construct.BackupJobOptions{
+			Name:                       restore.JobName(),
+			Namespace:                  restore.Namespace,
+			ConnectionStringSecretName: connectionStringSecretName,
+			ConnectionStringKey:        connectionStringStandardKey,
+			UploadImage:                restore.GetImage(),
+			URL:                        source.location,
+			Endpoint:                   source.endpoint,
+			Region:                     source.region,
+			CredentialsSecretName:      source.credentialsSecretRef,
+			AccessKeyIDKey:             mdbv1.S3AccessKeyIDKey,
+			SecretAccessKeyKey:         mdbv1.S3SecretAccessKeyKey,
+			Encryption:                 encryptionOptions(source.encryption, source.region),
+		},
+		Drop:  restore.Spec.Drop,
+		Oplog: source.oplog,
+	}
+	if mdb.Spec.Security.TLS.Enabled {
+		opts.CAConfigMapName = mdb.Spec.Security.TLS.CaConfigMap.Name
+	}
+	return construct.BuildRestoreJob(&mdb, opts)
+}
+
+// resolveRestoreSource returns the archive to restore. If the archive is not available, it returns the phase and
+// the message the restore is updated with instead.
+func (r RestoreReconciler) resolveRestoreSource(restore mdbv1.MongoDBCommunityRestore) (restoreSource, mdbv1.RestorePhase, string) {
+	sources := 0
+	for _, set := range []bool{restore.Spec.BackupRef != nil, restore.Spec.S3 != nil, restore.Spec.PointInTime != nil, restore.Spec.PBM != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return restoreSource{}, mdbv1.RestoreFailed, "Exactly one of spec.backupRef, spec.s3, spec.pointInTime and spec.pbm must be set"
+	}
+
+	if s3 := restore.Spec.S3; s3 != nil {
+		if message := validateEncryption(s3.Encryption, "spec.s3.encryption"); message != "" {
+			return restoreSource{}, mdbv1.RestoreFailed, message
+		}
+		return restoreSource{location: s3.Location, endpoint: s3.Endpoint, region: s3.Region, credentialsSecretRef: s3.CredentialsSecretRef.Name, encryption: s3.Encryption}, "", ""
+	}
+	if restore.Spec.PointInTime != nil {
+		return r.resolvePointInTime(restore)
+	}
+	if pbm := restore.Spec.PBM; pbm != nil {
+		if (pbm.BackupName == "") == (pbm.TargetTime == nil) {
+			return restoreSource{}, mdbv1.RestoreFailed, "Exactly one of spec.pbm.backupName and spec.pbm.targetTime must be set"
+		}
+		source := &pbmRestoreSource{backupName: pbm.BackupName}
+		if pbm.TargetTime != nil {
+			source.targetTime = pbm.TargetTime.Time
+		}
+		return restoreSource{pbm: source}, "", ""
+	}
+
+	backup := mdbv1.MongoDBCommunityBackup{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: restore.Spec.BackupRef.Name, Namespace: restore.Namespace}, &backup); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("MongoDBCommunityBackup resource %s does not exist", restore.Spec.BackupRef.Name)
+		}
+		return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("Could not get MongoDBCommunityBackup resource %s: %s", restore.Spec.BackupRef.Name, err)
+	}
+	if backup.GetMethod() == mdbv1.BackupMethodSnapshot {
+		return restoreSource{}, mdbv1.RestoreFailed, fmt.Sprintf("Backup %s is a VolumeSnapshot, which is restored by creating the data volumes from it", backup.Name)
+	}
+	if backup.GetMethod() == mdbv1.BackupMethodPBM && backup.Spec.PBM.GetType() != mdbv1.PBMBackupLogical {
+		return restoreSource{}, mdbv1.RestoreFailed, fmt.Sprintf("Backup %s is a %s PBM backup, which PBM restores by restarting mongod, and can not be restored by the operator", backup.Name, backup.Spec.PBM.GetType())
+	}
+	switch backup.Status.Phase {
+	case mdbv1.BackupCompleted:
+		if backup.GetMethod() == mdbv1.BackupMethodPBM {
+			if backup.Status.PBM == nil || backup.Status.PBM.Name == "" {
+				return restoreSource{}, mdbv1.RestoreFailed, fmt.Sprintf("The name of PBM backup %s is unknown", backup.Name)
+			}
+			return restoreSource{pbm: &pbmRestoreSource{backupName: backup.Status.PBM.Name}}, "", ""
+		}
+		source := restoreSource{location: backup.Status.Location, endpoint: backup.Spec.S3.Endpoint, region: backup.Spec.S3.Region, credentialsSecretRef: backup.Spec.S3.CredentialsSecretRef.Name}
+		if backup.Status.Encryption != nil {
+			source.encryption = backup.Status.Encryption.Encryption()
+		}
+		return source, "", ""
+	case mdbv1.BackupFailed:
+		return restoreSource{}, mdbv1.RestoreFailed, fmt.Sprintf("Backup %s failed", backup.Name)
+	default:
+		return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("Waiting for backup %s to complete", backup.Name)
+	}
+}
+
+// resolvePointInTime returns the archive of the latest backup of the schedule which completed before the target
+// time, and the range of the oplog replayed from the start of the backup until the target time. The restore is
+// pending until the oplog has been captured until the target time.
+func (r RestoreReconciler) resolvePointInTime(restore mdbv1.MongoDBCommunityRestore) (restoreSource, mdbv1.RestorePhase, string) {
+	pointInTime := restore.Spec.PointInTime
+	target := pointInTime.TargetTime.UTC().Format(time.RFC3339)
+	schedule := mdbv1.MongoDBCommunityBackupSchedule{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pointInTime.ScheduleRef.Name, Namespace: restore.Namespace}, &schedule); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("MongoDBCommunityBackupSchedule resource %s does not exist", pointInTime.ScheduleRef.Name)
+		}
+		return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("Could not get MongoDBCommunityBackupSchedule resource %s: %s", pointInTime.ScheduleRef.Name, err)
+	}
+	if schedule.Spec.Oplog == nil {
+		return restoreSource{}, mdbv1.RestoreFailed, fmt.Sprintf("Backup schedule %s does not capture the oplog", schedule.Name)
+	}
+	oplog := schedule.Status.Oplog
+	if oplog == nil || oplog.CapturedUntil == nil || oplog.CapturedUntil.Before(&pointInTime.TargetTime) {
+		return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("Waiting for the oplog to be captured until %s", target)
+	}
+
+	backups, err := scheduledBackups(r.client, schedule)
+	if err != nil {
+		return restoreSource{}, mdbv1.RestorePending, fmt.Sprintf("Could not list the backups of schedule %s: %s", schedule.Name, err)
+	}
+	var latest *mdbv1.MongoDBCommunityBackup
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Status.Phase != mdbv1.BackupCompleted || backup.GetMethod() != mdbv1.BackupMethodDump || backup.Status.StartTime == nil || backup.Status.CompletionTime == nil {
+			continue
+		}
+		// the archive is not consistent before the oplog written while it was dumped has been replayed
+		if backup.Status.StartTime.Before(oplog.CapturedSince) || pointInTime.TargetTime.Before(backup.Status.CompletionTime) {
+			continue
+		}
+		if latest == nil || latest.Status.StartTime.Before(backup.Status.StartTime) {
+			latest = backup
+		}
+	}
+	if latest == nil {
+		return restoreSource{}, mdbv1.RestoreFailed, fmt.Sprintf("No backup of schedule %s can be restored to %s", schedule.Name, target)
+	}
+
+	// the archive is decrypted with the key it was encrypted with, which the schedule may have replaced since. The
+	// oplog slices are decrypted with the same key, as they are encrypted with the key of the schedule too.
+	encryption := schedule.Spec.Template.Encryption
+	if latest.Status.Encryption != nil {
+		encryption = latest.Status.Encryption.Encryption()
+	}
+	return restoreSource{
+		location:             latest.Status.Location,
+		endpoint:             latest.Spec.S3.Endpoint,
+		region:               latest.Spec.S3.Region,
+		credentialsSecretRef: latest.Spec.S3.CredentialsSecretRef.Name,
+		encryption:           encryption,
+		oplog: &construct.OplogReplayOptions{
+			URL:   schedule.OplogLocation(),
+			From:  latest.Status.StartTime.Time,
+			Until: pointInTime.TargetTime.Time,
+		},
+	}, "", ""
+}
+
+// createMongoDBResource creates the MongoDBCommunity resource the archive is restored into.
+func (r RestoreReconciler) createMongoDBResource(restore mdbv1.MongoDBCommunityRestore) (reconcile.Result, error) {
+	mdb := mdbv1.MongoDBCommunity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Spec.MongoDBResourceRef.Name,
+			Namespace: restore.Namespace,
+		},
+		Spec: *restore.Spec.MongoDBResourceSpec,
+	}
+	if err := r.client.Create(context.TODO(), &mdb); err != nil && !apiErrors.IsAlreadyExists(err) {
+		return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Could not create MongoDBCommunity resource %s: %s", mdb.Name, err))
+	}
+	r.log.Infof("Created MongoDBCommunity resource %s", mdb.Name)
+	return r.updateRestoreStatus(restore, mdbv1.RestorePending, fmt.Sprintf("Waiting for MongoDBCommunity resource %s to be running", mdb.Name))
+}
+
+// observeRestoreJob reports the progress of the given restore Job, and resumes the reconciliation of the
+// MongoDBCommunity resource once the Job has finished.
+func (r RestoreReconciler) observeRestoreJob(restore mdbv1.MongoDBCommunityRestore, job batchv1.Job) (reconcile.Result, error) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue || (c.Type != batchv1.JobComplete && c.Type != batchv1.JobFailed) {
+			continue
+		}
+		if err := r.clearRestoreInProgress(restore); err != nil {
+			r.log.Errorf("Could not resume the reconciliation of MongoDBCommunity resource %s: %s", restore.Spec.MongoDBResourceRef.Name, err)
+			return result.Failed()
+		}
+		if c.Type == batchv1.JobFailed {
+			r.log.Warnf("Restore Job %s failed: %s", job.Name, c.Message)
+			return r.updateRestoreStatus(restore, mdbv1.RestoreFailed, fmt.Sprintf("Job %s failed: %s", job.Name, c.Message))
+		}
+		r.log.Infof("Restored %s", restore.Status.Location)
+		completionTime := c.LastTransitionTime
+		restore.Status.CompletionTime = &completionTime
+		return r.updateRestoreStatus(restore, mdbv1.RestoreCompleted, "")
+	}
+	return result.Retry(backupPollInterval)
+}
+
+// setRestoreInProgress annotates the MongoDBCommunity resource so that it is not reconciled during the restore.
+func (r RestoreReconciler) setRestoreInProgress(mdb mdbv1.MongoDBCommunity, restoreName string) error {
+	if mdb.Annotations[mdbv1.RestoreInProgressAnnotation] == restoreName {
+		return nil
+	}
+	if mdb.Annotations == nil {
+		mdb.Annotations = map[string]string{}
+	}
+	mdb.Annotations[mdbv1.RestoreInProgressAnnotation] = restoreName
+	return r.client.Update(context.TODO(), &mdb)
+}
+
+// clearRestoreInProgress removes the annotation of the restore from the MongoDBCommunity resource, if the
+// resource is still annotated with it.
+func (r RestoreReconciler) clearRestoreInProgress(restore mdbv1.MongoDBCommunityRestore) error {
+	mdb := mdbv1.MongoDBCommunity{}
+	if err := r.client.Get(context.TODO(), restore.MongoDBResourceNamespacedName(), &mdb); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if mdb.Annotations[mdbv1.RestoreInProgressAnnotation] != restore.Name {
+		return nil
+	}
+	delete(mdb.Annotations, mdbv1.RestoreInProgressAnnotation)
+	return r.client.Update(context.TODO(), &mdb)
+}
+
+// updateRestoreStatus updates the phase and the message of the restore. Pending restores are reconciled again later.
+func (r RestoreReconciler) updateRestoreStatus(restore mdbv1.MongoDBCommunityRestore, phase mdbv1.RestorePhase, message string) (reconcile.Result, error) {
+	restore.Status.Phase = phase
+	restore.Status.Message = message
+	if err := r.client.Status().Update(context.TODO(), &restore); err != nil {
+		r.log.Errorf("Error updating the status of the MongoDBCommunityRestore resource: %s", err)
+		return reconcile.Result{}, err
+	}
+	if phase == mdbv1.RestorePending {
+		r.log.Infof("Restore is pending: %s", message)
+		return result.Retry(backupPollInterval)
+	}
+	return result.OK()
+}
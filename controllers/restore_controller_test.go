@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/controllers/construct"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/backup/encryption"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+)
+
+func newTestRestore() mdbv1.MongoDBCommunityRestore {
+	return mdbv1.MongoDBCommunityRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-restore",
+			Namespace: "my-ns",
+		},
+		Spec: mdbv1.MongoDBCommunityRestoreSpec{
+			MongoDBResourceRef: mdbv1.LocalObjectReference{Name: "my-rs"},
+			User:               "restore-user",
+			BackupRef:          &mdbv1.LocalObjectReference{Name: "my-backup"},
+		},
+	}
+}
+
+// newCompletedBackup returns a backup of the test resource whose archive has been uploaded.
+func newCompletedBackup() mdbv1.MongoDBCommunityBackup {
+	backup := newTestBackup()
+	backup.Status.Phase = mdbv1.BackupCompleted
+	backup.Status.Location = backup.ArchiveLocation()
+	return backup
+}
+
+// setupRestore returns a reconciler for the given restore, with the given resources, the connection string
+// Secret of the restore user and the credentials of the bucket.
+func setupRestore(t *testing.T, restore mdbv1.MongoDBCommunityRestore, objects ...k8sClient.Object) (*RestoreReconciler, *client.MockedManager) {
+	mgr := client.NewManager(&restore)
+	for _, obj := range objects {
+		assert.NoError(t, mgr.GetClient().Create(context.TODO(), obj))
+	}
+	user := mdbv1.MongoDBUser{Name: "restore-user", DB: "admin"}
+	for _, name := range []string{user.GetConnectionStringSecretName("my-rs"), "bucket-credentials"} {
+		s := secret.Builder().SetName(name).SetNamespace(restore.Namespace).SetField("key", "value").Build()
+		assert.NoError(t, mgr.Client.CreateSecret(s))
+	}
+	return NewRestoreReconciler(mgr), mgr
+}
+
+// newRestoreReplicaSet returns a running replica set with the restore user.
+func newRestoreReplicaSet() mdbv1.MongoDBCommunity {
+	mdb := newTestReplicaSet()
+	mdb.Spec.Users = []mdbv1.MongoDBUser{{Name: "restore-user", DB: "admin", PasswordSecretRef: mdbv1.SecretKeyReference{Name: "restore-user-password"}}}
+	mdb.Status.Phase = mdbv1.Running
+	return mdb
+}
+
+func reconcileRestore(t *testing.T, r *RestoreReconciler, mgr *client.MockedManager, restore mdbv1.MongoDBCommunityRestore) (reconcile.Result, mdbv1.MongoDBCommunityRestore) {
+	res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: restore.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), restore.NamespacedName(), &restore))
+	return res, restore
+}
+
+func TestRestore_RestoresTheArchiveOfABackup(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	backup := newCompletedBackup()
+	restore := newTestRestore()
+	restore.Spec.Drop = true
+	r, mgr := setupRestore(t, restore, &mdb, &backup)
+
+	res, restore := reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+	assert.Equal(t, backup.Status.Location, restore.Status.Location)
+	assert.NotNil(t, restore.Status.StartTime)
+	assert.Equal(t, time.Duration(backupPollInterval)*time.Second, res.RequeueAfter)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.Equal(t, "my-restore", mdb.Annotations[mdbv1.RestoreInProgressAnnotation])
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	assert.True(t, metav1.IsControlledBy(&job, &restore))
+	assert.Equal(t, string(mdbv1.RestoreJob), job.Labels[mdbv1.JobTypeLabel])
+
+	download := job.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(t, construct.RestoreDownloadContainerName, download.Name)
+	assert.Contains(t, download.Env, corev1.EnvVar{Name: "S3_URL", Value: backup.Status.Location})
+	assert.Contains(t, download.Env, corev1.EnvVar{Name: "S3_ENDPOINT", Value: backup.Spec.S3.Endpoint})
+
+	mongorestore := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, construct.RestoreContainerName, mongorestore.Name)
+	assert.Contains(t, mongorestore.Command[2], "mongorestore")
+	assert.Contains(t, mongorestore.Env, corev1.EnvVar{Name: "DROP_OPTION", Value: "--drop"})
+
+	t.Run("The resource is not reconciled during the restore", func(t *testing.T) {
+		res, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+		assert.NoError(t, err)
+		assert.True(t, res.RequeueAfter > 0)
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.Equal(t, mdbv1.Pending, mdb.Status.Phase)
+		assert.Equal(t, "Reconciliation is paused while restore my-restore is in progress", mdb.Status.Message)
+	})
+
+	t.Run("The reconciliation resumes once the restore has completed", func(t *testing.T) {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+		assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+		res, restore := reconcileRestore(t, r, mgr, restore)
+		assert.Equal(t, mdbv1.RestoreCompleted, restore.Status.Phase)
+		assert.NotNil(t, restore.Status.CompletionTime)
+		assert.Equal(t, reconcile.Result{}, res)
+
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+		assert.NotContains(t, mdb.Annotations, mdbv1.RestoreInProgressAnnotation)
+	})
+}
+
+func TestRestore_FailedJob(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	backup := newCompletedBackup()
+	restore := newTestRestore()
+	r, mgr := setupRestore(t, restore, &mdb, &backup)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"}}
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &job))
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreFailed, restore.Status.Phase)
+	assert.Equal(t, "Job my-restore-restore failed: Job has reached the specified backoff limit", restore.Status.Message)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotContains(t, mdb.Annotations, mdbv1.RestoreInProgressAnnotation)
+}
+
+func TestRestore_FromS3Location(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	restore := newTestRestore()
+	restore.Spec.BackupRef = nil
+	restore.Spec.S3 = &mdbv1.S3RestoreSource{
+		Location:             "s3://my-bucket/archives/my-rs.archive.gz",
+		Region:               "eu-west-1",
+		CredentialsSecretRef: mdbv1.LocalObjectReference{Name: "bucket-credentials"},
+	}
+	r, mgr := setupRestore(t, restore, &mdb)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+	assert.Equal(t, "s3://my-bucket/archives/my-rs.archive.gz", restore.Status.Location)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	assert.Contains(t, job.Spec.Template.Spec.InitContainers[0].Env, corev1.EnvVar{Name: "S3_URL", Value: restore.Status.Location})
+}
+
+func TestRestore_CreatesTheResource(t *testing.T) {
+	backup := newCompletedBackup()
+	restore := newTestRestore()
+	spec := newRestoreReplicaSet().Spec
+	restore.Spec.MongoDBResourceSpec = &spec
+	r, mgr := setupRestore(t, restore, &backup)
+
+	res, restore := reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestorePending, restore.Status.Phase)
+	assert.Equal(t, "Waiting for MongoDBCommunity resource my-rs to be running", restore.Status.Message)
+	assert.True(t, res.RequeueAfter > 0)
+
+	mdb := mdbv1.MongoDBCommunity{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), restore.MongoDBResourceNamespacedName(), &mdb))
+	assert.Equal(t, spec, mdb.Spec)
+	assert.Empty(t, mdb.OwnerReferences, "the resource is kept when the restore is deleted")
+}
+
+func TestRestore_IsPending(t *testing.T) {
+	t.Run("Until the backup has completed", func(t *testing.T) {
+		mdb := newRestoreReplicaSet()
+		backup := newTestBackup()
+		backup.Status.Phase = mdbv1.BackupDumping
+		r, mgr := setupRestore(t, newTestRestore(), &mdb, &backup)
+
+		_, restore := reconcileRestore(t, r, mgr, newTestRestore())
+		assert.Equal(t, mdbv1.RestorePending, restore.Status.Phase)
+		assert.Equal(t, "Waiting for backup my-backup to complete", restore.Status.Message)
+	})
+
+	t.Run("Until the resource is running", func(t *testing.T) {
+		mdb := newRestoreReplicaSet()
+		mdb.Status.Phase = mdbv1.Pending
+		backup := newCompletedBackup()
+		r, mgr := setupRestore(t, newTestRestore(), &mdb, &backup)
+
+		_, restore := reconcileRestore(t, r, mgr, newTestRestore())
+		assert.Equal(t, mdbv1.RestorePending, restore.Status.Phase)
+		assert.Equal(t, "Waiting for MongoDBCommunity resource my-rs to be running", restore.Status.Message)
+	})
+
+	t.Run("While another restore is in progress", func(t *testing.T) {
+		mdb := newRestoreReplicaSet()
+		mdb.Annotations = map[string]string{mdbv1.RestoreInProgressAnnotation: "other-restore"}
+		backup := newCompletedBackup()
+		r, mgr := setupRestore(t, newTestRestore(), &mdb, &backup)
+
+		_, restore := reconcileRestore(t, r, mgr, newTestRestore())
+		assert.Equal(t, mdbv1.RestorePending, restore.Status.Phase)
+		assert.Equal(t, "Waiting for restore other-restore of MongoDBCommunity resource my-rs to finish", restore.Status.Message)
+	})
+}
+
+func TestRestore_InvalidSource(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	restore := newTestRestore()
+	restore.Spec.S3 = &mdbv1.S3RestoreSource{Location: "s3://my-bucket/my-rs.archive.gz"}
+	r, mgr := setupRestore(t, restore, &mdb)
+
+	res, restore := reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreFailed, restore.Status.Phase)
+	assert.Equal(t, "Exactly one of spec.backupRef, spec.s3, spec.pointInTime and spec.pbm must be set", restore.Status.Message)
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func TestReplicaSet_RemovesStaleRestoreAnnotation(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Annotations = map[string]string{mdbv1.RestoreInProgressAnnotation: "deleted-restore"}
+	mgr := client.NewManager(&mdb)
+
+	_, err := NewReconciler(mgr).Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotContains(t, mdb.Annotations, mdbv1.RestoreInProgressAnnotation)
+	assert.NotEqual(t, "Reconciliation is paused while restore deleted-restore is in progress", mdb.Status.Message)
+}
+
+func TestReplicaSet_RemovingTheRestoreAnnotationKeepsTheSpec(t *testing.T) {
+	mdb := newTestReplicaSet()
+	mdb.Spec.MaintenanceWindow = closedMaintenanceWindow()
+	mgr := client.NewManager(&mdb)
+	createPodsInZones(t, mgr.GetClient(), mdb, "zone-a", "zone-b", "zone-c")
+	r := NewReconciler(mgr)
+	reconcileWithAgentsInGoalState(t, r, mgr, mdb)
+
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	mdb.Spec.Version = "4.4.8"
+	mdb.Annotations[mdbv1.RestoreInProgressAnnotation] = "deleted-restore"
+	assert.NoError(t, mgr.GetClient().Update(context.TODO(), &mdb))
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: mdb.NamespacedName()})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), mdb.NamespacedName(), &mdb))
+	assert.NotContains(t, mdb.Annotations, mdbv1.RestoreInProgressAnnotation)
+	assert.Equal(t, "4.4.8", mdb.Spec.Version, "the version held back by the maintenance window is not written back")
+}
+
+func TestRestore_ToPointInTime(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	at := func(minutes int) *metav1.Time {
+		t := metav1.NewTime(now.Add(time.Duration(minutes) * time.Minute))
+		return &t
+	}
+	schedule := newTestBackupSchedule(now.Add(-2 * time.Hour))
+	schedule.Spec.Oplog = &mdbv1.OplogCapture{Interval: metav1.Duration{Duration: 10 * time.Minute}}
+	schedule.Status.Oplog = &mdbv1.OplogCaptureStatus{CapturedSince: at(-100), CapturedUntil: at(-10)}
+	var backups []mdbv1.MongoDBCommunityBackup
+	for _, start := range []int{-110, -90, -60, -30} {
+		backup := newScheduledBackup(schedule, at(start).Time, mdbv1.BackupCompleted)
+		backup.Status.StartTime = at(start)
+		backup.Status.CompletionTime = at(start + 5)
+		backups = append(backups, backup)
+	}
+	mdb := newRestoreReplicaSet()
+
+	newPointInTimeRestore := func(target *metav1.Time) mdbv1.MongoDBCommunityRestore {
+		restore := newTestRestore()
+		restore.Spec.BackupRef = nil
+		restore.Spec.PointInTime = &mdbv1.PointInTimeSource{ScheduleRef: mdbv1.LocalObjectReference{Name: schedule.Name}, TargetTime: *target}
+		return restore
+	}
+	setup := func(restore mdbv1.MongoDBCommunityRestore) (*RestoreReconciler, *client.MockedManager) {
+		objects := []k8sClient.Object{mdb.DeepCopy(), schedule.DeepCopy()}
+		for i := range backups {
+			objects = append(objects, backups[i].DeepCopy())
+		}
+		return setupRestore(t, restore, objects...)
+	}
+
+	t.Run("The latest backup completed before the target time is restored", func(t *testing.T) {
+		restore := newPointInTimeRestore(at(-40))
+		r, mgr := setup(restore)
+
+		_, restore = reconcileRestore(t, r, mgr, restore)
+		assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+		assert.Equal(t, backups[2].Status.Location, restore.Status.Location)
+		assert.Equal(t, schedule.OplogLocation(), restore.Status.OplogLocation)
+
+		job := batchv1.Job{}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+		mongorestore := job.Spec.Template.Spec.Containers[0]
+		assert.Contains(t, mongorestore.Command[2], "--oplogReplay")
+		assert.Contains(t, mongorestore.Env, corev1.EnvVar{Name: "OPLOG_FROM", Value: fmt.Sprint(at(-60).Unix())})
+		assert.Contains(t, mongorestore.Env, corev1.EnvVar{Name: "OPLOG_UNTIL", Value: fmt.Sprint(at(-40).Unix())})
+		assert.Contains(t, job.Spec.Template.Spec.InitContainers[0].Env, corev1.EnvVar{Name: "OPLOG_URL", Value: schedule.OplogLocation()})
+	})
+
+	t.Run("The archive is decrypted with the key recorded in the backup", func(t *testing.T) {
+		rotated := schedule.DeepCopy()
+		rotated.Spec.Template.Encryption = &mdbv1.BackupEncryption{KeySecretRef: &mdbv1.SecretKeyReference{Name: "new-key", Key: "aes"}}
+		encrypted := backups[2].DeepCopy()
+		encrypted.Status.Encryption = &mdbv1.BackupEncryptionStatus{
+			Algorithm:    encryption.Algorithm,
+			KeySecretRef: &mdbv1.SecretKeyReference{Name: "old-key", Key: "aes"},
+		}
+		restore := newPointInTimeRestore(at(-40))
+		r, mgr := setupRestore(t, restore, mdb.DeepCopy(), rotated, encrypted)
+		s := secret.Builder().SetName("old-key").SetNamespace(restore.Namespace).SetField("aes", strings.Repeat("k", 32)).Build()
+		assert.NoError(t, mgr.Client.CreateSecret(s))
+
+		_, restore = reconcileRestore(t, r, mgr, restore)
+		assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+		job := batchv1.Job{}
+		assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+		for _, volume := range job.Spec.Template.Spec.Volumes {
+			if volume.Name == "encryption-key" {
+				assert.Equal(t, "old-key", volume.Secret.SecretName)
+			}
+		}
+	})
+
+	t.Run("Pending until the oplog has been captured until the target time", func(t *testing.T) {
+		restore := newPointInTimeRestore(at(-5))
+		r, mgr := setup(restore)
+
+		_, restore = reconcileRestore(t, r, mgr, restore)
+		assert.Equal(t, mdbv1.RestorePending, restore.Status.Phase)
+		assert.Contains(t, restore.Status.Message, "Waiting for the oplog to be captured until")
+	})
+
+	t.Run("Backups started before the oplog was captured can not be restored", func(t *testing.T) {
+		restore := newPointInTimeRestore(at(-95))
+		r, mgr := setup(restore)
+
+		_, restore = reconcileRestore(t, r, mgr, restore)
+		assert.Equal(t, mdbv1.RestoreFailed, restore.Status.Phase)
+		assert.Contains(t, restore.Status.Message, "No backup of schedule my-schedule can be restored to")
+	})
+}
+
+func TestRestore_DecryptsTheArchiveOfAnEncryptedBackup(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	backup := newCompletedBackup()
+	backup.Status.Encryption = &mdbv1.BackupEncryptionStatus{
+		Algorithm:    encryption.Algorithm,
+		KeySecretRef: &mdbv1.SecretKeyReference{Name: "backup-key", Key: "aes"},
+	}
+	restore := newTestRestore()
+	r, mgr := setupRestore(t, restore, &mdb, &backup)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestorePending, restore.Status.Phase)
+	assert.Equal(t, "Waiting for Secret backup-key to exist", restore.Status.Message)
+
+	s := secret.Builder().SetName("backup-key").SetNamespace(restore.Namespace).SetField("aes", strings.Repeat("k", 32)).Build()
+	assert.NoError(t, mgr.Client.CreateSecret(s))
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	assert.Equal(t, construct.BackupCryptContainerName, job.Spec.Template.Spec.InitContainers[0].Name, "the backup-crypt binary is copied before the archive is downloaded")
+	download, _ := findContainer(job, construct.RestoreDownloadContainerName)
+	assert.Contains(t, download.Command[2], "decrypt /restore/archive.gz")
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		if volume.Name == "encryption-key" {
+			assert.Equal(t, "backup-key", volume.Secret.SecretName)
+			assert.Equal(t, []corev1.KeyToPath{{Key: "aes", Path: "key"}}, volume.Secret.Items)
+		}
+	}
+}
+
+func TestRestore_DecryptsTheArchiveWithAKMSDataKey(t *testing.T) {
+	mdb := newRestoreReplicaSet()
+	restore := newTestRestore()
+	restore.Spec.BackupRef = nil
+	restore.Spec.S3 = &mdbv1.S3RestoreSource{
+		Location:             "s3://my-bucket/archives/my-rs.archive.gz",
+		Region:               "eu-west-1",
+		CredentialsSecretRef: mdbv1.LocalObjectReference{Name: "bucket-credentials"},
+		Encryption:           &mdbv1.BackupEncryption{KMS: &mdbv1.KMSEncryption{Provider: mdbv1.KMSProviderAWS, KeyID: "alias/backups"}},
+	}
+	r, mgr := setupRestore(t, restore, &mdb)
+
+	_, restore = reconcileRestore(t, r, mgr, restore)
+	assert.Equal(t, mdbv1.RestoreRestoring, restore.Status.Phase)
+
+	job := batchv1.Job{}
+	assert.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: restore.JobName(), Namespace: restore.Namespace}, &job))
+	download, _ := findContainer(job, construct.RestoreDownloadContainerName)
+	assert.Contains(t, download.Command[2], "aws kms decrypt")
+	assert.Contains(t, download.Env, corev1.EnvVar{Name: "KMS_PROVIDER", Value: "AWS"})
+	assert.Contains(t, download.Env, corev1.EnvVar{Name: "KMS_REGION", Value: "eu-west-1"})
+}
@@ -0,0 +1,105 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// availability is the range of server versions which accept a mongod option.
+type availability struct {
+	// introduced is the first version accepting the option, empty if all supported versions accept it
+	introduced string
+	// removed is the first version which no longer accepts the option, empty if it has not been removed
+	removed string
+}
+
+// mongodOptionCatalog lists the mongod configuration options and server parameters which are only accepted by
+// some server versions, and which prevent mongod from starting on the other versions. Options below an entry
+// share its availability. Options which are not listed are not checked.
+var mongodOptionCatalog = map[string]availability{
+	"net.serviceExecutor":            {removed: "5.0.0"},
+	"net.tls":                        {introduced: "4.2.0"},
+	"operationProfiling.filter":      {introduced: "4.4.2"},
+	"storage.journal.enabled":        {removed: "6.1.0"},
+	"storage.mmapv1":                 {removed: "4.2.0"},
+	"storage.oplogMinRetentionHours": {introduced: "4.4.0"},
+	"storage.wiredTiger.engineConfig.zstdCompressionLevel": {introduced: "5.0.0"},
+
+	"setParameter.disableJavaScriptJIT":                         {introduced: "4.0.0"},
+	"setParameter.enableFlowControl":                            {introduced: "4.2.0"},
+	"setParameter.failIndexKeyTooLong":                          {removed: "4.4.0"},
+	"setParameter.flowControlTargetLagSeconds":                  {introduced: "4.2.0"},
+	"setParameter.internalQueryExecMaxBlockingSortBytes":        {removed: "4.4.0"},
+	"setParameter.internalQueryMaxBlockingSortMemoryUsageBytes": {introduced: "4.4.0"},
+	"setParameter.maxTransactionLockRequestTimeoutMillis":       {introduced: "4.0.0"},
+	"setParameter.minSnapshotHistoryWindowInSeconds":            {introduced: "4.4.0"},
+	"setParameter.replWriterMinThreadCount":                     {introduced: "4.4.0"},
+	"setParameter.tlsOCSPEnabled":                               {introduced: "4.4.0"},
+	"setParameter.transactionLifetimeLimitSeconds":              {introduced: "4.0.0"},
+}
+
+// ValidateMongodOptions returns an error listing the options of additionalMongodConfig and the server parameters
+// which the given server version does not accept according to the catalog. An unparseable version is not checked.
+func ValidateMongodOptions(version string, additionalMongodConfig, serverParameters map[string]interface{}) error {
+	v, err := semver.Make(version)
+	if err != nil {
+		return nil
+	}
+	// pre-release and build suffixes, such as -ent, do not change which options are accepted
+	v = semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+
+	options := map[string]string{}
+	for _, option := range flattenOptions(additionalMongodConfig, "") {
+		options[option] = "additionalMongodConfig." + option
+	}
+	for name := range serverParameters {
+		options["setParameter."+name] = "serverParameters." + name
+	}
+
+	var incompatible []string
+	for option, field := range options {
+		if reason := unavailableReason(option, v); reason != "" {
+			incompatible = append(incompatible, fmt.Sprintf("%s (%s)", field, reason))
+		}
+	}
+	if len(incompatible) == 0 {
+		return nil
+	}
+	sort.Strings(incompatible)
+	return errors.Errorf("MongoDB %s does not accept %s", version, strings.Join(incompatible, ", "))
+}
+
+// unavailableReason returns why the given version does not accept the option, empty if it does.
+func unavailableReason(option string, v semver.Version) string {
+	for prefix, a := range mongodOptionCatalog {
+		if option != prefix && !strings.HasPrefix(option, prefix+".") {
+			continue
+		}
+		if a.introduced != "" && v.LT(semver.MustParse(a.introduced)) {
+			return "introduced in " + a.introduced
+		}
+		if a.removed != "" && v.GTE(semver.MustParse(a.removed)) {
+			return "removed in " + a.removed
+		}
+	}
+	return ""
+}
+
+// flattenOptions returns the dotted paths of the values of the given configuration, which may be nested or
+// already use dotted keys.
+func flattenOptions(config map[string]interface{}, prefix string) []string {
+	var options []string
+	for key, value := range config {
+		path := prefix + key
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			options = append(options, flattenOptions(nested, path+".")...)
+			continue
+		}
+		options = append(options, path)
+	}
+	return options
+}
@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMongodOptions(t *testing.T) {
+	t.Run("Options accepted by the version", func(t *testing.T) {
+		config := map[string]interface{}{
+			"net":          map[string]interface{}{"tls": map[string]interface{}{"mode": "requireTLS"}},
+			"setParameter": map[string]interface{}{"enableFlowControl": true},
+		}
+		assert.NoError(t, ValidateMongodOptions("4.4.6", config, map[string]interface{}{"transactionLifetimeLimitSeconds": 60}))
+	})
+	t.Run("Options which are not in the catalog are not checked", func(t *testing.T) {
+		assert.NoError(t, ValidateMongodOptions("4.0.20", map[string]interface{}{"net.maxIncomingConnections": 100}, map[string]interface{}{"someParameter": 1}))
+	})
+	t.Run("Removed options", func(t *testing.T) {
+		config := map[string]interface{}{
+			"storage":      map[string]interface{}{"journal": map[string]interface{}{"enabled": true}},
+			"setParameter": map[string]interface{}{"failIndexKeyTooLong": false},
+		}
+		err := ValidateMongodOptions("6.1.0", config, map[string]interface{}{"internalQueryExecMaxBlockingSortBytes": 1024})
+		if assert.Error(t, err) {
+			assert.Equal(t, "MongoDB 6.1.0 does not accept "+
+				"additionalMongodConfig.setParameter.failIndexKeyTooLong (removed in 4.4.0), "+
+				"additionalMongodConfig.storage.journal.enabled (removed in 6.1.0), "+
+				"serverParameters.internalQueryExecMaxBlockingSortBytes (removed in 4.4.0)", err.Error())
+		}
+	})
+	t.Run("Options introduced in a later version", func(t *testing.T) {
+		err := ValidateMongodOptions("4.2.8", map[string]interface{}{"storage.oplogMinRetentionHours": 24}, map[string]interface{}{"enableFlowControl": true})
+		if assert.Error(t, err) {
+			assert.Equal(t, "MongoDB 4.2.8 does not accept additionalMongodConfig.storage.oplogMinRetentionHours (introduced in 4.4.0)", err.Error())
+		}
+		assert.Error(t, ValidateMongodOptions("4.4.1", map[string]interface{}{"operationProfiling": map[string]interface{}{"filter": "{}"}}, nil))
+		assert.NoError(t, ValidateMongodOptions("4.4.2", map[string]interface{}{"operationProfiling": map[string]interface{}{"filter": "{}"}}, nil))
+	})
+	t.Run("Suffixes of the version are ignored", func(t *testing.T) {
+		assert.Error(t, ValidateMongodOptions("4.4.0-ent", nil, map[string]interface{}{"failIndexKeyTooLong": false}))
+	})
+	t.Run("Unparseable versions are not checked", func(t *testing.T) {
+		assert.NoError(t, ValidateMongodOptions("latest", nil, map[string]interface{}{"failIndexKeyTooLong": false}))
+	})
+}
@@ -0,0 +1,152 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+)
+
+// deprecatedField is a field of the spec which is scheduled for removal.
+type deprecatedField struct {
+	// path of the field below the spec, e.g. "security.tls.optional"
+	path []string
+	// replacement describes what to use instead of the field
+	replacement string
+}
+
+// deprecatedFields are reported in strict mode if they are set to a value other than their zero value.
+var deprecatedFields = []deprecatedField{
+	{path: []string{"security", "tls", "optional"}, replacement: "set spec.security.tls.mode to preferTLS instead"},
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// typeOverrides maps types decoding their fields themselves to the type their fields are decoded into.
+var typeOverrides = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(mdbv1.StatefulSetSpecWrapper{}): reflect.TypeOf(appsv1.StatefulSetSpec{}),
+}
+
+// ValidateStrict returns an error if the given spec, as stored in the API server, contains fields which are not
+// part of the MongoDBCommunity spec, such as misspelled fields which would otherwise be ignored, or deprecated
+// fields which are scheduled for removal.
+func ValidateStrict(spec map[string]interface{}) error {
+	var problems []string
+	if unknown := UnknownFields(spec, reflect.TypeOf(mdbv1.MongoDBCommunitySpec{}), "spec"); len(unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown fields %s", strings.Join(unknown, ", ")))
+	}
+	for _, field := range deprecatedFields {
+		if value, ok := lookup(spec, field.path); ok && !isZero(value) {
+			problems = append(problems, fmt.Sprintf("deprecated field spec.%s is set, %s", strings.Join(field.path, "."), field.replacement))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// UnknownFields returns the sorted paths of the fields of the given object which are not fields of the given
+// type according to their JSON names. The fields of types which decode themselves are not checked.
+func UnknownFields(value interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if override, ok := typeOverrides[t]; ok {
+		t = override
+	} else if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for name, fieldValue := range object {
+			fieldType, ok := fields[name]
+			if !ok {
+				unknown = append(unknown, path+"."+name)
+				continue
+			}
+			unknown = append(unknown, UnknownFields(fieldValue, fieldType, path+"."+name)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			unknown = append(unknown, UnknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, item := range object {
+			unknown = append(unknown, UnknownFields(item, t.Elem(), fmt.Sprintf("%s[%s]", path, key))...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// jsonFields returns the types of the fields of the given struct by their JSON names, including the fields
+// of embedded and inlined structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" && (field.Anonymous || strings.Contains(tag, "inline")) {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFields(embedded) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lookup returns the value at the given path of the object.
+func lookup(object map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = object
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func isZero(value interface{}) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
+}
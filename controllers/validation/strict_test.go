@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStrict(t *testing.T) {
+	t.Run("Known fields are accepted", func(t *testing.T) {
+		spec := map[string]interface{}{
+			"members": int64(3),
+			"version": "4.4.0",
+			"security": map[string]interface{}{
+				"tls": map[string]interface{}{"enabled": true, "optional": false, "caConfigMapRef": map[string]interface{}{"name": "ca"}},
+			},
+			"users": []interface{}{
+				map[string]interface{}{"name": "my-user", "db": "admin", "roles": []interface{}{map[string]interface{}{"name": "root", "db": "admin"}}},
+			},
+			"additionalMongodConfig": map[string]interface{}{"net.maxIncomingConnections": int64(100)},
+			"statefulSet": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "a"}},
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{"name": "mongod", "resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}}},
+							},
+						},
+					},
+				},
+			},
+		}
+		assert.NoError(t, ValidateStrict(spec))
+	})
+
+	t.Run("Unknown fields are rejected with their paths", func(t *testing.T) {
+		spec := map[string]interface{}{
+			"member": int64(3),
+			"users": []interface{}{
+				map[string]interface{}{"name": "my-user", "passwordSecret": map[string]interface{}{"name": "p"}},
+			},
+			"statefulSet": map[string]interface{}{
+				"spec": map[string]interface{}{"replica": int64(3)},
+			},
+		}
+		err := ValidateStrict(spec)
+		assert.EqualError(t, err, "unknown fields spec.member, spec.statefulSet.spec.replica, spec.users[0].passwordSecret")
+	})
+
+	t.Run("Deprecated fields are rejected if they are set", func(t *testing.T) {
+		spec := map[string]interface{}{
+			"security": map[string]interface{}{"tls": map[string]interface{}{"enabled": true, "optional": true}},
+		}
+		err := ValidateStrict(spec)
+		assert.EqualError(t, err, "deprecated field spec.security.tls.optional is set, set spec.security.tls.mode to preferTLS instead")
+	})
+}
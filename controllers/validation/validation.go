@@ -10,5 +10,24 @@ func Validate(oldSpec, newSpec mdbv1.MongoDBCommunitySpec) error {
 		return errors.New("TLS can't be set to disabled after it has been enabled")
 	}
 
+	if oldSpec.ReplicaSetName != newSpec.ReplicaSetName && newSpec.ReplicaSetNameChangePolicy != mdbv1.ReplicaSetNameChangeRecreateRetainingData {
+		return errors.Errorf("the replica set name can't be changed from %q to %q in place. "+
+			"Revert spec.replicaSetName, or set spec.replicaSetNameChangePolicy to %s to recreate the replica set "+
+			"under the new name while retaining its data, which requires downtime",
+			oldSpec.ReplicaSetName, newSpec.ReplicaSetName, mdbv1.ReplicaSetNameChangeRecreateRetainingData)
+	}
+
+	if oldSpec.PodSubdomain != newSpec.PodSubdomain {
+		return errors.Errorf("the Pod subdomain can't be changed from %q to %q", oldSpec.PodSubdomain, newSpec.PodSubdomain)
+	}
+
+	if oldSpec.PodHostnamePrefix != newSpec.PodHostnamePrefix {
+		return errors.Errorf("the Pod hostname prefix can't be changed from %q to %q", oldSpec.PodHostnamePrefix, newSpec.PodHostnamePrefix)
+	}
+
+	if oldSpec.MemberOrdinalStart != newSpec.MemberOrdinalStart {
+		return errors.Errorf("the member ordinal start can't be changed from %d to %d", oldSpec.MemberOrdinalStart, newSpec.MemberOrdinalStart)
+	}
+
 	return nil
 }
@@ -1,6 +1,8 @@
 package watch
 
 import (
+	"sync"
+
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/contains"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -14,17 +16,23 @@ import (
 // If multiple types should be watched, one ResourceWatcher for each type should be used.
 type ResourceWatcher struct {
 	watched map[types.NamespacedName][]types.NamespacedName
+	// lock guards watched, which is modified during reconciliation while events are being handled.
+	lock *sync.RWMutex
 }
 
 // New will create a new ResourceWatcher with no watched objects.
 func New() ResourceWatcher {
 	return ResourceWatcher{
 		watched: make(map[types.NamespacedName][]types.NamespacedName),
+		lock:    &sync.RWMutex{},
 	}
 }
 
 // Watch will add a new object to watch.
 func (w ResourceWatcher) Watch(watchedName, dependentName types.NamespacedName) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
 	existing, hasExisting := w.watched[watchedName]
 	if !hasExisting {
 		existing = []types.NamespacedName{}
@@ -63,6 +71,9 @@ func (w ResourceWatcher) handleEvent(meta metav1.Object, queue workqueue.RateLim
 		Namespace: meta.GetNamespace(),
 	}
 
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
 	// Enqueue reconciliation for each dependent object.
 	for _, reconciledObjectName := range w.watched[changedObjectName] {
 		queue.Add(reconcile.Request{
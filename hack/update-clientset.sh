@@ -0,0 +1,46 @@
+#!/usr/bin/env bash
+
+# Generates the typed clientset in pkg/client/clientset from the types in api/v1.
+#
+# client-gen expects the types of each API group in a "<group>/<version>" directory and
+# reads the group name from a doc.go file, so the types are copied to a temporary package
+# with this layout and the import path is rewritten in the generated code.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+CLIENT_GEN=${CLIENT_GEN:-client-gen}
+ROOT_DIR=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+MODULE=github.com/mongodb/mongodb-kubernetes-operator
+OUTPUT_PACKAGE="${MODULE}/pkg/client/clientset"
+
+TMP_API_DIR="${ROOT_DIR}/hack/clientset-input"
+TMP_OUTPUT_DIR=$(mktemp -d)
+cleanup() {
+  rm -rf "${TMP_API_DIR}" "${TMP_OUTPUT_DIR}"
+}
+trap cleanup EXIT
+
+mkdir -p "${TMP_API_DIR}/mongodbcommunity"
+cp -r "${ROOT_DIR}/api/v1" "${TMP_API_DIR}/mongodbcommunity/v1"
+rm -f "${TMP_API_DIR}"/mongodbcommunity/v1/*_test.go
+cat > "${TMP_API_DIR}/mongodbcommunity/v1/doc.go" <<DOC
+// +groupName=mongodbcommunity.mongodb.com
+package v1
+DOC
+
+"${CLIENT_GEN}" \
+  --clientset-name versioned \
+  --input-base "${MODULE}/hack/clientset-input" \
+  --input mongodbcommunity/v1 \
+  --output-base "${TMP_OUTPUT_DIR}" \
+  --output-package "${OUTPUT_PACKAGE}" \
+  --go-header-file "${ROOT_DIR}/hack/boilerplate.go.txt"
+
+rm -rf "${ROOT_DIR}/pkg/client/clientset"
+mkdir -p "${ROOT_DIR}/pkg/client"
+cp -r "${TMP_OUTPUT_DIR}/${OUTPUT_PACKAGE}" "${ROOT_DIR}/pkg/client/clientset"
+grep -rl "${MODULE}/hack/clientset-input/mongodbcommunity/v1" "${ROOT_DIR}/pkg/client/clientset" |
+  xargs sed -i.bak "s|${MODULE}/hack/clientset-input/mongodbcommunity/v1|${MODULE}/api/v1|g"
+find "${ROOT_DIR}/pkg/client/clientset" -name '*.bak' -delete
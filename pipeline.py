@@ -13,6 +13,7 @@ VALID_IMAGE_NAMES = frozenset(
         "agent-ubuntu",
         "readiness-probe-init",
         "version-post-start-hook-init",
+        "backup-crypt-init",
         "operator-ubi",
         "e2e",
     ]
@@ -92,6 +93,20 @@ def build_version_post_start_hook_image(config: DevConfig) -> None:
     )
 
 
+def build_backup_crypt_image(config: DevConfig) -> None:
+    release = _load_release()
+    config.ensure_tag_is_run("backup-crypt")
+
+    sonar_build_image(
+        "backup-crypt-init",
+        config,
+        args={
+            "registry": config.repo_url,
+            "release_version": release["backup-crypt"],
+        },
+    )
+
+
 def build_operator_ubi_image(config: DevConfig) -> None:
     config.ensure_tag_is_run("ubi")
     sonar_build_image(
@@ -167,6 +182,7 @@ def main() -> int:
         "agent-ubuntu": build_agent_image_ubuntu,
         "readiness-probe-init": build_readiness_probe_image,
         "version-post-start-hook-init": build_version_post_start_hook_image,
+        "backup-crypt-init": build_backup_crypt_image,
         "operator-ubi": build_operator_ubi_image,
         "e2e": build_e2e_image,
     }[image_name]
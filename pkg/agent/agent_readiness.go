@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/pod"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
 	"github.com/spf13/cast"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -12,6 +13,9 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// LoggerName is the name of the logger of this package, which can be given its own log level.
+const LoggerName = "agent"
+
 const (
 	// podAnnotationAgentVersion is the Pod Annotation key which contains the current version of the Automation Config
 	// the Agent on the Pod is on now
@@ -21,6 +25,7 @@ const (
 // AllReachedGoalState returns whether or not the agents associated with a given StatefulSet have reached goal state.
 // it achieves this by reading the Pod annotations and checking to see if they have reached the expected config versions.
 func AllReachedGoalState(sts appsv1.StatefulSet, podGetter pod.Getter, desiredMemberCount, targetConfigVersion int, log *zap.SugaredLogger) (bool, error) {
+	log = log.Named(LoggerName)
 	var podsNotFound []string
 
 	for _, podName := range statefulSetPodNames(sts, desiredMemberCount) {
@@ -68,12 +73,27 @@ func ReachedGoalState(pod corev1.Pod, targetConfigVersion int, log *zap.SugaredL
 	return true
 }
 
+// GoalStateVersion returns the version of the most recent automation config the Agent in the Pod has reached the
+// goal state of, or false if it has not reported one yet.
+func GoalStateVersion(pod corev1.Pod) (int, bool) {
+	currentAgentVersion, ok := pod.Annotations[podAnnotationAgentVersion]
+	if !ok || currentAgentVersion == "" {
+		return 0, false
+	}
+	version, err := cast.ToIntE(currentAgentVersion)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
 // statefulSetPodNames returns a slice of names for a subset of the StatefulSet pods.
 // we need a subset in the case of scaling up/down.
 func statefulSetPodNames(sts appsv1.StatefulSet, currentMembersCount int) []string {
+	start := statefulset.OrdinalsStart(sts)
 	names := make([]string, currentMembersCount)
 	for i := 0; i < currentMembersCount; i++ {
-		names[i] = fmt.Sprintf("%s-%d", sts.Name, i)
+		names[i] = fmt.Sprintf("%s-%d", sts.Name, start+i)
 	}
 	return names
 }
@@ -76,6 +76,17 @@ func TestReachedGoalState(t *testing.T) {
 	})
 }
 
+func TestGoalStateVersion(t *testing.T) {
+	version, ok := GoalStateVersion(createPodWithAgentAnnotation("4"))
+	assert.True(t, ok)
+	assert.Equal(t, 4, version)
+
+	_, ok = GoalStateVersion(createPodWithAgentAnnotation(""))
+	assert.False(t, ok, "the readiness probe sets an empty annotation before the agent reports")
+	_, ok = GoalStateVersion(corev1.Pod{})
+	assert.False(t, ok)
+}
+
 func createPodWithAgentAnnotation(versionStr string) corev1.Pod {
 	return corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -80,6 +80,12 @@ type User struct {
 	// for this user. These credentials will be generated if they do not exist, or used if they do.
 	// Note: there will be one secret with credentials per user created.
 	ScramCredentialsSecretName string
+
+	// AuthenticationRestrictions limit the addresses this user can connect from and to.
+	AuthenticationRestrictions []automationconfig.AuthenticationRestriction
+
+	// ScramSha1 allows the user to authenticate with SCRAM-SHA-1 in addition to SCRAM-SHA-256.
+	ScramSha1 bool
 }
 
 // Options contains a set of values that can be used for more fine grained configuration of authentication.
@@ -93,18 +99,27 @@ type Options struct {
 	// AutoAuthMechanisms is a list of valid authentication mechanisms that the agents can use.
 	AutoAuthMechanisms []string
 
+	// DeploymentAuthMechanisms is a list of authentication mechanisms which are enabled on the deployment
+	// in addition to AutoAuthMechanisms.
+	DeploymentAuthMechanisms []string
+
 	// AgentName is username that the Automation Agent will have.
 	AgentName string
 
 	// AutoAuthMechanism is the desired authentication mechanism that the agents will use.
 	AutoAuthMechanism string
+
+	// UseExistingAgentCredentials indicates that the agent credentials are read from an existing Secret
+	// instead of being generated. The Secret stores the password and optionally the username of the agent.
+	UseExistingAgentCredentials bool
 }
 
 // Enable will configure all of the required Kubernetes resources for SCRAM-SHA to be enabled.
 // The agent password and keyfile contents will be configured and stored in a secret.
 // the user credentials will be generated if not present, or existing credentials will be read.
-func Enable(auth *automationconfig.Auth, secretGetUpdateCreateDeleter secret.GetUpdateCreateDeleter, mdb Configurable) error {
-	generatedPassword, err := generate.RandomFixedLengthStringOfSize(20)
+// The agent password is generated by the given generator.
+func Enable(auth *automationconfig.Auth, secretGetUpdateCreateDeleter secret.GetUpdateCreateDeleter, mdb Configurable, passwords generate.PasswordGenerator) error {
+	generatedPassword, err := passwords.Password(20)
 	if err != nil {
 		return errors.Errorf("could not generate password: %s", err)
 	}
@@ -119,10 +134,19 @@ func Enable(auth *automationconfig.Auth, secretGetUpdateCreateDeleter secret.Get
 		return errors.Errorf("could not convert users to Automation Config users: %s", err)
 	}
 
-	// ensure that the agent password secret exists or read existing password.
-	agentPassword, err := secret.EnsureSecretWithKey(secretGetUpdateCreateDeleter, mdb.GetAgentPasswordSecretNamespacedName(), mdb.GetOwnerReferences(), AgentPasswordKey, generatedPassword)
-	if err != nil {
-		return err
+	opts := mdb.GetScramOptions()
+	var agentPassword string
+	if opts.UseExistingAgentCredentials {
+		opts.AgentName, agentPassword, err = readExistingAgentCredentials(secretGetUpdateCreateDeleter, mdb.GetAgentPasswordSecretNamespacedName(), opts.AgentName)
+		if err != nil {
+			return err
+		}
+	} else {
+		// ensure that the agent password secret exists or read existing password.
+		agentPassword, err = secret.EnsureSecretWithKey(secretGetUpdateCreateDeleter, mdb.GetAgentPasswordSecretNamespacedName(), mdb.GetOwnerReferences(), AgentPasswordKey, generatedPassword)
+		if err != nil {
+			return err
+		}
 	}
 
 	// ensure that the agent keyfile secret exists or read existing keyfile.
@@ -131,12 +155,36 @@ func Enable(auth *automationconfig.Auth, secretGetUpdateCreateDeleter secret.Get
 		return err
 	}
 
+	if isSha1Enabled(mdb.GetScramUsers()) {
+		opts.DeploymentAuthMechanisms = append(opts.DeploymentAuthMechanisms, Sha1)
+	}
+
 	return configureScramInAutomationConfig(auth,
 		agentPassword,
-		agentKeyFile, desiredUsers, mdb.GetScramOptions(),
+		agentKeyFile, desiredUsers, opts,
 	)
 }
 
+// readExistingAgentCredentials reads the username and password of the agent from the given Secret.
+// The username defaults to defaultAgentName if the Secret does not specify one.
+func readExistingAgentCredentials(secretGetter secret.Getter, nsName types.NamespacedName, defaultAgentName string) (string, string, error) {
+	data, err := secret.ReadStringData(secretGetter, nsName)
+	if err != nil {
+		return "", "", errors.Errorf("could not read agent credentials from secret %s: %s", nsName, err)
+	}
+
+	password := data[AgentPasswordKey]
+	if password == "" {
+		return "", "", errors.Errorf("agent credentials secret %s does not contain the key %s", nsName, AgentPasswordKey)
+	}
+
+	username := data[AgentUsernameKey]
+	if username == "" {
+		username = defaultAgentName
+	}
+	return username, password, nil
+}
+
 // ensureScramCredentials will ensure that the ScramSha1 & ScramSha256 credentials exist and are stored in the credentials
 // secret corresponding to user of the given MongoDB deployment.
 func ensureScramCredentials(getUpdateCreator secret.GetUpdateCreator, user User, mdbNamespacedName types.NamespacedName) (scramcredentials.ScramCreds, scramcredentials.ScramCreds, error) {
@@ -302,17 +350,36 @@ func readExistingCredentials(secretGetter secret.Getter, mdbObjectKey types.Name
 
 // convertMongoDBResourceUsersToAutomationConfigUsers returns a list of users that are able to be set in the AutomationConfig
 func convertMongoDBResourceUsersToAutomationConfigUsers(secretGetUpdateCreateDeleter secret.GetUpdateCreateDeleter, mdb Configurable) ([]automationconfig.MongoDBUser, error) {
+	users := mdb.GetScramUsers()
+	sha1Enabled := isSha1Enabled(users)
+
 	var usersWanted []automationconfig.MongoDBUser
-	for _, u := range mdb.GetScramUsers() {
+	for _, u := range users {
 		acUser, err := convertMongoDBUserToAutomationConfigUser(secretGetUpdateCreateDeleter, mdb.NamespacedName(), u)
 		if err != nil {
 			return nil, errors.Errorf("failed to convert scram user %s to Automation Config user: %s", u.Username, err)
 		}
+		if sha1Enabled && !u.ScramSha1 {
+			// SCRAM-SHA-1 is enabled on the deployment, the users which have not opted in must
+			// not be able to authenticate with it.
+			acUser.Mechanisms = []string{Sha256}
+			acUser.ScramSha1Creds = nil
+		}
 		usersWanted = append(usersWanted, acUser)
 	}
 	return usersWanted, nil
 }
 
+// isSha1Enabled returns true if any of the users can authenticate with SCRAM-SHA-1.
+func isSha1Enabled(users []User) bool {
+	for _, u := range users {
+		if u.ScramSha1 {
+			return true
+		}
+	}
+	return false
+}
+
 // convertMongoDBUserToAutomationConfigUser converts a single user configured in the MongoDB resource and converts it to a user
 // that can be added directly to the AutomationConfig.
 func convertMongoDBUserToAutomationConfigUser(secretGetUpdateCreateDeleter secret.GetUpdateCreateDeleter, mdbNsName types.NamespacedName, user User) (automationconfig.MongoDBUser, error) {
@@ -330,8 +397,12 @@ func convertMongoDBUserToAutomationConfigUser(secretGetUpdateCreateDeleter secre
 	if err != nil {
 		return automationconfig.MongoDBUser{}, errors.Errorf("could not ensure scram credentials: %s", err)
 	}
-	acUser.AuthenticationRestrictions = []string{}
+	acUser.AuthenticationRestrictions = []automationconfig.AuthenticationRestriction{}
+	acUser.AuthenticationRestrictions = append(acUser.AuthenticationRestrictions, user.AuthenticationRestrictions...)
 	acUser.Mechanisms = []string{}
+	if user.ScramSha1 {
+		acUser.Mechanisms = []string{sha1UserMechanism, Sha256}
+	}
 	acUser.ScramSha1Creds = &sha1Creds
 	acUser.ScramSha256Creds = &sha256Creds
 	return acUser, nil
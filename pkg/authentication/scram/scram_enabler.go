@@ -11,10 +11,12 @@ import (
 const (
 	Sha256                                = "SCRAM-SHA-256"
 	Sha1                                  = "MONGODB-CR"
+	sha1UserMechanism                     = "SCRAM-SHA-1"
 	AutomationAgentKeyFilePathInContainer = "/var/lib/mongodb-mms-automation/authentication/keyfile"
-	automationAgentWindowsKeyFilePath     = "%SystemDrive%\\MMSAutomation\\versions\\keyfile"
+	AutomationAgentWindowsKeyFilePath     = "%SystemDrive%\\MMSAutomation\\versions\\keyfile"
 	AgentName                             = "mms-automation"
 	AgentPasswordKey                      = "password"
+	AgentUsernameKey                      = "username"
 	AgentKeyfileKey                       = "keyfile"
 )
 
@@ -36,7 +38,7 @@ func enableAgentAuthentication(auth *automationconfig.Auth, agentPassword, agent
 	auth.KeyFile = opts.KeyFile
 
 	// windows file is specified to pass validation, this will never be used
-	auth.KeyFileWindows = automationAgentWindowsKeyFilePath
+	auth.KeyFileWindows = AutomationAgentWindowsKeyFilePath
 
 	for _, authMode := range opts.AutoAuthMechanisms {
 		if !contains.String(auth.AutoAuthMechanisms, authMode) {
@@ -62,7 +64,8 @@ func enableAgentAuthentication(auth *automationconfig.Auth, agentPassword, agent
 }
 
 func enableDeploymentMechanisms(auth *automationconfig.Auth, opts Options) {
-	for _, authMode := range opts.AutoAuthMechanisms {
+	mechanisms := append([]string{}, opts.AutoAuthMechanisms...)
+	for _, authMode := range append(mechanisms, opts.DeploymentAuthMechanisms...) {
 		if !contains.String(auth.DeploymentAuthMechanisms, authMode) {
 			auth.DeploymentAuthMechanisms = append(auth.DeploymentAuthMechanisms, authMode)
 		}
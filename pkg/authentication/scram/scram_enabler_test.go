@@ -30,7 +30,7 @@ func TestScramAutomationConfig(t *testing.T) {
 		assert.Equal(t, []string{Sha256}, auth.DeploymentAuthMechanisms)
 		assert.Equal(t, []string{Sha256}, auth.AutoAuthMechanisms)
 		assert.Equal(t, AutomationAgentKeyFilePathInContainer, auth.KeyFile)
-		assert.Equal(t, automationAgentWindowsKeyFilePath, auth.KeyFileWindows)
+		assert.Equal(t, AutomationAgentWindowsKeyFilePath, auth.KeyFileWindows)
 	})
 
 	t.Run("Subsequent configuration doesn't add to deployment auth mechanisms", func(t *testing.T) {
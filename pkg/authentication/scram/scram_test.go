@@ -175,14 +175,14 @@ func TestConfigureScram(t *testing.T) {
 		s := newMockedSecretGetUpdateCreateDeleter()
 
 		auth := automationconfig.Auth{}
-		err := Enable(&auth, s, mdb)
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
 		assert.Error(t, err)
 	})
 	t.Run("Agent Credentials Secret should be created if there are no users", func(t *testing.T) {
 		mdb := buildConfigurable("mdb-0")
 		s := newMockedSecretGetUpdateCreateDeleter()
 		auth := automationconfig.Auth{}
-		err := Enable(&auth, s, mdb)
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
 		assert.NoError(t, err)
 
 		passwordSecret, err := s.GetSecret(mdb.GetAgentPasswordSecretNamespacedName())
@@ -207,7 +207,7 @@ func TestConfigureScram(t *testing.T) {
 
 		s := newMockedSecretGetUpdateCreateDeleter(agentPasswordSecret)
 		auth := automationconfig.Auth{}
-		err := Enable(&auth, s, mdb)
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
 		assert.NoError(t, err)
 
 		ps, err := s.GetSecret(mdb.GetAgentPasswordSecretNamespacedName())
@@ -229,7 +229,7 @@ func TestConfigureScram(t *testing.T) {
 
 		s := newMockedSecretGetUpdateCreateDeleter(keyfileSecret)
 		auth := automationconfig.Auth{}
-		err := Enable(&auth, s, mdb)
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
 		assert.NoError(t, err)
 
 		ks, err := s.GetSecret(mdb.GetAgentKeyfileSecretNamespacedName())
@@ -243,9 +243,71 @@ func TestConfigureScram(t *testing.T) {
 		mdb := buildConfigurable("mdb-0")
 		s := newMockedSecretGetUpdateCreateDeleter()
 		auth := automationconfig.Auth{}
-		err := Enable(&auth, s, mdb)
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
 		assert.NoError(t, err)
 	})
+
+	t.Run("Existing Agent Credentials are used", func(t *testing.T) {
+		mdb := buildConfigurable("mdb-0").(mockConfigurable)
+		mdb.opts.UseExistingAgentCredentials = true
+
+		agentCredentialsSecret := secret.Builder().
+			SetName(mdb.GetAgentPasswordSecretNamespacedName().Name).
+			SetNamespace(mdb.GetAgentPasswordSecretNamespacedName().Namespace).
+			SetField(AgentUsernameKey, "iam-agent").
+			SetField(AgentPasswordKey, "externally-managed").
+			Build()
+
+		s := newMockedSecretGetUpdateCreateDeleter(agentCredentialsSecret)
+		auth := automationconfig.Auth{}
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
+		assert.NoError(t, err)
+		assert.Equal(t, "iam-agent", auth.AutoUser)
+		assert.Equal(t, "externally-managed", auth.AutoPwd)
+	})
+
+	t.Run("Existing Agent Credentials are not generated", func(t *testing.T) {
+		mdb := buildConfigurable("mdb-0").(mockConfigurable)
+		mdb.opts.UseExistingAgentCredentials = true
+
+		s := newMockedSecretGetUpdateCreateDeleter()
+		auth := automationconfig.Auth{}
+		err := Enable(&auth, s, mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{}))
+		assert.Error(t, err)
+
+		_, err = s.GetSecret(mdb.GetAgentPasswordSecretNamespacedName())
+		assert.Error(t, err)
+	})
+
+	t.Run("SCRAM-SHA-1 is only enabled for users which opt in", func(t *testing.T) {
+		legacyUser := buildMongoDBUser("legacy")
+		legacyUser.ScramCredentialsSecretName = "legacy-scram"
+		legacyUser.ScramSha1 = true
+		user := buildMongoDBUser("modern")
+		user.ScramCredentialsSecretName = "modern-scram"
+		mdb := buildConfigurable("mdb-0", legacyUser, user)
+
+		var passwordSecrets []corev1.Secret
+		for _, u := range []User{legacyUser, user} {
+			passwordSecrets = append(passwordSecrets, secret.Builder().
+				SetName(u.PasswordSecretName).
+				SetNamespace(mdb.NamespacedName().Namespace).
+				SetField(u.PasswordSecretKey, "TDg_DESiScDrJV6").
+				Build())
+		}
+
+		auth := automationconfig.Auth{}
+		assert.NoError(t, Enable(&auth, newMockedSecretGetUpdateCreateDeleter(passwordSecrets...), mdb, generate.NewPasswordGenerator(generate.PasswordPolicy{})))
+		assert.Equal(t, []string{Sha256, Sha1}, auth.DeploymentAuthMechanisms)
+		assert.Equal(t, []string{Sha256}, auth.AutoAuthMechanisms)
+
+		assert.Len(t, auth.Users, 2)
+		assert.Equal(t, []string{"SCRAM-SHA-1", Sha256}, auth.Users[0].Mechanisms)
+		assert.NotNil(t, auth.Users[0].ScramSha1Creds)
+		assert.Equal(t, []string{Sha256}, auth.Users[1].Mechanisms)
+		assert.Nil(t, auth.Users[1].ScramSha1Creds)
+		assert.NotNil(t, auth.Users[1].ScramSha256Creds)
+	})
 }
 
 func buildConfigurable(name string, users ...User) Configurable {
@@ -0,0 +1,95 @@
+package verifier
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/auth"
+)
+
+// appName identifies the connections of the verifier in the logs of the members.
+const appName = "mongodb-kubernetes-operator-credential-verification"
+
+// disconnectTimeout bounds the time given to close the connection, which is not covered by the context of the
+// verification as that may have expired already.
+const disconnectTimeout = 5 * time.Second
+
+// Verifier verifies that a user can authenticate against a deployment.
+type Verifier interface {
+	// Verify connects to the deployment with the given connection string and returns an error if the
+	// connection could not be established or the user could not authenticate.
+	Verify(ctx context.Context, connectionString string, tlsConfig *tls.Config) error
+
+	// VerifyChangeStreams connects to the deployment with the given connection string and opens a change
+	// stream on the given database with majority read concern. It returns an error if the change stream
+	// could not be opened.
+	VerifyChangeStreams(ctx context.Context, connectionString string, tlsConfig *tls.Config, database string) error
+}
+
+// New returns a Verifier which opens a single connection to the nearest member and pings it.
+// Authentication happens when the connection is established, no data is read or written.
+func New() Verifier {
+	return mongoVerifier{}
+}
+
+type mongoVerifier struct{}
+
+func (mongoVerifier) Verify(ctx context.Context, connectionString string, tlsConfig *tls.Config) error {
+	client, err := connect(ctx, connectionString, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer disconnect(client)
+
+	return client.Ping(ctx, readpref.Nearest())
+}
+
+// VerifyChangeStreams opens a change stream and closes it right away, no change is waited for. Opening the
+// change stream fails if the members do not form a replica set with the name given in the connection string,
+// or if they do not support majority read concern.
+func (mongoVerifier) VerifyChangeStreams(ctx context.Context, connectionString string, tlsConfig *tls.Config, database string) error {
+	client, err := connect(ctx, connectionString, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer disconnect(client)
+
+	db := client.Database(database, options.Database().SetReadConcern(readconcern.Majority()))
+	stream, err := db.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		return err
+	}
+	return stream.Close(ctx)
+}
+
+func disconnect(client *mongo.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer cancel()
+	_ = client.Disconnect(ctx)
+}
+
+func connect(ctx context.Context, connectionString string, tlsConfig *tls.Config) (*mongo.Client, error) {
+	opts := options.Client().
+		ApplyURI(connectionString).
+		SetAppName(appName).
+		SetMaxPoolSize(1)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	return mongo.Connect(ctx, opts)
+}
+
+// IsAuthenticationError returns true if the error returned by Verify was caused by the members
+// rejecting the credentials, rather than by the members being unreachable.
+func IsAuthenticationError(err error) bool {
+	var authErr *auth.Error
+	return errors.As(err, &authErr)
+}
@@ -30,6 +30,7 @@ type AutomationConfig struct {
 	MonitoringVersions []MonitoringVersion    `json:"monitoringVersions"`
 	Options            Options                `json:"options"`
 	Roles              []CustomRole           `json:"roles,omitempty"`
+	Ldap               *Ldap                  `json:"ldap,omitempty"`
 }
 
 type BackupVersion struct {
@@ -51,6 +52,7 @@ type Process struct {
 	ProcessType                 ProcessType `json:"processType"`
 	Version                     string      `json:"version"`
 	AuthSchemaVersion           int         `json:"authSchemaVersion"`
+	LogRotate                   *LogRotate  `json:"logRotate,omitempty"`
 }
 
 func (p *Process) SetPort(port int) *Process {
@@ -101,6 +103,15 @@ const (
 	TLSModeRequired  TLSMode = "requireTLS"
 )
 
+type ClusterAuthMode string
+
+const (
+	ClusterAuthModeKeyFile     ClusterAuthMode = "keyFile"
+	ClusterAuthModeSendKeyFile ClusterAuthMode = "sendKeyFile"
+	ClusterAuthModeSendX509    ClusterAuthMode = "sendX509"
+	ClusterAuthModeX509        ClusterAuthMode = "x509"
+)
+
 type ProcessType string
 
 type SystemLog struct {
@@ -121,6 +132,25 @@ type ReplicaSet struct {
 	Id              string             `json:"_id"`
 	Members         []ReplicaSetMember `json:"members"`
 	ProtocolVersion string             `json:"protocolVersion"`
+	// Force makes the agents apply the configuration of the replica set with a forced reconfiguration, which
+	// does not require a majority of the current members.
+	Force *ReplicaSetForce `json:"force,omitempty"`
+	// Settings are the settings of the replica set configuration.
+	Settings *ReplicaSetSettings `json:"settings,omitempty"`
+}
+
+// ReplicaSetSettings are the settings of a replica set configuration.
+type ReplicaSetSettings struct {
+	// GetLastErrorModes are the custom write concerns, which require writes to be acknowledged by members with
+	// the given number of distinct values of each tag.
+	GetLastErrorModes map[string]map[string]int `json:"getLastErrorModes,omitempty"`
+}
+
+// ReplicaSetForce requests a forced reconfiguration of a replica set.
+type ReplicaSetForce struct {
+	// CurrentVersion is the version of the replica set configuration which is forcibly replaced, -1 replaces
+	// whichever configuration the members have.
+	CurrentVersion int64 `json:"currentVersion"`
 }
 
 type ReplicaSetMember struct {
@@ -130,6 +160,7 @@ type ReplicaSetMember struct {
 	ArbiterOnly bool               `json:"arbiterOnly"`
 	Votes       int                `json:"votes"`
 	Horizons    ReplicaSetHorizons `json:"horizons,omitempty"`
+	Tags        map[string]string  `json:"tags,omitempty"`
 }
 
 type ReplicaSetHorizons map[string]string
@@ -180,6 +211,22 @@ type Auth struct {
 	AutoPwd string `json:"autoPwd,omitempty"`
 }
 
+type Ldap struct {
+	// Servers is a comma separated list of LDAP servers
+	Servers           string `json:"servers"`
+	TransportSecurity string `json:"transportSecurity"`
+	BindMethod        string `json:"bindMethod"`
+	BindQueryUser     string `json:"bindQueryUser"`
+	// BindQueryPassword is the password of the BindQueryUser, it must never be logged.
+	BindQueryPassword        string `json:"bindQueryPassword"`
+	UserToDNMapping          string `json:"userToDNMapping,omitempty"`
+	AuthzQueryTemplate       string `json:"authzQueryTemplate,omitempty"`
+	ValidateLDAPServerConfig bool   `json:"validateLDAPServerConfig"`
+	TimeoutMS                int    `json:"timeoutMS,omitempty"`
+	// CaFileContents is the CA certificate used to verify the LDAP servers
+	CaFileContents string `json:"CAFileContents,omitempty"`
+}
+
 type CustomRole struct {
 	Role                       string                      `json:"role"`
 	DB                         string                      `json:"db"`
@@ -201,16 +248,16 @@ type Resource struct {
 }
 
 type AuthenticationRestriction struct {
-	ClientSource  []string `json:"clientSource"`
-	ServerAddress []string `json:"serverAddress"`
+	ClientSource  []string `json:"clientSource,omitempty"`
+	ServerAddress []string `json:"serverAddress,omitempty"`
 }
 
 type MongoDBUser struct {
-	Mechanisms                 []string `json:"mechanisms"`
-	Roles                      []Role   `json:"roles"`
-	Username                   string   `json:"user"`
-	Database                   string   `json:"db"`
-	AuthenticationRestrictions []string `json:"authenticationRestrictions"`
+	Mechanisms                 []string                    `json:"mechanisms"`
+	Roles                      []Role                      `json:"roles"`
+	Username                   string                      `json:"user"`
+	Database                   string                      `json:"db"`
+	AuthenticationRestrictions []AuthenticationRestriction `json:"authenticationRestrictions"`
 
 	// ScramShaCreds are generated by the operator.
 	ScramSha256Creds *scramcredentials.ScramCreds `json:"scramSha256Creds"`
@@ -242,11 +289,16 @@ const (
 type TLS struct {
 	CAFilePath            string                `json:"CAFilePath"`
 	ClientCertificateMode ClientCertificateMode `json:"clientCertificateMode"`
+	// AutoPEMKeyFilePath is the path of the certificate and key the agent authenticates with, if it authenticates
+	// with MONGODB-X509.
+	AutoPEMKeyFilePath string `json:"autoPEMKeyFilePath,omitempty"`
 }
 
 type LogRotate struct {
 	SizeThresholdMB  int `json:"sizeThresholdMB"`
 	TimeThresholdHrs int `json:"timeThresholdHrs"`
+	NumUncompressed  int `json:"numUncompressed,omitempty"`
+	NumTotal         int `json:"numTotal,omitempty"`
 }
 
 type ToolsVersion struct {
@@ -17,6 +17,12 @@ type Topology string
 const (
 	ReplicaSetTopology Topology = "ReplicaSet"
 	maxVotingMembers   int      = 7
+
+	// DefaultDBPort is the port mongod listens on.
+	DefaultDBPort = 27017
+
+	// MaxMemberID is the highest id MongoDB accepts for a replica set member.
+	MaxMemberID = 255
 )
 
 type Modification func(*AutomationConfig)
@@ -30,8 +36,10 @@ type Builder struct {
 	replicaSets        []ReplicaSet
 	replicaSetHorizons []ReplicaSetHorizons
 	members            int
+	memberOrdinalStart int
 	domain             string
 	name               string
+	replicaSetName     string
 	fcv                string
 	topology           Topology
 	mongodbVersion     string
@@ -93,6 +101,11 @@ func (b *Builder) SetMembers(members int) *Builder {
 	return b
 }
 
+func (b *Builder) SetMemberOrdinalStart(memberOrdinalStart int) *Builder {
+	b.memberOrdinalStart = memberOrdinalStart
+	return b
+}
+
 func (b *Builder) SetDomain(domain string) *Builder {
 	b.domain = domain
 	return b
@@ -103,6 +116,20 @@ func (b *Builder) SetName(name string) *Builder {
 	return b
 }
 
+// SetReplicaSetName sets the name of the replica set, which defaults to the name set with SetName.
+// The process names are always derived from the name set with SetName.
+func (b *Builder) SetReplicaSetName(replicaSetName string) *Builder {
+	b.replicaSetName = replicaSetName
+	return b
+}
+
+func (b *Builder) getReplicaSetName() string {
+	if b.replicaSetName != "" {
+		return b.replicaSetName
+	}
+	return b.name
+}
+
 func (b *Builder) SetFCV(fcv string) *Builder {
 	b.fcv = fcv
 	return b
@@ -193,9 +220,12 @@ func (b *Builder) setFeatureCompatibilityVersionIfUpgradeIsHappening() error {
 }
 
 func (b *Builder) Build() (AutomationConfig, error) {
+	if b.memberOrdinalStart+b.members-1 > MaxMemberID {
+		return AutomationConfig{}, errors.Errorf("can't build the automation config: the ids of the members from %d to %d exceed %d", b.memberOrdinalStart, b.memberOrdinalStart+b.members-1, MaxMemberID)
+	}
 	hostnames := make([]string, b.members)
 	for i := 0; i < b.members; i++ {
-		hostnames[i] = fmt.Sprintf("%s-%d.%s", b.name, i, b.domain)
+		hostnames[i] = fmt.Sprintf("%s-%d.%s", b.name, b.memberOrdinalStart+i, b.domain)
 	}
 
 	members := make([]ReplicaSetMember, b.members)
@@ -207,7 +237,7 @@ func (b *Builder) Build() (AutomationConfig, error) {
 	for i, h := range hostnames {
 
 		process := &Process{
-			Name:                        toProcessName(b.name, i),
+			Name:                        toProcessName(b.name, b.memberOrdinalStart+i),
 			HostName:                    h,
 			FeatureCompatibilityVersion: versions.CalculateFeatureCompatibilityVersion(b.mongodbVersion),
 			ProcessType:                 Mongod,
@@ -224,9 +254,9 @@ func (b *Builder) Build() (AutomationConfig, error) {
 			process.FeatureCompatibilityVersion = b.fcv
 		}
 
-		process.SetPort(27017)
+		process.SetPort(DefaultDBPort)
 		process.SetStoragePath(DefaultMongoDBDataDir)
-		process.SetReplicaSetName(b.name)
+		process.SetReplicaSetName(b.getReplicaSetName())
 
 		for _, mod := range b.processModifications {
 			mod(i, process)
@@ -236,9 +266,9 @@ func (b *Builder) Build() (AutomationConfig, error) {
 
 		totalVotes := 0
 		if b.replicaSetHorizons != nil {
-			members[i] = newReplicaSetMember(*process, i, b.replicaSetHorizons[i], totalVotes)
+			members[i] = newReplicaSetMember(*process, b.memberOrdinalStart+i, b.replicaSetHorizons[i], totalVotes)
 		} else {
-			members[i] = newReplicaSetMember(*process, i, nil, totalVotes)
+			members[i] = newReplicaSetMember(*process, b.memberOrdinalStart+i, nil, totalVotes)
 		}
 		totalVotes += members[i].Votes
 	}
@@ -258,7 +288,7 @@ func (b *Builder) Build() (AutomationConfig, error) {
 		Processes: processes,
 		ReplicaSets: []ReplicaSet{
 			{
-				Id:              b.name,
+				Id:              b.getReplicaSetName(),
 				Members:         members,
 				ProtocolVersion: "1",
 			},
@@ -62,6 +62,49 @@ func TestBuildAutomationConfig(t *testing.T) {
 	}
 }
 
+func TestBuildAutomationConfig_ReplicaSetName(t *testing.T) {
+	ac, err := NewBuilder().
+		SetName("my-rs").
+		SetReplicaSetName("renamed-rs").
+		SetDomain("my-ns.svc.cluster.local").
+		SetMongoDBVersion("4.2.0").
+		SetMembers(3).
+		Build()
+
+	assert.NoError(t, err)
+	for i, p := range ac.Processes {
+		assert.Equal(t, "renamed-rs", p.Args26.Get("replication.replSetName").Data())
+		assert.Equal(t, toProcessName("my-rs", i), p.Name, "process names must not depend on the replica set name")
+	}
+	assert.Equal(t, "renamed-rs", ac.ReplicaSets[0].Id)
+}
+
+func TestBuildAutomationConfig_MemberOrdinalStart(t *testing.T) {
+	ac, err := NewBuilder().
+		SetName("my-rs").
+		SetDomain("my-ns.svc.cluster.local").
+		SetMongoDBVersion("4.2.0").
+		SetMembers(3).
+		SetMemberOrdinalStart(3).
+		Build()
+
+	assert.NoError(t, err)
+	for i, member := range ac.ReplicaSets[0].Members {
+		assert.Equal(t, i+3, member.Id)
+		assert.Equal(t, toProcessName("my-rs", i+3), member.Host)
+		assert.Equal(t, fmt.Sprintf("my-rs-%d.my-ns.svc.cluster.local", i+3), ac.Processes[i].HostName)
+	}
+
+	_, err = NewBuilder().
+		SetName("my-rs").
+		SetDomain("my-ns.svc.cluster.local").
+		SetMongoDBVersion("4.2.0").
+		SetMembers(3).
+		SetMemberOrdinalStart(254).
+		Build()
+	assert.EqualError(t, err, "can't build the automation config: the ids of the members from 254 to 256 exceed 255")
+}
+
 func TestReplicaSetHorizons(t *testing.T) {
 	ac, err := NewBuilder().
 		SetName("my-rs").
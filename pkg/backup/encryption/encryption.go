@@ -0,0 +1,271 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Algorithm is the algorithm the artifacts are encrypted with.
+const Algorithm = "AES-256-GCM"
+
+const (
+	keySize = 32
+
+	// chunkSize is the size of the plaintext sealed at once, so that artifacts of any size can be streamed
+	chunkSize = 64 * 1024
+
+	// finalChunk is set in the length of the last chunk, so that a truncated artifact is detected
+	finalChunk = 1 << 31
+
+	maxHeaderSize = 64 * 1024
+)
+
+// magic starts every encrypted artifact, followed by the length and the JSON encoding of the Header.
+var magic = []byte("MDBCENC1")
+
+// Header describes how an artifact was encrypted. It is stored in clear at the start of the artifact, so that a
+// restore can locate the key without any other record of the backup.
+type Header struct {
+	Algorithm string `json:"algorithm"`
+
+	// KeyFingerprint identifies the key the artifact was encrypted with, see Fingerprint
+	KeyFingerprint string `json:"keyFingerprint"`
+
+	// KeySecret is the "<name>/<key>" of the Secret the key was read from, empty for data keys generated by a KMS
+	KeySecret string `json:"keySecret,omitempty"`
+
+	// KMSProvider and KMSKeyID identify the key of the KMS which wrapped the data key
+	KMSProvider string `json:"kmsProvider,omitempty"`
+	KMSKeyID    string `json:"kmsKeyId,omitempty"`
+	// WrappedKey is the base64 encoding of the data key wrapped by the KMS
+	WrappedKey string `json:"wrappedKey,omitempty"`
+
+	// NoncePrefix is the random part of the nonces of the chunks, which is followed by the number of the chunk
+	NoncePrefix []byte `json:"noncePrefix"`
+
+	// additionalData is the encoded header read from an artifact, which its chunks are bound to
+	additionalData []byte
+}
+
+// ParseKey returns the 32 byte key stored in a Secret, either as is or base64 encoded.
+func ParseKey(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == keySize {
+		return data, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil || len(key) != keySize {
+		return nil, errors.Errorf("the key must be %d bytes, or their base64 encoding", keySize)
+	}
+	return key, nil
+}
+
+// Fingerprint returns an identifier of the key which does not reveal it.
+func Fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewWriter returns a writer encrypting the data written to it with the given key, and writing the header and
+// the encrypted data to w. The writer must be closed to write the last chunk.
+func NewWriter(w io.Writer, key []byte, header Header) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header.Algorithm = Algorithm
+	header.KeyFingerprint = Fingerprint(key)
+	header.NoncePrefix = make([]byte, aead.NonceSize()-4)
+	if _, err := rand.Read(header.NoncePrefix); err != nil {
+		return nil, err
+	}
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := append([]byte{}, magic...)
+	prefix = append(prefix, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(prefix[len(magic):], uint32(len(encodedHeader)))
+	if _, err := w.Write(append(prefix, encodedHeader...)); err != nil {
+		return nil, err
+	}
+	return &writer{w: w, aead: aead, header: header, additionalData: encodedHeader, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+type writer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header Header
+	// additionalData binds each chunk to the header
+	additionalData []byte
+	buf            []byte
+	chunk          uint32
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+		// the last chunk is only written on Close, so a full buffer is kept until more data follows
+		if len(w.buf) == cap(w.buf) && len(p) > 0 {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *writer) Close() error {
+	return w.seal(true)
+}
+
+func (w *writer) seal(final bool) error {
+	sealed := w.aead.Seal(nil, nonce(w.header.NoncePrefix, w.chunk), w.buf, chunkAdditionalData(w.additionalData, final))
+	length := uint32(len(sealed))
+	if final {
+		length |= finalChunk
+	}
+	lengthBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBytes, length)
+	if _, err := w.w.Write(append(lengthBytes, sealed...)); err != nil {
+		return err
+	}
+	w.chunk++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// ReadHeader reads the header of an encrypted artifact from r. It returns false without consuming any data if the
+// artifact is not encrypted.
+func ReadHeader(r *bufio.Reader) (Header, bool, error) {
+	prefix, err := r.Peek(len(magic) + 4)
+	if err == io.EOF || (err == nil && !bytes.Equal(prefix[:len(magic)], magic)) {
+		return Header{}, false, nil
+	}
+	if err != nil {
+		return Header{}, false, err
+	}
+	length := binary.BigEndian.Uint32(prefix[len(magic):])
+	if length > maxHeaderSize {
+		return Header{}, true, errors.New("the header of the encrypted artifact is corrupted")
+	}
+	if _, err := r.Discard(len(prefix)); err != nil {
+		return Header{}, true, err
+	}
+	encodedHeader := make([]byte, length)
+	if _, err := io.ReadFull(r, encodedHeader); err != nil {
+		return Header{}, true, err
+	}
+	header := Header{}
+	if err := json.Unmarshal(encodedHeader, &header); err != nil {
+		return Header{}, true, errors.Errorf("the header of the encrypted artifact is corrupted: %s", err)
+	}
+	if header.Algorithm != Algorithm {
+		return Header{}, true, errors.Errorf("the artifact is encrypted with the unsupported algorithm %q", header.Algorithm)
+	}
+	header.additionalData = encodedHeader
+	return header, true, nil
+}
+
+// NewReader returns a reader decrypting the data of the artifact whose header has been read from r. It fails if
+// the key is not the key the artifact was encrypted with.
+func NewReader(r io.Reader, header Header, key []byte) (io.Reader, error) {
+	if fingerprint := Fingerprint(key); fingerprint != header.KeyFingerprint {
+		return nil, errors.Errorf("the artifact was encrypted with the key with fingerprint %s, not with the key with fingerprint %s", header.KeyFingerprint, fingerprint)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{r: r, aead: aead, header: header}, nil
+}
+
+type reader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	header Header
+	buf    []byte
+	chunk  uint32
+	done   bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *reader) open() error {
+	lengthBytes := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, lengthBytes); err != nil {
+		return errors.Errorf("the encrypted artifact is truncated: %s", err)
+	}
+	length := binary.BigEndian.Uint32(lengthBytes)
+	final := length&finalChunk != 0
+	length &^= finalChunk
+	if length > chunkSize+uint32(r.aead.Overhead()) {
+		return errors.New("the encrypted artifact is corrupted")
+	}
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		return errors.Errorf("the encrypted artifact is truncated: %s", err)
+	}
+	opened, err := r.aead.Open(sealed[:0], nonce(r.header.NoncePrefix, r.chunk), sealed, chunkAdditionalData(r.header.additionalData, final))
+	if err != nil {
+		return errors.Errorf("could not decrypt chunk %d of the artifact: %s", r.chunk, err)
+	}
+	r.buf = opened
+	r.chunk++
+	r.done = final
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, errors.Errorf("the key must be %d bytes", keySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func nonce(prefix []byte, chunk uint32) []byte {
+	n := append([]byte{}, prefix...)
+	n = append(n, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(n[len(prefix):], chunk)
+	return n
+}
+
+func chunkAdditionalData(header []byte, final bool) []byte {
+	data := append([]byte{}, header...)
+	if final {
+		return append(data, 1)
+	}
+	return append(data, 0)
+}
@@ -0,0 +1,122 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newKey(t *testing.T) []byte {
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	assert.NoError(t, err)
+	return key
+}
+
+func encryptData(t *testing.T, key, data []byte, header Header) []byte {
+	encrypted := bytes.Buffer{}
+	w, err := NewWriter(&encrypted, key, header)
+	assert.NoError(t, err)
+	_, err = w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return encrypted.Bytes()
+}
+
+func decryptData(encrypted, key []byte) ([]byte, Header, error) {
+	in := bufio.NewReader(bytes.NewReader(encrypted))
+	header, _, err := ReadHeader(in)
+	if err != nil {
+		return nil, header, err
+	}
+	r, err := NewReader(in, header, key)
+	if err != nil {
+		return nil, header, err
+	}
+	data, err := ioutil.ReadAll(r)
+	return data, header, err
+}
+
+func TestEncryption_RoundTrip(t *testing.T) {
+	key := newKey(t)
+	for _, size := range []int{0, 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+
+		encrypted := encryptData(t, key, data, Header{KeySecret: "backup-key/key"})
+		if size > 16 {
+			assert.False(t, bytes.Contains(encrypted, data[:16]), "the data is not stored in clear")
+		}
+
+		decrypted, header, err := decryptData(encrypted, key)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decrypted, "size %d", size)
+		assert.Equal(t, Algorithm, header.Algorithm)
+		assert.Equal(t, "backup-key/key", header.KeySecret)
+		assert.Equal(t, Fingerprint(key), header.KeyFingerprint)
+	}
+}
+
+func TestEncryption_RejectsTheWrongKey(t *testing.T) {
+	encrypted := encryptData(t, newKey(t), []byte("archive"), Header{})
+
+	_, _, err := decryptData(encrypted, newKey(t))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "was encrypted with the key with fingerprint")
+	}
+}
+
+func TestEncryption_DetectsTampering(t *testing.T) {
+	key := newKey(t)
+	data := make([]byte, 2*chunkSize+10)
+	encrypted := encryptData(t, key, data, Header{})
+
+	t.Run("Modified data", func(t *testing.T) {
+		modified := append([]byte{}, encrypted...)
+		modified[len(modified)-20] ^= 1
+		_, _, err := decryptData(modified, key)
+		assert.Error(t, err)
+	})
+	t.Run("Truncated artifact", func(t *testing.T) {
+		// drop the last chunk, the remaining chunks are intact
+		truncated := encrypted[:len(encrypted)-(10+16+4)]
+		_, _, err := decryptData(truncated, key)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "truncated")
+		}
+	})
+	t.Run("Modified header", func(t *testing.T) {
+		modified := bytes.Replace(encrypted, []byte(`"algorithm"`), []byte(`"Algorithm"`), 1)
+		_, _, err := decryptData(modified, key)
+		assert.Error(t, err)
+	})
+}
+
+func TestReadHeader_LeavesPlaintextUnread(t *testing.T) {
+	in := bufio.NewReader(bytes.NewReader([]byte("plain archive")))
+	_, encrypted, err := ReadHeader(in)
+	assert.NoError(t, err)
+	assert.False(t, encrypted)
+	data, _ := ioutil.ReadAll(in)
+	assert.Equal(t, "plain archive", string(data))
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, keySize)
+
+	parsed, err := ParseKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	parsed, err = ParseKey([]byte(base64.StdEncoding.EncodeToString(key) + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParseKey([]byte("too short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package locker
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// appName identifies the connections of the locker in the logs of the members.
+const appName = "mongodb-kubernetes-operator-snapshot-backup"
+
+// ErrNotSecondary is returned by Lock if the member is not a secondary.
+var ErrNotSecondary = errors.New("the member is not a secondary")
+
+// Locker flushes the data of a member to disk and locks it for writes, so that a consistent snapshot of its
+// volume can be taken.
+type Locker interface {
+	// Lock connects directly to the member with the given connection string and locks it with fsync. It
+	// returns ErrNotSecondary without locking the member if the member is not a secondary.
+	Lock(ctx context.Context, connectionString string, tlsConfig *tls.Config) error
+
+	// Unlock connects directly to the member with the given connection string and unlocks it. Unlocking a
+	// member which is not locked succeeds.
+	Unlock(ctx context.Context, connectionString string, tlsConfig *tls.Config) error
+}
+
+// New returns a Locker which opens a single connection to the member for each call.
+func New() Locker {
+	return mongoLocker{}
+}
+
+type mongoLocker struct{}
+
+func (mongoLocker) Lock(ctx context.Context, connectionString string, tlsConfig *tls.Config) error {
+	client, err := connect(ctx, connectionString, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	admin := client.Database("admin")
+	hello := struct {
+		Secondary bool `bson:"secondary"`
+	}{}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if !hello.Secondary {
+		return ErrNotSecondary
+	}
+	return admin.RunCommand(ctx, bson.D{{Key: "fsync", Value: 1}, {Key: "lock", Value: true}}).Err()
+}
+
+func (mongoLocker) Unlock(ctx context.Context, connectionString string, tlsConfig *tls.Config) error {
+	client, err := connect(ctx, connectionString, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "fsyncUnlock", Value: 1}}).Err()
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && strings.Contains(cmdErr.Message, "not locked") {
+		return nil
+	}
+	return err
+}
+
+func connect(ctx context.Context, connectionString string, tlsConfig *tls.Config) (*mongo.Client, error) {
+	opts := options.Client().
+		ApplyURI(connectionString).
+		SetAppName(appName).
+		SetDirect(true).
+		SetMaxPoolSize(1)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	return mongo.Connect(ctx, opts)
+}
@@ -0,0 +1,78 @@
+// Package helpers contains high level operations on MongoDBCommunity resources built on top of the generated clientset.
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/client/clientset/versioned"
+)
+
+// IsReady returns true if the operator has finished reconciling the resource with all of its members.
+func IsReady(mdb mdbv1.MongoDBCommunity) bool {
+	return mdb.Status.Phase == mdbv1.Running &&
+		mdb.Status.CurrentMongoDBMembers == mdb.Spec.Members &&
+		mdb.Status.CurrentStatefulSetReplicas == mdb.Spec.Members
+}
+
+// WaitForReady polls the resource with the given interval until it is ready, or the context is done.
+func WaitForReady(ctx context.Context, c versioned.Interface, namespace, name string, interval time.Duration) (*mdbv1.MongoDBCommunity, error) {
+	var mdb *mdbv1.MongoDBCommunity
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		var err error
+		mdb, err = c.MongodbcommunityV1().MongoDBCommunities(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return IsReady(*mdb), nil
+	}, ctx.Done())
+
+	if err == wait.ErrWaitTimeout && mdb != nil {
+		return mdb, errors.Errorf("resource %s/%s is not ready: phase=%s, message=%q", namespace, name, mdb.Status.Phase, mdb.Status.Message)
+	}
+	return mdb, err
+}
+
+// ScaleTo sets the number of members of the resource. It does not wait for the scaling operation to complete,
+// use WaitForReady for that.
+func ScaleTo(ctx context.Context, c versioned.Interface, namespace, name string, members int) (*mdbv1.MongoDBCommunity, error) {
+	if members < 1 {
+		return nil, errors.Errorf("invalid number of members %d", members)
+	}
+	return update(ctx, c, namespace, name, func(mdb *mdbv1.MongoDBCommunity) {
+		mdb.Spec.Members = members
+	})
+}
+
+// TriggerRotation requests a rotation of the keyfile used by the members to authenticate to each other.
+// A rotation is only performed once per rotationID.
+func TriggerRotation(ctx context.Context, c versioned.Interface, namespace, name, rotationID string) (*mdbv1.MongoDBCommunity, error) {
+	if rotationID == "" {
+		return nil, errors.New("rotation ID must not be empty")
+	}
+	return update(ctx, c, namespace, name, func(mdb *mdbv1.MongoDBCommunity) {
+		mdb.Spec.Security.KeyfileRotation = &mdbv1.KeyfileRotation{RotationID: rotationID}
+	})
+}
+
+// update applies updateFunc to the latest version of the resource, retrying on conflicts.
+func update(ctx context.Context, c versioned.Interface, namespace, name string, updateFunc func(*mdbv1.MongoDBCommunity)) (*mdbv1.MongoDBCommunity, error) {
+	var updated *mdbv1.MongoDBCommunity
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mdb, err := c.MongodbcommunityV1().MongoDBCommunities(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updateFunc(mdb)
+		updated, err = c.MongodbcommunityV1().MongoDBCommunities(namespace).Update(ctx, mdb, metav1.UpdateOptions{})
+		return err
+	})
+	return updated, err
+}
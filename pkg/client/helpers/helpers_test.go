@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/api/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newFakeClientset returns a fake clientset containing the given resource. The resource is created through
+// the client, as the object tracker would otherwise guess the plural "mongodbcommunities".
+func newFakeClientset(t *testing.T, mdb *mdbv1.MongoDBCommunity) *fake.Clientset {
+	c := fake.NewSimpleClientset()
+	_, err := c.MongodbcommunityV1().MongoDBCommunities(mdb.Namespace).Create(context.TODO(), mdb, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	return c
+}
+
+func newMongoDBCommunity() *mdbv1.MongoDBCommunity {
+	return &mdbv1.MongoDBCommunity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-rs",
+			Namespace: "my-ns",
+		},
+		Spec: mdbv1.MongoDBCommunitySpec{
+			Members: 3,
+		},
+	}
+}
+
+func TestScaleTo(t *testing.T) {
+	c := newFakeClientset(t, newMongoDBCommunity())
+
+	mdb, err := ScaleTo(context.TODO(), c, "my-ns", "my-rs", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, mdb.Spec.Members)
+
+	_, err = ScaleTo(context.TODO(), c, "my-ns", "my-rs", 0)
+	assert.Error(t, err)
+
+	_, err = ScaleTo(context.TODO(), c, "my-ns", "missing", 3)
+	assert.Error(t, err)
+}
+
+func TestTriggerRotation(t *testing.T) {
+	c := newFakeClientset(t, newMongoDBCommunity())
+
+	mdb, err := TriggerRotation(context.TODO(), c, "my-ns", "my-rs", "2021-05")
+	assert.NoError(t, err)
+	assert.Equal(t, "2021-05", mdb.Spec.Security.KeyfileRotation.RotationID)
+
+	_, err = TriggerRotation(context.TODO(), c, "my-ns", "my-rs", "")
+	assert.Error(t, err)
+}
+
+func TestWaitForReady(t *testing.T) {
+	mdb := newMongoDBCommunity()
+	mdb.Status.Phase = mdbv1.Pending
+	c := newFakeClientset(t, mdb)
+
+	t.Run("Times out while the resource is pending", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+		_, err := WaitForReady(ctx, c, "my-ns", "my-rs", 10*time.Millisecond)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "phase=Pending")
+	})
+
+	t.Run("Returns once the resource is running", func(t *testing.T) {
+		mdb.Status = mdbv1.MongoDBCommunityStatus{Phase: mdbv1.Running, CurrentMongoDBMembers: 3, CurrentStatefulSetReplicas: 3}
+		_, err := c.MongodbcommunityV1().MongoDBCommunities("my-ns").UpdateStatus(context.TODO(), mdb, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+
+		ready, err := WaitForReady(context.TODO(), c, "my-ns", "my-rs", 10*time.Millisecond)
+		assert.NoError(t, err)
+		assert.True(t, IsReady(*ready))
+	})
+}
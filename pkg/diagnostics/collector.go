@@ -0,0 +1,127 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// appName identifies the connections of the collector in the logs of the members.
+const appName = "mongodb-kubernetes-operator-diagnostics"
+
+// Collector collects diagnostic data from the members of a deployment.
+type Collector interface {
+	// ContainerLogs returns the last lines of the log of the given container. If previous is true, the log of
+	// the previous instance of the container is returned instead.
+	ContainerLogs(ctx context.Context, namespace, podName, containerName string, lines int64, previous bool) (string, error)
+
+	// ReadFile returns the contents of the file at the given path in the given container.
+	ReadFile(ctx context.Context, namespace, podName, containerName, path string) (string, error)
+
+	// ReplSetStatus connects directly to the member with the given connection string and returns the output
+	// of replSetGetStatus as extended JSON.
+	ReplSetStatus(ctx context.Context, connectionString string, tlsConfig *tls.Config) (string, error)
+}
+
+// New returns a Collector which reads the logs of the containers with the given configuration.
+func New(config *rest.Config) Collector {
+	if config == nil {
+		return collector{err: errors.New("no configuration to connect to the Kubernetes API with")}
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	return collector{clientset: clientset, config: config, err: err}
+}
+
+type collector struct {
+	clientset kubernetes.Interface
+	config    *rest.Config
+	// err is the error creating the clientset
+	err error
+}
+
+func (c collector) ContainerLogs(ctx context.Context, namespace, podName, containerName string, lines int64, previous bool) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+
+	opts := &corev1.PodLogOptions{Container: containerName, TailLines: &lines, Previous: previous}
+	data, err := c.clientset.CoreV1().Pods(namespace).GetLogs(podName, opts).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (c collector) ReadFile(ctx context.Context, namespace, podName, containerName, path string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   []string{"cat", path},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
+	if err != nil {
+		return "", err
+	}
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	if err := executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		if stderr.Len() > 0 {
+			return "", errors.Errorf("%s: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+func (collector) ReplSetStatus(ctx context.Context, connectionString string, tlsConfig *tls.Config) (string, error) {
+	opts := options.Client().
+		ApplyURI(connectionString).
+		SetAppName(appName).
+		SetDirect(true).
+		SetMaxPoolSize(1)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	status := bson.Raw{}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}).Decode(&status); err != nil {
+		return "", err
+	}
+	data, err := bson.MarshalExtJSON(status, false, false)
+	if err != nil {
+		return "", err
+	}
+	indented := bytes.Buffer{}
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
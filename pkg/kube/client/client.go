@@ -12,6 +12,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"k8s.io/apimachinery/pkg/types"
 	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -151,13 +152,40 @@ func (c client) GetStatefulSet(objectKey k8sClient.ObjectKey) (appsv1.StatefulSe
 // UpdateStatefulSet provides a thin wrapper and client.Client to update appsv1.StatefulSet types
 // the updated StatefulSet is returned
 func (c client) UpdateStatefulSet(sts appsv1.StatefulSet) (appsv1.StatefulSet, error) {
+	if statefulset.OrdinalsStart(sts) != 0 {
+		return c.updateStatefulSetWithOrdinals(sts)
+	}
 	stsToUpdate := &sts
 	err := c.Update(context.TODO(), stsToUpdate)
 	return *stsToUpdate, err
 }
 
+// updateStatefulSetWithOrdinals updates a StatefulSet whose Pods are not numbered from 0. It is sent as an
+// unstructured object, as spec.ordinals would otherwise be dropped and reset by the update.
+func (c client) updateStatefulSetWithOrdinals(sts appsv1.StatefulSet) (appsv1.StatefulSet, error) {
+	u, err := statefulset.ToUnstructured(sts)
+	if err != nil {
+		return sts, err
+	}
+	if err := c.Update(context.TODO(), u); err != nil {
+		return sts, err
+	}
+	updated := appsv1.StatefulSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &updated); err != nil {
+		return sts, err
+	}
+	return updated, nil
+}
+
 // CreateStatefulSet provides a thin wrapper and client.Client to create appsv1.StatefulSet types
 func (c client) CreateStatefulSet(sts appsv1.StatefulSet) error {
+	if statefulset.OrdinalsStart(sts) != 0 {
+		u, err := statefulset.ToUnstructured(sts)
+		if err != nil {
+			return err
+		}
+		return c.Create(context.TODO(), u)
+	}
 	return c.Create(context.TODO(), &sts)
 }
 
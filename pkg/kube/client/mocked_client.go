@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -60,12 +63,35 @@ func (m *mockedClient) Get(_ context.Context, key k8sClient.ObjectKey, obj k8sCl
 	return notFoundError()
 }
 
-func (m *mockedClient) Create(_ context.Context, obj k8sClient.Object, _ ...k8sClient.CreateOption) error {
+// fromUnstructured converts unstructured StatefulSets, which are sent to keep spec.ordinals, to their typed
+// representation, so that they can be retrieved as such.
+func fromUnstructured(obj k8sClient.Object) (k8sClient.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || u.GetKind() != "StatefulSet" {
+		return obj, nil
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, sts); err != nil {
+		return nil, err
+	}
+	return sts, nil
+}
+
+func (m *mockedClient) Create(_ context.Context, obj k8sClient.Object, opts ...k8sClient.CreateOption) error {
+	obj, err := fromUnstructured(obj)
+	if err != nil {
+		return err
+	}
 	relevantMap := m.ensureMapFor(obj)
 	objKey := k8sClient.ObjectKeyFromObject(obj)
 	if _, ok := relevantMap[objKey]; ok {
 		return alreadyExistsError()
 	}
+	createOpts := k8sClient.CreateOptions{}
+	createOpts.ApplyOptions(opts)
+	if len(createOpts.DryRun) > 0 {
+		return nil
+	}
 
 	switch v := obj.(type) {
 	case *appsv1.StatefulSet:
@@ -82,7 +108,37 @@ func makeStatefulSetReady(set *appsv1.StatefulSet) {
 	set.Status.ReadyReplicas = *set.Spec.Replicas
 }
 
-func (m *mockedClient) List(_ context.Context, _ k8sClient.ObjectList, _ ...k8sClient.ListOption) error {
+// List returns all objects of the list's item type which match the namespace and label selector
+// of the given options. Objects are sorted by namespace and name.
+func (m *mockedClient) List(_ context.Context, list k8sClient.ObjectList, opts ...k8sClient.ListOption) error {
+	listOpts := k8sClient.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	items := reflect.ValueOf(list).Elem().FieldByName("Items")
+	if !items.IsValid() {
+		return fmt.Errorf("list type %T does not have an Items field", list)
+	}
+
+	relevantMap := m.backingMap[reflect.PtrTo(items.Type().Elem())]
+	keys := make([]k8sClient.ObjectKey, 0, len(relevantMap))
+	for key, obj := range relevantMap {
+		if listOpts.Namespace != "" && key.Namespace != listOpts.Namespace {
+			continue
+		}
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	result := reflect.MakeSlice(items.Type(), 0, len(keys))
+	for _, key := range keys {
+		result = reflect.Append(result, reflect.ValueOf(relevantMap[key]).Elem())
+	}
+	items.Set(result)
 	return nil
 }
 
@@ -93,7 +149,16 @@ func (m *mockedClient) Delete(_ context.Context, obj k8sClient.Object, _ ...k8sC
 	return nil
 }
 
-func (m *mockedClient) Update(_ context.Context, obj k8sClient.Object, _ ...k8sClient.UpdateOption) error {
+func (m *mockedClient) Update(_ context.Context, obj k8sClient.Object, opts ...k8sClient.UpdateOption) error {
+	updateOpts := k8sClient.UpdateOptions{}
+	updateOpts.ApplyOptions(opts)
+	if len(updateOpts.DryRun) > 0 {
+		return nil
+	}
+	obj, err := fromUnstructured(obj)
+	if err != nil {
+		return err
+	}
 	relevantMap := m.ensureMapFor(obj)
 	objKey := k8sClient.ObjectKeyFromObject(obj)
 	relevantMap[objKey] = obj
@@ -106,7 +171,10 @@ type patchValue struct {
 	Value interface{} `json:"value"`
 }
 
-func (m *mockedClient) Patch(_ context.Context, obj k8sClient.Object, patch k8sClient.Patch, _ ...k8sClient.PatchOption) error {
+func (m *mockedClient) Patch(ctx context.Context, obj k8sClient.Object, patch k8sClient.Patch, _ ...k8sClient.PatchOption) error {
+	if patch.Type() == types.MergePatchType {
+		return m.mergePatchAnnotations(ctx, obj, patch)
+	}
 	if patch.Type() != types.JSONPatchType {
 		return fmt.Errorf("patch types different from JSONPatchType are not yet implemented")
 	}
@@ -147,12 +215,72 @@ func (m *mockedClient) Patch(_ context.Context, obj k8sClient.Object, patch k8sC
 	return nil
 }
 
+// mergePatchAnnotations applies a merge patch of the annotations to the stored object, and returns the patched
+// object in obj. Annotations with a null value are removed.
+func (m *mockedClient) mergePatchAnnotations(ctx context.Context, obj k8sClient.Object, patch k8sClient.Patch) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	mergePatch := map[string]map[string]map[string]*string{}
+	if err := json.Unmarshal(data, &mergePatch); err != nil {
+		return fmt.Errorf("merge patches of fields other than the annotations are not yet implemented: %s", err)
+	}
+	for field, value := range mergePatch {
+		if _, ok := value["annotations"]; field != "metadata" || !ok || len(value) != 1 {
+			return fmt.Errorf("merge patches of fields other than the annotations are not yet implemented")
+		}
+	}
+
+	stored, ok := m.ensureMapFor(obj)[k8sClient.ObjectKeyFromObject(obj)]
+	if !ok {
+		return notFoundError()
+	}
+	objectAnnotations := map[string]string{}
+	for key, val := range stored.GetAnnotations() {
+		objectAnnotations[key] = val
+	}
+	for key, val := range mergePatch["metadata"]["annotations"] {
+		if val == nil {
+			delete(objectAnnotations, key)
+		} else {
+			objectAnnotations[key] = *val
+		}
+	}
+	stored.SetAnnotations(objectAnnotations)
+	return m.Get(ctx, k8sClient.ObjectKeyFromObject(obj), obj)
+}
+
 func (m *mockedClient) DeleteAllOf(_ context.Context, _ k8sClient.Object, _ ...k8sClient.DeleteAllOfOption) error {
 	return nil
 }
 
 func (m *mockedClient) Status() k8sClient.StatusWriter {
-	return m
+	return mockedStatusWriter{m}
+}
+
+// mockedStatusWriter only updates the status of the stored objects, as the status subresource does.
+type mockedStatusWriter struct {
+	m *mockedClient
+}
+
+func (w mockedStatusWriter) Update(ctx context.Context, obj k8sClient.Object, opts ...k8sClient.UpdateOption) error {
+	relevantMap := w.m.ensureMapFor(obj)
+	objKey := k8sClient.ObjectKeyFromObject(obj)
+	stored, ok := relevantMap[objKey]
+	status := reflect.ValueOf(obj).Elem().FieldByName("Status")
+	if !ok || !status.IsValid() {
+		return w.m.Update(ctx, obj, opts...)
+	}
+	updated := reflect.New(reflect.TypeOf(stored).Elem())
+	updated.Elem().Set(reflect.ValueOf(stored).Elem())
+	updated.Elem().FieldByName("Status").Set(status)
+	relevantMap[objKey] = updated.Interface().(k8sClient.Object)
+	return nil
+}
+
+func (w mockedStatusWriter) Patch(ctx context.Context, obj k8sClient.Object, patch k8sClient.Patch, opts ...k8sClient.PatchOption) error {
+	return w.m.Patch(ctx, obj, patch, opts...)
 }
 
 func (m *mockedClient) RESTMapper() meta.RESTMapper {
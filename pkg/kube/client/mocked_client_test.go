@@ -8,7 +8,9 @@ import (
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestMockedClient(t *testing.T) {
@@ -44,3 +46,57 @@ func TestMockedClient(t *testing.T) {
 	assert.Equal(t, "svc-namespace", newSvc.Namespace)
 	assert.Equal(t, "svc-name", newSvc.Name)
 }
+
+func TestMockedClient_DryRun(t *testing.T) {
+	mockedClient := NewMockedClient()
+	cm := configmap.Builder().
+		SetName("cm-name").
+		SetNamespace("cm-namespace").
+		SetField("field-1", "value-1").
+		Build()
+
+	assert.NoError(t, mockedClient.Create(context.TODO(), &cm, k8sClient.DryRunAll))
+	err := mockedClient.Get(context.TODO(), types.NamespacedName{Name: "cm-name", Namespace: "cm-namespace"}, &corev1.ConfigMap{})
+	assert.True(t, apiErrors.IsNotFound(err), "dry-run requests are not persisted")
+
+	assert.NoError(t, mockedClient.Create(context.TODO(), &cm))
+	updated := cm.DeepCopy()
+	updated.Data["field-1"] = "value-2"
+	assert.NoError(t, mockedClient.Update(context.TODO(), updated, k8sClient.DryRunAll))
+	stored := corev1.ConfigMap{}
+	assert.NoError(t, mockedClient.Get(context.TODO(), types.NamespacedName{Name: "cm-name", Namespace: "cm-namespace"}, &stored))
+	assert.Equal(t, "value-1", stored.Data["field-1"])
+}
+
+func TestMockedClient_List(t *testing.T) {
+	mockedClient := NewMockedClient()
+
+	for _, cm := range []corev1.ConfigMap{
+		configmap.Builder().SetName("cm-b").SetNamespace("ns").Build(),
+		configmap.Builder().SetName("cm-a").SetNamespace("ns").Build(),
+		configmap.Builder().SetName("cm-c").SetNamespace("other-ns").Build(),
+	} {
+		cm := cm
+		if cm.Name == "cm-b" {
+			cm.Labels = map[string]string{"app": "test"}
+		}
+		assert.NoError(t, mockedClient.Create(context.TODO(), &cm))
+	}
+
+	cmList := corev1.ConfigMapList{}
+	err := mockedClient.List(context.TODO(), &cmList, k8sClient.InNamespace("ns"))
+	assert.NoError(t, err)
+	assert.Len(t, cmList.Items, 2)
+	assert.Equal(t, "cm-a", cmList.Items[0].Name)
+	assert.Equal(t, "cm-b", cmList.Items[1].Name)
+
+	err = mockedClient.List(context.TODO(), &cmList, k8sClient.MatchingLabels{"app": "test"})
+	assert.NoError(t, err)
+	assert.Len(t, cmList.Items, 1)
+	assert.Equal(t, "cm-b", cmList.Items[0].Name)
+
+	svcList := corev1.ServiceList{}
+	err = mockedClient.List(context.TODO(), &svcList)
+	assert.NoError(t, err)
+	assert.Len(t, svcList.Items, 0)
+}
@@ -7,6 +7,7 @@ import (
 
 type builder struct {
 	data            map[string]string
+	labels          map[string]string
 	name            string
 	namespace       string
 	ownerReferences []metav1.OwnerReference
@@ -32,12 +33,22 @@ func (b *builder) SetOwnerReferences(ownerReferences []metav1.OwnerReference) *b
 	return b
 }
 
+func (b *builder) SetLabels(labels map[string]string) *builder {
+	newLabels := make(map[string]string, len(labels))
+	for k, v := range labels {
+		newLabels[k] = v
+	}
+	b.labels = newLabels
+	return b
+}
+
 func (b builder) Build() corev1.ConfigMap {
 	return corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            b.name,
 			Namespace:       b.namespace,
 			OwnerReferences: b.ownerReferences,
+			Labels:          b.labels,
 		},
 		Data: b.data,
 	}
@@ -45,6 +56,7 @@ func (b builder) Build() corev1.ConfigMap {
 
 func Builder() *builder {
 	return &builder{
+		labels:          map[string]string{},
 		data:            map[string]string{},
 		ownerReferences: []metav1.OwnerReference{},
 	}
@@ -107,11 +107,12 @@ func WithServiceAccount(serviceAccountName string) Modification {
 	}
 }
 
-// WithVolume ensures the given volume exists
+// WithVolume ensures the given volume exists, replacing an existing volume with the same name
 func WithVolume(volume corev1.Volume) Modification {
 	return func(template *corev1.PodTemplateSpec) {
-		for _, v := range template.Spec.Volumes {
+		for i, v := range template.Spec.Volumes {
 			if v.Name == volume.Name {
+				template.Spec.Volumes[i] = volume
 				return
 			}
 		}
@@ -119,6 +120,31 @@ func WithVolume(volume corev1.Volume) Modification {
 	}
 }
 
+// RemoveVolume removes the volume with the given name, and the mounts of the volume from all containers
+func RemoveVolume(name string) Modification {
+	return func(template *corev1.PodTemplateSpec) {
+		volumes := template.Spec.Volumes[:0]
+		for _, v := range template.Spec.Volumes {
+			if v.Name != name {
+				volumes = append(volumes, v)
+			}
+		}
+		template.Spec.Volumes = volumes
+
+		for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+			for i := range containers {
+				mounts := containers[i].VolumeMounts[:0]
+				for _, m := range containers[i].VolumeMounts {
+					if m.Name != name {
+						mounts = append(mounts, m)
+					}
+				}
+				containers[i].VolumeMounts = mounts
+			}
+		}
+	}
+}
+
 func findIndexByName(name string, containers []corev1.Container) int {
 	for idx, c := range containers {
 		if c.Name == name {
@@ -442,3 +442,40 @@ func getCustomContainer() corev1.Container {
 		Image: "image-1",
 	}
 }
+
+func TestWithVolume_ReplacesVolumeWithTheSameName(t *testing.T) {
+	p := New(
+		WithVolume(corev1.Volume{Name: "volume", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "old"}}}}),
+		WithVolume(corev1.Volume{Name: "other-volume"}),
+	)
+
+	WithVolume(corev1.Volume{Name: "volume", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "new"}}})(&p)
+
+	assert.Len(t, p.Spec.Volumes, 2)
+	assert.Equal(t, "volume", p.Spec.Volumes[0].Name)
+	assert.Nil(t, p.Spec.Volumes[0].ConfigMap)
+	assert.Equal(t, "new", p.Spec.Volumes[0].Secret.SecretName)
+	assert.Equal(t, "other-volume", p.Spec.Volumes[1].Name)
+}
+
+func TestRemoveVolume(t *testing.T) {
+	p := New(
+		WithVolume(corev1.Volume{Name: "volume"}),
+		WithVolume(corev1.Volume{Name: "other-volume"}),
+		WithInitContainer("init-container", func(c *corev1.Container) {
+			c.VolumeMounts = []corev1.VolumeMount{{Name: "volume", MountPath: "/init"}}
+		}),
+		WithContainer("container", func(c *corev1.Container) {
+			c.VolumeMounts = []corev1.VolumeMount{{Name: "other-volume", MountPath: "/other"}, {Name: "volume", MountPath: "/volume"}}
+		}),
+	)
+
+	RemoveVolume("volume")(&p)
+
+	assert.Equal(t, []corev1.Volume{{Name: "other-volume"}}, p.Spec.Volumes)
+	assert.Empty(t, p.Spec.InitContainers[0].VolumeMounts)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "other-volume", MountPath: "/other"}}, p.Spec.Containers[0].VolumeMounts)
+
+	RemoveVolume("missing-volume")(&p)
+	assert.Len(t, p.Spec.Volumes, 1)
+}
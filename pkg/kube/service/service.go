@@ -2,6 +2,8 @@ package service
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -60,3 +62,24 @@ func Merge(dest corev1.Service, source corev1.Service) corev1.Service {
 	dest.Spec.ExternalTrafficPolicy = source.Spec.ExternalTrafficPolicy
 	return dest
 }
+
+// CreateOrUpdate creates the given Service if it doesn't exist, or merges it into the existing one otherwise,
+// which keeps the cluster IP and the node ports allocated to the existing Service.
+func CreateOrUpdate(getUpdateCreator GetUpdateCreator, svc corev1.Service) error {
+	existing, err := getUpdateCreator.GetService(types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return getUpdateCreator.CreateService(svc)
+		}
+		return err
+	}
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	if existing.Labels == nil {
+		existing.Labels = map[string]string{}
+	}
+	merged := Merge(existing, svc)
+	merged.Spec.Selector = svc.Spec.Selector
+	return getUpdateCreator.UpdateService(merged)
+}
@@ -1,11 +1,16 @@
 package statefulset
 
 import (
-	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/annotations"
+	"strconv"
+	"strings"
+
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/merge"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -14,6 +19,11 @@ import (
 
 const (
 	notFound = -1
+
+	// OrdinalsStartAnnotation holds spec.ordinals.start of a StatefulSet. The field is not part of the apps/v1
+	// types the operator is built against, so it is set from this annotation when the StatefulSet is created or
+	// updated, see ToUnstructured.
+	OrdinalsStartAnnotation = "mongodb.com/v1.ordinalsStart"
 )
 
 type Getter interface {
@@ -63,6 +73,24 @@ func CreateOrUpdate(getUpdateCreator GetUpdateCreator, sts appsv1.StatefulSet) (
 	return getUpdateCreator.UpdateStatefulSet(sts)
 }
 
+// IsImmutableFieldError returns true if the update of a StatefulSet was rejected because it changes fields which
+// can not be updated, such as the selector or the volume claim templates.
+func IsImmutableFieldError(err error) bool {
+	statusErr, ok := err.(apiErrors.APIStatus)
+	if !ok || !apiErrors.IsInvalid(err) || statusErr.Status().Details == nil {
+		return false
+	}
+	for _, cause := range statusErr.Status().Details.Causes {
+		if cause.Type == metav1.CauseType(field.ErrorTypeForbidden) && cause.Field == "spec" {
+			return true
+		}
+		if strings.Contains(cause.Message, "field is immutable") {
+			return true
+		}
+	}
+	return false
+}
+
 // GetAndUpdate applies the provided function to the most recent version of the object
 func GetAndUpdate(getUpdater GetUpdater, nsName types.NamespacedName, updateFunc func(*appsv1.StatefulSet)) (appsv1.StatefulSet, error) {
 	sts, err := getUpdater.GetStatefulSet(nsName)
@@ -261,6 +289,44 @@ func WithUpdateStrategyType(strategyType appsv1.StatefulSetUpdateStrategyType) M
 	}
 }
 
+// WithOrdinalsStart numbers the Pods of the StatefulSet from the given ordinal instead of 0.
+func WithOrdinalsStart(start int) Modification {
+	return func(set *appsv1.StatefulSet) {
+		if start == 0 {
+			delete(set.Annotations, OrdinalsStartAnnotation)
+			return
+		}
+		if set.Annotations == nil {
+			set.Annotations = map[string]string{}
+		}
+		set.Annotations[OrdinalsStartAnnotation] = strconv.Itoa(start)
+	}
+}
+
+// OrdinalsStart returns the ordinal of the first Pod of the StatefulSet.
+func OrdinalsStart(sts appsv1.StatefulSet) int {
+	start, err := strconv.Atoi(sts.Annotations[OrdinalsStartAnnotation])
+	if err != nil {
+		return 0
+	}
+	return start
+}
+
+// ToUnstructured returns the StatefulSet as an unstructured object with spec.ordinals.start set from
+// OrdinalsStartAnnotation, so that it is not reset when the StatefulSet is updated.
+func ToUnstructured(sts appsv1.StatefulSet) (*unstructured.Unstructured, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&sts)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("StatefulSet"))
+	if err := unstructured.SetNestedField(u.Object, int64(OrdinalsStart(sts)), "spec", "ordinals", "start"); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 func WithPodSpecTemplate(templateFunc func(*corev1.PodTemplateSpec)) Modification {
 	return func(set *appsv1.StatefulSet) {
 		template := &set.Spec.Template
@@ -305,14 +371,19 @@ func VolumeMountWithNameExists(mounts []corev1.VolumeMount, volumeName string) b
 }
 
 // ResetUpdateStrategy resets the statefulset update strategy to RollingUpdate.
-// If a version change is in progress, it doesn't do anything.
-func ResetUpdateStrategy(mdb annotations.Versioned, kubeClient GetUpdater) error {
-	if !mdb.IsChangingVersion() {
+// The StatefulSet itself is checked instead of whether a version change is in progress, so that
+// an OnDelete strategy left behind by an interrupted version change is reset as well.
+func ResetUpdateStrategy(nsName types.NamespacedName, kubeClient GetUpdater) error {
+	sts, err := kubeClient.GetStatefulSet(nsName)
+	if err != nil {
+		return err
+	}
+	if sts.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType {
 		return nil
 	}
 
 	// if we changed the version, we need to reset the UpdatePolicy back to OnUpdate
-	_, err := GetAndUpdate(kubeClient, mdb.NamespacedName(), func(sts *appsv1.StatefulSet) {
+	_, err = GetAndUpdate(kubeClient, nsName, func(sts *appsv1.StatefulSet) {
 		sts.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
 	})
 	return err
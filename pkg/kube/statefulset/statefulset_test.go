@@ -1,13 +1,17 @@
 package statefulset
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const (
@@ -205,3 +209,40 @@ func TestCreateVolumeMountWithMultipleOptions(t *testing.T) {
 	assert.Equal(t, mount.SubPath, "our-subpath")
 	assert.True(t, mount.ReadOnly)
 }
+
+func TestIsImmutableFieldError(t *testing.T) {
+	kind := appsv1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind()
+	specForbidden := apiErrors.NewInvalid(kind, TestName, field.ErrorList{
+		field.Forbidden(field.NewPath("spec"), "updates to statefulset spec for fields other than 'replicas', 'template' and 'updateStrategy' are forbidden"),
+	})
+	assert.True(t, IsImmutableFieldError(specForbidden))
+
+	selectorImmutable := apiErrors.NewInvalid(kind, TestName, field.ErrorList{
+		field.Invalid(field.NewPath("spec", "selector"), map[string]string{"app": "db"}, "field is immutable"),
+	})
+	assert.True(t, IsImmutableFieldError(selectorImmutable))
+
+	invalidReplicas := apiErrors.NewInvalid(kind, TestName, field.ErrorList{
+		field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+	})
+	assert.False(t, IsImmutableFieldError(invalidReplicas))
+	assert.False(t, IsImmutableFieldError(apiErrors.NewConflict(appsv1.Resource("statefulsets"), TestName, errors.New("modified"))))
+	assert.False(t, IsImmutableFieldError(errors.New("field is immutable")))
+}
+
+func TestToUnstructured_SetsOrdinalsStart(t *testing.T) {
+	sts := New(WithName(TestName), WithNamespace(TestNamespace), WithReplicas(3), WithOrdinalsStart(3))
+	assert.Equal(t, 3, OrdinalsStart(sts))
+
+	u, err := ToUnstructured(sts)
+	assert.NoError(t, err)
+	assert.Equal(t, "StatefulSet", u.GetKind())
+	start, found, err := unstructured.NestedInt64(u.Object, "spec", "ordinals", "start")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(3), start)
+
+	WithOrdinalsStart(0)(&sts)
+	assert.NotContains(t, sts.Annotations, OrdinalsStartAnnotation)
+	assert.Equal(t, 0, OrdinalsStart(sts))
+}
@@ -0,0 +1,112 @@
+package replication
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// appName identifies the connections of the status reader in the logs of the members.
+const appName = "mongodb-kubernetes-operator-replication-status"
+
+// Member states reported by replSetGetStatus.
+const (
+	StatePrimary    = "PRIMARY"
+	StateSecondary  = "SECONDARY"
+	StateRecovering = "RECOVERING"
+	StateRollback   = "ROLLBACK"
+)
+
+// Status is the part of the output of replSetGetStatus the operator uses.
+type Status struct {
+	Members []MemberStatus `bson:"members"`
+}
+
+// MemberStatus is the state of a member as seen by the member replSetGetStatus ran on.
+type MemberStatus struct {
+	// Name is the "<hostname>:<port>" of the member in the replica set configuration
+	Name string `bson:"name"`
+	// Health is 1 if the member is up, 0 otherwise
+	Health float64 `bson:"health"`
+	// StateStr is the replica set state of the member, e.g. PRIMARY or SECONDARY
+	StateStr string `bson:"stateStr"`
+	// Self is true for the member replSetGetStatus ran on.
+	Self bool `bson:"self"`
+	// OptimeDate is the time of the last operation applied by the member
+	OptimeDate time.Time `bson:"optimeDate"`
+	// LastHeartbeat is when the member replSetGetStatus ran on last received a heartbeat response from the
+	// member. It is not reported for the member replSetGetStatus ran on.
+	LastHeartbeat time.Time `bson:"lastHeartbeat"`
+}
+
+// Primary returns the status of the primary, or false if there is no primary.
+func (s Status) Primary() (MemberStatus, bool) {
+	for _, m := range s.Members {
+		if m.StateStr == StatePrimary {
+			return m, true
+		}
+	}
+	return MemberStatus{}, false
+}
+
+// Self returns the status of the member replSetGetStatus ran on.
+func (s Status) Self() (MemberStatus, bool) {
+	for _, m := range s.Members {
+		if m.Self {
+			return m, true
+		}
+	}
+	return MemberStatus{}, false
+}
+
+// Member returns the status of the member with the given name, or false if it is not a member.
+func (s Status) Member(name string) (MemberStatus, bool) {
+	for _, m := range s.Members {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return MemberStatus{}, false
+}
+
+// StatusReader reads the state of the replica set.
+type StatusReader interface {
+	// ReplSetStatus connects directly to the member with the given connection string and returns the output
+	// of replSetGetStatus.
+	ReplSetStatus(ctx context.Context, connectionString string, tlsConfig *tls.Config) (Status, error)
+}
+
+// NewStatusReader returns a StatusReader which opens a single connection to the member for each call.
+func NewStatusReader() StatusReader {
+	return mongoStatusReader{}
+}
+
+type mongoStatusReader struct{}
+
+func (mongoStatusReader) ReplSetStatus(ctx context.Context, connectionString string, tlsConfig *tls.Config) (Status, error) {
+	opts := options.Client().
+		ApplyURI(connectionString).
+		SetAppName(appName).
+		SetDirect(true).
+		SetMaxPoolSize(1)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return Status{}, err
+	}
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	status := Status{}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}).Decode(&status); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
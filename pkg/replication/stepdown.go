@@ -0,0 +1,53 @@
+package replication
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// stepDownPeriod is how long the stepped down primary is not eligible to become primary again.
+const stepDownPeriod = 60 * time.Second
+
+// StepDowner hands over the primary role to another member.
+type StepDowner interface {
+	// StepDown connects directly to the primary with the given connection string and runs replSetStepDown,
+	// so that one of the secondaries is elected primary.
+	StepDown(ctx context.Context, connectionString string, tlsConfig *tls.Config) error
+}
+
+// NewStepDowner returns a StepDowner which opens a single connection to the primary for each call.
+func NewStepDowner() StepDowner {
+	return mongoStepDowner{}
+}
+
+type mongoStepDowner struct{}
+
+func (mongoStepDowner) StepDown(ctx context.Context, connectionString string, tlsConfig *tls.Config) error {
+	opts := options.Client().
+		ApplyURI(connectionString).
+		SetAppName(appName).
+		SetDirect(true).
+		SetMaxPoolSize(1)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetStepDown", Value: int(stepDownPeriod.Seconds())}}).Err()
+	// members before MongoDB 4.2 close all connections when they step down.
+	if mongo.IsNetworkError(err) {
+		return nil
+	}
+	return err
+}
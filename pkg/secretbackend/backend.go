@@ -0,0 +1,131 @@
+package secretbackend
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SecretBackendEnv selects the backend the credentials of MongoDB resources are read from.
+	SecretBackendEnv = "SECRET_BACKEND"
+
+	Kubernetes = "kubernetes"
+	Vault      = "vault"
+	CSI        = "csi"
+)
+
+// Backend reads the credentials of MongoDB resources, such as user passwords, keyfiles and TLS certificates,
+// from the store they are kept in. The contents are returned as a Secret so that they can be consumed
+// in the same way as native Secrets. A Backend returns a NotFound error for every Secret it does not store.
+type Backend interface {
+	secret.Getter
+}
+
+// FromEnv returns the Backend configured by the environment of the operator.
+// A nil Backend is returned if the credentials are stored in native Secrets.
+func FromEnv() (Backend, error) {
+	switch backend := os.Getenv(SecretBackendEnv); backend {
+	case "", Kubernetes:
+		return nil, nil
+	case Vault:
+		return newVaultBackendFromEnv()
+	case CSI:
+		return newCSIBackendFromEnv(), nil
+	default:
+		return nil, errors.Errorf("unknown secret backend %q, must be one of %s, %s or %s", backend, Kubernetes, Vault, CSI)
+	}
+}
+
+// Unavailable returns a Backend which fails to read any Secret with the given error.
+func Unavailable(err error) Backend {
+	return unavailable{err: err}
+}
+
+type unavailable struct {
+	err error
+}
+
+func (u unavailable) GetSecret(objectKey k8sClient.ObjectKey) (corev1.Secret, error) {
+	return corev1.Secret{}, errors.Errorf("secret backend is not available: %s", u.err)
+}
+
+// NewClient returns a client which reads Secrets from the given Backend and falls back to
+// Kubernetes for the Secrets not stored in it, such as the ones managed by the operator.
+// All writes go to Kubernetes.
+func NewClient(c kubernetesClient.Client, backend Backend) kubernetesClient.Client {
+	if backend == nil {
+		return c
+	}
+	return client{Client: c, backend: backend}
+}
+
+type client struct {
+	kubernetesClient.Client
+	backend Backend
+}
+
+func (c client) GetSecret(objectKey k8sClient.ObjectKey) (corev1.Secret, error) {
+	s, err := c.backend.GetSecret(objectKey)
+	if err == nil {
+		return s, nil
+	}
+	if !apiErrors.IsNotFound(err) {
+		return corev1.Secret{}, err
+	}
+	return c.Client.GetSecret(objectKey)
+}
+
+func notFound(objectKey k8sClient.ObjectKey) error {
+	return apiErrors.NewNotFound(corev1.Resource("secrets"), objectKey.Name)
+}
+
+func newSecret(objectKey k8sClient.ObjectKey, data map[string][]byte) corev1.Secret {
+	s := corev1.Secret{Data: data}
+	s.Name = objectKey.Name
+	s.Namespace = objectKey.Namespace
+	return s
+}
+
+const (
+	// RefreshIntervalEnv is the number of seconds after which a resource is reconciled again to pick up
+	// credentials changed in an external backend, as those changes can not be watched.
+	RefreshIntervalEnv     = "SECRET_BACKEND_REFRESH_INTERVAL"
+	defaultRefreshInterval = 5 * time.Minute
+)
+
+// RefreshIntervalFromEnv returns the interval in which resources using the given Backend are reconciled again.
+// Changes to native Secrets are watched, so no periodic reconciliation is required for them.
+// The default interval is returned alongside the error if the configured one is invalid.
+func RefreshIntervalFromEnv(backend Backend) (time.Duration, error) {
+	if backend == nil {
+		return 0, nil
+	}
+	value, ok := os.LookupEnv(RefreshIntervalEnv)
+	if !ok {
+		return defaultRefreshInterval, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return defaultRefreshInterval, errors.Errorf("%s must be a non-negative number of seconds, got %q", RefreshIntervalEnv, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ValidateEnv checks that the secret backend is configured correctly.
+func ValidateEnv() error {
+	backend, err := FromEnv()
+	if err != nil {
+		return err
+	}
+	_, err = RefreshIntervalFromEnv(backend)
+	return err
+}
@@ -0,0 +1,72 @@
+package secretbackend
+
+import (
+	"os"
+	"testing"
+
+	kubernetesClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/secret"
+	"github.com/stretchr/testify/assert"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFromEnv(t *testing.T) {
+	defer os.Unsetenv(SecretBackendEnv)
+
+	t.Run("Kubernetes is the default backend", func(t *testing.T) {
+		os.Unsetenv(SecretBackendEnv)
+		backend, err := FromEnv()
+		assert.NoError(t, err)
+		assert.Nil(t, backend)
+	})
+	t.Run("CSI backend", func(t *testing.T) {
+		os.Setenv(SecretBackendEnv, CSI)
+		backend, err := FromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, NewCSIBackend(defaultCSISecretsPath), backend)
+	})
+	t.Run("Vault backend requires an address", func(t *testing.T) {
+		os.Setenv(SecretBackendEnv, Vault)
+		_, err := FromEnv()
+		assert.Error(t, err)
+	})
+	t.Run("Unknown backend", func(t *testing.T) {
+		os.Setenv(SecretBackendEnv, "aws")
+		_, err := FromEnv()
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_FallsBackToKubernetes(t *testing.T) {
+	dir := t.TempDir()
+	writeSecretFiles(t, dir, "my-ns", "my-secret", map[string]string{"password": "from-backend"})
+
+	kubeClient := kubernetesClient.NewClient(kubernetesClient.NewMockedClient())
+	for _, name := range []string{"my-secret", "other-secret"} {
+		s := secret.Builder().SetName(name).SetNamespace("my-ns").SetField("password", "from-kubernetes").Build()
+		assert.NoError(t, kubeClient.CreateSecret(s))
+	}
+
+	c := NewClient(kubeClient, NewCSIBackend(dir))
+
+	password, err := secret.ReadKey(c, "password", types.NamespacedName{Name: "my-secret", Namespace: "my-ns"})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-backend", password)
+
+	password, err = secret.ReadKey(c, "password", types.NamespacedName{Name: "other-secret", Namespace: "my-ns"})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-kubernetes", password)
+
+	_, err = c.GetSecret(types.NamespacedName{Name: "missing-secret", Namespace: "my-ns"})
+	assert.True(t, apiErrors.IsNotFound(err))
+}
+
+func TestClient_BackendErrorsAreReturned(t *testing.T) {
+	kubeClient := kubernetesClient.NewClient(kubernetesClient.NewMockedClient())
+	c := NewClient(kubeClient, Unavailable(assert.AnError))
+
+	_, err := c.GetSecret(types.NamespacedName{Name: "my-secret", Namespace: "my-ns"})
+	assert.Error(t, err)
+	assert.False(t, apiErrors.IsNotFound(err))
+}
@@ -0,0 +1,66 @@
+package secretbackend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+	corev1 "k8s.io/api/core/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CSISecretsPathEnv is the directory the secrets-store CSI driver mounts the credentials to.
+	CSISecretsPathEnv     = "CSI_SECRETS_PATH"
+	defaultCSISecretsPath = "/mnt/secrets-store"
+)
+
+// csiBackend reads Secrets from the files mounted into the operator pod by the secrets-store CSI driver.
+// Each key of a Secret is stored in the file <path>/<namespace>/<name>/<key>.
+type csiBackend struct {
+	path string
+}
+
+func newCSIBackendFromEnv() Backend {
+	return NewCSIBackend(envvar.GetEnvOrDefault(CSISecretsPathEnv, defaultCSISecretsPath))
+}
+
+// NewCSIBackend returns a Backend reading the Secrets mounted in the given directory.
+func NewCSIBackend(path string) Backend {
+	return csiBackend{path: path}
+}
+
+func (c csiBackend) GetSecret(objectKey k8sClient.ObjectKey) (corev1.Secret, error) {
+	dir := filepath.Join(c.path, objectKey.Namespace, objectKey.Name)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return corev1.Secret{}, notFound(objectKey)
+		}
+		return corev1.Secret{}, err
+	}
+
+	data := map[string][]byte{}
+	for _, f := range files {
+		// the driver updates the files atomically through hidden directories and symlinks.
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		fileName := filepath.Join(dir, f.Name())
+		info, err := os.Stat(fileName)
+		if err != nil {
+			return corev1.Secret{}, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		contents, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return corev1.Secret{}, err
+		}
+		data[f.Name()] = contents
+	}
+	return newSecret(objectKey, data), nil
+}
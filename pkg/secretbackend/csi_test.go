@@ -0,0 +1,40 @@
+package secretbackend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCSIBackend_GetSecret(t *testing.T) {
+	dir := t.TempDir()
+	writeSecretFiles(t, dir, "my-ns", "tls-secret", map[string]string{
+		"tls.crt": "CERT",
+		"tls.key": "KEY",
+	})
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "my-ns", "tls-secret", "..data"), 0755))
+
+	backend := NewCSIBackend(dir)
+
+	s, err := backend.GetSecret(types.NamespacedName{Name: "tls-secret", Namespace: "my-ns"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tls-secret", s.Name)
+	assert.Equal(t, "my-ns", s.Namespace)
+	assert.Equal(t, map[string][]byte{"tls.crt": []byte("CERT"), "tls.key": []byte("KEY")}, s.Data)
+
+	_, err = backend.GetSecret(types.NamespacedName{Name: "tls-secret", Namespace: "other-ns"})
+	assert.True(t, apiErrors.IsNotFound(err))
+}
+
+func writeSecretFiles(t *testing.T, dir, namespace, name string, data map[string]string) {
+	secretDir := filepath.Join(dir, namespace, name)
+	assert.NoError(t, os.MkdirAll(secretDir, 0755))
+	for k, v := range data {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(secretDir, k), []byte(v), 0600))
+	}
+}
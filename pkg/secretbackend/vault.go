@@ -0,0 +1,212 @@
+package secretbackend
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/util/envvar"
+	corev1 "k8s.io/api/core/v1"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	VaultAddressEnv    = "VAULT_ADDR"
+	VaultTokenEnv      = "VAULT_TOKEN"
+	VaultCACertEnv     = "VAULT_CACERT"
+	VaultRoleEnv       = "VAULT_ROLE"
+	VaultAuthPathEnv   = "VAULT_AUTH_PATH"
+	VaultMountEnv      = "VAULT_SECRET_MOUNT"
+	VaultPathPrefixEnv = "VAULT_SECRET_PATH_PREFIX"
+
+	defaultVaultAuthPath   = "kubernetes"
+	defaultVaultMount      = "secret"
+	defaultVaultPathPrefix = "mongodbcommunity"
+
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint
+)
+
+// VaultConfig configures the access to the KV version 2 secrets engine of Vault.
+type VaultConfig struct {
+	// Address is the URL of the Vault server.
+	Address string
+	// Token authenticates the operator. If empty, the operator logs in with the Kubernetes auth method as Role.
+	Token    string
+	Role     string
+	AuthPath string
+	// Mount is the path the KV secrets engine is mounted at.
+	Mount string
+	// PathPrefix is prepended to <namespace>/<name> to get the path a Secret is stored at.
+	PathPrefix string
+	// ServiceAccountTokenPath is the file the JWT used by the Kubernetes auth method is read from.
+	ServiceAccountTokenPath string
+	HTTPClient              *http.Client
+}
+
+type vaultBackend struct {
+	config VaultConfig
+
+	mu    sync.Mutex
+	token string
+}
+
+func newVaultBackendFromEnv() (Backend, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	if caFile := os.Getenv(VaultCACertEnv); caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Errorf("could not read the Vault CA certificate: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("no certificate found in %s", caFile)
+		}
+		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}
+	}
+
+	return NewVaultBackend(VaultConfig{
+		Address:                 os.Getenv(VaultAddressEnv),
+		Token:                   os.Getenv(VaultTokenEnv),
+		Role:                    os.Getenv(VaultRoleEnv),
+		AuthPath:                envvar.GetEnvOrDefault(VaultAuthPathEnv, defaultVaultAuthPath),
+		Mount:                   envvar.GetEnvOrDefault(VaultMountEnv, defaultVaultMount),
+		PathPrefix:              envvar.GetEnvOrDefault(VaultPathPrefixEnv, defaultVaultPathPrefix),
+		ServiceAccountTokenPath: serviceAccountTokenPath,
+		HTTPClient:              httpClient,
+	})
+}
+
+// NewVaultBackend returns a Backend reading the Secrets stored in Vault.
+func NewVaultBackend(config VaultConfig) (Backend, error) {
+	if config.Address == "" {
+		return nil, errors.Errorf("%s must be set to use the Vault secret backend", VaultAddressEnv)
+	}
+	if config.Token == "" && config.Role == "" {
+		return nil, errors.Errorf("either %s or %s must be set to use the Vault secret backend", VaultTokenEnv, VaultRoleEnv)
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.Address = strings.TrimRight(config.Address, "/")
+	return &vaultBackend{config: config, token: config.Token}, nil
+}
+
+func (v *vaultBackend) GetSecret(objectKey k8sClient.ObjectKey) (corev1.Secret, error) {
+	path := strings.Trim(fmt.Sprintf("%s/data/%s/%s/%s", v.config.Mount, v.config.PathPrefix, objectKey.Namespace, objectKey.Name), "/")
+
+	resp, err := v.read(path)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return corev1.Secret{}, notFound(objectKey)
+	default:
+		return corev1.Secret{}, errors.Errorf("could not read %s from Vault: %s", path, resp.Status)
+	}
+
+	body := struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return corev1.Secret{}, errors.Errorf("could not decode %s: %s", path, err)
+	}
+
+	data := map[string][]byte{}
+	for k, v := range body.Data.Data {
+		if s, ok := v.(string); ok {
+			data[k] = []byte(s)
+			continue
+		}
+		return corev1.Secret{}, errors.Errorf("the value of %s in %s is not a string", k, path)
+	}
+	return newSecret(objectKey, data), nil
+}
+
+// read reads the given path, logging in again if the current token has expired.
+func (v *vaultBackend) read(path string) (*http.Response, error) {
+	token, err := v.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.get(path, token)
+	if err != nil || resp.StatusCode != http.StatusForbidden || v.config.Role == "" {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	v.mu.Lock()
+	v.token = ""
+	v.mu.Unlock()
+	if token, err = v.getToken(); err != nil {
+		return nil, err
+	}
+	return v.get(path, token)
+}
+
+func (v *vaultBackend) get(path, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s", v.config.Address, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	return v.config.HTTPClient.Do(req)
+}
+
+// getToken returns the current token, logging in with the Kubernetes auth method if there is none.
+func (v *vaultBackend) getToken() (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.token != "" {
+		return v.token, nil
+	}
+
+	jwt, err := ioutil.ReadFile(v.config.ServiceAccountTokenPath)
+	if err != nil {
+		return "", errors.Errorf("could not read service account token: %s", err)
+	}
+	payload, err := json.Marshal(map[string]string{"role": v.config.Role, "jwt": strings.TrimSpace(string(jwt))})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/v1/auth/%s/login", v.config.Address, strings.Trim(v.config.AuthPath, "/"))
+	resp, err := v.config.HTTPClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", errors.Errorf("could not log in to Vault: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("could not log in to Vault as role %s: %s", v.config.Role, resp.Status)
+	}
+
+	body := struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", errors.Errorf("could not decode Vault login response: %s", err)
+	}
+	if body.Auth.ClientToken == "" {
+		return "", errors.New("Vault login response did not contain a token")
+	}
+	v.token = body.Auth.ClientToken
+	return v.token, nil
+}
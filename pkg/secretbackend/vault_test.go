@@ -0,0 +1,94 @@
+package secretbackend
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// newFakeVault returns a Vault server storing a single secret, which only accepts validToken.
+func newFakeVault(t *testing.T, validToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			body := map[string]string{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body["role"] != "mongodb-operator" || body["jwt"] != "service-account-jwt" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "` + validToken + `"}}`))
+		case "/v1/secret/data/mongodbcommunity/my-ns/my-user-password":
+			if r.Header.Get("X-Vault-Token") != validToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "vault-password"}, "metadata": {"version": 2}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVaultBackend_GetSecret(t *testing.T) {
+	server := newFakeVault(t, "root-token")
+	defer server.Close()
+
+	backend, err := NewVaultBackend(VaultConfig{
+		Address:    server.URL,
+		Token:      "root-token",
+		Mount:      defaultVaultMount,
+		PathPrefix: defaultVaultPathPrefix,
+	})
+	assert.NoError(t, err)
+
+	s, err := backend.GetSecret(types.NamespacedName{Name: "my-user-password", Namespace: "my-ns"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"password": []byte("vault-password")}, s.Data)
+
+	_, err = backend.GetSecret(types.NamespacedName{Name: "other-password", Namespace: "my-ns"})
+	assert.True(t, apiErrors.IsNotFound(err))
+}
+
+func TestVaultBackend_KubernetesAuth(t *testing.T) {
+	server := newFakeVault(t, "login-token")
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(tokenPath, []byte("service-account-jwt\n"), 0600))
+
+	backend, err := NewVaultBackend(VaultConfig{
+		Address:                 server.URL,
+		Role:                    "mongodb-operator",
+		AuthPath:                defaultVaultAuthPath,
+		Mount:                   defaultVaultMount,
+		PathPrefix:              defaultVaultPathPrefix,
+		ServiceAccountTokenPath: tokenPath,
+	})
+	assert.NoError(t, err)
+
+	t.Run("Operator logs in before reading", func(t *testing.T) {
+		s, err := backend.GetSecret(types.NamespacedName{Name: "my-user-password", Namespace: "my-ns"})
+		assert.NoError(t, err)
+		assert.Equal(t, "vault-password", string(s.Data["password"]))
+	})
+	t.Run("Operator logs in again when the token expired", func(t *testing.T) {
+		backend.(*vaultBackend).token = "expired-token"
+		s, err := backend.GetSecret(types.NamespacedName{Name: "my-user-password", Namespace: "my-ns"})
+		assert.NoError(t, err)
+		assert.Equal(t, "vault-password", string(s.Data["password"]))
+		assert.Equal(t, "login-token", backend.(*vaultBackend).token)
+	})
+}
+
+func TestNewVaultBackend_RequiresCredentials(t *testing.T) {
+	_, err := NewVaultBackend(VaultConfig{Address: "https://vault:8200"})
+	assert.Error(t, err)
+}
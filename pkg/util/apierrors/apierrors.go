@@ -6,6 +6,9 @@ import "strings"
 // in this case we just want to retry but not log it as an error.
 var objectModifiedText = "the object has been modified; please apply your changes to the latest version and try again"
 
+// namespaceTerminatingText is part of the error returned when creating an object in a namespace which is being deleted.
+var namespaceTerminatingText = "because it is being terminated"
+
 // IsTransientError returns a boolean indicating if a given error is transient.
 func IsTransientError(err error) bool {
 	return IsTransientMessage(err.Error())
@@ -15,3 +18,9 @@ func IsTransientError(err error) bool {
 func IsTransientMessage(msg string) bool {
 	return strings.Contains(strings.ToLower(msg), objectModifiedText)
 }
+
+// IsNamespaceTerminatingError returns a boolean indicating if a given error was caused by creating an object in
+// a namespace which is being deleted.
+func IsNamespaceTerminatingError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), namespaceTerminatingText)
+}
@@ -35,3 +35,29 @@ func TestIsTransientError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsNamespaceTerminatingError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			"Test Namespace terminating error",
+			fmt.Errorf("error creating StatefulSet: statefulsets.apps \"mdb0\" is forbidden: unable to create new content in namespace mongodb because it is being terminated"),
+			true,
+		},
+		{
+			"Test Other forbidden error",
+			fmt.Errorf("statefulsets.apps \"mdb0\" is forbidden: exceeded quota: compute-resources"),
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNamespaceTerminatingError(tt.err); got != tt.want {
+				t.Errorf("IsNamespaceTerminatingError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Resolver resolves hostnames to addresses and looks up the SRV and TXT records used by
+// mongodb+srv:// connection strings.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewResolver returns a Resolver which sends all queries to the DNS server with the given
+// address, in "host" or "host:port" format. This makes it possible to resolve cluster hostnames
+// when the operator runs outside of the cluster or in a split DNS environment.
+// If the address is empty, the resolver of the host the operator is running on is used.
+func NewResolver(serverAddress string) Resolver {
+	if serverAddress == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(serverAddress); err != nil {
+		serverAddress = net.JoinHostPort(serverAddress, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, serverAddress)
+		},
+	}
+}
+
+// UnresolvableHostnames returns the hostnames which the given Resolver can not resolve.
+func UnresolvableHostnames(ctx context.Context, resolver Resolver, hostnames []string) []string {
+	var unresolvable []string
+	for _, hostname := range hostnames {
+		if addrs, err := resolver.LookupHost(ctx, hostname); err != nil || len(addrs) == 0 {
+			unresolvable = append(unresolvable, hostname)
+		}
+	}
+	return unresolvable
+}
+
+// VerifySRV checks that drivers can connect with a mongodb+srv:// connection string for the given host.
+// The SRV records of the host must point to the given port of hosts in its parent domain, and it must have at
+// most one TXT record.
+func VerifySRV(ctx context.Context, resolver Resolver, host string, port uint16) error {
+	_, records, err := resolver.LookupSRV(ctx, "mongodb", "tcp", host)
+	if err != nil {
+		return errors.Errorf("could not look up the SRV records of %s: %s", host, err)
+	}
+	if len(records) == 0 {
+		return errors.Errorf("%s has no SRV records", host)
+	}
+
+	parentDomain := host[strings.Index(host, ".")+1:]
+	for _, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		if !strings.HasSuffix(target, "."+parentDomain) {
+			return errors.Errorf("the SRV record %s of %s is not in the domain %s", target, host, parentDomain)
+		}
+		if record.Port != port {
+			return errors.Errorf("the SRV record %s of %s has the port %d, mongod listens on port %d", target, host, record.Port, port)
+		}
+	}
+
+	txt, err := resolver.LookupTXT(ctx, host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil
+		}
+		return errors.Errorf("could not look up the TXT records of %s: %s", host, err)
+	}
+	if len(txt) > 1 {
+		return errors.Errorf("%s has %d TXT records, drivers only accept one", host, len(txt))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockResolver maps hostnames to their addresses, "_service._proto.name" to the targets of the SRV records
+// and "TXT name" to the TXT records.
+type mockResolver map[string][]string
+
+func (m mockResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := m[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (m mockResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	targets, ok := m[fmt.Sprintf("_%s._%s.%s", service, proto, name)]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	var records []*net.SRV
+	for _, target := range targets {
+		records = append(records, &net.SRV{Target: target, Port: 27017})
+	}
+	return name, records, nil
+}
+
+func (m mockResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if txt, ok := m["TXT "+name]; ok {
+		return txt, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestNewResolver(t *testing.T) {
+	assert.Equal(t, net.DefaultResolver, NewResolver(""))
+
+	resolver, ok := NewResolver("10.96.0.10").(*net.Resolver)
+	assert.True(t, ok)
+	assert.True(t, resolver.PreferGo)
+	assert.NotNil(t, resolver.Dial)
+}
+
+func TestNewResolver_UsesConfiguredServer(t *testing.T) {
+	// nothing listens on this address, the lookup must fail rather than fall back to the host resolver.
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.LocalAddr().String()
+	assert.NoError(t, listener.Close())
+
+	_, err = NewResolver(address).LookupHost(context.TODO(), "localhost.example.")
+	assert.Error(t, err)
+}
+
+func TestUnresolvableHostnames(t *testing.T) {
+	resolver := mockResolver{
+		"my-rs-0.my-rs-svc.my-ns.svc.cluster.local": {"10.0.0.1"},
+		"my-rs-2.my-rs-svc.my-ns.svc.cluster.local": {},
+	}
+	hostnames := []string{
+		"my-rs-0.my-rs-svc.my-ns.svc.cluster.local",
+		"my-rs-1.my-rs-svc.my-ns.svc.cluster.local",
+		"my-rs-2.my-rs-svc.my-ns.svc.cluster.local",
+	}
+	assert.Equal(t, []string{"my-rs-1.my-rs-svc.my-ns.svc.cluster.local", "my-rs-2.my-rs-svc.my-ns.svc.cluster.local"}, UnresolvableHostnames(context.TODO(), resolver, hostnames))
+	assert.Empty(t, UnresolvableHostnames(context.TODO(), resolver, hostnames[:1]))
+}
+
+func TestVerifySRV(t *testing.T) {
+	host := "my-rs-svc.my-ns.svc.cluster.local"
+	resolver := mockResolver{}
+	assert.Error(t, VerifySRV(context.TODO(), resolver, host, 27017), "the Service has no named mongodb port")
+
+	resolver["_mongodb._tcp."+host] = []string{
+		"my-rs-0.my-rs-svc.my-ns.svc.cluster.local.",
+		"my-rs-1.my-rs-svc.my-ns.svc.cluster.local.",
+	}
+	assert.NoError(t, VerifySRV(context.TODO(), resolver, host, 27017))
+	assert.Error(t, VerifySRV(context.TODO(), resolver, host, 27018), "the records must point to the port of mongod")
+
+	resolver["TXT "+host] = []string{"authSource=admin", "replicaSet=my-rs"}
+	assert.Error(t, VerifySRV(context.TODO(), resolver, host, 27017))
+	resolver["TXT "+host] = []string{"replicaSet=my-rs"}
+	assert.NoError(t, VerifySRV(context.TODO(), resolver, host, 27017))
+
+	resolver["_mongodb._tcp."+host] = []string{"my-rs-0.example.com."}
+	assert.Error(t, VerifySRV(context.TODO(), resolver, host, 27017), "drivers reject targets outside of the parent domain")
+
+	resolver["_mongodb._tcp."+host] = []string{}
+	assert.Error(t, VerifySRV(context.TODO(), resolver, host, 27017))
+}
@@ -0,0 +1,135 @@
+package generate
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CharacterClass is a class of characters a generated password can be required to contain.
+type CharacterClass string
+
+const (
+	Lowercase CharacterClass = "lowercase"
+	Uppercase CharacterClass = "uppercase"
+	Digits    CharacterClass = "digits"
+	// Symbols excludes quotes, backslashes and backticks, which break naive quoting in scripts and configuration files.
+	Symbols CharacterClass = "symbols"
+)
+
+var characterClasses = map[CharacterClass]string{
+	Lowercase: "abcdefghijklmnopqrstuvwxyz",
+	Uppercase: "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	Digits:    "0123456789",
+	Symbols:   "!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// fipsEnabledPath reports whether the kernel runs in FIPS mode, in which the kernel random number generator, which
+// crypto/rand reads from, is a FIPS 140 approved DRBG.
+var fipsEnabledPath = "/proc/sys/crypto/fips_enabled"
+
+// PasswordPolicy defines the passwords generated by the operator.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters of a password. Passwords are generated with the length
+	// requested by the caller if it is longer.
+	MinLength int
+
+	// CharacterClasses are the classes every password contains at least one character of, the characters of a
+	// password are drawn from all of them. If empty, passwords are URL-safe base64 strings.
+	CharacterClasses []CharacterClass
+
+	// RequireFIPS refuses to generate passwords unless the random number generator is FIPS 140 approved.
+	RequireFIPS bool
+}
+
+// Validate checks that the policy can be satisfied.
+func (p PasswordPolicy) Validate() error {
+	if p.MinLength < 0 {
+		return errors.Errorf("the minimum password length must not be negative, got %d", p.MinLength)
+	}
+	for _, class := range p.CharacterClasses {
+		if _, ok := characterClasses[class]; !ok {
+			return errors.Errorf("unknown character class %q, must be one of lowercase, uppercase, digits and symbols", class)
+		}
+	}
+	if p.MinLength > 0 && p.MinLength < len(p.CharacterClasses) {
+		return errors.Errorf("a password of %d characters can not contain all of the %d character classes", p.MinLength, len(p.CharacterClasses))
+	}
+	if p.RequireFIPS && !fipsEnabled() {
+		return errors.Errorf("a FIPS approved random number generator is required, but the kernel does not run in FIPS mode (%s)", fipsEnabledPath)
+	}
+	return nil
+}
+
+func fipsEnabled() bool {
+	contents, err := ioutil.ReadFile(fipsEnabledPath)
+	return err == nil && strings.TrimSpace(string(contents)) == "1"
+}
+
+// PasswordGenerator generates the passwords of the credentials the operator creates.
+type PasswordGenerator interface {
+	// Password returns a new password of at least the given length.
+	Password(length int) (string, error)
+}
+
+// NewPasswordGenerator returns a generator of passwords satisfying the given policy, which must be valid.
+func NewPasswordGenerator(policy PasswordPolicy) PasswordGenerator {
+	return policyGenerator{policy: policy}
+}
+
+type policyGenerator struct {
+	policy PasswordPolicy
+}
+
+func (g policyGenerator) Password(length int) (string, error) {
+	if g.policy.RequireFIPS && !fipsEnabled() {
+		return "", errors.New("the kernel no longer runs in FIPS mode")
+	}
+	if g.policy.MinLength > length {
+		length = g.policy.MinLength
+	}
+	if len(g.policy.CharacterClasses) == 0 {
+		return RandomFixedLengthStringOfSize(length)
+	}
+
+	alphabet := ""
+	for _, class := range g.policy.CharacterClasses {
+		alphabet += characterClasses[class]
+	}
+	// passwords missing a class are drawn again rather than patched, so that every character stays uniformly
+	// distributed.
+	for {
+		password, err := randomString(alphabet, length)
+		if err != nil {
+			return "", err
+		}
+		if containsAll(password, g.policy.CharacterClasses) {
+			return password, nil
+		}
+	}
+}
+
+func randomString(alphabet string, length int) (string, error) {
+	b := strings.Builder{}
+	max := big.NewInt(int64(len(alphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(alphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func containsAll(password string, classes []CharacterClass) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(password, characterClasses[class]) {
+			return false
+		}
+	}
+	return true
+}
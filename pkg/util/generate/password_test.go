@@ -0,0 +1,73 @@
+package generate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordGenerator_WithoutClassesGeneratesBase64Passwords(t *testing.T) {
+	password, err := NewPasswordGenerator(PasswordPolicy{}).Password(20)
+	assert.NoError(t, err)
+	assert.Len(t, password, 20)
+}
+
+func TestPasswordGenerator_EnforcesTheMinimumLength(t *testing.T) {
+	password, err := NewPasswordGenerator(PasswordPolicy{MinLength: 40}).Password(20)
+	assert.NoError(t, err)
+	assert.Len(t, password, 40)
+
+	password, err = NewPasswordGenerator(PasswordPolicy{MinLength: 10}).Password(20)
+	assert.NoError(t, err)
+	assert.Len(t, password, 20)
+}
+
+func TestPasswordGenerator_ContainsEveryCharacterClass(t *testing.T) {
+	generator := NewPasswordGenerator(PasswordPolicy{CharacterClasses: []CharacterClass{Lowercase, Uppercase, Digits, Symbols}})
+	for i := 0; i < 100; i++ {
+		password, err := generator.Password(8)
+		assert.NoError(t, err)
+		assert.Len(t, password, 8)
+		for _, class := range []CharacterClass{Lowercase, Uppercase, Digits, Symbols} {
+			assert.True(t, strings.ContainsAny(password, characterClasses[class]), "%q has no %s", password, class)
+		}
+	}
+}
+
+func TestPasswordGenerator_OnlyUsesTheGivenClasses(t *testing.T) {
+	password, err := NewPasswordGenerator(PasswordPolicy{CharacterClasses: []CharacterClass{Digits}}).Password(32)
+	assert.NoError(t, err)
+	assert.Equal(t, "", strings.Trim(password, characterClasses[Digits]))
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	assert.NoError(t, PasswordPolicy{}.Validate())
+	assert.NoError(t, PasswordPolicy{MinLength: 4, CharacterClasses: []CharacterClass{Lowercase, Uppercase, Digits, Symbols}}.Validate())
+
+	assert.EqualError(t, PasswordPolicy{MinLength: -1}.Validate(), "the minimum password length must not be negative, got -1")
+	assert.EqualError(t, PasswordPolicy{CharacterClasses: []CharacterClass{"emoji"}}.Validate(), `unknown character class "emoji", must be one of lowercase, uppercase, digits and symbols`)
+	assert.EqualError(t, PasswordPolicy{MinLength: 2, CharacterClasses: []CharacterClass{Lowercase, Uppercase, Digits}}.Validate(), "a password of 2 characters can not contain all of the 3 character classes")
+}
+
+func TestPasswordPolicy_RequireFIPS(t *testing.T) {
+	defer func(path string) { fipsEnabledPath = path }(fipsEnabledPath)
+	fipsEnabledPath = filepath.Join(t.TempDir(), "fips_enabled")
+
+	policy := PasswordPolicy{RequireFIPS: true}
+	assert.Error(t, policy.Validate(), "the kernel FIPS mode can not be read")
+
+	assert.NoError(t, ioutil.WriteFile(fipsEnabledPath, []byte("0\n"), 0644))
+	assert.Error(t, policy.Validate())
+
+	assert.NoError(t, ioutil.WriteFile(fipsEnabledPath, []byte("1\n"), 0644))
+	assert.NoError(t, policy.Validate())
+	_, err := NewPasswordGenerator(policy).Password(20)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(fipsEnabledPath, []byte("0\n"), 0644))
+	_, err = NewPasswordGenerator(policy).Password(20)
+	assert.Error(t, err)
+}
@@ -0,0 +1,221 @@
+package loglevel
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the log level configuration of the operator. It is read from a YAML file, e.g.
+//
+//	level: info
+//	loggers:
+//	  agent: debug
+//
+// Level applies to all log entries, unless the logger which wrote them has a level in Loggers.
+type Config struct {
+	Level   string            `json:"level,omitempty"`
+	Loggers map[string]string `json:"loggers,omitempty"`
+}
+
+// Levels holds the log level of the operator and the levels of individual named loggers.
+// The levels can be changed while the operator is running.
+type Levels struct {
+	mu           sync.RWMutex
+	defaultLevel zapcore.Level
+	loggers      map[string]zapcore.Level
+}
+
+// New returns Levels which enable the given level for all loggers.
+func New(defaultLevel zapcore.Level) *Levels {
+	return &Levels{defaultLevel: defaultLevel, loggers: map[string]zapcore.Level{}}
+}
+
+// Apply replaces the current levels with the ones in the given Config. The current levels are
+// kept if any of the levels is invalid. An empty Level keeps the current default level.
+func (l *Levels) Apply(config Config) error {
+	l.mu.RLock()
+	defaultLevel := l.defaultLevel
+	l.mu.RUnlock()
+
+	if config.Level != "" {
+		if err := defaultLevel.UnmarshalText([]byte(config.Level)); err != nil {
+			return errors.Errorf("invalid log level %q", config.Level)
+		}
+	}
+	loggers := map[string]zapcore.Level{}
+	for name, value := range config.Loggers {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return errors.Errorf("invalid log level %q for logger %q", value, name)
+		}
+		loggers[name] = level
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultLevel = defaultLevel
+	l.loggers = loggers
+	return nil
+}
+
+// Enabled returns true if entries of the given level written by the logger with the given name are logged.
+// Names of nested loggers are separated by dots, the innermost name with a configured level applies.
+func (l *Levels) Enabled(loggerName string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	names := strings.Split(loggerName, ".")
+	for i := len(names) - 1; i >= 0; i-- {
+		if loggerLevel, ok := l.loggers[names[i]]; ok {
+			return loggerLevel.Enabled(level)
+		}
+	}
+	return l.defaultLevel.Enabled(level)
+}
+
+// minLevel returns the lowest level enabled for any logger.
+func (l *Levels) minLevel() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	min := l.defaultLevel
+	for _, level := range l.loggers {
+		if level < min {
+			min = level
+		}
+	}
+	return min
+}
+
+// WrapCore wraps the given zapcore.Core so that it only receives the entries enabled by the Levels.
+// It can be used with zap.WrapCore when configuring a logger.
+func (l *Levels) WrapCore(c zapcore.Core) zapcore.Core {
+	return core{Core: c, levels: l}
+}
+
+// Watch applies the configuration in the given file whenever the operator receives SIGHUP, and whenever the
+// contents of the file change, which are checked in the given interval. This makes it possible to mount the
+// file from a ConfigMap. Watch returns when the context is done.
+func (l *Levels) Watch(ctx context.Context, path string, interval time.Duration, log *zap.SugaredLogger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var applied []byte
+	reload := func(force bool) {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Warnf("Could not read log level configuration %s: %s", path, err)
+			return
+		}
+		if !force && string(contents) == string(applied) {
+			return
+		}
+		applied = contents
+
+		config := Config{}
+		if err := yaml.Unmarshal(contents, &config); err != nil {
+			log.Warnf("Could not parse log level configuration %s: %s", path, err)
+			return
+		}
+		if err := l.Apply(config); err != nil {
+			log.Warnf("Could not apply log level configuration %s: %s", path, err)
+			return
+		}
+		log.Infof("Applied log level configuration: level=%q, loggers=%v", config.Level, config.Loggers)
+	}
+
+	reload(true)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reload(true)
+		case <-ticker.C:
+			reload(false)
+		}
+	}
+}
+
+// debugUntilKey is the key of the field added by DebugUntil.
+const debugUntilKey = "debugUntil"
+
+// levelKey is the key of the field added by Level.
+const levelKey = "logLevel"
+
+// DebugUntil returns a field which makes the logger it is added to log entries of all levels, regardless of the
+// configured levels. The field records the given deadline, the caller stops adding it once the deadline passed.
+func DebugUntil(deadline time.Time) zap.Field {
+	return zap.Time(debugUntilKey, deadline)
+}
+
+// Level returns a field which makes the logger it is added to log the entries of the given level and above,
+// regardless of the configured levels. If a logger has several such fields, the lowest level applies.
+func Level(level zapcore.Level) zap.Field {
+	return zap.String(levelKey, level.String())
+}
+
+// core is a zapcore.Core which drops the entries not enabled by the Levels
+// before they are passed on to the wrapped Core.
+type core struct {
+	zapcore.Core
+	levels *Levels
+	// override is set on the loggers with a DebugUntil or a Level field, which bypass the Levels.
+	override *zapcore.Level
+}
+
+// Enabled implements zapcore.Core
+func (c core) Enabled(level zapcore.Level) bool {
+	if c.override != nil {
+		return c.override.Enabled(level) && c.Core.Enabled(level)
+	}
+	return level >= c.levels.minLevel() && c.Core.Enabled(level)
+}
+
+// With implements zapcore.Core
+func (c core) With(fields []zapcore.Field) zapcore.Core {
+	override := c.override
+	for _, field := range fields {
+		level := zapcore.DebugLevel
+		switch field.Key {
+		case debugUntilKey:
+		case levelKey:
+			if err := level.UnmarshalText([]byte(field.String)); err != nil {
+				continue
+			}
+		default:
+			continue
+		}
+		if override == nil || level < *override {
+			override = &level
+		}
+	}
+	return core{Core: c.Core.With(fields), levels: c.levels, override: override}
+}
+
+// Check implements zapcore.Core
+func (c core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.override != nil {
+		if !c.override.Enabled(entry.Level) {
+			return checked
+		}
+	} else if !c.levels.Enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,112 @@
+package loglevel
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger(levels *Levels) (*zap.SugaredLogger, *observer.ObservedLogs) {
+	observedCore, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(levels.WrapCore(observedCore)).Sugar(), logs
+}
+
+func TestLevels_Apply(t *testing.T) {
+	levels := New(zapcore.InfoLevel)
+	log, logs := newObservedLogger(levels)
+
+	log.Debug("dropped")
+	log.Info("logged")
+	assert.Equal(t, 1, logs.Len())
+
+	assert.NoError(t, levels.Apply(Config{Level: "debug"}))
+	log.Debug("logged")
+	assert.Equal(t, 2, logs.Len())
+
+	assert.Error(t, levels.Apply(Config{Level: "verbose"}))
+	assert.Error(t, levels.Apply(Config{Level: "error", Loggers: map[string]string{"agent": "verbose"}}))
+	log.Debug("logged, the invalid configuration is not applied")
+	assert.Equal(t, 3, logs.Len())
+}
+
+func TestLevels_NamedLoggers(t *testing.T) {
+	levels := New(zapcore.InfoLevel)
+	assert.NoError(t, levels.Apply(Config{Loggers: map[string]string{"controllers": "warn", "agent": "debug"}}))
+	log, logs := newObservedLogger(levels)
+
+	log.Debug("dropped")
+	log.Named("controllers").Info("dropped")
+	log.Named("controllers").Warn("logged")
+	log.Named("controllers").Named("agent").Debug("logged, the innermost logger applies")
+	log.Named("scram").Info("logged")
+	log.Named("scram").Debug("dropped")
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"logged", "logged, the innermost logger applies", "logged"}, messages)
+}
+
+func TestDebugUntil(t *testing.T) {
+	levels := New(zapcore.WarnLevel)
+	assert.NoError(t, levels.Apply(Config{Loggers: map[string]string{"agent": "error"}}))
+	log, logs := newObservedLogger(levels)
+
+	log.Named("controllers").Debug("dropped")
+	debugLog := log.Named("controllers").With(DebugUntil(time.Now().Add(time.Hour)))
+	debugLog.Debug("logged")
+	debugLog.Named("agent").Info("logged, nested loggers keep the field")
+	log.Named("controllers").Info("dropped, other loggers are not affected")
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"logged", "logged, nested loggers keep the field"}, messages)
+}
+
+func TestLevel(t *testing.T) {
+	levels := New(zapcore.WarnLevel)
+	log, logs := newObservedLogger(levels)
+
+	log.Named("controllers").Info("dropped")
+	infoLog := log.Named("controllers").With(Level(zapcore.InfoLevel))
+	infoLog.Info("logged")
+	infoLog.Debug("dropped, below the level of the field")
+	infoLog.With(DebugUntil(time.Now().Add(time.Hour))).Debug("logged, the lowest level applies")
+	log.With(Level(zapcore.ErrorLevel)).Warn("dropped, the level of the field applies")
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"logged", "logged, the lowest level applies"}, messages)
+}
+
+func TestLevels_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log-level.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("level: warn\n"), 0600))
+
+	levels := New(zapcore.DebugLevel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go levels.Watch(ctx, path, 10*time.Millisecond, zap.NewNop().Sugar())
+
+	assert.Eventually(t, func() bool {
+		return !levels.Enabled("", zapcore.InfoLevel)
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("level: warn\nloggers:\n  agent: debug\n"), 0600))
+	assert.Eventually(t, func() bool {
+		return levels.Enabled("controllers.agent", zapcore.DebugLevel)
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, levels.Enabled("controllers", zapcore.InfoLevel))
+}
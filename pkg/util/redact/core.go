@@ -0,0 +1,80 @@
+package redact
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// core is a zapcore.Core which removes sensitive data from every entry
+// before it is passed on to the wrapped Core.
+type core struct {
+	zapcore.Core
+}
+
+// NewCore wraps the given zapcore.Core so that sensitive data never reaches it.
+// It can be used with zap.WrapCore when configuring a logger.
+func NewCore(c zapcore.Core) zapcore.Core {
+	return core{Core: c}
+}
+
+// With implements zapcore.Core
+func (c core) With(fields []zapcore.Field) zapcore.Core {
+	return core{Core: c.Core.With(Fields(fields))}
+}
+
+// Check implements zapcore.Core
+func (c core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core
+func (c core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = String(entry.Message)
+	return c.Core.Write(entry, Fields(fields))
+}
+
+// Fields returns a copy of the given fields with all sensitive data removed.
+func Fields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = field(f)
+	}
+	return redacted
+}
+
+func field(f zapcore.Field) zapcore.Field {
+	if IsSensitiveKey(f.Key) {
+		return zap.String(f.Key, Placeholder)
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, String(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, String(err.Error()))
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return zap.String(f.Key, String(stringOf(s)))
+		}
+	case zapcore.ReflectType:
+		return zap.Reflect(f.Key, Object(f.Interface))
+	}
+	return f
+}
+
+// stringOf calls String on the given Stringer, recovering from panics caused
+// by nil receivers in the same way zap does.
+func stringOf(s fmt.Stringer) (str string) {
+	defer func() {
+		if r := recover(); r != nil {
+			str = fmt.Sprintf("<PANIC=%v>", r)
+		}
+	}()
+	return s.String()
+}
@@ -0,0 +1,121 @@
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Placeholder is the value which replaces any sensitive data.
+const Placeholder = "<redacted>"
+
+// sensitiveKeys contains the (lower case) names of fields whose values must never be logged.
+// These cover the SCRAM credentials, the agent password and the keyfile contents
+// stored in the automation config as well as any password fields.
+var sensitiveKeys = []string{
+	"password",
+	"pwd",
+	"autopwd",
+	"key",
+	"keyfile",
+	"salt",
+	"serverkey",
+	"storedkey",
+	"scramsha1creds",
+	"scramsha256creds",
+	"token",
+	"certificatekey",
+	"bindquerypassword",
+}
+
+var (
+	// uriCredentialsRegex matches the user information of a MongoDB connection string.
+	uriCredentialsRegex = regexp.MustCompile(`(mongodb(?:\+srv)?://)[^@/\s"]+@`)
+
+	// jsonSensitiveFieldRegex matches sensitive fields in serialized JSON documents which
+	// end up in log messages, e.g. as part of an error.
+	jsonSensitiveFieldRegex = regexp.MustCompile(`(?i)("(?:` + strings.Join(sensitiveKeys, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// Secret is a string which is never printed. It can be used to pass sensitive
+// values around without the risk of them ending up in log output.
+type Secret string
+
+// String implements fmt.Stringer.
+func (s Secret) String() string {
+	return Placeholder
+}
+
+// GoString implements fmt.GoStringer, it is used for the %#v verb.
+func (s Secret) GoString() string {
+	return Placeholder
+}
+
+// MarshalJSON ensures that the value is not leaked when the Secret is serialized.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Placeholder)
+}
+
+// Value returns the underlying sensitive value.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// IsSensitiveKey returns true if values stored under the given key should not be logged.
+func IsSensitiveKey(key string) bool {
+	normalized := strings.ToLower(key)
+	if idx := strings.LastIndex(normalized, "."); idx != -1 {
+		normalized = normalized[idx+1:]
+	}
+	for _, k := range sensitiveKeys {
+		if normalized == k {
+			return true
+		}
+	}
+	return false
+}
+
+// String removes credentials from connection strings and sensitive fields from
+// any JSON documents contained in the given string.
+func String(s string) string {
+	s = uriCredentialsRegex.ReplaceAllString(s, "${1}"+Placeholder+"@")
+	return jsonSensitiveFieldRegex.ReplaceAllString(s, `${1}"`+Placeholder+`"`)
+}
+
+// Object returns a representation of the given object which is safe to log.
+// The object is converted to its JSON representation and the value of every
+// sensitive field is replaced by the Placeholder.
+func Object(obj interface{}) interface{} {
+	bytes, err := json.Marshal(obj)
+	if err != nil {
+		return Placeholder
+	}
+	var generic interface{}
+	if err := json.Unmarshal(bytes, &generic); err != nil {
+		return Placeholder
+	}
+	return redactValue(generic)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if IsSensitiveKey(key) && val != nil {
+				v[key] = Placeholder
+				continue
+			}
+			v[key] = redactValue(val)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+		return v
+	case string:
+		return String(v)
+	default:
+		return v
+	}
+}
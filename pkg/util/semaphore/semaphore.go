@@ -0,0 +1,69 @@
+package semaphore
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Keyed is a counting semaphore whose slots are held by resources. Acquiring a slot for a resource which
+// already holds one succeeds without taking another slot, so it can be called on every reconciliation.
+type Keyed struct {
+	mu      sync.Mutex
+	limit   int
+	holders map[types.NamespacedName]struct{}
+}
+
+// NewKeyed returns a semaphore with the given number of slots. A limit of zero or less means
+// that acquiring a slot always succeeds.
+func NewKeyed(limit int) *Keyed {
+	return &Keyed{
+		limit:   limit,
+		holders: map[types.NamespacedName]struct{}{},
+	}
+}
+
+// TryAcquire takes a slot for the given resource and returns true if the resource holds a slot afterwards.
+func (k *Keyed) TryAcquire(holder types.NamespacedName) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.holders[holder]; ok {
+		return true
+	}
+	if k.limit > 0 && len(k.holders) >= k.limit {
+		return false
+	}
+	k.holders[holder] = struct{}{}
+	return true
+}
+
+// Holds returns true if the given resource holds a slot.
+func (k *Keyed) Holds(holder types.NamespacedName) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	_, ok := k.holders[holder]
+	return ok
+}
+
+// Release frees the slot held by the given resource, if any.
+func (k *Keyed) Release(holder types.NamespacedName) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.holders, holder)
+}
+
+// InUse returns the number of slots currently held.
+func (k *Keyed) InUse() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.holders)
+}
+
+// Limited returns true if the number of slots is limited.
+func (k *Keyed) Limited() bool {
+	return k.limit > 0
+}
@@ -0,0 +1,41 @@
+package semaphore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestKeyed(t *testing.T) {
+	a := types.NamespacedName{Name: "a", Namespace: "ns"}
+	b := types.NamespacedName{Name: "b", Namespace: "ns"}
+	c := types.NamespacedName{Name: "c", Namespace: "ns"}
+
+	s := NewKeyed(2)
+	assert.True(t, s.Limited())
+	assert.True(t, s.TryAcquire(a))
+	assert.True(t, s.TryAcquire(a), "acquiring a held slot again must succeed")
+	assert.True(t, s.TryAcquire(b))
+	assert.Equal(t, 2, s.InUse())
+
+	assert.False(t, s.TryAcquire(c))
+	assert.False(t, s.Holds(c))
+
+	s.Release(a)
+	assert.False(t, s.Holds(a))
+	assert.True(t, s.TryAcquire(c))
+	assert.True(t, s.Holds(c))
+
+	s.Release(a)
+	assert.Equal(t, 2, s.InUse(), "releasing a slot which is not held must have no effect")
+}
+
+func TestKeyed_Unlimited(t *testing.T) {
+	s := NewKeyed(0)
+	assert.False(t, s.Limited())
+	for i := 0; i < 10; i++ {
+		assert.True(t, s.TryAcquire(types.NamespacedName{Name: string(rune('a' + i))}))
+	}
+	assert.Equal(t, 10, s.InUse())
+}
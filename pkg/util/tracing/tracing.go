@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	// EndpointEnv and TracesEndpointEnv are the OTLP endpoints the spans are exported to. Tracing is only
+	// enabled if one of them is set. The exporter is configured with the other OTEL_EXPORTER_OTLP_*
+	// environment variables, e.g. OTEL_EXPORTER_OTLP_HEADERS.
+	EndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	TracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+
+	serviceName = "mongodb-kubernetes-operator"
+)
+
+// Enabled returns true if an OTLP endpoint is configured.
+func Enabled() bool {
+	return os.Getenv(EndpointEnv) != "" || os.Getenv(TracesEndpointEnv) != ""
+}
+
+// Setup exports the spans of the operator over OTLP/HTTP, if an OTLP endpoint is configured. The returned
+// function flushes the spans which have not been exported yet, and must be called before the operator exits.
+// Without an endpoint, the spans are not recorded.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, errors.Errorf("could not create the OTLP exporter: %s", err)
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence over the service name of the operator.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, errors.Errorf("could not create the resource of the spans: %s", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetup(t *testing.T) {
+	defer os.Unsetenv(TracesEndpointEnv)
+
+	shutdown, err := Setup(context.TODO())
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.TODO()))
+	_, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.False(t, ok, "the spans are not recorded without an endpoint")
+
+	os.Setenv(TracesEndpointEnv, "http://otel-collector.monitoring.svc:4318/v1/traces")
+	shutdown, err = Setup(context.TODO())
+	assert.NoError(t, err)
+	_, ok = otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.True(t, ok)
+	assert.NoError(t, shutdown(context.TODO()))
+}
@@ -53,15 +53,25 @@ func TestCrossNamespaceDeploy(t *testing.T) {
 			Resources: []string{"pods", "services", "services/finalizers", "endpoints", "persistentvolumeclaims", "events", "configmaps", "secrets"},
 			Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
 		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/status"},
+			Verbs:     []string{"update"},
+		},
 		{
 			APIGroups: []string{"apps"},
 			Resources: []string{"deployments", "daemonsets", "replicasets", "statefulsets"},
 			Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
 		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs", "cronjobs"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
 		{
 			APIGroups: []string{"monitoring.coreos.com"},
-			Resources: []string{"servicemonitors"},
-			Verbs:     []string{"get", "create"},
+			Resources: []string{"servicemonitors", "podmonitors", "prometheusrules"},
+			Verbs:     []string{"get", "create", "update", "delete"},
 		},
 		{
 			APIGroups:     []string{"apps"},
@@ -104,15 +114,25 @@ func TestCrossNamespaceDeploy(t *testing.T) {
 			Resources: []string{"pods", "services", "services/finalizers", "endpoints", "persistentvolumeclaims", "events", "configmaps", "secrets"},
 			Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
 		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/status"},
+			Verbs:     []string{"update"},
+		},
 		{
 			APIGroups: []string{"apps"},
 			Resources: []string{"deployments", "daemonsets", "replicasets", "statefulsets"},
 			Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
 		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs", "cronjobs"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
 		{
 			APIGroups: []string{"monitoring.coreos.com"},
-			Resources: []string{"servicemonitors"},
-			Verbs:     []string{"get", "create"},
+			Resources: []string{"servicemonitors", "podmonitors", "prometheusrules"},
+			Verbs:     []string{"get", "create", "update", "delete"},
 		},
 		{
 			APIGroups:     []string{"apps"},